@@ -0,0 +1,97 @@
+// Command fit-fusion fits the multimodal fusion projection offline from training
+// pairs and writes it as the next versioned artifact.
+//
+// Pairs are NDJSON, one {"a": {...}, "b": {...}} per line, where each side holds a
+// "text" and/or "image" embedding. Use co-interacted items, or the text and image
+// of the same item, as positive pairs.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"log"
+	"math/rand"
+	"os"
+
+	"github.com/temcen/pirex/internal/ml"
+)
+
+func main() {
+	pairsPath := flag.String("pairs", "", "NDJSON file of training pairs")
+	outDir := flag.String("out", "./models/fusion", "directory for versioned projection artifacts")
+	textDims := flag.Int("text-dims", 384, "text embedding dimensions")
+	imageDims := flag.Int("image-dims", 512, "image embedding dimensions")
+	finalDims := flag.Int("final-dims", 768, "fused embedding dimensions")
+	ridge := flag.Float64("ridge", 1e-3, "within-pair covariance regularization")
+	minPairs := flag.Int("min-pairs", 1000, "minimum number of training pairs")
+	holdout := flag.Float64("holdout", 0.1, "fraction of pairs held out for evaluation")
+	dryRun := flag.Bool("dry-run", false, "fit and evaluate without writing an artifact")
+	flag.Parse()
+
+	if *pairsPath == "" {
+		log.Fatal("-pairs is required")
+	}
+
+	pairs, err := readPairs(*pairsPath)
+	if err != nil {
+		log.Fatalf("Failed to read training pairs: %v", err)
+	}
+
+	rand.New(rand.NewSource(1)).Shuffle(len(pairs), func(i, j int) { pairs[i], pairs[j] = pairs[j], pairs[i] })
+	split := len(pairs) - int(float64(len(pairs))**holdout)
+	training, evaluation := pairs[:split], pairs[split:]
+
+	artifact, err := ml.FitProjection(training, ml.FitOptions{
+		TextDimensions:  *textDims,
+		ImageDimensions: *imageDims,
+		FinalDimensions: *finalDims,
+		Ridge:           *ridge,
+		MinPairs:        *minPairs,
+	})
+	if err != nil {
+		log.Fatalf("Failed to fit projection: %v", err)
+	}
+
+	log.Printf("Training: %d pairs, pair cosine %.3f, shuffled cosine %.3f",
+		artifact.Report.Pairs, artifact.Report.PairCosine, artifact.Report.ShuffledCosine)
+	if len(evaluation) > 0 {
+		report := ml.EvaluateProjection(artifact, evaluation)
+		log.Printf("Holdout: %d pairs, pair cosine %.3f, shuffled cosine %.3f",
+			report.Pairs, report.PairCosine, report.ShuffledCosine)
+		artifact.Report = report
+	}
+
+	if *dryRun {
+		return
+	}
+
+	path, err := ml.SaveProjectionArtifact(*outDir, artifact)
+	if err != nil {
+		log.Fatalf("Failed to save projection artifact: %v", err)
+	}
+	log.Printf("Wrote projection v%d to %s", artifact.Version, path)
+}
+
+func readPairs(path string) ([]ml.FusionTrainingPair, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var pairs []ml.FusionTrainingPair
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var pair ml.FusionTrainingPair
+		if err := json.Unmarshal(scanner.Bytes(), &pair); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, scanner.Err()
+}
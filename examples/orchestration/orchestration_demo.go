@@ -17,7 +17,7 @@ import (
 type MockAlgorithmService struct{}
 
 func (m *MockAlgorithmService) SemanticSearchRecommendations(
-	ctx context.Context, userID uuid.UUID, userEmbedding []float32, contentTypes []string, categories []string, limit int,
+	ctx context.Context, userID uuid.UUID, interests []models.InterestVector, contentTypes []string, categories []string, limit int,
 ) ([]models.ScoredItem, error) {
 	return []models.ScoredItem{
 		{ItemID: uuid.New(), Score: 0.92, Algorithm: "semantic_search", Confidence: 0.85},
@@ -109,6 +109,9 @@ func (m *MockUserService) GetUserInteractions(ctx context.Context, userID uuid.U
 func (m *MockUserService) GetSimilarUsers(ctx context.Context, userID uuid.UUID, limit int) ([]models.SimilarUser, error) {
 	return nil, nil
 }
+func (m *MockUserService) UpdateExplicitPreferences(ctx context.Context, userID uuid.UUID, req *models.ProfileUpdateRequest) (*models.UserProfile, error) {
+	return nil, nil
+}
 func (m *MockUserService) Stop() {}
 
 func main() {
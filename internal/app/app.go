@@ -49,6 +49,25 @@ func New(cfg *config.Config) (*App, error) {
 	}
 	app.services = services
 
+	// Bring the graph schema up to date before anything writes to it
+	if err := services.Graph.Migrate(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to migrate graph schema: %w", err)
+	}
+
+	// Load the category taxonomy and keyword frequencies, then start job
+	// webhooks, scheduled catalog feed polling, the graph outbox relay, graph
+	// reconciliation, job cleanup and fusion weight reloading
+	services.Taxonomy.Start(context.Background())
+	services.KeywordIndex.Start(context.Background())
+	services.Webhooks.Start(context.Background())
+	services.CatalogSync.Start(context.Background())
+	services.GraphOutbox.Start(context.Background())
+	services.GraphReconciliation.Start(context.Background())
+	services.JobManager.StartCleanup(context.Background())
+	if services.FusionWeights != nil {
+		services.FusionWeights.Start(context.Background())
+	}
+
 	// Initialize handlers
 	app.handlers = handlers.New(app.logger, services)
 
@@ -69,6 +88,24 @@ func (a *App) Router() *gin.Engine {
 func (a *App) Shutdown(ctx context.Context) error {
 	a.logger.Info("Shutting down application...")
 
+	a.services.CatalogSync.Stop()
+	a.services.GraphReconciliation.Stop()
+	a.services.UserData.Stop()
+	a.services.GraphOutbox.Stop()
+	a.services.Taxonomy.Stop()
+	a.services.KeywordIndex.Stop()
+	a.services.JobManager.Stop()
+	a.services.Webhooks.Stop()
+	if a.services.TextEmbedding != nil {
+		a.services.TextEmbedding.Stop()
+	}
+	if a.services.FusionWeights != nil {
+		a.services.FusionWeights.Stop()
+	}
+	if a.services.ContentEmbedding != nil {
+		a.services.ContentEmbedding.Stop()
+	}
+
 	if err := a.db.Close(); err != nil {
 		a.logger.WithError(err).Error("Error closing database connections")
 		return err
@@ -142,7 +179,12 @@ func (a *App) setupRouter() {
 		{
 			content.POST("", a.handlers.Content.Create)
 			content.POST("/batch", a.handlers.Content.CreateBatch)
+			content.POST("/imports", a.handlers.Content.Import)
+			content.GET("/jobs", a.handlers.Content.ListJobs)
 			content.GET("/jobs/:jobId", a.handlers.Content.GetJobStatus)
+			content.POST("/jobs/:jobId/cancel", a.handlers.Content.CancelJob)
+			content.POST("/jobs/:jobId/retry-failed", a.handlers.Content.RetryFailedItems)
+			content.GET("/jobs/:jobId/errors", a.handlers.Content.GetJobErrors)
 		}
 
 		// Interaction routes
@@ -161,13 +203,27 @@ func (a *App) setupRouter() {
 			recommendations.GET("/:userId/similar/:itemId", a.handlers.Recommendation.GetSimilar)
 		}
 
+		// Search routes
+		api.GET("/search", a.handlers.Search.Search)
+
 		// Feedback routes
 		api.POST("/feedback", a.handlers.Recommendation.RecordFeedback)
 
+		// Onboarding routes
+		api.GET("/onboarding/items", a.handlers.Onboarding.GetItems)
+
 		// User routes
 		users := api.Group("/users")
 		{
+			users.DELETE("/:userId", a.handlers.User.DeleteUser)
+			users.GET("/:userId/export", a.handlers.User.ExportData)
 			users.GET("/:userId/interactions", a.handlers.User.GetInteractions)
+			users.POST("/:userId/onboarding", a.handlers.Onboarding.Submit)
+			users.GET("/:userId/profile", a.handlers.User.GetProfile)
+			users.PATCH("/:userId/profile", a.handlers.User.UpdateProfile)
+			users.GET("/:userId/similar", a.handlers.User.GetSimilarUsers)
+			users.GET("/:userId/suppressions", a.handlers.User.GetSuppressions)
+			users.DELETE("/:userId/suppressions/:suppressionId", a.handlers.User.DeleteSuppression)
 		}
 
 		// Metrics routes
@@ -197,6 +253,16 @@ func (a *App) setupRouter() {
 			// System configuration
 			admin.GET("/system/config", a.handlers.Admin.GetSystemConfiguration)
 			admin.PUT("/system/config", a.handlers.Admin.UpdateSystemConfiguration)
+
+			// Category taxonomy; the handlers require the admin role for changes
+			admin.GET("/taxonomy/categories", a.handlers.Taxonomy.ListCategories)
+			admin.POST("/taxonomy/categories", a.handlers.Taxonomy.CreateCategory)
+			admin.GET("/taxonomy/categories/:categoryId", a.handlers.Taxonomy.GetCategory)
+			admin.PUT("/taxonomy/categories/:categoryId", a.handlers.Taxonomy.UpdateCategory)
+			admin.DELETE("/taxonomy/categories/:categoryId", a.handlers.Taxonomy.DeleteCategory)
+
+			// Graph maintenance; the handler requires the admin role
+			admin.POST("/graph/reconcile", a.handlers.Graph.Reconcile)
 		}
 	}
 
@@ -19,6 +19,7 @@ type Config struct {
 	Models     ModelConfig      `mapstructure:"models"`
 	Monitoring MonitoringConfig `mapstructure:"monitoring"`
 	Security   SecurityConfig   `mapstructure:"security"`
+	Ingestion  IngestionConfig  `mapstructure:"ingestion"`
 }
 
 type ServerConfig struct {
@@ -48,9 +49,31 @@ type RedisInstanceConfig struct {
 }
 
 type Neo4jConfig struct {
-	URL      string `mapstructure:"url"`
-	Username string `mapstructure:"username"`
-	Password string `mapstructure:"password"`
+	URL            string                    `mapstructure:"url"`
+	Username       string                    `mapstructure:"username"`
+	Password       string                    `mapstructure:"password"`
+	Outbox         GraphOutboxConfig         `mapstructure:"outbox"`
+	Reconciliation GraphReconciliationConfig `mapstructure:"reconciliation"`
+}
+
+// GraphOutboxConfig controls the relay writing queued interaction relationships
+// from the Postgres outbox to Neo4j
+type GraphOutboxConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"` // Also woken by new interactions
+	BatchSize    int           `mapstructure:"batch_size"`    // Rows written per Neo4j transaction
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // Rows are kept as failed after this many
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"` // Doubled on every failed attempt
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`
+}
+
+// GraphReconciliationConfig controls the job comparing the graph with Postgres.
+// Admin-triggered runs work whether or not the schedule is enabled.
+type GraphReconciliationConfig struct {
+	Enabled    bool          `mapstructure:"enabled"` // Run on Interval
+	Interval   time.Duration `mapstructure:"interval"`
+	Repair     bool          `mapstructure:"repair"`      // Scheduled runs repair what they find
+	ChunkSize  int           `mapstructure:"chunk_size"`  // Users or items compared per round trip
+	MaxSamples int           `mapstructure:"max_samples"` // Issues listed individually in the report
 }
 
 type KafkaConfig struct {
@@ -62,9 +85,10 @@ type KafkaConfig struct {
 }
 
 type AuthConfig struct {
-	JWTSecret string          `mapstructure:"jwt_secret"`
-	TokenTTL  time.Duration   `mapstructure:"token_ttl"`
-	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	JWTSecret    string          `mapstructure:"jwt_secret"`
+	TokenTTL     time.Duration   `mapstructure:"token_ttl"`
+	AdminAPIKeys []string        `mapstructure:"admin_api_keys"` // API keys allowed to act on any user's data
+	RateLimit    RateLimitConfig `mapstructure:"rate_limit"`
 }
 
 type RateLimitConfig struct {
@@ -79,11 +103,20 @@ type LoggingConfig struct {
 }
 
 type AlgorithmConfig struct {
-	SemanticSearch      AlgorithmWeightConfig `mapstructure:"semantic_search"`
-	CollaborativeFilter AlgorithmWeightConfig `mapstructure:"collaborative_filtering"`
-	PageRank            AlgorithmWeightConfig `mapstructure:"pagerank"`
-	Diversity           DiversityConfig       `mapstructure:"diversity"`
-	Caching             CachingConfig         `mapstructure:"caching"`
+	SemanticSearch      AlgorithmWeightConfig     `mapstructure:"semantic_search"`
+	CollaborativeFilter AlgorithmWeightConfig     `mapstructure:"collaborative_filtering"`
+	PageRank            AlgorithmWeightConfig     `mapstructure:"pagerank"`
+	Lexical             AlgorithmWeightConfig     `mapstructure:"lexical"` // Keyword overlap with the user's liked items or the seed item
+	Diversity           DiversityConfig           `mapstructure:"diversity"`
+	Locale              LocaleConfig              `mapstructure:"locale"`
+	Search              SearchConfig              `mapstructure:"search"`
+	Session             SessionConfig             `mapstructure:"session"`
+	CoVisitation        CoVisitationConfig        `mapstructure:"co_visitation"`
+	Interests           InterestConfig            `mapstructure:"interests"`
+	NegativeFeedback    NegativeFeedbackConfig    `mapstructure:"negative_feedback"`
+	ExplicitPreferences ExplicitPreferencesConfig `mapstructure:"explicit_preferences"`
+	Onboarding          OnboardingConfig          `mapstructure:"onboarding"`
+	Caching             CachingConfig             `mapstructure:"caching"`
 }
 
 type AlgorithmWeightConfig struct {
@@ -99,6 +132,116 @@ type DiversityConfig struct {
 	MaxSimilarityThreshold float64 `mapstructure:"max_similarity_threshold"`
 	TemporalDecayFactor    float64 `mapstructure:"temporal_decay_factor"`
 	MaxRecentSimilarItems  int     `mapstructure:"max_recent_similar_items"`
+
+	// VisualDuplicateDistance is the largest perceptual hash distance at which two
+	// items count as the same picture; only one of them is shown. Negative disables.
+	VisualDuplicateDistance int `mapstructure:"visual_duplicate_distance"`
+}
+
+// LocaleConfig controls market availability filtering and language matching
+type LocaleConfig struct {
+	Enabled       bool    `mapstructure:"enabled"`
+	LanguageBoost float64 `mapstructure:"language_boost"` // Relative score boost for items in the user's language
+	// FilterLanguage drops items in other languages instead of only boosting
+	// matches. Items of unknown language are always kept.
+	FilterLanguage bool   `mapstructure:"filter_language"`
+	DefaultMarket  string `mapstructure:"default_market"` // Used when the request names no market
+}
+
+// SearchConfig controls the hybrid search endpoint, which fuses full-text and
+// vector retrieval with reciprocal rank fusion
+type SearchConfig struct {
+	// VectorSearch embeds queries with the text embedding model. Creating the
+	// model starts the Python bridge; queries are embedded only when the model's
+	// dimensions match the content embeddings.
+	VectorSearch          bool          `mapstructure:"vector_search"`
+	EmbeddingModel        string        `mapstructure:"embedding_model"`
+	EmbeddingTimeout      time.Duration `mapstructure:"embedding_timeout"`      // Search continues full-text only after this
+	CandidateLimit        int           `mapstructure:"candidate_limit"`        // Results taken from each retriever before fusion
+	RRFK                  int           `mapstructure:"rrf_k"`                  // Reciprocal rank fusion constant
+	PersonalizationWeight float64       `mapstructure:"personalization_weight"` // Fusion weight of the user's preference ranking
+	MaxQueryLength        int           `mapstructure:"max_query_length"`       // In characters
+}
+
+// SessionConfig controls short-term intent: the items, categories and queries
+// of the user's current session are kept in Redis, generate item-to-item
+// candidates and are blended into the long-term preference vector
+type SessionConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	Weight           float64       `mapstructure:"weight"`             // Fusion weight of the session candidate generator
+	ShortTermWeight  float64       `mapstructure:"short_term_weight"`  // Share of the session vector in the blended preference vector
+	IdleTimeout      time.Duration `mapstructure:"idle_timeout"`       // Session state expires after this long without interactions
+	MaxItems         int           `mapstructure:"max_items"`          // Recent items kept per session
+	MaxQueries       int           `mapstructure:"max_queries"`        // Recent search queries kept per session
+	SeedItems        int           `mapstructure:"seed_items"`         // Most recent items candidates are generated from
+	NeighborsPerSeed int           `mapstructure:"neighbors_per_seed"` // Similar items fetched per seed item
+	RecencyDecay     float64       `mapstructure:"recency_decay"`      // Weight kept per step back in the session
+}
+
+// CoVisitationConfig controls the item-to-item matrices counted from sessions:
+// items interacted with close together in a session, and items converted on
+// together ("frequently bought together")
+type CoVisitationConfig struct {
+	Enabled              bool          `mapstructure:"enabled"`
+	Weight               float64       `mapstructure:"weight"`                 // Fusion weight of the co-visitation candidate generator
+	Window               time.Duration `mapstructure:"window"`                 // Items pair up when interacted with this close together in a session
+	ConversionWindow     time.Duration `mapstructure:"conversion_window"`      // Same for conversions
+	ConversionTypes      []string      `mapstructure:"conversion_types"`       // Interaction types counted as conversions
+	MaxNeighbors         int           `mapstructure:"max_neighbors"`          // Neighbours kept per item
+	Retention            time.Duration `mapstructure:"retention"`              // A neighbour list expires after this long without updates
+	BoughtTogetherWeight float64       `mapstructure:"bought_together_weight"` // Share of bought-together counts in candidate scores
+	SeedItems            int           `mapstructure:"seed_items"`             // Recent items used as seeds outside a session
+}
+
+// InterestConfig controls the interest vectors of user profiles: clusters of
+// the items a user interacted with, so users with several distinct interests
+// are not reduced to a single blurred centroid
+type InterestConfig struct {
+	MaxInterests  int     `mapstructure:"max_interests"`  // 1 keeps a single preference vector
+	MinItems      int     `mapstructure:"min_items"`      // Interacted items needed per interest
+	MinShare      float64 `mapstructure:"min_share"`      // Interests with less of the user's weight are merged away
+	MaxSimilarity float64 `mapstructure:"max_similarity"` // Interests with more similar centroids are merged
+	Iterations    int     `mapstructure:"iterations"`     // k-means iterations per profile update
+}
+
+// NegativeFeedbackConfig controls how dislikes and negative recommendation
+// feedback push rankings away from what the user rejected: a penalty for items
+// similar to the profile's negative vector, decaying penalties for suppressed
+// categories and brands, and hard suppression of items the user never wants
+// to see again
+type NegativeFeedbackConfig struct {
+	Enabled             bool          `mapstructure:"enabled"`
+	VectorPenalty       float64       `mapstructure:"vector_penalty"`       // Relative score penalty for items identical to the negative vector
+	SimilarityThreshold float64       `mapstructure:"similarity_threshold"` // Items less similar to the negative vector are not penalized
+	SuppressionPenalty  float64       `mapstructure:"suppression_penalty"`  // Relative score penalty for a full strength category or brand suppression
+	HalfLife            time.Duration `mapstructure:"half_life"`            // Category and brand suppressions halve in strength after this long
+	MinStrength         float64       `mapstructure:"min_strength"`         // Weaker suppressions are ignored and eventually deleted
+	HardFeedbackTypes   []string      `mapstructure:"hard_feedback_types"`  // Feedback types that hide the item itself for good
+}
+
+// ExplicitPreferencesConfig controls how the preferences users state in their
+// profile shape rankings: items in blocked categories or of content types
+// weighted 0 are dropped, favourite categories and content type weights scale
+// scores, and new users get candidates from their favourite categories
+type ExplicitPreferencesConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	FavoriteBoost    float64       `mapstructure:"favorite_boost"`    // Relative score boost for items in a favourite category
+	Weight           float64       `mapstructure:"weight"`            // Fusion weight of the favourite category generator for new users
+	PopularityWindow time.Duration `mapstructure:"popularity_window"` // Interactions counted when ranking favourite category items
+}
+
+// OnboardingConfig controls the cold-start questionnaire: a set of items
+// covering the catalogue's categories and embedding space that new users pick
+// from, and the profile synthesized from their picks
+type OnboardingConfig struct {
+	Enabled               bool          `mapstructure:"enabled"`
+	ItemCount             int           `mapstructure:"item_count"`              // Items offered when the request names no count
+	CandidatesPerCategory int           `mapstructure:"candidates_per_category"` // Most popular items considered per primary category
+	PoolSize              int           `mapstructure:"pool_size"`               // Candidates considered in total
+	PopularityWindow      time.Duration `mapstructure:"popularity_window"`       // Interactions counted when ranking candidates
+	CacheTTL              time.Duration `mapstructure:"cache_ttl"`               // Offered items are computed once for all users per TTL
+	SelectionWeight       float64       `mapstructure:"selection_weight"`        // Weight of a pick in the preference vector; a like weighs 0.8
+	MaxFavorites          int           `mapstructure:"max_favorites"`           // Strongest categories of the picks added to the favourites
 }
 
 type CachingConfig struct {
@@ -106,11 +249,21 @@ type CachingConfig struct {
 	RecommendationsTTL time.Duration `mapstructure:"recommendations_ttl"`
 	MetadataTTL        time.Duration `mapstructure:"metadata_ttl"`
 	GraphResultsTTL    time.Duration `mapstructure:"graph_results_ttl"`
+	EmbeddingEncoding  string        `mapstructure:"embedding_encoding"` // float32, float16 or int8
 }
 
 type ModelConfig struct {
 	TextEmbedding  ModelInstanceConfig `mapstructure:"text_embedding"`
 	ImageEmbedding ModelInstanceConfig `mapstructure:"image_embedding"`
+	Fusion         FusionConfig        `mapstructure:"fusion"`
+}
+
+type FusionConfig struct {
+	Enabled            bool              `mapstructure:"enabled"`              // Embed ingested items with the fusion service instead of a placeholder vector
+	Method             string            `mapstructure:"method"`               // Default fusion method
+	ContentTypeMethods map[string]string `mapstructure:"content_type_methods"` // Content type -> fusion method
+	WeightsPath        string            `mapstructure:"weights_path"`         // Projection artifact file, or directory written by fit-fusion
+	ReloadInterval     time.Duration     `mapstructure:"reload_interval"`      // How often to look for a newer artifact version; 0 disables
 }
 
 type ModelInstanceConfig struct {
@@ -124,8 +277,158 @@ type MonitoringConfig struct {
 	MetricsPath string `mapstructure:"metrics_path"`
 }
 
+type IngestionConfig struct {
+	IdempotencyTTL time.Duration     `mapstructure:"idempotency_ttl"`
+	Dedup          DedupConfig       `mapstructure:"dedup"`
+	BulkImport     BulkImportConfig  `mapstructure:"bulk_import"`
+	CatalogSync    CatalogSyncConfig `mapstructure:"catalog_sync"`
+	JobCleanup     JobCleanupConfig  `mapstructure:"job_cleanup"`
+	Webhooks       WebhookConfig     `mapstructure:"webhooks"`
+	Images         ImageConfig       `mapstructure:"images"`
+	Taxonomy       TaxonomyConfig    `mapstructure:"taxonomy"`
+	ContentTypes   ContentTypeConfig `mapstructure:"content_types"`
+	Keywords       KeywordConfig     `mapstructure:"keywords"`
+
+	// MaxItemOutcomes caps the per-item results kept for a job
+	MaxItemOutcomes int `mapstructure:"max_item_outcomes"`
+}
+
+// WebhookConfig controls job completion callbacks. Jobs can also name their own
+// callback URL with the X-Callback-URL request header.
+type WebhookConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	URLs            []string      `mapstructure:"urls"`   // Receive events for every job
+	Secret          string        `mapstructure:"secret"` // HMAC-SHA256 signing key; required when enabled
+	Timeout         time.Duration `mapstructure:"timeout"`
+	MaxAttempts     int           `mapstructure:"max_attempts"`
+	InitialBackoff  time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff      time.Duration `mapstructure:"max_backoff"`
+	Workers         int           `mapstructure:"workers"`
+	MaxPayloadItems int           `mapstructure:"max_payload_items"` // Item outcomes included in the payload
+
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"` // Development only
+}
+
+// KeywordConfig controls keyword weighting against the catalog-wide document
+// frequency model
+type KeywordConfig struct {
+	Scoring        string        `mapstructure:"scoring"` // bm25 or tfidf
+	K1             float64       `mapstructure:"k1"`      // BM25 term frequency saturation
+	B              float64       `mapstructure:"b"`       // BM25 length normalization
+	TitleBoost     float64       `mapstructure:"title_boost"`
+	MaxTerms       int           `mapstructure:"max_terms"`       // Distinct terms indexed per item; the most frequent are kept
+	ReloadInterval time.Duration `mapstructure:"reload_interval"` // Pick up frequencies written by other instances
+}
+
+// ContentTypeConfig points to JSON Schema documents describing content types and
+// their metadata. Each <type>.json file adds a type or replaces a built-in one.
+type ContentTypeConfig struct {
+	SchemaDir string `mapstructure:"schema_dir"`
+}
+
+// TaxonomyConfig controls the category taxonomy kept in the categories table
+type TaxonomyConfig struct {
+	ExpandParents  bool          `mapstructure:"expand_parents"`  // Add ancestor categories to ingested content
+	ReloadInterval time.Duration `mapstructure:"reload_interval"` // Pick up changes made by other instances
+	SyncGraph      bool          `mapstructure:"sync_graph"`      // Mirror categories as Neo4j Category nodes
+}
+
+// ImageConfig controls how content images are downloaded and kept
+type ImageConfig struct {
+	Fetch ImageFetchConfig `mapstructure:"fetch"`
+	Store ImageStoreConfig `mapstructure:"store"`
+}
+
+// ImageFetchConfig limits outbound image requests. Private, loopback, link-local
+// and cloud metadata addresses are refused unless AllowPrivateNetworks is set.
+type ImageFetchConfig struct {
+	Timeout              time.Duration `mapstructure:"timeout"`
+	MaxBytes             int64         `mapstructure:"max_bytes"`
+	MaxPixels            int64         `mapstructure:"max_pixels"` // Width x height an image may declare before it is decoded
+	MaxRedirects         int           `mapstructure:"max_redirects"`
+	PerHostConcurrency   int           `mapstructure:"per_host_concurrency"`
+	AllowPrivateNetworks bool          `mapstructure:"allow_private_networks"` // Development only
+	UserAgent            string        `mapstructure:"user_agent"`
+}
+
+// ImageStoreConfig keeps fetched images, their perceptual hash and a thumbnail so
+// re-embedding reads local bytes instead of downloading again
+type ImageStoreConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Backend       string `mapstructure:"backend"` // local
+	LocalPath     string `mapstructure:"local_path"`
+	ThumbnailSize int    `mapstructure:"thumbnail_size"` // Longest side in pixels
+}
+
+// JobCleanupConfig schedules removal of finished jobs from Redis
+type JobCleanupConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	Interval  time.Duration `mapstructure:"interval"`
+	Retention time.Duration `mapstructure:"retention"` // finished jobs older than this are removed
+}
+
+type DedupConfig struct {
+	Enabled             bool    `mapstructure:"enabled"`
+	Policy              string  `mapstructure:"policy"`               // flag, merge
+	MaxHammingDistance  int     `mapstructure:"max_hamming_distance"` // The fingerprint index finds every match up to 3
+	EmbeddingSimilarity float64 `mapstructure:"embedding_similarity"`
+	CandidateLimit      int     `mapstructure:"candidate_limit"` // Closest fingerprint matches compared by embedding
+
+	// ImageHashing downloads images during preprocessing to compute pHash/dHash;
+	// items whose images are within MaxImageDistance bits are reported as visual
+	// duplicates. The hash index finds every match up to a distance of 3.
+	ImageHashing     bool `mapstructure:"image_hashing"`
+	MaxImageDistance int  `mapstructure:"max_image_distance"`
+}
+
+type BulkImportConfig struct {
+	SpoolDir           string            `mapstructure:"spool_dir"`              // Uploaded files are buffered here before import
+	LocalRoot          string            `mapstructure:"local_root"`             // Local path sources must live under this directory; empty disables them
+	AllowURLSources    bool              `mapstructure:"allow_url_sources"`      // URL sources may only reach public addresses
+	AllowPrivateURLs   bool              `mapstructure:"allow_private_networks"` // Development only
+	MaxUploadSize      int64             `mapstructure:"max_upload_size"`
+	MaxLineBytes       int               `mapstructure:"max_line_bytes"`
+	PublishBatchSize   int               `mapstructure:"publish_batch_size"`
+	PublishWorkers     int               `mapstructure:"publish_workers"`
+	MaxInFlight        int               `mapstructure:"max_in_flight"` // Queued but unprocessed items before reading pauses
+	MaxErrorReports    int               `mapstructure:"max_error_reports"`
+	ErrorReportTTL     time.Duration     `mapstructure:"error_report_ttl"`
+	FetchTimeout       time.Duration     `mapstructure:"fetch_timeout"`
+	DefaultContentType string            `mapstructure:"default_content_type"`
+	ListSeparator      string            `mapstructure:"list_separator"`
+	Mapping            map[string]string `mapstructure:"mapping"` // ContentIngestionRequest field -> source column
+}
+
+type CatalogSyncConfig struct {
+	Enabled              bool                `mapstructure:"enabled"`
+	DefaultInterval      time.Duration       `mapstructure:"default_interval"`
+	MaxDeactivationRatio float64             `mapstructure:"max_deactivation_ratio"` // Skip deactivations above this share of known items
+	Feeds                []CatalogFeedConfig `mapstructure:"feeds"`
+}
+
+type CatalogFeedConfig struct {
+	Name        string            `mapstructure:"name"`
+	Format      string            `mapstructure:"format"` // json, csv, rss, atom, google_shopping
+	URL         string            `mapstructure:"url"`
+	Path        string            `mapstructure:"path"` // Local file instead of URL
+	Interval    time.Duration     `mapstructure:"interval"`
+	Timeout     time.Duration     `mapstructure:"timeout"`
+	Headers     map[string]string `mapstructure:"headers"`
+	ItemsKey    string            `mapstructure:"items_key"` // JSON feeds: key holding the item array
+	Delimiter   string            `mapstructure:"delimiter"` // CSV feeds
+	DefaultType string            `mapstructure:"default_type"`
+	Mapping     map[string]string `mapstructure:"mapping"`
+}
+
 type SecurityConfig struct {
-	CORS CORSConfig `mapstructure:"cors"`
+	CORS    CORSConfig    `mapstructure:"cors"`
+	Privacy PrivacyConfig `mapstructure:"privacy"`
+}
+
+// PrivacyConfig controls user data export and deletion
+type PrivacyConfig struct {
+	PublishDeletions bool  `mapstructure:"publish_deletions"` // Announce deleted users on the user-deletions topic
+	ScanCount        int64 `mapstructure:"scan_count"`        // Redis keys requested per SCAN when purging a user's keys
 }
 
 type CORSConfig struct {
@@ -204,6 +507,9 @@ func setDefaults() {
 	viper.SetDefault("recommendation.pagerank.enabled", true)
 	viper.SetDefault("recommendation.pagerank.weight", 0.3)
 	viper.SetDefault("recommendation.pagerank.similarity_threshold", 0.0)
+	viper.SetDefault("recommendation.lexical.enabled", true)
+	viper.SetDefault("recommendation.lexical.weight", 0.2)
+	viper.SetDefault("recommendation.lexical.similarity_threshold", 0.0)
 
 	// Diversity defaults
 	viper.SetDefault("recommendation.diversity.intra_list_diversity", 0.3)
@@ -212,9 +518,80 @@ func setDefaults() {
 	viper.SetDefault("recommendation.diversity.max_similarity_threshold", 0.8)
 	viper.SetDefault("recommendation.diversity.temporal_decay_factor", 7.0)
 	viper.SetDefault("recommendation.diversity.max_recent_similar_items", 2)
+	viper.SetDefault("recommendation.diversity.visual_duplicate_distance", 3)
+
+	// Locale defaults
+	viper.SetDefault("recommendation.locale.enabled", true)
+	viper.SetDefault("recommendation.locale.language_boost", 0.15)
+	viper.SetDefault("recommendation.locale.filter_language", false)
+	viper.SetDefault("recommendation.locale.default_market", "")
+
+	// Search defaults
+	viper.SetDefault("recommendation.search.vector_search", false)
+	viper.SetDefault("recommendation.search.embedding_model", "all-MiniLM-L6-v2")
+	viper.SetDefault("recommendation.search.embedding_timeout", "500ms")
+	viper.SetDefault("recommendation.search.candidate_limit", 100)
+	viper.SetDefault("recommendation.search.rrf_k", 60)
+	viper.SetDefault("recommendation.search.personalization_weight", 0.5)
+	viper.SetDefault("recommendation.search.max_query_length", 256)
+
+	// Session defaults
+	viper.SetDefault("recommendation.session.enabled", true)
+	viper.SetDefault("recommendation.session.weight", 0.3)
+	viper.SetDefault("recommendation.session.short_term_weight", 0.5)
+	viper.SetDefault("recommendation.session.idle_timeout", "30m")
+	viper.SetDefault("recommendation.session.max_items", 50)
+	viper.SetDefault("recommendation.session.max_queries", 10)
+	viper.SetDefault("recommendation.session.seed_items", 5)
+	viper.SetDefault("recommendation.session.neighbors_per_seed", 20)
+	viper.SetDefault("recommendation.session.recency_decay", 0.7)
+
+	// Co-visitation defaults
+	viper.SetDefault("recommendation.co_visitation.enabled", true)
+	viper.SetDefault("recommendation.co_visitation.weight", 0.25)
+	viper.SetDefault("recommendation.co_visitation.window", "1h")
+	viper.SetDefault("recommendation.co_visitation.conversion_window", "24h")
+	viper.SetDefault("recommendation.co_visitation.conversion_types", []string{"purchase"})
+	viper.SetDefault("recommendation.co_visitation.max_neighbors", 50)
+	viper.SetDefault("recommendation.co_visitation.retention", "720h")
+	viper.SetDefault("recommendation.co_visitation.bought_together_weight", 0.5)
+	viper.SetDefault("recommendation.co_visitation.seed_items", 5)
+
+	// Interest defaults
+	viper.SetDefault("recommendation.interests.max_interests", 4)
+	viper.SetDefault("recommendation.interests.min_items", 5)
+	viper.SetDefault("recommendation.interests.min_share", 0.1)
+	viper.SetDefault("recommendation.interests.max_similarity", 0.9)
+	viper.SetDefault("recommendation.interests.iterations", 10)
+
+	// Negative feedback defaults
+	viper.SetDefault("recommendation.negative_feedback.enabled", true)
+	viper.SetDefault("recommendation.negative_feedback.vector_penalty", 0.5)
+	viper.SetDefault("recommendation.negative_feedback.similarity_threshold", 0.6)
+	viper.SetDefault("recommendation.negative_feedback.suppression_penalty", 0.5)
+	viper.SetDefault("recommendation.negative_feedback.half_life", "720h")
+	viper.SetDefault("recommendation.negative_feedback.min_strength", 0.05)
+	viper.SetDefault("recommendation.negative_feedback.hard_feedback_types", []string{"not_interested", "inappropriate"})
+
+	// Explicit preference defaults
+	viper.SetDefault("recommendation.explicit_preferences.enabled", true)
+	viper.SetDefault("recommendation.explicit_preferences.favorite_boost", 0.25)
+	viper.SetDefault("recommendation.explicit_preferences.weight", 0.5)
+	viper.SetDefault("recommendation.explicit_preferences.popularity_window", "720h")
+
+	// Onboarding defaults
+	viper.SetDefault("recommendation.onboarding.enabled", true)
+	viper.SetDefault("recommendation.onboarding.item_count", 12)
+	viper.SetDefault("recommendation.onboarding.candidates_per_category", 10)
+	viper.SetDefault("recommendation.onboarding.pool_size", 500)
+	viper.SetDefault("recommendation.onboarding.popularity_window", "720h")
+	viper.SetDefault("recommendation.onboarding.cache_ttl", "1h")
+	viper.SetDefault("recommendation.onboarding.selection_weight", 0.8)
+	viper.SetDefault("recommendation.onboarding.max_favorites", 5)
 
 	// Caching defaults
 	viper.SetDefault("recommendation.caching.embeddings_ttl", "24h")
+	viper.SetDefault("recommendation.caching.embedding_encoding", "float32")
 	viper.SetDefault("recommendation.caching.recommendations_ttl", "15m")
 	viper.SetDefault("recommendation.caching.metadata_ttl", "1h")
 	viper.SetDefault("recommendation.caching.graph_results_ttl", "30m")
@@ -224,12 +601,95 @@ func setDefaults() {
 	viper.SetDefault("models.text_embedding.dimensions", 384)
 	viper.SetDefault("models.image_embedding.model_path", "./models/clip-vit-base-patch32.onnx")
 	viper.SetDefault("models.image_embedding.dimensions", 512)
+	viper.SetDefault("models.fusion.enabled", false)
+	viper.SetDefault("models.fusion.method", "late_fusion_with_projection")
+	viper.SetDefault("models.fusion.weights_path", "./models/fusion")
+	viper.SetDefault("models.fusion.reload_interval", "5m")
+
+	// Graph outbox defaults
+	viper.SetDefault("neo4j.outbox.poll_interval", "5s")
+	viper.SetDefault("neo4j.outbox.batch_size", 100)
+	viper.SetDefault("neo4j.outbox.max_attempts", 10)
+	viper.SetDefault("neo4j.outbox.retry_backoff", "5s")
+	viper.SetDefault("neo4j.outbox.max_backoff", "10m")
+
+	// Graph reconciliation defaults
+	viper.SetDefault("neo4j.reconciliation.enabled", false)
+	viper.SetDefault("neo4j.reconciliation.interval", "24h")
+	viper.SetDefault("neo4j.reconciliation.repair", true)
+	viper.SetDefault("neo4j.reconciliation.chunk_size", 500)
+	viper.SetDefault("neo4j.reconciliation.max_samples", 100)
+
+	// Privacy defaults
+	viper.SetDefault("security.privacy.publish_deletions", true)
+	viper.SetDefault("security.privacy.scan_count", 500)
 
 	// Monitoring defaults
 	viper.SetDefault("monitoring.enabled", true)
 	viper.SetDefault("monitoring.port", "9090")
 	viper.SetDefault("monitoring.metrics_path", "/metrics")
 
+	// Ingestion defaults
+	viper.SetDefault("ingestion.idempotency_ttl", "24h")
+	viper.SetDefault("ingestion.dedup.enabled", true)
+	viper.SetDefault("ingestion.dedup.policy", "flag")
+	viper.SetDefault("ingestion.dedup.max_hamming_distance", 3)
+	viper.SetDefault("ingestion.dedup.embedding_similarity", 0.95)
+	viper.SetDefault("ingestion.dedup.candidate_limit", 10)
+	viper.SetDefault("ingestion.dedup.image_hashing", true)
+	viper.SetDefault("ingestion.dedup.max_image_distance", 3)
+	viper.SetDefault("ingestion.bulk_import.spool_dir", "") // Empty uses the OS temp directory
+	viper.SetDefault("ingestion.bulk_import.local_root", "")
+	viper.SetDefault("ingestion.bulk_import.allow_url_sources", false)
+	viper.SetDefault("ingestion.bulk_import.allow_private_networks", false)
+	viper.SetDefault("ingestion.bulk_import.max_upload_size", 2<<30) // 2GB
+	viper.SetDefault("ingestion.bulk_import.max_line_bytes", 1<<20)  // 1MB
+	viper.SetDefault("ingestion.bulk_import.publish_batch_size", 100)
+	viper.SetDefault("ingestion.bulk_import.publish_workers", 4)
+	viper.SetDefault("ingestion.bulk_import.max_in_flight", 5000)
+	viper.SetDefault("ingestion.bulk_import.max_error_reports", 10000)
+	viper.SetDefault("ingestion.bulk_import.error_report_ttl", "168h")
+	viper.SetDefault("ingestion.bulk_import.fetch_timeout", "30m")
+	viper.SetDefault("ingestion.bulk_import.default_content_type", "product")
+	viper.SetDefault("ingestion.bulk_import.list_separator", "|")
+	viper.SetDefault("ingestion.catalog_sync.enabled", false)
+	viper.SetDefault("ingestion.catalog_sync.default_interval", "1h")
+	viper.SetDefault("ingestion.catalog_sync.max_deactivation_ratio", 0.5)
+	viper.SetDefault("ingestion.max_item_outcomes", 10000)
+	viper.SetDefault("ingestion.webhooks.enabled", false)
+	viper.SetDefault("ingestion.webhooks.secret", "")
+	viper.SetDefault("ingestion.webhooks.timeout", "10s")
+	viper.SetDefault("ingestion.webhooks.max_attempts", 6)
+	viper.SetDefault("ingestion.webhooks.initial_backoff", "5s")
+	viper.SetDefault("ingestion.webhooks.max_backoff", "10m")
+	viper.SetDefault("ingestion.webhooks.workers", 2)
+	viper.SetDefault("ingestion.webhooks.max_payload_items", 1000)
+	viper.SetDefault("ingestion.webhooks.allow_private_networks", false)
+	viper.SetDefault("ingestion.images.fetch.timeout", "10s")
+	viper.SetDefault("ingestion.images.fetch.max_bytes", 10*1024*1024)
+	viper.SetDefault("ingestion.images.fetch.max_pixels", 40000000)
+	viper.SetDefault("ingestion.images.fetch.max_redirects", 3)
+	viper.SetDefault("ingestion.images.fetch.per_host_concurrency", 4)
+	viper.SetDefault("ingestion.images.fetch.allow_private_networks", false)
+	viper.SetDefault("ingestion.images.fetch.user_agent", "pirex-image-fetcher/1.0")
+	viper.SetDefault("ingestion.images.store.enabled", false)
+	viper.SetDefault("ingestion.images.store.backend", "local")
+	viper.SetDefault("ingestion.images.store.local_path", "./data/images")
+	viper.SetDefault("ingestion.images.store.thumbnail_size", 256)
+	viper.SetDefault("ingestion.content_types.schema_dir", "")
+	viper.SetDefault("ingestion.keywords.scoring", "bm25")
+	viper.SetDefault("ingestion.keywords.k1", 1.2)
+	viper.SetDefault("ingestion.keywords.b", 0.75)
+	viper.SetDefault("ingestion.keywords.title_boost", 2.0)
+	viper.SetDefault("ingestion.keywords.max_terms", 100)
+	viper.SetDefault("ingestion.keywords.reload_interval", "5m")
+	viper.SetDefault("ingestion.taxonomy.expand_parents", true)
+	viper.SetDefault("ingestion.taxonomy.reload_interval", "5m")
+	viper.SetDefault("ingestion.taxonomy.sync_graph", true)
+	viper.SetDefault("ingestion.job_cleanup.enabled", true)
+	viper.SetDefault("ingestion.job_cleanup.interval", "1h")
+	viper.SetDefault("ingestion.job_cleanup.retention", "24h")
+
 	// Security defaults
 	viper.SetDefault("security.cors.allowed_origins", []string{"*"})
 	viper.SetDefault("security.cors.allowed_methods", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"})
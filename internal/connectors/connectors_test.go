@@ -0,0 +1,166 @@
+package connectors
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+)
+
+func TestParseFeed_JSON(t *testing.T) {
+	records, err := ParseFeed(FormatJSON, strings.NewReader(`{"products": [{"sku": "A"}, {"sku": "B"}]}`), ParseOptions{})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "A", records[0]["sku"])
+
+	records, err = ParseFeed(FormatJSON, strings.NewReader(`{"catalog": [{"sku": "C"}]}`), ParseOptions{ItemsKey: "catalog"})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	_, err = ParseFeed(FormatJSON, strings.NewReader(`{"catalog": []}`), ParseOptions{})
+	assert.Error(t, err, "object without a known item key")
+
+	_, err = ParseFeed(FormatJSON, strings.NewReader(`[1, 2]`), ParseOptions{})
+	assert.Error(t, err, "items must be objects")
+}
+
+func TestParseFeed_CSV(t *testing.T) {
+	records, err := ParseFeed(FormatCSV, strings.NewReader("\ufeffsku;title\nA;Shoe\nB;Hat\n"), ParseOptions{Delimiter: ";"})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "A", records[0]["sku"])
+	assert.Equal(t, "Hat", records[1]["title"])
+}
+
+func TestParseFeed_RSS(t *testing.T) {
+	feed := `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+  <item>
+    <guid>post-1</guid>
+    <link>https://example.com/post-1</link>
+    <title> First post </title>
+    <description>Hello</description>
+    <category>News</category>
+    <enclosure url="https://example.com/1.jpg" type="image/jpeg"/>
+    <enclosure url="https://example.com/1.mp3" type="audio/mpeg"/>
+  </item>
+  <item><link>https://example.com/post-2</link><title>Second</title></item>
+</channel></rss>`
+
+	records, err := ParseFeed(FormatRSS, strings.NewReader(feed), ParseOptions{})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "post-1", records[0]["external_id"])
+	assert.Equal(t, "article", records[0]["type"])
+	assert.Equal(t, "First post", records[0]["title"])
+	assert.Equal(t, []interface{}{"https://example.com/1.jpg"}, records[0]["image_urls"])
+	assert.Equal(t, []interface{}{"News"}, records[0]["categories"])
+	assert.Equal(t, "https://example.com/post-2", records[1]["external_id"], "link is used when guid is missing")
+}
+
+func TestParseFeed_Atom(t *testing.T) {
+	feed := `<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>urn:entry:1</id>
+    <title>Entry</title>
+    <summary>Summary text</summary>
+    <link href="https://example.com/entry"/>
+    <link rel="enclosure" type="image/png" href="https://example.com/e.png"/>
+    <category term="Tech"/>
+    <author><name>Jane</name></author>
+  </entry>
+</feed>`
+
+	records, err := ParseFeed(FormatAtom, strings.NewReader(feed), ParseOptions{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	assert.Equal(t, "urn:entry:1", records[0]["external_id"])
+	assert.Equal(t, "Summary text", records[0]["description"])
+	assert.Equal(t, []interface{}{"https://example.com/e.png"}, records[0]["image_urls"])
+	metadata := records[0]["metadata"].(map[string]interface{})
+	assert.Equal(t, "https://example.com/entry", metadata["link"])
+	assert.Equal(t, "Jane", metadata["author"])
+}
+
+func TestParseFeed_GoogleShopping(t *testing.T) {
+	feed := `<?xml version="1.0"?>
+<rss version="2.0" xmlns:g="http://base.google.com/ns/1.0"><channel>
+  <item>
+    <g:id>SKU-1</g:id>
+    <title>Chef Knife</title>
+    <description>Forged steel</description>
+    <link>https://shop.example.com/knife</link>
+    <g:image_link>https://shop.example.com/knife.jpg</g:image_link>
+    <g:price>49.90 usd</g:price>
+    <g:sale_price>39.90 USD</g:sale_price>
+    <g:availability>in_stock</g:availability>
+    <g:brand>Acme</g:brand>
+    <g:product_type>Home &gt; Kitchen &gt; Knives</g:product_type>
+  </item>
+</channel></rss>`
+
+	records, err := ParseFeed(FormatGoogleShopping, strings.NewReader(feed), ParseOptions{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	record := records[0]
+	assert.Equal(t, "SKU-1", record["external_id"])
+	assert.Equal(t, "product", record["type"])
+	assert.Equal(t, []interface{}{"Home", "Kitchen", "Knives"}, record["categories"])
+
+	metadata := record["metadata"].(map[string]interface{})
+	assert.Equal(t, 49.90, metadata["price"])
+	assert.Equal(t, "USD", metadata["currency"])
+	assert.Equal(t, 39.90, metadata["sale_price"])
+	assert.Equal(t, "in stock", metadata["availability"])
+	assert.NotContains(t, metadata, "gtin", "empty attributes are dropped")
+}
+
+func TestParseMerchantPrice(t *testing.T) {
+	amount, currency, ok := parseMerchantPrice("15.00 eur")
+	assert.True(t, ok)
+	assert.Equal(t, 15.0, amount)
+	assert.Equal(t, "EUR", currency)
+
+	_, _, ok = parseMerchantPrice("free")
+	assert.False(t, ok)
+
+	_, _, ok = parseMerchantPrice("")
+	assert.False(t, ok)
+}
+
+func TestNewFeedConnector_Validation(t *testing.T) {
+	_, err := NewFeedConnector(config.CatalogFeedConfig{Format: FormatJSON, URL: "https://example.com/feed.json"})
+	assert.Error(t, err, "name is required")
+
+	_, err = NewFeedConnector(config.CatalogFeedConfig{Name: "feed", Format: FormatJSON})
+	assert.Error(t, err, "url or path is required")
+
+	_, err = NewFeedConnector(config.CatalogFeedConfig{Name: "feed", Format: FormatJSON, URL: "https://example.com", Path: "/tmp/feed.json"})
+	assert.Error(t, err, "url and path are exclusive")
+
+	_, err = NewFeedConnector(config.CatalogFeedConfig{Name: "feed", Format: "xlsx", URL: "https://example.com"})
+	assert.Error(t, err, "unknown format")
+}
+
+func TestFeedConnector_FetchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.csv")
+	require.NoError(t, os.WriteFile(path, []byte("sku,title\nA,Shoe\n"), 0o600))
+
+	connector, err := NewFeedConnector(config.CatalogFeedConfig{Name: "local", Format: FormatCSV, Path: path})
+	require.NoError(t, err)
+	assert.Equal(t, "local", connector.Name())
+
+	records, err := connector.Fetch(context.Background())
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "Shoe", records[0]["title"])
+}
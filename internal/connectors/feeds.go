@@ -0,0 +1,373 @@
+package connectors
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// jsonItemKeys are tried in order when a JSON feed is an object and no items key is configured
+var jsonItemKeys = []string{"items", "products", "entries", "data"}
+
+// ParseOptions tunes feed parsing for formats that need it
+type ParseOptions struct {
+	ItemsKey  string // JSON: key holding the item array
+	Delimiter string // CSV: field delimiter, defaults to a comma
+}
+
+// ParseFeed decodes a feed into records
+func ParseFeed(format string, r io.Reader, opts ParseOptions) ([]Record, error) {
+	switch format {
+	case FormatJSON:
+		return parseJSONFeed(r, opts.ItemsKey)
+	case FormatCSV:
+		return parseCSVFeed(r, opts.Delimiter)
+	case FormatRSS, FormatAtom:
+		return parseSyndicationFeed(r)
+	case FormatGoogleShopping:
+		return parseGoogleShoppingFeed(r)
+	default:
+		return nil, fmt.Errorf("unsupported feed format %q", format)
+	}
+}
+
+func parseJSONFeed(r io.Reader, itemsKey string) ([]Record, error) {
+	var document interface{}
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON feed: %w", err)
+	}
+
+	var items []interface{}
+	switch v := document.(type) {
+	case []interface{}:
+		items = v
+	case map[string]interface{}:
+		keys := jsonItemKeys
+		if itemsKey != "" {
+			keys = []string{itemsKey}
+		}
+		for _, key := range keys {
+			if list, ok := v[key].([]interface{}); ok {
+				items = list
+				break
+			}
+		}
+		if items == nil {
+			return nil, fmt.Errorf("JSON feed has no item array under %s", strings.Join(keys, ", "))
+		}
+	default:
+		return nil, fmt.Errorf("JSON feed must be an array or an object")
+	}
+
+	records := make([]Record, 0, len(items))
+	for i, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("JSON feed item %d is not an object", i)
+		}
+		records = append(records, Record(object))
+	}
+
+	return records, nil
+}
+
+func parseCSVFeed(r io.Reader, delimiter string) ([]Record, error) {
+	reader := csv.NewReader(r)
+	if delimiter != "" {
+		reader.Comma = []rune(delimiter)[0]
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV feed: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	records := make([]Record, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		record := make(Record, len(header))
+		for i, column := range header {
+			if i < len(row) {
+				record[strings.TrimSpace(column)] = row[i]
+			}
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+type rssDocument struct {
+	XMLName xml.Name `xml:"rss"`
+	Items   []struct {
+		GUID        string   `xml:"guid"`
+		Link        string   `xml:"link"`
+		Title       string   `xml:"title"`
+		Description string   `xml:"description"`
+		Categories  []string `xml:"category"`
+		Author      string   `xml:"author"`
+		PubDate     string   `xml:"pubDate"`
+		Enclosures  []struct {
+			URL  string `xml:"url,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"enclosure"`
+	} `xml:"channel>item"`
+}
+
+type atomDocument struct {
+	XMLName xml.Name `xml:"feed"`
+	Entries []struct {
+		ID      string `xml:"id"`
+		Title   string `xml:"title"`
+		Summary string `xml:"summary"`
+		Content string `xml:"content"`
+		Updated string `xml:"updated"`
+		Author  struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"link"`
+		Categories []struct {
+			Term string `xml:"term,attr"`
+		} `xml:"category"`
+	} `xml:"entry"`
+}
+
+// parseSyndicationFeed accepts RSS 2.0 and Atom, detected from the root element
+func parseSyndicationFeed(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+
+	root, err := xmlRootElement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch root {
+	case "rss":
+		var doc rssDocument
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to decode RSS feed: %w", err)
+		}
+
+		records := make([]Record, 0, len(doc.Items))
+		for _, item := range doc.Items {
+			var images []interface{}
+			for _, enclosure := range item.Enclosures {
+				if strings.HasPrefix(enclosure.Type, "image/") {
+					images = append(images, enclosure.URL)
+				}
+			}
+
+			records = append(records, Record{
+				"external_id": firstNonEmpty(item.GUID, item.Link),
+				"type":        "article",
+				"title":       strings.TrimSpace(item.Title),
+				"description": strings.TrimSpace(item.Description),
+				"image_urls":  images,
+				"categories":  stringsToInterfaces(item.Categories),
+				"metadata": compactMetadata(map[string]interface{}{
+					"link":      item.Link,
+					"author":    item.Author,
+					"published": item.PubDate,
+				}),
+			})
+		}
+		return records, nil
+
+	case "feed":
+		var doc atomDocument
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to decode Atom feed: %w", err)
+		}
+
+		records := make([]Record, 0, len(doc.Entries))
+		for _, entry := range doc.Entries {
+			var link string
+			var images []interface{}
+			for _, l := range entry.Links {
+				switch {
+				case l.Rel == "enclosure" && strings.HasPrefix(l.Type, "image/"):
+					images = append(images, l.Href)
+				case (l.Rel == "" || l.Rel == "alternate") && link == "":
+					link = l.Href
+				}
+			}
+
+			categories := make([]string, 0, len(entry.Categories))
+			for _, category := range entry.Categories {
+				categories = append(categories, category.Term)
+			}
+
+			records = append(records, Record{
+				"external_id": firstNonEmpty(entry.ID, link),
+				"type":        "article",
+				"title":       strings.TrimSpace(entry.Title),
+				"description": strings.TrimSpace(firstNonEmpty(entry.Summary, entry.Content)),
+				"image_urls":  images,
+				"categories":  stringsToInterfaces(categories),
+				"metadata": compactMetadata(map[string]interface{}{
+					"link":      link,
+					"author":    entry.Author.Name,
+					"published": entry.Updated,
+				}),
+			})
+		}
+		return records, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported feed root element <%s>", root)
+	}
+}
+
+// googleShoppingDocument maps Merchant Center attributes in the g: namespace
+// (http://base.google.com/ns/1.0)
+type googleShoppingDocument struct {
+	Items []struct {
+		ID                    string   `xml:"http://base.google.com/ns/1.0 id"`
+		Title                 string   `xml:"title"`
+		Description           string   `xml:"description"`
+		Link                  string   `xml:"link"`
+		ImageLink             string   `xml:"http://base.google.com/ns/1.0 image_link"`
+		AdditionalImageLinks  []string `xml:"http://base.google.com/ns/1.0 additional_image_link"`
+		Price                 string   `xml:"http://base.google.com/ns/1.0 price"`
+		SalePrice             string   `xml:"http://base.google.com/ns/1.0 sale_price"`
+		Availability          string   `xml:"http://base.google.com/ns/1.0 availability"`
+		Brand                 string   `xml:"http://base.google.com/ns/1.0 brand"`
+		Condition             string   `xml:"http://base.google.com/ns/1.0 condition"`
+		GTIN                  string   `xml:"http://base.google.com/ns/1.0 gtin"`
+		MPN                   string   `xml:"http://base.google.com/ns/1.0 mpn"`
+		ProductType           string   `xml:"http://base.google.com/ns/1.0 product_type"`
+		GoogleProductCategory string   `xml:"http://base.google.com/ns/1.0 google_product_category"`
+	} `xml:"channel>item"`
+}
+
+// parseGoogleShoppingFeed reads a Google Merchant Center RSS 2.0 product feed
+func parseGoogleShoppingFeed(r io.Reader) ([]Record, error) {
+	var doc googleShoppingDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode Google Shopping feed: %w", err)
+	}
+
+	records := make([]Record, 0, len(doc.Items))
+	for _, item := range doc.Items {
+		var images []interface{}
+		for _, image := range append([]string{item.ImageLink}, item.AdditionalImageLinks...) {
+			if image = strings.TrimSpace(image); image != "" {
+				images = append(images, image)
+			}
+		}
+
+		// product_type is the merchant's own "Home > Kitchen > Knives" path
+		var categories []string
+		for _, part := range strings.Split(item.ProductType, ">") {
+			if part = strings.TrimSpace(part); part != "" {
+				categories = append(categories, part)
+			}
+		}
+
+		metadata := map[string]interface{}{
+			"link":                    item.Link,
+			"availability":            strings.ReplaceAll(item.Availability, "_", " "),
+			"brand":                   item.Brand,
+			"condition":               item.Condition,
+			"gtin":                    item.GTIN,
+			"mpn":                     item.MPN,
+			"google_product_category": item.GoogleProductCategory,
+		}
+		if amount, currency, ok := parseMerchantPrice(item.Price); ok {
+			metadata["price"] = amount
+			metadata["currency"] = currency
+		}
+		if amount, _, ok := parseMerchantPrice(item.SalePrice); ok {
+			metadata["sale_price"] = amount
+		}
+
+		records = append(records, Record{
+			"external_id": strings.TrimSpace(item.ID),
+			"type":        "product",
+			"title":       strings.TrimSpace(item.Title),
+			"description": strings.TrimSpace(item.Description),
+			"image_urls":  images,
+			"categories":  stringsToInterfaces(categories),
+			"metadata":    compactMetadata(metadata),
+		})
+	}
+
+	return records, nil
+}
+
+// parseMerchantPrice splits a Merchant Center price such as "15.00 USD"
+func parseMerchantPrice(value string) (float64, string, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0, "", false
+	}
+
+	amount, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, "", false
+	}
+
+	currency := ""
+	if len(fields) > 1 {
+		currency = strings.ToUpper(fields[1])
+	}
+	return amount, currency, true
+}
+
+func xmlRootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("failed to read feed XML: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func compactMetadata(metadata map[string]interface{}) map[string]interface{} {
+	for key, value := range metadata {
+		if s, ok := value.(string); ok && strings.TrimSpace(s) == "" {
+			delete(metadata, key)
+		}
+	}
+	return metadata
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		result = append(result, value)
+	}
+	return result
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,199 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/sirupsen/logrus"
+)
+
+// Migration is one versioned change to the graph schema. Each statement runs in
+// its own transaction, since Neo4j does not mix schema and data changes, so
+// statements must be idempotent: a migration interrupted part way is run again
+// from the start.
+type Migration struct {
+	Version     int
+	Description string
+	Statements  []string
+}
+
+// Migrations is the schema history, applied in version order at startup and
+// recorded as (:SchemaMigration {version}) nodes. Append new migrations; never
+// change one that has been released.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Node key constraints",
+		Statements: []string{
+			`CREATE CONSTRAINT schema_migration_version_unique IF NOT EXISTS FOR (m:SchemaMigration) REQUIRE m.version IS UNIQUE`,
+			`CREATE CONSTRAINT user_id_unique IF NOT EXISTS FOR (u:User) REQUIRE u.id IS UNIQUE`,
+			`CREATE CONSTRAINT content_id_unique IF NOT EXISTS FOR (c:Content) REQUIRE c.id IS UNIQUE`,
+			`CREATE CONSTRAINT category_id_unique IF NOT EXISTS FOR (c:Category) REQUIRE c.id IS UNIQUE`,
+		},
+	},
+	{
+		Version:     2,
+		Description: "Lookup and relationship property indexes",
+		Statements: []string{
+			`CREATE INDEX content_type IF NOT EXISTS FOR (c:Content) ON (c.type)`,
+			`CREATE INDEX content_active IF NOT EXISTS FOR (c:Content) ON (c.active)`,
+			`CREATE INDEX category_name IF NOT EXISTS FOR (c:Category) ON (c.name)`,
+			`CREATE INDEX user_community IF NOT EXISTS FOR (u:User) ON (u.community)`,
+			`CREATE INDEX rated_timestamp IF NOT EXISTS FOR ()-[r:RATED]-() ON (r.timestamp)`,
+			`CREATE INDEX rated_rating IF NOT EXISTS FOR ()-[r:RATED]-() ON (r.rating)`,
+			`CREATE INDEX viewed_timestamp IF NOT EXISTS FOR ()-[r:VIEWED]-() ON (r.timestamp)`,
+			`CREATE INDEX shared_timestamp IF NOT EXISTS FOR ()-[r:SHARED]-() ON (r.timestamp)`,
+			`CREATE INDEX interacted_timestamp IF NOT EXISTS FOR ()-[r:INTERACTED_WITH]-() ON (r.timestamp)`,
+			`CREATE INDEX similar_to_score IF NOT EXISTS FOR ()-[r:SIMILAR_TO]-() ON (r.score)`,
+		},
+	},
+	{
+		// Earlier readers keyed nodes by user_id and content_id. Nodes whose
+		// id is already taken are left for reconciliation.
+		Version:     3,
+		Description: "Move user_id and content_id node keys to id",
+		Statements: []string{
+			`MATCH (u:User) WHERE u.id IS NULL AND u.user_id IS NOT NULL
+			 AND NOT EXISTS { MATCH (other:User) WHERE other.id = u.user_id }
+			 SET u.id = u.user_id REMOVE u.user_id`,
+			`MATCH (c:Content) WHERE c.id IS NULL AND c.content_id IS NOT NULL
+			 AND NOT EXISTS { MATCH (other:Content) WHERE other.id = c.content_id }
+			 SET c.id = c.content_id REMOVE c.content_id`,
+		},
+	},
+	{
+		// The interaction writer used to create relationships literally typed
+		// "rel.type". Their properties tell ratings and views apart.
+		Version:     4,
+		Description: "Retype relationships written as rel.type",
+		Statements: []string{
+			"MATCH (u:User)-[old:`rel.type`]->(c:Content) WHERE old.rating IS NOT NULL " +
+				"MERGE (u)-[r:RATED]->(c) SET r += properties(old) DELETE old",
+			"MATCH (u:User)-[old:`rel.type`]->(c:Content) WHERE old.duration IS NOT NULL " +
+				"MERGE (u)-[r:VIEWED]->(c) SET r += properties(old) DELETE old",
+			"MATCH (u:User)-[old:`rel.type`]->(c:Content) " +
+				"MERGE (u)-[r:INTERACTED_WITH]->(c) SET r += properties(old) DELETE old",
+		},
+	},
+}
+
+// Migrate applies the migrations that have not been recorded yet, in version
+// order
+func (r *Repository) Migrate(ctx context.Context) error {
+	if !r.available() {
+		return nil
+	}
+	if err := validateMigrations(Migrations); err != nil {
+		return err
+	}
+
+	applied, err := r.appliedMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read applied graph migrations: %w", err)
+	}
+
+	pending := pendingMigrations(Migrations, applied)
+	for _, migration := range pending {
+		for i, statement := range migration.Statements {
+			if err := r.write(ctx, statement, nil); err != nil {
+				return fmt.Errorf("graph migration %d (%s) statement %d failed: %w",
+					migration.Version, migration.Description, i+1, err)
+			}
+		}
+
+		err := r.write(ctx, `
+			MERGE (m:SchemaMigration {version: $version})
+			SET m.description = $description, m.applied_at = datetime()`,
+			map[string]interface{}{"version": migration.Version, "description": migration.Description})
+		if err != nil {
+			return fmt.Errorf("failed to record graph migration %d: %w", migration.Version, err)
+		}
+
+		r.logger.WithFields(logrus.Fields{
+			"version":     migration.Version,
+			"description": migration.Description,
+		}).Info("Applied graph migration")
+	}
+
+	if len(pending) == 0 {
+		r.logger.Debug("Graph schema is up to date")
+	}
+	return nil
+}
+
+func (r *Repository) appliedMigrations(ctx context.Context) (map[int]bool, error) {
+	records, err := r.read(ctx, `MATCH (m:SchemaMigration) RETURN m.version AS version`, nil)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]bool, len(records))
+	for _, record := range records {
+		version, _ := record.Get("version")
+		applied[asInt(version)] = true
+	}
+	return applied, nil
+}
+
+// pendingMigrations returns the migrations not yet applied, in version order
+func pendingMigrations(migrations []Migration, applied map[int]bool) []Migration {
+	var pending []Migration
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Version < pending[j].Version
+	})
+	return pending
+}
+
+// validateMigrations checks that versions are positive and unique
+func validateMigrations(migrations []Migration) error {
+	seen := make(map[int]bool, len(migrations))
+	for _, migration := range migrations {
+		if migration.Version <= 0 {
+			return fmt.Errorf("graph migration %q has invalid version %d", migration.Description, migration.Version)
+		}
+		if seen[migration.Version] {
+			return fmt.Errorf("duplicate graph migration version %d", migration.Version)
+		}
+		seen[migration.Version] = true
+	}
+	return nil
+}
+
+// read runs a query in a read transaction and returns all records
+func (r *Repository) read(ctx context.Context, cypher string, params map[string]interface{}) ([]*neo4j.Record, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	records, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := tx.Run(ctx, cypher, params)
+		if err != nil {
+			return nil, err
+		}
+		return result.Collect(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records.([]*neo4j.Record), nil
+}
+
+// write runs a statement in a write transaction
+func (r *Repository) write(ctx context.Context, cypher string, params map[string]interface{}) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := tx.Run(ctx, cypher, params)
+		if err != nil {
+			return nil, err
+		}
+		return result.Consume(ctx)
+	})
+	return err
+}
@@ -0,0 +1,30 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations_Valid(t *testing.T) {
+	assert.NoError(t, validateMigrations(Migrations))
+	for _, migration := range Migrations {
+		assert.NotEmpty(t, migration.Description, "migration %d", migration.Version)
+		assert.NotEmpty(t, migration.Statements, "migration %d", migration.Version)
+	}
+}
+
+func TestValidateMigrations(t *testing.T) {
+	assert.Error(t, validateMigrations([]Migration{{Version: 0}}))
+	assert.Error(t, validateMigrations([]Migration{{Version: 1}, {Version: 1}}))
+	assert.NoError(t, validateMigrations([]Migration{{Version: 2}, {Version: 1}}))
+}
+
+func TestPendingMigrations(t *testing.T) {
+	migrations := []Migration{{Version: 3}, {Version: 1}, {Version: 2}}
+
+	pending := pendingMigrations(migrations, map[int]bool{2: true})
+	assert.Equal(t, []Migration{{Version: 1}, {Version: 3}}, pending)
+
+	assert.Empty(t, pendingMigrations(migrations, map[int]bool{1: true, 2: true, 3: true}))
+}
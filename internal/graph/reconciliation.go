@@ -0,0 +1,224 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Reads and repairs used to reconcile the graph with Postgres
+
+// ContentState is a content node as found in the graph
+type ContentState struct {
+	ContentNode
+	SimilarLinks int // SIMILAR_TO relationships of the item
+}
+
+// InteractionsOf returns the interaction relationships of the given users.
+// Ratings are returned as float64.
+func (r *Repository) InteractionsOf(ctx context.Context, userIDs []uuid.UUID) ([]Interaction, error) {
+	if !r.available() {
+		return nil, ErrUnavailable
+	}
+
+	records, err := r.read(ctx, `
+		MATCH (u:User)-[r:`+engagement+`]->(c:Content)
+		WHERE u.id IN $userIds
+		RETURN u.id AS user_id, c.id AS item_id, type(r) AS type, properties(r) AS properties`,
+		map[string]interface{}{"userIds": uuidStrings(userIDs)})
+	if err != nil {
+		return nil, err
+	}
+
+	interactions := make([]Interaction, 0, len(records))
+	for _, record := range records {
+		values := record.AsMap()
+		userID, err := asUUID(values["user_id"])
+		if err != nil {
+			continue
+		}
+		itemID, err := asUUID(values["item_id"])
+		if err != nil {
+			continue
+		}
+		properties, _ := values["properties"].(map[string]interface{})
+		if rating, ok := properties[PropRating]; ok {
+			properties[PropRating] = asFloat(rating)
+		}
+		interactions = append(interactions, Interaction{
+			UserID:     userID,
+			ItemID:     itemID,
+			Type:       asString(values["type"]),
+			Properties: properties,
+		})
+	}
+	return interactions, nil
+}
+
+// ExistingUsers returns which of the given users have a node
+func (r *Repository) ExistingUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	if !r.available() {
+		return nil, ErrUnavailable
+	}
+
+	records, err := r.read(ctx, `
+		UNWIND $ids AS id
+		MATCH (u:User {id: id})
+		RETURN u.id AS id`,
+		map[string]interface{}{"ids": uuidStrings(userIDs)})
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[uuid.UUID]bool, len(records))
+	for _, record := range records {
+		id, _ := record.Get("id")
+		if userID, err := asUUID(id); err == nil {
+			existing[userID] = true
+		}
+	}
+	return existing, nil
+}
+
+// DeleteInteractions removes interaction relationships, leaving the nodes
+func (r *Repository) DeleteInteractions(ctx context.Context, batch []Interaction) error {
+	if !r.available() || len(batch) == 0 {
+		return nil
+	}
+
+	types, groups := groupInteractions(batch)
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		for _, relType := range types {
+			cypher := `
+				UNWIND $relationships AS rel
+				MATCH (:User {id: rel.user_id})-[r:` + relType + `]->(:Content {id: rel.item_id})
+				DELETE r`
+			if _, err := tx.Run(ctx, cypher, map[string]interface{}{"relationships": groups[relType]}); err != nil {
+				return nil, fmt.Errorf("failed to delete %s relationships: %w", relType, err)
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// Contents returns the graph state of the given items, keyed by ID. Items
+// without a node are absent.
+func (r *Repository) Contents(ctx context.Context, itemIDs []uuid.UUID) (map[uuid.UUID]ContentState, error) {
+	if !r.available() {
+		return nil, ErrUnavailable
+	}
+
+	records, err := r.read(ctx, `
+		UNWIND $ids AS id
+		MATCH (c:Content {id: id})
+		RETURN c.id AS id, c.type AS type, c.categories AS categories, c.active AS active,
+			   size([(c)-[:SIMILAR_TO]-() | 1]) AS similar_links`,
+		map[string]interface{}{"ids": uuidStrings(itemIDs)})
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make(map[uuid.UUID]ContentState, len(records))
+	for _, record := range records {
+		values := record.AsMap()
+		itemID, err := asUUID(values["id"])
+		if err != nil {
+			continue
+		}
+		var categories []string
+		list, _ := values["categories"].([]interface{})
+		for _, category := range list {
+			categories = append(categories, asString(category))
+		}
+		// Nodes created by interactions before the item was ingested have
+		// no active flag; they count as active like everywhere else
+		active, ok := values["active"].(bool)
+		if !ok {
+			active = true
+		}
+		contents[itemID] = ContentState{
+			ContentNode: ContentNode{
+				ID:         itemID,
+				Type:       asString(values["type"]),
+				Categories: categories,
+				Active:     active,
+			},
+			SimilarLinks: asInt(values["similar_links"]),
+		}
+	}
+	return contents, nil
+}
+
+// UnlinkSimilarContent removes the SIMILAR_TO relationships of the given items
+func (r *Repository) UnlinkSimilarContent(ctx context.Context, itemIDs []uuid.UUID) error {
+	if !r.available() || len(itemIDs) == 0 {
+		return nil
+	}
+	return r.write(ctx, `
+		UNWIND $ids AS id
+		MATCH (:Content {id: id})-[s:SIMILAR_TO]-()
+		DELETE s`,
+		map[string]interface{}{"ids": uuidStrings(itemIDs)})
+}
+
+// DeleteContents removes content nodes and all their relationships
+func (r *Repository) DeleteContents(ctx context.Context, itemIDs []uuid.UUID) error {
+	if !r.available() || len(itemIDs) == 0 {
+		return nil
+	}
+	return r.write(ctx, `
+		UNWIND $ids AS id
+		MATCH (c:Content {id: id})
+		DETACH DELETE c`,
+		map[string]interface{}{"ids": uuidStrings(itemIDs)})
+}
+
+// UserIDsAfter pages through user node IDs in ID order. Nodes whose ID is not
+// a UUID are skipped but still advance the returned cursor, which is empty
+// after the last page.
+func (r *Repository) UserIDsAfter(ctx context.Context, after string, limit int) ([]uuid.UUID, string, error) {
+	return r.nodeIDsAfter(ctx, LabelUser, after, limit)
+}
+
+// ContentIDsAfter pages through content node IDs like UserIDsAfter
+func (r *Repository) ContentIDsAfter(ctx context.Context, after string, limit int) ([]uuid.UUID, string, error) {
+	return r.nodeIDsAfter(ctx, LabelContent, after, limit)
+}
+
+// nodeIDsAfter pages through node IDs of a label from this package's constants
+func (r *Repository) nodeIDsAfter(ctx context.Context, label, after string, limit int) ([]uuid.UUID, string, error) {
+	if !r.available() {
+		return nil, "", ErrUnavailable
+	}
+
+	records, err := r.read(ctx, `
+		MATCH (n:`+label+`) WHERE n.id > $after
+		RETURN n.id AS id
+		ORDER BY n.id
+		LIMIT $limit`,
+		map[string]interface{}{"after": after, "limit": limit})
+	if err != nil {
+		return nil, "", err
+	}
+
+	ids := make([]uuid.UUID, 0, len(records))
+	cursor := ""
+	for _, record := range records {
+		value, _ := record.Get("id")
+		cursor = asString(value)
+		if id, err := uuid.Parse(cursor); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(records) < limit {
+		cursor = ""
+	}
+	return ids, cursor, nil
+}
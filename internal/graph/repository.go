@@ -0,0 +1,638 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// ErrUnavailable is returned by reads when no Neo4j driver is configured
+var ErrUnavailable = errors.New("graph database is not configured")
+
+// communityGraph is the GDS projection UpdateCommunities runs Louvain on
+const communityGraph = "user-similarity"
+
+// Repository reads and writes the recommendation graph. Methods are safe to
+// call on a nil repository: writes do nothing and reads return ErrUnavailable.
+type Repository struct {
+	driver neo4j.DriverWithContext
+	logger *logrus.Logger
+}
+
+func NewRepository(driver neo4j.DriverWithContext, logger *logrus.Logger) *Repository {
+	return &Repository{
+		driver: driver,
+		logger: logger,
+	}
+}
+
+// Available reports whether a Neo4j driver is configured
+func (r *Repository) Available() bool {
+	return r.available()
+}
+
+func (r *Repository) available() bool {
+	return r != nil && r.driver != nil
+}
+
+// RecordInteractions merges (:User)-[type]->(:Content) relationships, creating
+// missing nodes. The batch is written in one transaction. Properties older than
+// the relationship's timestamp are skipped, so replaying interactions or
+// writing batches out of order leaves the latest interaction's properties.
+func (r *Repository) RecordInteractions(ctx context.Context, batch []Interaction) error {
+	if !r.available() || len(batch) == 0 {
+		return nil
+	}
+
+	types, groups := groupInteractions(batch)
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		for _, relType := range types {
+			// relType comes from interactionRelationships, never from input
+			cypher := `
+				UNWIND $relationships AS rel
+				MERGE (u:User {id: rel.user_id})
+				MERGE (c:Content {id: rel.item_id})
+				MERGE (u)-[r:` + relType + `]->(c)
+				WITH r, rel
+				WHERE r.timestamp IS NULL OR rel.properties.timestamp IS NULL
+					OR rel.properties.timestamp >= r.timestamp
+				SET r += rel.properties, r.updated_at = datetime()`
+			if _, err := tx.Run(ctx, cypher, map[string]interface{}{"relationships": groups[relType]}); err != nil {
+				return nil, fmt.Errorf("failed to write %s relationships: %w", relType, err)
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// UpsertContents writes content nodes and links them to their categories.
+// Categories not yet in the graph are linked when the item is written again.
+func (r *Repository) UpsertContents(ctx context.Context, contents []ContentNode) error {
+	if !r.available() || len(contents) == 0 {
+		return nil
+	}
+
+	nodes := make([]map[string]interface{}, len(contents))
+	for i, content := range contents {
+		categories := content.Categories
+		if categories == nil {
+			categories = []string{}
+		}
+		nodes[i] = map[string]interface{}{
+			"id":         content.ID.String(),
+			"type":       content.Type,
+			"categories": categories,
+			"active":     content.Active,
+		}
+	}
+	params := map[string]interface{}{"contents": nodes}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		if _, err := tx.Run(ctx, `
+			UNWIND $contents AS content
+			MERGE (c:Content {id: content.id})
+			SET c.type = content.type, c.categories = content.categories,
+				c.active = content.active, c.updated_at = datetime()
+			WITH c
+			MATCH (c)-[old:IN_CATEGORY]->(:Category)
+			DELETE old
+		`, params); err != nil {
+			return nil, err
+		}
+		_, err := tx.Run(ctx, `
+			UNWIND $contents AS content
+			UNWIND content.categories AS name
+			MATCH (c:Content {id: content.id}), (category:Category {name: name})
+			MERGE (c)-[:IN_CATEGORY]->(category)
+		`, params)
+		return nil, err
+	})
+	return err
+}
+
+// SetContentActive flags a content node active or inactive. Inactive items
+// keep their relationships but are not recommended.
+func (r *Repository) SetContentActive(ctx context.Context, itemID uuid.UUID, active bool) error {
+	if !r.available() {
+		return nil
+	}
+	return r.write(ctx, `
+		MATCH (c:Content {id: $id})
+		SET c.active = $active, c.updated_at = datetime()`,
+		map[string]interface{}{"id": itemID.String(), "active": active})
+}
+
+// SyncCategories replaces the category tree with the given categories,
+// rebuilding SUBCATEGORY_OF edges and deleting categories no longer listed
+func (r *Repository) SyncCategories(ctx context.Context, categories []CategoryNode) error {
+	if !r.available() {
+		return nil
+	}
+
+	nodes := make([]map[string]interface{}, len(categories))
+	ids := make([]string, len(categories))
+	for i, category := range categories {
+		nodes[i] = map[string]interface{}{
+			"id":        category.ID,
+			"name":      category.Name,
+			"path":      category.Path,
+			"depth":     category.Depth,
+			"parent_id": category.ParentID,
+		}
+		ids[i] = category.ID
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		if _, err := tx.Run(ctx, `
+			UNWIND $categories AS category
+			MERGE (c:Category {id: category.id})
+			SET c.name = category.name, c.path = category.path, c.depth = category.depth
+		`, map[string]interface{}{"categories": nodes}); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Run(ctx, `
+			MATCH (c:Category)-[r:SUBCATEGORY_OF]->()
+			DELETE r
+		`, nil); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Run(ctx, `
+			UNWIND $categories AS category
+			WITH category WHERE category.parent_id <> ''
+			MATCH (c:Category {id: category.id}), (p:Category {id: category.parent_id})
+			MERGE (c)-[:SUBCATEGORY_OF]->(p)
+		`, map[string]interface{}{"categories": nodes}); err != nil {
+			return nil, err
+		}
+		_, err := tx.Run(ctx, `
+			MATCH (c:Category) WHERE NOT c.id IN $ids
+			DETACH DELETE c
+		`, map[string]interface{}{"ids": ids})
+		return nil, err
+	})
+	return err
+}
+
+// UpdateUserSimilarities links users with at least three co-rated items whose
+// ratings correlate above 0.5 (Pearson), returning the number of links written
+func (r *Repository) UpdateUserSimilarities(ctx context.Context) (int64, error) {
+	if !r.available() {
+		return 0, nil
+	}
+	return r.writeCount(ctx, `
+		MATCH (u1:User)-[r1:RATED]->(item:Content)<-[r2:RATED]-(u2:User)
+		WHERE u1.id < u2.id AND r1.rating IS NOT NULL AND r2.rating IS NOT NULL
+		WITH u1, u2,
+			 COUNT(item) as shared_items,
+			 COLLECT({r1: r1.rating, r2: r2.rating}) as ratings
+		WHERE shared_items >= 3
+		WITH u1, u2, shared_items,
+			 REDUCE(sum = 0.0, rating IN ratings | sum + rating.r1 * rating.r2) as dot_product,
+			 REDUCE(sum1 = 0.0, rating IN ratings | sum1 + rating.r1 * rating.r1) as sum_sq1,
+			 REDUCE(sum2 = 0.0, rating IN ratings | sum2 + rating.r2 * rating.r2) as sum_sq2,
+			 REDUCE(sum1 = 0.0, rating IN ratings | sum1 + rating.r1) as sum1,
+			 REDUCE(sum2 = 0.0, rating IN ratings | sum2 + rating.r2) as sum2
+		WITH u1, u2, shared_items, dot_product, sum1, sum2,
+			 (sum_sq1 - (sum1 * sum1 / shared_items)) * (sum_sq2 - (sum2 * sum2 / shared_items)) as variance
+		WHERE variance > 0
+		WITH u1, u2, shared_items,
+			 (dot_product - (sum1 * sum2 / shared_items)) / SQRT(variance) as correlation
+		WHERE correlation > 0.5
+		MERGE (u1)-[s:SIMILAR_TO]-(u2)
+		SET s.score = correlation,
+			s.basis = 'collaborative_filtering',
+			s.shared_items = shared_items,
+			s.computed_at = datetime()
+		RETURN COUNT(s) as count`, nil)
+}
+
+// UpdateContentSimilarities links active items engaged with by at least three
+// shared users whose Jaccard similarity exceeds 0.1, returning the number of
+// links written
+func (r *Repository) UpdateContentSimilarities(ctx context.Context) (int64, error) {
+	if !r.available() {
+		return 0, nil
+	}
+	return r.writeCount(ctx, `
+		MATCH (c1:Content)<-[:`+engagement+`]-(user:User)-[:`+engagement+`]->(c2:Content)
+		WHERE c1.id < c2.id AND coalesce(c1.active, true) AND coalesce(c2.active, true)
+		WITH c1, c2, COUNT(DISTINCT user) as shared_users
+		WHERE shared_users >= 3
+		MATCH (c1)<-[:`+engagement+`]-(u1:User)
+		WITH c1, c2, shared_users, COUNT(DISTINCT u1) as users_c1
+		MATCH (c2)<-[:`+engagement+`]-(u2:User)
+		WITH c1, c2, shared_users, users_c1, COUNT(DISTINCT u2) as users_c2
+		WITH c1, c2, shared_users,
+			 toFloat(shared_users) / (users_c1 + users_c2 - shared_users) as jaccard_similarity
+		WHERE jaccard_similarity > 0.1
+		MERGE (c1)-[s:SIMILAR_TO]-(c2)
+		SET s.score = jaccard_similarity,
+			s.algorithm = 'jaccard_similarity',
+			s.shared_users = shared_users,
+			s.computed_at = datetime()
+		RETURN COUNT(s) as count`, nil)
+}
+
+// UpdateCommunities runs Louvain over user SIMILAR_TO links and stores each
+// user's community in the community property, returning the community count
+func (r *Repository) UpdateCommunities(ctx context.Context) (int64, error) {
+	if !r.available() {
+		return 0, nil
+	}
+
+	// A projection left behind by an interrupted run would block the next one
+	if err := r.write(ctx, `CALL gds.graph.drop($graph, false) YIELD graphName RETURN graphName`,
+		map[string]interface{}{"graph": communityGraph}); err != nil {
+		return 0, fmt.Errorf("failed to drop stale community projection: %w", err)
+	}
+
+	if err := r.write(ctx, `
+		CALL gds.graph.project($graph, 'User', {SIMILAR_TO: {orientation: 'UNDIRECTED', properties: 'score'}})
+		YIELD graphName RETURN graphName`,
+		map[string]interface{}{"graph": communityGraph}); err != nil {
+		return 0, fmt.Errorf("failed to project user similarity graph: %w", err)
+	}
+	defer func() {
+		if err := r.write(context.Background(), `CALL gds.graph.drop($graph, false) YIELD graphName RETURN graphName`,
+			map[string]interface{}{"graph": communityGraph}); err != nil {
+			r.logger.WithError(err).Warn("Failed to drop user similarity projection")
+		}
+	}()
+
+	count, err := r.writeCount(ctx, `
+		CALL gds.louvain.write($graph, {writeProperty: 'community', relationshipWeightProperty: 'score'})
+		YIELD communityCount
+		RETURN communityCount AS count`,
+		map[string]interface{}{"graph": communityGraph})
+	if err != nil {
+		return 0, fmt.Errorf("failed to detect user communities: %w", err)
+	}
+	return count, nil
+}
+
+// StoredSimilarUsers returns the SIMILAR_TO links UpdateUserSimilarities
+// wrote for a user, best first
+func (r *Repository) StoredSimilarUsers(ctx context.Context, userID uuid.UUID, limit int) ([]models.SimilarUser, error) {
+	if !r.available() {
+		return nil, ErrUnavailable
+	}
+
+	records, err := r.read(ctx, `
+		MATCH (u:User {id: $user_id})-[s:SIMILAR_TO]-(similar:User)
+		RETURN similar.id as user_id, s.score as similarity_score, s.basis as basis, s.shared_items as shared_items
+		ORDER BY s.score DESC
+		LIMIT $limit`,
+		map[string]interface{}{"user_id": userID.String(), "limit": limit})
+	if err != nil {
+		return nil, err
+	}
+
+	similarUsers := make([]models.SimilarUser, 0, len(records))
+	for _, record := range records {
+		values := record.AsMap()
+		similarID, err := asUUID(values["user_id"])
+		if err != nil {
+			continue
+		}
+		similarUsers = append(similarUsers, models.SimilarUser{
+			UserID:          similarID,
+			SimilarityScore: asFloat(values["similarity_score"]),
+			Basis:           asString(values["basis"]),
+			SharedItems:     asInt(values["shared_items"]),
+		})
+	}
+	return similarUsers, nil
+}
+
+// CorrelatedUsers computes the users whose ratings correlate with the user's
+// (Pearson, at least three co-rated items) at or above threshold, best first
+func (r *Repository) CorrelatedUsers(ctx context.Context, userID uuid.UUID, threshold float64, limit int) ([]models.SimilarUser, error) {
+	if !r.available() {
+		return nil, ErrUnavailable
+	}
+
+	records, err := r.read(ctx, `
+		MATCH (u1:User {id: $userId})-[r1:RATED]->(item:Content)<-[r2:RATED]-(u2:User)
+		WHERE u1 <> u2 AND r1.rating IS NOT NULL AND r2.rating IS NOT NULL
+		WITH u1, u2, collect({rating1: r1.rating, rating2: r2.rating}) AS shared_ratings
+		WHERE size(shared_ratings) >= 3
+		WITH u1, u2, shared_ratings,
+			 reduce(sum = 0.0, rating IN shared_ratings | sum + rating.rating1) / size(shared_ratings) AS avg1,
+			 reduce(sum = 0.0, rating IN shared_ratings | sum + rating.rating2) / size(shared_ratings) AS avg2
+		WITH u1, u2, shared_ratings, avg1, avg2,
+			 reduce(num = 0.0, rating IN shared_ratings | num + (rating.rating1 - avg1) * (rating.rating2 - avg2)) AS numerator,
+			 sqrt(reduce(sum = 0.0, rating IN shared_ratings | sum + (rating.rating1 - avg1)^2)) AS denom1,
+			 sqrt(reduce(sum = 0.0, rating IN shared_ratings | sum + (rating.rating2 - avg2)^2)) AS denom2
+		WITH u2, shared_ratings,
+			 CASE WHEN denom1 * denom2 = 0 THEN 0 ELSE numerator / (denom1 * denom2) END AS correlation
+		WHERE correlation >= $threshold
+		RETURN u2.id AS user_id, correlation AS similarity_score, size(shared_ratings) AS shared_items
+		ORDER BY correlation DESC
+		LIMIT $limit`,
+		map[string]interface{}{"userId": userID.String(), "threshold": threshold, "limit": limit})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]models.SimilarUser, 0, len(records))
+	for _, record := range records {
+		values := record.AsMap()
+		similarID, err := asUUID(values["user_id"])
+		if err != nil {
+			continue
+		}
+		users = append(users, models.SimilarUser{
+			UserID:          similarID,
+			SimilarityScore: asFloat(values["similarity_score"]),
+			Basis:           "pearson_correlation",
+			SharedItems:     asInt(values["shared_items"]),
+		})
+	}
+	return users, nil
+}
+
+// RatingsByUsers returns the ratings the given users gave to active items the
+// target user has not rated
+func (r *Repository) RatingsByUsers(ctx context.Context, userIDs []uuid.UUID, target uuid.UUID, limit int) ([]ItemRatings, error) {
+	if !r.available() {
+		return nil, ErrUnavailable
+	}
+
+	records, err := r.read(ctx, `
+		MATCH (u:User)-[r:RATED]->(item:Content)
+		WHERE u.id IN $userIds AND r.rating IS NOT NULL AND coalesce(item.active, true)
+			AND NOT EXISTS {
+				MATCH (:User {id: $targetUserId})-[:RATED]->(item)
+			}
+		WITH item, collect({user_id: u.id, rating: r.rating}) AS ratings
+		RETURN item.id AS item_id, ratings
+		LIMIT $limit`,
+		map[string]interface{}{"userIds": uuidStrings(userIDs), "targetUserId": target.String(), "limit": limit})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]ItemRatings, 0, len(records))
+	for _, record := range records {
+		values := record.AsMap()
+		itemID, err := asUUID(values["item_id"])
+		if err != nil {
+			continue
+		}
+		item := ItemRatings{ItemID: itemID}
+		ratings, _ := values["ratings"].([]interface{})
+		for _, entry := range ratings {
+			rating, _ := entry.(map[string]interface{})
+			raterID, err := asUUID(rating["user_id"])
+			if err != nil {
+				continue
+			}
+			item.Ratings = append(item.Ratings, UserRating{UserID: raterID, Rating: asFloat(rating["rating"])})
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// PersonalizedPageRank runs PageRank from the user over the engagement graph of
+// the user and their SIMILAR_TO neighbours, returning the best active items
+func (r *Repository) PersonalizedPageRank(ctx context.Context, userID uuid.UUID, limit int) ([]ItemScore, error) {
+	if !r.available() {
+		return nil, ErrUnavailable
+	}
+
+	// Concurrent requests for the same user must not share a projection
+	graphName := "user-centric-" + uuid.NewString()
+	err := r.write(ctx, `
+		CALL gds.graph.project.cypher(
+			$graphName,
+			'MATCH (n) WHERE n:User OR n:Content RETURN id(n) AS id, labels(n) AS labels',
+			'MATCH (u:User)-[r:`+engagement+`]->(c:Content)
+			 WHERE u.id = $userId OR
+				   u.id IN [(u2:User)-[:SIMILAR_TO]-(:User {id: $userId}) | u2.id][0..50]
+			 RETURN id(u) AS source, id(c) AS target,
+					CASE type(r)
+						WHEN "RATED" THEN coalesce(r.rating, 3.0) / 5.0
+						WHEN "SHARED" THEN 0.8
+						WHEN "VIEWED" THEN coalesce(r.confidence, 0.5)
+						ELSE 0.3
+					END AS weight',
+			{parameters: {userId: $userId}}
+		) YIELD graphName
+		RETURN graphName`,
+		map[string]interface{}{"graphName": graphName, "userId": userID.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create graph projection: %w", err)
+	}
+	defer func() {
+		if err := r.write(context.Background(), `CALL gds.graph.drop($graphName, false) YIELD graphName RETURN graphName`,
+			map[string]interface{}{"graphName": graphName}); err != nil {
+			r.logger.WithError(err).WithField("graph_name", graphName).Warn("Failed to cleanup graph projection")
+		}
+	}()
+
+	records, err := r.read(ctx, `
+		MATCH (source:User {id: $userId})
+		CALL gds.pageRank.stream($graphName, {
+			dampingFactor: 0.85,
+			maxIterations: 20,
+			tolerance: 0.0001,
+			relationshipWeightProperty: 'weight',
+			sourceNodes: [source]
+		})
+		YIELD nodeId, score
+		WITH gds.util.asNode(nodeId) AS n, score
+		WHERE n:Content AND coalesce(n.active, true)
+		RETURN n.id AS item_id, score
+		ORDER BY score DESC
+		LIMIT $limit`,
+		map[string]interface{}{"graphName": graphName, "userId": userID.String(), "limit": limit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run PageRank: %w", err)
+	}
+	return itemScores(records), nil
+}
+
+// UserCommunities returns the community UpdateCommunities assigned the user,
+// or none if the user has no similarity links yet
+func (r *Repository) UserCommunities(ctx context.Context, userID uuid.UUID) ([]int, error) {
+	if !r.available() {
+		return nil, ErrUnavailable
+	}
+
+	records, err := r.read(ctx, `
+		MATCH (u:User {id: $userId}) WHERE u.community IS NOT NULL
+		RETURN u.community AS community`,
+		map[string]interface{}{"userId": userID.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	communities := make([]int, 0, len(records))
+	for _, record := range records {
+		community, _ := record.Get("community")
+		communities = append(communities, asInt(community))
+	}
+	return communities, nil
+}
+
+// CommunityItemSimilarities scores items by their Jaccard similarity to items
+// engaged with by users of the given communities. No communities means no
+// community restriction.
+func (r *Repository) CommunityItemSimilarities(ctx context.Context, communities []int) (map[uuid.UUID]float64, error) {
+	if !r.available() {
+		return nil, ErrUnavailable
+	}
+
+	records, err := r.read(ctx, `
+		MATCH (u:User)-[:`+engagement+`]->(item1:Content)
+		WHERE size($communities) = 0 OR u.community IN $communities
+		MATCH (item1)<-[:`+engagement+`]-(u2:User)-[:`+engagement+`]->(item2:Content)
+		WHERE item1 <> item2 AND coalesce(item2.active, true)
+			AND (size($communities) = 0 OR u2.community IN $communities)
+		WITH item1, item2, count(DISTINCT u2) AS shared_users
+		WHERE shared_users >= 3
+		MATCH (item1)<-[:`+engagement+`]-(all_users1:User)
+		MATCH (item2)<-[:`+engagement+`]-(all_users2:User)
+		WITH item1, item2, shared_users,
+			 count(DISTINCT all_users1) AS total_users1,
+			 count(DISTINCT all_users2) AS total_users2
+		WITH item1, item2,
+			 toFloat(shared_users) / (total_users1 + total_users2 - shared_users) AS score
+		WHERE score > 0.1
+		RETURN item2.id AS item_id, score
+		ORDER BY score DESC
+		LIMIT 100`,
+		map[string]interface{}{"communities": communities})
+	if err != nil {
+		return nil, err
+	}
+	return scoreMap(itemScores(records)), nil
+}
+
+// PropagatedScores follows the user's high ratings (4 and up) through users of
+// the given communities to the other items they rated highly
+func (r *Repository) PropagatedScores(ctx context.Context, userID uuid.UUID, communities []int, limit int) (map[uuid.UUID]float64, error) {
+	if !r.available() {
+		return nil, ErrUnavailable
+	}
+
+	records, err := r.read(ctx, `
+		MATCH (source:User {id: $userId})-[r1:RATED]->(item:Content)
+		WHERE r1.rating >= 4.0
+		MATCH (item)<-[r2:RATED]-(intermediate:User)-[r3:RATED]->(target:Content)
+		WHERE (size($communities) = 0 OR intermediate.community IN $communities)
+			AND r2.rating >= 4.0
+			AND r3.rating >= 4.0
+			AND target <> item
+			AND coalesce(target.active, true)
+		WITH target,
+			 count(DISTINCT intermediate) AS propagation_strength,
+			 avg(r3.rating) AS avg_rating
+		WHERE propagation_strength >= 2
+		RETURN target.id AS item_id,
+			   (propagation_strength * avg_rating / 5.0) AS score
+		ORDER BY score DESC
+		LIMIT $limit`,
+		map[string]interface{}{"userId": userID.String(), "communities": communities, "limit": limit})
+	if err != nil {
+		return nil, err
+	}
+	return scoreMap(itemScores(records)), nil
+}
+
+// UserExists reports whether the graph has a node for the user
+func (r *Repository) UserExists(ctx context.Context, userID uuid.UUID) (bool, error) {
+	if !r.available() {
+		return false, ErrUnavailable
+	}
+
+	records, err := r.read(ctx, `MATCH (u:User {id: $user_id}) RETURN COUNT(u) as count`,
+		map[string]interface{}{"user_id": userID.String()})
+	if err != nil {
+		return false, err
+	}
+	if len(records) == 0 {
+		return false, nil
+	}
+	count, _ := records[0].Get("count")
+	return asInt(count) > 0, nil
+}
+
+// DeleteUser removes the user's node with all of its relationships and returns
+// how many relationships went with it. Deleting a missing user is a no-op.
+func (r *Repository) DeleteUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	if !r.available() {
+		return 0, ErrUnavailable
+	}
+
+	return r.writeCount(ctx, `
+		OPTIONAL MATCH (u:User {id: $user_id})
+		OPTIONAL MATCH (u)-[r]-()
+		WITH collect(DISTINCT u) AS users, count(r) AS count
+		FOREACH (u IN users | DETACH DELETE u)
+		RETURN count`,
+		map[string]interface{}{"user_id": userID.String()})
+}
+
+// writeCount runs a write returning a single count column
+func (r *Repository) writeCount(ctx context.Context, cypher string, params map[string]interface{}) (int64, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	count, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := tx.Run(ctx, cypher, params)
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		count, _ := record.Get("count")
+		return int64(asInt(count)), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count.(int64), nil
+}
+
+// itemScores reads item_id and score columns, skipping rows without a valid ID
+func itemScores(records []*neo4j.Record) []ItemScore {
+	scores := make([]ItemScore, 0, len(records))
+	for _, record := range records {
+		values := record.AsMap()
+		itemID, err := asUUID(values["item_id"])
+		if err != nil {
+			continue
+		}
+		scores = append(scores, ItemScore{ItemID: itemID, Score: asFloat(values["score"])})
+	}
+	return scores
+}
+
+func scoreMap(scores []ItemScore) map[uuid.UUID]float64 {
+	m := make(map[uuid.UUID]float64, len(scores))
+	for _, score := range scores {
+		m[score.ItemID] = score.Score
+	}
+	return m
+}
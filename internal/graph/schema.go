@@ -0,0 +1,226 @@
+// Package graph owns the Neo4j schema: node labels, property names,
+// relationship types, constraints and indexes, the migrations that create
+// them, and the typed reads and writes the services make against the graph.
+// Cypher belongs in this package only.
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Node labels
+const (
+	LabelUser            = "User"
+	LabelContent         = "Content"
+	LabelCategory        = "Category"
+	LabelSchemaMigration = "SchemaMigration"
+)
+
+// Node properties. User and Content nodes are keyed by the UUID string of the
+// Postgres row in PropID; Category nodes by the category ID.
+const (
+	PropID         = "id"
+	PropName       = "name"
+	PropPath       = "path"
+	PropDepth      = "depth"
+	PropType       = "type"
+	PropCategories = "categories"
+	PropActive     = "active"
+	PropCommunity  = "community" // Louvain community of a user, written by UpdateCommunities
+	PropUpdatedAt  = "updated_at"
+)
+
+// Relationship types
+const (
+	RelRated          = "RATED"           // (:User)->(:Content), with a rating
+	RelViewed         = "VIEWED"          // (:User)->(:Content), views and clicks
+	RelShared         = "SHARED"          // (:User)->(:Content)
+	RelInteractedWith = "INTERACTED_WITH" // (:User)->(:Content), any other interaction
+	RelSimilarTo      = "SIMILAR_TO"      // (:User)-(:User) and (:Content)-(:Content), with a score
+	RelInCategory     = "IN_CATEGORY"     // (:Content)->(:Category)
+	RelSubcategoryOf  = "SUBCATEGORY_OF"  // (:Category)->(:Category)
+)
+
+// Relationship properties
+const (
+	PropRating          = "rating" // 1-5; likes and dislikes carry an implied rating
+	PropInteractionType = "interaction_type"
+	PropTimestamp       = "timestamp" // Unix seconds of the interaction
+	PropConfidence      = "confidence"
+	PropDuration        = "duration" // Seconds
+	PropScore           = "score"
+	PropComputedAt      = "computed_at"
+)
+
+// Ratings implied by likes and dislikes, so collaborative filtering can treat
+// them like explicit ratings
+const (
+	LikeRating    = 5.0
+	DislikeRating = 1.0
+)
+
+// engagement matches every (:User)->(:Content) relationship type
+const engagement = RelRated + "|" + RelViewed + "|" + RelShared + "|" + RelInteractedWith
+
+// interactionRelationships are the relationship types RecordInteractions writes
+var interactionRelationships = map[string]bool{
+	RelRated:          true,
+	RelViewed:         true,
+	RelShared:         true,
+	RelInteractedWith: true,
+}
+
+// Interaction is one (:User)-[Type]->(:Content) relationship. Writing it again
+// overwrites its properties, so replays are harmless.
+type Interaction struct {
+	UserID     uuid.UUID
+	ItemID     uuid.UUID
+	Type       string // One of RATED, VIEWED, SHARED or INTERACTED_WITH
+	Properties map[string]interface{}
+}
+
+// ContentNode is the graph copy of a content item
+type ContentNode struct {
+	ID         uuid.UUID
+	Type       string
+	Categories []string // Category names; linked with IN_CATEGORY when the category exists
+	Active     bool
+}
+
+// CategoryNode is the graph copy of a taxonomy category
+type CategoryNode struct {
+	ID       string
+	Name     string
+	Path     []string
+	Depth    int
+	ParentID string // Empty for root categories
+}
+
+// ItemScore is a content item scored by a graph algorithm
+type ItemScore struct {
+	ItemID uuid.UUID
+	Score  float64
+}
+
+// UserRating is one user's rating of an item
+type UserRating struct {
+	UserID uuid.UUID
+	Rating float64
+}
+
+// ItemRatings holds the ratings an item received from a set of users
+type ItemRatings struct {
+	ItemID  uuid.UUID
+	Ratings []UserRating
+}
+
+// RelationshipForInteraction returns the relationship type recording an
+// interaction type
+func RelationshipForInteraction(interactionType string) string {
+	switch interactionType {
+	case "rating", "like", "dislike":
+		return RelRated
+	case "view", "click":
+		return RelViewed
+	case "share":
+		return RelShared
+	default:
+		return RelInteractedWith
+	}
+}
+
+// ImpliedRating returns the rating stored on the RATED relationship of an
+// interaction: the explicit value of ratings, and a fixed rating for likes and
+// dislikes
+func ImpliedRating(interactionType string, value *float64) (float64, bool) {
+	switch interactionType {
+	case "rating":
+		if value == nil {
+			return 0, false
+		}
+		return *value, true
+	case "like":
+		return LikeRating, true
+	case "dislike":
+		return DislikeRating, true
+	default:
+		return 0, false
+	}
+}
+
+// groupInteractions splits interactions by relationship type, since Cypher
+// cannot take a relationship type as a parameter. Unknown types are recorded
+// as INTERACTED_WITH. Types are returned in first-seen order.
+func groupInteractions(batch []Interaction) ([]string, map[string][]map[string]interface{}) {
+	var types []string
+	groups := make(map[string][]map[string]interface{})
+	for _, interaction := range batch {
+		relType := strings.ToUpper(interaction.Type)
+		if !interactionRelationships[relType] {
+			relType = RelInteractedWith
+		}
+		if _, ok := groups[relType]; !ok {
+			types = append(types, relType)
+		}
+
+		properties := interaction.Properties
+		if properties == nil {
+			properties = map[string]interface{}{}
+		}
+		groups[relType] = append(groups[relType], map[string]interface{}{
+			"user_id":    interaction.UserID.String(),
+			"item_id":    interaction.ItemID.String(),
+			"properties": properties,
+		})
+	}
+	return types, groups
+}
+
+// Record value conversions. Neo4j returns integers as int64 and may return
+// whole-number floats either way, and missing properties as nil.
+
+func asString(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+func asFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	default:
+		return 0
+	}
+}
+
+func asInt(value interface{}) int {
+	switch v := value.(type) {
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+func asUUID(value interface{}) (uuid.UUID, error) {
+	s, ok := value.(string)
+	if !ok {
+		return uuid.Nil, fmt.Errorf("expected a UUID string, got %T", value)
+	}
+	return uuid.Parse(s)
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	return strs
+}
@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelationshipForInteraction(t *testing.T) {
+	tests := map[string]string{
+		"rating":  RelRated,
+		"like":    RelRated,
+		"dislike": RelRated,
+		"view":    RelViewed,
+		"click":   RelViewed,
+		"share":   RelShared,
+		"search":  RelInteractedWith,
+		"unknown": RelInteractedWith,
+	}
+	for interactionType, expected := range tests {
+		assert.Equal(t, expected, RelationshipForInteraction(interactionType), interactionType)
+	}
+}
+
+func TestImpliedRating(t *testing.T) {
+	value := 3.5
+
+	rating, ok := ImpliedRating("rating", &value)
+	assert.True(t, ok)
+	assert.Equal(t, 3.5, rating)
+
+	_, ok = ImpliedRating("rating", nil)
+	assert.False(t, ok)
+
+	rating, ok = ImpliedRating("like", nil)
+	assert.True(t, ok)
+	assert.Equal(t, LikeRating, rating)
+
+	rating, ok = ImpliedRating("dislike", &value)
+	assert.True(t, ok)
+	assert.Equal(t, DislikeRating, rating)
+
+	_, ok = ImpliedRating("view", &value)
+	assert.False(t, ok)
+}
+
+func TestGroupInteractions(t *testing.T) {
+	user, a, b := uuid.New(), uuid.New(), uuid.New()
+
+	types, groups := groupInteractions([]Interaction{
+		{UserID: user, ItemID: a, Type: RelViewed, Properties: map[string]interface{}{"duration": 30}},
+		{UserID: user, ItemID: b, Type: "rated"},
+		{UserID: user, ItemID: b, Type: "DELETE r"},
+		{UserID: user, ItemID: a, Type: RelViewed},
+	})
+
+	assert.Equal(t, []string{RelViewed, RelRated, RelInteractedWith}, types)
+	require.Len(t, groups[RelViewed], 2)
+	assert.Equal(t, user.String(), groups[RelViewed][0]["user_id"])
+	assert.Equal(t, a.String(), groups[RelViewed][0]["item_id"])
+	assert.Equal(t, map[string]interface{}{"duration": 30}, groups[RelViewed][0]["properties"])
+	assert.Equal(t, map[string]interface{}{}, groups[RelRated][0]["properties"])
+	assert.Len(t, groups[RelInteractedWith], 1)
+}
+
+func TestRepository_Unavailable(t *testing.T) {
+	ctx := context.Background()
+	var repo *Repository
+
+	assert.NoError(t, repo.Migrate(ctx))
+	assert.NoError(t, repo.RecordInteractions(ctx, []Interaction{{UserID: uuid.New(), ItemID: uuid.New(), Type: RelRated}}))
+	assert.NoError(t, repo.SetContentActive(ctx, uuid.New(), false))
+
+	_, err := repo.StoredSimilarUsers(ctx, uuid.New(), 10)
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	_, err = NewRepository(nil, nil).PersonalizedPageRank(ctx, uuid.New(), 10)
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	_, err = repo.DeleteUser(ctx, uuid.New())
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,10 +20,12 @@ import (
 )
 
 type ContentHandler struct {
-	messageBus *messaging.MessageBus
-	jobManager *services.JobManager
-	validator  *validator.Validate
-	logger     *logrus.Logger
+	messageBus   *messaging.MessageBus
+	jobManager   *services.JobManager
+	bulkImporter *services.BulkImporter
+	pipeline     *services.PipelineOrchestrator
+	validator    *validator.Validate
+	logger       *logrus.Logger
 }
 
 type ContentResponse struct {
@@ -29,12 +35,24 @@ type ContentResponse struct {
 	Message       string    `json:"message"`
 }
 
-func NewContentHandler(messageBus *messaging.MessageBus, jobManager *services.JobManager, logger *logrus.Logger) *ContentHandler {
+const (
+	// IdempotencyKeyHeader lets clients safely retry ingestion requests
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// CallbackURLHeader names a URL that receives a signed webhook when the job finishes
+	CallbackURLHeader = "X-Callback-URL"
+
+	defaultJobItemsLimit = 100
+	maxJobItemsLimit     = 1000
+)
+
+func NewContentHandler(messageBus *messaging.MessageBus, jobManager *services.JobManager, bulkImporter *services.BulkImporter, pipeline *services.PipelineOrchestrator, logger *logrus.Logger) *ContentHandler {
 	return &ContentHandler{
-		messageBus: messageBus,
-		jobManager: jobManager,
-		validator:  validator.New(),
-		logger:     logger,
+		messageBus:   messageBus,
+		jobManager:   jobManager,
+		bulkImporter: bulkImporter,
+		pipeline:     pipeline,
+		validator:    validator.New(),
+		logger:       logger,
 	}
 }
 
@@ -66,8 +84,32 @@ func (h *ContentHandler) Create(c *gin.Context) {
 		return
 	}
 
+	// Content type and metadata schema
+	if fieldErrors := h.pipeline.ValidateContent(request); len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_FAILED",
+				"message": "Content validation failed",
+				"details": services.FieldErrorsMessage(fieldErrors).Error(),
+				"fields":  fieldErrors,
+			},
+		})
+		return
+	}
+
+	jobDetails, ok := callbackJobDetails(c)
+	if !ok {
+		return
+	}
+
+	idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+	requestHash := hashIngestionRequest(request)
+	if idempotencyKey != "" && h.replayIdempotentRequest(c, idempotencyKey, requestHash) {
+		return
+	}
+
 	// Create job for tracking
-	job, err := h.jobManager.CreateJob(c.Request.Context(), 1, "single_content_ingestion")
+	job, err := h.jobManager.CreateJobWithDetails(c.Request.Context(), 1, "single_content_ingestion", jobDetails)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to create job")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -79,11 +121,17 @@ func (h *ContentHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if idempotencyKey != "" && !h.bindIdempotencyKey(c, idempotencyKey, requestHash, job) {
+		return
+	}
+
 	// Publish to Kafka for async processing
 	processingHints := map[string]interface{}{
 		"source":    "api",
 		"user_id":   c.GetString("user_id"), // From JWT middleware
 		"timestamp": time.Now(),
+
+		services.HintItemIndex: 0,
 	}
 
 	if err := h.messageBus.PublishContentIngestion(job.JobID, request, processingHints); err != nil {
@@ -179,10 +227,33 @@ func (h *ContentHandler) CreateBatch(c *gin.Context) {
 			})
 			return
 		}
+
+		if fieldErrors := h.pipeline.ValidateContent(item); len(fieldErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "ITEM_VALIDATION_FAILED",
+					"message": fmt.Sprintf("Item at index %d failed validation", i),
+					"details": services.FieldErrorsMessage(fieldErrors).Error(),
+					"fields":  fieldErrors,
+				},
+			})
+			return
+		}
+	}
+
+	jobDetails, ok := callbackJobDetails(c)
+	if !ok {
+		return
+	}
+
+	idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+	requestHash := hashIngestionRequest(request)
+	if idempotencyKey != "" && h.replayIdempotentRequest(c, idempotencyKey, requestHash) {
+		return
 	}
 
 	// Create job for tracking batch processing
-	job, err := h.jobManager.CreateJob(c.Request.Context(), len(request.Items), "batch_content_ingestion")
+	job, err := h.jobManager.CreateJobWithDetails(c.Request.Context(), len(request.Items), "batch_content_ingestion", jobDetails)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to create batch job")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -194,8 +265,13 @@ func (h *ContentHandler) CreateBatch(c *gin.Context) {
 		return
 	}
 
+	if idempotencyKey != "" && !h.bindIdempotencyKey(c, idempotencyKey, requestHash, job) {
+		return
+	}
+
 	// Publish each item to Kafka for async processing
 	successCount := 0
+	var publishErrors []services.ItemError
 	processingHints := map[string]interface{}{
 		"source":     "api_batch",
 		"user_id":    c.GetString("user_id"),
@@ -209,13 +285,19 @@ func (h *ContentHandler) CreateBatch(c *gin.Context) {
 		for k, v := range processingHints {
 			itemHints[k] = v
 		}
-		itemHints["batch_index"] = i
+		itemHints[services.HintItemIndex] = i
 
 		if err := h.messageBus.PublishContentIngestion(job.JobID, item, itemHints); err != nil {
 			h.logger.WithError(err).WithFields(logrus.Fields{
 				"job_id":     job.JobID,
 				"item_index": i,
 			}).Error("Failed to publish batch item to message bus")
+
+			itemErr := services.ItemError{Row: i + 1, Stage: "publish", Message: err.Error()}
+			if item.ExternalID != nil {
+				itemErr.ExternalID = *item.ExternalID
+			}
+			publishErrors = append(publishErrors, itemErr)
 			continue
 		}
 		successCount++
@@ -236,6 +318,11 @@ func (h *ContentHandler) CreateBatch(c *gin.Context) {
 	}
 
 	if successCount < len(request.Items) {
+		// Count unqueued items as failed so the job can still complete
+		if err := h.jobManager.FailItems(c.Request.Context(), job.JobID, publishErrors); err != nil {
+			h.logger.WithError(err).WithField("job_id", job.JobID).Warn("Failed to record unqueued batch items")
+		}
+
 		h.logger.WithFields(logrus.Fields{
 			"job_id":        job.JobID,
 			"success_count": successCount,
@@ -284,19 +371,164 @@ func (h *ContentHandler) GetJobStatus(c *gin.Context) {
 		return
 	}
 
-	// Convert to API response format
-	response := models.ContentJobStatus{
-		JobID:          job.JobID,
-		Status:         job.Status,
-		Progress:       job.Progress,
-		TotalItems:     job.TotalItems,
-		ProcessedItems: job.ProcessedItems,
-		FailedItems:    job.FailedItems,
-		EstimatedTime:  job.EstimatedTime,
-		ErrorMessage:   job.ErrorMessage,
-		CreatedAt:      job.CreatedAt,
-		UpdatedAt:      job.UpdatedAt,
+	status := job.ToStatus()
+	if c.Query("include") == "items" {
+		offset, limit, ok := parseJobItemsPage(c)
+		if !ok {
+			return
+		}
+
+		outcomes, err := h.jobManager.GetItemOutcomes(c.Request.Context(), jobID)
+		if err != nil {
+			h.logger.WithError(err).WithField("job_id", jobID).Error("Failed to load job item outcomes")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":    "ITEM_OUTCOMES_FAILED",
+					"message": "Failed to load job item outcomes",
+				},
+			})
+			return
+		}
+
+		total := len(outcomes)
+		status.ItemsTotal = &total
+		status.Items = []models.ContentItemOutcome{}
+		if offset < total {
+			end := offset + limit
+			if end > total {
+				end = total
+			}
+			status.Items = outcomes[offset:end]
+		}
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// parseJobItemsPage reads items_offset and items_limit for ?include=items
+func parseJobItemsPage(c *gin.Context) (int, int, bool) {
+	offset, err := strconv.Atoi(c.DefaultQuery("items_offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_ITEMS_OFFSET",
+				"message": "items_offset must be a non-negative integer",
+			},
+		})
+		return 0, 0, false
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("items_limit", strconv.Itoa(defaultJobItemsLimit)))
+	if err != nil || limit < 1 || limit > maxJobItemsLimit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_ITEMS_LIMIT",
+				"message": fmt.Sprintf("items_limit must be between 1 and %d", maxJobItemsLimit),
+			},
+		})
+		return 0, 0, false
+	}
+
+	return offset, limit, true
+}
+
+// callbackJobDetails validates the optional X-Callback-URL header and returns the
+// job details that carry it. It returns false if an error response was written.
+func callbackJobDetails(c *gin.Context) (map[string]interface{}, bool) {
+	callbackURL := c.GetHeader(CallbackURLHeader)
+	if callbackURL == "" {
+		return nil, true
+	}
+	if err := services.ValidateWebhookURL(callbackURL); err != nil {
+		respondInvalidCallbackURL(c)
+		return nil, false
+	}
+	return map[string]interface{}{"webhook_url": callbackURL}, true
+}
+
+func respondInvalidCallbackURL(c *gin.Context) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": gin.H{
+			"code":    "INVALID_CALLBACK_URL",
+			"message": services.ErrInvalidWebhookURL.Error(),
+		},
+	})
+}
+
+// replayIdempotentRequest answers a retried request with the job created for the
+// original one. It returns true if a response was written.
+func (h *ContentHandler) replayIdempotentRequest(c *gin.Context, key, requestHash string) bool {
+	record, err := h.jobManager.GetIdempotencyRecord(c.Request.Context(), idempotencyScope(c), key)
+	if err != nil {
+		h.logger.WithError(err).WithField("idempotency_key", key).Warn("Failed to check idempotency key")
+		return false
 	}
+	if record == nil {
+		return false
+	}
+
+	if record.RequestHash != requestHash {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "IDEMPOTENCY_KEY_CONFLICT",
+				"message": "Idempotency key was already used with a different request body",
+			},
+		})
+		return true
+	}
+
+	response := ContentResponse{
+		JobID:   record.JobID,
+		Status:  services.JobStatusQueued,
+		Message: "Request already accepted",
+	}
+	if job, err := h.jobManager.GetJob(c.Request.Context(), record.JobID); err == nil {
+		response.Status = job.Status
+		response.EstimatedTime = job.EstimatedTime
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.JSON(http.StatusAccepted, response)
+	return true
+}
+
+// bindIdempotencyKey associates the key with a freshly created job. If a concurrent
+// request claimed the key first, the new job is cancelled and the winner replayed.
+func (h *ContentHandler) bindIdempotencyKey(c *gin.Context, key, requestHash string, job *services.JobProgress) bool {
+	_, bound, err := h.jobManager.BindIdempotencyKey(c.Request.Context(), idempotencyScope(c), key, requestHash, job.JobID)
+	if err != nil {
+		h.logger.WithError(err).WithField("idempotency_key", key).Warn("Failed to bind idempotency key")
+		return true
+	}
+	if bound {
+		return true
+	}
+
+	errorMsg := "Superseded by concurrent request with the same idempotency key"
+	h.jobManager.UpdateJobProgress(c.Request.Context(), job.JobID, 0, 0, services.JobStatusCancelled, &errorMsg)
+
+	h.replayIdempotentRequest(c, key, requestHash)
+	return false
+}
+
+// idempotencyScope names the caller an idempotency key belongs to: the user of a
+// token, otherwise the API key. API keys are hashed so they never appear in
+// Redis key names.
+func idempotencyScope(c *gin.Context) string {
+	if c.GetBool("user_verified") {
+		if userID, ok := c.Get("user_id"); ok {
+			return fmt.Sprintf("user:%v", userID)
+		}
+	}
+	if apiKey := c.GetString("api_key"); apiKey != "" {
+		sum := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(sum[:16])
+	}
+	return "anonymous"
+}
 
-	c.JSON(http.StatusOK, response)
+func hashIngestionRequest(request interface{}) string {
+	data, _ := json.Marshal(request)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
 }
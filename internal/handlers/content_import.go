@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/services"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// maxImportFormValueBytes caps non-file multipart fields such as the mapping
+const maxImportFormValueBytes = 64 * 1024
+
+// Import starts a bulk catalog import. It accepts a multipart upload (field "file"),
+// a raw NDJSON/CSV body, or a JSON body pointing at a local path or URL.
+func (h *ContentHandler) Import(c *gin.Context) {
+	switch c.ContentType() {
+	case "application/json":
+		h.importFromSource(c)
+	case "multipart/form-data":
+		h.importUpload(c)
+	case "application/x-ndjson", "application/jsonl", "text/csv":
+		h.importBody(c)
+	default:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": gin.H{
+				"code":    "UNSUPPORTED_MEDIA_TYPE",
+				"message": "Use multipart/form-data, application/x-ndjson, text/csv or application/json",
+			},
+		})
+	}
+}
+
+func (h *ContentHandler) importFromSource(c *gin.Context) {
+	var request models.ContentImportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_JSON",
+				"message": "Invalid JSON format",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	if !h.validateImportRequest(c, &request) {
+		return
+	}
+
+	job, err := h.bulkImporter.StartFromSource(c.Request.Context(), request)
+	if err != nil {
+		h.respondImportError(c, err)
+		return
+	}
+
+	h.respondImportStarted(c, job)
+}
+
+func (h *ContentHandler) importUpload(c *gin.Context) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_MULTIPART",
+				"message": "Invalid multipart upload",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	values := make(map[string]string)
+	var spoolPath, filename string
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			h.discardSpool(spoolPath)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "INVALID_MULTIPART",
+					"message": "Invalid multipart upload",
+					"details": err.Error(),
+				},
+			})
+			return
+		}
+
+		if part.FormName() == "file" && spoolPath == "" {
+			filename = part.FileName()
+			spoolPath, err = h.bulkImporter.Spool(part)
+			part.Close()
+			if err != nil {
+				h.respondImportError(c, err)
+				return
+			}
+			continue
+		}
+
+		value, _ := io.ReadAll(io.LimitReader(part, maxImportFormValueBytes))
+		values[part.FormName()] = string(value)
+		part.Close()
+	}
+
+	if spoolPath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "MISSING_FILE",
+				"message": "Multipart upload must include a \"file\" field",
+			},
+		})
+		return
+	}
+
+	request, err := parseImportOptions(func(key string) string { return values[key] })
+	if err != nil || !h.validateImportRequest(c, &request) {
+		h.discardSpool(spoolPath)
+		if err != nil {
+			h.respondImportError(c, err)
+		}
+		return
+	}
+
+	job, err := h.bulkImporter.StartSpooled(c.Request.Context(), spoolPath, filename, request)
+	if err != nil {
+		h.respondImportError(c, err)
+		return
+	}
+
+	h.respondImportStarted(c, job)
+}
+
+func (h *ContentHandler) importBody(c *gin.Context) {
+	request, err := parseImportOptions(c.Query)
+	if err != nil {
+		h.respondImportError(c, err)
+		return
+	}
+	if request.Format == "" {
+		request.Format = services.ImportFormatNDJSON
+		if c.ContentType() == "text/csv" {
+			request.Format = services.ImportFormatCSV
+		}
+	}
+	if !h.validateImportRequest(c, &request) {
+		return
+	}
+
+	spoolPath, err := h.bulkImporter.Spool(c.Request.Body)
+	if err != nil {
+		h.respondImportError(c, err)
+		return
+	}
+
+	job, err := h.bulkImporter.StartSpooled(c.Request.Context(), spoolPath, c.DefaultQuery("filename", "upload"), request)
+	if err != nil {
+		h.respondImportError(c, err)
+		return
+	}
+
+	h.respondImportStarted(c, job)
+}
+
+// GetJobErrors downloads the per-item error report of a job as NDJSON (default) or CSV
+func (h *ContentHandler) GetJobErrors(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_JOB_ID",
+				"message": "Invalid job ID format",
+			},
+		})
+		return
+	}
+
+	if _, err := h.jobManager.GetJob(c.Request.Context(), jobID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "JOB_NOT_FOUND",
+				"message": "Job not found",
+			},
+		})
+		return
+	}
+
+	itemErrors, err := h.jobManager.GetItemErrors(c.Request.Context(), jobID)
+	if err != nil {
+		h.logger.WithError(err).WithField("job_id", jobID).Error("Failed to load job error report")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "ERROR_REPORT_FAILED",
+				"message": "Failed to load job error report",
+			},
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", services.ImportFormatNDJSON)
+	switch format {
+	case services.ImportFormatCSV:
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=job-%s-errors.csv", jobID))
+		c.Header("Content-Type", "text/csv")
+		c.Status(http.StatusOK)
+		writeItemErrorsCSV(c.Writer, itemErrors)
+	case services.ImportFormatNDJSON:
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=job-%s-errors.ndjson", jobID))
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+		encoder := json.NewEncoder(c.Writer)
+		for _, itemErr := range itemErrors {
+			encoder.Encode(itemErr)
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_FORMAT",
+				"message": "format must be ndjson or csv",
+			},
+		})
+	}
+}
+
+func (h *ContentHandler) validateImportRequest(c *gin.Context, request *models.ContentImportRequest) bool {
+	if request.CallbackURL == "" {
+		request.CallbackURL = c.GetHeader(CallbackURLHeader)
+	}
+	if request.CallbackURL != "" && services.ValidateWebhookURL(request.CallbackURL) != nil {
+		respondInvalidCallbackURL(c)
+		return false
+	}
+
+	if err := h.validator.Struct(request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_FAILED",
+				"message": "Import request validation failed",
+				"details": err.Error(),
+			},
+		})
+		return false
+	}
+	return true
+}
+
+func (h *ContentHandler) respondImportStarted(c *gin.Context, job *services.JobProgress) {
+	h.logger.WithFields(logrus.Fields{
+		"job_id": job.JobID,
+		"source": job.Details["source"],
+	}).Info("Bulk import started")
+
+	c.JSON(http.StatusAccepted, ContentResponse{
+		JobID:   job.JobID,
+		Status:  job.Status,
+		Message: "Import started; track progress via the job status endpoint",
+	})
+}
+
+func (h *ContentHandler) respondImportError(c *gin.Context, err error) {
+	status, code := http.StatusInternalServerError, "IMPORT_START_FAILED"
+	switch {
+	case errors.Is(err, services.ErrImportTooLarge):
+		status, code = http.StatusRequestEntityTooLarge, "IMPORT_TOO_LARGE"
+	case errors.Is(err, services.ErrImportSourceNotAllowed):
+		status, code = http.StatusBadRequest, "IMPORT_SOURCE_NOT_ALLOWED"
+	case errors.Is(err, services.ErrImportFormatUnknown):
+		status, code = http.StatusBadRequest, "UNKNOWN_IMPORT_FORMAT"
+	case errors.Is(err, services.ErrImportMappingInvalid):
+		status, code = http.StatusBadRequest, "INVALID_IMPORT_MAPPING"
+	default:
+		h.logger.WithError(err).Error("Failed to start bulk import")
+	}
+
+	c.JSON(status, gin.H{
+		"error": gin.H{
+			"code":    code,
+			"message": err.Error(),
+		},
+	})
+}
+
+func (h *ContentHandler) discardSpool(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil {
+		h.logger.WithError(err).WithField("path", path).Warn("Failed to remove import spool file")
+	}
+}
+
+// parseImportOptions reads import options from form values or query parameters.
+// The mapping is passed as a JSON object string.
+func parseImportOptions(value func(string) string) (models.ContentImportRequest, error) {
+	request := models.ContentImportRequest{
+		Format:        value("format"),
+		DefaultType:   value("default_type"),
+		ListSeparator: value("list_separator"),
+		Delimiter:     value("delimiter"),
+		CallbackURL:   value("callback_url"),
+	}
+
+	if mapping := value("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &request.Mapping); err != nil {
+			return request, fmt.Errorf("%w: mapping must be a JSON object of strings", services.ErrImportMappingInvalid)
+		}
+	}
+
+	return request, nil
+}
+
+func writeItemErrorsCSV(w io.Writer, itemErrors []services.ItemError) {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"row", "external_id", "stage", "message"})
+	for _, itemErr := range itemErrors {
+		writer.Write([]string{strconv.Itoa(itemErr.Row), itemErr.ExternalID, itemErr.Stage, itemErr.Message})
+	}
+	writer.Flush()
+}
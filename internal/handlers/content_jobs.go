@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/temcen/pirex/internal/middleware"
+	"github.com/temcen/pirex/internal/services"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+var jobStatuses = map[string]bool{
+	services.JobStatusQueued:     true,
+	services.JobStatusProcessing: true,
+	services.JobStatusCompleted:  true,
+	services.JobStatusFailed:     true,
+	services.JobStatusCancelled:  true,
+}
+
+// ListJobs lists ingestion jobs newest first, filtered by status, type and creation date.
+// User deletion jobs are listed only for admins.
+func (h *ContentHandler) ListJobs(c *gin.Context) {
+	filter := services.JobListFilter{
+		Status:  c.Query("status"),
+		JobType: c.Query("type"),
+		Cursor:  c.Query("cursor"),
+		Limit:   50,
+
+		IncludeAdminJobs: middleware.IsAdmin(c),
+	}
+
+	if filter.Status != "" && !jobStatuses[filter.Status] {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_STATUS",
+				"message": "status must be one of queued, processing, completed, failed, cancelled",
+			},
+		})
+		return
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "INVALID_LIMIT",
+					"message": "limit must be between 1 and 100",
+				},
+			})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	var err error
+	if filter.CreatedAfter, err = parseJobDate(c.Query("created_after")); err != nil {
+		respondInvalidJobDate(c, "created_after")
+		return
+	}
+	if filter.CreatedBefore, err = parseJobDate(c.Query("created_before")); err != nil {
+		respondInvalidJobDate(c, "created_before")
+		return
+	}
+
+	jobs, nextCursor, err := h.jobManager.ListJobs(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidJobCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "INVALID_CURSOR",
+					"message": "Invalid pagination cursor",
+				},
+			})
+			return
+		}
+
+		h.logger.WithError(err).Error("Failed to list jobs")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "QUERY_FAILED",
+				"message": "Failed to list jobs",
+			},
+		})
+		return
+	}
+
+	response := models.ContentJobList{
+		Jobs: make([]models.ContentJobStatus, 0, len(jobs)),
+		Pagination: models.PaginationResponse{
+			NextCursor: nextCursor,
+			HasMore:    nextCursor != "",
+		},
+	}
+	for _, job := range jobs {
+		response.Jobs = append(response.Jobs, job.ToStatus())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CancelJob cancels a queued or processing job. Items not yet processed are skipped.
+func (h *ContentHandler) CancelJob(c *gin.Context) {
+	jobID, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.jobManager.CancelJob(c.Request.Context(), jobID)
+	if err != nil {
+		h.respondJobError(c, jobID, job, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job.ToStatus())
+}
+
+// RetryFailedItems re-publishes the failed items of a completed or failed job
+func (h *ContentHandler) RetryFailedItems(c *gin.Context) {
+	jobID, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, retried, err := h.pipeline.RetryFailedItems(c.Request.Context(), jobID)
+	if err != nil {
+		h.respondJobError(c, jobID, job, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job":           job.ToStatus(),
+		"retried_items": retried,
+	})
+}
+
+func (h *ContentHandler) respondJobError(c *gin.Context, jobID uuid.UUID, job *services.JobProgress, err error) {
+	var status int
+	var code string
+
+	switch {
+	case errors.Is(err, services.ErrJobNotFound):
+		status, code = http.StatusNotFound, "JOB_NOT_FOUND"
+	case errors.Is(err, services.ErrJobNotCancellable):
+		status, code = http.StatusConflict, "JOB_NOT_CANCELLABLE"
+	case errors.Is(err, services.ErrJobNotRetryable):
+		status, code = http.StatusConflict, "JOB_NOT_RETRYABLE"
+	case errors.Is(err, services.ErrNoRetryableItems):
+		status, code = http.StatusConflict, "NO_RETRYABLE_ITEMS"
+	default:
+		h.logger.WithError(err).WithField("job_id", jobID).Error("Job operation failed")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "JOB_OPERATION_FAILED",
+				"message": "Job operation failed",
+			},
+		})
+		return
+	}
+
+	body := gin.H{
+		"code":    code,
+		"message": err.Error(),
+	}
+	if job != nil && status == http.StatusConflict {
+		body["details"] = gin.H{"status": job.Status}
+	}
+	c.JSON(status, gin.H{"error": body})
+}
+
+func parseJobID(c *gin.Context) (uuid.UUID, bool) {
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_JOB_ID",
+				"message": "Invalid job ID format",
+			},
+		})
+		return uuid.Nil, false
+	}
+	return jobID, true
+}
+
+// parseJobDate accepts RFC 3339 timestamps or plain dates; empty means unset
+func parseJobDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+func respondInvalidJobDate(c *gin.Context, param string) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": gin.H{
+			"code":    "INVALID_DATE",
+			"message": param + " must be an RFC 3339 timestamp or YYYY-MM-DD date",
+		},
+	})
+}
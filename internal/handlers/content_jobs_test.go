@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentHandler_ListJobs_InvalidParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &ContentHandler{logger: logrus.New()}
+	router := gin.New()
+	router.GET("/api/v1/content/jobs", handler.ListJobs)
+	router.POST("/api/v1/content/jobs/:jobId/cancel", handler.CancelJob)
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		code   string
+	}{
+		{"unknown status", "GET", "/api/v1/content/jobs?status=done", "INVALID_STATUS"},
+		{"limit too large", "GET", "/api/v1/content/jobs?limit=500", "INVALID_LIMIT"},
+		{"bad date", "GET", "/api/v1/content/jobs?created_after=yesterday", "INVALID_DATE"},
+		{"bad job id", "POST", "/api/v1/content/jobs/not-a-uuid/cancel", "INVALID_JOB_ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), tt.code)
+		})
+	}
+}
+
+func TestParseJobDate(t *testing.T) {
+	parsed, err := parseJobDate("2024-01-15")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), parsed)
+
+	parsed, err = parseJobDate("2024-01-15T10:30:00+02:00")
+	assert.NoError(t, err)
+	assert.Equal(t, 8, parsed.UTC().Hour())
+
+	parsed, err = parseJobDate("")
+	assert.NoError(t, err)
+	assert.True(t, parsed.IsZero())
+
+	_, err = parseJobDate("15/01/2024")
+	assert.Error(t, err)
+}
+
+func TestContentHandler_InvalidCallbackURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &ContentHandler{validator: validator.New(), logger: logrus.New()}
+	router := gin.New()
+	router.POST("/api/v1/content", handler.Create)
+
+	for _, callbackURL := range []string{"ftp://example.com/hook", "/relative/hook", "https://", "http://127.0.0.1:8080/admin"} {
+		body := `{"type":"article","title":"Webhook test"}`
+		req := httptest.NewRequest("POST", "/api/v1/content", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(CallbackURLHeader, callbackURL)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, callbackURL)
+		assert.Contains(t, w.Body.String(), "INVALID_CALLBACK_URL")
+	}
+}
+
+func TestParseJobItemsPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		query  string
+		offset int
+		limit  int
+		ok     bool
+	}{
+		{"", 0, defaultJobItemsLimit, true},
+		{"items_offset=200&items_limit=50", 200, 50, true},
+		{"items_offset=-1", 0, 0, false},
+		{"items_limit=0", 0, 0, false},
+		{"items_limit=5000", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/v1/content/jobs/x?"+tt.query, nil)
+
+		offset, limit, ok := parseJobItemsPage(c)
+		assert.Equal(t, tt.ok, ok, tt.query)
+		if tt.ok {
+			assert.Equal(t, tt.offset, offset)
+			assert.Equal(t, tt.limit, limit)
+		} else {
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		}
+	}
+}
@@ -516,6 +516,30 @@ func TestContentHandler_GetJobStatus(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestIdempotencyScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	scope := func(values map[string]interface{}) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		for key, value := range values {
+			c.Set(key, value)
+		}
+		return idempotencyScope(c)
+	}
+
+	userID := uuid.New()
+	assert.Equal(t, "user:"+userID.String(), scope(map[string]interface{}{
+		"user_id": userID, "user_verified": true, "api_key": "shared",
+	}))
+
+	// API key callers are told apart by key, whatever user ID they claim
+	first := scope(map[string]interface{}{"user_id": userID, "api_key": "key-one"})
+	second := scope(map[string]interface{}{"user_id": userID, "api_key": "key-two"})
+	assert.NotEqual(t, first, second)
+	assert.NotContains(t, first, "key-one")
+
+	assert.Equal(t, "anonymous", scope(nil))
+}
+
 // Helper function
 func stringPtr(s string) *string {
 	return &s
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/graph"
+	"github.com/temcen/pirex/internal/services"
+)
+
+// GraphHandler exposes graph maintenance through the admin API
+type GraphHandler struct {
+	reconciler *services.GraphReconciliationService
+	logger     *logrus.Logger
+}
+
+func NewGraphHandler(reconciler *services.GraphReconciliationService, logger *logrus.Logger) *GraphHandler {
+	return &GraphHandler{
+		reconciler: reconciler,
+		logger:     logger,
+	}
+}
+
+// Reconcile starts comparing the graph with Postgres. Differences are only
+// reported unless repair=true is given. Admins only.
+func (h *GraphHandler) Reconcile(c *gin.Context) {
+	if !authorizeAdmin(c) {
+		return
+	}
+
+	repair := false
+	if repairStr := c.Query("repair"); repairStr != "" {
+		parsed, err := strconv.ParseBool(repairStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "INVALID_REPAIR",
+					"message": "repair must be true or false",
+				},
+			})
+			return
+		}
+		repair = parsed
+	}
+
+	job, err := h.reconciler.Reconcile(c.Request.Context(), repair)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrReconciliationInProgress):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": gin.H{
+					"code":    "RECONCILIATION_IN_PROGRESS",
+					"message": "A graph reconciliation is already running",
+				},
+			})
+		case errors.Is(err, graph.ErrUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": gin.H{
+					"code":    "GRAPH_UNAVAILABLE",
+					"message": "Neo4j is not configured",
+				},
+			})
+		default:
+			h.logger.WithError(err).Error("Failed to start graph reconciliation")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"code":    "RECONCILIATION_FAILED",
+					"message": "Failed to start graph reconciliation",
+				},
+			})
+		}
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"job_id": job.JobID,
+		"repair": repair,
+	}).Info("Graph reconciliation started")
+
+	c.JSON(http.StatusAccepted, ContentResponse{
+		JobID:   job.JobID,
+		Status:  job.Status,
+		Message: "Reconciliation started; the report is added to the job details when it finishes",
+	})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/services"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func TestGraphHandler_Reconcile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	// Without a Neo4j driver the service refuses to start a run
+	reconciler := services.NewGraphReconciliationService(nil, nil, nil, &config.GraphReconciliationConfig{}, logger)
+	handler := NewGraphHandler(reconciler, logger)
+
+	tests := []struct {
+		name           string
+		role           string
+		url            string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "invalid repair flag",
+			role:           models.RoleAdmin,
+			url:            "/admin/graph/reconcile?repair=maybe",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "INVALID_REPAIR",
+		},
+		{
+			name:           "graph not configured",
+			role:           models.RoleAdmin,
+			url:            "/admin/graph/reconcile?repair=true",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedError:  "GRAPH_UNAVAILABLE",
+		},
+		{
+			name:           "repair without admin role",
+			url:            "/admin/graph/reconcile?repair=true",
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
+		{
+			name:           "report without admin role",
+			role:           "user",
+			url:            "/admin/graph/reconcile",
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.role != "" {
+					c.Set("user_role", tt.role)
+				}
+				c.Next()
+			})
+			router.POST("/admin/graph/reconcile", handler.Reconcile)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tt.url, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var body map[string]map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.expectedError, body["error"]["code"])
+		})
+	}
+}
@@ -13,10 +13,14 @@ type Handlers struct {
 	Content        *ContentHandler
 	Interaction    *InteractionHandler
 	Recommendation *RecommendationHandler
+	Search         *SearchHandler
 	User           *UserHandler
+	Onboarding     *OnboardingHandler
 	GraphQL        *GraphQLHandler
 	Metrics        *MetricsHandler
 	Admin          *AdminHandler
+	Taxonomy       *TaxonomyHandler
+	Graph          *GraphHandler
 	SwaggerSpec    gin.HandlerFunc
 	SwaggerUI      gin.HandlerFunc
 }
@@ -38,13 +42,23 @@ func New(logger *logrus.Logger, services *services.Services) *Handlers {
 		graphqlHTTPHandler = NewGraphQLHandler(graphqlSvc, logger)
 	}
 
+	recommendationHandler := NewRecommendationHandler(services.RecommendationOrchestrator, logger)
+	recommendationHandler.SetCoVisitation(services.CoVisitation)
+	userHandler := NewUserHandler(logger, services.UserInteraction)
+	userHandler.SetNegativeFeedback(services.NegativeFeedback)
+	userHandler.SetUserData(services.UserData)
+
 	return &Handlers{
 		Health:         NewHealthHandler(logger, services.Health),
-		Content:        NewContentHandler(services.MessageBus, services.JobManager, logger),
+		Content:        NewContentHandler(services.MessageBus, services.JobManager, services.BulkImporter, services.PipelineOrchestrator, logger),
 		Interaction:    NewInteractionHandler(logger, services.UserInteraction),
-		Recommendation: NewRecommendationHandler(services.RecommendationOrchestrator, logger),
-		User:           NewUserHandler(logger, services.UserInteraction),
+		Recommendation: recommendationHandler,
+		Search:         NewSearchHandler(services.Search, services.UserInteraction, logger),
+		User:           userHandler,
+		Onboarding:     NewOnboardingHandler(services.Onboarding, logger),
 		GraphQL:        graphqlHTTPHandler,
+		Taxonomy:       NewTaxonomyHandler(services.Taxonomy, logger),
+		Graph:          NewGraphHandler(services.GraphReconciliation, logger),
 		SwaggerSpec:    nil, // TODO: Implement swagger spec handler
 		SwaggerUI:      nil, // TODO: Implement swagger UI handler
 	}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -155,6 +156,9 @@ func (h *MetricsHandler) RecordInteraction(c *gin.Context) {
 		if category, ok := interaction.Context["content_category"].(string); ok {
 			event.ContentCategory = category
 		}
+		if market, ok := interaction.Context["market"].(string); ok {
+			event.Market = strings.ToUpper(market)
+		}
 	}
 
 	if h.metricsCollector != nil {
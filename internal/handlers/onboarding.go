@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/services"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// maxOnboardingItems caps the count parameter of the onboarding items
+const maxOnboardingItems = 50
+
+// OnboardingHandler serves the cold-start questionnaire
+type OnboardingHandler struct {
+	onboarding *services.OnboardingService // Optional; routes answer 503 without it
+	validator  *validator.Validate
+	logger     *logrus.Logger
+}
+
+func NewOnboardingHandler(onboarding *services.OnboardingService, logger *logrus.Logger) *OnboardingHandler {
+	return &OnboardingHandler{
+		onboarding: onboarding,
+		validator:  validator.New(),
+		logger:     logger,
+	}
+}
+
+// GetItems returns the items new users pick what they like from
+func (h *OnboardingHandler) GetItems(c *gin.Context) {
+	if h.onboarding == nil {
+		h.onboardingUnavailable(c)
+		return
+	}
+
+	count := h.onboarding.DefaultItemCount()
+	if countStr := c.Query("count"); countStr != "" {
+		parsed, err := strconv.Atoi(countStr)
+		if err != nil || parsed < 1 || parsed > maxOnboardingItems {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "INVALID_COUNT",
+					"message": "count must be between 1 and 50",
+				},
+			})
+			return
+		}
+		count = parsed
+	}
+
+	items, err := h.onboarding.Items(c.Request.Context(), count)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to select onboarding items")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "QUERY_FAILED",
+				"message": "Failed to select onboarding items",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"items": items,
+			"count": len(items),
+		},
+	})
+}
+
+// Submit records what the user picked and returns the profile built from it
+func (h *OnboardingHandler) Submit(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_USER_ID",
+				"message": "Invalid user ID format",
+			},
+		})
+		return
+	}
+	if !authorizeUser(c, userID) {
+		return
+	}
+
+	var req models.OnboardingSelection
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_FAILED",
+				"message": "Request validation failed",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	if h.onboarding == nil {
+		h.onboardingUnavailable(c)
+		return
+	}
+
+	profile, err := h.onboarding.Submit(c.Request.Context(), userID, &req)
+	switch {
+	case errors.Is(err, services.ErrNoOnboardingItems):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_SELECTION",
+				"message": "None of the selected items are available",
+			},
+		})
+	case err != nil:
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to complete onboarding")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "ONBOARDING_FAILED",
+				"message": "Failed to complete onboarding",
+			},
+		})
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"data": profile,
+		})
+	}
+}
+
+func (h *OnboardingHandler) onboardingUnavailable(c *gin.Context) {
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error": gin.H{
+			"code":    "ONBOARDING_DISABLED",
+			"message": "Onboarding is disabled",
+		},
+	})
+}
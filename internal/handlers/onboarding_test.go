@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/services"
+)
+
+func TestOnboardingHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	// Requests that reach the database are not exercised here
+	onboarding := services.NewOnboardingService(nil, nil, nil, nil, &config.OnboardingConfig{}, logger)
+
+	userID := uuid.New()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("user_verified", true)
+	})
+	enabled := NewOnboardingHandler(onboarding, logger)
+	router.GET("/onboarding/items", enabled.GetItems)
+	router.POST("/users/:userId/onboarding", enabled.Submit)
+	disabled := NewOnboardingHandler(nil, logger)
+	router.GET("/disabled/onboarding/items", disabled.GetItems)
+	router.POST("/disabled/users/:userId/onboarding", disabled.Submit)
+
+	selection := `{"selected_items": ["` + uuid.New().String() + `"], "favorite_categories": ["books"]}`
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "count out of range",
+			method:         http.MethodGet,
+			url:            "/onboarding/items?count=51",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "INVALID_COUNT",
+		},
+		{
+			name:           "count not a number",
+			method:         http.MethodGet,
+			url:            "/onboarding/items?count=many",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "INVALID_COUNT",
+		},
+		{
+			name:           "items while disabled",
+			method:         http.MethodGet,
+			url:            "/disabled/onboarding/items",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedError:  "ONBOARDING_DISABLED",
+		},
+		{
+			name:           "invalid user ID",
+			method:         http.MethodPost,
+			url:            "/users/not-a-uuid/onboarding",
+			body:           selection,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "INVALID_USER_ID",
+		},
+		{
+			name:           "submit for another user",
+			method:         http.MethodPost,
+			url:            "/users/" + uuid.New().String() + "/onboarding",
+			body:           selection,
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
+		{
+			name:           "malformed body",
+			method:         http.MethodPost,
+			url:            "/users/" + userID.String() + "/onboarding",
+			body:           `{"selected_items": "all"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "INVALID_REQUEST",
+		},
+		{
+			name:           "nothing selected",
+			method:         http.MethodPost,
+			url:            "/users/" + userID.String() + "/onboarding",
+			body:           `{"selected_items": []}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "VALIDATION_FAILED",
+		},
+		{
+			name:           "submit while disabled",
+			method:         http.MethodPost,
+			url:            "/disabled/users/" + userID.String() + "/onboarding",
+			body:           selection,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedError:  "ONBOARDING_DISABLED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var body map[string]map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.expectedError, body["error"]["code"])
+		})
+	}
+}
@@ -15,6 +15,7 @@ import (
 
 type RecommendationHandler struct {
 	orchestrator services.RecommendationOrchestratorInterface
+	coVisitation *services.CoVisitationIndex // Optional; adds bought-together items to similar items
 	logger       *logrus.Logger
 }
 
@@ -28,6 +29,12 @@ func NewRecommendationHandler(
 	}
 }
 
+// SetCoVisitation lists the items frequently bought together with the seed
+// item in similar-item responses
+func (h *RecommendationHandler) SetCoVisitation(index *services.CoVisitationIndex) {
+	h.coVisitation = index
+}
+
 func (h *RecommendationHandler) Get(c *gin.Context) {
 	// Parse user ID from path
 	userIDStr := c.Param("userId")
@@ -85,6 +92,8 @@ func (h *RecommendationHandler) Get(c *gin.Context) {
 		ExcludeItems:        excludeItems,
 		IncludeExplanations: explain,
 		TimeoutMs:           2000, // 2 second timeout
+		Locale:              requestLocale(c, c.Query("locale")),
+		Market:              c.Query("market"),
 	}
 
 	// Generate recommendations
@@ -156,6 +165,8 @@ func (h *RecommendationHandler) GetBatch(c *gin.Context) {
 			Context:             req.Context,
 			IncludeExplanations: req.Explain,
 			TimeoutMs:           1500, // Shorter timeout for batch requests
+			Locale:              requestLocale(c, req.Locale),
+			Market:              req.Market,
 		}
 
 		// Generate recommendations
@@ -240,6 +251,8 @@ func (h *RecommendationHandler) GetSimilar(c *gin.Context) {
 		SeedItemID:          &itemID,
 		IncludeExplanations: explain,
 		TimeoutMs:           2000,
+		Locale:              requestLocale(c, c.Query("locale")),
+		Market:              c.Query("market"),
 	}
 
 	// Generate similar item recommendations
@@ -265,6 +278,16 @@ func (h *RecommendationHandler) GetSimilar(c *gin.Context) {
 		CacheHit:        result.CacheHit,
 	}
 
+	if h.coVisitation.Enabled() {
+		bought, err := h.coVisitation.FrequentlyBoughtTogether(c.Request.Context(), itemID, count)
+		if err != nil {
+			h.logger.Warn("Failed to read frequently bought together items",
+				"error", err, "item_id", itemID)
+		} else {
+			response.FrequentlyBoughtTogether = bought
+		}
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -319,3 +342,11 @@ func (h *RecommendationHandler) RecordFeedback(c *gin.Context) {
 		"feedback_id": feedback.RecommendationID, // In a real system, this would be a unique feedback ID
 	})
 }
+
+// requestLocale returns the explicit locale if given, else the Accept-Language header
+func requestLocale(c *gin.Context, locale string) string {
+	if locale != "" {
+		return locale
+	}
+	return c.GetHeader("Accept-Language")
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/services"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// SearchHandler serves hybrid lexical and vector search
+type SearchHandler struct {
+	search             services.SearchServiceInterface
+	userInteractionSvc services.UserInteractionServiceInterface
+	logger             *logrus.Logger
+}
+
+func NewSearchHandler(
+	search services.SearchServiceInterface,
+	userInteractionSvc services.UserInteractionServiceInterface,
+	logger *logrus.Logger,
+) *SearchHandler {
+	return &SearchHandler{
+		search:             search,
+		userInteractionSvc: userInteractionSvc,
+		logger:             logger,
+	}
+}
+
+// Search answers GET /search?q=. The query is recorded as a search interaction
+// of the caller when the user is identified.
+func (h *SearchHandler) Search(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "MISSING_QUERY",
+				"message": "Query parameter q is required",
+			},
+		})
+		return
+	}
+
+	limit := 20 // default
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 100 {
+			limit = parsedLimit
+		}
+	}
+
+	var sessionID uuid.UUID
+	if sessionStr := c.Query("session_id"); sessionStr != "" {
+		parsed, err := uuid.Parse(sessionStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"code":    "INVALID_SESSION_ID",
+					"message": "Invalid session ID format",
+				},
+			})
+			return
+		}
+		sessionID = parsed
+	}
+
+	userID := identifiedUserID(c)
+	req := &services.SearchRequest{
+		Query:        query,
+		UserID:       userID,
+		ContentTypes: splitList(c.Query("content_types")),
+		Categories:   splitList(c.Query("categories")),
+		Limit:        limit,
+		Personalize:  c.Query("personalize") == "true" && userID != uuid.Nil,
+	}
+
+	response, err := h.search.Search(c.Request.Context(), req)
+	if err != nil {
+		h.logger.WithError(err).WithField("query", query).Error("Search failed")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "SEARCH_FAILED",
+				"message": "Failed to search content",
+			},
+		})
+		return
+	}
+
+	h.recordSearch(c, userID, sessionID, req, response)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// recordSearch logs the query as a search interaction. Failures are logged and
+// do not fail the search.
+func (h *SearchHandler) recordSearch(c *gin.Context, userID, sessionID uuid.UUID, req *services.SearchRequest, response *models.SearchResponse) {
+	if h.userInteractionSvc == nil || userID == uuid.Nil {
+		return
+	}
+	if sessionID == uuid.Nil {
+		sessionID = uuid.New()
+	}
+
+	context := map[string]interface{}{
+		"results":    response.Total,
+		"retrievers": response.Retrievers,
+	}
+	if len(req.ContentTypes) > 0 {
+		context["content_types"] = req.ContentTypes
+	}
+	if len(req.Categories) > 0 {
+		context["categories"] = req.Categories
+	}
+
+	query := response.Query
+	_, err := h.userInteractionSvc.RecordImplicitInteraction(c.Request.Context(), &models.ImplicitInteractionRequest{
+		UserID:    userID,
+		Type:      "search",
+		Query:     &query,
+		SessionID: sessionID,
+		Context:   context,
+	})
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Warn("Failed to record search interaction")
+	}
+}
+
+// identifiedUserID returns the caller's user when it is known: authenticated
+// by token, or named by the X-User-ID header of an API key request. Otherwise
+// the auth middleware made up an ID for the request, and uuid.Nil is returned.
+func identifiedUserID(c *gin.Context) uuid.UUID {
+	if !c.GetBool("user_verified") && c.GetHeader("X-User-ID") == "" {
+		return uuid.Nil
+	}
+	return contextUserID(c)
+}
+
+// contextUserID returns the user set by the auth middleware, or uuid.Nil
+func contextUserID(c *gin.Context) uuid.UUID {
+	if value, ok := c.Get("user_id"); ok {
+		if userID, ok := value.(uuid.UUID); ok {
+			return userID
+		}
+	}
+	return uuid.Nil
+}
+
+// splitList splits a comma-separated query parameter, dropping empty entries
+func splitList(value string) []string {
+	var list []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/temcen/pirex/internal/services"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// MockSearchService is a mock implementation for testing
+type MockSearchService struct {
+	mock.Mock
+}
+
+func (m *MockSearchService) Search(ctx context.Context, req *services.SearchRequest) (*models.SearchResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SearchResponse), args.Error(1)
+}
+
+func TestSearchHandler_Search(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+
+	tests := []struct {
+		name           string
+		url            string
+		userID         uuid.UUID
+		verified       bool // Authenticated by token
+		userHeader     bool // API key request naming the user in X-User-ID
+		mockSetup      func(*MockSearchService, *MockUserInteractionService)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "search with filters records the query",
+			url:  "/api/v1/search?q=wireless+headphones&limit=5&content_types=product&categories=audio,+headphones&personalize=true&session_id=" + sessionID.String(),
+			mockSetup: func(s *MockSearchService, u *MockUserInteractionService) {
+				s.On("Search", mock.Anything, mock.MatchedBy(func(req *services.SearchRequest) bool {
+					return req.Query == "wireless headphones" && req.Limit == 5 && req.UserID == userID &&
+						req.Personalize && assert.ObjectsAreEqual([]string{"product"}, req.ContentTypes) &&
+						assert.ObjectsAreEqual([]string{"audio", "headphones"}, req.Categories)
+				})).Return(&models.SearchResponse{
+					Query:       "wireless headphones",
+					Results:     []models.SearchResult{{ItemID: uuid.New(), Score: 0.03, Position: 1}},
+					Total:       1,
+					Retrievers:  []string{services.SearchRetrieverLexical},
+					GeneratedAt: time.Now(),
+				}, nil)
+				u.On("RecordImplicitInteraction", mock.Anything, mock.MatchedBy(func(req *models.ImplicitInteractionRequest) bool {
+					return req.Type == "search" && req.UserID == userID && req.SessionID == sessionID &&
+						req.Query != nil && *req.Query == "wireless headphones" && req.ItemID == nil
+				})).Return(&models.UserInteraction{ID: uuid.New()}, nil)
+			},
+			userID:         userID,
+			verified:       true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failed interaction logging does not fail the search",
+			url:  "/api/v1/search?q=camera",
+			mockSetup: func(s *MockSearchService, u *MockUserInteractionService) {
+				s.On("Search", mock.Anything, mock.Anything).Return(&models.SearchResponse{Query: "camera"}, nil)
+				u.On("RecordImplicitInteraction", mock.Anything, mock.Anything).
+					Return((*models.UserInteraction)(nil), errors.New("database unavailable"))
+			},
+			userID:         userID,
+			verified:       true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "API key request naming the user is recorded",
+			url:  "/api/v1/search?q=camera&personalize=true",
+			mockSetup: func(s *MockSearchService, u *MockUserInteractionService) {
+				s.On("Search", mock.Anything, mock.MatchedBy(func(req *services.SearchRequest) bool {
+					return req.Personalize && req.UserID == userID
+				})).Return(&models.SearchResponse{Query: "camera"}, nil)
+				u.On("RecordImplicitInteraction", mock.Anything, mock.MatchedBy(func(req *models.ImplicitInteractionRequest) bool {
+					return req.UserID == userID
+				})).Return(&models.UserInteraction{ID: uuid.New()}, nil)
+			},
+			userID:         userID,
+			userHeader:     true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "API key request without a user is neither personalized nor recorded",
+			url:  "/api/v1/search?q=camera&personalize=true",
+			mockSetup: func(s *MockSearchService, u *MockUserInteractionService) {
+				s.On("Search", mock.Anything, mock.MatchedBy(func(req *services.SearchRequest) bool {
+					return !req.Personalize && req.UserID == uuid.Nil
+				})).Return(&models.SearchResponse{Query: "camera"}, nil)
+			},
+			userID:         uuid.New(), // Made up by the auth middleware
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "anonymous search is neither personalized nor recorded",
+			url:  "/api/v1/search?q=camera&personalize=true",
+			mockSetup: func(s *MockSearchService, u *MockUserInteractionService) {
+				s.On("Search", mock.Anything, mock.MatchedBy(func(req *services.SearchRequest) bool {
+					return !req.Personalize && req.Limit == 20
+				})).Return(&models.SearchResponse{Query: "camera"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing query",
+			url:            "/api/v1/search?q=++",
+			mockSetup:      func(s *MockSearchService, u *MockUserInteractionService) {},
+			userID:         userID,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "MISSING_QUERY",
+		},
+		{
+			name:           "invalid session ID",
+			url:            "/api/v1/search?q=camera&session_id=abc",
+			mockSetup:      func(s *MockSearchService, u *MockUserInteractionService) {},
+			userID:         userID,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "INVALID_SESSION_ID",
+		},
+		{
+			name: "search failure",
+			url:  "/api/v1/search?q=camera",
+			mockSetup: func(s *MockSearchService, u *MockUserInteractionService) {
+				s.On("Search", mock.Anything, mock.Anything).Return(nil, errors.New("database unavailable"))
+			},
+			userID:         userID,
+			expectedStatus: http.StatusInternalServerError,
+			expectedError:  "SEARCH_FAILED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			searchService := new(MockSearchService)
+			userService := new(MockUserInteractionService)
+			tt.mockSetup(searchService, userService)
+
+			handler := NewSearchHandler(searchService, userService, logger)
+
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			if tt.userHeader {
+				req.Header.Set("X-User-ID", tt.userID.String())
+			}
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			if tt.userID != uuid.Nil {
+				c.Set("user_id", tt.userID)
+			}
+			if tt.verified {
+				c.Set("user_verified", true)
+			}
+
+			handler.Search(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedError != "" {
+				var response map[string]interface{}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				errorObj := response["error"].(map[string]interface{})
+				assert.Equal(t, tt.expectedError, errorObj["code"])
+			}
+
+			searchService.AssertExpectations(t)
+			userService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/services"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// TaxonomyHandler manages the category taxonomy through the admin API
+type TaxonomyHandler struct {
+	taxonomy  *services.TaxonomyService
+	validator *validator.Validate
+	logger    *logrus.Logger
+}
+
+func NewTaxonomyHandler(taxonomy *services.TaxonomyService, logger *logrus.Logger) *TaxonomyHandler {
+	return &TaxonomyHandler{
+		taxonomy:  taxonomy,
+		validator: validator.New(),
+		logger:    logger,
+	}
+}
+
+// ListCategories returns every category with its path, ordered by path
+func (h *TaxonomyHandler) ListCategories(c *gin.Context) {
+	categories := h.taxonomy.List()
+	c.JSON(http.StatusOK, gin.H{
+		"categories": categories,
+		"total":      len(categories),
+	})
+}
+
+func (h *TaxonomyHandler) GetCategory(c *gin.Context) {
+	category, err := h.taxonomy.Get(c.Param("categoryId"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, category)
+}
+
+func (h *TaxonomyHandler) CreateCategory(c *gin.Context) {
+	if !authorizeAdmin(c) {
+		return
+	}
+
+	request, ok := h.bindRequest(c)
+	if !ok {
+		return
+	}
+
+	category, err := h.taxonomy.Create(c.Request.Context(), request)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.logger.WithField("category_id", category.ID).Info("Category created")
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory replaces a category's name, parent and synonyms
+func (h *TaxonomyHandler) UpdateCategory(c *gin.Context) {
+	if !authorizeAdmin(c) {
+		return
+	}
+
+	request, ok := h.bindRequest(c)
+	if !ok {
+		return
+	}
+
+	category, err := h.taxonomy.Update(c.Request.Context(), c.Param("categoryId"), request)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.logger.WithField("category_id", category.ID).Info("Category updated")
+	c.JSON(http.StatusOK, category)
+}
+
+func (h *TaxonomyHandler) DeleteCategory(c *gin.Context) {
+	if !authorizeAdmin(c) {
+		return
+	}
+
+	categoryID := c.Param("categoryId")
+	if err := h.taxonomy.Delete(c.Request.Context(), categoryID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.logger.WithField("category_id", categoryID).Info("Category deleted")
+	c.Status(http.StatusNoContent)
+}
+
+func (h *TaxonomyHandler) bindRequest(c *gin.Context) (*models.CategoryRequest, bool) {
+	var request models.CategoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_JSON",
+				"message": "Invalid JSON format",
+				"details": err.Error(),
+			},
+		})
+		return nil, false
+	}
+
+	if err := h.validator.Struct(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_FAILED",
+				"message": "Category validation failed",
+				"details": err.Error(),
+			},
+		})
+		return nil, false
+	}
+
+	return &request, true
+}
+
+func (h *TaxonomyHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "CATEGORY_NOT_FOUND",
+				"message": "Category not found",
+			},
+		})
+	case errors.Is(err, services.ErrCategoryExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "CATEGORY_EXISTS",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, services.ErrCategoryHasChildren):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": gin.H{
+				"code":    "CATEGORY_HAS_CHILDREN",
+				"message": "Delete or move the subcategories first",
+			},
+		})
+	case errors.Is(err, services.ErrInvalidCategory):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_CATEGORY",
+				"message": err.Error(),
+			},
+		})
+	default:
+		h.logger.WithError(err).Error("Category update failed")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "INTERNAL_ERROR",
+				"message": "Failed to update taxonomy",
+			},
+		})
+	}
+}
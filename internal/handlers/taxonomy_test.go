@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func TestTaxonomyHandler_RequiresAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	// Requests stopped before the service is reached need no taxonomy
+	handler := NewTaxonomyHandler(nil, logger)
+
+	tests := []struct {
+		name           string
+		role           string
+		method         string
+		url            string
+		body           string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "create without admin role",
+			method:         http.MethodPost,
+			url:            "/admin/taxonomy/categories",
+			body:           `{"id":"audio","name":"Audio"}`,
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
+		{
+			name:           "update without admin role",
+			role:           "user",
+			method:         http.MethodPut,
+			url:            "/admin/taxonomy/categories/audio",
+			body:           `{"id":"audio","name":"Sound"}`,
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
+		{
+			name:           "delete without admin role",
+			method:         http.MethodDelete,
+			url:            "/admin/taxonomy/categories/audio",
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
+		{
+			name:           "admin passes the role check",
+			role:           models.RoleAdmin,
+			method:         http.MethodPost,
+			url:            "/admin/taxonomy/categories",
+			body:           `{"id":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "INVALID_JSON",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.role != "" {
+					c.Set("user_role", tt.role)
+				}
+				c.Next()
+			})
+			router.POST("/admin/taxonomy/categories", handler.CreateCategory)
+			router.PUT("/admin/taxonomy/categories/:categoryId", handler.UpdateCategory)
+			router.DELETE("/admin/taxonomy/categories/:categoryId", handler.DeleteCategory)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var body map[string]map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.expectedError, body["error"]["code"])
+		})
+	}
+}
@@ -1,29 +1,48 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
+	"github.com/temcen/pirex/internal/middleware"
 	"github.com/temcen/pirex/internal/services"
+	"github.com/temcen/pirex/pkg/models"
 )
 
 type UserHandler struct {
 	logger             *logrus.Logger
 	userInteractionSvc services.UserInteractionServiceInterface
+	validator          *validator.Validate
+	negativeFeedback   *services.NegativeFeedback // Optional; suppression routes answer 503 without it
+	userData           *services.UserDataService  // Optional; export and deletion answer 503 without it
 }
 
 func NewUserHandler(logger *logrus.Logger, userInteractionSvc services.UserInteractionServiceInterface) *UserHandler {
 	return &UserHandler{
 		logger:             logger,
 		userInteractionSvc: userInteractionSvc,
+		validator:          validator.New(),
 	}
 }
 
+// SetNegativeFeedback enables listing and undoing suppressions
+func (h *UserHandler) SetNegativeFeedback(negativeFeedback *services.NegativeFeedback) {
+	h.negativeFeedback = negativeFeedback
+}
+
+// SetUserData enables exporting and deleting users
+func (h *UserHandler) SetUserData(userData *services.UserDataService) {
+	h.userData = userData
+}
+
 func (h *UserHandler) GetInteractions(c *gin.Context) {
 	// Parse user ID from URL parameter
 	userIDStr := c.Param("userId")
@@ -121,6 +140,9 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		})
 		return
 	}
+	if !authorizeUser(c, userID) {
+		return
+	}
 
 	// Get user profile
 	profile, err := h.userInteractionSvc.GetUserProfile(c.Request.Context(), userID)
@@ -140,6 +162,64 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	})
 }
 
+// UpdateProfile changes the user's explicit preferences: favourite and blocked
+// categories and content type weights. Onboarding flows set onboarding to
+// record that cold-start preferences were captured.
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_USER_ID",
+				"message": "Invalid user ID format",
+			},
+		})
+		return
+	}
+
+	if !authorizeUser(c, userID) {
+		return
+	}
+
+	var req models.ProfileUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "VALIDATION_FAILED",
+				"message": "Request validation failed",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	profile, err := h.userInteractionSvc.UpdateExplicitPreferences(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to update user profile")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "PROFILE_UPDATE_FAILED",
+				"message": "Failed to update user profile",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": profile,
+	})
+}
+
 func (h *UserHandler) GetSimilarUsers(c *gin.Context) {
 	// Parse user ID from URL parameter
 	userIDStr := c.Param("userId")
@@ -154,6 +234,9 @@ func (h *UserHandler) GetSimilarUsers(c *gin.Context) {
 		})
 		return
 	}
+	if !authorizeUser(c, userID) {
+		return
+	}
 
 	// Parse limit parameter
 	limitStr := c.DefaultQuery("limit", "10")
@@ -182,3 +265,247 @@ func (h *UserHandler) GetSimilarUsers(c *gin.Context) {
 		},
 	})
 }
+
+// GetSuppressions lists the items, categories and brands the user asked not to see
+func (h *UserHandler) GetSuppressions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_USER_ID",
+				"message": "Invalid user ID format",
+			},
+		})
+		return
+	}
+	if !authorizeUser(c, userID) {
+		return
+	}
+
+	if h.negativeFeedback == nil {
+		h.suppressionsUnavailable(c)
+		return
+	}
+
+	suppressions, err := h.negativeFeedback.List(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list suppressions")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "QUERY_FAILED",
+				"message": "Failed to retrieve suppressions",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"suppressions": suppressions,
+			"count":        len(suppressions),
+		},
+	})
+}
+
+// DeleteSuppression undoes a suppression, so its item, category or brand is
+// recommended again
+func (h *UserHandler) DeleteSuppression(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_USER_ID",
+				"message": "Invalid user ID format",
+			},
+		})
+		return
+	}
+	if !authorizeUser(c, userID) {
+		return
+	}
+	suppressionID, err := uuid.Parse(c.Param("suppressionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_SUPPRESSION_ID",
+				"message": "Invalid suppression ID format",
+			},
+		})
+		return
+	}
+
+	if h.negativeFeedback == nil {
+		h.suppressionsUnavailable(c)
+		return
+	}
+
+	err = h.negativeFeedback.Remove(c.Request.Context(), userID, suppressionID)
+	switch {
+	case errors.Is(err, services.ErrSuppressionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "SUPPRESSION_NOT_FOUND",
+				"message": "Suppression not found",
+			},
+		})
+	case err != nil:
+		h.logger.WithError(err).Error("Failed to delete suppression")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "DELETE_FAILED",
+				"message": "Failed to delete suppression",
+			},
+		})
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (h *UserHandler) suppressionsUnavailable(c *gin.Context) {
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error": gin.H{
+			"code":    "NEGATIVE_FEEDBACK_DISABLED",
+			"message": "Negative feedback handling is disabled",
+		},
+	})
+}
+
+// ExportData returns everything stored about the user as a JSON archive
+func (h *UserHandler) ExportData(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_USER_ID",
+				"message": "Invalid user ID format",
+			},
+		})
+		return
+	}
+
+	if !authorizeUser(c, userID) {
+		return
+	}
+
+	if h.userData == nil {
+		h.userDataUnavailable(c)
+		return
+	}
+
+	export, err := h.userData.Export(c.Request.Context(), userID)
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"code":    "USER_NOT_FOUND",
+				"message": "No data is stored for this user",
+			},
+		})
+	case err != nil:
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to export user data")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "EXPORT_FAILED",
+				"message": "Failed to export user data",
+			},
+		})
+	default:
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%s.json"`, userID))
+		c.JSON(http.StatusOK, gin.H{
+			"data": export,
+		})
+	}
+}
+
+// DeleteUser starts deleting everything stored about the user. The job's
+// details hold the deletion report once it finishes.
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"code":    "INVALID_USER_ID",
+				"message": "Invalid user ID format",
+			},
+		})
+		return
+	}
+
+	if !authorizeUser(c, userID) {
+		return
+	}
+
+	if h.userData == nil {
+		h.userDataUnavailable(c)
+		return
+	}
+
+	job, err := h.userData.Delete(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrDeletionInProgress) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": gin.H{
+					"code":    "DELETION_IN_PROGRESS",
+					"message": "A deletion of this user is already running",
+				},
+			})
+			return
+		}
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to start user deletion")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{
+				"code":    "DELETION_FAILED",
+				"message": "Failed to start user deletion",
+			},
+		})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"job_id":  job.JobID,
+		"user_id": userID,
+	}).Info("User deletion started")
+
+	c.JSON(http.StatusAccepted, ContentResponse{
+		JobID:   job.JobID,
+		Status:  job.Status,
+		Message: "Deletion started; the deletion report is added to the job details when it finishes",
+	})
+}
+
+func (h *UserHandler) userDataUnavailable(c *gin.Context) {
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error": gin.H{
+			"code":    "USER_DATA_UNAVAILABLE",
+			"message": "User data export and deletion are not available",
+		},
+	})
+}
+
+// authorizeUser answers 403 unless the caller may act on the user's data
+func authorizeUser(c *gin.Context, userID uuid.UUID) bool {
+	if middleware.CanActOnUser(c, userID) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": gin.H{
+			"code":    "FORBIDDEN",
+			"message": "Not allowed to access this user's data",
+		},
+	})
+	return false
+}
+
+// authorizeAdmin answers 403 unless the caller is an admin
+func authorizeAdmin(c *gin.Context) bool {
+	if middleware.IsAdmin(c) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": gin.H{
+			"code":    "FORBIDDEN",
+			"message": "Admin role required",
+		},
+	})
+	return false
+}
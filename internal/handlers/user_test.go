@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,6 +29,11 @@ func (m *MockUserInteractionService) GetUserProfile(ctx context.Context, userID
 	return args.Get(0).(*models.UserProfile), args.Error(1)
 }
 
+func (m *MockUserInteractionService) UpdateExplicitPreferences(ctx context.Context, userID uuid.UUID, req *models.ProfileUpdateRequest) (*models.UserProfile, error) {
+	args := m.Called(ctx, userID, req)
+	return args.Get(0).(*models.UserProfile), args.Error(1)
+}
+
 func (m *MockUserInteractionService) GetSimilarUsers(ctx context.Context, userID uuid.UUID, limit int) ([]models.SimilarUser, error) {
 	args := m.Called(ctx, userID, limit)
 	return args.Get(0).([]models.SimilarUser), args.Error(1)
@@ -146,6 +152,8 @@ func TestUserHandler_GetProfile(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.ErrorLevel)
 
+	userID := uuid.New()
+
 	tests := []struct {
 		name           string
 		userID         string
@@ -155,7 +163,7 @@ func TestUserHandler_GetProfile(t *testing.T) {
 	}{
 		{
 			name:   "valid user profile request",
-			userID: uuid.New().String(),
+			userID: userID.String(),
 			mockSetup: func(m *MockUserInteractionService) {
 				profile := &models.UserProfile{
 					UserID:           uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
@@ -171,6 +179,13 @@ func TestUserHandler_GetProfile(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "profile of another user",
+			userID:         uuid.New().String(),
+			mockSetup:      func(m *MockUserInteractionService) {},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
 		{
 			name:   "invalid user ID",
 			userID: "invalid-uuid",
@@ -197,6 +212,8 @@ func TestUserHandler_GetProfile(t *testing.T) {
 			c, _ := gin.CreateTestContext(w)
 			c.Request = req
 			c.Params = []gin.Param{{Key: "userId", Value: tt.userID}}
+			c.Set("user_id", userID)
+			c.Set("user_verified", true)
 
 			// Call handler
 			handler.GetProfile(c)
@@ -220,11 +237,117 @@ func TestUserHandler_GetProfile(t *testing.T) {
 	}
 }
 
+func TestUserHandler_UpdateProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name           string
+		userID         string
+		body           string
+		mockSetup      func(*MockUserInteractionService)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:   "onboarding preferences",
+			userID: userID.String(),
+			body:   `{"favorite_categories":["books","music"],"content_types":{"video":0,"article":1.5},"onboarding":true}`,
+			mockSetup: func(m *MockUserInteractionService) {
+				m.On("UpdateExplicitPreferences", mock.Anything, mock.AnythingOfType("uuid.UUID"),
+					mock.MatchedBy(func(req *models.ProfileUpdateRequest) bool {
+						return req.Onboarding && len(*req.FavoriteCategories) == 2 &&
+							req.BlockedCategories == nil && (*req.ContentTypes)["video"] == 0
+					})).
+					Return(&models.UserProfile{ExplicitPrefs: map[string]interface{}{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "content type weight out of range",
+			userID:         userID.String(),
+			body:           `{"content_types":{"video":9}}`,
+			mockSetup:      func(m *MockUserInteractionService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "VALIDATION_FAILED",
+		},
+		{
+			name:           "empty category",
+			userID:         userID.String(),
+			body:           `{"blocked_categories":[""]}`,
+			mockSetup:      func(m *MockUserInteractionService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "VALIDATION_FAILED",
+		},
+		{
+			name:           "malformed body",
+			userID:         userID.String(),
+			body:           `{"favorite_categories":"books"}`,
+			mockSetup:      func(m *MockUserInteractionService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "INVALID_REQUEST",
+		},
+		{
+			name:           "profile of another user",
+			userID:         uuid.New().String(),
+			body:           `{"favorite_categories":["books"]}`,
+			mockSetup:      func(m *MockUserInteractionService) {},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
+		{
+			name:           "invalid user ID",
+			userID:         "invalid-uuid",
+			body:           `{}`,
+			mockSetup:      func(m *MockUserInteractionService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "INVALID_USER_ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserInteractionService)
+			tt.mockSetup(mockService)
+
+			handler := NewUserHandler(logger, mockService)
+
+			req, _ := http.NewRequest("PATCH", "/api/v1/users/"+tt.userID+"/profile", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = []gin.Param{{Key: "userId", Value: tt.userID}}
+			c.Set("user_id", userID)
+			c.Set("user_verified", true)
+
+			handler.UpdateProfile(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedError != "" {
+				var response map[string]interface{}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				errorObj := response["error"].(map[string]interface{})
+				assert.Equal(t, tt.expectedError, errorObj["code"])
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestUserHandler_GetSimilarUsers(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	logger := logrus.New()
 	logger.SetLevel(logrus.ErrorLevel)
 
+	userID := uuid.New()
+
 	tests := []struct {
 		name           string
 		userID         string
@@ -235,7 +358,7 @@ func TestUserHandler_GetSimilarUsers(t *testing.T) {
 	}{
 		{
 			name:   "valid similar users request",
-			userID: uuid.New().String(),
+			userID: userID.String(),
 			queryParams: map[string]string{
 				"limit": "5",
 			},
@@ -253,6 +376,13 @@ func TestUserHandler_GetSimilarUsers(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "similar users of another user",
+			userID:         uuid.New().String(),
+			mockSetup:      func(m *MockUserInteractionService) {},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
 		{
 			name:   "invalid user ID",
 			userID: "invalid-uuid",
@@ -264,7 +394,7 @@ func TestUserHandler_GetSimilarUsers(t *testing.T) {
 		},
 		{
 			name:   "default limit",
-			userID: uuid.New().String(),
+			userID: userID.String(),
 			mockSetup: func(m *MockUserInteractionService) {
 				similarUsers := []models.SimilarUser{}
 				m.On("GetSimilarUsers", mock.Anything, mock.AnythingOfType("uuid.UUID"), 10).
@@ -296,6 +426,8 @@ func TestUserHandler_GetSimilarUsers(t *testing.T) {
 			c, _ := gin.CreateTestContext(w)
 			c.Request = req
 			c.Params = []gin.Param{{Key: "userId", Value: tt.userID}}
+			c.Set("user_id", userID)
+			c.Set("user_verified", true)
 
 			// Call handler
 			handler.GetSimilarUsers(c)
@@ -321,3 +453,204 @@ func TestUserHandler_GetSimilarUsers(t *testing.T) {
 		})
 	}
 }
+
+func TestUserHandler_Suppressions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name           string
+		method         string
+		params         []gin.Param
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "list with invalid user ID",
+			method:         "GET",
+			params:         []gin.Param{{Key: "userId", Value: "invalid-uuid"}},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "INVALID_USER_ID",
+		},
+		{
+			name:           "list without negative feedback",
+			method:         "GET",
+			params:         []gin.Param{{Key: "userId", Value: userID.String()}},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedError:  "NEGATIVE_FEEDBACK_DISABLED",
+		},
+		{
+			name:           "list of another user",
+			method:         "GET",
+			params:         []gin.Param{{Key: "userId", Value: uuid.New().String()}},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
+		{
+			name:   "delete for another user",
+			method: "DELETE",
+			params: []gin.Param{
+				{Key: "userId", Value: uuid.New().String()},
+				{Key: "suppressionId", Value: uuid.New().String()},
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
+		{
+			name:   "delete with invalid suppression ID",
+			method: "DELETE",
+			params: []gin.Param{
+				{Key: "userId", Value: userID.String()},
+				{Key: "suppressionId", Value: "invalid-uuid"},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "INVALID_SUPPRESSION_ID",
+		},
+		{
+			name:   "delete without negative feedback",
+			method: "DELETE",
+			params: []gin.Param{
+				{Key: "userId", Value: userID.String()},
+				{Key: "suppressionId", Value: uuid.New().String()},
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedError:  "NEGATIVE_FEEDBACK_DISABLED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewUserHandler(logger, new(MockUserInteractionService))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(tt.method, "/api/v1/users/suppressions", nil)
+			c.Params = tt.params
+			c.Set("user_id", userID)
+			c.Set("user_verified", true)
+
+			if tt.method == "DELETE" {
+				handler.DeleteSuppression(c)
+			} else {
+				handler.GetSuppressions(c)
+			}
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var response map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			errorObj := response["error"].(map[string]interface{})
+			assert.Equal(t, tt.expectedError, errorObj["code"])
+		})
+	}
+}
+
+func TestUserHandler_UserData(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	userID := uuid.New()
+	asUser := func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("user_verified", true)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		userID         string
+		caller         func(c *gin.Context)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "export with invalid user ID",
+			method:         "GET",
+			userID:         "invalid-uuid",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "INVALID_USER_ID",
+		},
+		{
+			name:           "export of another user",
+			method:         "GET",
+			userID:         uuid.New().String(),
+			caller:         asUser,
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
+		{
+			name:   "export with unverified user ID",
+			method: "GET",
+			userID: userID.String(),
+			caller: func(c *gin.Context) {
+				// API keys name their user in a header anyone can set
+				c.Set("user_id", userID)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
+		{
+			name:           "export without user data service",
+			method:         "GET",
+			userID:         userID.String(),
+			caller:         asUser,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedError:  "USER_DATA_UNAVAILABLE",
+		},
+		{
+			name:           "delete with invalid user ID",
+			method:         "DELETE",
+			userID:         "invalid-uuid",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "INVALID_USER_ID",
+		},
+		{
+			name:           "delete of another user",
+			method:         "DELETE",
+			userID:         uuid.New().String(),
+			caller:         asUser,
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "FORBIDDEN",
+		},
+		{
+			name:   "delete by admin",
+			method: "DELETE",
+			userID: uuid.New().String(),
+			caller: func(c *gin.Context) {
+				c.Set("user_id", uuid.New())
+				c.Set("user_role", models.RoleAdmin)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedError:  "USER_DATA_UNAVAILABLE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewUserHandler(logger, new(MockUserInteractionService))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(tt.method, "/api/v1/users/"+tt.userID, nil)
+			c.Params = []gin.Param{{Key: "userId", Value: tt.userID}}
+			if tt.caller != nil {
+				tt.caller(c)
+			}
+
+			if tt.method == "DELETE" {
+				handler.DeleteUser(c)
+			} else {
+				handler.ExportData(c)
+			}
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var response map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			errorObj := response["error"].(map[string]interface{})
+			assert.Equal(t, tt.expectedError, errorObj["code"])
+		})
+	}
+}
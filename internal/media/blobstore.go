@@ -0,0 +1,114 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps opaque objects by key. Keys are slash-separated relative paths.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalBlobStore keeps blobs as files under a root directory
+type LocalBlobStore struct {
+	root string
+}
+
+func NewLocalBlobStore(root string) (*LocalBlobStore, error) {
+	if root == "" {
+		return nil, fmt.Errorf("local blob store requires a root directory")
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(absRoot, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create blob store directory: %w", err)
+	}
+	return &LocalBlobStore{root: absRoot}, nil
+}
+
+func (s *LocalBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so readers never see a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".blob-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (s *LocalBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, key)
+	}
+	return data, err
+}
+
+func (s *LocalBlobStore) Exists(ctx context.Context, key string) (bool, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path maps a key to a file under the root, rejecting keys that would escape it
+func (s *LocalBlobStore) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if key == "" || cleaned == "/" || strings.Contains(key, "\\") || cleaned != "/"+key {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned[1:])), nil
+}
@@ -0,0 +1,390 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/temcen/pirex/internal/config"
+)
+
+var (
+	ErrBlockedAddress         = errors.New("destination address is not allowed")
+	ErrTooManyRedirects       = errors.New("too many redirects")
+	ErrImageTooLarge          = errors.New("image exceeds maximum size")
+	ErrUnsupportedContentType = errors.New("response is not an image")
+	ErrUnsupportedScheme      = errors.New("only http and https URLs are allowed")
+)
+
+const (
+	defaultFetchTimeout        = 10 * time.Second
+	defaultMaxImageBytes int64 = 10 * 1024 * 1024
+	// defaultMaxImagePixels keeps a decoded image around 160MB as RGBA
+	defaultMaxImagePixels int64 = 40_000_000
+
+	// maxConnectTimeout bounds dialing, the TLS handshake and waiting for
+	// response headers when the overall timeout is longer
+	maxConnectTimeout = 30 * time.Second
+)
+
+// blockedPrefixes are refused after DNS resolution, so a public hostname that
+// resolves to an internal address is caught as well as literal IPs. IPv4-mapped
+// IPv6 addresses are unmapped before checking.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),          // "This" network
+	netip.MustParsePrefix("10.0.0.0/8"),         // Private
+	netip.MustParsePrefix("100.64.0.0/10"),      // Carrier-grade NAT
+	netip.MustParsePrefix("127.0.0.0/8"),        // Loopback
+	netip.MustParsePrefix("169.254.0.0/16"),     // Link-local, including 169.254.169.254 metadata
+	netip.MustParsePrefix("172.16.0.0/12"),      // Private
+	netip.MustParsePrefix("192.0.0.0/24"),       // IETF protocol assignments
+	netip.MustParsePrefix("192.168.0.0/16"),     // Private
+	netip.MustParsePrefix("198.18.0.0/15"),      // Benchmarking
+	netip.MustParsePrefix("224.0.0.0/4"),        // Multicast
+	netip.MustParsePrefix("240.0.0.0/4"),        // Reserved and broadcast
+	netip.MustParsePrefix("::/128"),             // Unspecified
+	netip.MustParsePrefix("::1/128"),            // Loopback
+	netip.MustParsePrefix("64:ff9b::/96"),       // NAT64 can reach IPv4 internals
+	netip.MustParsePrefix("fc00::/7"),           // Unique local, including fd00:ec2::254 metadata
+	netip.MustParsePrefix("fe80::/10"),          // Link-local
+	netip.MustParsePrefix("ff00::/8"),           // Multicast
+	netip.MustParsePrefix("2001:db8::/32"),      // Documentation
+	netip.MustParsePrefix("2002::/16"),          // 6to4 can embed private IPv4
+	netip.MustParsePrefix("100::/64"),           // Discard
+	netip.MustParsePrefix("2001::/32"),          // Teredo can embed private IPv4
+	netip.MustParsePrefix("198.51.100.0/24"),    // Documentation
+	netip.MustParsePrefix("203.0.113.0/24"),     // Documentation
+	netip.MustParsePrefix("192.0.2.0/24"),       // Documentation
+	netip.MustParsePrefix("255.255.255.255/32"), // Broadcast
+}
+
+// IsBlockedAddress reports whether an address is internal or otherwise not a
+// public destination
+func IsBlockedAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchedImage is a downloaded image body
+type FetchedImage struct {
+	URL         string // Final URL after redirects
+	ContentType string
+	Data        []byte
+}
+
+// ProbeResult is what a HEAD request reports about an image
+type ProbeResult struct {
+	URL           string
+	ContentType   string
+	ContentLength int64 // -1 when unknown
+}
+
+// ImageFetcher downloads images from untrusted URLs. Connections are only made
+// to public addresses, redirects are capped and each host gets a bounded number
+// of concurrent requests.
+type ImageFetcher struct {
+	client       *http.Client
+	config       config.ImageFetchConfig
+	hostsMu      sync.Mutex
+	hostSlots    map[string]chan struct{}
+	allowPrivate bool
+}
+
+// DefaultImageFetchConfig is used when no configuration is supplied
+func DefaultImageFetchConfig() config.ImageFetchConfig {
+	return config.ImageFetchConfig{
+		Timeout:            defaultFetchTimeout,
+		MaxBytes:           defaultMaxImageBytes,
+		MaxPixels:          defaultMaxImagePixels,
+		MaxRedirects:       3,
+		PerHostConcurrency: 4,
+		UserAgent:          "pirex-image-fetcher/1.0",
+	}
+}
+
+func NewImageFetcher(cfg config.ImageFetchConfig) *ImageFetcher {
+	defaults := DefaultImageFetchConfig()
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaults.MaxBytes
+	}
+	if cfg.MaxPixels <= 0 {
+		cfg.MaxPixels = defaults.MaxPixels
+	}
+	if cfg.MaxRedirects < 0 {
+		cfg.MaxRedirects = 0
+	}
+	if cfg.PerHostConcurrency <= 0 {
+		cfg.PerHostConcurrency = defaults.PerHostConcurrency
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = defaults.UserAgent
+	}
+
+	f := &ImageFetcher{
+		config:       cfg,
+		hostSlots:    make(map[string]chan struct{}),
+		allowPrivate: cfg.AllowPrivateNetworks,
+	}
+
+	f.client = NewPublicClient(cfg.Timeout, cfg.MaxRedirects, cfg.AllowPrivateNetworks)
+	f.client.Transport.(*http.Transport).MaxIdleConnsPerHost = cfg.PerHostConcurrency
+
+	return f
+}
+
+// MaxPixels is the largest width x height a fetched image may declare
+func (f *ImageFetcher) MaxPixels() int64 {
+	return f.config.MaxPixels
+}
+
+// NewPublicClient returns an HTTP client for untrusted URLs. Every connection
+// is checked against the blocklist with the resolved IP, which also covers
+// redirects and DNS rebinding, and at most maxRedirects redirects are
+// followed, each validated like the original URL. allowPrivate lifts the
+// address checks for development.
+func NewPublicClient(timeout time.Duration, maxRedirects int, allowPrivate bool) *http.Client {
+	connectTimeout := timeout
+	if connectTimeout <= 0 || connectTimeout > maxConnectTimeout {
+		connectTimeout = maxConnectTimeout
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+		Control:   publicDialControl(allowPrivate),
+	}
+
+	transport := &http.Transport{
+		// No proxy: a proxy would make the connection on our behalf and bypass the
+		// address check
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: connectTimeout,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return ErrTooManyRedirects
+			}
+			_, err := ValidatePublicURL(req.URL.String(), allowPrivate)
+			return err
+		},
+	}
+}
+
+// MaxBytes returns the largest body the fetcher will read
+func (f *ImageFetcher) MaxBytes() int64 {
+	return f.config.MaxBytes
+}
+
+// Fetch downloads an image, rejecting non-image responses and bodies over the
+// size limit
+func (f *ImageFetcher) Fetch(ctx context.Context, rawURL string) (*FetchedImage, error) {
+	resp, release, err := f.do(ctx, http.MethodGet, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d fetching image", resp.StatusCode)
+	}
+
+	contentType, err := imageContentType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.ContentLength > f.config.MaxBytes {
+		return nil, fmt.Errorf("%w: %d bytes (max %d)", ErrImageTooLarge, resp.ContentLength, f.config.MaxBytes)
+	}
+
+	// Read one byte past the limit to detect bodies without a Content-Length
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.config.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > f.config.MaxBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrImageTooLarge, f.config.MaxBytes)
+	}
+
+	return &FetchedImage{
+		URL:         resp.Request.URL.String(),
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
+
+// Probe checks an image URL with a HEAD request without downloading the body
+func (f *ImageFetcher) Probe(ctx context.Context, rawURL string) (*ProbeResult, error) {
+	resp, release, err := f.do(ctx, http.MethodHead, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d probing image", resp.StatusCode)
+	}
+
+	contentType, err := imageContentType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.ContentLength > f.config.MaxBytes {
+		return nil, fmt.Errorf("%w: %d bytes (max %d)", ErrImageTooLarge, resp.ContentLength, f.config.MaxBytes)
+	}
+
+	return &ProbeResult{
+		URL:           resp.Request.URL.String(),
+		ContentType:   contentType,
+		ContentLength: resp.ContentLength,
+	}, nil
+}
+
+// do validates the URL, waits for a slot on the host and sends the request. The
+// returned release func frees the slot and must be called once the body is read.
+func (f *ImageFetcher) do(ctx context.Context, method, rawURL string) (*http.Response, func(), error) {
+	parsed, err := f.validateURL(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	release, err := f.acquireHost(ctx, parsed.Hostname())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, parsed.String(), nil)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", f.config.UserAgent)
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		release()
+		// Surface our sentinel errors through the url.Error wrapper
+		for _, sentinel := range []error{ErrBlockedAddress, ErrTooManyRedirects, ErrUnsupportedScheme} {
+			if errors.Is(err, sentinel) {
+				return nil, nil, fmt.Errorf("%w: %s", sentinel, rawURL)
+			}
+		}
+		return nil, nil, fmt.Errorf("failed to fetch image: %w", err)
+	}
+
+	return resp, release, nil
+}
+
+// validateURL rejects non-http schemes, credentials in the URL and literal
+// blocked addresses before any connection is attempted
+func (f *ImageFetcher) validateURL(rawURL string) (*url.URL, error) {
+	return ValidatePublicURL(rawURL, f.allowPrivate)
+}
+
+// ValidatePublicURL rejects non-http schemes, credentials in the URL and
+// literal blocked addresses. Hostnames are checked when the connection is made.
+func ValidatePublicURL(rawURL string, allowPrivate bool) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, ErrUnsupportedScheme
+	}
+	if parsed.Hostname() == "" {
+		return nil, fmt.Errorf("invalid URL: missing host")
+	}
+	if parsed.User != nil {
+		return nil, fmt.Errorf("invalid URL: credentials are not allowed")
+	}
+
+	if !allowPrivate {
+		host := strings.ToLower(parsed.Hostname())
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+			return nil, fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+		}
+		if addr, err := netip.ParseAddr(host); err == nil && IsBlockedAddress(addr) {
+			return nil, fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+		}
+	}
+
+	return parsed, nil
+}
+
+// publicDialControl checks every connection with the resolved IP
+func publicDialControl(allowPrivate bool) func(network, address string, _ syscall.RawConn) error {
+	return func(network, address string, _ syscall.RawConn) error {
+		if allowPrivate {
+			return nil
+		}
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+		}
+		addr, err := netip.ParseAddr(host)
+		if err != nil || IsBlockedAddress(addr) {
+			return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+		}
+		return nil
+	}
+}
+
+// acquireHost blocks until the host has a free request slot
+func (f *ImageFetcher) acquireHost(ctx context.Context, host string) (func(), error) {
+	f.hostsMu.Lock()
+	slots, ok := f.hostSlots[host]
+	if !ok {
+		slots = make(chan struct{}, f.config.PerHostConcurrency)
+		f.hostSlots[host] = slots
+	}
+	f.hostsMu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// imageContentType returns the media type if it is an image
+func imageContentType(header string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil || !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedContentType, header)
+	}
+	return mediaType, nil
+}
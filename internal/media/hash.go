@@ -0,0 +1,176 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+const (
+	// pHashSize is the side of the grayscale image the DCT is taken over
+	pHashSize = 32
+	// pHashLowFrequencies is the side of the low-frequency block kept from the DCT
+	pHashLowFrequencies = 8
+)
+
+// ImageHashes are the perceptual hashes of one image. Copies of an image that
+// were resized, recompressed or slightly recoloured differ in only a few bits.
+type ImageHashes struct {
+	PHash uint64 // DCT hash, robust to scaling and compression
+	DHash uint64 // Gradient hash, cheap and sensitive to crops and flips
+}
+
+// ImageInfo is what decoding an image yields
+type ImageInfo struct {
+	Format string
+	Width  int
+	Height int
+	Hashes ImageHashes
+}
+
+// InspectImage decodes image bytes and computes their perceptual hashes. A few
+// megabytes of compressed data can declare billions of pixels, so the declared
+// size is checked against maxPixels before anything is decoded.
+func InspectImage(data []byte, maxPixels int64) (*ImageInfo, image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+		return nil, nil, fmt.Errorf("%w: %dx%d pixels", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	bounds := img.Bounds()
+	return &ImageInfo{
+		Format: format,
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+		Hashes: ComputeHashes(img),
+	}, img, nil
+}
+
+// ComputeHashes returns both perceptual hashes of an image
+func ComputeHashes(img image.Image) ImageHashes {
+	return ImageHashes{
+		PHash: PerceptualHash(img),
+		DHash: DifferenceHash(img),
+	}
+}
+
+// HashDistance returns the number of differing bits between two hashes
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// FormatHash renders a 64-bit perceptual hash as 16 hex digits
+func FormatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// ParseHash parses a hash written by FormatHash
+func ParseHash(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// PerceptualHash computes a 64-bit pHash: the image is shrunk to 32x32 grayscale,
+// transformed with a 2D DCT, and each bit records whether one of the 8x8 lowest
+// frequencies is above their median. The DC term is left out of the median.
+func PerceptualHash(img image.Image) uint64 {
+	gray := grayscale(img, pHashSize, pHashSize)
+
+	// Separable DCT-II: rows first, then the columns of the low frequencies we keep
+	cosines := dctCosines()
+	rows := make([]float64, pHashSize*pHashLowFrequencies)
+	for y := 0; y < pHashSize; y++ {
+		for u := 0; u < pHashLowFrequencies; u++ {
+			var sum float64
+			for x := 0; x < pHashSize; x++ {
+				sum += gray[y*pHashSize+x] * cosines[u][x]
+			}
+			rows[y*pHashLowFrequencies+u] = sum
+		}
+	}
+
+	coefficients := make([]float64, pHashLowFrequencies*pHashLowFrequencies)
+	for v := 0; v < pHashLowFrequencies; v++ {
+		for u := 0; u < pHashLowFrequencies; u++ {
+			var sum float64
+			for y := 0; y < pHashSize; y++ {
+				sum += rows[y*pHashLowFrequencies+u] * cosines[v][y]
+			}
+			coefficients[v*pHashLowFrequencies+u] = sum
+		}
+	}
+
+	sorted := append([]float64(nil), coefficients[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for _, c := range coefficients {
+		hash <<= 1
+		if c > median {
+			hash |= 1
+		}
+	}
+	return hash
+}
+
+// dctCosines returns cos((2x+1)uπ/2N) for the kept frequencies u
+func dctCosines() [pHashLowFrequencies][pHashSize]float64 {
+	var cosines [pHashLowFrequencies][pHashSize]float64
+	for u := 0; u < pHashLowFrequencies; u++ {
+		for x := 0; x < pHashSize; x++ {
+			cosines[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * pHashSize))
+		}
+	}
+	return cosines
+}
+
+// DifferenceHash computes a 64-bit dHash: the image is shrunk to 9x8 grayscale
+// and each bit records whether a pixel is brighter than its right neighbour.
+func DifferenceHash(img image.Image) uint64 {
+	gray := grayscale(img, 9, 8)
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if gray[y*9+x] > gray[y*9+x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// grayscale box-samples the image to width x height luminance values
+func grayscale(img image.Image, width, height int) []float64 {
+	bounds := img.Bounds()
+	out := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/width, x0+1)
+
+			var sum float64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					sum += float64(color.GrayModel.Convert(img.At(sx, sy)).(color.Gray).Y)
+				}
+			}
+			out[y*width+x] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+	return out
+}
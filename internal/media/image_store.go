@@ -0,0 +1,233 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // Register decoders for image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/temcen/pirex/internal/config"
+)
+
+const (
+	BackendLocal = "local"
+
+	defaultThumbnailSize = 256
+	thumbnailQuality     = 80
+)
+
+// StoredImage describes an image kept in the blob store
+type StoredImage struct {
+	Key          string    `json:"key"`
+	URL          string    `json:"url"`
+	FinalURL     string    `json:"final_url"` // After redirects
+	ContentType  string    `json:"content_type"`
+	Format       string    `json:"format"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	PHash        string    `json:"phash"` // 64-bit DCT perceptual hash as 16 hex digits
+	DHash        string    `json:"dhash"` // 64-bit difference hash as 16 hex digits
+	OriginalKey  string    `json:"original_key"`
+	ThumbnailKey string    `json:"thumbnail_key"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// Hashes parses the stored perceptual hashes
+func (s *StoredImage) Hashes() (ImageHashes, error) {
+	pHash, err := ParseHash(s.PHash)
+	if err != nil {
+		return ImageHashes{}, fmt.Errorf("invalid stored pHash: %w", err)
+	}
+	dHash, err := ParseHash(s.DHash)
+	if err != nil {
+		return ImageHashes{}, fmt.Errorf("invalid stored dHash: %w", err)
+	}
+	return ImageHashes{PHash: pHash, DHash: dHash}, nil
+}
+
+// ImageStore fetches images once and keeps the original bytes, a thumbnail and
+// metadata in a blob store. Blobs live under images/<sha256(url)>/.
+type ImageStore struct {
+	fetcher       *ImageFetcher
+	blobs         BlobStore
+	thumbnailSize int
+	logger        *logrus.Logger
+}
+
+func NewImageStore(fetcher *ImageFetcher, blobs BlobStore, thumbnailSize int, logger *logrus.Logger) *ImageStore {
+	if thumbnailSize <= 0 {
+		thumbnailSize = defaultThumbnailSize
+	}
+	return &ImageStore{
+		fetcher:       fetcher,
+		blobs:         blobs,
+		thumbnailSize: thumbnailSize,
+		logger:        logger,
+	}
+}
+
+// NewBlobStore creates the configured blob store backend
+func NewBlobStore(cfg config.ImageStoreConfig) (BlobStore, error) {
+	switch cfg.Backend {
+	case "", BackendLocal:
+		return NewLocalBlobStore(cfg.LocalPath)
+	default:
+		return nil, fmt.Errorf("unknown image store backend %q", cfg.Backend)
+	}
+}
+
+// Fetcher returns the fetcher used for downloads
+func (s *ImageStore) Fetcher() *ImageFetcher {
+	return s.fetcher
+}
+
+// Get returns a stored image and its bytes, downloading and storing it on first use
+func (s *ImageStore) Get(ctx context.Context, imageURL string) (*StoredImage, []byte, error) {
+	stored, err := s.Metadata(ctx, imageURL)
+	if err == nil {
+		data, err := s.blobs.Get(ctx, stored.OriginalKey)
+		if err == nil {
+			return stored, data, nil
+		}
+		s.logger.WithError(err).WithField("url", imageURL).Warn("Stored image is incomplete, fetching again")
+	} else if !errors.Is(err, ErrBlobNotFound) {
+		s.logger.WithError(err).WithField("url", imageURL).Warn("Failed to read stored image metadata")
+	}
+
+	fetched, err := s.fetcher.Fetch(ctx, imageURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stored, err = s.Put(ctx, imageURL, fetched)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stored, fetched.Data, nil
+}
+
+// Metadata returns the stored metadata for a URL, or ErrBlobNotFound
+func (s *ImageStore) Metadata(ctx context.Context, imageURL string) (*StoredImage, error) {
+	data, err := s.blobs.Get(ctx, imageKey(imageURL)+"/meta.json")
+	if err != nil {
+		return nil, err
+	}
+	var stored StoredImage
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("corrupt image metadata: %w", err)
+	}
+	return &stored, nil
+}
+
+// Thumbnail returns the stored JPEG thumbnail for a URL, or ErrBlobNotFound
+func (s *ImageStore) Thumbnail(ctx context.Context, imageURL string) ([]byte, error) {
+	return s.blobs.Get(ctx, imageKey(imageURL)+"/thumb.jpg")
+}
+
+// Put decodes a fetched image and stores the original, thumbnail and metadata.
+// Metadata is written last so a partially stored image is fetched again.
+func (s *ImageStore) Put(ctx context.Context, imageURL string, fetched *FetchedImage) (*StoredImage, error) {
+	info, img, err := InspectImage(fetched.Data, s.fetcher.MaxPixels())
+	if err != nil {
+		return nil, err
+	}
+
+	key := imageKey(imageURL)
+	stored := &StoredImage{
+		Key:          key,
+		URL:          imageURL,
+		FinalURL:     fetched.URL,
+		ContentType:  fetched.ContentType,
+		Format:       info.Format,
+		Size:         int64(len(fetched.Data)),
+		Width:        info.Width,
+		Height:       info.Height,
+		PHash:        FormatHash(info.Hashes.PHash),
+		DHash:        FormatHash(info.Hashes.DHash),
+		OriginalKey:  key + "/original",
+		ThumbnailKey: key + "/thumb.jpg",
+		FetchedAt:    time.Now(),
+	}
+
+	var thumb bytes.Buffer
+	if err := jpeg.Encode(&thumb, Thumbnail(img, s.thumbnailSize), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	metadata, err := json.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.blobs.Put(ctx, stored.OriginalKey, fetched.Data); err != nil {
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+	if err := s.blobs.Put(ctx, stored.ThumbnailKey, thumb.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to store thumbnail: %w", err)
+	}
+	if err := s.blobs.Put(ctx, key+"/meta.json", metadata); err != nil {
+		return nil, fmt.Errorf("failed to store image metadata: %w", err)
+	}
+
+	return stored, nil
+}
+
+func imageKey(imageURL string) string {
+	sum := sha256.Sum256([]byte(imageURL))
+	digest := hex.EncodeToString(sum[:])
+	return "images/" + digest[:2] + "/" + digest
+}
+
+// Thumbnail scales an image down to fit in a size x size box, averaging the
+// source pixels covered by each output pixel. Smaller images are returned as is.
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= size && srcH <= size {
+		return img
+	}
+
+	dstW, dstH := size, size
+	if srcW > srcH {
+		dstH = max(1, srcH*size/srcW)
+	} else {
+		dstW = max(1, srcW*size/srcH)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0 := bounds.Min.Y + y*srcH/dstH
+		y1 := max(bounds.Min.Y+(y+1)*srcH/dstH, y0+1)
+		for x := 0; x < dstW; x++ {
+			x0 := bounds.Min.X + x*srcW/dstW
+			x1 := max(bounds.Min.X+(x+1)*srcW/dstW, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
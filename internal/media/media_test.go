@@ -0,0 +1,351 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 255 / width), G: uint8(y * 255 / height), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// texturedImage has structure at several scales, like a photo
+func texturedImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			fx, fy := float64(x)/float64(width), float64(y)/float64(height)
+			v := 128 + 60*math.Sin(fx*7)*math.Cos(fy*5) + 40*math.Sin((fx+2*fy)*11)
+			if (fx-0.3)*(fx-0.3)+(fy-0.6)*(fy-0.6) < 0.02 {
+				v = 240
+			}
+			img.Set(x, y, color.RGBA{R: uint8(v), G: uint8(v * 0.8), B: uint8(255 - v), A: 255})
+		}
+	}
+	return img
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage(width, height)))
+	return buf.Bytes()
+}
+
+// localFetcher can reach httptest servers on loopback
+func localFetcher(cfg config.ImageFetchConfig) *ImageFetcher {
+	cfg.AllowPrivateNetworks = true
+	return NewImageFetcher(cfg)
+}
+
+func TestIsBlockedAddress(t *testing.T) {
+	tests := []struct {
+		addr    string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.20.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"::1", true},
+		{"fd00:ec2::254", true},
+		{"fe80::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"8.8.8.8", false},
+		{"93.184.216.34", false},
+		{"2606:4700:4700::1111", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.blocked, IsBlockedAddress(netip.MustParseAddr(tt.addr)), tt.addr)
+	}
+}
+
+func TestImageFetcher_BlocksInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(testPNG(t, 4, 4))
+	}))
+	defer server.Close()
+
+	fetcher := NewImageFetcher(DefaultImageFetchConfig())
+	ctx := context.Background()
+
+	for _, rawURL := range []string{
+		server.URL + "/a.png", // Literal loopback IP
+		"http://localhost/a.png",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]/a.png",
+	} {
+		_, err := fetcher.Fetch(ctx, rawURL)
+		assert.ErrorIs(t, err, ErrBlockedAddress, rawURL)
+	}
+
+	_, err := fetcher.Fetch(ctx, "file:///etc/passwd")
+	assert.ErrorIs(t, err, ErrUnsupportedScheme)
+
+	// The dial check catches hostnames resolving to internal addresses
+	assert.ErrorIs(t, publicDialControl(false)("tcp", "10.0.0.5:80", nil), ErrBlockedAddress)
+	assert.NoError(t, publicDialControl(false)("tcp", "93.184.216.34:443", nil))
+}
+
+func TestImageFetcher_RedirectsAndLimits(t *testing.T) {
+	pngData := testPNG(t, 8, 8)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngData)
+	})
+	mux.HandleFunc("/hop/", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hop/"))
+		if n == 0 {
+			http.Redirect(w, r, "/image.png", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, "/hop/"+strconv.Itoa(n-1), http.StatusFound)
+	})
+	mux.HandleFunc("/text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := localFetcher(config.ImageFetchConfig{MaxRedirects: 2, MaxBytes: 1 << 20})
+	ctx := context.Background()
+
+	fetched, err := fetcher.Fetch(ctx, server.URL+"/hop/1") // Two redirects
+	require.NoError(t, err)
+	assert.Equal(t, pngData, fetched.Data)
+	assert.Equal(t, "image/png", fetched.ContentType)
+	assert.Equal(t, server.URL+"/image.png", fetched.URL)
+
+	_, err = fetcher.Fetch(ctx, server.URL+"/hop/2") // Three redirects
+	assert.ErrorIs(t, err, ErrTooManyRedirects)
+
+	_, err = fetcher.Fetch(ctx, server.URL+"/text")
+	assert.ErrorIs(t, err, ErrUnsupportedContentType)
+
+	probe, err := fetcher.Probe(ctx, server.URL+"/image.png")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(pngData)), probe.ContentLength)
+
+	small := localFetcher(config.ImageFetchConfig{MaxBytes: 10})
+	_, err = small.Fetch(ctx, server.URL+"/image.png")
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+
+	// Redirect targets are checked against the blocklist too
+	strict := NewImageFetcher(DefaultImageFetchConfig())
+	assert.ErrorIs(t, strict.client.CheckRedirect(mustRequest(t, "http://169.254.169.254/"), nil), ErrBlockedAddress)
+}
+
+func TestImageFetcher_PerHostConcurrency(t *testing.T) {
+	var active, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("x"))
+	}))
+	defer server.Close()
+
+	fetcher := localFetcher(config.ImageFetchConfig{PerHostConcurrency: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := fetcher.Fetch(context.Background(), server.URL+"/x.png")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(2))
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	store, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = store.Get(ctx, "a/b")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+
+	require.NoError(t, store.Put(ctx, "a/b", []byte("hello")))
+	data, err := store.Get(ctx, "a/b")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), data)
+
+	exists, err := store.Exists(ctx, "a/b")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, store.Delete(ctx, "a/b"))
+	exists, err = store.Exists(ctx, "a/b")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.NoError(t, store.Delete(ctx, "a/b"))
+
+	for _, key := range []string{"", "../escape", "a/../../b", "/abs", "a\\b", "a//b"} {
+		assert.Error(t, store.Put(ctx, key, []byte("x")), key)
+	}
+}
+
+func TestImageStore_FetchesOnce(t *testing.T) {
+	pngData := testPNG(t, 600, 300)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngData)
+	}))
+	defer server.Close()
+
+	blobs, err := NewBlobStore(config.ImageStoreConfig{Backend: BackendLocal, LocalPath: t.TempDir()})
+	require.NoError(t, err)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	store := NewImageStore(localFetcher(config.ImageFetchConfig{}), blobs, 128, logger)
+	ctx := context.Background()
+
+	stored, data, err := store.Get(ctx, server.URL+"/p.png")
+	require.NoError(t, err)
+	assert.Equal(t, pngData, data)
+	assert.Equal(t, 600, stored.Width)
+	assert.Equal(t, 300, stored.Height)
+	assert.Equal(t, "png", stored.Format)
+	assert.Len(t, stored.DHash, 16)
+
+	// Second read comes from the blob store
+	again, data, err := store.Get(ctx, server.URL+"/p.png")
+	require.NoError(t, err)
+	assert.Equal(t, pngData, data)
+	assert.Equal(t, stored.DHash, again.DHash)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	thumbData, err := store.Thumbnail(ctx, server.URL+"/p.png")
+	require.NoError(t, err)
+	thumb, _, err := image.Decode(bytes.NewReader(thumbData))
+	require.NoError(t, err)
+	assert.Equal(t, 128, thumb.Bounds().Dx())
+	assert.Equal(t, 64, thumb.Bounds().Dy())
+
+	_, err = NewBlobStore(config.ImageStoreConfig{Backend: "s3"})
+	assert.Error(t, err)
+}
+
+func TestDifferenceHash(t *testing.T) {
+	original := testImage(300, 200)
+	resized := Thumbnail(original, 120)
+
+	// A resized copy stays within a few bits
+	distance := HashDistance(DifferenceHash(original), DifferenceHash(resized))
+	assert.LessOrEqual(t, distance, 4)
+
+	// A flipped image does not
+	flipped := image.NewRGBA(original.Bounds())
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			flipped.Set(299-x, y, original.At(x, y))
+		}
+	}
+	assert.Greater(t, HashDistance(DifferenceHash(original), DifferenceHash(flipped)), 32)
+
+	assert.Equal(t, "00000000000000ff", FormatHash(0xff))
+}
+
+func mustRequest(t *testing.T, rawURL string) *http.Request {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	require.NoError(t, err)
+	return req
+}
+
+func TestPerceptualHash(t *testing.T) {
+	original := texturedImage(320, 240)
+
+	// Resized and recompressed copies stay within a few bits
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, Thumbnail(original, 160), &jpeg.Options{Quality: 60}))
+	info, _, err := InspectImage(buf.Bytes(), defaultMaxImagePixels)
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", info.Format)
+	assert.Equal(t, 160, info.Width)
+
+	hashes := ComputeHashes(original)
+	assert.LessOrEqual(t, HashDistance(hashes.PHash, info.Hashes.PHash), 3)
+	assert.LessOrEqual(t, HashDistance(hashes.DHash, info.Hashes.DHash), 3)
+
+	// A different picture is far away
+	rotated := image.NewRGBA(image.Rect(0, 0, 240, 320))
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 320; x++ {
+			rotated.Set(239-y, x, original.At(x, y))
+		}
+	}
+	assert.Greater(t, HashDistance(hashes.PHash, PerceptualHash(rotated)), 10)
+
+	parsed, err := ParseHash(FormatHash(hashes.PHash))
+	require.NoError(t, err)
+	assert.Equal(t, hashes.PHash, parsed)
+
+	_, _, err = InspectImage([]byte("not an image"), defaultMaxImagePixels)
+	assert.Error(t, err)
+}
+
+func TestInspectImage_DeclaredSize(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, gif.Encode(&buf, image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{color.Black, color.White}), nil))
+	data := buf.Bytes()
+
+	_, _, err := InspectImage(data, 16)
+	require.NoError(t, err)
+	_, _, err = InspectImage(data, 15)
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+
+	// A tiny file declaring 65535x65535 pixels is refused without decoding it
+	binary.LittleEndian.PutUint16(data[6:8], 0xffff)
+	binary.LittleEndian.PutUint16(data[8:10], 0xffff)
+	_, _, err = InspectImage(data, defaultMaxImagePixels)
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+}
@@ -18,6 +18,9 @@ const (
 	ContentIngestionTopic    = "content-ingestion"
 	ContentIngestionDLQTopic = "content-ingestion-dlq"
 	ConsumerGroup            = "content-processors"
+
+	// UserDeletionTopic announces deleted users to consumers holding their data
+	UserDeletionTopic = "user-deletions"
 )
 
 type KafkaMessage struct {
@@ -28,6 +31,15 @@ type KafkaMessage struct {
 	ProcessingHints map[string]interface{}         `json:"processing_hints,omitempty"`
 }
 
+// UserDeletionEvent tells consumers to purge everything they derived from a
+// user's records. Records already on topics cannot be deleted and age out
+// with the topics' retention.
+type UserDeletionEvent struct {
+	UserID      uuid.UUID `json:"user_id"`
+	JobID       uuid.UUID `json:"job_id"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
 type KafkaProducer struct {
 	writer *kafka.Writer
 	logger *logrus.Logger
@@ -42,7 +54,9 @@ type MessageBus struct {
 	producer  *KafkaProducer
 	consumer  *KafkaConsumer
 	dlqWriter *kafka.Writer
-	logger    *logrus.Logger
+	// deletionWriter publishes user deletion events
+	deletionWriter *kafka.Writer
+	logger         *logrus.Logger
 }
 
 func NewMessageBus(cfg *config.Config, logger *logrus.Logger) (*MessageBus, error) {
@@ -82,11 +96,20 @@ func NewMessageBus(cfg *config.Config, logger *logrus.Logger) (*MessageBus, erro
 		Async:        false,
 	}
 
+	deletionWriter := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Kafka.Brokers...),
+		Topic:        UserDeletionTopic,
+		Balancer:     &kafka.Hash{}, // Keyed by user like the topics it purges
+		RequiredAcks: kafka.RequireAll,
+		Async:        false,
+	}
+
 	return &MessageBus{
-		producer:  producer,
-		consumer:  consumer,
-		dlqWriter: dlqWriter,
-		logger:    logger,
+		producer:       producer,
+		consumer:       consumer,
+		dlqWriter:      dlqWriter,
+		deletionWriter: deletionWriter,
+		logger:         logger,
 	}, nil
 }
 
@@ -131,6 +154,89 @@ func (mb *MessageBus) PublishContentIngestion(jobID uuid.UUID, content models.Co
 	return nil
 }
 
+// PublishContentIngestionBatch writes several items for the same job in a single
+// Kafka request. hints[i] holds the processing hints for items[i].
+func (mb *MessageBus) PublishContentIngestionBatch(jobID uuid.UUID, items []models.ContentIngestionRequest, hints []map[string]interface{}) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	kafkaMessages := make([]kafka.Message, 0, len(items))
+	for i, content := range items {
+		var itemHints map[string]interface{}
+		if i < len(hints) {
+			itemHints = hints[i]
+		}
+
+		messageBytes, err := json.Marshal(KafkaMessage{
+			JobID:           jobID,
+			ContentItem:     content,
+			Timestamp:       now,
+			RetryCount:      0,
+			ProcessingHints: itemHints,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal message %d: %w", i, err)
+		}
+
+		kafkaMessages = append(kafkaMessages, kafka.Message{
+			Key:   []byte(content.Type),
+			Value: messageBytes,
+			Headers: []kafka.Header{
+				{Key: "job_id", Value: []byte(jobID.String())},
+				{Key: "content_type", Value: []byte(content.Type)},
+				{Key: "timestamp", Value: []byte(now.Format(time.RFC3339))},
+			},
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := mb.producer.writer.WriteMessages(ctx, kafkaMessages...); err != nil {
+		mb.logger.WithError(err).WithField("job_id", jobID).Error("Failed to publish message batch to Kafka")
+		return fmt.Errorf("failed to write message batch to Kafka: %w", err)
+	}
+
+	mb.logger.WithFields(logrus.Fields{
+		"job_id":     jobID,
+		"batch_size": len(items),
+		"topic":      ContentIngestionTopic,
+	}).Debug("Message batch published to Kafka")
+
+	return nil
+}
+
+// PublishUserDeletion announces a deleted user, keyed by user ID
+func (mb *MessageBus) PublishUserDeletion(ctx context.Context, event UserDeletionEvent) error {
+	messageBytes, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deletion event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := mb.deletionWriter.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.UserID.String()),
+		Value: messageBytes,
+		Headers: []kafka.Header{
+			{Key: "job_id", Value: []byte(event.JobID.String())},
+			{Key: "timestamp", Value: []byte(event.RequestedAt.Format(time.RFC3339))},
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to write deletion event to Kafka: %w", err)
+	}
+
+	mb.logger.WithFields(logrus.Fields{
+		"job_id": event.JobID,
+		"topic":  UserDeletionTopic,
+	}).Info("User deletion event published to Kafka")
+
+	return nil
+}
+
 func (mb *MessageBus) ConsumeMessages(ctx context.Context, handler func(KafkaMessage) error) error {
 	for {
 		select {
@@ -258,6 +364,10 @@ func (mb *MessageBus) Close() error {
 		errors = append(errors, fmt.Errorf("failed to close DLQ writer: %w", err))
 	}
 
+	if err := mb.deletionWriter.Close(); err != nil {
+		errors = append(errors, fmt.Errorf("failed to close deletion writer: %w", err))
+	}
+
 	if len(errors) > 0 {
 		return fmt.Errorf("errors closing message bus: %v", errors)
 	}
@@ -270,6 +270,7 @@ func TestTopicConfiguration(t *testing.T) {
 	assert.Equal(t, "content-ingestion", ContentIngestionTopic)
 	assert.Equal(t, "content-ingestion-dlq", ContentIngestionDLQTopic)
 	assert.Equal(t, "content-processors", ConsumerGroup)
+	assert.Equal(t, "user-deletions", UserDeletionTopic)
 
 	// Test topic naming conventions
 	assert.Contains(t, ContentIngestionTopic, "content")
@@ -9,6 +9,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/temcen/pirex/internal/services"
+	"github.com/temcen/pirex/pkg/models"
 )
 
 func Auth(authService *services.AuthService, logger *logrus.Logger) gin.HandlerFunc {
@@ -77,10 +78,14 @@ func Auth(authService *services.AuthService, logger *logrus.Logger) gin.HandlerF
 				userID = uuid.New() // Generate temporary ID for API key requests
 			}
 
-			// Set user context
+			// Set user context. The user ID header is not proof of identity,
+			// so API key requests act on users only with an admin key.
 			c.Set("user_id", userID)
 			c.Set("user_tier", userTier)
 			c.Set("api_key", tokenString)
+			if authService.IsAdminAPIKey(tokenString) {
+				c.Set("user_role", models.RoleAdmin)
+			}
 			c.Next()
 			return
 		}
@@ -103,6 +108,8 @@ func Auth(authService *services.AuthService, logger *logrus.Logger) gin.HandlerF
 		c.Set("user_id", claims.UserID)
 		c.Set("user_tier", claims.UserTier)
 		c.Set("api_key", claims.APIKey)
+		c.Set("user_role", claims.Role)
+		c.Set("user_verified", true)
 		c.Next()
 	}
 }
@@ -114,3 +121,22 @@ func GetUserFromContext(c *gin.Context) (uuid.UUID, string, string) {
 
 	return userID.(uuid.UUID), userTier.(string), apiKey.(string)
 }
+
+// CanActOnUser reports whether the caller may read or change the data of the
+// given user: the caller is that user, authenticated by token, or an admin
+func CanActOnUser(c *gin.Context, userID uuid.UUID) bool {
+	if IsAdmin(c) {
+		return true
+	}
+	if !c.GetBool("user_verified") {
+		return false
+	}
+	callerID, ok := c.Get("user_id")
+	return ok && callerID == userID
+}
+
+// IsAdmin reports whether the caller authenticated with an admin API key or
+// a token carrying the admin role
+func IsAdmin(c *gin.Context) bool {
+	return c.GetString("user_role") == models.RoleAdmin
+}
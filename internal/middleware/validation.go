@@ -189,7 +189,7 @@ func (vm *ValidationMiddleware) ValidateQueryParams() gin.HandlerFunc {
 
 		// Validate interaction type parameter
 		if interactionType := c.Query("type"); interactionType != "" {
-			validTypes := []string{"rating", "like", "dislike", "share", "click", "view", "search", "browse"}
+			validTypes := []string{"rating", "like", "dislike", "not_interested", "share", "click", "view", "search", "browse", "purchase"}
 			if !vm.isValidEnum(interactionType, validTypes) {
 				errors = append(errors, validation.ValidationError{
 					Field:   "type",
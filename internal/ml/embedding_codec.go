@@ -0,0 +1,318 @@
+package ml
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// EmbeddingEncoding selects how vector components are stored
+type EmbeddingEncoding uint8
+
+const (
+	EncodingFloat32 EmbeddingEncoding = iota // 4 bytes per component, lossless
+	EncodingFloat16                          // 2 bytes per component, IEEE 754 half precision
+	EncodingInt8                             // 1 byte per component, symmetric scale per vector
+)
+
+// embeddingCodecVersion is bumped when the header layout changes
+const embeddingCodecVersion = 1
+
+// maxEmbeddingDimensions bounds what a header may claim, well above any model
+// in use, so corrupt data cannot make the decoder allocate gigabytes
+const maxEmbeddingDimensions = 1 << 16
+
+var (
+	embeddingMagic = [3]byte{'E', 'M', 'B'}
+
+	// ErrNotBinaryEmbedding means the data does not start with the binary header,
+	// e.g. a legacy JSON array
+	ErrNotBinaryEmbedding = errors.New("not a binary embedding")
+	ErrCorruptEmbedding   = errors.New("corrupt binary embedding")
+	// ErrStaleEmbedding means a cached vector was produced by another model or
+	// model version than the one in use
+	ErrStaleEmbedding = errors.New("embedding from another model version")
+)
+
+// EmbeddingHeader describes an encoded vector.
+//
+// Layout (little-endian): "EMB", codec version (1), encoding (1), reserved (1),
+// dimensions (uint32), model length (1) + model, version length (1) + version,
+// then for int8 a float32 scale, then the components.
+type EmbeddingHeader struct {
+	Encoding     EmbeddingEncoding
+	Model        string
+	ModelVersion string
+	Dimensions   int
+}
+
+func (e EmbeddingEncoding) String() string {
+	switch e {
+	case EncodingFloat32:
+		return "float32"
+	case EncodingFloat16:
+		return "float16"
+	case EncodingInt8:
+		return "int8"
+	default:
+		return fmt.Sprintf("encoding(%d)", uint8(e))
+	}
+}
+
+// ParseEmbeddingEncoding parses a configured encoding name; empty means float32
+func ParseEmbeddingEncoding(name string) (EmbeddingEncoding, error) {
+	switch name {
+	case "", "float32":
+		return EncodingFloat32, nil
+	case "float16":
+		return EncodingFloat16, nil
+	case "int8":
+		return EncodingInt8, nil
+	default:
+		return 0, fmt.Errorf("unknown embedding encoding %q (use float32, float16 or int8)", name)
+	}
+}
+
+// EncodeEmbedding serializes a vector with its header
+func EncodeEmbedding(header EmbeddingHeader, embedding []float32) ([]byte, error) {
+	if len(header.Model) > math.MaxUint8 || len(header.ModelVersion) > math.MaxUint8 {
+		return nil, fmt.Errorf("model name and version must be at most 255 bytes")
+	}
+	if len(embedding) > maxEmbeddingDimensions {
+		return nil, fmt.Errorf("embeddings are limited to %d dimensions", maxEmbeddingDimensions)
+	}
+
+	payload, ok := header.Encoding.payloadSize(len(embedding))
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding encoding %d", header.Encoding)
+	}
+
+	size := 10 + len(header.Model) + 1 + len(header.ModelVersion) + payload
+
+	buf := make([]byte, 0, size)
+	buf = append(buf, embeddingMagic[:]...)
+	buf = append(buf, embeddingCodecVersion, byte(header.Encoding), 0)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(embedding)))
+	buf = append(buf, byte(len(header.Model)))
+	buf = append(buf, header.Model...)
+	buf = append(buf, byte(len(header.ModelVersion)))
+	buf = append(buf, header.ModelVersion...)
+
+	switch header.Encoding {
+	case EncodingFloat32:
+		for _, v := range embedding {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+		}
+	case EncodingFloat16:
+		for _, v := range embedding {
+			buf = binary.LittleEndian.AppendUint16(buf, float32ToFloat16(v))
+		}
+	case EncodingInt8:
+		var maxAbs float32
+		for _, v := range embedding {
+			if abs := float32(math.Abs(float64(v))); abs > maxAbs {
+				maxAbs = abs
+			}
+		}
+		scale := maxAbs / 127
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(scale))
+		for _, v := range embedding {
+			var q float64
+			if scale > 0 {
+				q = math.Round(float64(v / scale))
+			}
+			buf = append(buf, byte(int8(math.Max(-127, math.Min(127, q)))))
+		}
+	}
+
+	return buf, nil
+}
+
+// DecodeEmbedding parses a vector written by EncodeEmbedding. Data without the
+// binary header returns ErrNotBinaryEmbedding so callers can fall back to JSON.
+func DecodeEmbedding(data []byte) (EmbeddingHeader, []float32, error) {
+	var header EmbeddingHeader
+	if len(data) < 3 || data[0] != embeddingMagic[0] || data[1] != embeddingMagic[1] || data[2] != embeddingMagic[2] {
+		return header, nil, ErrNotBinaryEmbedding
+	}
+	if len(data) < 10 {
+		return header, nil, ErrCorruptEmbedding
+	}
+	if data[3] != embeddingCodecVersion {
+		return header, nil, fmt.Errorf("%w: unsupported codec version %d", ErrCorruptEmbedding, data[3])
+	}
+
+	header.Encoding = EmbeddingEncoding(data[4])
+	dimensions := binary.LittleEndian.Uint32(data[6:10])
+	if dimensions > maxEmbeddingDimensions {
+		return header, nil, fmt.Errorf("%w: %d dimensions", ErrCorruptEmbedding, dimensions)
+	}
+	header.Dimensions = int(dimensions)
+	rest := data[10:]
+
+	var ok bool
+	if header.Model, rest, ok = readShortString(rest); !ok {
+		return header, nil, ErrCorruptEmbedding
+	}
+	if header.ModelVersion, rest, ok = readShortString(rest); !ok {
+		return header, nil, ErrCorruptEmbedding
+	}
+
+	// Check the length before allocating for what the header claims
+	payload, ok := header.Encoding.payloadSize(header.Dimensions)
+	if !ok {
+		return header, nil, fmt.Errorf("%w: unknown encoding %d", ErrCorruptEmbedding, header.Encoding)
+	}
+	if len(rest) != payload {
+		return header, nil, ErrCorruptEmbedding
+	}
+
+	embedding := make([]float32, header.Dimensions)
+	switch header.Encoding {
+	case EncodingFloat32:
+		for i := range embedding {
+			embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(rest[4*i:]))
+		}
+	case EncodingFloat16:
+		for i := range embedding {
+			embedding[i] = float16ToFloat32(binary.LittleEndian.Uint16(rest[2*i:]))
+		}
+	case EncodingInt8:
+		scale := math.Float32frombits(binary.LittleEndian.Uint32(rest))
+		for i := range embedding {
+			embedding[i] = float32(int8(rest[4+i])) * scale
+		}
+	}
+
+	return header, embedding, nil
+}
+
+// payloadSize returns the bytes after the header for a vector of the given
+// dimensions, or false for an unknown encoding
+func (e EmbeddingEncoding) payloadSize(dimensions int) (int, bool) {
+	switch e {
+	case EncodingFloat32:
+		return 4 * dimensions, true
+	case EncodingFloat16:
+		return 2 * dimensions, true
+	case EncodingInt8:
+		return 4 + dimensions, true // Scale, then one byte per component
+	default:
+		return 0, false
+	}
+}
+
+func readShortString(data []byte) (string, []byte, bool) {
+	if len(data) < 1 || len(data) < 1+int(data[0]) {
+		return "", nil, false
+	}
+	n := int(data[0])
+	return string(data[1 : 1+n]), data[1+n:], true
+}
+
+// float32ToFloat16 converts with round-to-nearest-even, saturating to infinity
+func float32ToFloat16(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int((bits >> 23) & 0xff)
+	mant := bits & 0x7fffff
+
+	switch {
+	case exp == 0xff: // Inf or NaN
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp-127 > 15: // Overflow
+		return sign | 0x7c00
+	case exp-127 >= -14: // Normal half
+		halfExp := uint32(exp-127+15) << 10
+		halfMant := mant >> 13
+		rounded := halfExp | halfMant
+		remainder := mant & 0x1fff
+		if remainder > 0x1000 || (remainder == 0x1000 && halfMant&1 == 1) {
+			rounded++ // May carry into the exponent, which is still correct
+		}
+		return sign | uint16(rounded)
+	case exp-127 >= -25: // Subnormal half
+		mant |= 0x800000
+		shift := uint32(-14-(exp-127)) + 13
+		halfMant := mant >> shift
+		remainder := mant & (1<<shift - 1)
+		halfway := uint32(1) << (shift - 1)
+		if remainder > halfway || (remainder == halfway && halfMant&1 == 1) {
+			halfMant++
+		}
+		return sign | uint16(halfMant)
+	default: // Underflow to zero
+		return sign
+	}
+}
+
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch {
+	case exp == 0x1f: // Inf or NaN
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case exp == 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// Subnormal: value = mant * 2^-24
+		value := float32(mant) / (1 << 24)
+		if sign != 0 {
+			value = -value
+		}
+		return value
+	default:
+		return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+	}
+}
+
+// decodeCachedEmbedding reads a binary cache entry, falling back to the legacy JSON
+// array format so entries written before the binary format keep working until expiry.
+// Binary entries written by another model or model version return ErrStaleEmbedding.
+func decodeCachedEmbedding(data []byte, model, version string) ([]float32, error) {
+	header, embedding, err := DecodeEmbedding(data)
+	if errors.Is(err, ErrNotBinaryEmbedding) {
+		var legacy []float32
+		if jsonErr := json.Unmarshal(data, &legacy); jsonErr != nil {
+			return nil, jsonErr
+		}
+		return legacy, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEmbeddingModel(header, model, version); err != nil {
+		return nil, err
+	}
+	return embedding, nil
+}
+
+// checkEmbeddingModel returns ErrStaleEmbedding unless the header names the model
+// and version in use
+func checkEmbeddingModel(header EmbeddingHeader, model, version string) error {
+	if header.Model != model || header.ModelVersion != version {
+		return fmt.Errorf("%w: cached %s@%s, using %s@%s",
+			ErrStaleEmbedding, header.Model, header.ModelVersion, model, version)
+	}
+	return nil
+}
+
+// modelVersion returns the registered version of a model for cache headers
+func modelVersion(registry *ModelRegistry, modelName string) string {
+	if registry == nil {
+		return "unknown"
+	}
+	modelInfo, err := registry.GetModelInfo(modelName)
+	if err != nil {
+		return "unknown"
+	}
+	return modelInfo.Version
+}
@@ -0,0 +1,149 @@
+package ml
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEmbedding(dims int) []float32 {
+	rng := rand.New(rand.NewSource(42))
+	embedding := make([]float32, dims)
+	for i := range embedding {
+		embedding[i] = float32(rng.NormFloat64() * 0.05)
+	}
+	return embedding
+}
+
+func TestEmbeddingCodec_RoundTrip(t *testing.T) {
+	embedding := testEmbedding(768)
+
+	tests := []struct {
+		encoding  EmbeddingEncoding
+		size      int
+		tolerance float64
+	}{
+		{EncodingFloat32, 4 * 768, 0},
+		{EncodingFloat16, 2 * 768, 1e-4},
+		{EncodingInt8, 768 + 4, 2e-3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding.String(), func(t *testing.T) {
+			header := EmbeddingHeader{Encoding: tt.encoding, Model: "all-MiniLM-L6-v2", ModelVersion: "1.0.0", Dimensions: 768}
+			data, err := EncodeEmbedding(header, embedding)
+			require.NoError(t, err)
+
+			// Header is 10 fixed bytes plus the length-prefixed model and version
+			assert.Equal(t, 10+1+len(header.Model)+1+len(header.ModelVersion)+tt.size, len(data))
+
+			decodedHeader, decoded, err := DecodeEmbedding(data)
+			require.NoError(t, err)
+			assert.Equal(t, header, decodedHeader)
+			require.Len(t, decoded, len(embedding))
+			for i := range embedding {
+				assert.InDelta(t, embedding[i], decoded[i], tt.tolerance)
+			}
+		})
+	}
+
+	// Much smaller than the JSON array it replaces
+	jsonData, _ := json.Marshal(embedding)
+	binaryData, _ := EncodeEmbedding(EmbeddingHeader{Encoding: EncodingFloat32}, embedding)
+	assert.Less(t, len(binaryData)*2, len(jsonData))
+}
+
+func TestEmbeddingCodec_Errors(t *testing.T) {
+	_, _, err := DecodeEmbedding([]byte(`[0.1,0.2]`))
+	assert.ErrorIs(t, err, ErrNotBinaryEmbedding)
+
+	data, err := EncodeEmbedding(EmbeddingHeader{Encoding: EncodingFloat16, Model: "m"}, []float32{1, 2, 3})
+	require.NoError(t, err)
+
+	_, _, err = DecodeEmbedding(data[:len(data)-1])
+	assert.ErrorIs(t, err, ErrCorruptEmbedding)
+
+	_, _, err = DecodeEmbedding(data[:8])
+	assert.ErrorIs(t, err, ErrCorruptEmbedding)
+
+	_, err = EncodeEmbedding(EmbeddingHeader{Encoding: EmbeddingEncoding(9)}, []float32{1})
+	assert.Error(t, err)
+
+	// A header claiming billions of dimensions is rejected before allocating
+	huge := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(huge[6:10], math.MaxUint32)
+	_, _, err = DecodeEmbedding(huge)
+	assert.ErrorIs(t, err, ErrCorruptEmbedding)
+
+	// So is one whose payload does not match its dimensions
+	binary.LittleEndian.PutUint32(huge[6:10], maxEmbeddingDimensions)
+	_, _, err = DecodeEmbedding(huge)
+	assert.ErrorIs(t, err, ErrCorruptEmbedding)
+
+	_, err = ParseEmbeddingEncoding("bf16")
+	assert.Error(t, err)
+	encoding, err := ParseEmbeddingEncoding("")
+	assert.NoError(t, err)
+	assert.Equal(t, EncodingFloat32, encoding)
+}
+
+func TestDecodeCachedEmbedding_LegacyJSON(t *testing.T) {
+	embedding, err := decodeCachedEmbedding([]byte(`[0.5,-0.25]`), "minilm", "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.5, -0.25}, embedding)
+
+	header := EmbeddingHeader{Encoding: EncodingFloat32, Model: "minilm", ModelVersion: "1.0"}
+	data, _ := EncodeEmbedding(header, []float32{0.5, -0.25})
+	embedding, err = decodeCachedEmbedding(data, "minilm", "1.0")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.5, -0.25}, embedding)
+
+	_, err = decodeCachedEmbedding([]byte("not an embedding"), "minilm", "1.0")
+	assert.Error(t, err)
+}
+
+func TestDecodeCachedEmbedding_ModelVersion(t *testing.T) {
+	data, err := EncodeEmbedding(EmbeddingHeader{Encoding: EncodingFloat32, Model: "minilm", ModelVersion: "1.0"}, []float32{1})
+	require.NoError(t, err)
+
+	// Vectors from an upgraded or different model are cache misses
+	_, err = decodeCachedEmbedding(data, "minilm", "2.0")
+	assert.ErrorIs(t, err, ErrStaleEmbedding)
+	_, err = decodeCachedEmbedding(data, "mpnet", "1.0")
+	assert.ErrorIs(t, err, ErrStaleEmbedding)
+}
+
+func TestFloat16Conversion(t *testing.T) {
+	tests := []struct {
+		value float32
+		bits  uint16
+	}{
+		{0, 0x0000},
+		{1, 0x3c00},
+		{-2, 0xc000},
+		{0.5, 0x3800},
+		{65504, 0x7bff},                 // Largest half
+		{6.103515625e-05, 0x0400},       // Smallest normal half
+		{5.960464477539063e-08, 0x0001}, // Smallest subnormal half
+		{1e6, 0x7c00},                   // Overflows to +Inf
+		{1e-10, 0x0000},                 // Underflows to zero
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.bits, float32ToFloat16(tt.value), "%g", tt.value)
+	}
+
+	assert.Equal(t, float32(1), float16ToFloat32(0x3c00))
+	assert.Equal(t, float32(5.960464477539063e-08), float16ToFloat32(0x0001))
+	assert.True(t, math.IsInf(float64(float16ToFloat32(0xfc00)), -1))
+	assert.True(t, math.IsNaN(float64(float16ToFloat32(float32ToFloat16(float32(math.NaN()))))))
+
+	// Round to nearest even: 1 + 2^-11 is halfway between 1 and the next half
+	assert.Equal(t, uint16(0x3c00), float32ToFloat16(1+1.0/2048))
+	assert.Equal(t, uint16(0x3c02), float32ToFloat16(1+3.0/2048))
+}
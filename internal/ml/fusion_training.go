@@ -0,0 +1,405 @@
+package ml
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ProjectionFitMethod identifies how a projection artifact was trained
+const ProjectionFitMethod = "paired_generalized_eigen"
+
+// Projection activations
+const (
+	ActivationReLU = "relu"
+	ActivationNone = "none"
+)
+
+var (
+	ErrInsufficientTrainingData = errors.New("not enough training pairs to fit projection")
+	ErrNoProjectionArtifact     = errors.New("no projection artifact found")
+
+	projectionArtifactPattern = regexp.MustCompile(`^fusion_projection_v(\d+)\.json$`)
+)
+
+// FusionInput holds the embeddings of one item. Either modality may be empty.
+type FusionInput struct {
+	Text  []float32 `json:"text,omitempty"`
+	Image []float32 `json:"image,omitempty"`
+}
+
+// FusionTrainingPair is a positive pair: two co-interacted items, or the text and
+// image of the same item ({"a": {"text": ...}, "b": {"image": ...}})
+type FusionTrainingPair struct {
+	A FusionInput `json:"a"`
+	B FusionInput `json:"b"`
+}
+
+// FitOptions controls projection fitting
+type FitOptions struct {
+	TextDimensions  int
+	ImageDimensions int
+	FinalDimensions int
+	Ridge           float64 // Regularization of the within-pair covariance, relative to its mean variance
+	MinPairs        int
+}
+
+// FitReport summarizes how well a projection separates positive pairs
+type FitReport struct {
+	Pairs          int     `json:"pairs"`
+	PairCosine     float64 `json:"pair_cosine"`     // Mean cosine of positive pairs after projection
+	ShuffledCosine float64 `json:"shuffled_cosine"` // Mean cosine of mismatched pairs after projection
+}
+
+// ProjectionArtifact is the persisted, versioned projection layer
+type ProjectionArtifact struct {
+	Version         int         `json:"version"`
+	CreatedAt       time.Time   `json:"created_at"`
+	FitMethod       string      `json:"fit_method"`
+	TextDimensions  int         `json:"text_dimensions"`
+	ImageDimensions int         `json:"image_dimensions"`
+	FinalDimensions int         `json:"final_dimensions"`
+	Activation      string      `json:"activation"`
+	Weights         [][]float64 `json:"weights"` // final_dimensions x (text_dimensions + image_dimensions)
+	Bias            []float32   `json:"bias"`
+	Report          FitReport   `json:"report"`
+}
+
+// FitProjection learns the projection applied to late-fused (concatenated) embeddings
+// so that positive pairs land close together while unrelated items stay spread out.
+// It maximizes w'·Ct·w / w'·Cw·w, where Ct is the covariance of all inputs and Cw the
+// covariance of within-pair differences, by solving the generalized eigenproblem and
+// keeping the top FinalDimensions directions.
+func FitProjection(pairs []FusionTrainingPair, opts FitOptions) (*ProjectionArtifact, error) {
+	if opts.MinPairs <= 0 {
+		opts.MinPairs = 100
+	}
+	if opts.Ridge <= 0 {
+		opts.Ridge = 1e-3
+	}
+	inputDims := opts.TextDimensions + opts.ImageDimensions
+	if opts.TextDimensions <= 0 || opts.ImageDimensions <= 0 || opts.FinalDimensions <= 0 {
+		return nil, fmt.Errorf("text, image and final dimensions must be positive")
+	}
+	if opts.FinalDimensions > inputDims {
+		return nil, fmt.Errorf("final dimensions %d exceed input dimensions %d", opts.FinalDimensions, inputDims)
+	}
+	if len(pairs) < opts.MinPairs {
+		return nil, fmt.Errorf("%w: got %d, need %d", ErrInsufficientTrainingData, len(pairs), opts.MinPairs)
+	}
+
+	n := len(pairs)
+	inputs := mat.NewDense(2*n, inputDims, nil)
+	diffs := mat.NewDense(n, inputDims, nil)
+	for i, pair := range pairs {
+		a, err := fusionTrainingInput(pair.A, opts.TextDimensions, opts.ImageDimensions)
+		if err != nil {
+			return nil, fmt.Errorf("pair %d: %w", i, err)
+		}
+		b, err := fusionTrainingInput(pair.B, opts.TextDimensions, opts.ImageDimensions)
+		if err != nil {
+			return nil, fmt.Errorf("pair %d: %w", i, err)
+		}
+		inputs.SetRow(2*i, a)
+		inputs.SetRow(2*i+1, b)
+		for j := range a {
+			diffs.Set(i, j, a[j]-b[j])
+		}
+	}
+
+	mean := make([]float64, inputDims)
+	for j := 0; j < inputDims; j++ {
+		mean[j] = mat.Sum(inputs.ColView(j)) / float64(2*n)
+	}
+	centered := mat.DenseCopyOf(inputs)
+	for i := 0; i < 2*n; i++ {
+		for j := 0; j < inputDims; j++ {
+			centered.Set(i, j, centered.At(i, j)-mean[j])
+		}
+	}
+
+	total := covariance(centered, float64(2*n))
+	within := covariance(diffs, float64(2*n))
+
+	// Ridge keeps Cw invertible when pairs agree exactly along some directions
+	ridge := opts.Ridge * mat.Trace(within) / float64(inputDims)
+	if ridge <= 0 {
+		ridge = opts.Ridge
+	}
+	for j := 0; j < inputDims; j++ {
+		within.SetSym(j, j, within.At(j, j)+ridge)
+	}
+
+	var chol mat.Cholesky
+	if !chol.Factorize(within) {
+		return nil, fmt.Errorf("within-pair covariance is not positive definite")
+	}
+	var lower, lowerInv mat.TriDense
+	chol.LTo(&lower)
+	if err := lowerInv.InverseTri(&lower); err != nil {
+		return nil, fmt.Errorf("failed to invert covariance factor: %w", err)
+	}
+
+	// Whitened problem: M = L^-1 Ct L^-T
+	var tmp, whitened mat.Dense
+	tmp.Mul(&lowerInv, total)
+	whitened.Mul(&tmp, lowerInv.T())
+	sym := mat.NewSymDense(inputDims, nil)
+	for i := 0; i < inputDims; i++ {
+		for j := i; j < inputDims; j++ {
+			sym.SetSym(i, j, (whitened.At(i, j)+whitened.At(j, i))/2)
+		}
+	}
+
+	var eigen mat.EigenSym
+	if !eigen.Factorize(sym, true) {
+		return nil, fmt.Errorf("eigendecomposition did not converge")
+	}
+	var vectors mat.Dense
+	eigen.VectorsTo(&vectors)
+
+	// Eigenvalues are ascending; rows of W are L^-T v for the largest ones
+	var directions mat.Dense
+	directions.Mul(lowerInv.T(), &vectors)
+
+	weights := make([][]float64, opts.FinalDimensions)
+	bias := make([]float32, opts.FinalDimensions)
+	for k := 0; k < opts.FinalDimensions; k++ {
+		col := inputDims - 1 - k
+		row := make([]float64, inputDims)
+		var offset float64
+		for j := 0; j < inputDims; j++ {
+			row[j] = directions.At(j, col)
+			offset += row[j] * mean[j]
+		}
+		weights[k] = row
+		bias[k] = float32(-offset)
+	}
+
+	artifact := &ProjectionArtifact{
+		CreatedAt:       time.Now().UTC(),
+		FitMethod:       ProjectionFitMethod,
+		TextDimensions:  opts.TextDimensions,
+		ImageDimensions: opts.ImageDimensions,
+		FinalDimensions: opts.FinalDimensions,
+		Activation:      ActivationNone,
+		Weights:         weights,
+		Bias:            bias,
+	}
+	artifact.Report = EvaluateProjection(artifact, pairs)
+
+	return artifact, nil
+}
+
+// EvaluateProjection reports the mean cosine of positive pairs and of mismatched
+// pairs (each A against the next pair's B) after projection
+func EvaluateProjection(artifact *ProjectionArtifact, pairs []FusionTrainingPair) FitReport {
+	report := FitReport{Pairs: len(pairs)}
+	if len(pairs) == 0 {
+		return report
+	}
+
+	projected := make([][2][]float64, 0, len(pairs))
+	for _, pair := range pairs {
+		a, errA := fusionTrainingInput(pair.A, artifact.TextDimensions, artifact.ImageDimensions)
+		b, errB := fusionTrainingInput(pair.B, artifact.TextDimensions, artifact.ImageDimensions)
+		if errA != nil || errB != nil {
+			continue
+		}
+		projected = append(projected, [2][]float64{artifact.project(a), artifact.project(b)})
+	}
+	if len(projected) == 0 {
+		return report
+	}
+
+	for i, pair := range projected {
+		report.PairCosine += cosine64(pair[0], pair[1])
+		report.ShuffledCosine += cosine64(pair[0], projected[(i+1)%len(projected)][1])
+	}
+	report.PairCosine /= float64(len(projected))
+	report.ShuffledCosine /= float64(len(projected))
+
+	return report
+}
+
+// Validate checks the artifact matches the fusion layout it will be loaded into
+func (pa *ProjectionArtifact) Validate(textDimensions, imageDimensions, finalDimensions int) error {
+	if pa.TextDimensions != textDimensions || pa.ImageDimensions != imageDimensions || pa.FinalDimensions != finalDimensions {
+		return fmt.Errorf("projection artifact v%d is %d+%d->%d, fusion expects %d+%d->%d",
+			pa.Version, pa.TextDimensions, pa.ImageDimensions, pa.FinalDimensions,
+			textDimensions, imageDimensions, finalDimensions)
+	}
+	if len(pa.Weights) != pa.FinalDimensions || len(pa.Bias) != pa.FinalDimensions {
+		return fmt.Errorf("projection artifact v%d has %d weight rows and %d biases, expected %d",
+			pa.Version, len(pa.Weights), len(pa.Bias), pa.FinalDimensions)
+	}
+	for i, row := range pa.Weights {
+		if len(row) != pa.TextDimensions+pa.ImageDimensions {
+			return fmt.Errorf("projection artifact v%d weight row %d has %d columns", pa.Version, i, len(row))
+		}
+	}
+	if pa.Activation != ActivationNone && pa.Activation != ActivationReLU {
+		return fmt.Errorf("projection artifact v%d has unknown activation %q", pa.Version, pa.Activation)
+	}
+	return nil
+}
+
+func (pa *ProjectionArtifact) project(input []float64) []float64 {
+	output := make([]float64, pa.FinalDimensions)
+	for i, row := range pa.Weights {
+		value := float64(pa.Bias[i])
+		for j, w := range row {
+			value += w * input[j]
+		}
+		if pa.Activation == ActivationReLU && value < 0 {
+			value = 0
+		}
+		output[i] = value
+	}
+	return output
+}
+
+// SaveProjectionArtifact writes the artifact to dir as the next version
+// (fusion_projection_v<N>.json) and returns the written path
+func SaveProjectionArtifact(dir string, artifact *ProjectionArtifact) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+
+	latest, err := latestProjectionVersion(dir)
+	if err != nil {
+		return "", err
+	}
+	artifact.Version = latest + 1
+
+	data, err := json.Marshal(artifact)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal projection artifact: %w", err)
+	}
+
+	path := filepath.Join(dir, projectionArtifactName(artifact.Version))
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write projection artifact: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to write projection artifact: %w", err)
+	}
+
+	return path, nil
+}
+
+// LoadProjectionArtifact reads an artifact file, or the highest version in a directory
+func LoadProjectionArtifact(path string) (*ProjectionArtifact, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat projection artifact: %w", err)
+	}
+	if info.IsDir() {
+		version, err := latestProjectionVersion(path)
+		if err != nil {
+			return nil, err
+		}
+		if version == 0 {
+			return nil, fmt.Errorf("%w in %s", ErrNoProjectionArtifact, path)
+		}
+		path = filepath.Join(path, projectionArtifactName(version))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read projection artifact: %w", err)
+	}
+
+	var artifact ProjectionArtifact
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		return nil, fmt.Errorf("failed to parse projection artifact %s: %w", path, err)
+	}
+	return &artifact, nil
+}
+
+func latestProjectionVersion(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list projection artifacts: %w", err)
+	}
+
+	latest := 0
+	for _, entry := range entries {
+		match := projectionArtifactPattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		if version, err := strconv.Atoi(match[1]); err == nil && version > latest {
+			latest = version
+		}
+	}
+	return latest, nil
+}
+
+func projectionArtifactName(version int) string {
+	return fmt.Sprintf("fusion_projection_v%d.json", version)
+}
+
+// fusionTrainingInput builds the late-fusion layout: L2-normalized text followed by
+// L2-normalized image, with zeros for a missing modality
+func fusionTrainingInput(input FusionInput, textDimensions, imageDimensions int) ([]float64, error) {
+	if len(input.Text) == 0 && len(input.Image) == 0 {
+		return nil, fmt.Errorf("item has neither text nor image embedding")
+	}
+	if len(input.Text) != 0 && len(input.Text) != textDimensions {
+		return nil, fmt.Errorf("text embedding dimension mismatch: expected %d, got %d", textDimensions, len(input.Text))
+	}
+	if len(input.Image) != 0 && len(input.Image) != imageDimensions {
+		return nil, fmt.Errorf("image embedding dimension mismatch: expected %d, got %d", imageDimensions, len(input.Image))
+	}
+
+	vec := make([]float64, textDimensions+imageDimensions)
+	copyNormalized(vec[:textDimensions], input.Text)
+	copyNormalized(vec[textDimensions:], input.Image)
+	return vec, nil
+}
+
+func copyNormalized(dst []float64, src []float32) {
+	var norm float64
+	for _, v := range src {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return
+	}
+	norm = math.Sqrt(norm)
+	for i, v := range src {
+		dst[i] = float64(v) / norm
+	}
+}
+
+// covariance returns X'X / n as a symmetric matrix
+func covariance(x *mat.Dense, n float64) *mat.SymDense {
+	_, cols := x.Dims()
+	cov := mat.NewSymDense(cols, nil)
+	cov.SymOuterK(1/n, x.T())
+	return cov
+}
+
+func cosine64(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
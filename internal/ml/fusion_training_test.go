@@ -0,0 +1,256 @@
+package ml
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syntheticFusionPairs pairs the text and image of items generated from a shared
+// latent vector, so a good projection should align them
+func syntheticFusionPairs(n, textDims, imageDims, latentDims int, seed int64) []FusionTrainingPair {
+	rng := rand.New(rand.NewSource(seed))
+
+	textMix := randomMatrix(rng, textDims, latentDims)
+	imageMix := randomMatrix(rng, imageDims, latentDims)
+
+	pairs := make([]FusionTrainingPair, n)
+	for i := range pairs {
+		latent := make([]float64, latentDims)
+		for j := range latent {
+			latent[j] = rng.NormFloat64()
+		}
+		pairs[i] = FusionTrainingPair{
+			A: FusionInput{Text: mixLatent(rng, textMix, latent)},
+			B: FusionInput{Image: mixLatent(rng, imageMix, latent)},
+		}
+	}
+	return pairs
+}
+
+func randomMatrix(rng *rand.Rand, rows, cols int) [][]float64 {
+	m := make([][]float64, rows)
+	for i := range m {
+		m[i] = make([]float64, cols)
+		for j := range m[i] {
+			m[i][j] = rng.NormFloat64()
+		}
+	}
+	return m
+}
+
+func mixLatent(rng *rand.Rand, mix [][]float64, latent []float64) []float32 {
+	out := make([]float32, len(mix))
+	for i, row := range mix {
+		var v float64
+		for j, w := range row {
+			v += w * latent[j]
+		}
+		out[i] = float32(v + 0.1*rng.NormFloat64())
+	}
+	return out
+}
+
+func TestFitProjection(t *testing.T) {
+	opts := FitOptions{TextDimensions: 12, ImageDimensions: 10, FinalDimensions: 4, MinPairs: 50}
+	pairs := syntheticFusionPairs(500, 12, 10, 4, 1)
+	training, holdout := pairs[:400], pairs[400:]
+
+	artifact, err := FitProjection(training, opts)
+	require.NoError(t, err)
+	require.NoError(t, artifact.Validate(12, 10, 4))
+
+	assert.Equal(t, ProjectionFitMethod, artifact.FitMethod)
+	assert.Equal(t, ActivationNone, artifact.Activation)
+	assert.Equal(t, 400, artifact.Report.Pairs)
+
+	// Text and image of the same item should align far better than mismatched ones
+	assert.Greater(t, artifact.Report.PairCosine, 0.8)
+	assert.Less(t, artifact.Report.ShuffledCosine, 0.3)
+
+	// The fit generalizes to held-out items
+	report := EvaluateProjection(artifact, holdout)
+	assert.Equal(t, 100, report.Pairs)
+	assert.Greater(t, report.PairCosine, report.ShuffledCosine+0.5)
+}
+
+func TestFitProjection_InvalidInput(t *testing.T) {
+	opts := FitOptions{TextDimensions: 12, ImageDimensions: 10, FinalDimensions: 4, MinPairs: 50}
+
+	_, err := FitProjection(syntheticFusionPairs(10, 12, 10, 4, 1), opts)
+	assert.ErrorIs(t, err, ErrInsufficientTrainingData)
+
+	pairs := syntheticFusionPairs(60, 12, 10, 4, 1)
+	pairs[3].B.Image = pairs[3].B.Image[:5]
+	_, err = FitProjection(pairs, opts)
+	assert.ErrorContains(t, err, "pair 3")
+
+	opts.FinalDimensions = 40
+	_, err = FitProjection(syntheticFusionPairs(60, 12, 10, 4, 1), opts)
+	assert.ErrorContains(t, err, "exceed input dimensions")
+}
+
+func TestProjectionArtifact_Versioning(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := LoadProjectionArtifact(dir)
+	assert.ErrorIs(t, err, ErrNoProjectionArtifact)
+
+	artifact, err := FitProjection(syntheticFusionPairs(200, 12, 10, 4, 2), FitOptions{
+		TextDimensions: 12, ImageDimensions: 10, FinalDimensions: 4, MinPairs: 50,
+	})
+	require.NoError(t, err)
+
+	path, err := SaveProjectionArtifact(dir, artifact)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "fusion_projection_v1.json"), path)
+
+	path, err = SaveProjectionArtifact(dir, artifact)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "fusion_projection_v2.json"), path)
+
+	// Unrelated files are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o644))
+
+	latest, err := LoadProjectionArtifact(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, latest.Version)
+	assert.Equal(t, artifact.Weights, latest.Weights)
+
+	first, err := LoadProjectionArtifact(filepath.Join(dir, "fusion_projection_v1.json"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.Version)
+
+	assert.ErrorContains(t, latest.Validate(12, 10, 8), "fusion expects")
+}
+
+func TestMultiModalFusion_ReloadNewerProjection(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	dir := t.TempDir()
+	artifact, err := FitProjection(syntheticFusionPairs(200, 12, 10, 4, 2), FitOptions{
+		TextDimensions: 12, ImageDimensions: 10, FinalDimensions: 4, MinPairs: 50,
+	})
+	require.NoError(t, err)
+	_, err = SaveProjectionArtifact(dir, artifact)
+	require.NoError(t, err)
+
+	fusionService := NewMultiModalFusionService(nil, nil, logger, MultiModalFusionConfig{
+		TextDimensions: 12, ImageDimensions: 10, FinalDimensions: 4, WeightsPath: dir,
+	})
+	assert.Equal(t, 1, fusionService.currentProjectionVersion())
+
+	// Nothing newer than the version loaded at startup
+	reloaded, err := fusionService.ReloadNewerProjection(dir)
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	// A version written later is picked up
+	_, err = SaveProjectionArtifact(dir, artifact)
+	require.NoError(t, err)
+	reloaded, err = fusionService.ReloadNewerProjection(dir)
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, 2, fusionService.currentProjectionVersion())
+
+	// A single artifact file is not watched
+	reloaded, err = fusionService.ReloadNewerProjection(filepath.Join(dir, "fusion_projection_v2.json"))
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+}
+
+func TestMultiModalFusion_Methods(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	fusionService := NewMultiModalFusionService(nil, nil, logger, MultiModalFusionConfig{
+		TextDimensions:  12,
+		ImageDimensions: 10,
+		FinalDimensions: 4,
+		ContentTypeMethods: map[string]string{
+			"article": FusionGated,
+			"video":   FusionWeightedEarly,
+			"product": "unknown",
+		},
+	})
+
+	assert.Equal(t, FusionGated, fusionService.MethodFor("article"))
+	assert.Equal(t, FusionWeightedEarly, fusionService.MethodFor("video"))
+	assert.Equal(t, FusionLateProjection, fusionService.MethodFor("product"))
+
+	pairs := syntheticFusionPairs(1, 12, 10, 4, 3)
+	text, image := pairs[0].A.Text, pairs[0].B.Image
+
+	t.Run("GatedTextOnly", func(t *testing.T) {
+		result, err := fusionService.FuseForContentType("article", text, nil)
+		require.NoError(t, err)
+		assert.Equal(t, FusionGated, result.FusionMethod)
+		assert.Equal(t, float32(1), result.TextWeight)
+		assert.Equal(t, float32(0), result.ImageWeight)
+		assert.Len(t, result.FinalEmbedding, 4)
+	})
+
+	t.Run("GatedBothModalities", func(t *testing.T) {
+		result, err := fusionService.FuseForContentType("article", text, image)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.6, result.TextWeight, 1e-6)
+		assert.InDelta(t, 0.4, result.ImageWeight, 1e-6)
+	})
+
+	t.Run("WeightedEarly", func(t *testing.T) {
+		result, err := fusionService.FuseForContentType("video", text, image)
+		require.NoError(t, err)
+		assert.Equal(t, FusionWeightedEarly, result.FusionMethod)
+		assert.Len(t, result.FusedEmbedding, 12)
+		assert.Len(t, result.FinalEmbedding, 4)
+	})
+
+	t.Run("NoModalities", func(t *testing.T) {
+		_, err := fusionService.FuseForContentType("product", nil, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("ApplyFittedProjection", func(t *testing.T) {
+		pairs := syntheticFusionPairs(301, 12, 10, 4, 4)
+		artifact, err := FitProjection(pairs[:300], FitOptions{
+			TextDimensions: 12, ImageDimensions: 10, FinalDimensions: 4, MinPairs: 50,
+		})
+		require.NoError(t, err)
+		artifact.Version = 7
+
+		require.NoError(t, fusionService.ApplyProjectionArtifact(artifact))
+		assert.Equal(t, 7, fusionService.GetFusionStats()["projection_version"])
+
+		// Text-only and image-only embeddings of an unseen item now land together
+		fromText, err := fusionService.FuseForContentType("product", pairs[300].A.Text, nil)
+		require.NoError(t, err)
+		fromImage, err := fusionService.FuseForContentType("product", nil, pairs[300].B.Image)
+		require.NoError(t, err)
+
+		var dot float64
+		for i := range fromText.FinalEmbedding {
+			dot += float64(fromText.FinalEmbedding[i] * fromImage.FinalEmbedding[i])
+		}
+		assert.Greater(t, dot, 0.5)
+	})
+}
+
+func TestModalityGates(t *testing.T) {
+	textGate, imageGate := modalityGates(true, true, 0.6, 0.4)
+	assert.InDelta(t, 0.6, textGate, 1e-6)
+	assert.InDelta(t, 0.4, imageGate, 1e-6)
+
+	textGate, imageGate = modalityGates(false, true, 0.6, 0.4)
+	assert.Equal(t, float32(0), textGate)
+	assert.Equal(t, float32(1), imageGate)
+
+	textGate, imageGate = modalityGates(true, false, 0, 0.4)
+	assert.Equal(t, float32(1), textGate)
+	assert.Equal(t, float32(0), imageGate)
+}
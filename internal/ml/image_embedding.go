@@ -5,17 +5,18 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
-	"io"
 	"math"
-	"net/http"
 	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/media"
 )
 
 // ImageEmbeddingService handles image embedding generation
@@ -32,8 +33,12 @@ type ImageEmbeddingService struct {
 	cachePrefix  string
 	cacheTTL     time.Duration
 
-	// HTTP client for fetching images
-	httpClient *http.Client
+	cacheEncoding EmbeddingEncoding
+
+	// Images are fetched through the SSRF-safe fetcher, or read from the image
+	// store when one is set
+	fetcher    *media.ImageFetcher
+	imageStore *media.ImageStore
 }
 
 // ImageEmbeddingConfig contains configuration for the image embedding service
@@ -44,6 +49,12 @@ type ImageEmbeddingConfig struct {
 	Timeout      time.Duration `json:"timeout"`
 	CachePrefix  string        `json:"cache_prefix"`
 	CacheTTL     time.Duration `json:"cache_ttl"`
+
+	CacheEncoding string `json:"cache_encoding"` // float32 (default), float16 or int8
+
+	MaxRedirects         int  `json:"max_redirects"`
+	PerHostConcurrency   int  `json:"per_host_concurrency"`
+	AllowPrivateNetworks bool `json:"allow_private_networks"` // Development only
 }
 
 // ImageMetadata contains metadata about a processed image
@@ -75,6 +86,21 @@ func NewImageEmbeddingService(registry *ModelRegistry, redisClient *redis.Client
 	if config.CacheTTL == 0 {
 		config.CacheTTL = 24 * time.Hour
 	}
+	cacheEncoding, err := ParseEmbeddingEncoding(config.CacheEncoding)
+	if err != nil {
+		logger.WithError(err).Warn("Invalid image embedding cache encoding, using float32")
+	}
+
+	fetchConfig := media.DefaultImageFetchConfig()
+	fetchConfig.Timeout = config.Timeout
+	fetchConfig.MaxBytes = config.MaxFileSize
+	fetchConfig.AllowPrivateNetworks = config.AllowPrivateNetworks
+	if config.MaxRedirects > 0 {
+		fetchConfig.MaxRedirects = config.MaxRedirects
+	}
+	if config.PerHostConcurrency > 0 {
+		fetchConfig.PerHostConcurrency = config.PerHostConcurrency
+	}
 
 	return &ImageEmbeddingService{
 		registry:     registry,
@@ -86,12 +112,18 @@ func NewImageEmbeddingService(registry *ModelRegistry, redisClient *redis.Client
 		timeout:      config.Timeout,
 		cachePrefix:  config.CachePrefix,
 		cacheTTL:     config.CacheTTL,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+
+		cacheEncoding: cacheEncoding,
+		fetcher:       media.NewImageFetcher(fetchConfig),
 	}
 }
 
+// SetImageStore makes URL embeddings read images kept by ingestion instead of
+// downloading them again
+func (ies *ImageEmbeddingService) SetImageStore(store *media.ImageStore) {
+	ies.imageStore = store
+}
+
 // GenerateEmbeddingFromURL generates an embedding from an image URL
 func (ies *ImageEmbeddingService) GenerateEmbeddingFromURL(imageURL string, modelName string) ([]float32, *ImageMetadata, error) {
 	if imageURL == "" {
@@ -144,51 +176,30 @@ func (ies *ImageEmbeddingService) GenerateEmbeddingFromData(imageData []byte, mo
 
 // fetchImage downloads an image from a URL with validation
 func (ies *ImageEmbeddingService) fetchImage(imageURL string) ([]byte, *ImageMetadata, error) {
-	// Validate URL
-	if !strings.HasPrefix(imageURL, "http://") && !strings.HasPrefix(imageURL, "https://") {
-		return nil, nil, fmt.Errorf("invalid image URL scheme")
-	}
-
-	// Create request
-	req, err := http.NewRequest("GET", imageURL, nil)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	req.Header.Set("User-Agent", "RecommendationEngine/1.0")
-	req.Header.Set("Accept", "image/jpeg,image/png,image/webp,image/*")
-
-	// Make request
-	resp, err := ies.httpClient.Do(req)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to fetch image: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	ctx, cancel := context.WithTimeout(context.Background(), ies.timeout)
+	defer cancel()
+
+	var imageData []byte
+	var contentType string
+	if ies.imageStore != nil {
+		stored, data, err := ies.imageStore.Get(ctx, imageURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		imageData, contentType = data, stored.ContentType
+	} else {
+		fetched, err := ies.fetcher.Fetch(ctx, imageURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		imageData, contentType = fetched.Data, fetched.ContentType
 	}
 
 	// Check content type
-	contentType := resp.Header.Get("Content-Type")
 	if !ies.isValidImageContentType(contentType) {
 		return nil, nil, fmt.Errorf("invalid content type: %s", contentType)
 	}
 
-	// Check content length
-	if resp.ContentLength > ies.maxFileSize {
-		return nil, nil, fmt.Errorf("image too large: %d bytes", resp.ContentLength)
-	}
-
-	// Read image data with size limit
-	limitedReader := io.LimitReader(resp.Body, ies.maxFileSize)
-	imageData, err := io.ReadAll(limitedReader)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read image data: %w", err)
-	}
-
 	// Validate and extract metadata
 	metadata, err := ies.validateAndExtractMetadata(imageData)
 	if err != nil {
@@ -381,7 +392,9 @@ func (ies *ImageEmbeddingService) generateMockEmbedding(preprocessed []float32,
 	return embedding
 }
 
-// CachedImageEmbedding represents a cached image embedding with metadata
+// CachedImageEmbedding is the legacy JSON cache entry, still read until it expires.
+// New entries store the binary embedding under the key and the metadata under
+// "<key>:meta".
 type CachedImageEmbedding struct {
 	Embedding []float32      `json:"embedding"`
 	Metadata  *ImageMetadata `json:"metadata"`
@@ -392,14 +405,30 @@ func (ies *ImageEmbeddingService) getCachedEmbedding(imageURL string, modelName
 	key := ies.generateCacheKey(imageURL, modelName)
 
 	ctx := context.Background()
-	result, err := ies.redisClient.Get(ctx, key).Result()
+	values, err := ies.redisClient.MGet(ctx, key, key+":meta").Result()
 	if err != nil {
 		return nil, nil, false
 	}
+	data, ok := values[0].(string)
+	if !ok {
+		return nil, nil, false
+	}
 
-	// Deserialize cached embedding and metadata from JSON
-	var cached CachedImageEmbedding
-	if err := json.Unmarshal([]byte(result), &cached); err != nil {
+	header, embedding, err := DecodeEmbedding([]byte(data))
+	if errors.Is(err, ErrNotBinaryEmbedding) {
+		var cached CachedImageEmbedding
+		if err = json.Unmarshal([]byte(data), &cached); err == nil {
+			return cached.Embedding, cached.Metadata, true
+		}
+	}
+	if err == nil {
+		err = checkEmbeddingModel(header, modelName, modelVersion(ies.registry, modelName))
+	}
+	if errors.Is(err, ErrStaleEmbedding) {
+		ies.logger.WithField("key", key).Debug("Ignoring cached image embedding from another model version")
+		return nil, nil, false
+	}
+	if err != nil {
 		ies.logger.WithFields(logrus.Fields{
 			"error": err.Error(),
 			"key":   key,
@@ -407,20 +436,26 @@ func (ies *ImageEmbeddingService) getCachedEmbedding(imageURL string, modelName
 		return nil, nil, false
 	}
 
-	return cached.Embedding, cached.Metadata, true
+	var metadata *ImageMetadata
+	if meta, ok := values[1].(string); ok {
+		if err := json.Unmarshal([]byte(meta), &metadata); err != nil {
+			metadata = nil
+		}
+	}
+
+	return embedding, metadata, true
 }
 
 // cacheEmbedding stores an embedding in cache
 func (ies *ImageEmbeddingService) cacheEmbedding(imageURL string, modelName string, embedding []float32, metadata *ImageMetadata) {
 	key := ies.generateCacheKey(imageURL, modelName)
 
-	// Serialize embedding and metadata to JSON
-	cached := CachedImageEmbedding{
-		Embedding: embedding,
-		Metadata:  metadata,
-	}
-
-	data, err := json.Marshal(cached)
+	data, err := EncodeEmbedding(EmbeddingHeader{
+		Encoding:     ies.cacheEncoding,
+		Model:        modelName,
+		ModelVersion: modelVersion(ies.registry, modelName),
+		Dimensions:   len(embedding),
+	}, embedding)
 	if err != nil {
 		ies.logger.WithFields(logrus.Fields{
 			"error": err.Error(),
@@ -428,9 +463,15 @@ func (ies *ImageEmbeddingService) cacheEmbedding(imageURL string, modelName stri
 		}).Warn("Failed to serialize image embedding for caching")
 		return
 	}
+	meta, _ := json.Marshal(metadata)
 
 	ctx := context.Background()
-	if err := ies.redisClient.Set(ctx, key, data, ies.cacheTTL).Err(); err != nil {
+	_, err = ies.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, data, ies.cacheTTL)
+		pipe.Set(ctx, key+":meta", meta, ies.cacheTTL)
+		return nil
+	})
+	if err != nil {
 		ies.logger.WithFields(logrus.Fields{
 			"error": err.Error(),
 			"key":   key,
@@ -7,6 +7,8 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/media"
 )
 
 // MLService orchestrates all ML components
@@ -174,6 +176,12 @@ func (mls *MLService) GenerateImageEmbedding(imageURL string, modelName string)
 	return embedding, metadata, nil
 }
 
+// SetImageStore shares the ingestion image store so URL embeddings reuse
+// downloaded images
+func (mls *MLService) SetImageStore(store *media.ImageStore) {
+	mls.imageService.SetImageStore(store)
+}
+
 // GenerateMultiModalEmbedding generates a fused multi-modal embedding
 func (mls *MLService) GenerateMultiModalEmbedding(
 	text string,
@@ -201,6 +209,47 @@ func (mls *MLService) GenerateMultiModalEmbedding(
 	return result, nil
 }
 
+// GenerateContentEmbedding generates a fused embedding using the fusion method of
+// the content type. imageURL may be empty for items without an image.
+func (mls *MLService) GenerateContentEmbedding(
+	contentType string,
+	text string,
+	imageURL string,
+	textModelName string,
+	imageModelName string,
+) (*FusionResult, error) {
+	startTime := time.Now()
+
+	result, err := mls.fusionService.GenerateContentEmbedding(contentType, text, imageURL, textModelName, imageModelName)
+
+	// Update metrics
+	mls.updateMetrics(time.Since(startTime), err == nil)
+
+	if err != nil {
+		mls.logger.WithFields(logrus.Fields{
+			"error":        err.Error(),
+			"content_type": contentType,
+			"text_model":   textModelName,
+			"image_model":  imageModelName,
+			"image_url":    imageURL,
+		}).Error("Failed to generate content embedding")
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ReloadFusionWeights loads a new projection artifact without restarting
+func (mls *MLService) ReloadFusionWeights(path string) error {
+	return mls.fusionService.LoadProjectionWeights(path)
+}
+
+// ReloadNewerFusionWeights loads the highest artifact version under a weights
+// directory if it is newer than the loaded one, and reports whether it did
+func (mls *MLService) ReloadNewerFusionWeights(path string) (bool, error) {
+	return mls.fusionService.ReloadNewerProjection(path)
+}
+
 // GenerateBatchTextEmbeddings generates embeddings for multiple texts
 func (mls *MLService) GenerateBatchTextEmbeddings(texts []string, modelName string) ([][]float32, error) {
 	startTime := time.Now()
@@ -372,6 +421,11 @@ func DefaultMLConfig() *MLConfig {
 			FinalDimensions: 768,
 			TextWeight:      0.6,
 			ImageWeight:     0.4,
+			Method:          FusionLateProjection,
+			ContentTypeMethods: map[string]string{
+				"article": FusionGated, // Articles often have no image
+			},
+			WeightsPath: "./models/fusion",
 		},
 	}
 }
@@ -1,14 +1,37 @@
 package ml
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"math"
+	"os"
+	"path/filepath"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 	"gonum.org/v1/gonum/floats"
 	"gonum.org/v1/gonum/mat"
 )
 
+// Fusion methods
+const (
+	// FusionLateProjection concatenates both embeddings and applies the projection layer
+	FusionLateProjection = "late_fusion_with_projection"
+	// FusionWeightedEarly mixes both embeddings element-wise by the text/image weights.
+	// It only makes sense when both encoders share an embedding space (e.g. CLIP).
+	FusionWeightedEarly = "weighted_early_fusion"
+	// FusionGated scales each modality by an attention-style gate over the modalities
+	// present on the item before the projection layer
+	FusionGated = "gated_fusion"
+)
+
+var fusionMethods = map[string]bool{
+	FusionLateProjection: true,
+	FusionWeightedEarly:  true,
+	FusionGated:          true,
+}
+
 // MultiModalFusionService handles fusion of text and image embeddings
 type MultiModalFusionService struct {
 	textService  *TextEmbeddingService
@@ -22,12 +45,19 @@ type MultiModalFusionService struct {
 	finalDimensions int
 
 	// Projection layer for dimensionality reduction
-	projectionMatrix *mat.Dense
-	projectionBias   []float32
+	projectionMatrix     *mat.Dense
+	projectionBias       []float32
+	projectionActivation string
+	projectionVersion    int // 0 until a fitted artifact is loaded
+	projectionMutex      sync.RWMutex
 
 	// Fusion weights
 	textWeight  float32
 	imageWeight float32
+
+	// Fusion method, overridable per content type
+	method             string
+	contentTypeMethods map[string]string
 }
 
 // MultiModalFusionConfig contains configuration for the fusion service
@@ -37,6 +67,10 @@ type MultiModalFusionConfig struct {
 	FinalDimensions int     `json:"final_dimensions"`
 	TextWeight      float32 `json:"text_weight"`
 	ImageWeight     float32 `json:"image_weight"`
+
+	Method             string            `json:"method"`               // Default fusion method
+	ContentTypeMethods map[string]string `json:"content_type_methods"` // Content type -> fusion method
+	WeightsPath        string            `json:"weights_path"`         // Projection artifact file or directory
 }
 
 // FusionResult contains the result of multi-modal fusion
@@ -72,6 +106,24 @@ func NewMultiModalFusionService(
 	if config.ImageWeight == 0 {
 		config.ImageWeight = 0.4
 	}
+	if !fusionMethods[config.Method] {
+		if config.Method != "" {
+			logger.WithField("method", config.Method).Warn("Unknown fusion method, using late fusion")
+		}
+		config.Method = FusionLateProjection
+	}
+
+	contentTypeMethods := make(map[string]string)
+	for contentType, method := range config.ContentTypeMethods {
+		if !fusionMethods[method] {
+			logger.WithFields(logrus.Fields{
+				"content_type": contentType,
+				"method":       method,
+			}).Warn("Unknown fusion method for content type, using default")
+			continue
+		}
+		contentTypeMethods[contentType] = method
+	}
 
 	service := &MultiModalFusionService{
 		textService:     textService,
@@ -83,10 +135,23 @@ func NewMultiModalFusionService(
 		finalDimensions: config.FinalDimensions,
 		textWeight:      config.TextWeight,
 		imageWeight:     config.ImageWeight,
+
+		method:             config.Method,
+		contentTypeMethods: contentTypeMethods,
 	}
 
-	// Initialize projection layer
+	// Initialize projection layer, then replace it with fitted weights if available
 	service.initializeProjectionLayer()
+	if config.WeightsPath != "" {
+		err := service.LoadProjectionWeights(config.WeightsPath)
+		switch {
+		case errors.Is(err, fs.ErrNotExist), errors.Is(err, ErrNoProjectionArtifact):
+			logger.WithField("path", config.WeightsPath).Info("No fitted projection weights, using initial projection")
+		case err != nil:
+			logger.WithError(err).WithField("path", config.WeightsPath).
+				Warn("Failed to load projection weights, using initial projection")
+		}
+	}
 
 	return service
 }
@@ -114,6 +179,84 @@ func (mmfs *MultiModalFusionService) initializeProjectionLayer() {
 
 	// Initialize bias to zero
 	mmfs.projectionBias = make([]float32, mmfs.finalDimensions)
+	mmfs.projectionActivation = ActivationReLU
+}
+
+// LoadProjectionWeights loads a fitted projection artifact (a file, or the latest
+// version in a directory) and swaps it in
+func (mmfs *MultiModalFusionService) LoadProjectionWeights(path string) error {
+	artifact, err := LoadProjectionArtifact(path)
+	if err != nil {
+		return err
+	}
+	return mmfs.ApplyProjectionArtifact(artifact)
+}
+
+// ReloadNewerProjection loads the highest artifact version under a weights directory
+// when it is newer than the loaded projection. A path naming a single artifact file
+// is only loaded at startup.
+func (mmfs *MultiModalFusionService) ReloadNewerProjection(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat projection artifact: %w", err)
+	}
+	if !info.IsDir() {
+		return false, nil
+	}
+
+	latest, err := latestProjectionVersion(path)
+	if err != nil {
+		return false, err
+	}
+	if latest <= mmfs.currentProjectionVersion() {
+		return false, nil
+	}
+
+	artifact, err := LoadProjectionArtifact(filepath.Join(path, projectionArtifactName(latest)))
+	if err != nil {
+		return false, err
+	}
+	if err := mmfs.ApplyProjectionArtifact(artifact); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ApplyProjectionArtifact swaps in the weights of a fitted projection artifact
+func (mmfs *MultiModalFusionService) ApplyProjectionArtifact(artifact *ProjectionArtifact) error {
+	if err := artifact.Validate(mmfs.textDimensions, mmfs.imageDimensions, mmfs.finalDimensions); err != nil {
+		return err
+	}
+
+	projectionMatrix := mat.NewDense(mmfs.finalDimensions, mmfs.fusedDimensions, nil)
+	for i, row := range artifact.Weights {
+		projectionMatrix.SetRow(i, row)
+	}
+	bias := make([]float32, mmfs.finalDimensions)
+	copy(bias, artifact.Bias)
+
+	mmfs.projectionMutex.Lock()
+	mmfs.projectionMatrix = projectionMatrix
+	mmfs.projectionBias = bias
+	mmfs.projectionActivation = artifact.Activation
+	mmfs.projectionVersion = artifact.Version
+	mmfs.projectionMutex.Unlock()
+
+	mmfs.logger.WithFields(logrus.Fields{
+		"version":         artifact.Version,
+		"pairs":           artifact.Report.Pairs,
+		"pair_cosine":     artifact.Report.PairCosine,
+		"shuffled_cosine": artifact.Report.ShuffledCosine,
+	}).Info("Projection weights loaded")
+	return nil
+}
+
+// MethodFor returns the fusion method configured for a content type
+func (mmfs *MultiModalFusionService) MethodFor(contentType string) string {
+	if method, ok := mmfs.contentTypeMethods[contentType]; ok {
+		return method
+	}
+	return mmfs.method
 }
 
 // GenerateMultiModalEmbedding generates a fused embedding from text and image
@@ -162,6 +305,40 @@ func (mmfs *MultiModalFusionService) GenerateMultiModalEmbeddingFromData(
 	return mmfs.fuseEmbeddings(textEmbedding, imageEmbedding)
 }
 
+// GenerateContentEmbedding generates a fused embedding with the method configured for
+// the content type. An empty imageURL produces a text-only embedding.
+func (mmfs *MultiModalFusionService) GenerateContentEmbedding(
+	contentType string,
+	text string,
+	imageURL string,
+	textModelName string,
+	imageModelName string,
+) (*FusionResult, error) {
+
+	// Generate text embedding
+	textEmbedding, err := mmfs.textService.GenerateEmbedding(text, textModelName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate text embedding: %w", err)
+	}
+
+	// Generate image embedding if the item has one
+	var imageEmbedding []float32
+	if imageURL != "" {
+		imageEmbedding, _, err = mmfs.imageService.GenerateEmbeddingFromURL(imageURL, imageModelName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate image embedding: %w", err)
+		}
+	}
+
+	return mmfs.FuseForContentType(contentType, textEmbedding, imageEmbedding)
+}
+
+// FuseForContentType fuses embeddings with the method configured for the content type.
+// Either embedding may be nil when the item has no text or no image.
+func (mmfs *MultiModalFusionService) FuseForContentType(contentType string, textEmbedding, imageEmbedding []float32) (*FusionResult, error) {
+	return mmfs.fuse(mmfs.MethodFor(contentType), textEmbedding, imageEmbedding)
+}
+
 // fuseEmbeddings performs the actual fusion of text and image embeddings
 func (mmfs *MultiModalFusionService) fuseEmbeddings(textEmbedding, imageEmbedding []float32) (*FusionResult, error) {
 	// Validate dimensions
@@ -174,24 +351,66 @@ func (mmfs *MultiModalFusionService) fuseEmbeddings(textEmbedding, imageEmbeddin
 			mmfs.imageDimensions, len(imageEmbedding))
 	}
 
+	return mmfs.fuse(mmfs.method, textEmbedding, imageEmbedding)
+}
+
+// fuse combines the embeddings with the given method. Missing modalities are zeros.
+func (mmfs *MultiModalFusionService) fuse(method string, textEmbedding, imageEmbedding []float32) (*FusionResult, error) {
+	hasText, hasImage := len(textEmbedding) > 0, len(imageEmbedding) > 0
+	if !hasText && !hasImage {
+		return nil, fmt.Errorf("at least one of text or image embedding is required")
+	}
+	if hasText && len(textEmbedding) != mmfs.textDimensions {
+		return nil, fmt.Errorf("text embedding dimension mismatch: expected %d, got %d",
+			mmfs.textDimensions, len(textEmbedding))
+	}
+	if hasImage && len(imageEmbedding) != mmfs.imageDimensions {
+		return nil, fmt.Errorf("image embedding dimension mismatch: expected %d, got %d",
+			mmfs.imageDimensions, len(imageEmbedding))
+	}
+
 	// Normalize embeddings
-	normalizedText := mmfs.l2Normalize(textEmbedding)
-	normalizedImage := mmfs.l2Normalize(imageEmbedding)
+	normalizedText := make([]float32, mmfs.textDimensions)
+	if hasText {
+		normalizedText = mmfs.l2Normalize(textEmbedding)
+	}
+	normalizedImage := make([]float32, mmfs.imageDimensions)
+	if hasImage {
+		normalizedImage = mmfs.l2Normalize(imageEmbedding)
+	}
 
-	// Late fusion: concatenate normalized embeddings
-	fusedEmbedding := mmfs.lateFusion(normalizedText, normalizedImage)
+	textWeight, imageWeight := mmfs.textWeight, mmfs.imageWeight
 
-	// Apply learned projection to final dimensions
-	finalEmbedding := mmfs.applyProjection(fusedEmbedding)
+	var fusedEmbedding, finalEmbedding []float32
+	switch method {
+	case FusionWeightedEarly:
+		// Weighted element-wise mix, padded to final dimensions without projection
+		if !hasText || !hasImage {
+			textWeight, imageWeight = modalityGates(hasText, hasImage, textWeight, imageWeight)
+		}
+		fusedEmbedding = mmfs.weightedEarlyFusion(normalizedText, normalizedImage, textWeight, imageWeight)
+		finalEmbedding = mmfs.l2Normalize(mmfs.padEmbedding(fusedEmbedding, mmfs.finalDimensions))
+	case FusionGated:
+		textWeight, imageWeight = modalityGates(hasText, hasImage, textWeight, imageWeight)
+		fusedEmbedding = mmfs.gatedFusion(normalizedText, normalizedImage, textWeight, imageWeight, hasText, hasImage)
+		finalEmbedding = mmfs.applyProjection(fusedEmbedding)
+	default:
+		// Late fusion: concatenate normalized embeddings
+		fusedEmbedding = mmfs.lateFusion(normalizedText, normalizedImage)
+
+		// Apply learned projection to final dimensions
+		finalEmbedding = mmfs.applyProjection(fusedEmbedding)
+		method = FusionLateProjection
+	}
 
 	return &FusionResult{
 		TextEmbedding:  normalizedText,
 		ImageEmbedding: normalizedImage,
 		FusedEmbedding: fusedEmbedding,
 		FinalEmbedding: finalEmbedding,
-		FusionMethod:   "late_fusion_with_projection",
-		TextWeight:     mmfs.textWeight,
-		ImageWeight:    mmfs.imageWeight,
+		FusionMethod:   method,
+		TextWeight:     textWeight,
+		ImageWeight:    imageWeight,
 	}, nil
 }
 
@@ -209,11 +428,13 @@ func (mmfs *MultiModalFusionService) lateFusion(textEmbedding, imageEmbedding []
 	return fused
 }
 
-// earlyFusion performs early fusion with weighted combination (hook for future enhancement)
+// earlyFusion performs early fusion with the configured text/image weights
 func (mmfs *MultiModalFusionService) earlyFusion(textEmbedding, imageEmbedding []float32) []float32 {
-	// This is a hook for future early fusion implementation
-	// For now, pad shorter embedding to match dimensions and combine
+	return mmfs.weightedEarlyFusion(textEmbedding, imageEmbedding, mmfs.textWeight, mmfs.imageWeight)
+}
 
+// weightedEarlyFusion pads the shorter embedding and combines them element-wise
+func (mmfs *MultiModalFusionService) weightedEarlyFusion(textEmbedding, imageEmbedding []float32, textWeight, imageWeight float32) []float32 {
 	maxDim := max(mmfs.textDimensions, mmfs.imageDimensions)
 
 	// Pad embeddings to same dimension
@@ -223,12 +444,59 @@ func (mmfs *MultiModalFusionService) earlyFusion(textEmbedding, imageEmbedding [
 	// Weighted combination
 	fused := make([]float32, maxDim)
 	for i := 0; i < maxDim; i++ {
-		fused[i] = mmfs.textWeight*paddedText[i] + mmfs.imageWeight*paddedImage[i]
+		fused[i] = textWeight*paddedText[i] + imageWeight*paddedImage[i]
+	}
+
+	return fused
+}
+
+// gatedFusion concatenates the gated embeddings. Gates are rescaled so an item with
+// every modality present matches the late-fusion input the projection was fitted on.
+func (mmfs *MultiModalFusionService) gatedFusion(textEmbedding, imageEmbedding []float32, textGate, imageGate float32, hasText, hasImage bool) []float32 {
+	available := float32(0)
+	if hasText {
+		available++
+	}
+	if hasImage {
+		available++
 	}
 
+	fused := mmfs.lateFusion(textEmbedding, imageEmbedding)
+	for i := range fused {
+		if i < len(textEmbedding) {
+			fused[i] *= textGate * available
+		} else {
+			fused[i] *= imageGate * available
+		}
+	}
 	return fused
 }
 
+// modalityGates computes attention-style gates: a softmax over the log weights of
+// the modalities present on the item, so a missing modality gets zero weight
+func modalityGates(hasText, hasImage bool, textWeight, imageWeight float32) (float32, float32) {
+	logits := []float64{math.Inf(-1), math.Inf(-1)}
+	if hasText && textWeight > 0 {
+		logits[0] = math.Log(float64(textWeight))
+	}
+	if hasImage && imageWeight > 0 {
+		logits[1] = math.Log(float64(imageWeight))
+	}
+	if math.IsInf(logits[0], -1) && math.IsInf(logits[1], -1) {
+		if hasText {
+			return 1, 0
+		}
+		return 0, 1
+	}
+
+	maxLogit := math.Max(logits[0], logits[1])
+	textGate := math.Exp(logits[0] - maxLogit)
+	imageGate := math.Exp(logits[1] - maxLogit)
+	sum := textGate + imageGate
+
+	return float32(textGate / sum), float32(imageGate / sum)
+}
+
 // padEmbedding pads an embedding to target dimension
 func (mmfs *MultiModalFusionService) padEmbedding(embedding []float32, targetDim int) []float32 {
 	if len(embedding) >= targetDim {
@@ -250,6 +518,9 @@ func (mmfs *MultiModalFusionService) applyProjection(fusedEmbedding []float32) [
 		input.Set(0, i, float64(v))
 	}
 
+	mmfs.projectionMutex.RLock()
+	defer mmfs.projectionMutex.RUnlock()
+
 	// Matrix multiplication: output = input * projection^T
 	var output mat.Dense
 	output.Mul(input, mmfs.projectionMatrix.T())
@@ -260,10 +531,13 @@ func (mmfs *MultiModalFusionService) applyProjection(fusedEmbedding []float32) [
 		result[i] = float32(output.At(0, i)) + mmfs.projectionBias[i]
 	}
 
-	// Apply activation function (ReLU)
-	for i := range result {
-		if result[i] < 0 {
-			result[i] = 0
+	// Apply activation function (ReLU for the initial projection; fitted
+	// projections are linear)
+	if mmfs.projectionActivation == ActivationReLU {
+		for i := range result {
+			if result[i] < 0 {
+				result[i] = 0
+			}
 		}
 	}
 
@@ -303,6 +577,9 @@ func (mmfs *MultiModalFusionService) UpdateProjectionWeights(weights [][]float64
 		return fmt.Errorf("bias vector dimension mismatch")
 	}
 
+	mmfs.projectionMutex.Lock()
+	defer mmfs.projectionMutex.Unlock()
+
 	// Update projection matrix
 	for i := 0; i < mmfs.finalDimensions; i++ {
 		for j := 0; j < mmfs.fusedDimensions; j++ {
@@ -320,12 +597,20 @@ func (mmfs *MultiModalFusionService) UpdateProjectionWeights(weights [][]float64
 // GetFusionStats returns fusion service statistics
 func (mmfs *MultiModalFusionService) GetFusionStats() map[string]any {
 	return map[string]interface{}{
-		"text_dimensions":  mmfs.textDimensions,
-		"image_dimensions": mmfs.imageDimensions,
-		"fused_dimensions": mmfs.fusedDimensions,
-		"final_dimensions": mmfs.finalDimensions,
-		"text_weight":      mmfs.textWeight,
-		"image_weight":     mmfs.imageWeight,
-		"fusion_method":    "late_fusion_with_projection",
+		"text_dimensions":      mmfs.textDimensions,
+		"image_dimensions":     mmfs.imageDimensions,
+		"fused_dimensions":     mmfs.fusedDimensions,
+		"final_dimensions":     mmfs.finalDimensions,
+		"text_weight":          mmfs.textWeight,
+		"image_weight":         mmfs.imageWeight,
+		"fusion_method":        mmfs.method,
+		"content_type_methods": mmfs.contentTypeMethods,
+		"projection_version":   mmfs.currentProjectionVersion(),
 	}
 }
+
+func (mmfs *MultiModalFusionService) currentProjectionVersion() int {
+	mmfs.projectionMutex.RLock()
+	defer mmfs.projectionMutex.RUnlock()
+	return mmfs.projectionVersion
+}
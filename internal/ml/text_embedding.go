@@ -3,7 +3,7 @@ package ml
 import (
 	"context"
 	"crypto/sha256"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -23,10 +23,11 @@ type TextEmbeddingService struct {
 	pythonBridge *PythonBridge
 
 	// Configuration
-	maxTokens   int
-	batchSize   int
-	cachePrefix string
-	cacheTTL    time.Duration
+	maxTokens     int
+	batchSize     int
+	cachePrefix   string
+	cacheTTL      time.Duration
+	cacheEncoding EmbeddingEncoding
 
 	// Worker pool for batch processing
 	workerPool  chan chan EmbeddingJob
@@ -40,6 +41,7 @@ type EmbeddingJob struct {
 	ID        string
 	Text      string
 	ModelName string
+	SkipCache bool // Set by batch requests, which read and write the cache in bulk
 	Response  chan EmbeddingResult
 }
 
@@ -66,6 +68,8 @@ type TextEmbeddingConfig struct {
 	CachePrefix string        `json:"cache_prefix"`
 	CacheTTL    time.Duration `json:"cache_ttl"`
 	WorkerCount int           `json:"worker_count"`
+
+	CacheEncoding string `json:"cache_encoding"` // float32 (default), float16 or int8
 }
 
 // NewTextEmbeddingService creates a new text embedding service
@@ -86,6 +90,11 @@ func NewTextEmbeddingService(registry *ModelRegistry, redisClient *redis.Client,
 		config.WorkerCount = 4
 	}
 
+	cacheEncoding, err := ParseEmbeddingEncoding(config.CacheEncoding)
+	if err != nil {
+		logger.WithError(err).Warn("Invalid text embedding cache encoding, using float32")
+	}
+
 	// Initialize Python bridge for real model inference
 	pythonBridge := NewPythonBridge(logger)
 
@@ -101,6 +110,8 @@ func NewTextEmbeddingService(registry *ModelRegistry, redisClient *redis.Client,
 		workerCount:  config.WorkerCount,
 		workerPool:   make(chan chan EmbeddingJob, config.WorkerCount),
 		jobQueue:     make(chan EmbeddingJob, config.BatchSize*2),
+
+		cacheEncoding: cacheEncoding,
 	}
 
 	// Initialize Python bridge
@@ -166,14 +177,16 @@ func (w *EmbeddingWorker) processJob(job EmbeddingJob) {
 	startTime := time.Now()
 
 	// Try cache first
-	if embedding, found := w.service.getCachedEmbedding(job.Text, job.ModelName); found {
-		job.Response <- EmbeddingResult{
-			Embedding: embedding,
-			Error:     nil,
-			Cached:    true,
-			Latency:   time.Since(startTime),
+	if !job.SkipCache {
+		if embedding, found := w.service.getCachedEmbedding(job.Text, job.ModelName); found {
+			job.Response <- EmbeddingResult{
+				Embedding: embedding,
+				Error:     nil,
+				Cached:    true,
+				Latency:   time.Since(startTime),
+			}
+			return
 		}
-		return
 	}
 
 	// Generate embedding
@@ -189,7 +202,9 @@ func (w *EmbeddingWorker) processJob(job EmbeddingJob) {
 	}
 
 	// Cache the result
-	w.service.cacheEmbedding(job.Text, job.ModelName, embedding)
+	if !job.SkipCache {
+		w.service.cacheEmbedding(job.Text, job.ModelName, embedding)
+	}
 
 	job.Response <- EmbeddingResult{
 		Embedding: embedding,
@@ -221,35 +236,65 @@ func (tes *TextEmbeddingService) GenerateEmbedding(text string, modelName string
 	return result.Embedding, result.Error
 }
 
-// GenerateBatchEmbeddings generates embeddings for multiple texts
+// GenerateBatchEmbeddings generates embeddings for multiple texts. Cached embeddings
+// are fetched with a single MGET, only misses go to the workers, and new embeddings
+// are written back in one pipeline.
 func (tes *TextEmbeddingService) GenerateBatchEmbeddings(texts []string, modelName string) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, fmt.Errorf("texts cannot be empty")
 	}
 
-	results := make([][]float32, len(texts))
-	jobs := make([]EmbeddingJob, len(texts))
+	results := tes.getCachedEmbeddings(texts, modelName)
 
-	// Create jobs
+	// Submit one job per distinct uncached text
+	jobs := make(map[string]EmbeddingJob)
+	var order []string
 	for i, text := range texts {
-		jobs[i] = EmbeddingJob{
+		if results[i] != nil {
+			continue
+		}
+		if _, ok := jobs[text]; ok {
+			continue
+		}
+		job := EmbeddingJob{
 			ID:        fmt.Sprintf("%d_%d", time.Now().UnixNano(), i),
 			Text:      text,
 			ModelName: modelName,
+			SkipCache: true,
 			Response:  make(chan EmbeddingResult, 1),
 		}
+		jobs[text] = job
+		order = append(order, text)
 
 		// Submit job
-		tes.jobQueue <- jobs[i]
+		tes.jobQueue <- job
 	}
 
 	// Collect results
-	for i, job := range jobs {
-		result := <-job.Response
+	generated := make(map[string][]float32, len(jobs))
+	var firstErr error
+	for _, text := range order {
+		result := <-jobs[text].Response
 		if result.Error != nil {
-			return nil, fmt.Errorf("failed to generate embedding for text %d: %w", i, result.Error)
+			if firstErr == nil {
+				firstErr = result.Error
+			}
+			continue
+		}
+		generated[text] = result.Embedding
+	}
+
+	tes.cacheEmbeddings(generated, modelName)
+
+	for i, text := range texts {
+		if results[i] != nil {
+			continue
+		}
+		embedding, ok := generated[text]
+		if !ok {
+			return nil, fmt.Errorf("failed to generate embedding for text %d: %w", i, firstErr)
 		}
-		results[i] = result.Embedding
+		results[i] = embedding
 	}
 
 	return results, nil
@@ -554,14 +599,17 @@ func (tes *TextEmbeddingService) getCachedEmbedding(text string, modelName strin
 	key := tes.generateCacheKey(text, modelName)
 
 	ctx := context.Background()
-	result, err := tes.redisClient.Get(ctx, key).Result()
+	result, err := tes.redisClient.Get(ctx, key).Bytes()
 	if err != nil {
 		return nil, false
 	}
 
-	// Deserialize cached embedding from JSON
-	var embedding []float32
-	if err := json.Unmarshal([]byte(result), &embedding); err != nil {
+	embedding, err := decodeCachedEmbedding(result, modelName, modelVersion(tes.registry, modelName))
+	if errors.Is(err, ErrStaleEmbedding) {
+		tes.logger.WithField("key", key).Debug("Ignoring cached embedding from another model version")
+		return nil, false
+	}
+	if err != nil {
 		tes.logger.WithFields(logrus.Fields{
 			"error": err.Error(),
 			"key":   key,
@@ -572,12 +620,50 @@ func (tes *TextEmbeddingService) getCachedEmbedding(text string, modelName strin
 	return embedding, true
 }
 
+// getCachedEmbeddings looks up many texts with one MGET. Misses are nil.
+func (tes *TextEmbeddingService) getCachedEmbeddings(texts []string, modelName string) [][]float32 {
+	embeddings := make([][]float32, len(texts))
+
+	keys := make([]string, len(texts))
+	for i, text := range texts {
+		keys[i] = tes.generateCacheKey(text, modelName)
+	}
+
+	ctx := context.Background()
+	values, err := tes.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		tes.logger.WithError(err).WithField("batch_size", len(texts)).Warn("Failed to read cached embeddings")
+		return embeddings
+	}
+
+	version := modelVersion(tes.registry, modelName)
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		embedding, err := decodeCachedEmbedding([]byte(data), modelName, version)
+		if errors.Is(err, ErrStaleEmbedding) {
+			continue
+		}
+		if err != nil {
+			tes.logger.WithFields(logrus.Fields{
+				"error": err.Error(),
+				"key":   keys[i],
+			}).Warn("Failed to deserialize cached embedding")
+			continue
+		}
+		embeddings[i] = embedding
+	}
+
+	return embeddings
+}
+
 // cacheEmbedding stores an embedding in cache
 func (tes *TextEmbeddingService) cacheEmbedding(text string, modelName string, embedding []float32) {
 	key := tes.generateCacheKey(text, modelName)
 
-	// Serialize embedding to JSON
-	data, err := json.Marshal(embedding)
+	data, err := tes.encodeEmbedding(modelName, embedding)
 	if err != nil {
 		tes.logger.WithFields(logrus.Fields{
 			"error": err.Error(),
@@ -595,6 +681,38 @@ func (tes *TextEmbeddingService) cacheEmbedding(text string, modelName string, e
 	}
 }
 
+// cacheEmbeddings stores many embeddings, keyed by text, in one pipeline
+func (tes *TextEmbeddingService) cacheEmbeddings(embeddings map[string][]float32, modelName string) {
+	if len(embeddings) == 0 {
+		return
+	}
+
+	ctx := context.Background()
+	_, err := tes.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for text, embedding := range embeddings {
+			data, err := tes.encodeEmbedding(modelName, embedding)
+			if err != nil {
+				tes.logger.WithError(err).Warn("Failed to serialize embedding for caching")
+				continue
+			}
+			pipe.Set(ctx, tes.generateCacheKey(text, modelName), data, tes.cacheTTL)
+		}
+		return nil
+	})
+	if err != nil {
+		tes.logger.WithError(err).WithField("batch_size", len(embeddings)).Warn("Failed to cache embeddings")
+	}
+}
+
+func (tes *TextEmbeddingService) encodeEmbedding(modelName string, embedding []float32) ([]byte, error) {
+	return EncodeEmbedding(EmbeddingHeader{
+		Encoding:     tes.cacheEncoding,
+		Model:        modelName,
+		ModelVersion: modelVersion(tes.registry, modelName),
+		Dimensions:   len(embedding),
+	}, embedding)
+}
+
 // generateCacheKey creates a hierarchical cache key
 func (tes *TextEmbeddingService) generateCacheKey(text string, modelName string) string {
 	// Get model info for version
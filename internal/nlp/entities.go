@@ -0,0 +1,265 @@
+package nlp
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxPhraseWords is the longest noun phrase reported, in words
+const maxPhraseWords = 3
+
+var (
+	// modelTokenRegex matches words including inner hyphens, slashes and dots,
+	// e.g. "WH-1000XM4" or "A7/III"
+	modelTokenRegex = regexp.MustCompile(`[\p{L}\p{N}]+(?:[-/.][\p{L}\p{N}]+)*`)
+
+	// unitSuffixRegex matches quantities such as "128GB", "4K" or "2nd", which
+	// mix digits and letters but are not model numbers
+	unitSuffixRegex = regexp.MustCompile(`(?i)^\d+(?:[.,]\d+)?(?:k|m|gb|tb|mb|kb|mp|mm|cm|km|in|ft|hz|khz|mhz|ghz|w|kw|v|mah|wh|kg|g|mg|lb|lbs|oz|ml|l|x|p|fps|st|nd|rd|th|s|h|min|pcs|pc|pack)$`)
+)
+
+// NounPhrases returns up to limit multi-word phrases from text: runs of two or
+// more consecutive words that are not stop words or numbers and are not broken
+// by punctuation. Longer runs are reported as windows of maxPhraseWords words.
+// Phrases are ordered by frequency, then by first occurrence.
+func NounPhrases(text, lang string, limit int) []string {
+	type phrase struct {
+		text  string
+		count int
+		first int
+	}
+
+	stopWords := StopWords(lang)
+	phrases := make(map[string]*phrase)
+	position := 0
+	add := func(words []string) {
+		key := strings.Join(words, " ")
+		if p, ok := phrases[key]; ok {
+			p.count++
+			return
+		}
+		phrases[key] = &phrase{text: key, count: 1, first: position}
+		position++
+	}
+
+	flush := func(run []string) {
+		switch {
+		case len(run) < 2:
+		case len(run) <= maxPhraseWords:
+			add(run)
+		default:
+			for i := 0; i+maxPhraseWords <= len(run); i++ {
+				add(run[i : i+maxPhraseWords])
+			}
+		}
+	}
+
+	for _, segment := range strings.FieldsFunc(Normalize(text), isPhraseBreak) {
+		var run []string
+		for _, token := range Tokenize(segment) {
+			if stopWords[token] || isNumeric(token) || isCJK([]rune(token)[0]) || utf8.RuneCountInString(token) < 2 {
+				flush(run)
+				run = nil
+				continue
+			}
+			run = append(run, token)
+		}
+		flush(run)
+	}
+
+	ordered := make([]*phrase, 0, len(phrases))
+	for _, p := range phrases {
+		ordered = append(ordered, p)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].count != ordered[j].count {
+			return ordered[i].count > ordered[j].count
+		}
+		return ordered[i].first < ordered[j].first
+	})
+	if limit > 0 && len(ordered) > limit {
+		ordered = ordered[:limit]
+	}
+
+	result := make([]string, 0, len(ordered))
+	for _, p := range ordered {
+		result = append(result, p.text)
+	}
+	return result
+}
+
+// isPhraseBreak reports whether a rune ends a phrase. Hyphens and apostrophes
+// join words instead.
+func isPhraseBreak(r rune) bool {
+	if r == '-' || r == '\'' || r == '’' {
+		return false
+	}
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// ModelNumbers returns product model identifiers in text, as written: words
+// that mix letters and digits ("WH-1000XM4", "A7III"), and short uppercase
+// series names followed by a number ("RTX 4090"). Quantities with a unit
+// suffix ("128GB", "4K") are skipped.
+func ModelNumbers(text string) []string {
+	var models []string
+	seen := make(map[string]bool)
+	add := func(model string) {
+		if key := strings.ToLower(model); !seen[key] {
+			seen[key] = true
+			models = append(models, model)
+		}
+	}
+
+	matches := modelTokenRegex.FindAllStringIndex(text, -1)
+	for i := 0; i < len(matches); i++ {
+		token := text[matches[i][0]:matches[i][1]]
+
+		// "RTX 4090": a series name and its number separated by one space
+		if i+1 < len(matches) && isSeriesName(token) && matches[i+1][0] == matches[i][1]+1 && text[matches[i][1]] == ' ' {
+			next := text[matches[i+1][0]:matches[i+1][1]]
+			if len(next) >= 2 && isNumeric(next) {
+				add(token + " " + next)
+				i++
+				continue
+			}
+		}
+
+		if isModelNumber(token) {
+			add(token)
+		}
+	}
+	return models
+}
+
+// isModelNumber reports whether a word mixes letters and digits like a model
+// number rather than a quantity
+func isModelNumber(token string) bool {
+	if utf8.RuneCountInString(token) < 3 || unitSuffixRegex.MatchString(token) {
+		return false
+	}
+
+	var letters, digits bool
+	for _, r := range token {
+		switch {
+		case unicode.IsLetter(r):
+			letters = true
+		case unicode.IsDigit(r):
+			digits = true
+		}
+	}
+	return letters && digits
+}
+
+// isSeriesName reports whether a word is a short uppercase name such as "RTX"
+func isSeriesName(token string) bool {
+	n := utf8.RuneCountInString(token)
+	if n < 2 || n > 5 {
+		return false
+	}
+	for _, r := range token {
+		if !unicode.IsUpper(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Gazetteer finds known names, such as brands, in text. Names match whole
+// words case-insensitively, the longest name starting at a word wins, and
+// matches are reported with the spelling the name was first added with. It is
+// safe for concurrent use.
+type Gazetteer struct {
+	mu      sync.RWMutex
+	byFirst map[string][]gazetteerEntry // First token -> names starting with it
+	known   map[string]bool             // Normalized names
+	size    int
+}
+
+type gazetteerEntry struct {
+	tokens []string
+	name   string
+}
+
+// NewGazetteer returns a gazetteer holding the given names
+func NewGazetteer(names ...string) *Gazetteer {
+	g := &Gazetteer{
+		byFirst: make(map[string][]gazetteerEntry),
+		known:   make(map[string]bool),
+	}
+	for _, name := range names {
+		g.Add(name)
+	}
+	return g
+}
+
+// Add adds a name and reports whether it was new
+func (g *Gazetteer) Add(name string) bool {
+	name = strings.TrimSpace(name)
+	tokens := Tokenize(Normalize(name))
+	if len(tokens) == 0 {
+		return false
+	}
+	key := strings.Join(tokens, " ")
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.known[key] {
+		return false
+	}
+	g.known[key] = true
+	g.byFirst[tokens[0]] = append(g.byFirst[tokens[0]], gazetteerEntry{tokens: tokens, name: name})
+	g.size++
+	return true
+}
+
+// Len returns the number of names
+func (g *Gazetteer) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.size
+}
+
+// Find returns the names found in text, in order of first occurrence
+func (g *Gazetteer) Find(text string) []string {
+	tokens := Tokenize(Normalize(text))
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var found []string
+	seen := make(map[string]bool)
+	for i := 0; i < len(tokens); i++ {
+		var best *gazetteerEntry
+		for j, entry := range g.byFirst[tokens[i]] {
+			if len(entry.tokens) > len(tokens)-i || (best != nil && len(entry.tokens) <= len(best.tokens)) {
+				continue
+			}
+			if equalTokens(entry.tokens, tokens[i:i+len(entry.tokens)]) {
+				best = &g.byFirst[tokens[i]][j]
+			}
+		}
+		if best == nil {
+			continue
+		}
+		if !seen[best.name] {
+			seen[best.name] = true
+			found = append(found, best.name)
+		}
+		i += len(best.tokens) - 1
+	}
+	return found
+}
+
+func equalTokens(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,233 @@
+package nlp
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// LanguageUnknown is returned when text is too short, has no letters or could
+// be one of several languages
+const LanguageUnknown = "unknown"
+
+const (
+	// minDetectLetters is the smallest number of letters worth classifying
+	minDetectLetters = 3
+	// stopWordWeight is how many times each stop word counts in training;
+	// they dominate real text but appear only once in the word lists
+	stopWordWeight = 3
+	// distinctiveLetterWeight is the log-likelihood bonus per occurrence of a
+	// letter only one language in the script uses
+	distinctiveLetterWeight = 2.0
+	// minDetectConfidence is the posterior below which text shared between
+	// scripts' languages is reported as unknown rather than guessed
+	minDetectConfidence = 0.6
+)
+
+// Detection is the result of language identification
+type Detection struct {
+	Language   string  `json:"language"`   // ISO 639-1 code or "unknown"
+	Script     string  `json:"script"`     // Dominant Unicode script
+	Confidence float64 `json:"confidence"` // 0-1
+}
+
+// ngramModel holds smoothed n-gram log probabilities for one language
+type ngramModel struct {
+	code     string
+	logProb  map[string]float64
+	logUnsen float64 // Log probability of an unseen n-gram
+	letters  string  // Letters only this language uses
+}
+
+var (
+	modelsOnce     sync.Once
+	modelsByScript map[string][]*ngramModel
+)
+
+// Languages returns the codes of all languages the detector can return
+func Languages() []string {
+	seen := make(map[string]bool)
+	for _, p := range languageProfiles {
+		seen[p.code] = true
+	}
+	for _, s := range scriptLanguages {
+		seen[s.language] = true
+	}
+	codes := make([]string, 0, len(seen))
+	for code := range seen {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// DetectLanguage identifies the language of text. Languages with their own script
+// are recognised from the script; languages sharing Latin, Cyrillic or Arabic
+// script are scored with a naive Bayes model over character trigrams and words.
+// Text the model cannot tell apart with enough confidence is unknown.
+func DetectLanguage(text string) Detection {
+	script, letters, share := dominantScript(text)
+	if letters < minDetectLetters || script == "" {
+		return Detection{Language: LanguageUnknown, Script: script}
+	}
+
+	for _, s := range scriptLanguages {
+		if s.script == script {
+			return Detection{Language: s.language, Script: script, Confidence: share}
+		}
+	}
+
+	models := scriptModels()[script]
+	if len(models) == 0 {
+		return Detection{Language: LanguageUnknown, Script: script}
+	}
+
+	normalized := Normalize(text)
+	features := extractFeatures(normalized)
+	scores := make([]float64, len(models))
+	best := 0
+	for i, m := range models {
+		for _, f := range features {
+			if lp, ok := m.logProb[f]; ok {
+				scores[i] += lp
+			} else {
+				scores[i] += m.logUnsen
+			}
+		}
+		if m.letters != "" {
+			for _, r := range normalized {
+				if strings.ContainsRune(m.letters, r) {
+					scores[i] += distinctiveLetterWeight
+				}
+			}
+		}
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+
+	// Posterior of the winner, assuming equal priors
+	var total float64
+	for _, s := range scores {
+		total += math.Exp(s - scores[best])
+	}
+
+	confidence := share / total
+	if confidence < minDetectConfidence {
+		return Detection{Language: LanguageUnknown, Script: script, Confidence: confidence}
+	}
+
+	return Detection{
+		Language:   models[best].code,
+		Script:     script,
+		Confidence: confidence,
+	}
+}
+
+// dominantScript returns the script most letters belong to, the letter count and
+// the share of letters in that script. Han text with any kana is Japanese.
+func dominantScript(text string) (string, int, float64) {
+	counts := make(map[string]int)
+	letters := 0
+	hasKana := false
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if script := runeScript(r); script != "" {
+			counts[script]++
+			if script == "Hiragana" || script == "Katakana" {
+				hasKana = true
+			}
+		}
+	}
+	if letters == 0 {
+		return "", 0, 0
+	}
+
+	if hasKana {
+		kana := counts["Hiragana"] + counts["Katakana"] + counts["Han"]
+		return "Hiragana", letters, float64(kana) / float64(letters)
+	}
+
+	best, bestCount := "", 0
+	for script, count := range counts {
+		if count > bestCount || (count == bestCount && script < best) {
+			best, bestCount = script, count
+		}
+	}
+	return best, letters, float64(bestCount) / float64(letters)
+}
+
+func runeScript(r rune) string {
+	for _, s := range sharedScripts {
+		if unicode.Is(s.table, r) {
+			return s.script
+		}
+	}
+	for _, s := range scriptLanguages {
+		if unicode.Is(s.table, r) {
+			return s.script
+		}
+	}
+	return ""
+}
+
+// extractFeatures returns the padded character trigrams of each word plus the
+// words themselves, prefixed so they cannot collide with trigrams
+func extractFeatures(text string) []string {
+	var features []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		features = append(features, "w:"+word)
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			features = append(features, string(runes[i:i+3]))
+		}
+	}
+	return features
+}
+
+func scriptModels() map[string][]*ngramModel {
+	modelsOnce.Do(func() {
+		counts := make(map[string]map[string]float64, len(languageProfiles))
+		vocab := make(map[string]bool)
+		for _, p := range languageProfiles {
+			c := make(map[string]float64)
+			for _, f := range extractFeatures(Normalize(p.sample + " " + p.extra)) {
+				c[f]++
+			}
+			for _, f := range extractFeatures(Normalize(p.stopWords)) {
+				c[f] += stopWordWeight
+			}
+			for f := range c {
+				vocab[f] = true
+			}
+			counts[p.code] = c
+		}
+
+		// Laplace smoothing over the shared vocabulary
+		modelsByScript = make(map[string][]*ngramModel)
+		v := float64(len(vocab))
+		for _, p := range languageProfiles {
+			c := counts[p.code]
+			var total float64
+			for _, n := range c {
+				total += n
+			}
+			m := &ngramModel{
+				code:     p.code,
+				logProb:  make(map[string]float64, len(c)),
+				logUnsen: math.Log(1 / (total + v)),
+				letters:  Normalize(p.letters),
+			}
+			for f, n := range c {
+				m.logProb[f] = math.Log((n + 1) / (total + v))
+			}
+			modelsByScript[p.script] = append(modelsByScript[p.script], m)
+		}
+	})
+	return modelsByScript
+}
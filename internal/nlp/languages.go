@@ -0,0 +1,374 @@
+package nlp
+
+import "unicode"
+
+// languageProfile is the training data for a language that shares its script
+// with others. The n-gram model is built from the sample (Article 1 of the
+// Universal Declaration of Human Rights) together with the stop words, which are
+// also what keyword extraction removes. Languages the declaration alone confuses
+// with a neighbour get everyday text as well, and letters no other language in
+// the script uses count as strong evidence.
+type languageProfile struct {
+	code      string
+	script    string
+	sample    string
+	extra     string
+	stopWords string
+	letters   string
+}
+
+// scriptLanguage maps a script used by essentially one language to that language
+type scriptLanguage struct {
+	script   string
+	table    *unicode.RangeTable
+	language string
+}
+
+// Scripts shared by several languages; text in these is resolved with n-grams
+const (
+	ScriptLatin    = "Latin"
+	ScriptCyrillic = "Cyrillic"
+	ScriptArabic   = "Arabic"
+)
+
+var sharedScripts = []struct {
+	script string
+	table  *unicode.RangeTable
+}{
+	{ScriptLatin, unicode.Latin},
+	{ScriptCyrillic, unicode.Cyrillic},
+	{ScriptArabic, unicode.Arabic},
+}
+
+var scriptLanguages = []scriptLanguage{
+	{"Greek", unicode.Greek, "el"},
+	{"Hebrew", unicode.Hebrew, "he"},
+	{"Thai", unicode.Thai, "th"},
+	{"Hangul", unicode.Hangul, "ko"},
+	{"Hiragana", unicode.Hiragana, "ja"},
+	{"Katakana", unicode.Katakana, "ja"},
+	{"Han", unicode.Han, "zh"}, // Japanese when kana are present
+	{"Devanagari", unicode.Devanagari, "hi"},
+	{"Bengali", unicode.Bengali, "bn"},
+	{"Gurmukhi", unicode.Gurmukhi, "pa"},
+	{"Gujarati", unicode.Gujarati, "gu"},
+	{"Tamil", unicode.Tamil, "ta"},
+	{"Telugu", unicode.Telugu, "te"},
+	{"Kannada", unicode.Kannada, "kn"},
+	{"Malayalam", unicode.Malayalam, "ml"},
+	{"Sinhala", unicode.Sinhala, "si"},
+	{"Georgian", unicode.Georgian, "ka"},
+	{"Armenian", unicode.Armenian, "hy"},
+	{"Khmer", unicode.Khmer, "km"},
+	{"Lao", unicode.Lao, "lo"},
+	{"Myanmar", unicode.Myanmar, "my"},
+	{"Ethiopic", unicode.Ethiopic, "am"},
+}
+
+var languageProfiles = []languageProfile{
+	{
+		code:   "en",
+		script: ScriptLatin,
+		sample: "All human beings are born free and equal in dignity and rights. They are endowed with reason and " +
+			"conscience and should act towards one another in a spirit of brotherhood.",
+		stopWords: "a about above after again against all also am an and any are as at be because been before being " +
+			"below between both but by can could did do does doing down during each few for from further had has " +
+			"have having he her here hers herself him himself his how i if in into is it its itself just many me " +
+			"more most my myself no nor not now of off on once only or other our ours out over own said same she " +
+			"should so some such than that the their theirs them themselves then there these they this those " +
+			"through to too under until up very was we were what when where which while who whom why will with " +
+			"would you your yours",
+	},
+	{
+		code:   "de",
+		script: ScriptLatin,
+		sample: "Alle Menschen sind frei und gleich an Würde und Rechten geboren. Sie sind mit Vernunft und Gewissen " +
+			"begabt und sollen einander im Geist der Brüderlichkeit begegnen.",
+		stopWords: "aber alle allem allen aller alles als also am an andere auch auf aus bei bin bis bist da damit " +
+			"dann das dass dem den denn der des dich die dir doch dort du durch ein eine einem einen einer eines er " +
+			"es etwas für gegen haben hat hatte hier ich ihm ihn ihr ihre im in ist jede jedem jeden jeder jetzt " +
+			"kann kein keine mich mir mit muss nach nicht nichts noch nun nur ob oder ohne sehr sein seine sich sie " +
+			"sind so soll sondern über um und uns unser unter viel vom von vor war waren was weil welche wenn " +
+			"werden wie wir wird wo zu zum zur zwischen",
+	},
+	{
+		code:   "fr",
+		script: ScriptLatin,
+		sample: "Tous les êtres humains naissent libres et égaux en dignité et en droits. Ils sont doués de raison " +
+			"et de conscience et doivent agir les uns envers les autres dans un esprit de fraternité.",
+		stopWords: "au aussi aux avec ce ces cette comme dans de des du elle elles en est et été être eu fait il ils " +
+			"je la le les leur leurs lui ma mais me même mes moi mon ne nos notre nous on ont ou où par pas plus " +
+			"pour qu que qui sa sans se ses son sont sur ta te tes toi ton tous tout très tu un une vos votre vous",
+	},
+	{
+		code:   "es",
+		script: ScriptLatin,
+		sample: "Todos los seres humanos nacen libres e iguales en dignidad y derechos y, dotados como están de " +
+			"razón y conciencia, deben comportarse fraternalmente los unos con los otros.",
+		extra: "El hombre llegó a la plaza con su hijo el domingo por la mañana. No sé dónde quedó el coche, pero " +
+			"creo que está cerca de la estación. Ella trabaja en una tienda del centro y vuelve a casa después de " +
+			"la cena. Hoy hace buen tiempo y los niños juegan en la calle con el perro del vecino.",
+		letters: "ñ",
+		stopWords: "a al algo algunos ante antes como con contra cual cuando de del desde donde durante e el ella " +
+			"ellas ellos en entre era es esa ese eso esta este esto estos está están fue ha hay la las le les lo los " +
+			"más me mi muy nada ni no nos o otra otro para pero poco por porque que quien se sin sobre su sus " +
+			"también tan te todo todos tu un una uno y ya",
+	},
+	{
+		code:   "it",
+		script: ScriptLatin,
+		sample: "Tutti gli esseri umani nascono liberi ed eguali in dignità e diritti. Essi sono dotati di ragione " +
+			"e di coscienza e devono agire gli uni verso gli altri in spirito di fratellanza.",
+		stopWords: "a ad agli ai al alla alle anche che chi ci come con da dal dalla dei del della delle di e ed era " +
+			"essere è gli ha hanno i il in io la le lei lo loro lui ma mi molto ne nel nella noi non o per più poi " +
+			"quale quando quello questa questo se si sono su sua sul sulla suo tra tu un una uno voi",
+	},
+	{
+		code:   "pt",
+		script: ScriptLatin,
+		sample: "Todos os seres humanos nascem livres e iguais em dignidade e em direitos. Dotados de razão e de " +
+			"consciência, devem agir uns para com os outros em espírito de fraternidade.",
+		extra: "O homem chegou à praça com o filho no domingo de manhã. Não sei onde ficou o carro, mas acho que " +
+			"está perto da estação. Ela trabalha numa loja do centro e volta para casa depois do jantar. Hoje o " +
+			"tempo está bom e as crianças brincam na rua com o cão do vizinho.",
+		letters: "ãõ",
+		stopWords: "a ao aos as até com como da das de dela dele do dos e ela ele eles em entre era essa esse esta " +
+			"este está eu foi há isso já mais mas me mesmo meu minha muito na nas não nem no nos o os ou para pela " +
+			"pelo por qual quando que se sem ser seu sua são também te tem um uma você",
+	},
+	{
+		code:   "nl",
+		script: ScriptLatin,
+		sample: "Alle mensen worden vrij en gelijk in waardigheid en rechten geboren. Zij zijn begiftigd met " +
+			"verstand en geweten, en behoren zich jegens elkander in een geest van broederschap te gedragen.",
+		stopWords: "aan al alles als bij dan dat de der deze die dit door dus een en er geen had heb hebben heeft " +
+			"hem het hier hij hoe hun ik in is ja je kan maar me men met mij mijn na naar niet nog nu of om omdat " +
+			"ook op over te tegen toch tot u uit van veel voor want was wat we wel werd wie wij wordt zal ze zei " +
+			"zich zij zijn zo zonder",
+	},
+	{
+		code:   "sv",
+		script: ScriptLatin,
+		sample: "Alla människor är födda fria och lika i värde och rättigheter. De har utrustats med förnuft och " +
+			"samvete och bör handla gentemot varandra i en anda av broderskap.",
+		stopWords: "alla att av blev bli de dem den denna deras dess det detta dig din du där efter eller en ett " +
+			"från för hade han hans har henne hennes hon hur här i inte jag kan man med mig min mot mycket ni nu " +
+			"när och om oss på sig sin sina ska skulle som så till under upp ut var vad vara vi vid vilken är över",
+	},
+	{
+		code:   "da",
+		script: ScriptLatin,
+		sample: "Alle mennesker er født frie og lige i værdighed og rettigheder. De er udstyret med fornuft og " +
+			"samvittighed, og de bør handle mod hverandre i en broderskabets ånd.",
+		stopWords: "af alle at blev bliver da de dem den denne der deres det dette dig din disse du efter eller en " +
+			"end er et for fra ham han hans har havde hende hendes her hos hun hvad hvis hvor i ikke ind jeg jer " +
+			"kan man mange med meget men mig min mod ned noget nogle nu når og også om op os over på sig sin skal " +
+			"som så til ud under var vi vil være",
+	},
+	{
+		code:   "no",
+		script: ScriptLatin,
+		sample: "Alle mennesker er født frie og med samme menneskeverd og menneskerettigheter. De er utstyrt med " +
+			"fornuft og samvittighet og bør handle mot hverandre i brorskapets ånd.",
+		stopWords: "alle at av bare ble bli da de deg dei dem den denne der dere deres det dette din disse du eller " +
+			"en enn er et etter for fra før han hans har hun hva hvis hvor i ikke inn jeg kan kunne man mange med " +
+			"meg men mitt mot må ned noe noen nå og også om opp oss over på seg sin skal som så til ut var ved vi " +
+			"vil være",
+	},
+	{
+		code:   "fi",
+		script: ScriptLatin,
+		sample: "Kaikki ihmiset syntyvät vapaina ja tasavertaisina arvoltaan ja oikeuksiltaan. Heille on annettu " +
+			"järki ja omatunto, ja heidän on toimittava toisiaan kohtaan veljeyden hengessä.",
+		stopWords: "ei he heidän hän hänen ja jo joita joka jos jotka kaikki kanssa koska kuin kun kuten lisäksi me " +
+			"mikä minä mitä mukaan mutta myös ne niin noin nyt olen oli olla on ovat se sekä sen siellä sinä sitten " +
+			"sitä tai tämä tässä vain vielä voi yli",
+	},
+	{
+		code:   "pl",
+		script: ScriptLatin,
+		sample: "Wszyscy ludzie rodzą się wolni i równi pod względem swej godności i swych praw. Są oni obdarzeni " +
+			"rozumem i sumieniem i powinni postępować wobec innych w duchu braterstwa.",
+		stopWords: "a aby ale bardzo bez być był była było były co czy dla do gdy gdzie go i ich im jak jako jego " +
+			"jej jest jeszcze już każdy kiedy która które który lub ma mi może na nad nie nich niż o od oraz po pod " +
+			"przez przy się sobie są ta tak takie także tam te tego tej to tu ty tylko w we więc z za ze że",
+	},
+	{
+		code:   "cs",
+		script: ScriptLatin,
+		sample: "Všichni lidé rodí se svobodní a sobě rovní co do důstojnosti a práv. Jsou nadáni rozumem a " +
+			"svědomím a mají spolu jednat v duchu bratrství.",
+		stopWords: "a aby ale ani ano asi až bez by byl byla bylo být co či další do i jak jako je jeho jej její " +
+			"jejich jen ještě již jsem jsme jsou k kde kdo když která které který mezi mi mnoho na nad ne nebo " +
+			"není o od on ona oni po pod podle pro proto před při s se si tak také tam tedy to tu ty už v ve však " +
+			"z za ze že",
+	},
+	{
+		code:   "sk",
+		script: ScriptLatin,
+		sample: "Všetci ľudia sa rodia slobodní a sebe rovní, čo sa týka ich dôstojnosti a práv. Sú obdarení " +
+			"rozumom a svedomím a majú spolu navzájom jednať v bratskom duchu.",
+		stopWords: "a aby aj ako ale až bez by bol bola bolo byť čo do i ich ja je jeho jej ju k kde keď ktorá " +
+			"ktoré ktorý medzi mi na nad nie o od on ona oni po pod pre pri s sa si sme so sú ta tak tam to tu ty " +
+			"už v vo však z za zo že",
+	},
+	{
+		code:   "hu",
+		script: ScriptLatin,
+		sample: "Minden emberi lény szabadon születik és egyenlő méltósága és joga van. Az emberek, ésszel és " +
+			"lelkiismerettel bírván, egymással szemben testvéri szellemben kell hogy viseltessenek.",
+		stopWords: "a akkor ami amely amikor az azon azt be csak de egy el én és ez ezen ezt fel ha hogy is itt ki " +
+			"le lesz lett már meg mert még mi minden mint nagyon nem ő ők ott sem te ti után vagy vagyok van " +
+			"vannak volt között",
+	},
+	{
+		code:   "ro",
+		script: ScriptLatin,
+		sample: "Toate ființele umane se nasc libere și egale în demnitate și în drepturi. Ele sunt înzestrate cu " +
+			"rațiune și conștiință și trebuie să se comporte unele față de altele în spiritul fraternității.",
+		stopWords: "a acea aceasta această acest acesta aceste al ale au ca care ce cu cum când da de decât din după " +
+			"ea ei el era este eu fi fost în la le lor lui mai mult nu o or pe pentru prin sau se să și sunt tot " +
+			"toate un una unei unui va",
+	},
+	{
+		code:   "tr",
+		script: ScriptLatin,
+		sample: "Bütün insanlar hür, haysiyet ve haklar bakımından eşit doğarlar. Akıl ve vicdana sahiptirler ve " +
+			"birbirlerine karşı kardeşlik zihniyeti ile hareket etmelidirler.",
+		extra: "Adam pazar sabahı oğluyla birlikte meydana geldi. Arabanın nerede kaldığını bilmiyorum ama " +
+			"istasyonun yakınında olduğunu düşünüyorum. Şehir merkezindeki bir dükkânda çalışıyor ve akşam " +
+			"yemeğinden sonra eve dönüyor. Bugün hava güzel, çocuklar sokakta komşunun kedisiyle koşuyorlar.",
+		letters: "ğış",
+		stopWords: "ama ben bir biz bu bunu çok da daha de değil en gibi hem her ile ise için kadar ki mi mı mu mü " +
+			"nasıl ne neden o olan olarak onlar önce sen siz sonra şey şu var ve veya ya yok",
+	},
+	{
+		code:   "hr",
+		script: ScriptLatin,
+		sample: "Sva ljudska bića rađaju se slobodna i jednaka u dostojanstvu i pravima. Ona su obdarena razumom " +
+			"i sviješću pa jedna prema drugima trebaju postupati u duhu bratstva.",
+		stopWords: "a ako ali bi bila bilo bio da do ga gdje i ih ili im iz ja je jedan jer još ju kad kako kao koja " +
+			"koje koji li me mi na nakon ne nego ni niti no o od on ona oni ono pa po pod prema s sa se sve svoj ta " +
+			"taj te to tu u uz već za što",
+	},
+	{
+		code:   "sl",
+		script: ScriptLatin,
+		sample: "Vsi ljudje se rodijo svobodni in imajo enako dostojanstvo in enake pravice. Obdarjeni so z " +
+			"razumom in vestjo in bi morali ravnati drug z drugim kakor bratje.",
+		stopWords: "a ali bi bil bila bilo da do ga in iz ja je jih kaj kako kar ker ki ko le me mi na ne ni o od on " +
+			"ona oni pa po pri s se so sta tako tam to tudi v vse z za že",
+	},
+	{
+		code:   "lt",
+		script: ScriptLatin,
+		sample: "Visi žmonės gimsta laisvi ir lygūs savo orumu ir teisėmis. Jiems suteiktas protas ir sąžinė ir " +
+			"jie turi elgtis vienas kito atžvilgiu kaip broliai.",
+		stopWords: "apie arba aš bet būti buvo dėl ir iš į ji jie jis jūs kad kaip kur kuri kurie kuris mes ne nuo " +
+			"pat per po prie savo su ta tai taip tas tik tu yra",
+	},
+	{
+		code:   "lv",
+		script: ScriptLatin,
+		sample: "Visi cilvēki piedzimst brīvi un vienlīdzīgi savā pašcieņā un tiesībās. Viņi ir apveltīti ar " +
+			"saprātu un sirdsapziņu, un viņiem jāizturas citam pret citu brālības garā.",
+		stopWords: "ar arī bet bija būt es ir jau jūs ka kā kas lai mēs nav no par pēc pie savā savu tā tas tie " +
+			"tikai tu un uz vai viņa viņi viņš",
+	},
+	{
+		code:   "et",
+		script: ScriptLatin,
+		sample: "Kõik inimesed sünnivad vabadena ja võrdsetena oma väärikuselt ja õigustelt. Neile on antud " +
+			"mõistus ja südametunnistus ja nende suhtumist üksteisesse peab kandma vendluse vaim.",
+		stopWords: "aga ainult ei enne et ilma ja juba ka koos kui kõik ma me mis nad nii oli oma on pärast sa see " +
+			"siis ta te veel või üle",
+	},
+	{
+		code:   "id",
+		script: ScriptLatin,
+		sample: "Semua orang dilahirkan merdeka dan mempunyai martabat dan hak-hak yang sama. Mereka dikaruniai " +
+			"akal dan hati nurani dan hendaknya bergaul satu sama lain dalam semangat persaudaraan.",
+		stopWords: "ada adalah akan atau bisa dalam dan dari dengan di dia hanya ini itu juga kami karena ke kita " +
+			"lebih mereka namun oleh pada saya sebagai seperti sudah telah tidak untuk yang",
+	},
+	{
+		code:   "vi",
+		script: ScriptLatin,
+		sample: "Tất cả mọi người sinh ra đều được tự do và bình đẳng về nhân phẩm và quyền lợi. Mọi con người " +
+			"đều được tạo hóa ban cho lý trí và lương tâm và cần phải đối xử với nhau trong tình bằng hữu.",
+		stopWords: "các cho có của cũng đã để đến được khi không là lại một này nên người như những nhưng ra rằng " +
+			"sẽ theo thì trong từ và về với",
+	},
+	{
+		code:   "ca",
+		script: ScriptLatin,
+		sample: "Tots els éssers humans neixen lliures i iguals en dignitat i en drets. Són dotats de raó i de " +
+			"consciència, i han de comportar-se fraternalment els uns amb els altres.",
+		stopWords: "a aquest aquesta això al als amb com de del dels el els en entre era és ha han i la les li lo " +
+			"més no o per però que qui se sense ser seu sobre són també un una uns",
+	},
+	{
+		code:   "ru",
+		script: ScriptCyrillic,
+		sample: "Все люди рождаются свободными и равными в своем достоинстве и правах. Они наделены разумом и " +
+			"совестью и должны поступать в отношении друг друга в духе братства.",
+		stopWords: "а без более бы был была были было быть в вам вас весь во вот все всех вы где да даже для до его " +
+			"ее ей если есть еще же за здесь и из или им их к как какой когда кто ли между меня мне можно мы на " +
+			"над нас не него нет ни них но ну о об однако он она они оно от очень по под после при про раз с со " +
+			"так также такой там те тем то только том тот тут у уже хотя чем что чтобы эта эти это этот я",
+	},
+	{
+		code:   "uk",
+		script: ScriptCyrillic,
+		sample: "Всі люди народжуються вільними і рівними у своїй гідності та правах. Вони наділені розумом і " +
+			"совістю і повинні діяти у відношенні один до одного в дусі братерства.",
+		stopWords: "або але бо був була було бути в вже вона вони все він де для до є же з за і її їх й коли з ми " +
+			"на не ні по при про та так також те ти то той у хто це цей ці ця чи що щоб як який яка які я",
+	},
+	{
+		code:   "bg",
+		script: ScriptCyrillic,
+		sample: "Всички хора се раждат свободни и равни по достойнство и права. Те са надарени с разум и съвест " +
+			"и следва да се отнасят помежду си в дух на братство.",
+		stopWords: "аз ако бе беше без в до е за и или към като който която които между на не но още от по при с " +
+			"са само се след също съм те това този тази тези ти тя той че ние вие",
+	},
+	{
+		code:   "sr",
+		script: ScriptCyrillic,
+		sample: "Сва људска бића рађају се слободна и једнака у достојанству и правима. Она су обдарена разумом " +
+			"и свешћу и треба једни према другима да поступају у духу братства.",
+		stopWords: "ако али без већ ви да до за и или између ја је још као која које који ми на не од он она они " +
+			"ова овај после са се су ти то у само",
+	},
+	{
+		code:   "ar",
+		script: ScriptArabic,
+		sample: "يولد جميع الناس أحرارًا متساوين في الكرامة والحقوق. وقد وهبوا عقلاً وضميرًا وعليهم أن يعامل " +
+			"بعضهم بعضًا بروح الإخاء.",
+		stopWords: "في من على إلى عن مع هذا هذه ذلك التي الذي الذين كان كانت هو هي هم أن إن لا ما لم لن قد كل بعد " +
+			"قبل بين حتى أو ثم عند غير أي",
+	},
+	{
+		code:   "fa",
+		script: ScriptArabic,
+		sample: "تمام افراد بشر آزاد به دنیا می‌آیند و از لحاظ حیثیت و حقوق با هم برابرند. همه دارای عقل و وجدان " +
+			"هستند و باید نسبت به یکدیگر با روح برادری رفتار کنند.",
+		stopWords: "و در به از که این آن با را برای است بود می شود هم یک تا بر نیز اما یا هر خود ما شما او آنها ها " +
+			"کرد کند دارد",
+	},
+	{
+		code:   "ur",
+		script: ScriptArabic,
+		sample: "تمام انسان آزاد اور حقوق و عزت کے اعتبار سے برابر پیدا ہوئے ہیں۔ انہیں ضمیر اور عقل ودیعت ہوئی " +
+			"ہے۔ اس لئے انہیں ایک دوسرے کے ساتھ بھائی چارے کا سلوک کرنا چاہیئے۔",
+		stopWords: "اور کے کی کا میں سے کو ہے ہیں پر نے یہ وہ ایک بھی تھا تھے تھی کہ جو لیے ساتھ اس ان کر گیا ہو",
+	},
+}
+
+// scriptStopWords are stop words for languages identified by script alone
+var scriptStopWords = map[string]string{
+	"el": "και το η ο να του της των τα τις σε με για από στο στη στην που είναι δεν θα ως αν ή οι ένα μια αυτό αυτή",
+	"he": "של את על עם זה זו הוא היא הם אני אתה לא כי אם גם או כל יש אין מה אבל רק עוד כמו",
+	"hi": "का की के में है और से को पर यह वह एक भी था थे थी हैं कि जो लिए साथ इस उस कर तो ही",
+}
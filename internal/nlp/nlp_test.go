@@ -0,0 +1,178 @@
+package nlp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"This is an English text with normal characters", "en"},
+		{"The quick brown fox jumps over the lazy dog while the children are watching", "en"},
+		{"Das Wetter ist heute sehr schön und wir gehen in den Park", "de"},
+		{"Le chat dort sur le canapé pendant que les enfants jouent dans le jardin", "fr"},
+		{"El perro corre por la playa y los niños juegan con la pelota", "es"},
+		{"Il ragazzo mangia la pizza con gli amici nella piazza della città", "it"},
+		{"O menino come uma maçã enquanto os amigos jogam futebol na rua", "pt"},
+		{"De kinderen spelen buiten in de tuin omdat het mooi weer is", "nl"},
+		{"Barnen leker ute i trädgården eftersom det är fint väder idag", "sv"},
+		{"Lapset leikkivät ulkona puutarhassa koska tänään on kaunis ilma", "fi"},
+		{"Dzieci bawią się w ogrodzie, ponieważ dzisiaj jest piękna pogoda", "pl"},
+		{"Děti si hrají na zahradě, protože je dnes krásné počasí", "cs"},
+		{"A gyerekek a kertben játszanak, mert ma szép idő van", "hu"},
+		{"Çocuklar bahçede oynuyor çünkü bugün hava çok güzel", "tr"},
+		// Regressions: short Portuguese read as Spanish, Turkish as Croatian
+		{"O cachorro corre no parque com o dono", "pt"},
+		{"Köpek parkta koşuyor ve oynuyor", "tr"},
+		{"Anak-anak bermain di taman karena cuaca hari ini sangat cerah", "id"},
+		{"Дети играют в саду, потому что сегодня хорошая погода", "ru"},
+		{"Діти граються в саду, бо сьогодні гарна погода", "uk"},
+		{"يلعب الأطفال في الحديقة لأن الطقس جميل اليوم", "ar"},
+		{"Τα παιδιά παίζουν στον κήπο", "el"},
+		{"孩子们在花园里玩耍", "zh"},
+		{"子供たちは庭で遊んでいます", "ja"},
+		{"아이들이 정원에서 놀고 있다", "ko"},
+		{"बच्चे बगीचे में खेल रहे हैं", "hi"},
+		{"", LanguageUnknown},
+		{"12345", LanguageUnknown},
+		{"ok", LanguageUnknown},
+		// Too little to tell Latin-script languages apart
+		{"abc def", LanguageUnknown},
+		{"hello world", LanguageUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected+"/"+tt.text, func(t *testing.T) {
+			detection := DetectLanguage(tt.text)
+			assert.Equal(t, tt.expected, detection.Language)
+			if tt.expected != LanguageUnknown {
+				assert.Greater(t, detection.Confidence, 0.0)
+				assert.LessOrEqual(t, detection.Confidence, 1.0)
+			}
+		})
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	languages := Languages()
+	assert.GreaterOrEqual(t, len(languages), 40)
+	assert.Contains(t, languages, "en")
+	assert.Contains(t, languages, "ja")
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, Normalize("STRASSE"), Normalize("Straße"))
+	assert.Equal(t, "file", Normalize("ﬁle"))      // Ligature
+	assert.Equal(t, "abc123", Normalize("ＡＢＣ１２３")) // Full-width
+	assert.Equal(t, Normalize("café"), Normalize("café"))
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"hello", "world", "42"}, Tokenize("hello, world! 42"))
+	assert.Equal(t, []string{"東京", "京タ", "タワ", "ワー", "tokyo"}, Tokenize("東京タワー tokyo"))
+}
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word, lang, expected string
+	}{
+		{"cameras", "en", "camera"},
+		{"batteries", "en", "battery"},
+		{"classes", "en", "class"},
+		{"glass", "en", "glass"},
+		{"running", "en", "run"},
+		{"kameras", "de", "kamera"},
+		{"téléphones", "fr", "téléphon"},
+		{"teléfonos", "es", "teléfon"},
+		{"телефоны", "ru", "телефон"},
+		{"bus", "en", "bus"},
+		{"word", "xx", "word"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Stem(tt.word, tt.lang), "%s (%s)", tt.word, tt.lang)
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	t.Run("groups variants by stem", func(t *testing.T) {
+		keywords := Keywords("Camera with two cameras and a camera bag", "en", 10)
+		assert.Equal(t, []string{"camera", "two", "bag"}, keywords)
+	})
+
+	t.Run("uses language stop words", func(t *testing.T) {
+		keywords := Keywords("Die Kamera und die Kameras für den Urlaub", "de", 10)
+		assert.Equal(t, []string{"kamera", "urlaub"}, keywords)
+	})
+
+	t.Run("respects limit", func(t *testing.T) {
+		keywords := Keywords("alpha beta gamma delta epsilon", "en", 2)
+		assert.Equal(t, []string{"alpha", "beta"}, keywords)
+	})
+}
+
+func TestTerms(t *testing.T) {
+	terms := Terms("Camera with two cameras and a camera bag", "en")
+	assert.Equal(t, Term{Stem: "camera", Surface: "camera", Count: 3, First: 0}, terms[0])
+	assert.Len(t, terms, 3)
+}
+
+func TestWeighting(t *testing.T) {
+	corpus := CorpusStats{Documents: 100, TotalLength: 1000}
+	bm25 := DefaultWeighting()
+
+	t.Run("rare terms outweigh common ones", func(t *testing.T) {
+		assert.Greater(t, bm25.Weight(1, 2, 10, corpus), bm25.Weight(1, 80, 10, corpus))
+	})
+
+	t.Run("term frequency saturates", func(t *testing.T) {
+		once := bm25.Weight(1, 5, 10, corpus)
+		assert.Greater(t, bm25.Weight(2, 5, 10, corpus), once)
+		assert.Less(t, bm25.Weight(2, 5, 10, corpus), 2*once)
+	})
+
+	t.Run("long documents are normalized", func(t *testing.T) {
+		assert.Greater(t, bm25.Weight(2, 5, 5, corpus), bm25.Weight(2, 5, 40, corpus))
+	})
+
+	t.Run("tfidf", func(t *testing.T) {
+		tfidf := Weighting{Scheme: SchemeTFIDF}
+		assert.InDelta(t, IDF(5, 100), tfidf.Weight(1, 5, 10, corpus), 1e-9)
+	})
+
+	t.Run("empty corpus", func(t *testing.T) {
+		assert.Greater(t, bm25.Weight(1, 0, 10, CorpusStats{}), 0.0)
+		assert.Greater(t, IDF(100, 100), 0.0)
+	})
+}
+
+func TestNounPhrases(t *testing.T) {
+	phrases := NounPhrases("Noise cancelling for long flights. Wireless headphones with noise cancelling", "en", 10)
+	assert.Equal(t, []string{"noise cancelling", "long flights", "wireless headphones"}, phrases)
+
+	phrases = NounPhrases("Wireless noise cancelling headphones", "en", 10)
+	assert.Equal(t, []string{"wireless noise cancelling", "noise cancelling headphones"}, phrases)
+
+	assert.Equal(t, []string{"fast charger"}, NounPhrases("A fast charger, with cable", "en", 10))
+	assert.Empty(t, NounPhrases("Headphones", "en", 10))
+}
+
+func TestModelNumbers(t *testing.T) {
+	assert.Equal(t, []string{"WH-1000XM4", "RTX 4090", "A7III"},
+		ModelNumbers("Sony WH-1000XM4 headphones, RTX 4090 card, 128GB, 4K and A7III"))
+	assert.Empty(t, ModelNumbers("2nd edition, 55in screen, 10mm"))
+}
+
+func TestGazetteer(t *testing.T) {
+	brands := NewGazetteer("Sony", "Bang & Olufsen", "Bang")
+	assert.False(t, brands.Add("SONY"))
+	assert.Equal(t, 3, brands.Len())
+
+	assert.Equal(t, []string{"Bang & Olufsen", "Sony"}, brands.Find("BANG & OLUFSEN speaker, compare with sony and Sony"))
+	assert.Equal(t, []string{"Bang"}, brands.Find("Bang headphones"))
+	assert.Empty(t, brands.Find("Sonya"))
+}
@@ -0,0 +1,99 @@
+package nlp
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// minStemLength is the shortest stem a suffix may be stripped down to
+const minStemLength = 3
+
+// Light stemmers strip the most common inflectional suffixes. They are meant to
+// group keyword variants ("camera"/"cameras", "Kamera"/"Kameras"), not to
+// produce linguistic roots, so they err on the side of stripping too little.
+var stemSuffixes = map[string][]string{
+	"de": {"ungen", "heiten", "keiten", "ung", "heit", "keit", "ern", "em", "en", "er", "es", "e", "s"},
+	"fr": {"issements", "issement", "ations", "ation", "ements", "ement", "euses", "euse", "eaux", "ités",
+		"ité", "ives", "ive", "ifs", "if", "aux", "es", "er", "ez", "ée", "és", "e", "s"},
+	"es": {"aciones", "ación", "amientos", "amiento", "mente", "idades", "idad", "istas", "ista", "ores",
+		"ador", "es", "as", "os", "a", "o", "e", "s"},
+	"it": {"azioni", "azione", "mente", "ità", "ismi", "ismo", "iste", "ista", "ori", "ore", "i", "e", "a", "o"},
+	"pt": {"ações", "ação", "mente", "idades", "idade", "ismos", "ismo", "istas", "ista", "ores", "or", "es",
+		"as", "os", "a", "o", "e", "s"},
+	"nl": {"heden", "heid", "ingen", "ing", "en", "er", "e", "s"},
+	"sv": {"heterna", "heten", "het", "arna", "erna", "orna", "ande", "ende", "are", "ast", "ar", "er",
+		"or", "en", "et", "na", "a", "e", "s"},
+	"da": {"hederne", "heden", "hed", "erne", "ende", "ene", "er", "en", "et", "e", "s"},
+	"no": {"hetene", "heten", "het", "ene", "ende", "er", "en", "et", "a", "e", "s"},
+	"fi": {"issa", "issä", "ista", "istä", "iden", "jen", "lla", "llä", "lta", "ltä", "lle", "ssa", "ssä",
+		"sta", "stä", "ksi", "na", "nä", "ta", "tä", "t", "n"},
+	"pl": {"ami", "ach", "owi", "ów", "om", "em", "ie", "ych", "ymi", "ego", "emu", "ej", "y", "i", "a", "e", "ę", "ą", "o", "u"},
+	"ru": {"ами", "ями", "ого", "его", "ому", "ему", "ыми", "ими", "ах", "ях", "ов", "ев", "ой", "ей",
+		"ом", "ем", "ам", "ям", "ые", "ие", "ый", "ий", "ая", "яя", "ое", "ее", "а", "я", "о", "е", "ы", "и", "у", "ю", "ь"},
+	"uk": {"ами", "ями", "ого", "ому", "ими", "ах", "ях", "ів", "ой", "ом", "ем", "ам", "ям", "ий", "а",
+		"я", "о", "е", "и", "і", "у", "ю", "ь"},
+}
+
+func init() {
+	// Try longer suffixes first
+	for _, suffixes := range stemSuffixes {
+		sort.SliceStable(suffixes, func(i, j int) bool {
+			return utf8.RuneCountInString(suffixes[i]) > utf8.RuneCountInString(suffixes[j])
+		})
+	}
+}
+
+// Stem reduces a normalized (case-folded) word to a light stem for the language.
+// Words in languages without a stemmer are returned unchanged.
+func Stem(word, lang string) string {
+	if lang == "en" {
+		return stemEnglish(word)
+	}
+	suffixes, ok := stemSuffixes[lang]
+	if !ok {
+		return word
+	}
+	return stripSuffix(word, suffixes)
+}
+
+func stripSuffix(word string, suffixes []string) string {
+	length := utf8.RuneCountInString(word)
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(word, suffix) && length-utf8.RuneCountInString(suffix) >= minStemLength {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
+
+// stemEnglish handles plurals and the common -ing/-ed/-ly endings
+func stemEnglish(word string) string {
+	n := len(word)
+	switch {
+	case n > 4 && strings.HasSuffix(word, "ies"):
+		return word[:n-3] + "y"
+	case n > 4 && (strings.HasSuffix(word, "sses") || strings.HasSuffix(word, "shes") || strings.HasSuffix(word, "ches") ||
+		strings.HasSuffix(word, "xes")):
+		return word[:n-2]
+	case n > 3 && strings.HasSuffix(word, "s") &&
+		!strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		return word[:n-1]
+	case n > 5 && strings.HasSuffix(word, "ing"):
+		return undouble(word[:n-3])
+	case n > 4 && strings.HasSuffix(word, "ed") && !strings.HasSuffix(word, "eed"):
+		return undouble(word[:n-2])
+	case n > 5 && strings.HasSuffix(word, "ly"):
+		return word[:n-2]
+	}
+	return word
+}
+
+// undouble removes a doubled final consonant left by -ing/-ed ("stopp" -> "stop")
+func undouble(stem string) string {
+	n := len(stem)
+	if n >= 4 && stem[n-1] == stem[n-2] && !strings.ContainsRune("aeiouls", rune(stem[n-1])) {
+		return stem[:n-1]
+	}
+	return stem
+}
@@ -0,0 +1,183 @@
+package nlp
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// minKeywordLength is the shortest token kept as a keyword, in runes
+const minKeywordLength = 3
+
+var (
+	stopWordsOnce sync.Once
+	stopWordSets  map[string]map[string]bool
+)
+
+// Normalize applies compatibility normalization (full-width forms, ligatures)
+// and case folding, so that equal words compare equal across inputs
+func Normalize(text string) string {
+	// A Caser keeps state, so one is created per call
+	return norm.NFKC.String(cases.Fold().String(text))
+}
+
+// Tokenize splits normalized text into words. Han and kana text has no word
+// separators, so it is split into overlapping character bigrams instead.
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+
+	var cjk []rune
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			tokens = append(tokens, string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i+2 <= len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flush()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flush()
+			flushCJK()
+		}
+	}
+	flush()
+	flushCJK()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	// U+30FC (prolonged sound mark) is in the Common script but only used in kana
+	return r == '\u30fc' || unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// StopWords returns the stop word set for a language. Unknown languages get the
+// English list, which is the most common fallback for short or mixed text.
+func StopWords(lang string) map[string]bool {
+	stopWordsOnce.Do(func() {
+		stopWordSets = make(map[string]map[string]bool)
+		add := func(code, words string) {
+			set := make(map[string]bool)
+			for _, w := range strings.Fields(Normalize(words)) {
+				set[w] = true
+			}
+			stopWordSets[code] = set
+		}
+		for _, p := range languageProfiles {
+			add(p.code, p.stopWords)
+		}
+		for code, words := range scriptStopWords {
+			add(code, words)
+		}
+	})
+
+	if set, ok := stopWordSets[lang]; ok {
+		return set
+	}
+	return stopWordSets["en"]
+}
+
+// Term is a keyword candidate: every variant of a stem counted together
+type Term struct {
+	Stem    string
+	Surface string // Most frequent form as written, normalized
+	Count   int
+	First   int // Token position of the first occurrence
+}
+
+// Terms returns the keyword candidates of text in the given language. Stop
+// words, numbers and short tokens are dropped, and variants sharing a stem are
+// counted together. Terms are ordered by frequency, then by first occurrence.
+func Terms(text, lang string) []Term {
+	type group struct {
+		count    int
+		first    int
+		surfaces map[string]int
+	}
+
+	stopWords := StopWords(lang)
+	groups := make(map[string]*group)
+	for i, token := range Tokenize(Normalize(text)) {
+		if stopWords[token] || isNumeric(token) {
+			continue
+		}
+		if utf8.RuneCountInString(token) < minKeywordLength && !isCJK([]rune(token)[0]) {
+			continue
+		}
+
+		stem := Stem(token, lang)
+		g, ok := groups[stem]
+		if !ok {
+			g = &group{first: i, surfaces: make(map[string]int)}
+			groups[stem] = g
+		}
+		g.count++
+		g.surfaces[token]++
+	}
+
+	terms := make([]Term, 0, len(groups))
+	for stem, g := range groups {
+		best, bestCount := "", 0
+		for surface, count := range g.surfaces {
+			if count > bestCount || (count == bestCount && surface < best) {
+				best, bestCount = surface, count
+			}
+		}
+		terms = append(terms, Term{Stem: stem, Surface: best, Count: g.count, First: g.first})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Count != terms[j].Count {
+			return terms[i].Count > terms[j].Count
+		}
+		return terms[i].First < terms[j].First
+	})
+	return terms
+}
+
+// Keywords returns up to limit keywords from text in the given language, each
+// reported as its most frequent surface form. Keywords are ordered by
+// frequency, then by first occurrence.
+func Keywords(text, lang string, limit int) []string {
+	terms := Terms(text, lang)
+	if limit > 0 && len(terms) > limit {
+		terms = terms[:limit]
+	}
+
+	keywords := make([]string, 0, len(terms))
+	for _, term := range terms {
+		keywords = append(keywords, term.Surface)
+	}
+	return keywords
+}
+
+func isNumeric(token string) bool {
+	for _, r := range token {
+		if !unicode.IsNumber(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package nlp
+
+import "math"
+
+// Term weighting schemes
+const (
+	SchemeBM25  = "bm25"
+	SchemeTFIDF = "tfidf"
+)
+
+// CorpusStats describes the documents that term weights are computed against
+type CorpusStats struct {
+	Documents   int     // Documents in the corpus
+	TotalLength float64 // Sum of the document lengths, in term occurrences
+}
+
+// AverageLength returns the mean document length, or 0 for an empty corpus
+func (s CorpusStats) AverageLength() float64 {
+	if s.Documents <= 0 {
+		return 0
+	}
+	return s.TotalLength / float64(s.Documents)
+}
+
+// Weighting scores the terms of a document against corpus statistics
+type Weighting struct {
+	Scheme string  // bm25 (default) or tfidf
+	K1     float64 // BM25 term frequency saturation
+	B      float64 // BM25 document length normalization, 0-1
+}
+
+// DefaultWeighting is BM25 with the usual k1 = 1.2 and b = 0.75
+func DefaultWeighting() Weighting {
+	return Weighting{Scheme: SchemeBM25, K1: 1.2, B: 0.75}
+}
+
+// IDF returns the BM25 inverse document frequency of a term. It stays positive
+// for terms found in most documents, so common terms rank low but never count
+// against a document.
+func IDF(documentFrequency, documents int) float64 {
+	n, df := float64(documents), float64(documentFrequency)
+	if df > n {
+		n = df
+	}
+	return math.Log(1 + (n-df+0.5)/(df+0.5))
+}
+
+// Weight returns the weight of a term that occurs tf times in a document of the
+// given length and in documentFrequency documents of the corpus
+func (w Weighting) Weight(tf float64, documentFrequency int, length float64, corpus CorpusStats) float64 {
+	if tf <= 0 {
+		return 0
+	}
+	idf := IDF(documentFrequency, corpus.Documents)
+
+	if w.Scheme == SchemeTFIDF {
+		return (1 + math.Log(tf)) * idf
+	}
+
+	k1, b := w.K1, w.B
+	if k1 <= 0 {
+		k1 = 1.2
+	}
+	if b < 0 || b > 1 {
+		b = 0.75
+	}
+	lengthNorm := 1.0
+	if avg := corpus.AverageLength(); avg > 0 && length > 0 {
+		lengthNorm = 1 - b + b*length/avg
+	}
+	return idf * tf * (k1 + 1) / (tf + k1*lengthNorm)
+}
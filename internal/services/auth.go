@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"time"
 
@@ -112,6 +113,19 @@ func (s *AuthService) ValidateAPIKey(apiKey string) (string, error) {
 	if tier, exists := apiKeyToTier[apiKey]; exists {
 		return tier, nil
 	}
+	if s.IsAdminAPIKey(apiKey) {
+		return "enterprise", nil
+	}
 
 	return "", fmt.Errorf("invalid API key")
 }
+
+// IsAdminAPIKey reports whether the API key is configured as an admin key
+func (s *AuthService) IsAdminAPIKey(apiKey string) bool {
+	for _, key := range s.config.Auth.AdminAPIKeys {
+		if key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+			return true
+		}
+	}
+	return false
+}
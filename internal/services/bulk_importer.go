@@ -0,0 +1,683 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/media"
+	"github.com/temcen/pirex/internal/messaging"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+const (
+	bulkImportJobType = "bulk_import"
+
+	// importBackpressurePoll is how often a paused import re-checks the pipeline backlog
+	importBackpressurePoll = 500 * time.Millisecond
+
+	// importMaxRedirects caps redirects followed when fetching URL sources
+	importMaxRedirects = 5
+)
+
+var (
+	ErrImportSourceNotAllowed = errors.New("import source not allowed")
+	ErrImportFormatUnknown    = errors.New("unknown import format")
+	ErrImportTooLarge         = errors.New("import file exceeds maximum size")
+	ErrImportMappingInvalid   = errors.New("invalid import mapping")
+)
+
+// BulkImporter streams large NDJSON/CSV catalogs into the ingestion topic. Rows are
+// mapped to ContentIngestionRequest, validated, and published in batches; reading
+// pauses while too many published items are still waiting for the pipeline.
+type BulkImporter struct {
+	messageBus   *messaging.MessageBus
+	jobManager   *JobManager
+	config       *config.BulkImportConfig
+	validator    *validator.Validate
+	preprocessor *DataPreprocessor // Content type schemas; the built-in types without it
+	httpClient   *http.Client
+	logger       *logrus.Logger
+
+	runningMu sync.Mutex
+	running   map[uuid.UUID]context.CancelFunc // Stops reading imports whose job is cancelled
+}
+
+// importPlan is a resolved import: where to read from and how to map rows
+type importPlan struct {
+	format    string
+	delimiter rune
+	mapping   *ImportMapping
+	source    string
+	callback  string
+	open      func(ctx context.Context) (io.ReadCloser, error)
+	cleanup   func()
+}
+
+type importBatch struct {
+	items []models.ContentIngestionRequest
+	rows  []int
+}
+
+type importStats struct {
+	rowsRead     int64
+	rowsQueued   int64
+	rowsRejected int64
+}
+
+func (s *importStats) snapshot() map[string]interface{} {
+	return map[string]interface{}{
+		"rows_read":     atomic.LoadInt64(&s.rowsRead),
+		"rows_queued":   atomic.LoadInt64(&s.rowsQueued),
+		"rows_rejected": atomic.LoadInt64(&s.rowsRejected),
+	}
+}
+
+func NewBulkImporter(messageBus *messaging.MessageBus, jobManager *JobManager, cfg *config.BulkImportConfig, logger *logrus.Logger) *BulkImporter {
+	timeout := 30 * time.Minute
+	allowPrivate := false
+	if cfg != nil {
+		if cfg.FetchTimeout > 0 {
+			timeout = cfg.FetchTimeout
+		}
+		allowPrivate = cfg.AllowPrivateURLs
+	}
+
+	bi := &BulkImporter{
+		messageBus: messageBus,
+		jobManager: jobManager,
+		config:     cfg,
+		validator:  validator.New(),
+		httpClient: media.NewPublicClient(timeout, importMaxRedirects, allowPrivate),
+		logger:     logger,
+		running:    make(map[uuid.UUID]context.CancelFunc),
+	}
+	if jobManager != nil {
+		jobManager.OnJobFinished(bi.stopCancelled)
+	}
+	return bi
+}
+
+// SetPreprocessor makes row validation use the preprocessor's content types
+func (bi *BulkImporter) SetPreprocessor(preprocessor *DataPreprocessor) {
+	bi.preprocessor = preprocessor
+}
+
+// Spool buffers an upload to a temporary file so the import can outlive the request.
+// The caller owns the returned file until it is handed to StartSpooled.
+func (bi *BulkImporter) Spool(r io.Reader) (string, error) {
+	file, err := os.CreateTemp(bi.config.SpoolDir, "content-import-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create spool file: %w", err)
+	}
+	defer file.Close()
+
+	maxSize := bi.config.MaxUploadSize
+	if maxSize <= 0 {
+		maxSize = 2 << 30
+	}
+
+	written, err := io.Copy(file, io.LimitReader(r, maxSize+1))
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to spool upload: %w", err)
+	}
+	if written > maxSize {
+		os.Remove(file.Name())
+		return "", ErrImportTooLarge
+	}
+
+	return file.Name(), nil
+}
+
+// StartSpooled starts an import of a spooled upload. The spool file is removed
+// once the import finishes or fails to start.
+func (bi *BulkImporter) StartSpooled(ctx context.Context, spoolPath, filename string, request models.ContentImportRequest) (*JobProgress, error) {
+	plan, err := bi.newPlan(request, filename)
+	if err != nil {
+		os.Remove(spoolPath)
+		return nil, err
+	}
+
+	plan.source = "upload:" + filename
+	plan.open = func(context.Context) (io.ReadCloser, error) { return os.Open(spoolPath) }
+	plan.cleanup = func() { os.Remove(spoolPath) }
+
+	job, err := bi.start(ctx, plan)
+	if err != nil {
+		os.Remove(spoolPath)
+	}
+	return job, err
+}
+
+// StartFromSource starts an import from a local path under the configured root or
+// from an HTTP(S) URL (an object store stand-in)
+func (bi *BulkImporter) StartFromSource(ctx context.Context, request models.ContentImportRequest) (*JobProgress, error) {
+	switch {
+	case request.Path != nil && request.URL != nil:
+		return nil, fmt.Errorf("%w: specify either path or url, not both", ErrImportSourceNotAllowed)
+
+	case request.Path != nil:
+		path, err := bi.resolveLocalPath(*request.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		plan, err := bi.newPlan(request, path)
+		if err != nil {
+			return nil, err
+		}
+		plan.source = "path:" + *request.Path
+		plan.open = func(context.Context) (io.ReadCloser, error) { return os.Open(path) }
+		return bi.start(ctx, plan)
+
+	case request.URL != nil:
+		sourceURL, err := bi.validateSourceURL(*request.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		plan, err := bi.newPlan(request, sourceURL.Path)
+		if err != nil {
+			return nil, err
+		}
+		plan.source = "url:" + sourceURL.Redacted()
+		plan.open = func(ctx context.Context) (io.ReadCloser, error) { return bi.fetch(ctx, sourceURL.String()) }
+		return bi.start(ctx, plan)
+
+	default:
+		return nil, fmt.Errorf("%w: path or url is required", ErrImportSourceNotAllowed)
+	}
+}
+
+func (bi *BulkImporter) newPlan(request models.ContentImportRequest, filename string) (*importPlan, error) {
+	format := request.Format
+	if format == "" {
+		format = detectImportFormat(filename)
+	}
+	if format != ImportFormatNDJSON && format != ImportFormatCSV {
+		return nil, fmt.Errorf("%w: set format to ndjson or csv", ErrImportFormatUnknown)
+	}
+
+	fields := bi.config.Mapping
+	if len(request.Mapping) > 0 {
+		fields = request.Mapping
+	}
+
+	defaultType := request.DefaultType
+	if defaultType == "" {
+		defaultType = bi.config.DefaultContentType
+	}
+
+	listSeparator := request.ListSeparator
+	if listSeparator == "" {
+		listSeparator = bi.config.ListSeparator
+	}
+
+	mapping, err := NewImportMapping(fields, defaultType, listSeparator, format == ImportFormatCSV)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &importPlan{
+		format:   format,
+		mapping:  mapping,
+		callback: request.CallbackURL,
+	}
+	if request.Delimiter != "" {
+		plan.delimiter = []rune(request.Delimiter)[0]
+	}
+
+	return plan, nil
+}
+
+func (bi *BulkImporter) start(ctx context.Context, plan *importPlan) (*JobProgress, error) {
+	details := map[string]interface{}{
+		"source": plan.source,
+		"format": plan.format,
+	}
+	if plan.callback != "" {
+		details[jobDetailWebhookURL] = plan.callback
+	}
+
+	job, err := bi.jobManager.CreateStreamingJob(ctx, bulkImportJobType, details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	go bi.run(context.Background(), job.JobID, plan)
+
+	return job, nil
+}
+
+func (bi *BulkImporter) run(ctx context.Context, jobID uuid.UUID, plan *importPlan) {
+	if plan.cleanup != nil {
+		defer plan.cleanup()
+	}
+
+	logger := bi.logger.WithFields(logrus.Fields{
+		"job_id": jobID,
+		"source": plan.source,
+		"format": plan.format,
+	})
+	logger.Info("Bulk import started")
+	startTime := time.Now()
+
+	stats := &importStats{}
+
+	// Reading stops as soon as the job is cancelled on this instance; job
+	// updates keep using ctx so the job can still be sealed
+	readCtx := bi.track(ctx, jobID)
+	defer bi.untrack(jobID)
+
+	source, err := plan.open(readCtx)
+	if err != nil {
+		bi.finish(ctx, jobID, stats, fmt.Errorf("failed to open import source: %w", err))
+		return
+	}
+	defer source.Close()
+
+	reader, err := newImportRecordReader(plan.format, source, plan.delimiter, bi.maxLineBytes())
+	if err != nil {
+		bi.finish(ctx, jobID, stats, err)
+		return
+	}
+
+	batchSize := bi.publishBatchSize()
+	workers := bi.publishWorkers()
+
+	// The batch channel is unbuffered beyond one batch per worker, so a slow
+	// Kafka write blocks the reader instead of piling rows up in memory
+	batches := make(chan importBatch, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go bi.publishWorker(ctx, jobID, batches, stats, &wg)
+	}
+
+	var readErr error
+	var rejected []ItemError
+	batch := importBatch{}
+
+	for {
+		record, row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var rowErr *importRowError
+			if errors.As(err, &rowErr) {
+				rejected = append(rejected, ItemError{Row: rowErr.row, Stage: rowErr.stage, Message: rowErr.err.Error()})
+				atomic.AddInt64(&stats.rowsRead, 1)
+				atomic.AddInt64(&stats.rowsRejected, 1)
+			} else {
+				readErr = err
+				break
+			}
+		} else {
+			atomic.AddInt64(&stats.rowsRead, 1)
+
+			if item, itemErr := bi.mapRow(plan.mapping, record, row); itemErr != nil {
+				rejected = append(rejected, *itemErr)
+				atomic.AddInt64(&stats.rowsRejected, 1)
+			} else {
+				batch.items = append(batch.items, item)
+				batch.rows = append(batch.rows, row)
+			}
+		}
+
+		if len(batch.items) >= batchSize {
+			if err := bi.waitForCapacity(readCtx, jobID); err != nil {
+				readErr = err
+				batch = importBatch{}
+				break
+			}
+			batches <- batch
+			batch = importBatch{}
+		}
+
+		if len(rejected) >= batchSize {
+			bi.reject(ctx, jobID, rejected)
+			rejected = nil
+		}
+	}
+
+	// A cancelled download surfaces as a read error
+	if readErr != nil && readCtx.Err() != nil {
+		readErr = ErrJobCancelled
+		batch = importBatch{}
+	}
+
+	if len(batch.items) > 0 {
+		batches <- batch
+	}
+	bi.reject(ctx, jobID, rejected)
+
+	close(batches)
+	wg.Wait()
+
+	bi.finish(ctx, jobID, stats, readErr)
+
+	logger.WithFields(logrus.Fields{
+		"rows_read":     atomic.LoadInt64(&stats.rowsRead),
+		"rows_queued":   atomic.LoadInt64(&stats.rowsQueued),
+		"rows_rejected": atomic.LoadInt64(&stats.rowsRejected),
+		"duration":      time.Since(startTime),
+	}).Info("Bulk import finished reading")
+}
+
+// mapRow converts and validates one record, returning an ItemError when the row is rejected
+func (bi *BulkImporter) mapRow(mapping *ImportMapping, record map[string]interface{}, row int) (models.ContentIngestionRequest, *ItemError) {
+	item, err := mapping.Apply(record)
+	if err != nil {
+		return item, rowItemError(row, item, "mapping", err)
+	}
+
+	if err := bi.validator.Struct(&item); err != nil {
+		return item, rowItemError(row, item, "validation", err)
+	}
+	if itemErr := schemaItemError(bi.preprocessor, row, item); itemErr != nil {
+		return item, itemErr
+	}
+
+	return item, nil
+}
+
+func (bi *BulkImporter) publishWorker(ctx context.Context, jobID uuid.UUID, batches <-chan importBatch, stats *importStats, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for batch := range batches {
+		if err := bi.jobManager.ExpandJob(ctx, jobID, len(batch.items)); err != nil {
+			bi.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to add import items to job")
+		}
+
+		now := time.Now()
+		hints := make([]map[string]interface{}, len(batch.items))
+		for i := range batch.items {
+			hints[i] = map[string]interface{}{
+				"source":      "bulk_import",
+				"batch_id":    jobID,
+				HintImportRow: batch.rows[i],
+				HintItemIndex: batch.rows[i] - 1,
+				"timestamp":   now,
+			}
+		}
+
+		if err := bi.messageBus.PublishContentIngestionBatch(jobID, batch.items, hints); err != nil {
+			bi.logger.WithError(err).WithField("job_id", jobID).Error("Failed to publish import batch")
+
+			itemErrors := make([]ItemError, len(batch.items))
+			failed := make([]FailedItem, len(batch.items))
+			for i, item := range batch.items {
+				itemErrors[i] = *rowItemError(batch.rows[i], item, "publish", err)
+				failed[i] = FailedItem{Error: itemErrors[i], Item: item, Hints: hints[i]}
+			}
+			if err := bi.jobManager.StoreFailedItems(ctx, jobID, failed); err != nil {
+				bi.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to store failed items for retry")
+			}
+			if err := bi.jobManager.FailItems(ctx, jobID, itemErrors); err != nil {
+				bi.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to record publish failures")
+			}
+			atomic.AddInt64(&stats.rowsRejected, int64(len(batch.items)))
+			continue
+		}
+
+		atomic.AddInt64(&stats.rowsQueued, int64(len(batch.items)))
+	}
+}
+
+// reject counts rows that never reached the topic as failed items of the job
+func (bi *BulkImporter) reject(ctx context.Context, jobID uuid.UUID, itemErrors []ItemError) {
+	if len(itemErrors) == 0 {
+		return
+	}
+
+	if err := bi.jobManager.ExpandJob(ctx, jobID, len(itemErrors)); err != nil {
+		bi.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to add rejected rows to job")
+	}
+	if err := bi.jobManager.FailItems(ctx, jobID, itemErrors); err != nil {
+		bi.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to record rejected rows")
+	}
+}
+
+// waitForCapacity blocks while the job has more unprocessed items in the pipeline
+// than max_in_flight allows. It returns ErrJobCancelled once the job is cancelled.
+func (bi *BulkImporter) waitForCapacity(ctx context.Context, jobID uuid.UUID) error {
+	return awaitImportCapacity(ctx, func(ctx context.Context) (*JobProgress, error) {
+		return bi.jobManager.GetJob(ctx, jobID)
+	}, bi.config.MaxInFlight, importBackpressurePoll)
+}
+
+// awaitImportCapacity polls the job until fewer than maxInFlight of its items
+// are waiting for the pipeline. A cancelled job or context returns
+// ErrJobCancelled; lookup errors never stop an import.
+func awaitImportCapacity(
+	ctx context.Context,
+	getJob func(ctx context.Context) (*JobProgress, error),
+	maxInFlight int,
+	poll time.Duration,
+) error {
+	for {
+		job, err := getJob(ctx)
+		if ctx.Err() != nil {
+			return ErrJobCancelled
+		}
+		if err != nil {
+			return nil
+		}
+		if job.Status == JobStatusCancelled {
+			return ErrJobCancelled
+		}
+		if maxInFlight <= 0 || jobPendingItems(job) < maxInFlight {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ErrJobCancelled
+		case <-time.After(poll):
+		}
+	}
+}
+
+// track returns a context for reading an import that is cancelled with its job
+func (bi *BulkImporter) track(ctx context.Context, jobID uuid.UUID) context.Context {
+	readCtx, cancel := context.WithCancel(ctx)
+	bi.runningMu.Lock()
+	bi.running[jobID] = cancel
+	bi.runningMu.Unlock()
+	return readCtx
+}
+
+func (bi *BulkImporter) untrack(jobID uuid.UUID) {
+	bi.runningMu.Lock()
+	cancel := bi.running[jobID]
+	delete(bi.running, jobID)
+	bi.runningMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// stopCancelled is a job finished hook stopping the import of a cancelled job
+func (bi *BulkImporter) stopCancelled(job *JobProgress) {
+	if job.Status != JobStatusCancelled {
+		return
+	}
+	bi.runningMu.Lock()
+	cancel := bi.running[job.JobID]
+	bi.runningMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (bi *BulkImporter) finish(ctx context.Context, jobID uuid.UUID, stats *importStats, importErr error) {
+	details := map[string]interface{}{
+		"import": stats.snapshot(),
+	}
+	switch {
+	case errors.Is(importErr, ErrJobCancelled):
+		bi.logger.WithField("job_id", jobID).Info("Bulk import stopped after cancellation")
+		importErr = nil
+	case importErr != nil:
+		bi.logger.WithError(importErr).WithField("job_id", jobID).Error("Bulk import aborted")
+		details["import_error"] = importErr.Error()
+	}
+
+	// Nothing was read, so nothing will ever complete the job
+	if importErr != nil && atomic.LoadInt64(&stats.rowsRead) == 0 {
+		if err := bi.jobManager.AbortJob(ctx, jobID, importErr.Error(), details); err != nil {
+			bi.logger.WithError(err).WithField("job_id", jobID).Error("Failed to mark import job as failed")
+		}
+		return
+	}
+
+	if err := bi.jobManager.SealJob(ctx, jobID, details); err != nil {
+		bi.logger.WithError(err).WithField("job_id", jobID).Error("Failed to seal import job")
+	}
+}
+
+func (bi *BulkImporter) resolveLocalPath(path string) (string, error) {
+	if bi.config.LocalRoot == "" {
+		return "", fmt.Errorf("%w: local path sources are disabled", ErrImportSourceNotAllowed)
+	}
+
+	root, err := filepath.Abs(bi.config.LocalRoot)
+	if err != nil {
+		return "", fmt.Errorf("invalid import root: %w", err)
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", fmt.Errorf("invalid import root: %w", err)
+	}
+
+	resolved := filepath.Join(root, path)
+	if filepath.IsAbs(path) {
+		resolved = path
+	}
+	// Check where symlinks lead, not where they are
+	if resolved, err = filepath.EvalSymlinks(resolved); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrImportSourceNotAllowed, err)
+	}
+
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: path is outside the import root", ErrImportSourceNotAllowed)
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrImportSourceNotAllowed, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%w: path is a directory", ErrImportSourceNotAllowed)
+	}
+
+	return resolved, nil
+}
+
+func (bi *BulkImporter) validateSourceURL(raw string) (*url.URL, error) {
+	if !bi.config.AllowURLSources {
+		return nil, fmt.Errorf("%w: url sources are disabled", ErrImportSourceNotAllowed)
+	}
+
+	sourceURL, err := media.ValidatePublicURL(raw, bi.config.AllowPrivateURLs)
+	if errors.Is(err, media.ErrUnsupportedScheme) {
+		return nil, fmt.Errorf("%w: only http and https urls are supported", ErrImportSourceNotAllowed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImportSourceNotAllowed, err)
+	}
+
+	return sourceURL, nil
+}
+
+func (bi *BulkImporter) fetch(ctx context.Context, sourceURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := bi.httpClient.Do(req)
+	if err != nil {
+		// Hostnames resolving to internal addresses are refused when dialing
+		if errors.Is(err, media.ErrBlockedAddress) {
+			return nil, fmt.Errorf("%w: %v", ErrImportSourceNotAllowed, media.ErrBlockedAddress)
+		}
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+func (bi *BulkImporter) maxLineBytes() int {
+	if bi.config.MaxLineBytes > 0 {
+		return bi.config.MaxLineBytes
+	}
+	return 1 << 20
+}
+
+func (bi *BulkImporter) publishBatchSize() int {
+	if bi.config.PublishBatchSize > 0 {
+		return bi.config.PublishBatchSize
+	}
+	return 100
+}
+
+func (bi *BulkImporter) publishWorkers() int {
+	if bi.config.PublishWorkers > 0 {
+		return bi.config.PublishWorkers
+	}
+	return 4
+}
+
+func rowItemError(row int, item models.ContentIngestionRequest, stage string, err error) *ItemError {
+	itemErr := &ItemError{Row: row, Stage: stage, Message: err.Error()}
+	if item.ExternalID != nil {
+		itemErr.ExternalID = *item.ExternalID
+	}
+	return itemErr
+}
+
+// schemaItemError checks a row's content type and metadata schema, returning
+// nil when the item is valid
+func schemaItemError(preprocessor *DataPreprocessor, row int, item models.ContentIngestionRequest) *ItemError {
+	fieldErrors := preprocessor.ValidateContent(item)
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+
+	itemErr := rowItemError(row, item, "validation", FieldErrorsMessage(fieldErrors))
+	itemErr.Fields = fieldErrors
+	return itemErr
+}
+
+// detectImportFormat guesses the format from a file name or URL path
+func detectImportFormat(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ndjson", ".jsonl", ".json":
+		return ImportFormatNDJSON
+	case ".csv":
+		return ImportFormatCSV
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,546 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/connectors"
+	"github.com/temcen/pirex/internal/database"
+	"github.com/temcen/pirex/internal/messaging"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+const (
+	catalogSyncJobType = "catalog_sync"
+
+	CatalogChangeCreate     = "create"
+	CatalogChangeUpdate     = "update"
+	CatalogChangeDeactivate = "deactivate"
+
+	// HintOperation in processing hints selects a non-default pipeline operation
+	HintOperation       = "operation"
+	OperationDeactivate = "deactivate"
+)
+
+var ErrCatalogSyncInProgress = errors.New("catalog sync already running")
+
+var (
+	catalogSyncRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "catalog_sync_runs_total",
+		Help: "Catalog sync runs by connector and outcome",
+	}, []string{"connector", "status"})
+
+	catalogSyncChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "catalog_sync_changes_total",
+		Help: "Catalog items emitted by connector and change type",
+	}, []string{"connector", "change"})
+
+	catalogSyncDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "catalog_sync_duration_seconds",
+		Help:    "Catalog sync run duration in seconds",
+		Buckets: []float64{1, 5, 15, 60, 300, 900},
+	}, []string{"connector"})
+)
+
+// CatalogChange is a single item emitted by a sync run
+type CatalogChange struct {
+	Kind       string
+	ExternalID string
+	Hash       string
+	Item       models.ContentIngestionRequest
+	Index      int // position in the feed; deactivations are numbered after the feed items
+}
+
+// CatalogItemState is what the sync remembers about an item between runs
+type CatalogItemState struct {
+	Hash  string `json:"hash"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+}
+
+// CatalogSyncService polls content connectors on a schedule, diffs each snapshot
+// against the previous run by external ID and content hash, and publishes only
+// creates, updates and deactivations to the ingestion topic
+type CatalogSyncService struct {
+	db         *database.Database
+	messageBus *messaging.MessageBus
+	jobManager *JobManager
+	config     *config.CatalogSyncConfig
+	validator  *validator.Validate
+	logger     *logrus.Logger
+
+	preprocessor *DataPreprocessor // Content type schemas; the built-in types without it
+
+	mu    sync.RWMutex
+	feeds map[string]*catalogFeed
+	quit  chan struct{}
+	wg    sync.WaitGroup
+}
+
+type catalogFeed struct {
+	connector connectors.ContentConnector
+	mapping   *ImportMapping
+	interval  time.Duration
+	running   int32
+}
+
+func NewCatalogSyncService(db *database.Database, messageBus *messaging.MessageBus, jobManager *JobManager, cfg *config.CatalogSyncConfig, logger *logrus.Logger) *CatalogSyncService {
+	s := &CatalogSyncService{
+		db:         db,
+		messageBus: messageBus,
+		jobManager: jobManager,
+		config:     cfg,
+		validator:  validator.New(),
+		logger:     logger,
+		feeds:      make(map[string]*catalogFeed),
+		quit:       make(chan struct{}),
+	}
+
+	for _, feedCfg := range cfg.Feeds {
+		connector, err := connectors.NewFeedConnector(feedCfg)
+		if err != nil {
+			logger.WithError(err).WithField("connector", feedCfg.Name).Error("Skipping invalid catalog feed")
+			continue
+		}
+
+		mapping, err := NewImportMapping(feedCfg.Mapping, feedCfg.DefaultType, "", feedCfg.Format == connectors.FormatCSV)
+		if err != nil {
+			logger.WithError(err).WithField("connector", feedCfg.Name).Error("Skipping catalog feed with invalid mapping")
+			continue
+		}
+
+		if err := s.Register(connector, mapping, feedCfg.Interval); err != nil {
+			logger.WithError(err).WithField("connector", feedCfg.Name).Error("Failed to register catalog feed")
+		}
+	}
+
+	return s
+}
+
+// Register adds a connector. A zero interval uses the configured default.
+func (s *CatalogSyncService) Register(connector connectors.ContentConnector, mapping *ImportMapping, interval time.Duration) error {
+	if interval <= 0 {
+		interval = s.config.DefaultInterval
+	}
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.feeds[connector.Name()]; exists {
+		return fmt.Errorf("connector %s already registered", connector.Name())
+	}
+
+	s.feeds[connector.Name()] = &catalogFeed{
+		connector: connector,
+		mapping:   mapping,
+		interval:  interval,
+	}
+	return nil
+}
+
+// Start schedules every registered connector. Each runs once immediately and then
+// on its interval until Stop is called.
+// SetPreprocessor makes feed validation use the preprocessor's content types
+func (s *CatalogSyncService) SetPreprocessor(preprocessor *DataPreprocessor) {
+	s.preprocessor = preprocessor
+}
+
+func (s *CatalogSyncService) Start(ctx context.Context) {
+	if !s.config.Enabled {
+		s.logger.Info("Catalog sync disabled")
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, feed := range s.feeds {
+		s.wg.Add(1)
+		go s.schedule(ctx, feed)
+	}
+
+	s.logger.WithField("connectors", len(s.feeds)).Info("Catalog sync started")
+}
+
+func (s *CatalogSyncService) Stop() {
+	close(s.quit)
+	s.wg.Wait()
+}
+
+// Sync runs one connector immediately and returns its job once the run has finished
+// publishing. Items are processed asynchronously by the pipeline afterwards.
+func (s *CatalogSyncService) Sync(ctx context.Context, name string) (*JobProgress, error) {
+	s.mu.RLock()
+	feed, ok := s.feeds[name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("connector %s not found", name)
+	}
+
+	return s.run(ctx, feed)
+}
+
+func (s *CatalogSyncService) schedule(ctx context.Context, feed *catalogFeed) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(feed.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.run(ctx, feed); err != nil && !errors.Is(err, ErrCatalogSyncInProgress) {
+			s.logger.WithError(err).WithField("connector", feed.connector.Name()).Error("Catalog sync failed")
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.quit:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *CatalogSyncService) run(ctx context.Context, feed *catalogFeed) (*JobProgress, error) {
+	name := feed.connector.Name()
+	if !atomic.CompareAndSwapInt32(&feed.running, 0, 1) {
+		return nil, ErrCatalogSyncInProgress
+	}
+	defer atomic.StoreInt32(&feed.running, 0)
+
+	startTime := time.Now()
+	defer func() {
+		catalogSyncDuration.WithLabelValues(name).Observe(time.Since(startTime).Seconds())
+	}()
+
+	job, err := s.jobManager.CreateStreamingJob(ctx, catalogSyncJobType, map[string]interface{}{
+		"connector": name,
+	})
+	if err != nil {
+		catalogSyncRuns.WithLabelValues(name, "error").Inc()
+		return nil, fmt.Errorf("failed to create sync job: %w", err)
+	}
+
+	records, err := feed.connector.Fetch(ctx)
+	if err != nil {
+		catalogSyncRuns.WithLabelValues(name, "fetch_failed").Inc()
+		s.jobManager.FailJob(ctx, job.JobID, err.Error())
+		return job, err
+	}
+
+	previous, err := s.loadState(ctx, name)
+	if err != nil {
+		catalogSyncRuns.WithLabelValues(name, "error").Inc()
+		s.jobManager.FailJob(ctx, job.JobID, err.Error())
+		return job, err
+	}
+
+	current, positions, invalid, rejected := s.mapRecords(feed.mapping, records)
+	changes := DiffCatalog(current, invalid, previous)
+	indexCatalogChanges(changes, positions, len(records))
+
+	stats := map[string]interface{}{
+		"fetched":  len(records),
+		"rejected": len(rejected),
+		"invalid":  len(invalid),
+	}
+
+	// A feed that suddenly lost most of its items is more likely broken than emptied
+	changes, skipped := s.limitDeactivations(changes, len(previous))
+	if skipped > 0 {
+		stats["deactivations_skipped"] = skipped
+		s.logger.WithFields(logrus.Fields{
+			"connector": name,
+			"skipped":   skipped,
+			"known":     len(previous),
+		}).Warn("Skipping catalog deactivations above max_deactivation_ratio")
+	}
+
+	if err := s.jobManager.ExpandJob(ctx, job.JobID, len(changes)+len(rejected)); err != nil {
+		s.logger.WithError(err).WithField("job_id", job.JobID).Warn("Failed to size sync job")
+	}
+	if err := s.jobManager.FailItems(ctx, job.JobID, rejected); err != nil {
+		s.logger.WithError(err).WithField("job_id", job.JobID).Warn("Failed to record rejected feed items")
+	}
+
+	counts := map[string]int{}
+	var publishFailures []ItemError
+	for _, change := range changes {
+		if err := s.publish(job.JobID, name, change); err != nil {
+			publishFailures = append(publishFailures, ItemError{
+				Row:        change.Index + 1,
+				ExternalID: change.ExternalID,
+				Stage:      "publish",
+				Message:    err.Error(),
+			})
+			continue
+		}
+
+		// Only remember what was actually emitted so failures are retried next run
+		if err := s.saveState(ctx, name, change); err != nil {
+			s.logger.WithError(err).WithField("external_id", change.ExternalID).Warn("Failed to save catalog sync state")
+		}
+		counts[change.Kind]++
+		catalogSyncChanges.WithLabelValues(name, change.Kind).Inc()
+	}
+
+	if err := s.jobManager.FailItems(ctx, job.JobID, publishFailures); err != nil {
+		s.logger.WithError(err).WithField("job_id", job.JobID).Warn("Failed to record publish failures")
+	}
+
+	stats["created"] = counts[CatalogChangeCreate]
+	stats["updated"] = counts[CatalogChangeUpdate]
+	stats["deactivated"] = counts[CatalogChangeDeactivate]
+	stats["unchanged"] = len(current) - counts[CatalogChangeCreate] - counts[CatalogChangeUpdate]
+	stats["publish_failed"] = len(publishFailures)
+	stats["duration_ms"] = time.Since(startTime).Milliseconds()
+
+	if err := s.jobManager.SealJob(ctx, job.JobID, map[string]interface{}{"sync": stats}); err != nil {
+		s.logger.WithError(err).WithField("job_id", job.JobID).Warn("Failed to seal sync job")
+	}
+
+	status := "success"
+	if len(publishFailures) > 0 {
+		status = "partial"
+	}
+	catalogSyncRuns.WithLabelValues(name, status).Inc()
+
+	s.logger.WithFields(logrus.Fields{
+		"connector": name,
+		"job_id":    job.JobID,
+		"stats":     stats,
+	}).Info("Catalog sync completed")
+
+	return job, nil
+}
+
+// mapRecords converts feed records into validated items keyed by external ID,
+// along with each item's 0-based position in the feed. The external IDs of
+// records rejected after their ID was mapped are returned as invalid: they are
+// still in the feed and must not be deactivated.
+func (s *CatalogSyncService) mapRecords(mapping *ImportMapping, records []connectors.Record) (
+	map[string]models.ContentIngestionRequest, map[string]int, map[string]bool, []ItemError,
+) {
+	current := make(map[string]models.ContentIngestionRequest, len(records))
+	positions := make(map[string]int, len(records))
+	invalid := make(map[string]bool)
+	var rejected []ItemError
+
+	reject := func(item models.ContentIngestionRequest, itemErr ItemError) {
+		if item.ExternalID != nil && *item.ExternalID != "" {
+			invalid[*item.ExternalID] = true
+		}
+		rejected = append(rejected, itemErr)
+	}
+
+	for i, record := range records {
+		row := i + 1
+
+		item, err := mapping.Apply(record)
+		if err != nil {
+			reject(item, *rowItemError(row, item, "mapping", err))
+			continue
+		}
+		if item.ExternalID == nil {
+			rejected = append(rejected, ItemError{Row: row, Stage: "mapping", Message: "external_id is required for catalog sync"})
+			continue
+		}
+		if err := s.validator.Struct(&item); err != nil {
+			reject(item, *rowItemError(row, item, "validation", err))
+			continue
+		}
+		if itemErr := schemaItemError(s.preprocessor, row, item); itemErr != nil {
+			reject(item, *itemErr)
+			continue
+		}
+		if _, duplicate := current[*item.ExternalID]; duplicate {
+			rejected = append(rejected, ItemError{Row: row, ExternalID: *item.ExternalID, Stage: "diff", Message: "duplicate external_id in feed"})
+			continue
+		}
+
+		current[*item.ExternalID] = item
+		positions[*item.ExternalID] = i
+	}
+
+	// A valid record wins over an invalid duplicate of the same ID
+	for externalID := range current {
+		delete(invalid, externalID)
+	}
+
+	return current, positions, invalid, rejected
+}
+
+// indexCatalogChanges numbers changes by feed position so item outcomes line up
+// with the feed. Deactivated items are no longer in the feed and follow its end.
+func indexCatalogChanges(changes []CatalogChange, positions map[string]int, feedSize int) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ExternalID < changes[j].ExternalID
+	})
+
+	next := feedSize
+	for i := range changes {
+		if position, ok := positions[changes[i].ExternalID]; ok && changes[i].Kind != CatalogChangeDeactivate {
+			changes[i].Index = position
+			continue
+		}
+		changes[i].Index = next
+		next++
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Index < changes[j].Index
+	})
+}
+
+// limitDeactivations drops all deactivations when they exceed the configured share
+// of previously known items. It returns the remaining changes and the number dropped.
+func (s *CatalogSyncService) limitDeactivations(changes []CatalogChange, known int) ([]CatalogChange, int) {
+	ratio := s.config.MaxDeactivationRatio
+	if ratio <= 0 || known == 0 {
+		return changes, 0
+	}
+
+	deactivations := 0
+	for _, change := range changes {
+		if change.Kind == CatalogChangeDeactivate {
+			deactivations++
+		}
+	}
+	if float64(deactivations)/float64(known) <= ratio {
+		return changes, 0
+	}
+
+	kept := changes[:0]
+	for _, change := range changes {
+		if change.Kind != CatalogChangeDeactivate {
+			kept = append(kept, change)
+		}
+	}
+	return kept, deactivations
+}
+
+func (s *CatalogSyncService) publish(jobID uuid.UUID, connectorName string, change CatalogChange) error {
+	hints := map[string]interface{}{
+		"source":      "catalog_sync",
+		"connector":   connectorName,
+		"change":      change.Kind,
+		"timestamp":   time.Now(),
+		HintItemIndex: change.Index,
+	}
+	if change.Kind == CatalogChangeDeactivate {
+		hints[HintOperation] = OperationDeactivate
+	}
+
+	return s.messageBus.PublishContentIngestion(jobID, change.Item, hints)
+}
+
+// loadState returns what previous runs emitted for the connector. It lives in
+// Postgres: losing it would re-emit the whole feed as creates.
+func (s *CatalogSyncService) loadState(ctx context.Context, connectorName string) (map[string]CatalogItemState, error) {
+	rows, err := s.db.PG.Query(ctx, `
+		SELECT external_id, hash, type, title
+		FROM catalog_sync_state
+		WHERE connector = $1`, connectorName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load catalog sync state: %w", err)
+	}
+	defer rows.Close()
+
+	state := make(map[string]CatalogItemState)
+	for rows.Next() {
+		var externalID string
+		var itemState CatalogItemState
+		if err := rows.Scan(&externalID, &itemState.Hash, &itemState.Type, &itemState.Title); err != nil {
+			return nil, fmt.Errorf("failed to scan catalog sync state: %w", err)
+		}
+		state[externalID] = itemState
+	}
+
+	return state, rows.Err()
+}
+
+func (s *CatalogSyncService) saveState(ctx context.Context, connectorName string, change CatalogChange) error {
+	if change.Kind == CatalogChangeDeactivate {
+		_, err := s.db.PG.Exec(ctx, `
+			DELETE FROM catalog_sync_state
+			WHERE connector = $1 AND external_id = $2`, connectorName, change.ExternalID)
+		return err
+	}
+
+	_, err := s.db.PG.Exec(ctx, `
+		INSERT INTO catalog_sync_state (connector, external_id, hash, type, title, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (connector, external_id) DO UPDATE
+		SET hash = EXCLUDED.hash, type = EXCLUDED.type, title = EXCLUDED.title, updated_at = NOW()`,
+		connectorName, change.ExternalID, change.Hash, change.Item.Type, change.Item.Title)
+	return err
+}
+
+// DiffCatalog compares the current feed snapshot with the state of the previous run.
+// Items whose hash is unchanged are skipped; items missing from the snapshot are
+// deactivated using the type and title remembered from the previous run. Items
+// in the feed that failed validation (invalid) are left as they are.
+func DiffCatalog(
+	current map[string]models.ContentIngestionRequest,
+	invalid map[string]bool,
+	previous map[string]CatalogItemState,
+) []CatalogChange {
+	var changes []CatalogChange
+
+	for externalID, item := range current {
+		hash := CatalogItemHash(item)
+
+		prior, seen := previous[externalID]
+		switch {
+		case !seen:
+			changes = append(changes, CatalogChange{Kind: CatalogChangeCreate, ExternalID: externalID, Hash: hash, Item: item})
+		case prior.Hash != hash:
+			changes = append(changes, CatalogChange{Kind: CatalogChangeUpdate, ExternalID: externalID, Hash: hash, Item: item})
+		}
+	}
+
+	for externalID, prior := range previous {
+		if _, stillPresent := current[externalID]; stillPresent || invalid[externalID] {
+			continue
+		}
+
+		id := externalID
+		changes = append(changes, CatalogChange{
+			Kind:       CatalogChangeDeactivate,
+			ExternalID: externalID,
+			Item: models.ContentIngestionRequest{
+				ExternalID: &id,
+				Type:       prior.Type,
+				Title:      prior.Title,
+			},
+		})
+	}
+
+	return changes
+}
+
+// CatalogItemHash fingerprints an item's content. encoding/json sorts map keys,
+// so equal items always hash the same.
+func CatalogItemHash(item models.ContentIngestionRequest) string {
+	data, _ := json.Marshal(item)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
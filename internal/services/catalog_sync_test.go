@@ -0,0 +1,113 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/connectors"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func catalogItem(externalID, title string, metadata map[string]interface{}) models.ContentIngestionRequest {
+	return models.ContentIngestionRequest{
+		ExternalID: &externalID,
+		Type:       "product",
+		Title:      title,
+		Metadata:   metadata,
+	}
+}
+
+func TestDiffCatalog(t *testing.T) {
+	unchanged := catalogItem("A", "Shoe", map[string]interface{}{"price": 10.0, "brand": "Acme"})
+	updated := catalogItem("B", "Hat", map[string]interface{}{"price": 12.0})
+	created := catalogItem("C", "Scarf", nil)
+
+	previous := map[string]CatalogItemState{
+		"A": {Hash: CatalogItemHash(catalogItem("A", "Shoe", map[string]interface{}{"brand": "Acme", "price": 10.0}))},
+		"B": {Hash: CatalogItemHash(catalogItem("B", "Hat", map[string]interface{}{"price": 9.0}))},
+		"D": {Hash: "stale", Type: "product", Title: "Gloves"},
+	}
+
+	changes := DiffCatalog(map[string]models.ContentIngestionRequest{
+		"A": unchanged,
+		"B": updated,
+		"C": created,
+	}, nil, previous)
+
+	byID := make(map[string]CatalogChange)
+	for _, change := range changes {
+		byID[change.ExternalID] = change
+	}
+
+	require.Len(t, changes, 3, "unchanged items are not emitted")
+	assert.Equal(t, CatalogChangeUpdate, byID["B"].Kind)
+	assert.Equal(t, CatalogChangeCreate, byID["C"].Kind)
+	assert.Equal(t, CatalogItemHash(created), byID["C"].Hash)
+
+	deactivated := byID["D"]
+	assert.Equal(t, CatalogChangeDeactivate, deactivated.Kind)
+	require.NotNil(t, deactivated.Item.ExternalID)
+	assert.Equal(t, "D", *deactivated.Item.ExternalID)
+	assert.Equal(t, "Gloves", deactivated.Item.Title)
+}
+
+func TestDiffCatalog_InvalidItemsStayActive(t *testing.T) {
+	previous := map[string]CatalogItemState{
+		"A": {Hash: "known", Type: "product", Title: "Shoe"},
+		"B": {Hash: "known", Type: "product", Title: "Hat"},
+	}
+
+	// A is still in the feed but failed validation; B left the feed
+	changes := DiffCatalog(nil, map[string]bool{"A": true}, previous)
+
+	require.Len(t, changes, 1)
+	assert.Equal(t, "B", changes[0].ExternalID)
+	assert.Equal(t, CatalogChangeDeactivate, changes[0].Kind)
+}
+
+func TestCatalogSync_MapRecordsKeepsInvalidIDs(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	sync := &CatalogSyncService{validator: validator.New(), preprocessor: NewDataPreprocessor(logger)}
+
+	mapping, err := NewImportMapping(nil, "product", "", false)
+	require.NoError(t, err)
+
+	current, positions, invalid, rejected := sync.mapRecords(mapping, []connectors.Record{
+		{"external_id": "A", "title": "Shoe"},
+		{"external_id": "B", "title": ""},
+		{"title": "No ID"},
+		{"external_id": "A", "title": "Shoe again"},
+	})
+
+	assert.Contains(t, current, "A")
+	assert.Equal(t, 0, positions["A"])
+	// The record without a title is invalid but still in the feed; the
+	// duplicate of a valid item is not
+	assert.Equal(t, map[string]bool{"B": true}, invalid)
+	assert.Len(t, rejected, 3)
+}
+
+func TestCatalogSync_LimitDeactivations(t *testing.T) {
+	sync := &CatalogSyncService{config: &config.CatalogSyncConfig{MaxDeactivationRatio: 0.5}}
+
+	changes := []CatalogChange{
+		{Kind: CatalogChangeCreate, ExternalID: "A"},
+		{Kind: CatalogChangeDeactivate, ExternalID: "B"},
+		{Kind: CatalogChangeDeactivate, ExternalID: "C"},
+	}
+
+	kept, skipped := sync.limitDeactivations(append([]CatalogChange(nil), changes...), 10)
+	assert.Len(t, kept, 3)
+	assert.Equal(t, 0, skipped)
+
+	kept, skipped = sync.limitDeactivations(append([]CatalogChange(nil), changes...), 3)
+	require.Len(t, kept, 1)
+	assert.Equal(t, "A", kept[0].ExternalID)
+	assert.Equal(t, 2, skipped)
+}
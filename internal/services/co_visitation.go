@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+const (
+	coVisitMatrix        = "covisit"
+	boughtTogetherMatrix = "bought"
+
+	// coVisitationTrimFactor keeps this many times max_neighbors per item so
+	// rising pairs are not trimmed on arrival
+	coVisitationTrimFactor = 2
+	// coVisitationSeedDecay is the weight kept per step back in the user's
+	// recent items when they seed candidates outside a session
+	coVisitationSeedDecay = 0.7
+)
+
+var coVisitationPairs = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "co_visitation_pair_updates_total",
+	Help: "Item pair counts updated from interactions, by matrix",
+}, []string{"matrix"})
+
+// CoNeighbor is an item that co-occurs with another, with its pair count
+type CoNeighbor struct {
+	ItemID uuid.UUID `json:"item_id"`
+	Count  float64   `json:"count"`
+}
+
+// CoVisitationIndex counts item pairs from sessions as interactions arrive.
+// Items interacted with within the window of each other in a session pair up
+// in the co-visitation matrix, weighted by interaction type; conversions also
+// pair up in the bought-together matrix. Each item keeps its top neighbours
+// as a sorted set in the long-term Redis tier; the per-session state needed to
+// pair items lives in the hot tier and expires with the window.
+type CoVisitationIndex struct {
+	sessions *redis.Client
+	matrices *redis.Client
+	db       *pgxpool.Pool
+	config   *config.CoVisitationConfig
+	logger   *logrus.Logger
+
+	conversions map[string]bool
+}
+
+func NewCoVisitationIndex(sessions, matrices *redis.Client, db *pgxpool.Pool, cfg *config.CoVisitationConfig, logger *logrus.Logger) *CoVisitationIndex {
+	conversions := make(map[string]bool)
+	if cfg != nil {
+		for _, interactionType := range cfg.ConversionTypes {
+			conversions[interactionType] = true
+		}
+	}
+	return &CoVisitationIndex{
+		sessions:    sessions,
+		matrices:    matrices,
+		db:          db,
+		config:      cfg,
+		logger:      logger,
+		conversions: conversions,
+	}
+}
+
+// Enabled reports whether pairs are counted. It is safe to call on a nil index.
+func (c *CoVisitationIndex) Enabled() bool {
+	return c != nil && c.sessions != nil && c.matrices != nil
+}
+
+// Record counts the pairs an item interaction forms with the session's other
+// items. Negative interactions such as dislikes form no pairs.
+func (c *CoVisitationIndex) Record(ctx context.Context, interaction *models.UserInteraction, weight float64) error {
+	if !c.Enabled() || interaction.ItemID == nil || weight <= 0 {
+		return nil
+	}
+
+	if err := c.record(ctx, coVisitMatrix, c.window(), interaction, weight); err != nil {
+		return err
+	}
+	if c.conversions[interaction.InteractionType] {
+		// Every conversion counts the same; a pair counts once per session
+		if err := c.record(ctx, boughtTogetherMatrix, c.conversionWindow(), interaction, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record adds an interaction to one matrix. Within a session a pair counts the
+// smaller of its two items' highest weights, so repeated views do not inflate
+// it; when an item's weight rises, its pairs grow by the difference.
+func (c *CoVisitationIndex) record(ctx context.Context, matrix string, window time.Duration, interaction *models.UserInteraction, weight float64) error {
+	itemID := interaction.ItemID.String()
+	at := interaction.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	seenKey := coSessionKey(matrix, interaction.SessionID, "seen")
+	weightsKey := coSessionKey(matrix, interaction.SessionID, "weights")
+	windowStart := strconv.FormatInt(at.Add(-window).Unix(), 10)
+
+	pipe := c.sessions.Pipeline()
+	recent := pipe.ZRangeByScore(ctx, seenKey, &redis.ZRangeBy{Min: windowStart, Max: "+inf"})
+	weights := pipe.HGetAll(ctx, weightsKey)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to read %s session state: %w", matrix, err)
+	}
+
+	// An item seen again after the window pairs afresh with the items around it
+	sessionWeights := weights.Val()
+	var previous float64
+	others := make(map[string]float64)
+	for _, other := range recent.Val() {
+		if other == itemID {
+			previous, _ = strconv.ParseFloat(sessionWeights[itemID], 64)
+			continue
+		}
+		if otherWeight, err := strconv.ParseFloat(sessionWeights[other], 64); err == nil {
+			others[other] = otherWeight
+		}
+	}
+
+	pipe = c.sessions.TxPipeline()
+	pipe.ZAdd(ctx, seenKey, redis.Z{Score: float64(at.Unix()), Member: itemID})
+	pipe.ZRemRangeByScore(ctx, seenKey, "-inf", "("+windowStart)
+	pipe.HSet(ctx, weightsKey, itemID, strconv.FormatFloat(math.Max(weight, previous), 'f', -1, 64))
+	pipe.Expire(ctx, seenKey, window)
+	pipe.Expire(ctx, weightsKey, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update %s session state: %w", matrix, err)
+	}
+
+	increments := coOccurrenceIncrements(previous, weight, others)
+	if len(increments) == 0 {
+		return nil
+	}
+
+	keep := int64(c.maxNeighbors() * coVisitationTrimFactor)
+	pipe = c.matrices.Pipeline()
+	for other, increment := range increments {
+		for _, pair := range [][2]string{{itemID, other}, {other, itemID}} {
+			key := coNeighborsKey(matrix, pair[0])
+			pipe.ZIncrBy(ctx, key, increment, pair[1])
+			pipe.ZRemRangeByRank(ctx, key, 0, -keep-1)
+			pipe.Expire(ctx, key, c.retention())
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update %s matrix: %w", matrix, err)
+	}
+
+	coVisitationPairs.WithLabelValues(matrix).Add(float64(len(increments)))
+	return nil
+}
+
+// Neighbors returns the items most often interacted with together with an item
+func (c *CoVisitationIndex) Neighbors(ctx context.Context, itemID uuid.UUID, limit int) ([]CoNeighbor, error) {
+	return c.neighbors(ctx, coVisitMatrix, itemID, limit)
+}
+
+// BoughtTogether returns the items most often converted on together with an item
+func (c *CoVisitationIndex) BoughtTogether(ctx context.Context, itemID uuid.UUID, limit int) ([]CoNeighbor, error) {
+	return c.neighbors(ctx, boughtTogetherMatrix, itemID, limit)
+}
+
+func (c *CoVisitationIndex) neighbors(ctx context.Context, matrix string, itemID uuid.UUID, limit int) ([]CoNeighbor, error) {
+	if !c.Enabled() {
+		return nil, nil
+	}
+	if limit <= 0 || limit > c.maxNeighbors() {
+		limit = c.maxNeighbors()
+	}
+
+	members, err := c.matrices.ZRevRangeWithScores(ctx, coNeighborsKey(matrix, itemID.String()), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s neighbours: %w", matrix, err)
+	}
+
+	return coNeighbors(members), nil
+}
+
+// FrequentlyBoughtTogether returns the bought-together neighbours of an item
+// scored by their share of the strongest pair's count
+func (c *CoVisitationIndex) FrequentlyBoughtTogether(ctx context.Context, itemID uuid.UUID, limit int) ([]models.ScoredItem, error) {
+	neighbors, err := c.BoughtTogether(ctx, itemID, limit)
+	if err != nil || len(neighbors) == 0 {
+		return nil, err
+	}
+
+	items := make([]models.ScoredItem, len(neighbors))
+	for i, neighbor := range neighbors {
+		items[i] = models.ScoredItem{
+			ItemID:     neighbor.ItemID,
+			Score:      neighbor.Count / neighbors[0].Count,
+			Algorithm:  "bought_together",
+			Confidence: math.Min(0.9, 0.3+0.1*math.Log2(1+neighbor.Count)),
+		}
+	}
+	return items, nil
+}
+
+// coNeighbors converts sorted set members, strongest first, to neighbours
+func coNeighbors(members []redis.Z) []CoNeighbor {
+	neighbors := make([]CoNeighbor, 0, len(members))
+	for _, member := range members {
+		name, _ := member.Member.(string)
+		neighborID, err := uuid.Parse(name)
+		if err != nil {
+			continue
+		}
+		neighbors = append(neighbors, CoNeighbor{ItemID: neighborID, Count: member.Score})
+	}
+	return neighbors
+}
+
+// Recommendations scores the co-visitation and bought-together neighbours of
+// the seeds. Without seeds the user's most recent items are used.
+func (c *CoVisitationIndex) Recommendations(
+	ctx context.Context,
+	userID uuid.UUID,
+	seeds []SessionSeed,
+	exclude []uuid.UUID,
+	limit int,
+) ([]models.ScoredItem, error) {
+	if len(seeds) == 0 {
+		recent, err := c.recentItems(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		seeds = recent
+	}
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("no items to find co-visited items for")
+	}
+
+	pipe := c.matrices.Pipeline()
+	visitLists := make([]*redis.ZSliceCmd, len(seeds))
+	boughtLists := make([]*redis.ZSliceCmd, len(seeds))
+	for i, seed := range seeds {
+		end := int64(c.maxNeighbors() - 1)
+		visitLists[i] = pipe.ZRevRangeWithScores(ctx, coNeighborsKey(coVisitMatrix, seed.ItemID.String()), 0, end)
+		boughtLists[i] = pipe.ZRevRangeWithScores(ctx, coNeighborsKey(boughtTogetherMatrix, seed.ItemID.String()), 0, end)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to read co-visitation neighbours: %w", err)
+	}
+
+	visits := make(map[uuid.UUID][]CoNeighbor, len(seeds))
+	bought := make(map[uuid.UUID][]CoNeighbor, len(seeds))
+	for i, seed := range seeds {
+		visits[seed.ItemID] = coNeighbors(visitLists[i].Val())
+		bought[seed.ItemID] = coNeighbors(boughtLists[i].Val())
+	}
+
+	excluded := make(map[uuid.UUID]bool, len(seeds)+len(exclude))
+	for _, seed := range seeds {
+		excluded[seed.ItemID] = true
+	}
+	for _, itemID := range exclude {
+		excluded[itemID] = true
+	}
+
+	return scoreCoOccurrences(seeds, visits, bought, c.boughtTogetherWeight(), excluded, limit), nil
+}
+
+// recentItems returns the user's latest positively engaged items as seeds,
+// weighted by recency
+func (c *CoVisitationIndex) recentItems(ctx context.Context, userID uuid.UUID) ([]SessionSeed, error) {
+	rows, err := c.db.Query(ctx, `
+		SELECT item_id
+		FROM user_interactions
+		WHERE user_id = $1 AND item_id IS NOT NULL AND interaction_type NOT IN ('dislike', 'not_interested')
+		GROUP BY item_id
+		ORDER BY MAX(timestamp) DESC
+		LIMIT $2`, userID, c.seedItems())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recent items: %w", err)
+	}
+	defer rows.Close()
+
+	var seeds []SessionSeed
+	for rows.Next() {
+		var itemID uuid.UUID
+		if err := rows.Scan(&itemID); err != nil {
+			return nil, fmt.Errorf("failed to scan recent item: %w", err)
+		}
+		seeds = append(seeds, SessionSeed{
+			ItemID: itemID,
+			Weight: math.Pow(coVisitationSeedDecay, float64(len(seeds))),
+		})
+	}
+	return seeds, rows.Err()
+}
+
+// coOccurrenceIncrements returns how much each pair grows when an item's
+// weight in the session rises from previous to weight. A pair is worth the
+// smaller of its two item weights, counted once per session.
+func coOccurrenceIncrements(previous, weight float64, others map[string]float64) map[string]float64 {
+	if weight <= previous {
+		return nil
+	}
+
+	increments := make(map[string]float64)
+	for other, otherWeight := range others {
+		if increment := math.Min(weight, otherWeight) - math.Min(previous, otherWeight); increment > 0 {
+			increments[other] = increment
+		}
+	}
+	return increments
+}
+
+// scoreCoOccurrences sums each candidate's pair counts with the seeds, each
+// neighbour list scaled by its strongest count and weighted by seed weight.
+// boughtWeight is the share of the bought-together matrix.
+func scoreCoOccurrences(
+	seeds []SessionSeed,
+	visits, bought map[uuid.UUID][]CoNeighbor,
+	boughtWeight float64,
+	excluded map[uuid.UUID]bool,
+	limit int,
+) []models.ScoredItem {
+	var totalWeight float64
+	for _, seed := range seeds {
+		totalWeight += seed.Weight
+	}
+	if totalWeight <= 0 {
+		return nil
+	}
+
+	scores := make(map[uuid.UUID]float64)
+	support := make(map[uuid.UUID]int)
+	add := func(seed SessionSeed, neighbors []CoNeighbor, share float64) {
+		if len(neighbors) == 0 || share <= 0 || neighbors[0].Count <= 0 {
+			return
+		}
+		top := neighbors[0].Count
+		for _, neighbor := range neighbors {
+			if excluded[neighbor.ItemID] || neighbor.Count <= 0 {
+				continue
+			}
+			scores[neighbor.ItemID] += share * seed.Weight * neighbor.Count / top / totalWeight
+			support[neighbor.ItemID]++
+		}
+	}
+
+	for _, seed := range seeds {
+		// Without bought-together data the visits carry the full score
+		visitShare := 1.0
+		if len(bought[seed.ItemID]) > 0 {
+			visitShare = 1 - boughtWeight
+			add(seed, bought[seed.ItemID], boughtWeight)
+		}
+		add(seed, visits[seed.ItemID], visitShare)
+	}
+
+	items := make([]models.ScoredItem, 0, len(scores))
+	for itemID, score := range scores {
+		items = append(items, models.ScoredItem{
+			ItemID:     itemID,
+			Score:      score,
+			Algorithm:  "co_visitation",
+			Confidence: math.Min(0.9, 0.3+0.5*score+0.05*float64(support[itemID]-1)),
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		return items[i].ItemID.String() < items[j].ItemID.String()
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+func coSessionKey(matrix string, sessionID uuid.UUID, part string) string {
+	return fmt.Sprintf("%s:session:%s:%s", matrix, sessionID, part)
+}
+
+func coNeighborsKey(matrix string, itemID string) string {
+	return fmt.Sprintf("%s:item:%s", matrix, itemID)
+}
+
+func (c *CoVisitationIndex) window() time.Duration {
+	if c.config != nil && c.config.Window > 0 {
+		return c.config.Window
+	}
+	return time.Hour
+}
+
+func (c *CoVisitationIndex) conversionWindow() time.Duration {
+	if c.config != nil && c.config.ConversionWindow > 0 {
+		return c.config.ConversionWindow
+	}
+	return 24 * time.Hour
+}
+
+func (c *CoVisitationIndex) maxNeighbors() int {
+	if c.config != nil && c.config.MaxNeighbors > 0 {
+		return c.config.MaxNeighbors
+	}
+	return 50
+}
+
+func (c *CoVisitationIndex) retention() time.Duration {
+	if c.config != nil && c.config.Retention > 0 {
+		return c.config.Retention
+	}
+	return 30 * 24 * time.Hour
+}
+
+func (c *CoVisitationIndex) boughtTogetherWeight() float64 {
+	if c.config != nil && c.config.BoughtTogetherWeight >= 0 && c.config.BoughtTogetherWeight <= 1 {
+		return c.config.BoughtTogetherWeight
+	}
+	return 0.5
+}
+
+func (c *CoVisitationIndex) seedItems() int {
+	if c.config != nil && c.config.SeedItems > 0 {
+		return c.config.SeedItems
+	}
+	return 5
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func TestCoOccurrenceIncrements(t *testing.T) {
+	others := map[string]float64{"tent": 0.4, "stove": 1.0}
+
+	// A new item pairs with each item in the window at the smaller weight
+	increments := coOccurrenceIncrements(0, 0.6, others)
+	assert.InDelta(t, 0.4, increments["tent"], 1e-9)
+	assert.InDelta(t, 0.6, increments["stove"], 1e-9)
+
+	// A stronger interaction with the same item only adds the difference
+	increments = coOccurrenceIncrements(0.6, 1.0, others)
+	assert.NotContains(t, increments, "tent", "the tent pair is capped by the tent's weight")
+	assert.InDelta(t, 0.4, increments["stove"], 1e-9)
+
+	assert.Empty(t, coOccurrenceIncrements(1.0, 0.6, others), "repeat views add nothing")
+	assert.Empty(t, coOccurrenceIncrements(0, 1.0, nil))
+}
+
+func TestScoreCoOccurrences(t *testing.T) {
+	boots, tent := uuid.New(), uuid.New()
+	socks, laces, stove, seen := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	seeds := []SessionSeed{{ItemID: boots, Weight: 1.0}, {ItemID: tent, Weight: 1.0}}
+	visits := map[uuid.UUID][]CoNeighbor{
+		boots: {{ItemID: socks, Count: 10}, {ItemID: laces, Count: 5}, {ItemID: seen, Count: 4}},
+		tent:  {{ItemID: stove, Count: 8}, {ItemID: socks, Count: 2}},
+	}
+	bought := map[uuid.UUID][]CoNeighbor{
+		boots: {{ItemID: laces, Count: 3}},
+	}
+	excluded := map[uuid.UUID]bool{boots: true, tent: true, seen: true}
+
+	items := scoreCoOccurrences(seeds, visits, bought, 0.5, excluded, 10)
+	require.Len(t, items, 3)
+
+	scores := make(map[uuid.UUID]float64)
+	for _, item := range items {
+		scores[item.ItemID] = item.Score
+		assert.Equal(t, "co_visitation", item.Algorithm)
+		assert.LessOrEqual(t, item.Confidence, 0.9)
+	}
+	assert.NotContains(t, scores, seen, "excluded items are dropped")
+
+	// Each list is scaled by its strongest count and averaged over the seeds
+	assert.InDelta(t, (0.5*1.0+1.0*0.25)/2, scores[socks], 1e-9)
+	assert.InDelta(t, (0.5*0.5+0.5*1.0)/2, scores[laces], 1e-9, "bought together shares the score")
+	assert.InDelta(t, 1.0/2, scores[stove], 1e-9, "without bought-together data visits carry the score")
+	assert.Equal(t, stove, items[0].ItemID)
+
+	assert.Len(t, scoreCoOccurrences(seeds, visits, bought, 0.5, excluded, 1), 1)
+	assert.Nil(t, scoreCoOccurrences(nil, visits, bought, 0.5, excluded, 10))
+}
+
+func TestCoVisitationSeeds(t *testing.T) {
+	itemID := uuid.New()
+	sessionSeeds := []SessionSeed{{ItemID: uuid.New(), Weight: 1}}
+
+	seeds := coVisitationSeeds(&RecommendationContext{SeedItemID: &itemID}, sessionSeeds)
+	require.Len(t, seeds, 1)
+	assert.Equal(t, itemID, seeds[0].ItemID, "the seed item wins over the session")
+
+	assert.Equal(t, sessionSeeds, coVisitationSeeds(&RecommendationContext{}, sessionSeeds))
+}
+
+func TestCoVisitationIndex_Disabled(t *testing.T) {
+	var index *CoVisitationIndex
+	assert.False(t, index.Enabled())
+
+	itemID := uuid.New()
+	err := index.Record(context.Background(), &models.UserInteraction{ItemID: &itemID, InteractionType: "purchase"}, 1)
+	assert.NoError(t, err)
+
+	neighbors, err := index.Neighbors(context.Background(), itemID, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, neighbors)
+}
+
+func TestRecommendationOrchestrator_CacheKeySeedItem(t *testing.T) {
+	orchestrator := &RecommendationOrchestrator{}
+	first, second := uuid.New(), uuid.New()
+	userID := uuid.New()
+
+	plain := orchestrator.buildCacheKey(&RecommendationContext{UserID: userID, Context: "similar", Count: 10})
+	seeded := orchestrator.buildCacheKey(&RecommendationContext{UserID: userID, Context: "similar", Count: 10, SeedItemID: &first})
+	other := orchestrator.buildCacheKey(&RecommendationContext{UserID: userID, Context: "similar", Count: 10, SeedItemID: &second})
+
+	assert.NotEqual(t, plain, seeded)
+	assert.NotEqual(t, seeded, other, "similar items of different items are cached apart")
+}
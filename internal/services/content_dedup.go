@@ -0,0 +1,427 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/database"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+const (
+	DedupPolicyFlag  = "flag"
+	DedupPolicyMerge = "merge"
+
+	// Why an item was reported as a duplicate
+	DuplicateReasonText   = "text"
+	DuplicateReasonVisual = "visual"
+
+	// simHashShingleSize is the number of consecutive tokens hashed together
+	simHashShingleSize = 3
+
+	// hashBands is the number of 16-bit bands the SimHash and pHash are split
+	// into for lookups. Hashes within hashBands-1 bits share at least one band.
+	hashBands = 4
+
+	// fingerprintBandScanLimit bounds the rows sharing a fingerprint band that are
+	// read per lookup; unrelated fingerprints share a 16-bit band only by chance
+	fingerprintBandScanLimit = 1000
+)
+
+var simHashTokenRegex = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// ContentDeduplicator resolves client-supplied external IDs and detects
+// near-duplicate content using SimHash fingerprints plus embedding distance
+type ContentDeduplicator struct {
+	db     *database.Database
+	config *config.DedupConfig
+	logger *logrus.Logger
+}
+
+// DuplicateMatch describes an existing content item that a new item duplicates
+type DuplicateMatch struct {
+	ContentID           uuid.UUID `json:"content_id"`
+	DuplicateOf         uuid.UUID `json:"duplicate_of"`
+	HammingDistance     int       `json:"hamming_distance"`
+	EmbeddingSimilarity float64   `json:"embedding_similarity"`
+	Action              string    `json:"action"` // flagged, merged
+	ExternalID          *string   `json:"external_id,omitempty"`
+	Reason              string    `json:"reason"`                   // text or visual
+	ImageURL            string    `json:"image_url,omitempty"`      // Matching image of a visual duplicate
+	ImageDistance       int       `json:"image_distance,omitempty"` // Perceptual hash distance in bits
+}
+
+func NewContentDeduplicator(db *database.Database, cfg *config.DedupConfig, logger *logrus.Logger) *ContentDeduplicator {
+	return &ContentDeduplicator{
+		db:     db,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// ResolveExternalID returns the content ID previously assigned to an external ID, or nil if unseen
+func (cd *ContentDeduplicator) ResolveExternalID(ctx context.Context, contentType, externalID string) (*uuid.UUID, error) {
+	query := `SELECT content_id FROM content_external_ids WHERE content_type = $1 AND external_id = $2`
+
+	var contentID uuid.UUID
+	err := cd.db.PG.QueryRow(ctx, query, contentType, externalID).Scan(&contentID)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve external ID: %w", err)
+	}
+
+	return &contentID, nil
+}
+
+// ClaimExternalID binds an external ID to a content ID inside the given transaction.
+// If the external ID is already mapped, the existing content ID is returned so the
+// caller can upsert onto it instead of creating a duplicate row.
+func (cd *ContentDeduplicator) ClaimExternalID(ctx context.Context, tx pgx.Tx, contentType, externalID string, contentID uuid.UUID) (uuid.UUID, error) {
+	query := `
+		INSERT INTO content_external_ids (content_type, external_id, content_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (content_type, external_id) DO UPDATE SET updated_at = NOW()
+		RETURNING content_id
+	`
+
+	var mappedID uuid.UUID
+	if err := tx.QueryRow(ctx, query, contentType, externalID, contentID).Scan(&mappedID); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to claim external ID: %w", err)
+	}
+
+	return mappedID, nil
+}
+
+// fingerprintCandidate is an item whose SimHash is near the new item's
+type fingerprintCandidate struct {
+	id       uuid.UUID
+	distance int
+}
+
+// FindNearDuplicate looks for an existing active item of the same type whose SimHash
+// is within the configured Hamming distance and whose embedding is close enough.
+// Candidates come from the fingerprint band index, which finds every match up to
+// 3 bits; the closest ones are then confirmed by embedding similarity.
+func (cd *ContentDeduplicator) FindNearDuplicate(ctx context.Context, content *models.ContentItem, fingerprint uint64) (*DuplicateMatch, error) {
+	if cd.config == nil || !cd.config.Enabled || len(content.Embedding) == 0 {
+		return nil, nil
+	}
+
+	limit := cd.config.CandidateLimit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	// The band expressions match the fingerprint band indexes
+	bands := hashBandValues(fingerprint)
+	query := `
+		SELECT id, fingerprint
+		FROM content_items
+		WHERE active = true
+			AND type = $1
+			AND id <> $2
+			AND ((fingerprint & 65535) = $3
+				OR ((fingerprint >> 16) & 65535) = $4
+				OR ((fingerprint >> 32) & 65535) = $5
+				OR ((fingerprint >> 48) & 65535) = $6)
+		LIMIT $7
+	`
+
+	rows, err := cd.db.PG.Query(ctx, query, content.Type, content.ID,
+		int64(bands[0]), int64(bands[1]), int64(bands[2]), int64(bands[3]), fingerprintBandScanLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duplicate candidates: %w", err)
+	}
+
+	var banded []fingerprintCandidate
+	for rows.Next() {
+		var candidateID uuid.UUID
+		var candidateFingerprint int64
+		if err := rows.Scan(&candidateID, &candidateFingerprint); err != nil {
+			cd.logger.WithError(err).Warn("Failed to scan duplicate candidate")
+			continue
+		}
+		banded = append(banded, fingerprintCandidate{
+			id:       candidateID,
+			distance: HammingDistance(fingerprint, uint64(candidateFingerprint)),
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	near := nearestFingerprints(banded, cd.config.MaxHammingDistance, limit)
+	if len(near) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, len(near))
+	for i, candidate := range near {
+		ids[i] = candidate.id
+	}
+	rows, err = cd.db.PG.Query(ctx, `
+		SELECT id, 1 - (embedding <=> $1) AS similarity
+		FROM content_items
+		WHERE id = ANY($2) AND embedding IS NOT NULL`, content.Embedding, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare duplicate candidate embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	similarities := make(map[uuid.UUID]float64, len(near))
+	for rows.Next() {
+		var candidateID uuid.UUID
+		var similarity float64
+		if err := rows.Scan(&candidateID, &similarity); err != nil {
+			cd.logger.WithError(err).Warn("Failed to scan duplicate candidate similarity")
+			continue
+		}
+		similarities[candidateID] = similarity
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return cd.bestTextDuplicate(content.ID, near, similarities), nil
+}
+
+// nearestFingerprints keeps the candidates within maxDistance bits, closest
+// first, up to limit
+func nearestFingerprints(candidates []fingerprintCandidate, maxDistance, limit int) []fingerprintCandidate {
+	var near []fingerprintCandidate
+	for _, candidate := range candidates {
+		if candidate.distance <= maxDistance {
+			near = append(near, candidate)
+		}
+	}
+	sort.SliceStable(near, func(i, j int) bool {
+		return near[i].distance < near[j].distance
+	})
+	if len(near) > limit {
+		near = near[:limit]
+	}
+	return near
+}
+
+// bestTextDuplicate returns the closest fingerprint match whose embedding is also
+// similar enough. Candidates are ordered by distance, closest first.
+func (cd *ContentDeduplicator) bestTextDuplicate(contentID uuid.UUID, near []fingerprintCandidate, similarities map[uuid.UUID]float64) *DuplicateMatch {
+	for _, candidate := range near {
+		similarity, ok := similarities[candidate.id]
+		if !ok || !cd.isDuplicate(candidate.distance, similarity) {
+			continue
+		}
+		return &DuplicateMatch{
+			ContentID:           contentID,
+			DuplicateOf:         candidate.id,
+			HammingDistance:     candidate.distance,
+			EmbeddingSimilarity: similarity,
+			Reason:              DuplicateReasonText,
+		}
+	}
+	return nil
+}
+
+// FindVisualDuplicates looks for other active items of the same type that show
+// the same picture as one of the item's images. Candidates come from the pHash
+// band index and are confirmed on both hashes. Visual duplicates are always
+// flagged, never merged, since different listings may share product photos.
+func (cd *ContentDeduplicator) FindVisualDuplicates(ctx context.Context, content *models.ContentItem) ([]*DuplicateMatch, error) {
+	if cd.config == nil || !cd.config.Enabled || !cd.config.ImageHashing || len(content.ImageHashes) == 0 {
+		return nil, nil
+	}
+
+	limit := cd.config.CandidateLimit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	query := `
+		SELECT h.content_id, h.image_url, h.phash, h.dhash
+		FROM content_image_hashes h
+		JOIN content_items c ON c.id = h.content_id
+		WHERE c.active = true
+			AND c.type = $1
+			AND h.content_id <> $2
+			AND (h.phash_band0 = $3 OR h.phash_band1 = $4 OR h.phash_band2 = $5 OR h.phash_band3 = $6)
+		LIMIT $7
+	`
+
+	matches := make(map[uuid.UUID]*DuplicateMatch)
+	for _, hash := range content.ImageHashes {
+		bands := hashBandValues(hash.PHash)
+		rows, err := cd.db.PG.Query(ctx, query, content.Type, content.ID, bands[0], bands[1], bands[2], bands[3], limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query visual duplicate candidates: %w", err)
+		}
+
+		for rows.Next() {
+			var candidateID uuid.UUID
+			var candidateURL string
+			var pHash, dHash int64
+			if err := rows.Scan(&candidateID, &candidateURL, &pHash, &dHash); err != nil {
+				cd.logger.WithError(err).Warn("Failed to scan visual duplicate candidate")
+				continue
+			}
+
+			distance, ok := visualDuplicateDistance(hash, models.ImageHash{PHash: uint64(pHash), DHash: uint64(dHash)}, cd.config.MaxImageDistance)
+			if !ok {
+				continue
+			}
+			if existing := matches[candidateID]; existing == nil || distance < existing.ImageDistance {
+				matches[candidateID] = &DuplicateMatch{
+					ContentID:     content.ID,
+					DuplicateOf:   candidateID,
+					Action:        "flagged",
+					Reason:        DuplicateReasonVisual,
+					ImageURL:      hash.ImageURL,
+					ImageDistance: distance,
+				}
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]*DuplicateMatch, 0, len(matches))
+	for _, match := range matches {
+		result = append(result, match)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ImageDistance != result[j].ImageDistance {
+			return result[i].ImageDistance < result[j].ImageDistance
+		}
+		return result[i].DuplicateOf.String() < result[j].DuplicateOf.String()
+	})
+
+	return result, nil
+}
+
+// SaveImageHashes replaces the stored image hashes of an item inside the given transaction
+func SaveImageHashes(ctx context.Context, tx pgx.Tx, contentID uuid.UUID, hashes []models.ImageHash) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM content_image_hashes WHERE content_id = $1`, contentID); err != nil {
+		return fmt.Errorf("failed to clear image hashes: %w", err)
+	}
+
+	query := `
+		INSERT INTO content_image_hashes (
+			content_id, image_url, phash, dhash,
+			phash_band0, phash_band1, phash_band2, phash_band3
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (content_id, image_url) DO NOTHING
+	`
+	for _, hash := range hashes {
+		bands := hashBandValues(hash.PHash)
+		_, err := tx.Exec(ctx, query, contentID, hash.ImageURL, int64(hash.PHash), int64(hash.DHash),
+			bands[0], bands[1], bands[2], bands[3])
+		if err != nil {
+			return fmt.Errorf("failed to store image hash: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// IsVisualDuplicate reports whether any image of one item shows the same picture
+// as any image of the other, within maxDistance bits on both hashes
+func IsVisualDuplicate(a, b []models.ImageHash, maxDistance int) bool {
+	for _, hashA := range a {
+		for _, hashB := range b {
+			if _, ok := visualDuplicateDistance(hashA, hashB, maxDistance); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// visualDuplicateDistance returns the larger of the pHash and dHash distances
+// and whether it is within maxDistance
+func visualDuplicateDistance(a, b models.ImageHash, maxDistance int) (int, bool) {
+	distance := max(HammingDistance(a.PHash, b.PHash), HammingDistance(a.DHash, b.DHash))
+	return distance, distance <= maxDistance
+}
+
+// hashBandValues splits a SimHash or pHash into the 16-bit band values used for lookups
+func hashBandValues(pHash uint64) [hashBands]int32 {
+	var bands [hashBands]int32
+	for i := range bands {
+		bands[i] = int32((pHash >> (16 * uint(i))) & 0xffff)
+	}
+	return bands
+}
+
+// Policy returns the configured duplicate handling policy
+func (cd *ContentDeduplicator) Policy() string {
+	if cd.config == nil || cd.config.Policy != DedupPolicyMerge {
+		return DedupPolicyFlag
+	}
+	return DedupPolicyMerge
+}
+
+func (cd *ContentDeduplicator) isDuplicate(hammingDistance int, embeddingSimilarity float64) bool {
+	return hammingDistance <= cd.config.MaxHammingDistance &&
+		embeddingSimilarity >= cd.config.EmbeddingSimilarity
+}
+
+// SimHash computes a 64-bit SimHash over word shingles of the given text.
+// Texts that differ by a few words produce fingerprints with a small Hamming distance.
+func SimHash(text string) uint64 {
+	tokens := simHashTokenRegex.FindAllString(strings.ToLower(text), -1)
+	if len(tokens) == 0 {
+		return 0
+	}
+
+	shingles := make([]string, 0, len(tokens))
+	if len(tokens) < simHashShingleSize {
+		shingles = append(shingles, strings.Join(tokens, " "))
+	} else {
+		for i := 0; i+simHashShingleSize <= len(tokens); i++ {
+			shingles = append(shingles, strings.Join(tokens[i:i+simHashShingleSize], " "))
+		}
+	}
+
+	var vector [64]int
+	for _, shingle := range shingles {
+		hasher := fnv.New64a()
+		hasher.Write([]byte(shingle))
+		hash := hasher.Sum64()
+
+		for bit := 0; bit < 64; bit++ {
+			if hash&(1<<uint(bit)) != 0 {
+				vector[bit]++
+			} else {
+				vector[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit := 0; bit < 64; bit++ {
+		if vector[bit] > 0 {
+			fingerprint |= 1 << uint(bit)
+		}
+	}
+
+	return fingerprint
+}
+
+// HammingDistance returns the number of differing bits between two fingerprints
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func TestSimHash(t *testing.T) {
+	base := "Wireless noise cancelling headphones with thirty hour battery life and fast charging support"
+
+	tests := []struct {
+		name        string
+		a           string
+		b           string
+		maxDistance int
+		minDistance int
+	}{
+		{
+			name:        "identical text",
+			a:           base,
+			b:           base,
+			maxDistance: 0,
+		},
+		{
+			name:        "case and punctuation differences",
+			a:           base,
+			b:           "WIRELESS noise-cancelling headphones, with thirty hour battery life and fast charging support!",
+			maxDistance: 3,
+		},
+		{
+			name:        "single word changed",
+			a:           base,
+			b:           "Wireless noise cancelling headphones with forty hour battery life and fast charging support",
+			maxDistance: 20,
+		},
+		{
+			name:        "unrelated text",
+			a:           base,
+			b:           "Slow cooked beef stew recipe with root vegetables, red wine and fresh thyme for winter evenings",
+			maxDistance: 64,
+			minDistance: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distance := HammingDistance(SimHash(tt.a), SimHash(tt.b))
+			assert.LessOrEqual(t, distance, tt.maxDistance)
+			assert.GreaterOrEqual(t, distance, tt.minDistance)
+		})
+	}
+}
+
+func TestSimHash_EmptyText(t *testing.T) {
+	assert.Equal(t, uint64(0), SimHash(""))
+	assert.Equal(t, uint64(0), SimHash("  ---  "))
+	assert.NotEqual(t, uint64(0), SimHash("short"))
+}
+
+func TestHammingDistance(t *testing.T) {
+	assert.Equal(t, 0, HammingDistance(0, 0))
+	assert.Equal(t, 1, HammingDistance(0, 1))
+	assert.Equal(t, 64, HammingDistance(0, ^uint64(0)))
+	assert.Equal(t, 2, HammingDistance(0xF0, 0xF3))
+}
+
+func TestContentDeduplicator_Policy(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	tests := []struct {
+		name     string
+		config   *config.DedupConfig
+		expected string
+	}{
+		{name: "nil config", config: nil, expected: DedupPolicyFlag},
+		{name: "flag policy", config: &config.DedupConfig{Policy: "flag"}, expected: DedupPolicyFlag},
+		{name: "merge policy", config: &config.DedupConfig{Policy: "merge"}, expected: DedupPolicyMerge},
+		{name: "unknown policy", config: &config.DedupConfig{Policy: "drop"}, expected: DedupPolicyFlag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dedup := NewContentDeduplicator(nil, tt.config, logger)
+			assert.Equal(t, tt.expected, dedup.Policy())
+		})
+	}
+}
+
+func TestContentDeduplicator_IsDuplicate(t *testing.T) {
+	logger := logrus.New()
+	dedup := NewContentDeduplicator(nil, &config.DedupConfig{
+		Enabled:             true,
+		MaxHammingDistance:  3,
+		EmbeddingSimilarity: 0.95,
+	}, logger)
+
+	assert.True(t, dedup.isDuplicate(2, 0.97))
+	assert.False(t, dedup.isDuplicate(5, 0.99), "fingerprint too far apart")
+	assert.False(t, dedup.isDuplicate(0, 0.5), "embeddings not similar enough")
+}
+
+func TestHashBandValues(t *testing.T) {
+	bands := hashBandValues(0x1111222233334444)
+	assert.Equal(t, [hashBands]int32{0x4444, 0x3333, 0x2222, 0x1111}, bands)
+
+	// Any hash within three bits still shares a band, so the index finds it
+	original := uint64(0x8f3a9c21d04e7b65)
+	nearby := original ^ (1 << 3) ^ (1 << 20) ^ (1 << 41)
+	a, b := hashBandValues(original), hashBandValues(nearby)
+	shared := 0
+	for i := range a {
+		if a[i] == b[i] {
+			shared++
+		}
+	}
+	assert.Equal(t, 1, shared)
+}
+
+func TestContentDeduplicator_FingerprintCandidates(t *testing.T) {
+	logger := logrus.New()
+	dedup := NewContentDeduplicator(nil, &config.DedupConfig{
+		Enabled:             true,
+		MaxHammingDistance:  3,
+		EmbeddingSimilarity: 0.95,
+	}, logger)
+
+	// Placeholder embeddings make many unrelated items look alike, so the
+	// duplicate is nowhere near the top of an embedding ranking
+	duplicate := fingerprintCandidate{id: uuid.New(), distance: 2}
+	similarities := map[uuid.UUID]float64{duplicate.id: 0.96}
+	var banded []fingerprintCandidate
+	for i := 0; i < 50; i++ {
+		unrelated := fingerprintCandidate{id: uuid.New(), distance: 20 + i%10}
+		banded = append(banded, unrelated)
+		similarities[unrelated.id] = 1.0
+	}
+	banded = append(banded, duplicate)
+
+	near := nearestFingerprints(banded, 3, 10)
+	require.Len(t, near, 1)
+
+	match := dedup.bestTextDuplicate(uuid.New(), near, similarities)
+	require.NotNil(t, match)
+	assert.Equal(t, duplicate.id, match.DuplicateOf)
+	assert.Equal(t, DuplicateReasonText, match.Reason)
+
+	// A fingerprint match still needs a similar embedding
+	similarities[duplicate.id] = 0.5
+	assert.Nil(t, dedup.bestTextDuplicate(uuid.New(), near, similarities))
+
+	// Closest first, capped at the candidate limit
+	near = nearestFingerprints([]fingerprintCandidate{{distance: 3}, {distance: 0}, {distance: 2}}, 3, 2)
+	assert.Equal(t, []int{0, 2}, []int{near[0].distance, near[1].distance})
+}
+
+func TestIsVisualDuplicate(t *testing.T) {
+	photo := models.ImageHash{ImageURL: "https://a.example/1.jpg", PHash: 0x8f3a9c21d04e7b65, DHash: 0x0f0f0f0f0f0f0f0f}
+	resized := models.ImageHash{ImageURL: "https://b.example/1.jpg", PHash: photo.PHash ^ 0b101, DHash: photo.DHash ^ 0b1}
+	other := models.ImageHash{ImageURL: "https://c.example/2.jpg", PHash: ^photo.PHash, DHash: photo.DHash}
+
+	assert.True(t, IsVisualDuplicate([]models.ImageHash{photo}, []models.ImageHash{other, resized}, 3))
+	assert.False(t, IsVisualDuplicate([]models.ImageHash{photo}, []models.ImageHash{other}, 3))
+	assert.False(t, IsVisualDuplicate([]models.ImageHash{photo}, []models.ImageHash{resized}, 1), "pHash two bits apart")
+	assert.False(t, IsVisualDuplicate(nil, []models.ImageHash{photo}, 3))
+
+	// The dHash must agree as well
+	sameDCT := models.ImageHash{PHash: photo.PHash, DHash: ^photo.DHash}
+	assert.False(t, IsVisualDuplicate([]models.ImageHash{photo}, []models.ImageHash{sameDCT}, 3))
+}
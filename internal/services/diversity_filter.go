@@ -17,9 +17,10 @@ import (
 
 // DiversityFilter applies various diversity filters to recommendation lists
 type DiversityFilter struct {
-	db     *pgxpool.Pool
-	config *config.DiversityConfig
-	logger *logrus.Logger
+	db       *pgxpool.Pool
+	config   *config.DiversityConfig
+	taxonomy *TaxonomyService // Optional; makes category limits path-aware
+	logger   *logrus.Logger
 }
 
 // NewDiversityFilter creates a new diversity filter
@@ -35,6 +36,12 @@ func NewDiversityFilter(
 	}
 }
 
+// SetTaxonomy makes the category limit count related categories, so siblings in
+// the taxonomy share part of each other's quota
+func (df *DiversityFilter) SetTaxonomy(taxonomy *TaxonomyService) {
+	df.taxonomy = taxonomy
+}
+
 // FilteredRecommendation represents a recommendation with diversity metadata
 type FilteredRecommendation struct {
 	models.Recommendation
@@ -79,6 +86,7 @@ func (df *DiversityFilter) ApplyDiversityFilters(
 	filteredRecs := df.prepareFilteredRecommendations(recommendations, contentItems)
 
 	// Apply filters in sequence
+	filteredRecs = df.applyVisualDuplicateFilter(filteredRecs, contentItems)
 	filteredRecs = df.applyIntraListDiversityFilter(filteredRecs, contentItems)
 	filteredRecs = df.applyCategoryDiversityFilter(filteredRecs, contentItems)
 	filteredRecs = df.applyTemporalDiversityFilter(filteredRecs, contentItems, recentInteractions)
@@ -100,6 +108,46 @@ func (df *DiversityFilter) ApplyDiversityFilters(
 	return result, nil
 }
 
+// applyVisualDuplicateFilter drops items showing the same picture as a higher-scored
+// item in the list, e.g. one product listed by several sellers
+func (df *DiversityFilter) applyVisualDuplicateFilter(
+	recommendations []FilteredRecommendation,
+	contentItems map[uuid.UUID]*models.ContentItem,
+) []FilteredRecommendation {
+
+	maxDistance := df.config.VisualDuplicateDistance
+	if maxDistance < 0 || len(recommendations) <= 1 {
+		return recommendations
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
+	})
+
+	var filtered []FilteredRecommendation
+	var kept []*models.ContentItem
+
+	for _, rec := range recommendations {
+		item := contentItems[rec.ItemID]
+		if item != nil && len(item.ImageHashes) > 0 {
+			duplicate := false
+			for _, keptItem := range kept {
+				if IsVisualDuplicate(item.ImageHashes, keptItem.ImageHashes, maxDistance) {
+					duplicate = true
+					break
+				}
+			}
+			if duplicate {
+				continue
+			}
+			kept = append(kept, item)
+		}
+		filtered = append(filtered, rec)
+	}
+
+	return filtered
+}
+
 // applyIntraListDiversityFilter implements greedy algorithm for intra-list diversity
 func (df *DiversityFilter) applyIntraListDiversityFilter(
 	recommendations []FilteredRecommendation,
@@ -172,8 +220,12 @@ func (df *DiversityFilter) applyCategoryDiversityFilter(
 	contentItems map[uuid.UUID]*models.ContentItem,
 ) []FilteredRecommendation {
 
-	maxPerCategory := df.config.CategoryMaxItems
-	categoryCount := make(map[string]int)
+	// Each accepted item adds its taxonomy similarity to a category's load, so
+	// an identical category counts 1 and a sibling counts less. Unknown
+	// categories only match themselves, which is plain per-category counting.
+	maxPerCategory := float64(df.config.CategoryMaxItems)
+	taxonomy := df.taxonomy.Current()
+	var accepted [][]string // Most specific categories of each accepted item
 	var filtered []FilteredRecommendation
 
 	for _, rec := range recommendations {
@@ -182,20 +234,28 @@ func (df *DiversityFilter) applyCategoryDiversityFilter(
 			continue
 		}
 
-		// Check category limits
+		// Parent categories added at ingestion would otherwise count every item
+		// against the root
+		categories := taxonomy.MostSpecific(item.Categories)
+
 		canAdd := true
-		for _, category := range item.Categories {
-			if categoryCount[category] >= maxPerCategory {
+		for _, category := range categories {
+			load := 0.0
+			for _, acceptedCategories := range accepted {
+				best := 0.0
+				for _, other := range acceptedCategories {
+					best = math.Max(best, taxonomy.Similarity(category, other))
+				}
+				load += best
+			}
+			if load >= maxPerCategory-1e-9 {
 				canAdd = false
 				break
 			}
 		}
 
 		if canAdd {
-			// Update category counts
-			for _, category := range item.Categories {
-				categoryCount[category]++
-			}
+			accepted = append(accepted, categories)
 			filtered = append(filtered, rec)
 		}
 	}
@@ -517,9 +577,53 @@ func (df *DiversityFilter) getContentItems(
 		items[item.ID] = &item
 	}
 
+	if err := df.loadImageHashes(ctx, items); err != nil {
+		df.logger.WithError(err).Warn("Failed to load image hashes for visual duplicate filtering")
+	}
+
 	return items, nil
 }
 
+// loadImageHashes attaches the perceptual hashes of each item's images
+func (df *DiversityFilter) loadImageHashes(ctx context.Context, items map[uuid.UUID]*models.ContentItem) error {
+	if len(items) == 0 || df.config.VisualDuplicateDistance < 0 {
+		return nil
+	}
+
+	itemIDs := make([]uuid.UUID, 0, len(items))
+	for id := range items {
+		itemIDs = append(itemIDs, id)
+	}
+
+	rows, err := df.db.Query(ctx, `
+		SELECT content_id, image_url, phash, dhash
+		FROM content_image_hashes
+		WHERE content_id = ANY($1)
+	`, itemIDs)
+	if err != nil {
+		return fmt.Errorf("failed to query image hashes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var contentID uuid.UUID
+		var imageURL string
+		var pHash, dHash int64
+		if err := rows.Scan(&contentID, &imageURL, &pHash, &dHash); err != nil {
+			continue
+		}
+		if item := items[contentID]; item != nil {
+			item.ImageHashes = append(item.ImageHashes, models.ImageHash{
+				ImageURL: imageURL,
+				PHash:    uint64(pHash),
+				DHash:    uint64(dHash),
+			})
+		}
+	}
+
+	return rows.Err()
+}
+
 func (df *DiversityFilter) getUserRecentInteractions(
 	ctx context.Context,
 	userID uuid.UUID,
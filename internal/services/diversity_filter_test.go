@@ -425,3 +425,79 @@ func BenchmarkDiversityFilter_CalculateCosineSimilarity(b *testing.B) {
 		df.calculateCosineSimilarity(vec1, vec2)
 	}
 }
+
+func TestDiversityFilter_ApplyVisualDuplicateFilter(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	df := NewDiversityFilter(nil, &config.DiversityConfig{VisualDuplicateDistance: 3}, logger)
+
+	id1 := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	id2 := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	id3 := uuid.MustParse("00000000-0000-0000-0000-000000000003")
+	id4 := uuid.MustParse("00000000-0000-0000-0000-000000000004")
+
+	photo := models.ImageHash{PHash: 0x8f3a9c21d04e7b65, DHash: 0x0f0f0f0f0f0f0f0f}
+	contentItems := map[uuid.UUID]*models.ContentItem{
+		id1: {ID: id1, ImageHashes: []models.ImageHash{photo}},
+		// Same product photo from another seller, recompressed
+		id2: {ID: id2, ImageHashes: []models.ImageHash{{PHash: photo.PHash ^ 0b11, DHash: photo.DHash ^ 0b1}}},
+		id3: {ID: id3, ImageHashes: []models.ImageHash{{PHash: ^photo.PHash, DHash: ^photo.DHash}}},
+		id4: {ID: id4}, // No images
+	}
+
+	recommendations := []FilteredRecommendation{
+		{Recommendation: models.Recommendation{ItemID: id2, Score: 0.8}},
+		{Recommendation: models.Recommendation{ItemID: id1, Score: 0.9}},
+		{Recommendation: models.Recommendation{ItemID: id3, Score: 0.7}},
+		{Recommendation: models.Recommendation{ItemID: id4, Score: 0.6}},
+	}
+
+	result := df.applyVisualDuplicateFilter(recommendations, contentItems)
+
+	// The higher-scored copy is kept
+	require.Len(t, result, 3)
+	assert.Equal(t, id1, result[0].ItemID)
+	assert.Equal(t, id3, result[1].ItemID)
+	assert.Equal(t, id4, result[2].ItemID)
+
+	// A negative distance disables the rule
+	df.config.VisualDuplicateDistance = -1
+	assert.Len(t, df.applyVisualDuplicateFilter(recommendations, contentItems), 4)
+}
+
+func TestDiversityFilter_ApplyCategoryDiversityFilter_Taxonomy(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	df := NewDiversityFilter(nil, &config.DiversityConfig{CategoryMaxItems: 2}, logger)
+	df.SetTaxonomy(newTestTaxonomyService(t, true))
+
+	// Categories as stored after parent expansion
+	items := []struct {
+		id         uuid.UUID
+		categories []string
+	}{
+		{uuid.New(), []string{"Headphones", "Audio", "Electronics"}},
+		{uuid.New(), []string{"Headphones", "Audio", "Electronics"}},
+		{uuid.New(), []string{"Speakers", "Audio", "Electronics"}},
+		{uuid.New(), []string{"Speakers", "Audio", "Electronics"}}, // Audio is saturated by now
+		{uuid.New(), []string{"Phones", "Electronics"}},
+		{uuid.New(), []string{"Fiction", "Books"}},
+	}
+
+	contentItems := make(map[uuid.UUID]*models.ContentItem)
+	var recommendations []FilteredRecommendation
+	for _, item := range items {
+		contentItems[item.id] = &models.ContentItem{ID: item.id, Categories: item.categories}
+		recommendations = append(recommendations, FilteredRecommendation{Recommendation: models.Recommendation{ItemID: item.id}})
+	}
+
+	result := df.applyCategoryDiversityFilter(recommendations, contentItems)
+
+	var ids []uuid.UUID
+	for _, rec := range result {
+		ids = append(ids, rec.ItemID)
+	}
+	assert.Equal(t, []uuid.UUID{items[0].id, items[1].id, items[2].id, items[4].id, items[5].id}, ids)
+}
@@ -17,8 +17,10 @@ import (
 
 // ExplanationService generates explanations for recommendations
 type ExplanationService struct {
-	db     *pgxpool.Pool
-	logger *logrus.Logger
+	db       *pgxpool.Pool
+	taxonomy *TaxonomyService // Optional; lets explanations name a common parent category
+	keywords *KeywordIndex    // Optional; enables keyword-based explanations
+	logger   *logrus.Logger
 }
 
 // NewExplanationService creates a new explanation service
@@ -29,11 +31,24 @@ func NewExplanationService(db *pgxpool.Pool, logger *logrus.Logger) *Explanation
 	}
 }
 
+// SetTaxonomy makes shared categories include the closest common parent of
+// related but different categories
+func (es *ExplanationService) SetTaxonomy(taxonomy *TaxonomyService) {
+	es.taxonomy = taxonomy
+}
+
+// SetKeywordIndex enables explanations naming the keywords an item shares with
+// items the user liked
+func (es *ExplanationService) SetKeywordIndex(index *KeywordIndex) {
+	es.keywords = index
+}
+
 // ExplanationType represents different types of explanations
 type ExplanationType string
 
 const (
 	ContentBasedExplanation    ExplanationType = "content_based"
+	KeywordBasedExplanation    ExplanationType = "keyword_based"
 	CollaborativeExplanation   ExplanationType = "collaborative"
 	GraphBasedExplanation      ExplanationType = "graph_based"
 	PopularityBasedExplanation ExplanationType = "popularity_based"
@@ -57,6 +72,7 @@ type SimilarItemInfo struct {
 	ItemID           uuid.UUID
 	Title            string
 	SharedCategories []string
+	SharedKeywords   []string // Keyword-based explanations
 	SimilarityScore  float64
 }
 
@@ -160,6 +176,13 @@ func (es *ExplanationService) gatherExplanationData(
 		explanations = append(explanations, *contentData)
 	}
 
+	// Keyword-based explanation
+	if es.keywords != nil {
+		if keywordData := es.getKeywordBasedExplanation(ctx, userID, recommendation.ItemID); keywordData != nil {
+			explanations = append(explanations, *keywordData)
+		}
+	}
+
 	// Collaborative explanation
 	if collabData := es.getCollaborativeExplanation(ctx, userID, recommendation.ItemID); collabData != nil {
 		explanations = append(explanations, *collabData)
@@ -285,6 +308,83 @@ func (es *ExplanationService) getContentBasedExplanation(
 	}
 }
 
+// getKeywordBasedExplanation finds liked items sharing the most keyword weight
+// with the recommended item
+func (es *ExplanationService) getKeywordBasedExplanation(
+	ctx context.Context,
+	userID uuid.UUID,
+	itemID uuid.UUID,
+) *ExplanationData {
+
+	if es.db == nil {
+		return nil
+	}
+
+	query := `
+		WITH user_liked_items AS (
+			SELECT DISTINCT ui.item_id, c.title
+			FROM user_interactions ui
+			JOIN content_items c ON ui.item_id = c.id
+			WHERE ui.user_id = $1
+			  AND ui.interaction_type IN ('rating', 'like')
+			  AND (ui.value IS NULL OR ui.value >= 4.0)
+			  AND ui.timestamp >= NOW() - INTERVAL '90 days'
+			  AND ui.item_id <> $2
+		),
+		target_keywords AS (
+			SELECT term, surface, weight
+			FROM content_keywords
+			WHERE content_id = $2
+		)
+		SELECT uli.item_id, uli.title,
+		       array_agg(tk.surface ORDER BY tk.weight * ck.weight DESC) AS shared,
+		       SUM(tk.weight * ck.weight) / NULLIF((SELECT SUM(weight * weight) FROM target_keywords), 0) AS score
+		FROM user_liked_items uli
+		JOIN content_keywords ck ON ck.content_id = uli.item_id
+		JOIN target_keywords tk ON tk.term = ck.term
+		GROUP BY uli.item_id, uli.title
+		ORDER BY score DESC
+		LIMIT 5
+	`
+
+	rows, err := es.db.Query(ctx, query, userID, itemID)
+	if err != nil {
+		es.logger.Warn("Failed to get keyword-based explanation data", "error", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var similarItems []SimilarItemInfo
+	for rows.Next() {
+		var similarItem SimilarItemInfo
+		var score *float64
+		if err := rows.Scan(&similarItem.ItemID, &similarItem.Title, &similarItem.SharedKeywords, &score); err != nil {
+			continue
+		}
+		if score != nil {
+			similarItem.SimilarityScore = math.Min(1, *score)
+		}
+		if len(similarItem.SharedKeywords) > 3 {
+			similarItem.SharedKeywords = similarItem.SharedKeywords[:3]
+		}
+		similarItems = append(similarItems, similarItem)
+	}
+
+	if len(similarItems) == 0 {
+		return nil
+	}
+
+	// Ranked just below category matches of the same strength; keywords are a
+	// weaker signal than curated categories
+	confidence := math.Min(0.8, float64(len(similarItems))*0.15+similarItems[0].SimilarityScore*0.5)
+
+	return &ExplanationData{
+		Type:         KeywordBasedExplanation,
+		Confidence:   confidence,
+		SimilarItems: similarItems,
+	}
+}
+
 // Collaborative explanation methods
 
 func (es *ExplanationService) getCollaborativeExplanation(
@@ -584,6 +684,8 @@ func (es *ExplanationService) generateExplanationText(data ExplanationData) stri
 	switch data.Type {
 	case ContentBasedExplanation:
 		return es.generateContentBasedText(data)
+	case KeywordBasedExplanation:
+		return es.generateKeywordBasedText(data)
 	case CollaborativeExplanation:
 		return es.generateCollaborativeText(data)
 	case GraphBasedExplanation:
@@ -614,6 +716,16 @@ func (es *ExplanationService) generateContentBasedText(data ExplanationData) str
 		similarItem.Title, len(data.SimilarItems)-1, sharedCats)
 }
 
+func (es *ExplanationService) generateKeywordBasedText(data ExplanationData) string {
+	if len(data.SimilarItems) == 0 || len(data.SimilarItems[0].SharedKeywords) == 0 {
+		return "Based on your preferences and similar content"
+	}
+
+	similarItem := data.SimilarItems[0]
+	return fmt.Sprintf("Because you liked \"%s\", which also mentions %s",
+		similarItem.Title, strings.Join(similarItem.SharedKeywords, ", "))
+}
+
 func (es *ExplanationService) generateCollaborativeText(data ExplanationData) string {
 	if len(data.SharedUsers) == 0 {
 		return "Recommended by users with similar tastes"
@@ -680,6 +792,9 @@ func (es *ExplanationService) generateSerendipityText(data ExplanationData) stri
 
 // Helper methods
 
+// findSharedCategories returns the categories in both lists, then the closest
+// common taxonomy parent of each pair that differs, e.g. "Audio" for
+// "Headphones" and "Speakers"
 func (es *ExplanationService) findSharedCategories(categories1, categories2 []string) []string {
 	categorySet := make(map[string]bool)
 	for _, cat := range categories1 {
@@ -687,9 +802,30 @@ func (es *ExplanationService) findSharedCategories(categories1, categories2 []st
 	}
 
 	var shared []string
+	seen := make(map[string]bool)
+	add := func(cat string) {
+		key := strings.ToLower(cat)
+		if !seen[key] {
+			seen[key] = true
+			shared = append(shared, cat)
+		}
+	}
+
 	for _, cat := range categories2 {
 		if categorySet[cat] {
-			shared = append(shared, cat)
+			add(cat)
+		}
+	}
+
+	taxonomy := es.taxonomy.Current()
+	for _, cat2 := range categories2 {
+		if categorySet[cat2] {
+			continue
+		}
+		for _, cat1 := range categories1 {
+			if ancestor := taxonomy.CommonAncestor(cat1, cat2); ancestor != "" {
+				add(ancestor)
+			}
 		}
 	}
 
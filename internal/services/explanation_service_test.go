@@ -374,6 +374,23 @@ func TestExplanationService_GenerateExplanationText(t *testing.T) {
 			},
 			expected: "Something new you might enjoy",
 		},
+		{
+			name: "keyword based",
+			data: ExplanationData{
+				Type: KeywordBasedExplanation,
+				SimilarItems: []SimilarItemInfo{
+					{Title: "Studio Monitor Headphones", SharedKeywords: []string{"studio", "monitor"}},
+				},
+			},
+			expected: "Because you liked \"Studio Monitor Headphones\", which also mentions studio, monitor",
+		},
+		{
+			name: "keyword based without keywords",
+			data: ExplanationData{
+				Type: KeywordBasedExplanation,
+			},
+			expected: "Based on your preferences and similar content",
+		},
 		{
 			name: "generic",
 			data: ExplanationData{
@@ -444,6 +461,19 @@ func TestExplanationService_FindSharedCategories(t *testing.T) {
 	}
 }
 
+func TestExplanationService_FindSharedCategories_Taxonomy(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	es := NewExplanationService(nil, logger)
+	es.SetTaxonomy(newTestTaxonomyService(t, true))
+
+	assert.Equal(t, []string{"Audio"}, es.findSharedCategories([]string{"Headphones"}, []string{"Speakers"}))
+	assert.Equal(t, []string{"Electronics"},
+		es.findSharedCategories([]string{"Headphones", "Electronics"}, []string{"Phones", "Electronics"}))
+	assert.Empty(t, es.findSharedCategories([]string{"Headphones"}, []string{"Fiction"}))
+}
+
 // Integration test for the complete explanation generation flow
 func TestExplanationService_GenerateExplanations_Integration(t *testing.T) {
 	logger := logrus.New()
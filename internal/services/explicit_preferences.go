@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// PreferenceFilter applies the preferences users state in their profile:
+// it drops items in blocked categories or of hidden content types, scales the
+// scores of favourite categories and weighted content types, and generates
+// candidates from favourite categories for users without history
+type PreferenceFilter struct {
+	db     *pgxpool.Pool
+	config *config.ExplicitPreferencesConfig
+	logger *logrus.Logger
+}
+
+// preferenceCandidate is the content type and categories of one candidate item
+type preferenceCandidate struct {
+	Type       string
+	Categories []string
+}
+
+func NewPreferenceFilter(db *pgxpool.Pool, config *config.ExplicitPreferencesConfig, logger *logrus.Logger) *PreferenceFilter {
+	return &PreferenceFilter{
+		db:     db,
+		config: config,
+		logger: logger,
+	}
+}
+
+// ExplicitPreferencesOf returns the explicit preferences stored in a profile.
+// Missing or malformed entries are left empty.
+func ExplicitPreferencesOf(profile *models.UserProfile) models.ExplicitPreferences {
+	var prefs models.ExplicitPreferences
+	if profile == nil || len(profile.ExplicitPrefs) == 0 {
+		return prefs
+	}
+
+	for key, target := range map[string]interface{}{
+		models.PrefFavoriteCategories: &prefs.FavoriteCategories,
+		models.PrefBlockedCategories:  &prefs.BlockedCategories,
+		models.PrefContentTypes:       &prefs.ContentTypes,
+		models.PrefOnboardedAt:        &prefs.OnboardedAt,
+		models.PrefOnboardingItems:    &prefs.OnboardingItems,
+	} {
+		value, ok := profile.ExplicitPrefs[key]
+		if !ok {
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		_ = json.Unmarshal(data, target)
+	}
+	return prefs
+}
+
+// hasExplicitPreferences reports whether the preferences change rankings
+func hasExplicitPreferences(prefs models.ExplicitPreferences) bool {
+	return len(prefs.FavoriteCategories) > 0 || len(prefs.BlockedCategories) > 0 || len(prefs.ContentTypes) > 0
+}
+
+// mergeExplicitPreferences applies a profile update to the stored explicit
+// preferences and returns them as a new map; other keys are kept. Categories
+// are canonicalized with the taxonomy and content types lowercased. A category
+// both blocked and favourite stays in the list the update set; when the update
+// sets both, blocking wins.
+func mergeExplicitPreferences(
+	stored map[string]interface{},
+	req *models.ProfileUpdateRequest,
+	taxonomy *Taxonomy,
+	now time.Time,
+) map[string]interface{} {
+	current := ExplicitPreferencesOf(&models.UserProfile{ExplicitPrefs: stored})
+
+	favorites := current.FavoriteCategories
+	if req.FavoriteCategories != nil {
+		favorites = taxonomy.Expand(*req.FavoriteCategories, false)
+	}
+	blocked := current.BlockedCategories
+	if req.BlockedCategories != nil {
+		blocked = taxonomy.Expand(*req.BlockedCategories, false)
+	}
+	switch {
+	case req.BlockedCategories != nil:
+		favorites = withoutCategories(favorites, blocked)
+	case req.FavoriteCategories != nil:
+		blocked = withoutCategories(blocked, favorites)
+	}
+
+	contentTypes := current.ContentTypes
+	if req.ContentTypes != nil {
+		contentTypes = make(map[string]float64, len(*req.ContentTypes))
+		for contentType, weight := range *req.ContentTypes {
+			if contentType = strings.ToLower(strings.TrimSpace(contentType)); contentType != "" {
+				contentTypes[contentType] = weight
+			}
+		}
+	}
+
+	merged := make(map[string]interface{}, len(stored)+4)
+	for key, value := range stored {
+		merged[key] = value
+	}
+	merged[models.PrefFavoriteCategories] = nonNilStrings(favorites)
+	merged[models.PrefBlockedCategories] = nonNilStrings(blocked)
+	if contentTypes == nil {
+		contentTypes = map[string]float64{}
+	}
+	merged[models.PrefContentTypes] = contentTypes
+	if req.Onboarding {
+		merged[models.PrefOnboardedAt] = now.UTC().Format(time.RFC3339)
+	}
+	return merged
+}
+
+func withoutCategories(categories, remove []string) []string {
+	removed := make(map[string]bool, len(remove))
+	for _, category := range remove {
+		removed[category] = true
+	}
+	kept := make([]string, 0, len(categories))
+	for _, category := range categories {
+		if !removed[category] {
+			kept = append(kept, category)
+		}
+	}
+	return kept
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// Apply drops recommendations the user's explicit preferences exclude and
+// boosts those they favour, then re-sorts by score
+func (pf *PreferenceFilter) Apply(
+	ctx context.Context,
+	userID uuid.UUID,
+	prefs models.ExplicitPreferences,
+	recommendations []models.Recommendation,
+) ([]models.Recommendation, error) {
+	if len(recommendations) == 0 || !hasExplicitPreferences(prefs) {
+		return recommendations, nil
+	}
+
+	itemIDs := make([]uuid.UUID, len(recommendations))
+	for i, rec := range recommendations {
+		itemIDs[i] = rec.ItemID
+	}
+	candidates, err := pf.loadCandidates(ctx, itemIDs)
+	if err != nil {
+		return recommendations, err
+	}
+
+	filtered := applyExplicitPreferences(recommendations, candidates, prefs, pf.config)
+
+	pf.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"dropped": len(recommendations) - len(filtered),
+	}).Debug("Applied explicit preferences")
+
+	return filtered, nil
+}
+
+// applyExplicitPreferences is the pure part of Apply. Scores are multiplied by
+// the weight of the item's content type and boosted once for any favourite
+// category, capped at 1. Items with no content information are kept unchanged.
+func applyExplicitPreferences(
+	recommendations []models.Recommendation,
+	candidates map[uuid.UUID]*preferenceCandidate,
+	prefs models.ExplicitPreferences,
+	cfg *config.ExplicitPreferencesConfig,
+) []models.Recommendation {
+	favorites := make(map[string]bool, len(prefs.FavoriteCategories))
+	for _, category := range prefs.FavoriteCategories {
+		favorites[category] = true
+	}
+	blocked := make(map[string]bool, len(prefs.BlockedCategories))
+	for _, category := range prefs.BlockedCategories {
+		blocked[category] = true
+	}
+
+	filtered := make([]models.Recommendation, 0, len(recommendations))
+	for _, rec := range recommendations {
+		candidate := candidates[rec.ItemID]
+		if candidate == nil {
+			filtered = append(filtered, rec)
+			continue
+		}
+
+		if weight, ok := prefs.ContentTypes[candidate.Type]; ok {
+			if weight <= 0 {
+				continue
+			}
+			rec.Score = math.Min(1.0, rec.Score*weight)
+		}
+
+		isBlocked, isFavorite := false, false
+		for _, category := range candidate.Categories {
+			isBlocked = isBlocked || blocked[category]
+			isFavorite = isFavorite || favorites[category]
+		}
+		if isBlocked {
+			continue
+		}
+		if isFavorite {
+			rec.Score = math.Min(1.0, rec.Score*(1+cfg.FavoriteBoost))
+		}
+
+		filtered = append(filtered, rec)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Score > filtered[j].Score
+	})
+	for i := range filtered {
+		filtered[i].Position = i + 1
+	}
+	return filtered
+}
+
+// Recommendations returns the items of the user's favourite categories most
+// interacted with in the popularity window, for users without the history
+// other algorithms need. Blocked categories and hidden content types are left
+// out, and the request's content type and category filters apply.
+func (pf *PreferenceFilter) Recommendations(
+	ctx context.Context,
+	prefs models.ExplicitPreferences,
+	contentTypes []string,
+	categories []string,
+	exclude []uuid.UUID,
+	limit int,
+) ([]models.ScoredItem, error) {
+	if len(prefs.FavoriteCategories) == 0 {
+		return nil, fmt.Errorf("no favourite categories")
+	}
+
+	hiddenTypes := []string{}
+	for contentType, weight := range prefs.ContentTypes {
+		if weight <= 0 {
+			hiddenTypes = append(hiddenTypes, contentType)
+		}
+	}
+
+	rows, err := pf.db.Query(ctx, `
+		SELECT c.id, COUNT(i.id)::float8 AS interactions
+		FROM content_items c
+		LEFT JOIN user_interactions i ON i.item_id = c.id AND i.timestamp > $1
+		WHERE c.active = true
+			AND c.categories && $2
+			AND NOT (COALESCE(c.categories, '{}') && $3)
+			AND NOT (c.type = ANY($4))
+			AND NOT (c.id = ANY($5))
+			AND (COALESCE(cardinality($6::text[]), 0) = 0 OR c.type = ANY($6))
+			AND (COALESCE(cardinality($7::text[]), 0) = 0 OR c.categories && $7)
+		GROUP BY c.id
+		ORDER BY interactions DESC, MAX(c.quality_score) DESC, MAX(c.created_at) DESC
+		LIMIT $8`,
+		time.Now().Add(-pf.popularityWindow()),
+		prefs.FavoriteCategories,
+		nonNilStrings(prefs.BlockedCategories),
+		hiddenTypes,
+		nonNilIDs(exclude),
+		contentTypes,
+		categories,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query favourite category items: %w", err)
+	}
+	defer rows.Close()
+
+	var itemIDs []uuid.UUID
+	var counts []float64
+	for rows.Next() {
+		var itemID uuid.UUID
+		var count float64
+		if err := rows.Scan(&itemID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan favourite category item: %w", err)
+		}
+		itemIDs = append(itemIDs, itemID)
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return scorePopularItems(itemIDs, counts, "explicit_preferences"), nil
+}
+
+// scorePopularItems scores items ranked by interaction count relative to the
+// most popular one. Items nobody interacted with still score above zero, so a
+// new catalogue is ranked too.
+func scorePopularItems(itemIDs []uuid.UUID, counts []float64, algorithm string) []models.ScoredItem {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+
+	top := counts[0]
+	items := make([]models.ScoredItem, len(itemIDs))
+	for i, itemID := range itemIDs {
+		score := (counts[i] + 1) / (top + 1)
+		items[i] = models.ScoredItem{
+			ItemID:     itemID,
+			Score:      score,
+			Algorithm:  algorithm,
+			Confidence: math.Min(0.8, 0.3+0.1*math.Log2(1+counts[i])),
+		}
+	}
+	return items
+}
+
+func nonNilIDs(ids []uuid.UUID) []uuid.UUID {
+	if ids == nil {
+		return []uuid.UUID{}
+	}
+	return ids
+}
+
+func (pf *PreferenceFilter) popularityWindow() time.Duration {
+	if pf.config.PopularityWindow <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return pf.config.PopularityWindow
+}
+
+func (pf *PreferenceFilter) loadCandidates(ctx context.Context, itemIDs []uuid.UUID) (map[uuid.UUID]*preferenceCandidate, error) {
+	rows, err := pf.db.Query(ctx, `
+		SELECT id, type, COALESCE(categories, '{}')
+		FROM content_items
+		WHERE id = ANY($1)`, itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query content categories: %w", err)
+	}
+	defer rows.Close()
+
+	candidates := make(map[uuid.UUID]*preferenceCandidate, len(itemIDs))
+	for rows.Next() {
+		var id uuid.UUID
+		var candidate preferenceCandidate
+		if err := rows.Scan(&id, &candidate.Type, &candidate.Categories); err != nil {
+			continue
+		}
+		candidate.Type = strings.ToLower(candidate.Type)
+		candidates[id] = &candidate
+	}
+	return candidates, rows.Err()
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func TestExplicitPreferencesOf(t *testing.T) {
+	// Profiles read from Postgres or the cache hold decoded JSON
+	var stored map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"favorite_categories": ["books"],
+		"blocked_categories": ["horror"],
+		"content_types": {"video": 0, "article": 1.5},
+		"onboarded_at": "2024-06-01T12:00:00Z",
+		"newsletter": true
+	}`), &stored))
+
+	prefs := ExplicitPreferencesOf(&models.UserProfile{ExplicitPrefs: stored})
+	assert.Equal(t, []string{"books"}, prefs.FavoriteCategories)
+	assert.Equal(t, []string{"horror"}, prefs.BlockedCategories)
+	assert.Equal(t, map[string]float64{"video": 0, "article": 1.5}, prefs.ContentTypes)
+	require.NotNil(t, prefs.OnboardedAt)
+	assert.True(t, prefs.OnboardedAt.Equal(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)))
+
+	// Malformed entries are ignored
+	prefs = ExplicitPreferencesOf(&models.UserProfile{ExplicitPrefs: map[string]interface{}{
+		models.PrefFavoriteCategories: "books",
+	}})
+	assert.Empty(t, prefs.FavoriteCategories)
+	assert.False(t, hasExplicitPreferences(ExplicitPreferencesOf(nil)))
+}
+
+func TestMergeExplicitPreferences(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	taxonomy := DefaultTaxonomy()
+	strings := func(values ...string) *[]string { return &values }
+
+	stored := map[string]interface{}{
+		models.PrefFavoriteCategories: []interface{}{"Books", "Travel"},
+		models.PrefBlockedCategories:  []interface{}{"horror"},
+		models.PrefContentTypes:       map[string]interface{}{"video": 0.0},
+		"newsletter":                  true,
+	}
+
+	t.Run("omitted fields are kept", func(t *testing.T) {
+		merged := mergeExplicitPreferences(stored, &models.ProfileUpdateRequest{
+			BlockedCategories: strings(" Horror ", "romance", "horror"),
+		}, taxonomy, now)
+		prefs := ExplicitPreferencesOf(&models.UserProfile{ExplicitPrefs: merged})
+
+		// Categories outside the taxonomy are lowercased
+		assert.Equal(t, []string{"Books", "Travel"}, prefs.FavoriteCategories)
+		assert.Equal(t, []string{"horror", "romance"}, prefs.BlockedCategories)
+		assert.Equal(t, map[string]float64{"video": 0}, prefs.ContentTypes)
+		assert.Nil(t, prefs.OnboardedAt)
+		assert.Equal(t, true, merged["newsletter"])
+	})
+
+	t.Run("blocking removes favourites", func(t *testing.T) {
+		merged := mergeExplicitPreferences(stored, &models.ProfileUpdateRequest{
+			FavoriteCategories: strings("book", "trip"),
+			BlockedCategories:  strings("travel"),
+		}, taxonomy, now)
+		prefs := ExplicitPreferencesOf(&models.UserProfile{ExplicitPrefs: merged})
+
+		// Synonyms resolve to taxonomy names
+		assert.Equal(t, []string{"Books"}, prefs.FavoriteCategories)
+		assert.Equal(t, []string{"Travel"}, prefs.BlockedCategories)
+	})
+
+	t.Run("new favourites unblock", func(t *testing.T) {
+		merged := mergeExplicitPreferences(stored, &models.ProfileUpdateRequest{
+			FavoriteCategories: strings("horror"),
+		}, taxonomy, now)
+		prefs := ExplicitPreferencesOf(&models.UserProfile{ExplicitPrefs: merged})
+
+		assert.Equal(t, []string{"horror"}, prefs.FavoriteCategories)
+		assert.Empty(t, prefs.BlockedCategories)
+	})
+
+	t.Run("content types are replaced and onboarding recorded", func(t *testing.T) {
+		merged := mergeExplicitPreferences(stored, &models.ProfileUpdateRequest{
+			ContentTypes: &map[string]float64{" Article ": 2, "": 1},
+			Onboarding:   true,
+		}, taxonomy, now)
+		prefs := ExplicitPreferencesOf(&models.UserProfile{ExplicitPrefs: merged})
+
+		assert.Equal(t, map[string]float64{"article": 2}, prefs.ContentTypes)
+		require.NotNil(t, prefs.OnboardedAt)
+		assert.True(t, prefs.OnboardedAt.Equal(now))
+	})
+}
+
+func TestApplyExplicitPreferences(t *testing.T) {
+	cfg := &config.ExplicitPreferencesConfig{FavoriteBoost: 0.5}
+
+	video, article, horror, book, unknown := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	candidates := map[uuid.UUID]*preferenceCandidate{
+		video:   {Type: "video", Categories: []string{"music"}},
+		article: {Type: "article", Categories: []string{"news"}},
+		horror:  {Type: "product", Categories: []string{"books", "horror"}},
+		book:    {Type: "product", Categories: []string{"books"}},
+	}
+	prefs := models.ExplicitPreferences{
+		FavoriteCategories: []string{"books"},
+		BlockedCategories:  []string{"horror"},
+		ContentTypes:       map[string]float64{"video": 0, "article": 1.2},
+	}
+
+	recommendations := []models.Recommendation{
+		{ItemID: video, Score: 0.9, Position: 1},
+		{ItemID: article, Score: 0.5, Position: 2},
+		{ItemID: horror, Score: 0.45, Position: 3},
+		{ItemID: unknown, Score: 0.42, Position: 4},
+		{ItemID: book, Score: 0.44, Position: 5},
+	}
+
+	filtered := applyExplicitPreferences(recommendations, candidates, prefs, cfg)
+
+	// The hidden content type and the blocked category are dropped, the
+	// favourite category boosted above the weighted article
+	require.Len(t, filtered, 3)
+	assert.Equal(t, book, filtered[0].ItemID)
+	assert.InDelta(t, 0.66, filtered[0].Score, 1e-9)
+	assert.Equal(t, article, filtered[1].ItemID)
+	assert.InDelta(t, 0.6, filtered[1].Score, 1e-9)
+	assert.Equal(t, unknown, filtered[2].ItemID)
+	assert.InDelta(t, 0.42, filtered[2].Score, 1e-9)
+	for i, rec := range filtered {
+		assert.Equal(t, i+1, rec.Position)
+	}
+}
+
+func TestScorePopularItems(t *testing.T) {
+	popular, quiet, unseen := uuid.New(), uuid.New(), uuid.New()
+
+	items := scorePopularItems([]uuid.UUID{popular, quiet, unseen}, []float64{9, 4, 0}, "explicit_preferences")
+
+	require.Len(t, items, 3)
+	assert.InDelta(t, 1.0, items[0].Score, 1e-9)
+	assert.InDelta(t, 0.5, items[1].Score, 1e-9)
+	assert.InDelta(t, 0.1, items[2].Score, 1e-9)
+	assert.Greater(t, items[0].Confidence, items[2].Confidence)
+	assert.Equal(t, "explicit_preferences", items[2].Algorithm)
+	assert.Empty(t, scorePopularItems(nil, nil, "explicit_preferences"))
+}
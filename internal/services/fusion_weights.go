@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/temcen/pirex/internal/config"
+)
+
+// FusionWeightReloader swaps in a newer fitted projection at runtime
+type FusionWeightReloader interface {
+	ReloadNewerFusionWeights(path string) (bool, error)
+}
+
+// FusionWeightsWatcher polls the fusion weights directory, so a projection
+// version written by fit-fusion embeds new items without a restart
+type FusionWeightsWatcher struct {
+	reloader FusionWeightReloader
+	config   *config.FusionConfig
+	logger   *logrus.Logger
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewFusionWeightsWatcher(reloader FusionWeightReloader, cfg *config.FusionConfig, logger *logrus.Logger) *FusionWeightsWatcher {
+	return &FusionWeightsWatcher{
+		reloader: reloader,
+		config:   cfg,
+		logger:   logger,
+		quit:     make(chan struct{}),
+	}
+}
+
+func (w *FusionWeightsWatcher) Start(ctx context.Context) {
+	if w.config.WeightsPath == "" || w.config.ReloadInterval <= 0 {
+		return
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(w.config.ReloadInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.Check()
+			case <-w.quit:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (w *FusionWeightsWatcher) Stop() {
+	close(w.quit)
+	w.wg.Wait()
+}
+
+// Check loads the newest artifact under the weights path if it is newer than
+// the one in use, and reports whether it did
+func (w *FusionWeightsWatcher) Check() bool {
+	reloaded, err := w.reloader.ReloadNewerFusionWeights(w.config.WeightsPath)
+	if err != nil {
+		w.logger.WithError(err).WithField("path", w.config.WeightsPath).Warn("Failed to reload fusion weights")
+		return false
+	}
+	if reloaded {
+		w.logger.WithField("path", w.config.WeightsPath).
+			Info("Reloaded fusion weights; items embedded earlier need re-embedding to stay comparable")
+	}
+	return reloaded
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/temcen/pirex/internal/config"
+)
+
+type fakeFusionWeightReloader struct {
+	paths    []string
+	reloaded bool
+	err      error
+}
+
+func (f *fakeFusionWeightReloader) ReloadNewerFusionWeights(path string) (bool, error) {
+	f.paths = append(f.paths, path)
+	return f.reloaded, f.err
+}
+
+func TestFusionWeightsWatcher_Check(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	cfg := &config.FusionConfig{WeightsPath: "./models/fusion"}
+
+	reloader := &fakeFusionWeightReloader{reloaded: true}
+	assert.True(t, NewFusionWeightsWatcher(reloader, cfg, logger).Check())
+	assert.Equal(t, []string{"./models/fusion"}, reloader.paths)
+
+	// Nothing newer, or an unreadable directory, keeps the current weights
+	assert.False(t, NewFusionWeightsWatcher(&fakeFusionWeightReloader{}, cfg, logger).Check())
+	assert.False(t, NewFusionWeightsWatcher(&fakeFusionWeightReloader{err: errors.New("permission denied")}, cfg, logger).Check())
+}
@@ -0,0 +1,372 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/database"
+	"github.com/temcen/pirex/internal/graph"
+)
+
+var (
+	graphOutboxRelayed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "graph_outbox_relayed_total",
+		Help: "Interaction relationships written from the outbox to Neo4j",
+	})
+
+	graphOutboxFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "graph_outbox_failures_total",
+		Help: "Outbox rows whose write to Neo4j failed and will be retried",
+	})
+
+	graphOutboxDeadLetters = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "graph_outbox_dead_letters_total",
+		Help: "Outbox rows given up on after max_attempts",
+	})
+
+	graphOutboxPending = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "graph_outbox_pending",
+		Help: "Outbox rows waiting to be written to Neo4j",
+	})
+
+	graphOutboxLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "graph_outbox_lag_seconds",
+		Help: "Age of the oldest outbox row waiting to be written to Neo4j",
+	})
+)
+
+// GraphOutboxRelay drains the graph_outbox table into Neo4j. Interactions and
+// their outbox rows are committed together, so relationships survive restarts
+// and Neo4j outages; rows are locked with SKIP LOCKED so several instances can
+// relay at once. Writes are idempotent merges, so a row relayed twice after a
+// crash does no harm.
+type GraphOutboxRelay struct {
+	db     *database.Database
+	graph  *graph.Repository
+	config *config.GraphOutboxConfig
+	logger *logrus.Logger
+
+	wake chan struct{}
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// outboxRow is one queued relationship
+type outboxRow struct {
+	id int64
+	graph.Interaction
+}
+
+func NewGraphOutboxRelay(db *database.Database, graphRepo *graph.Repository, cfg *config.GraphOutboxConfig, logger *logrus.Logger) *GraphOutboxRelay {
+	return &GraphOutboxRelay{
+		db:     db,
+		graph:  graphRepo,
+		config: cfg,
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+	}
+}
+
+// Enabled reports whether interactions should be queued for the graph
+func (r *GraphOutboxRelay) Enabled() bool {
+	return r != nil && r.graph.Available()
+}
+
+func (r *GraphOutboxRelay) Start(ctx context.Context) {
+	if !r.Enabled() {
+		r.logger.Info("Graph outbox relay disabled: Neo4j is not configured")
+		return
+	}
+
+	r.wg.Add(1)
+	go r.relayLoop(ctx)
+}
+
+// Stop ends the relay after the batch in flight. Undelivered rows stay in the
+// outbox for the next start.
+func (r *GraphOutboxRelay) Stop() {
+	close(r.quit)
+	r.wg.Wait()
+}
+
+// Notify wakes the relay after new rows were committed. It never blocks.
+func (r *GraphOutboxRelay) Notify() {
+	if r == nil {
+		return
+	}
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue adds relationships to the outbox inside the caller's transaction
+func (r *GraphOutboxRelay) Enqueue(ctx context.Context, tx pgx.Tx, relationships []graph.Interaction) error {
+	for _, relationship := range relationships {
+		properties, err := json.Marshal(relationship.Properties)
+		if err != nil {
+			return fmt.Errorf("failed to encode relationship properties: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO graph_outbox (user_id, item_id, relationship, properties)
+			VALUES ($1, $2, $3, $4)`,
+			relationship.UserID, relationship.ItemID, relationship.Type, properties); err != nil {
+			return fmt.Errorf("failed to enqueue graph relationship: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *GraphOutboxRelay) relayLoop(ctx context.Context) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		r.drain(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-r.wake:
+		case <-r.quit:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// drain relays full batches until the outbox has nothing due or a batch fails
+func (r *GraphOutboxRelay) drain(ctx context.Context) {
+	defer r.updateLag(ctx)
+
+	for {
+		select {
+		case <-r.quit:
+			return
+		default:
+		}
+
+		relayed, err := r.relayBatch(ctx)
+		if err != nil {
+			r.logger.WithError(err).Warn("Graph outbox relay failed")
+			return
+		}
+		if relayed < r.batchSize() {
+			return
+		}
+	}
+}
+
+// relayBatch writes the oldest due rows to Neo4j and returns how many were
+// taken. Rows are deleted on success and rescheduled with backoff on failure.
+// A failed batch is retried row by row, so one row Neo4j rejects does not use
+// up the attempts of the rest.
+func (r *GraphOutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	tx, err := r.db.PG.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin outbox transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := r.claim(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, tx.Commit(ctx)
+	}
+
+	ids := make([]int64, len(rows))
+	batch := make([]graph.Interaction, len(rows))
+	for i, row := range rows {
+		ids[i] = row.id
+		batch[i] = row.Interaction
+	}
+
+	delivered, failed := ids, map[string][]int64(nil)
+	if writeErr := r.graph.RecordInteractions(ctx, batch); writeErr != nil {
+		r.logger.WithError(writeErr).WithField("batch_size", len(rows)).
+			Debug("Graph outbox batch failed, retrying rows one at a time")
+		delivered, failed = relayIndividually(ctx, rows, r.graph.RecordInteractions)
+	}
+
+	if len(delivered) > 0 {
+		if _, err := tx.Exec(ctx, `DELETE FROM graph_outbox WHERE id = ANY($1)`, delivered); err != nil {
+			return 0, fmt.Errorf("failed to delete relayed outbox rows: %w", err)
+		}
+	}
+
+	var retried, dead int
+	var lastError string
+	for message, failedIDs := range failed {
+		given, err := r.reschedule(ctx, tx, failedIDs, message)
+		if err != nil {
+			return 0, err
+		}
+		retried += len(failedIDs) - given
+		dead += given
+		lastError = message
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		// Written relationships will be relayed again
+		return 0, fmt.Errorf("failed to commit relayed outbox rows: %w", err)
+	}
+
+	graphOutboxRelayed.Add(float64(len(delivered)))
+	graphOutboxFailures.Add(float64(retried))
+	graphOutboxDeadLetters.Add(float64(dead))
+
+	if len(failed) > 0 {
+		return len(rows), fmt.Errorf("failed to write %d of %d outbox rows to Neo4j (%d given up): %s",
+			retried+dead, len(rows), dead, lastError)
+	}
+
+	r.logger.WithField("batch_size", len(rows)).Debug("Relayed graph outbox batch")
+	return len(rows), nil
+}
+
+// relayIndividually writes rows one at a time after their batch failed. It
+// returns the IDs written and the IDs that failed, grouped by error message.
+func relayIndividually(
+	ctx context.Context,
+	rows []outboxRow,
+	write func(context.Context, []graph.Interaction) error,
+) ([]int64, map[string][]int64) {
+	var delivered []int64
+	failed := make(map[string][]int64)
+
+	for _, row := range rows {
+		err := ctx.Err()
+		if err == nil {
+			err = write(ctx, []graph.Interaction{row.Interaction})
+		}
+		if err != nil {
+			failed[err.Error()] = append(failed[err.Error()], row.id)
+			continue
+		}
+		delivered = append(delivered, row.id)
+	}
+
+	return delivered, failed
+}
+
+// claim locks the oldest due rows, skipping rows another relay holds
+func (r *GraphOutboxRelay) claim(ctx context.Context, tx pgx.Tx) ([]outboxRow, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id, user_id, item_id, relationship, properties
+		FROM graph_outbox
+		WHERE failed_at IS NULL AND next_attempt_at <= NOW()
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, r.batchSize())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var claimed []outboxRow
+	for rows.Next() {
+		var row outboxRow
+		var properties []byte
+		if err := rows.Scan(&row.id, &row.UserID, &row.ItemID, &row.Type, &properties); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox row: %w", err)
+		}
+		if row.Properties, err = decodeOutboxProperties(properties); err != nil {
+			return nil, fmt.Errorf("outbox row %d: %w", row.id, err)
+		}
+		claimed = append(claimed, row)
+	}
+	return claimed, rows.Err()
+}
+
+// reschedule records a failed attempt on the rows and returns how many ran out
+// of attempts
+func (r *GraphOutboxRelay) reschedule(ctx context.Context, tx pgx.Tx, ids []int64, lastError string) (int, error) {
+	tag, err := tx.Exec(ctx, `
+		UPDATE graph_outbox
+		SET attempts = attempts + 1,
+			last_error = $2,
+			next_attempt_at = NOW() + LEAST($3 * POWER(2, attempts), $4) * INTERVAL '1 second',
+			failed_at = CASE WHEN attempts + 1 >= $5 THEN NOW() END
+		WHERE id = ANY($1)`,
+		ids, lastError, r.config.RetryBackoff.Seconds(), r.config.MaxBackoff.Seconds(), r.maxAttempts())
+	if err != nil {
+		return 0, fmt.Errorf("failed to reschedule outbox rows: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, nil
+	}
+
+	var dead int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM graph_outbox WHERE id = ANY($1) AND failed_at IS NOT NULL`, ids).Scan(&dead)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count failed outbox rows: %w", err)
+	}
+	return dead, nil
+}
+
+// updateLag refreshes the pending and lag gauges
+func (r *GraphOutboxRelay) updateLag(ctx context.Context) {
+	var pending int
+	var lag float64
+	err := r.db.PG.QueryRow(ctx, `
+		SELECT COUNT(*), COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(created_at)), 0)
+		FROM graph_outbox
+		WHERE failed_at IS NULL`).Scan(&pending, &lag)
+	if err != nil {
+		r.logger.WithError(err).Debug("Failed to measure graph outbox lag")
+		return
+	}
+	graphOutboxPending.Set(float64(pending))
+	graphOutboxLag.Set(lag)
+}
+
+func (r *GraphOutboxRelay) pollInterval() time.Duration {
+	if r.config.PollInterval > 0 {
+		return r.config.PollInterval
+	}
+	return 5 * time.Second
+}
+
+func (r *GraphOutboxRelay) batchSize() int {
+	if r.config.BatchSize > 0 {
+		return r.config.BatchSize
+	}
+	return 100
+}
+
+func (r *GraphOutboxRelay) maxAttempts() int {
+	if r.config.MaxAttempts > 0 {
+		return r.config.MaxAttempts
+	}
+	return 10
+}
+
+// decodeOutboxProperties restores property types lost in JSON: timestamps and
+// durations are integers, every other number a float
+func decodeOutboxProperties(data []byte) (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	if err := json.Unmarshal(data, &properties); err != nil {
+		return nil, fmt.Errorf("invalid relationship properties: %w", err)
+	}
+	for _, key := range []string{graph.PropTimestamp, graph.PropDuration} {
+		if value, ok := properties[key].(float64); ok {
+			properties[key] = int64(value)
+		}
+	}
+	return properties, nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/graph"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func TestDecodeOutboxProperties_RoundTrip(t *testing.T) {
+	itemID := uuid.New()
+	duration := 45
+	rating := 5.0
+
+	for _, interaction := range []models.UserInteraction{
+		{UserID: uuid.New(), ItemID: &itemID, InteractionType: "view", Duration: &duration, Timestamp: time.Now()},
+		{UserID: uuid.New(), ItemID: &itemID, InteractionType: "rating", Value: &rating, Timestamp: time.Now()},
+	} {
+		relationship := graphInteraction(&interaction)
+
+		encoded, err := json.Marshal(relationship.Properties)
+		require.NoError(t, err)
+		decoded, err := decodeOutboxProperties(encoded)
+		require.NoError(t, err)
+
+		// Types must survive so relayed relationships match directly written ones
+		assert.Equal(t, relationship.Properties[graph.PropTimestamp], decoded[graph.PropTimestamp])
+		assert.Equal(t, relationship.Properties[graph.PropConfidence], decoded[graph.PropConfidence])
+		assert.Equal(t, relationship.Properties[graph.PropInteractionType], decoded[graph.PropInteractionType])
+		if interaction.Duration != nil {
+			assert.Equal(t, int64(duration), decoded[graph.PropDuration])
+		}
+		if interaction.Value != nil {
+			assert.Equal(t, 5.0, decoded[graph.PropRating], "whole ratings stay floats")
+		}
+	}
+
+	_, err := decodeOutboxProperties([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestRelayIndividually_PoisonRow(t *testing.T) {
+	poison := uuid.New()
+	rows := []outboxRow{
+		{id: 1, Interaction: graph.Interaction{UserID: uuid.New(), ItemID: uuid.New(), Type: "RATED"}},
+		{id: 2, Interaction: graph.Interaction{UserID: uuid.New(), ItemID: poison, Type: "RATED"}},
+		{id: 3, Interaction: graph.Interaction{UserID: uuid.New(), ItemID: uuid.New(), Type: "RATED"}},
+	}
+
+	var written []uuid.UUID
+	write := func(ctx context.Context, batch []graph.Interaction) error {
+		for _, relationship := range batch {
+			if relationship.ItemID == poison {
+				return errors.New("property type mismatch")
+			}
+		}
+		for _, relationship := range batch {
+			written = append(written, relationship.ItemID)
+		}
+		return nil
+	}
+
+	delivered, failed := relayIndividually(context.Background(), rows, write)
+
+	// Only the poison row is rescheduled; its neighbours are written
+	assert.Equal(t, []int64{1, 3}, delivered)
+	assert.Equal(t, map[string][]int64{"property type mismatch": {2}}, failed)
+	assert.Equal(t, []uuid.UUID{rows[0].ItemID, rows[2].ItemID}, written)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	delivered, failed = relayIndividually(ctx, rows, write)
+	assert.Empty(t, delivered)
+	assert.Len(t, failed[context.Canceled.Error()], 3)
+}
+
+func TestMirroredToGraph(t *testing.T) {
+	itemID := uuid.New()
+
+	for interactionType, expected := range map[string]bool{
+		"rating": true, "like": true, "dislike": true, "share": true,
+		"click": true, "view": true, "search": false, "browse": false,
+	} {
+		interaction := &models.UserInteraction{ItemID: &itemID, InteractionType: interactionType}
+		assert.Equal(t, expected, mirroredToGraph(interaction), interactionType)
+	}
+
+	assert.False(t, mirroredToGraph(&models.UserInteraction{InteractionType: "rating"}))
+}
+
+func TestGraphOutboxRelay_WithoutGraph(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	var missing *GraphOutboxRelay
+	assert.False(t, missing.Enabled())
+	missing.Notify()
+
+	relay := NewGraphOutboxRelay(nil, graph.NewRepository(nil, logger), &config.GraphOutboxConfig{}, logger)
+	assert.False(t, relay.Enabled())
+
+	// Notifications coalesce instead of blocking
+	relay.Notify()
+	relay.Notify()
+	assert.Len(t, relay.wake, 1)
+
+	relay.Start(context.Background())
+	relay.Stop()
+}
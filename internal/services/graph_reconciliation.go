@@ -0,0 +1,746 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/database"
+	"github.com/temcen/pirex/internal/graph"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+const graphReconciliationJobType = "graph_reconciliation"
+
+var (
+	ErrReconciliationInProgress = errors.New("graph reconciliation already running")
+	errReconciliationStopped    = errors.New("graph reconciliation stopped by shutdown")
+)
+
+var (
+	graphReconciliationRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "graph_reconciliation_runs_total",
+		Help: "Graph reconciliation runs by outcome",
+	}, []string{"status"})
+
+	graphReconciliationIssues = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "graph_reconciliation_issues_total",
+		Help: "Differences between Postgres and the graph found by reconciliation, by kind",
+	}, []string{"kind"})
+
+	graphReconciliationRepairs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "graph_reconciliation_repairs_total",
+		Help: "Graph differences repaired by reconciliation, by kind",
+	}, []string{"kind"})
+)
+
+// GraphReconciliationService compares the Neo4j graph with Postgres, which is
+// the source of truth, and optionally repairs the graph. Each run is a streaming
+// job counting one item per user or content item compared.
+//
+// Interactions reach the graph through the outbox, so edges written in the last
+// few seconds may be reported missing; repairs are idempotent merges.
+type GraphReconciliationService struct {
+	db         *database.Database
+	graph      *graph.Repository
+	jobManager *JobManager
+	config     *config.GraphReconciliationConfig
+	logger     *logrus.Logger
+
+	running int32
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func NewGraphReconciliationService(db *database.Database, graphRepo *graph.Repository, jobManager *JobManager, cfg *config.GraphReconciliationConfig, logger *logrus.Logger) *GraphReconciliationService {
+	return &GraphReconciliationService{
+		db:         db,
+		graph:      graphRepo,
+		jobManager: jobManager,
+		config:     cfg,
+		logger:     logger,
+		quit:       make(chan struct{}),
+	}
+}
+
+func (s *GraphReconciliationService) Start(ctx context.Context) {
+	if !s.config.Enabled || s.config.Interval <= 0 {
+		s.logger.Info("Scheduled graph reconciliation disabled")
+		return
+	}
+	if !s.graph.Available() {
+		s.logger.Warn("Scheduled graph reconciliation disabled: Neo4j is not configured")
+		return
+	}
+
+	s.wg.Add(1)
+	go s.schedule(ctx)
+
+	s.logger.WithField("interval", s.config.Interval).Info("Graph reconciliation scheduled")
+}
+
+// Stop ends the schedule and interrupts a running reconciliation between chunks
+func (s *GraphReconciliationService) Stop() {
+	close(s.quit)
+	s.wg.Wait()
+}
+
+// Reconcile starts a run in the background and returns its job. Without repair
+// the run only reports differences.
+func (s *GraphReconciliationService) Reconcile(ctx context.Context, repair bool) (*JobProgress, error) {
+	job, err := s.begin(ctx, repair)
+	if err != nil {
+		return nil, err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer atomic.StoreInt32(&s.running, 0)
+		s.run(context.Background(), job.JobID, repair)
+	}()
+
+	return job, nil
+}
+
+func (s *GraphReconciliationService) schedule(ctx context.Context) {
+	defer s.wg.Done()
+
+	// The first run waits a full interval so restarts do not rescan the graph
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.quit:
+			return
+		case <-ctx.Done():
+			return
+		}
+
+		job, err := s.begin(ctx, s.config.Repair)
+		if err != nil {
+			if !errors.Is(err, ErrReconciliationInProgress) {
+				s.logger.WithError(err).Error("Failed to start scheduled graph reconciliation")
+			}
+			continue
+		}
+		s.run(ctx, job.JobID, s.config.Repair)
+		atomic.StoreInt32(&s.running, 0)
+	}
+}
+
+// begin claims the running flag and creates the run's job. The caller releases
+// the flag once the run ends.
+func (s *GraphReconciliationService) begin(ctx context.Context, repair bool) (*JobProgress, error) {
+	if !s.graph.Available() {
+		return nil, graph.ErrUnavailable
+	}
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return nil, ErrReconciliationInProgress
+	}
+
+	job, err := s.jobManager.CreateStreamingJob(ctx, graphReconciliationJobType, map[string]interface{}{
+		"repair": repair,
+	})
+	if err != nil {
+		atomic.StoreInt32(&s.running, 0)
+		graphReconciliationRuns.WithLabelValues("error").Inc()
+		return nil, fmt.Errorf("failed to create reconciliation job: %w", err)
+	}
+	return job, nil
+}
+
+// reconciliation is the state of one run
+type reconciliation struct {
+	jobID      uuid.UUID
+	report     *models.GraphReconciliationReport
+	maxSamples int
+}
+
+func (rc *reconciliation) issue(issue models.GraphIssue) {
+	rc.report.Issues[issue.Kind]++
+	graphReconciliationIssues.WithLabelValues(issue.Kind).Inc()
+	if len(rc.report.Samples) < rc.maxSamples {
+		rc.report.Samples = append(rc.report.Samples, issue)
+	}
+}
+
+func (rc *reconciliation) repaired(kind string, n int) {
+	if n == 0 {
+		return
+	}
+	rc.report.Repaired[kind] += n
+	graphReconciliationRepairs.WithLabelValues(kind).Add(float64(n))
+}
+
+// run compares content first so that content nodes created by interaction
+// repairs are not reported as stale by the same run
+func (s *GraphReconciliationService) run(ctx context.Context, jobID uuid.UUID, repair bool) {
+	startTime := time.Now()
+	rc := &reconciliation{
+		jobID: jobID,
+		report: &models.GraphReconciliationReport{
+			Repair:    repair,
+			StartedAt: startTime,
+			Scanned:   map[string]int{},
+			Issues:    map[string]int{},
+			Repaired:  map[string]int{},
+			Samples:   []models.GraphIssue{},
+		},
+		maxSamples: s.config.MaxSamples,
+	}
+
+	passes := []func(context.Context, *reconciliation, bool) error{
+		s.reconcileContents,
+		s.reconcileGraphContents,
+		s.reconcileUsers,
+		s.reconcileGraphUsers,
+	}
+
+	var runErr error
+	for _, pass := range passes {
+		if runErr = pass(ctx, rc, repair); runErr != nil {
+			break
+		}
+	}
+
+	completedAt := time.Now()
+	rc.report.CompletedAt = &completedAt
+	details := map[string]interface{}{"reconciliation": rc.report}
+
+	fields := logrus.Fields{
+		"job_id":   jobID,
+		"repair":   repair,
+		"scanned":  rc.report.Scanned,
+		"issues":   rc.report.Issues,
+		"repaired": rc.report.Repaired,
+		"duration": time.Since(startTime),
+	}
+
+	switch {
+	case errors.Is(runErr, ErrJobCancelled):
+		graphReconciliationRuns.WithLabelValues("cancelled").Inc()
+		s.logger.WithFields(fields).Info("Graph reconciliation cancelled")
+	case runErr != nil:
+		graphReconciliationRuns.WithLabelValues("failed").Inc()
+		if err := s.jobManager.AbortJob(ctx, jobID, runErr.Error(), details); err != nil {
+			s.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to fail reconciliation job")
+		}
+		s.logger.WithError(runErr).WithFields(fields).Error("Graph reconciliation failed")
+	default:
+		status := "success"
+		if rc.report.RepairErrors > 0 {
+			status = "partial"
+		}
+		graphReconciliationRuns.WithLabelValues(status).Inc()
+		if err := s.jobManager.SealJob(ctx, jobID, details); err != nil {
+			s.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to seal reconciliation job")
+		}
+		s.logger.WithFields(fields).Info("Graph reconciliation completed")
+	}
+}
+
+// checkpoint stops the run on shutdown or cancellation and sizes the job for
+// the next chunk
+func (s *GraphReconciliationService) checkpoint(ctx context.Context, rc *reconciliation, items int) error {
+	select {
+	case <-s.quit:
+		return errReconciliationStopped
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.jobManager.IsJobCancelled(ctx, rc.jobID) {
+		return ErrJobCancelled
+	}
+	return s.jobManager.ExpandJob(ctx, rc.jobID, items)
+}
+
+// advance counts a compared chunk. A chunk whose repair failed counts as failed.
+func (s *GraphReconciliationService) advance(ctx context.Context, rc *reconciliation, items int, repairErr error) error {
+	if repairErr != nil {
+		rc.report.RepairErrors++
+		s.logger.WithError(repairErr).WithField("job_id", rc.jobID).Warn("Failed to repair graph chunk")
+		return s.jobManager.AdvanceJob(ctx, rc.jobID, 0, items)
+	}
+	return s.jobManager.AdvanceJob(ctx, rc.jobID, items, 0)
+}
+
+func (s *GraphReconciliationService) chunkSize() int {
+	if s.config.ChunkSize > 0 {
+		return s.config.ChunkSize
+	}
+	return 500
+}
+
+// reconcileContents compares content_items with their content nodes
+func (s *GraphReconciliationService) reconcileContents(ctx context.Context, rc *reconciliation, repair bool) error {
+	after := uuid.Nil
+	for {
+		rows, err := s.db.PG.Query(ctx, `
+			SELECT id, type, COALESCE(categories, '{}'), COALESCE(active, true)
+			FROM content_items
+			WHERE id > $1
+			ORDER BY id
+			LIMIT $2`, after, s.chunkSize())
+		if err != nil {
+			return fmt.Errorf("failed to read content items: %w", err)
+		}
+
+		var items []graph.ContentNode
+		for rows.Next() {
+			var item graph.ContentNode
+			if err := rows.Scan(&item.ID, &item.Type, &item.Categories, &item.Active); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan content item: %w", err)
+			}
+			items = append(items, item)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to read content items: %w", err)
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		after = items[len(items)-1].ID
+
+		if err := s.checkpoint(ctx, rc, len(items)); err != nil {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(items))
+		for i, item := range items {
+			ids[i] = item.ID
+		}
+		nodes, err := s.graph.Contents(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("failed to read content nodes: %w", err)
+		}
+
+		var upserts []graph.ContentNode
+		var unlink []uuid.UUID
+		repairs := map[string]int{}
+		for _, item := range items {
+			node, found := nodes[item.ID]
+			for _, issue := range contentIssues(item, node, found) {
+				rc.issue(issue)
+				repairs[issue.Kind]++
+				if issue.Kind == models.GraphIssueDeactivatedContentLinked {
+					unlink = append(unlink, item.ID)
+				} else {
+					upserts = append(upserts, item)
+				}
+			}
+		}
+		rc.report.Scanned["content_items"] += len(items)
+
+		var repairErr error
+		if repair {
+			repairErr = s.graph.UpsertContents(ctx, upserts)
+			if repairErr == nil {
+				repairErr = s.graph.UnlinkSimilarContent(ctx, unlink)
+			}
+			if repairErr == nil {
+				for kind, n := range repairs {
+					rc.repaired(kind, n)
+				}
+			}
+		}
+
+		if err := s.advance(ctx, rc, len(items), repairErr); err != nil {
+			return err
+		}
+	}
+}
+
+// reconcileGraphContents finds content nodes whose item no longer exists
+func (s *GraphReconciliationService) reconcileGraphContents(ctx context.Context, rc *reconciliation, repair bool) error {
+	cursor := ""
+	for {
+		ids, next, err := s.graph.ContentIDsAfter(ctx, cursor, s.chunkSize())
+		if err != nil {
+			return fmt.Errorf("failed to read content nodes: %w", err)
+		}
+
+		if len(ids) > 0 {
+			if err := s.checkpoint(ctx, rc, len(ids)); err != nil {
+				return err
+			}
+
+			known, err := s.existingIDs(ctx, `SELECT id FROM content_items WHERE id = ANY($1)`, ids)
+			if err != nil {
+				return fmt.Errorf("failed to read content items: %w", err)
+			}
+
+			var orphans []uuid.UUID
+			for _, id := range ids {
+				if !known[id] {
+					itemID := id
+					rc.issue(models.GraphIssue{Kind: models.GraphIssueOrphanContentNode, ItemID: &itemID})
+					orphans = append(orphans, id)
+				}
+			}
+			rc.report.Scanned["graph_contents"] += len(ids)
+
+			var repairErr error
+			if repair {
+				if repairErr = s.graph.DeleteContents(ctx, orphans); repairErr == nil {
+					rc.repaired(models.GraphIssueOrphanContentNode, len(orphans))
+				}
+			}
+
+			if err := s.advance(ctx, rc, len(ids), repairErr); err != nil {
+				return err
+			}
+		}
+
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// reconcileUsers compares the interactions of users that have any mirrored to
+// the graph with their relationships
+func (s *GraphReconciliationService) reconcileUsers(ctx context.Context, rc *reconciliation, repair bool) error {
+	after := uuid.Nil
+	for {
+		rows, err := s.db.PG.Query(ctx, `
+			SELECT DISTINCT user_id
+			FROM user_interactions
+			WHERE item_id IS NOT NULL AND interaction_type = ANY($1) AND user_id > $2
+			ORDER BY user_id
+			LIMIT $3`, graphInteractionTypes, after, s.chunkSize())
+		if err != nil {
+			return fmt.Errorf("failed to read interacting users: %w", err)
+		}
+
+		var userIDs []uuid.UUID
+		for rows.Next() {
+			var userID uuid.UUID
+			if err := rows.Scan(&userID); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan user: %w", err)
+			}
+			userIDs = append(userIDs, userID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to read interacting users: %w", err)
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+		after = userIDs[len(userIDs)-1]
+
+		if err := s.checkpoint(ctx, rc, len(userIDs)); err != nil {
+			return err
+		}
+		if err := s.reconcileUserChunk(ctx, rc, userIDs, repair); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *GraphReconciliationService) reconcileUserChunk(ctx context.Context, rc *reconciliation, userIDs []uuid.UUID, repair bool) error {
+	interactions, err := s.mirroredInteractions(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	existing, err := s.graph.ExistingUsers(ctx, userIDs)
+	if err != nil {
+		return fmt.Errorf("failed to read user nodes: %w", err)
+	}
+	actual, err := s.graph.InteractionsOf(ctx, userIDs)
+	if err != nil {
+		return fmt.Errorf("failed to read user relationships: %w", err)
+	}
+
+	var missingUsers int
+	for _, userID := range userIDs {
+		if !existing[userID] {
+			id := userID
+			rc.issue(models.GraphIssue{Kind: models.GraphIssueMissingUserNode, UserID: &id})
+			missingUsers++
+		}
+	}
+
+	missing, extra, stale := diffInteractions(expectedInteractions(interactions), actual)
+	rc.interactionIssues(models.GraphIssueMissingRelationship, missing)
+	rc.interactionIssues(models.GraphIssueExtraRelationship, extra)
+	rc.interactionIssues(models.GraphIssueStaleRating, stale)
+	rc.report.Scanned["users"] += len(userIDs)
+
+	var repairErr error
+	if repair {
+		// Missing user nodes are merged along with their relationships
+		repairErr = s.graph.RecordInteractions(ctx, append(missing, stale...))
+		if repairErr == nil {
+			rc.repaired(models.GraphIssueMissingUserNode, missingUsers)
+			rc.repaired(models.GraphIssueMissingRelationship, len(missing))
+			rc.repaired(models.GraphIssueStaleRating, len(stale))
+			if repairErr = s.graph.DeleteInteractions(ctx, extra); repairErr == nil {
+				rc.repaired(models.GraphIssueExtraRelationship, len(extra))
+			}
+		}
+	}
+
+	return s.advance(ctx, rc, len(userIDs), repairErr)
+}
+
+// reconcileGraphUsers finds user nodes with relationships but no mirrored
+// interactions left in Postgres. The user nodes themselves are kept.
+func (s *GraphReconciliationService) reconcileGraphUsers(ctx context.Context, rc *reconciliation, repair bool) error {
+	cursor := ""
+	for {
+		ids, next, err := s.graph.UserIDsAfter(ctx, cursor, s.chunkSize())
+		if err != nil {
+			return fmt.Errorf("failed to read user nodes: %w", err)
+		}
+
+		if len(ids) > 0 {
+			if err := s.checkpoint(ctx, rc, len(ids)); err != nil {
+				return err
+			}
+
+			known, err := s.existingIDs(ctx, `
+				SELECT DISTINCT user_id FROM user_interactions
+				WHERE user_id = ANY($1) AND item_id IS NOT NULL AND interaction_type = ANY($2)`,
+				ids, graphInteractionTypes)
+			if err != nil {
+				return fmt.Errorf("failed to read interacting users: %w", err)
+			}
+
+			var unknown []uuid.UUID
+			for _, id := range ids {
+				if !known[id] {
+					unknown = append(unknown, id)
+				}
+			}
+
+			var extra []graph.Interaction
+			if len(unknown) > 0 {
+				if extra, err = s.graph.InteractionsOf(ctx, unknown); err != nil {
+					return fmt.Errorf("failed to read user relationships: %w", err)
+				}
+			}
+			rc.interactionIssues(models.GraphIssueExtraRelationship, extra)
+			rc.report.Scanned["graph_users"] += len(ids)
+
+			var repairErr error
+			if repair {
+				if repairErr = s.graph.DeleteInteractions(ctx, extra); repairErr == nil {
+					rc.repaired(models.GraphIssueExtraRelationship, len(extra))
+				}
+			}
+
+			if err := s.advance(ctx, rc, len(ids), repairErr); err != nil {
+				return err
+			}
+		}
+
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func (rc *reconciliation) interactionIssues(kind string, interactions []graph.Interaction) {
+	for _, interaction := range interactions {
+		userID, itemID := interaction.UserID, interaction.ItemID
+		rc.issue(models.GraphIssue{
+			Kind:         kind,
+			UserID:       &userID,
+			ItemID:       &itemID,
+			Relationship: interaction.Type,
+		})
+	}
+}
+
+// mirroredInteractions loads the interactions of the users that are mirrored to
+// the graph, oldest first
+func (s *GraphReconciliationService) mirroredInteractions(ctx context.Context, userIDs []uuid.UUID) ([]models.UserInteraction, error) {
+	rows, err := s.db.PG.Query(ctx, `
+		SELECT user_id, item_id, interaction_type, value, duration, timestamp
+		FROM user_interactions
+		WHERE user_id = ANY($1) AND item_id IS NOT NULL AND interaction_type = ANY($2)
+		ORDER BY timestamp, id`, userIDs, graphInteractionTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read interactions: %w", err)
+	}
+	defer rows.Close()
+
+	var interactions []models.UserInteraction
+	for rows.Next() {
+		var interaction models.UserInteraction
+		if err := rows.Scan(&interaction.UserID, &interaction.ItemID, &interaction.InteractionType,
+			&interaction.Value, &interaction.Duration, &interaction.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan interaction: %w", err)
+		}
+		interactions = append(interactions, interaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read interactions: %w", err)
+	}
+	return interactions, nil
+}
+
+// existingIDs runs a query selecting one UUID column and returns the IDs found
+func (s *GraphReconciliationService) existingIDs(ctx context.Context, query string, args ...interface{}) (map[uuid.UUID]bool, error) {
+	rows, err := s.db.PG.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found[id] = true
+	}
+	return found, rows.Err()
+}
+
+type interactionKey struct {
+	userID  uuid.UUID
+	itemID  uuid.UUID
+	relType string
+}
+
+func keyOf(interaction graph.Interaction) interactionKey {
+	return interactionKey{userID: interaction.UserID, itemID: interaction.ItemID, relType: interaction.Type}
+}
+
+// expectedInteractions replays interactions, oldest first, into the
+// relationships the graph writer would have merged: one per user, item and
+// relationship type, later properties overwriting earlier ones
+func expectedInteractions(interactions []models.UserInteraction) []graph.Interaction {
+	index := make(map[interactionKey]int)
+	var expected []graph.Interaction
+	for i := range interactions {
+		relationship := graphInteraction(&interactions[i])
+		key := keyOf(relationship)
+		if at, ok := index[key]; ok {
+			for k, v := range relationship.Properties {
+				expected[at].Properties[k] = v
+			}
+			continue
+		}
+		index[key] = len(expected)
+		expected = append(expected, relationship)
+	}
+	return expected
+}
+
+// diffInteractions compares expected relationships with those in the graph.
+// Stale relationships exist on both sides with a different rating and are
+// returned with their expected properties.
+func diffInteractions(expected, actual []graph.Interaction) (missing, extra, stale []graph.Interaction) {
+	existing := make(map[interactionKey]graph.Interaction, len(actual))
+	for _, interaction := range actual {
+		existing[keyOf(interaction)] = interaction
+	}
+
+	wanted := make(map[interactionKey]bool, len(expected))
+	for _, interaction := range expected {
+		key := keyOf(interaction)
+		wanted[key] = true
+		current, ok := existing[key]
+		switch {
+		case !ok:
+			missing = append(missing, interaction)
+		case ratingsDiffer(interaction.Properties, current.Properties):
+			stale = append(stale, interaction)
+		}
+	}
+
+	for _, interaction := range actual {
+		if !wanted[keyOf(interaction)] {
+			extra = append(extra, interaction)
+		}
+	}
+	return missing, extra, stale
+}
+
+func ratingsDiffer(expected, actual map[string]interface{}) bool {
+	want, hasWant := expected[graph.PropRating].(float64)
+	got, hasGot := actual[graph.PropRating].(float64)
+	if hasWant != hasGot {
+		return true
+	}
+	return hasWant && math.Abs(want-got) > 1e-9
+}
+
+// contentIssues compares a content item with its graph node
+func contentIssues(item graph.ContentNode, node graph.ContentState, found bool) []models.GraphIssue {
+	itemID := item.ID
+	if !found {
+		return []models.GraphIssue{{Kind: models.GraphIssueMissingContentNode, ItemID: &itemID}}
+	}
+
+	var issues []models.GraphIssue
+	var drift []string
+	if node.Type != item.Type {
+		drift = append(drift, "type")
+	}
+	if !sameCategories(node.Categories, item.Categories) {
+		drift = append(drift, "categories")
+	}
+	if node.Active != item.Active {
+		drift = append(drift, "active")
+	}
+	if len(drift) > 0 {
+		issues = append(issues, models.GraphIssue{
+			Kind:   models.GraphIssueStaleContentNode,
+			ItemID: &itemID,
+			Detail: strings.Join(drift, ","),
+		})
+	}
+
+	if !item.Active && node.SimilarLinks > 0 {
+		issues = append(issues, models.GraphIssue{
+			Kind:   models.GraphIssueDeactivatedContentLinked,
+			ItemID: &itemID,
+			Detail: fmt.Sprintf("%d SIMILAR_TO relationships", node.SimilarLinks),
+		})
+	}
+	return issues
+}
+
+// sameCategories compares category lists ignoring order
+func sameCategories(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
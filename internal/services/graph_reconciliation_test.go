@@ -0,0 +1,170 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/graph"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func storedInteraction(userID, itemID uuid.UUID, interactionType string, value *float64, at time.Time) models.UserInteraction {
+	return models.UserInteraction{
+		UserID:          userID,
+		ItemID:          &itemID,
+		InteractionType: interactionType,
+		Value:           value,
+		Timestamp:       at,
+	}
+}
+
+func TestExpectedInteractions(t *testing.T) {
+	userID, itemID, otherItem := uuid.New(), uuid.New(), uuid.New()
+	start := time.Now().Add(-time.Hour)
+	four, two := 4.0, 2.0
+
+	expected := expectedInteractions([]models.UserInteraction{
+		storedInteraction(userID, itemID, "rating", &four, start),
+		storedInteraction(userID, itemID, "view", nil, start.Add(time.Minute)),
+		storedInteraction(userID, otherItem, "like", nil, start.Add(2*time.Minute)),
+		storedInteraction(userID, itemID, "rating", &two, start.Add(3*time.Minute)),
+		storedInteraction(userID, otherItem, "dislike", nil, start.Add(4*time.Minute)),
+	})
+
+	byKey := make(map[interactionKey]graph.Interaction)
+	for _, interaction := range expected {
+		byKey[keyOf(interaction)] = interaction
+	}
+	require.Len(t, byKey, 3)
+
+	// The latest rating wins, as with SET r += on the live writer
+	rated := byKey[interactionKey{userID, itemID, graph.RelRated}]
+	assert.Equal(t, 2.0, rated.Properties[graph.PropRating])
+
+	disliked := byKey[interactionKey{userID, otherItem, graph.RelRated}]
+	assert.Equal(t, graph.DislikeRating, disliked.Properties[graph.PropRating])
+	assert.Equal(t, "dislike", disliked.Properties[graph.PropInteractionType])
+
+	_, viewed := byKey[interactionKey{userID, itemID, graph.RelViewed}]
+	assert.True(t, viewed)
+}
+
+func TestDiffInteractions(t *testing.T) {
+	userID := uuid.New()
+	kept, missingItem, staleItem, extraItem := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	relationship := func(itemID uuid.UUID, relType string, rating interface{}) graph.Interaction {
+		properties := map[string]interface{}{}
+		if rating != nil {
+			properties[graph.PropRating] = rating
+		}
+		return graph.Interaction{UserID: userID, ItemID: itemID, Type: relType, Properties: properties}
+	}
+
+	expected := []graph.Interaction{
+		relationship(kept, graph.RelRated, 4.0),
+		relationship(missingItem, graph.RelViewed, nil),
+		relationship(staleItem, graph.RelRated, 5.0),
+	}
+	actual := []graph.Interaction{
+		relationship(kept, graph.RelRated, 4.0),
+		relationship(staleItem, graph.RelRated, 3.0),
+		relationship(extraItem, graph.RelShared, nil),
+		// Same pair under another relationship type is a different edge
+		relationship(missingItem, graph.RelInteractedWith, nil),
+	}
+
+	missing, extra, stale := diffInteractions(expected, actual)
+
+	require.Len(t, missing, 1)
+	assert.Equal(t, missingItem, missing[0].ItemID)
+
+	require.Len(t, stale, 1)
+	assert.Equal(t, staleItem, stale[0].ItemID)
+	assert.Equal(t, 5.0, stale[0].Properties[graph.PropRating], "stale edges carry the expected rating")
+
+	require.Len(t, extra, 2)
+	assert.ElementsMatch(t, []uuid.UUID{extraItem, missingItem}, []uuid.UUID{extra[0].ItemID, extra[1].ItemID})
+}
+
+func TestRatingsDiffer(t *testing.T) {
+	rating := func(value interface{}) map[string]interface{} {
+		return map[string]interface{}{graph.PropRating: value}
+	}
+
+	assert.False(t, ratingsDiffer(rating(4.0), rating(4.0)))
+	assert.False(t, ratingsDiffer(map[string]interface{}{}, map[string]interface{}{}))
+	assert.True(t, ratingsDiffer(rating(4.0), rating(3.5)))
+	assert.True(t, ratingsDiffer(rating(4.0), map[string]interface{}{}))
+	assert.True(t, ratingsDiffer(map[string]interface{}{}, rating(4.0)))
+}
+
+func TestContentIssues(t *testing.T) {
+	item := graph.ContentNode{ID: uuid.New(), Type: "product", Categories: []string{"shoes", "sale"}, Active: true}
+
+	t.Run("in sync", func(t *testing.T) {
+		node := graph.ContentState{ContentNode: graph.ContentNode{
+			ID: item.ID, Type: "product", Categories: []string{"sale", "shoes"}, Active: true,
+		}, SimilarLinks: 3}
+		assert.Empty(t, contentIssues(item, node, true))
+	})
+
+	t.Run("missing node", func(t *testing.T) {
+		issues := contentIssues(item, graph.ContentState{}, false)
+		require.Len(t, issues, 1)
+		assert.Equal(t, models.GraphIssueMissingContentNode, issues[0].Kind)
+		assert.Equal(t, item.ID, *issues[0].ItemID)
+	})
+
+	t.Run("stale node", func(t *testing.T) {
+		node := graph.ContentState{ContentNode: graph.ContentNode{
+			ID: item.ID, Type: "article", Categories: []string{"shoes"}, Active: true,
+		}}
+		issues := contentIssues(item, node, true)
+		require.Len(t, issues, 1)
+		assert.Equal(t, models.GraphIssueStaleContentNode, issues[0].Kind)
+		assert.Equal(t, "type,categories", issues[0].Detail)
+	})
+
+	t.Run("deactivated content still linked", func(t *testing.T) {
+		inactive := item
+		inactive.Active = false
+		node := graph.ContentState{ContentNode: inactive, SimilarLinks: 2}
+		issues := contentIssues(inactive, node, true)
+		require.Len(t, issues, 1)
+		assert.Equal(t, models.GraphIssueDeactivatedContentLinked, issues[0].Kind)
+
+		// A node still flagged active is also stale
+		node.Active = true
+		kinds := []string{}
+		for _, issue := range contentIssues(inactive, node, true) {
+			kinds = append(kinds, issue.Kind)
+		}
+		assert.Equal(t, []string{models.GraphIssueStaleContentNode, models.GraphIssueDeactivatedContentLinked}, kinds)
+	})
+}
+
+func TestReconciliation_SamplesAreCapped(t *testing.T) {
+	rc := &reconciliation{
+		report: &models.GraphReconciliationReport{
+			Issues:   map[string]int{},
+			Repaired: map[string]int{},
+		},
+		maxSamples: 2,
+	}
+
+	for i := 0; i < 5; i++ {
+		itemID := uuid.New()
+		rc.issue(models.GraphIssue{Kind: models.GraphIssueOrphanContentNode, ItemID: &itemID})
+	}
+	rc.repaired(models.GraphIssueOrphanContentNode, 5)
+	rc.repaired(models.GraphIssueMissingUserNode, 0)
+
+	assert.Equal(t, 5, rc.report.Issues[models.GraphIssueOrphanContentNode])
+	assert.Len(t, rc.report.Samples, 2)
+	assert.Equal(t, map[string]int{models.GraphIssueOrphanContentNode: 5}, rc.report.Repaired)
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/temcen/pirex/pkg/models"
+)
+
+const (
+	ImportFormatNDJSON = "ndjson"
+	ImportFormatCSV    = "csv"
+
+	// importMetadataPrefix maps a single source column into a metadata key, e.g. "metadata.price"
+	importMetadataPrefix = "metadata."
+)
+
+// importFields are the ContentIngestionRequest fields a column can be mapped to
+var importFields = []string{"external_id", "type", "title", "description", "image_urls", "categories", "metadata"}
+
+// ImportMapping converts source records (CSV rows or NDJSON objects) into ingestion requests
+type ImportMapping struct {
+	fields        map[string]string
+	defaultType   string
+	listSeparator string
+	coerceScalars bool // CSV values are always strings; turn numbers and booleans into typed metadata
+}
+
+// importRowError is a recoverable error affecting a single source row
+type importRowError struct {
+	row   int
+	stage string
+	err   error
+}
+
+func (e *importRowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.row, e.err)
+}
+
+// DefaultImportMapping maps every field to a column with the same name
+func DefaultImportMapping() map[string]string {
+	mapping := make(map[string]string, len(importFields))
+	for _, field := range importFields {
+		mapping[field] = field
+	}
+	return mapping
+}
+
+// NewImportMapping validates a field -> column mapping. Fields missing from the
+// mapping fall back to the column with the same name.
+func NewImportMapping(fields map[string]string, defaultType, listSeparator string, coerceScalars bool) (*ImportMapping, error) {
+	merged := DefaultImportMapping()
+	for field, column := range fields {
+		if !isImportField(field) {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrImportMappingInvalid, field)
+		}
+		if strings.TrimSpace(column) == "" {
+			return nil, fmt.Errorf("%w: field %q has an empty column", ErrImportMappingInvalid, field)
+		}
+		merged[field] = column
+	}
+
+	if listSeparator == "" {
+		listSeparator = "|"
+	}
+
+	return &ImportMapping{
+		fields:        merged,
+		defaultType:   defaultType,
+		listSeparator: listSeparator,
+		coerceScalars: coerceScalars,
+	}, nil
+}
+
+// Apply builds an ingestion request from a source record. Validation of the
+// result is left to the caller.
+func (m *ImportMapping) Apply(record map[string]interface{}) (models.ContentIngestionRequest, error) {
+	var request models.ContentIngestionRequest
+
+	if externalID := importString(record[m.fields["external_id"]]); externalID != "" {
+		request.ExternalID = &externalID
+	}
+
+	request.Type = strings.ToLower(importString(record[m.fields["type"]]))
+	if request.Type == "" {
+		request.Type = m.defaultType
+	}
+
+	request.Title = importString(record[m.fields["title"]])
+
+	if description := importString(record[m.fields["description"]]); description != "" {
+		request.Description = &description
+	}
+
+	request.ImageURLs = importList(record[m.fields["image_urls"]], m.listSeparator)
+	request.Categories = importList(record[m.fields["categories"]], m.listSeparator)
+
+	metadata, err := importMetadata(record[m.fields["metadata"]])
+	if err != nil {
+		return request, err
+	}
+
+	for field, column := range m.fields {
+		if !strings.HasPrefix(field, importMetadataPrefix) {
+			continue
+		}
+		value, ok := record[column]
+		if !ok || value == nil || value == "" {
+			continue
+		}
+		if m.coerceScalars {
+			value = coerceImportScalar(value)
+		}
+		if metadata == nil {
+			metadata = make(map[string]interface{})
+		}
+		metadata[strings.TrimPrefix(field, importMetadataPrefix)] = value
+	}
+	request.Metadata = metadata
+
+	return request, nil
+}
+
+func isImportField(field string) bool {
+	if strings.HasPrefix(field, importMetadataPrefix) {
+		return len(field) > len(importMetadataPrefix)
+	}
+	for _, known := range importFields {
+		if field == known {
+			return true
+		}
+	}
+	return false
+}
+
+func importString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
+
+func importList(value interface{}, separator string) []string {
+	var parts []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			parts = append(parts, importString(item))
+		}
+	case string:
+		parts = strings.Split(v, separator)
+	default:
+		return nil
+	}
+
+	list := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
+// importMetadata accepts a JSON object (NDJSON) or a JSON-encoded object string (CSV)
+func importMetadata(value interface{}) (map[string]interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return v, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil, nil
+		}
+		var metadata map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &metadata); err != nil {
+			return nil, fmt.Errorf("metadata column is not a JSON object: %w", err)
+		}
+		return metadata, nil
+	default:
+		return nil, fmt.Errorf("metadata must be an object, got %T", value)
+	}
+}
+
+func coerceImportScalar(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	s = strings.TrimSpace(s)
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	if b, err := strconv.ParseBool(s); err == nil && (s == "true" || s == "false") {
+		return b
+	}
+	return s
+}
+
+// importRecordReader yields source records one at a time. Recoverable problems
+// with a single row are returned as *importRowError; any other error is fatal.
+type importRecordReader interface {
+	Next() (record map[string]interface{}, row int, err error)
+}
+
+func newImportRecordReader(format string, r io.Reader, delimiter rune, maxLineBytes int) (importRecordReader, error) {
+	switch format {
+	case ImportFormatNDJSON:
+		// The token limit is the larger of the buffer capacity and max, so size both
+		initial := 64 * 1024
+		if maxLineBytes < initial {
+			initial = maxLineBytes
+		}
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, initial), maxLineBytes)
+		return &ndjsonRecordReader{scanner: scanner}, nil
+	case ImportFormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.ReuseRecord = false
+		if delimiter != 0 {
+			reader.Comma = delimiter
+		}
+
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		if len(header) > 0 {
+			header[0] = strings.TrimPrefix(header[0], "\ufeff")
+		}
+		for i := range header {
+			header[i] = strings.TrimSpace(header[i])
+		}
+
+		return &csvRecordReader{reader: reader, header: header}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrImportFormatUnknown, format)
+	}
+}
+
+type ndjsonRecordReader struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+func (r *ndjsonRecordReader) Next() (map[string]interface{}, int, error) {
+	for r.scanner.Scan() {
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		r.row++
+
+		var record map[string]interface{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, r.row, &importRowError{row: r.row, stage: "parse", err: err}
+		}
+		return record, r.row, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, r.row, fmt.Errorf("failed to read NDJSON after row %d: %w", r.row, err)
+	}
+	return nil, r.row, io.EOF
+}
+
+type csvRecordReader struct {
+	reader *csv.Reader
+	header []string
+	row    int
+}
+
+func (r *csvRecordReader) Next() (map[string]interface{}, int, error) {
+	fields, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, r.row, io.EOF
+	}
+	r.row++
+
+	if err != nil {
+		if _, ok := err.(*csv.ParseError); ok {
+			return nil, r.row, &importRowError{row: r.row, stage: "parse", err: err}
+		}
+		return nil, r.row, fmt.Errorf("failed to read CSV after row %d: %w", r.row, err)
+	}
+
+	if len(fields) != len(r.header) {
+		return nil, r.row, &importRowError{
+			row:   r.row,
+			stage: "parse",
+			err:   fmt.Errorf("expected %d columns, got %d", len(r.header), len(fields)),
+		}
+	}
+
+	record := make(map[string]interface{}, len(fields))
+	for i, value := range fields {
+		record[r.header[i]] = value
+	}
+	return record, r.row, nil
+}
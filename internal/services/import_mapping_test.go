@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+)
+
+func TestImportMapping_Apply(t *testing.T) {
+	mapping, err := NewImportMapping(map[string]string{
+		"external_id":    "sku",
+		"title":          "name",
+		"categories":     "cats",
+		"metadata.price": "price",
+		"metadata.brand": "brand",
+	}, "product", "|", true)
+	require.NoError(t, err)
+
+	item, err := mapping.Apply(map[string]interface{}{
+		"sku":         "SKU-1",
+		"name":        "  Trail Running Shoe ",
+		"description": "Lightweight shoe",
+		"cats":        "Sports| Shoes ||",
+		"image_urls":  "https://example.com/a.jpg|https://example.com/b.jpg",
+		"price":       "89.90",
+		"brand":       "Acme",
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, item.ExternalID)
+	assert.Equal(t, "SKU-1", *item.ExternalID)
+	assert.Equal(t, "product", item.Type, "default type applies when the column is missing")
+	assert.Equal(t, "Trail Running Shoe", item.Title)
+	require.NotNil(t, item.Description)
+	assert.Equal(t, "Lightweight shoe", *item.Description)
+	assert.Equal(t, []string{"Sports", "Shoes"}, item.Categories)
+	assert.Len(t, item.ImageURLs, 2)
+	assert.Equal(t, 89.90, item.Metadata["price"])
+	assert.Equal(t, "Acme", item.Metadata["brand"])
+}
+
+func TestImportMapping_ApplyNDJSONValues(t *testing.T) {
+	mapping, err := NewImportMapping(nil, "", "|", false)
+	require.NoError(t, err)
+
+	item, err := mapping.Apply(map[string]interface{}{
+		"external_id": float64(42),
+		"type":        "Article",
+		"title":       "Release notes",
+		"categories":  []interface{}{"News", "", "Tech"},
+		"metadata":    map[string]interface{}{"author": "Jane"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "42", *item.ExternalID)
+	assert.Equal(t, "article", item.Type)
+	assert.Equal(t, []string{"News", "Tech"}, item.Categories)
+	assert.Equal(t, "Jane", item.Metadata["author"])
+	assert.Nil(t, item.Description)
+}
+
+func TestImportMapping_Errors(t *testing.T) {
+	_, err := NewImportMapping(map[string]string{"price": "price"}, "", "", false)
+	assert.True(t, errors.Is(err, ErrImportMappingInvalid))
+
+	_, err = NewImportMapping(map[string]string{"title": " "}, "", "", false)
+	assert.True(t, errors.Is(err, ErrImportMappingInvalid))
+
+	mapping, err := NewImportMapping(nil, "", "", false)
+	require.NoError(t, err)
+	_, err = mapping.Apply(map[string]interface{}{"title": "x", "metadata": "not json"})
+	assert.Error(t, err)
+}
+
+func TestImportRecordReader_NDJSON(t *testing.T) {
+	input := `{"title": "First"}
+
+not json
+{"title": "Third"}
+`
+	reader, err := newImportRecordReader(ImportFormatNDJSON, strings.NewReader(input), 0, 1024)
+	require.NoError(t, err)
+
+	record, row, err := reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, 1, row)
+	assert.Equal(t, "First", record["title"])
+
+	_, row, err = reader.Next()
+	var rowErr *importRowError
+	require.True(t, errors.As(err, &rowErr))
+	assert.Equal(t, 2, row)
+	assert.Equal(t, "parse", rowErr.stage)
+
+	record, row, err = reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, 3, row)
+	assert.Equal(t, "Third", record["title"])
+
+	_, _, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestImportRecordReader_NDJSONLineTooLong(t *testing.T) {
+	input := `{"title": "` + strings.Repeat("x", 200) + `"}`
+	reader, err := newImportRecordReader(ImportFormatNDJSON, strings.NewReader(input), 0, 64)
+	require.NoError(t, err)
+
+	_, _, err = reader.Next()
+	require.Error(t, err)
+	var rowErr *importRowError
+	assert.False(t, errors.As(err, &rowErr), "an over-long line stops the import")
+}
+
+func TestImportRecordReader_CSV(t *testing.T) {
+	input := "\ufefftitle;type;price\nShoe;product;10\nBroken;row\n\"Hat\";product;5\n"
+	reader, err := newImportRecordReader(ImportFormatCSV, strings.NewReader(input), ';', 1024)
+	require.NoError(t, err)
+
+	record, row, err := reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, 1, row)
+	assert.Equal(t, "Shoe", record["title"], "byte order mark is stripped from the header")
+	assert.Equal(t, "10", record["price"])
+
+	_, row, err = reader.Next()
+	var rowErr *importRowError
+	require.True(t, errors.As(err, &rowErr))
+	assert.Equal(t, 2, row)
+
+	record, _, err = reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "Hat", record["title"])
+
+	_, _, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestImportRecordReader_UnknownFormat(t *testing.T) {
+	_, err := newImportRecordReader("xml", strings.NewReader(""), 0, 1024)
+	assert.True(t, errors.Is(err, ErrImportFormatUnknown))
+}
+
+func TestDetectImportFormat(t *testing.T) {
+	assert.Equal(t, ImportFormatNDJSON, detectImportFormat("catalog.ndjson"))
+	assert.Equal(t, ImportFormatNDJSON, detectImportFormat("/exports/catalog.JSONL"))
+	assert.Equal(t, ImportFormatCSV, detectImportFormat("catalog.csv"))
+	assert.Equal(t, "", detectImportFormat("catalog.xlsx"))
+}
+
+func TestBulkImporter_ResolveLocalPath(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "catalog.csv"), []byte("title\n"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(root, "nested"), 0o700))
+
+	outside := filepath.Join(t.TempDir(), "secret.csv")
+	require.NoError(t, os.WriteFile(outside, []byte("title\n"), 0o600))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "linked.csv")))
+
+	logger := logrus.New()
+	importer := NewBulkImporter(nil, nil, &config.BulkImportConfig{LocalRoot: root}, logger)
+
+	path, err := importer.resolveLocalPath("catalog.csv")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "catalog.csv"), path)
+
+	_, err = importer.resolveLocalPath("../etc/passwd")
+	assert.True(t, errors.Is(err, ErrImportSourceNotAllowed))
+
+	_, err = importer.resolveLocalPath("/etc/passwd")
+	assert.True(t, errors.Is(err, ErrImportSourceNotAllowed))
+
+	_, err = importer.resolveLocalPath("nested")
+	assert.True(t, errors.Is(err, ErrImportSourceNotAllowed))
+
+	// A symlink inside the root must not lead outside it
+	_, err = importer.resolveLocalPath("linked.csv")
+	assert.True(t, errors.Is(err, ErrImportSourceNotAllowed))
+
+	disabled := NewBulkImporter(nil, nil, &config.BulkImportConfig{}, logger)
+	_, err = disabled.resolveLocalPath("catalog.csv")
+	assert.True(t, errors.Is(err, ErrImportSourceNotAllowed))
+}
+
+func TestBulkImporter_ValidateSourceURL(t *testing.T) {
+	logger := logrus.New()
+	importer := NewBulkImporter(nil, nil, &config.BulkImportConfig{AllowURLSources: true}, logger)
+
+	_, err := importer.validateSourceURL("https://bucket.example.com/catalog.ndjson")
+	assert.NoError(t, err)
+
+	_, err = importer.validateSourceURL("file:///etc/passwd")
+	assert.True(t, errors.Is(err, ErrImportSourceNotAllowed))
+
+	for _, internal := range []string{
+		"http://169.254.169.254/latest/meta-data/",
+		"http://localhost:8080/catalog.ndjson",
+		"http://10.0.0.5/catalog.ndjson",
+	} {
+		_, err = importer.validateSourceURL(internal)
+		assert.True(t, errors.Is(err, ErrImportSourceNotAllowed), internal)
+	}
+
+	// Hostnames are checked when the connection is made
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"title": "internal"}`))
+	}))
+	defer server.Close()
+	_, err = importer.fetch(context.Background(), server.URL)
+	assert.True(t, errors.Is(err, ErrImportSourceNotAllowed))
+
+	disabled := NewBulkImporter(nil, nil, &config.BulkImportConfig{}, logger)
+	_, err = disabled.validateSourceURL("https://bucket.example.com/catalog.ndjson")
+	assert.True(t, errors.Is(err, ErrImportSourceNotAllowed))
+}
+
+func TestBulkImporter_MapRow(t *testing.T) {
+	logger := logrus.New()
+	importer := NewBulkImporter(nil, nil, &config.BulkImportConfig{}, logger)
+	mapping, err := NewImportMapping(nil, "", "|", true)
+	require.NoError(t, err)
+
+	_, itemErr := importer.mapRow(mapping, map[string]interface{}{"type": "product", "title": "Valid"}, 1)
+	assert.Nil(t, itemErr)
+
+	_, itemErr = importer.mapRow(mapping, map[string]interface{}{"external_id": "X-1", "type": "gadget", "title": "Bad type"}, 7)
+	require.NotNil(t, itemErr)
+	assert.Equal(t, 7, itemErr.Row)
+	assert.Equal(t, "X-1", itemErr.ExternalID)
+	assert.Equal(t, "validation", itemErr.Stage)
+}
+
+func TestAwaitImportCapacity_CancelledDuringBackpressure(t *testing.T) {
+	var cancelled atomic.Bool
+	getJob := func(ctx context.Context) (*JobProgress, error) {
+		job := &JobProgress{Status: JobStatusProcessing, TotalItems: 100, ProcessedItems: 10}
+		if cancelled.Load() {
+			// Items of a cancelled job are dropped, never processed
+			job.Status = JobStatusCancelled
+		}
+		return job, nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- awaitImportCapacity(context.Background(), getJob, 50, time.Millisecond)
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("returned before the backlog drained: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancelled.Store(true)
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrJobCancelled)
+	case <-time.After(time.Second):
+		t.Fatal("import kept waiting after its job was cancelled")
+	}
+}
+
+func TestBulkImporter_StopsReadingCancelledJob(t *testing.T) {
+	importer := NewBulkImporter(nil, nil, &config.BulkImportConfig{}, logrus.New())
+	jobID := uuid.New()
+	full := func(ctx context.Context) (*JobProgress, error) {
+		return &JobProgress{JobID: jobID, Status: JobStatusProcessing, TotalItems: 100}, nil
+	}
+
+	readCtx := importer.track(context.Background(), jobID)
+	defer importer.untrack(jobID)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- awaitImportCapacity(readCtx, full, 50, time.Hour)
+	}()
+
+	// The finished hook fires when the job is cancelled on this instance
+	importer.stopCancelled(&JobProgress{JobID: jobID, Status: JobStatusCancelled})
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrJobCancelled)
+	case <-time.After(time.Second):
+		t.Fatal("import kept waiting after its job was cancelled")
+	}
+}
+
+func TestJobPendingItems(t *testing.T) {
+	job := &JobProgress{TotalItems: 100, ProcessedItems: 30, FailedItems: 10}
+	assert.Equal(t, 60, jobPendingItems(job))
+
+	// Counts read back from Redis are JSON numbers
+	job.Details = map[string]interface{}{jobDetailDroppedItems: float64(60)}
+	assert.Equal(t, 0, jobPendingItems(job))
+}
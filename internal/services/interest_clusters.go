@@ -0,0 +1,272 @@
+package services
+
+import (
+	"math"
+	"sort"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// interestCategories is the number of categories kept per interest
+const interestCategories = 3
+
+// interestPoint is an interacted item in the profile window
+type interestPoint struct {
+	Embedding  []float32
+	Weight     float64 // Interaction weight with recency decay
+	Categories []string
+}
+
+// clusterInterests groups the interacted items into interest vectors with
+// weighted spherical k-means: items are compared by cosine similarity and
+// each centroid is the weighted mean of its items. The number of interests
+// grows with the number of items; an interest holding too little of the
+// user's weight or too few items, or lighter than a near-identical one, is
+// dropped and its items go to the nearest remaining one. Interests are
+// returned strongest first.
+func clusterInterests(points []interestPoint, cfg config.InterestConfig) []models.InterestVector {
+	points = normalizedPoints(points)
+	if len(points) == 0 {
+		return nil
+	}
+
+	minItems := cfg.MinItems
+	if minItems <= 0 {
+		minItems = 5
+	}
+	iterations := cfg.Iterations
+	if iterations <= 0 {
+		iterations = 10
+	}
+	maxSimilarity := cfg.MaxSimilarity
+	if maxSimilarity <= 0 {
+		maxSimilarity = 0.9
+	}
+	k := len(points) / minItems
+	if k > cfg.MaxInterests {
+		k = cfg.MaxInterests
+	}
+	if k < 1 {
+		k = 1
+	}
+
+	centroids := initialCentroids(points, k)
+	var assignment []int
+	for {
+		assignment = lloyd(points, centroids, iterations)
+		if len(centroids) == 1 {
+			break
+		}
+
+		weakest := weakestInterest(points, assignment, len(centroids), minItems, cfg.MinShare)
+		if weakest < 0 {
+			weakest = redundantInterest(points, assignment, centroids, maxSimilarity)
+		}
+		if weakest < 0 {
+			break
+		}
+		centroids = append(centroids[:weakest], centroids[weakest+1:]...)
+	}
+
+	return summarizeInterests(points, assignment, centroids)
+}
+
+// normalizedPoints drops points without weight or with another dimension than
+// the first, and L2 normalizes the embeddings
+func normalizedPoints(points []interestPoint) []interestPoint {
+	var normalized []interestPoint
+	for _, point := range points {
+		if point.Weight <= 0 || len(point.Embedding) == 0 {
+			continue
+		}
+		if len(normalized) > 0 && len(point.Embedding) != len(normalized[0].Embedding) {
+			continue
+		}
+		point.Embedding = normalizedVector(point.Embedding)
+		normalized = append(normalized, point)
+	}
+	return normalized
+}
+
+// initialCentroids seeds k-means deterministically: the heaviest item first,
+// then repeatedly the item that is heaviest relative to its similarity to the
+// centroids chosen so far
+func initialCentroids(points []interestPoint, k int) [][]float32 {
+	first := 0
+	for i, point := range points {
+		if point.Weight > points[first].Weight {
+			first = i
+		}
+	}
+	centroids := [][]float32{points[first].Embedding}
+
+	for len(centroids) < k {
+		best, bestScore := -1, 0.0
+		for i, point := range points {
+			_, similarity := nearestCentroid(point.Embedding, centroids)
+			if score := point.Weight * (1 - similarity); score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break // Every item coincides with a centroid
+		}
+		centroids = append(centroids, points[best].Embedding)
+	}
+	return centroids
+}
+
+// lloyd alternates assigning items to their nearest centroid and moving each
+// centroid to the weighted mean of its items, updating centroids in place. An
+// interest left without items keeps its centroid.
+func lloyd(points []interestPoint, centroids [][]float32, iterations int) []int {
+	assignment := make([]int, len(points))
+	for iteration := 0; iteration < iterations; iteration++ {
+		changed := iteration == 0
+		for i, point := range points {
+			nearest, _ := nearestCentroid(point.Embedding, centroids)
+			if nearest != assignment[i] {
+				assignment[i] = nearest
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		sums := make([][]float32, len(centroids))
+		for i, point := range points {
+			cluster := assignment[i]
+			if sums[cluster] == nil {
+				sums[cluster] = make([]float32, len(point.Embedding))
+			}
+			for j, value := range point.Embedding {
+				sums[cluster][j] += float32(point.Weight) * value
+			}
+		}
+		for cluster, sum := range sums {
+			if sum != nil {
+				centroids[cluster] = normalizedVector(sum)
+			}
+		}
+	}
+	return assignment
+}
+
+// weakestInterest returns the interest with the least weight among those
+// below minShare or minItems, or -1 when every interest is strong enough
+func weakestInterest(points []interestPoint, assignment []int, clusters, minItems int, minShare float64) int {
+	weights := make([]float64, clusters)
+	counts := make([]int, clusters)
+	var total float64
+	for i, point := range points {
+		weights[assignment[i]] += point.Weight
+		counts[assignment[i]]++
+		total += point.Weight
+	}
+
+	weakest := -1
+	for cluster := range weights {
+		if weights[cluster]/total >= minShare && counts[cluster] >= minItems {
+			continue
+		}
+		if weakest < 0 || weights[cluster] < weights[weakest] {
+			weakest = cluster
+		}
+	}
+	return weakest
+}
+
+// redundantInterest returns the lighter interest of the most similar pair of
+// centroids above maxSimilarity, or -1 when the interests are distinct enough
+func redundantInterest(points []interestPoint, assignment []int, centroids [][]float32, maxSimilarity float64) int {
+	weights := make([]float64, len(centroids))
+	for i, point := range points {
+		weights[assignment[i]] += point.Weight
+	}
+
+	redundant, best := -1, maxSimilarity
+	for i := range centroids {
+		for j := i + 1; j < len(centroids); j++ {
+			_, similarity := nearestCentroid(centroids[i], centroids[j:j+1])
+			if similarity <= best {
+				continue
+			}
+			best = similarity
+			redundant = i
+			if weights[j] < weights[i] {
+				redundant = j
+			}
+		}
+	}
+	return redundant
+}
+
+// summarizeInterests summarizes each cluster, strongest first
+func summarizeInterests(points []interestPoint, assignment []int, centroids [][]float32) []models.InterestVector {
+	interests := make([]models.InterestVector, len(centroids))
+	categoryWeights := make([]map[string]float64, len(centroids))
+	var total float64
+	for i := range interests {
+		interests[i].Vector = centroids[i]
+		categoryWeights[i] = make(map[string]float64)
+	}
+	for i, point := range points {
+		cluster := assignment[i]
+		interests[cluster].Weight += point.Weight
+		interests[cluster].ItemCount++
+		total += point.Weight
+		for _, category := range point.Categories {
+			categoryWeights[cluster][category] += point.Weight
+		}
+	}
+
+	var result []models.InterestVector
+	for i, interest := range interests {
+		if interest.ItemCount == 0 {
+			continue
+		}
+		interest.Weight /= total
+		interest.Categories = topCategories(categoryWeights[i], interestCategories)
+		result = append(result, interest)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Weight > result[j].Weight
+	})
+	return result
+}
+
+// nearestCentroid returns the index of the most similar centroid and the
+// cosine similarity of the normalized vectors
+func nearestCentroid(vector []float32, centroids [][]float32) (int, float64) {
+	nearest, best := 0, math.Inf(-1)
+	for i, centroid := range centroids {
+		var similarity float64
+		for j, value := range vector {
+			similarity += float64(value) * float64(centroid[j])
+		}
+		if similarity > best {
+			nearest, best = i, similarity
+		}
+	}
+	return nearest, best
+}
+
+// topCategories returns the n categories with the most weight
+func topCategories(weights map[string]float64, n int) []string {
+	categories := make([]string, 0, len(weights))
+	for category := range weights {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if weights[categories[i]] != weights[categories[j]] {
+			return weights[categories[i]] > weights[categories[j]]
+		}
+		return categories[i] < categories[j]
+	})
+	if len(categories) > n {
+		categories = categories[:n]
+	}
+	return categories
+}
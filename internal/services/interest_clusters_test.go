@@ -0,0 +1,111 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func interestTestConfig() config.InterestConfig {
+	return config.InterestConfig{MaxInterests: 4, MinItems: 2, MinShare: 0.1, MaxSimilarity: 0.9, Iterations: 10}
+}
+
+func TestClusterInterests(t *testing.T) {
+	var points []interestPoint
+	for i := 0; i < 4; i++ {
+		points = append(points, interestPoint{Embedding: []float32{1, 0.05 * float32(i), 0}, Weight: 1, Categories: []string{"hiking"}})
+	}
+	for i := 0; i < 2; i++ {
+		points = append(points, interestPoint{Embedding: []float32{0, 0.05 * float32(i), 1}, Weight: 1, Categories: []string{"cooking", "books"}})
+	}
+
+	interests := clusterInterests(points, interestTestConfig())
+	require.Len(t, interests, 2)
+
+	assert.Equal(t, 4, interests[0].ItemCount, "the strongest interest comes first")
+	assert.InDelta(t, 4.0/6, interests[0].Weight, 1e-9)
+	assert.Equal(t, []string{"hiking"}, interests[0].Categories)
+	assert.Greater(t, interests[0].Vector[0], float32(0.9))
+
+	assert.Equal(t, 2, interests[1].ItemCount)
+	assert.InDelta(t, 2.0/6, interests[1].Weight, 1e-9)
+	assert.Equal(t, []string{"books", "cooking"}, interests[1].Categories)
+	assert.Greater(t, interests[1].Vector[2], float32(0.9))
+}
+
+func TestClusterInterests_MergesWeakInterests(t *testing.T) {
+	var points []interestPoint
+	for i := 0; i < 5; i++ {
+		points = append(points, interestPoint{Embedding: []float32{1, 0.05 * float32(i), 0}, Weight: 1})
+	}
+	// A distinct but barely used interest holds too little of the weight
+	points = append(points,
+		interestPoint{Embedding: []float32{0, 0, 1}, Weight: 0.1},
+		interestPoint{Embedding: []float32{0, 0.05, 1}, Weight: 0.1},
+	)
+
+	interests := clusterInterests(points, interestTestConfig())
+	require.Len(t, interests, 1)
+	assert.Equal(t, 7, interests[0].ItemCount)
+	assert.InDelta(t, 1.0, interests[0].Weight, 1e-9)
+}
+
+func TestClusterInterests_FewItems(t *testing.T) {
+	cfg := interestTestConfig()
+	cfg.MinItems = 5
+
+	points := []interestPoint{
+		{Embedding: []float32{1, 0}, Weight: 1},
+		{Embedding: []float32{0, 1}, Weight: 1},
+		{Embedding: []float32{0, 1, 0}, Weight: 1}, // Other dimension
+		{Embedding: []float32{1, 1}, Weight: 0},    // No weight
+	}
+	interests := clusterInterests(points, cfg)
+	require.Len(t, interests, 1, "too few items for more than one interest")
+	assert.Equal(t, 2, interests[0].ItemCount)
+
+	assert.Nil(t, clusterInterests(nil, cfg))
+}
+
+func TestAllocateInterestSlots(t *testing.T) {
+	assert.Equal(t, []int{6, 3, 1}, allocateInterestSlots([]float64{0.6, 0.3, 0.1}, 10))
+	assert.Equal(t, []int{8, 1, 1}, allocateInterestSlots([]float64{0.98, 0.01, 0.01}, 10), "every interest gets a slot")
+	assert.Equal(t, []int{1, 1, 0}, allocateInterestSlots([]float64{0.5, 0.3, 0.2}, 2))
+	assert.Equal(t, []int{2, 2}, allocateInterestSlots([]float64{0, 0}, 4))
+	assert.Equal(t, []int{0, 0}, allocateInterestSlots([]float64{0.5, 0.5}, 0))
+
+	for _, limit := range []int{1, 7, 13, 40} {
+		total := 0
+		for _, slots := range allocateInterestSlots([]float64{0.5, 0.25, 0.15, 0.1}, limit) {
+			total += slots
+		}
+		assert.Equal(t, limit, total)
+	}
+}
+
+func TestMergeInterestResults(t *testing.T) {
+	a1, a2, a3, shared, b1, b2 := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	lists := [][]models.ScoredItem{
+		{{ItemID: a1, Score: 0.95}, {ItemID: shared, Score: 0.9}, {ItemID: a2, Score: 0.85}, {ItemID: a3, Score: 0.8}},
+		{{ItemID: shared, Score: 0.7}, {ItemID: b1, Score: 0.65}, {ItemID: b2, Score: 0.6}},
+	}
+
+	merged := mergeInterestResults(lists, []int{2, 2}, 4)
+	require.Len(t, merged, 4)
+
+	ids := make([]uuid.UUID, len(merged))
+	for i, item := range merged {
+		ids[i] = item.ItemID
+	}
+	assert.Equal(t, []uuid.UUID{a1, shared, b1, b2}, ids, "the weaker interest keeps its slots despite lower scores")
+
+	// Slots an interest cannot fill go to the best remaining candidates
+	merged = mergeInterestResults([][]models.ScoredItem{lists[0], nil}, []int{2, 2}, 4)
+	require.Len(t, merged, 4)
+	assert.Equal(t, a3, merged[3].ItemID)
+}
@@ -15,13 +15,19 @@ type UserInteractionServiceInterface interface {
 	RecordBatchInteractions(ctx context.Context, req *models.InteractionBatchRequest) ([]models.UserInteraction, error)
 	GetUserInteractions(ctx context.Context, userID uuid.UUID, interactionType string, limit, offset int, startDate, endDate *time.Time) ([]models.UserInteraction, int, error)
 	GetUserProfile(ctx context.Context, userID uuid.UUID) (*models.UserProfile, error)
+	UpdateExplicitPreferences(ctx context.Context, userID uuid.UUID, req *models.ProfileUpdateRequest) (*models.UserProfile, error)
 	GetSimilarUsers(ctx context.Context, userID uuid.UUID, limit int) ([]models.SimilarUser, error)
 	Stop()
 }
 
+// SearchServiceInterface defines the interface for hybrid search
+type SearchServiceInterface interface {
+	Search(ctx context.Context, req *SearchRequest) (*models.SearchResponse, error)
+}
+
 // RecommendationAlgorithmsServiceInterface defines the interface for recommendation algorithms
 type RecommendationAlgorithmsServiceInterface interface {
-	SemanticSearchRecommendations(ctx context.Context, userID uuid.UUID, userEmbedding []float32, contentTypes []string, categories []string, limit int) ([]models.ScoredItem, error)
+	SemanticSearchRecommendations(ctx context.Context, userID uuid.UUID, interests []models.InterestVector, contentTypes []string, categories []string, limit int) ([]models.ScoredItem, error)
 	CollaborativeFilteringRecommendations(ctx context.Context, userID uuid.UUID, limit int) ([]models.ScoredItem, error)
 	PersonalizedPageRankRecommendations(ctx context.Context, userID uuid.UUID, limit int) ([]models.ScoredItem, error)
 	GraphSignalAnalysisRecommendations(ctx context.Context, userID uuid.UUID, limit int) ([]models.ScoredItem, error)
@@ -2,20 +2,37 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 
+	"github.com/temcen/pirex/internal/config"
 	"github.com/temcen/pirex/internal/database"
+	"github.com/temcen/pirex/pkg/models"
 )
 
 type JobManager struct {
 	db     *database.Database
+	config *config.IngestionConfig
 	logger *logrus.Logger
+
+	// mu serialises read-modify-write updates of job counters and details from
+	// concurrent workers
+	mu sync.Mutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+
+	finishedHooks []func(job *JobProgress)
 }
 
 type JobProgress struct {
@@ -40,14 +57,107 @@ const (
 	JobStatusCancelled  = "cancelled"
 )
 
-func NewJobManager(db *database.Database, logger *logrus.Logger) *JobManager {
+var (
+	ErrJobNotFound       = errors.New("job not found")
+	ErrJobCancelled      = errors.New("job cancelled")
+	ErrJobNotCancellable = errors.New("job has already finished")
+	ErrJobNotRetryable   = errors.New("job is still running or was cancelled")
+	ErrNoRetryableItems  = errors.New("job has no retryable failed items")
+	ErrInvalidJobCursor  = errors.New("invalid job cursor")
+)
+
+func NewJobManager(db *database.Database, cfg *config.IngestionConfig, logger *logrus.Logger) *JobManager {
 	return &JobManager{
 		db:     db,
+		config: cfg,
 		logger: logger,
+		quit:   make(chan struct{}),
 	}
 }
 
+// ItemError describes why a single item of a job could not be ingested
+type ItemError struct {
+	Row        int    `json:"row,omitempty"` // 1-based source row for imports or position in a batch
+	ExternalID string `json:"external_id,omitempty"`
+	Stage      string `json:"stage"` // parse, mapping, validation, publish or a pipeline stage
+	Message    string `json:"message"`
+
+	Fields []models.FieldError `json:"fields,omitempty"` // Per-field validation errors
+}
+
+// Item outcome statuses
+const (
+	ItemStatusSucceeded = "succeeded"
+	ItemStatusFailed    = "failed"
+)
+
+// FailedItem keeps the payload of an item that failed after it was accepted so
+// that it can be re-published by a retry
+type FailedItem struct {
+	Error ItemError                      `json:"error"`
+	Item  models.ContentIngestionRequest `json:"item"`
+	Hints map[string]interface{}         `json:"hints,omitempty"`
+}
+
+// JobListFilter selects jobs for ListJobs. Zero values are not filtered on.
+type JobListFilter struct {
+	Status        string
+	JobType       string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Cursor        string
+	Limit         int
+
+	IncludeAdminJobs bool // List jobs of adminJobTypes too
+}
+
+// adminJobTypes are listed only for admins: a user deletion job names the
+// deleted user
+var adminJobTypes = []string{userDeletionJobType}
+
+const (
+	// jobDetailStreaming marks jobs whose total is still growing (e.g. bulk imports being read)
+	jobDetailStreaming = "streaming"
+	// jobDetailDroppedItems counts items of a cancelled job skipped by the pipeline
+	jobDetailDroppedItems = "dropped_items"
+)
+
 func (jm *JobManager) CreateJob(ctx context.Context, totalItems int, jobType string) (*JobProgress, error) {
+	return jm.createJob(ctx, totalItems, jobType, nil)
+}
+
+// CreateJobWithDetails creates a job with extra details such as its webhook_url
+func (jm *JobManager) CreateJobWithDetails(ctx context.Context, totalItems int, jobType string, details map[string]interface{}) (*JobProgress, error) {
+	return jm.createJob(ctx, totalItems, jobType, details)
+}
+
+// OnJobFinished registers a callback run whenever a job reaches completed, failed
+// or cancelled. Callbacks run with the job lock held and must not block.
+func (jm *JobManager) OnJobFinished(fn func(job *JobProgress)) {
+	jm.finishedHooks = append(jm.finishedHooks, fn)
+}
+
+// notifyFinished runs the finished hooks if the job just became terminal
+func (jm *JobManager) notifyFinished(previousStatus string, job *JobProgress) {
+	if isJobFinished(previousStatus) || !isJobFinished(job.Status) {
+		return
+	}
+	for _, hook := range jm.finishedHooks {
+		hook(job)
+	}
+}
+
+// CreateStreamingJob creates a job whose items are added with ExpandJob as they are
+// discovered. The job cannot complete until SealJob is called.
+func (jm *JobManager) CreateStreamingJob(ctx context.Context, jobType string, details map[string]interface{}) (*JobProgress, error) {
+	merged := map[string]interface{}{jobDetailStreaming: true}
+	for k, v := range details {
+		merged[k] = v
+	}
+	return jm.createJob(ctx, 0, jobType, merged)
+}
+
+func (jm *JobManager) createJob(ctx context.Context, totalItems int, jobType string, details map[string]interface{}) (*JobProgress, error) {
 	jobID := uuid.New()
 	now := time.Now()
 
@@ -64,6 +174,9 @@ func (jm *JobManager) CreateJob(ctx context.Context, totalItems int, jobType str
 			"job_type": jobType,
 		},
 	}
+	for k, v := range details {
+		job.Details[k] = v
+	}
 
 	// Estimate processing time (rough estimate: 2 seconds per item)
 	estimatedSeconds := totalItems * 2
@@ -103,7 +216,7 @@ func (jm *JobManager) GetJob(ctx context.Context, jobID uuid.UUID) (*JobProgress
 	// Fallback to PostgreSQL
 	job, err = jm.getJobFromPostgreSQL(ctx, jobID)
 	if err != nil {
-		return nil, fmt.Errorf("job not found: %w", err)
+		return nil, fmt.Errorf("%w: %v", ErrJobNotFound, err)
 	}
 
 	// Restore to Redis for future fast access
@@ -119,6 +232,7 @@ func (jm *JobManager) UpdateJobProgress(ctx context.Context, jobID uuid.UUID, pr
 	if err != nil {
 		return fmt.Errorf("failed to get job: %w", err)
 	}
+	previousStatus := job.Status
 
 	// Update job progress
 	job.ProcessedItems = processedItems
@@ -161,6 +275,7 @@ func (jm *JobManager) UpdateJobProgress(ctx context.Context, jobID uuid.UUID, pr
 		"failed_items":    failedItems,
 	}).Debug("Job progress updated")
 
+	jm.notifyFinished(previousStatus, job)
 	return nil
 }
 
@@ -174,7 +289,860 @@ func (jm *JobManager) CompleteJob(ctx context.Context, jobID uuid.UUID, successC
 }
 
 func (jm *JobManager) FailJob(ctx context.Context, jobID uuid.UUID, errorMessage string) error {
-	return jm.UpdateJobProgress(ctx, jobID, 0, 0, JobStatusFailed, &errorMessage)
+	return jm.AbortJob(ctx, jobID, errorMessage, nil)
+}
+
+// AbortJob fails a whole job, for example when its source could not be read. It
+// also seals streaming jobs and merges details into the job.
+func (jm *JobManager) AbortJob(ctx context.Context, jobID uuid.UUID, errorMessage string, details map[string]interface{}) error {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, err := jm.GetJob(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+	if job.Status == JobStatusCancelled {
+		return nil
+	}
+	previousStatus := job.Status
+
+	if job.Details == nil {
+		job.Details = make(map[string]interface{})
+	}
+	delete(job.Details, jobDetailStreaming)
+	for k, v := range details {
+		job.Details[k] = v
+	}
+
+	zero := 0
+	job.Status = JobStatusFailed
+	job.ErrorMessage = &errorMessage
+	job.EstimatedTime = &zero
+	job.UpdatedAt = time.Now()
+
+	if err := jm.saveJob(ctx, job); err != nil {
+		return err
+	}
+
+	jm.notifyFinished(previousStatus, job)
+	return nil
+}
+
+// MarkJobProcessing moves a queued job to processing without touching its counters.
+// It returns ErrJobCancelled if the job was cancelled so callers can drop the item.
+func (jm *JobManager) MarkJobProcessing(ctx context.Context, jobID uuid.UUID) error {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, err := jm.GetJob(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+	if job.Status == JobStatusCancelled {
+		return ErrJobCancelled
+	}
+	if job.Status != JobStatusQueued {
+		return nil
+	}
+
+	job.Status = JobStatusProcessing
+	job.UpdatedAt = time.Now()
+	return jm.saveJob(ctx, job)
+}
+
+// DropItems counts items of a cancelled job that the pipeline skipped, so they
+// no longer count as pending
+func (jm *JobManager) DropItems(ctx context.Context, jobID uuid.UUID, items int) error {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, err := jm.GetJob(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+
+	if job.Details == nil {
+		job.Details = make(map[string]interface{})
+	}
+	job.Details[jobDetailDroppedItems] = hintInt(job.Details, jobDetailDroppedItems) + items
+	job.UpdatedAt = time.Now()
+	return jm.saveJob(ctx, job)
+}
+
+// jobPendingItems is the number of a job's items neither processed, failed nor
+// dropped after cancellation
+func jobPendingItems(job *JobProgress) int {
+	return job.TotalItems - job.ProcessedItems - job.FailedItems - hintInt(job.Details, jobDetailDroppedItems)
+}
+
+// RecordItemResult counts one processed or failed item towards the job, stores its
+// outcome and derives the job status from the totals. Failed outcomes are also
+// kept in the job's error report.
+func (jm *JobManager) RecordItemResult(ctx context.Context, jobID uuid.UUID, outcome models.ContentItemOutcome) error {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, err := jm.GetJob(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+	previousStatus := job.Status
+
+	if outcome.Status == ItemStatusSucceeded {
+		job.ProcessedItems++
+	} else {
+		job.FailedItems++
+		job.ErrorMessage = &outcome.Error
+		itemErr := ItemError{Row: outcome.Row, ExternalID: outcome.ExternalID, Stage: outcome.Stage, Message: outcome.Error}
+		if err := jm.appendItemErrors(ctx, jobID, []ItemError{itemErr}); err != nil {
+			jm.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to store item error")
+		}
+	}
+
+	if err := jm.appendItemOutcomes(ctx, jobID, []models.ContentItemOutcome{outcome}); err != nil {
+		jm.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to store item outcome")
+	}
+
+	jm.refreshJob(job)
+	if err := jm.saveJob(ctx, job); err != nil {
+		return err
+	}
+
+	jm.notifyFinished(previousStatus, job)
+	return nil
+}
+
+// ExpandJob adds newly discovered items to a streaming job's total
+func (jm *JobManager) ExpandJob(ctx context.Context, jobID uuid.UUID, items int) error {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, err := jm.GetJob(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+
+	job.TotalItems += items
+	jm.refreshJob(job)
+	return jm.saveJob(ctx, job)
+}
+
+// AdvanceJob counts items a job processed itself (rather than through the
+// pipeline) as processed or failed, without per-item outcomes
+func (jm *JobManager) AdvanceJob(ctx context.Context, jobID uuid.UUID, processed, failed int) error {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, err := jm.GetJob(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+	if job.Status == JobStatusCancelled {
+		return ErrJobCancelled
+	}
+
+	previousStatus := job.Status
+
+	job.ProcessedItems += processed
+	job.FailedItems += failed
+	jm.refreshJob(job)
+	if err := jm.saveJob(ctx, job); err != nil {
+		return err
+	}
+
+	jm.notifyFinished(previousStatus, job)
+	return nil
+}
+
+// FailItems marks items that never reached the pipeline (parse or publish errors)
+// as failed and adds them to the job's error report
+func (jm *JobManager) FailItems(ctx context.Context, jobID uuid.UUID, itemErrors []ItemError) error {
+	if len(itemErrors) == 0 {
+		return nil
+	}
+
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, err := jm.GetJob(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+
+	previousStatus := job.Status
+
+	job.FailedItems += len(itemErrors)
+	if err := jm.appendItemErrors(ctx, jobID, itemErrors); err != nil {
+		jm.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to store item errors")
+	}
+
+	outcomes := make([]models.ContentItemOutcome, len(itemErrors))
+	for i, itemErr := range itemErrors {
+		outcomes[i] = FailedItemOutcome(itemErr, itemErr.Row-1)
+	}
+	if err := jm.appendItemOutcomes(ctx, jobID, outcomes); err != nil {
+		jm.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to store item outcomes")
+	}
+
+	jm.refreshJob(job)
+	if err := jm.saveJob(ctx, job); err != nil {
+		return err
+	}
+
+	jm.notifyFinished(previousStatus, job)
+	return nil
+}
+
+// SealJob marks a streaming job's total as final and merges details into the job.
+// The job completes immediately if every item has already been accounted for.
+func (jm *JobManager) SealJob(ctx context.Context, jobID uuid.UUID, details map[string]interface{}) error {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, err := jm.GetJob(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+
+	previousStatus := job.Status
+
+	if job.Details == nil {
+		job.Details = make(map[string]interface{})
+	}
+	delete(job.Details, jobDetailStreaming)
+	for k, v := range details {
+		job.Details[k] = v
+	}
+
+	jm.refreshJob(job)
+	if err := jm.saveJob(ctx, job); err != nil {
+		return err
+	}
+
+	jm.notifyFinished(previousStatus, job)
+	return nil
+}
+
+// CancelJob stops a queued or processing job. Messages already on the topic are
+// dropped by the pipeline workers; cancelling a cancelled job is a no-op.
+func (jm *JobManager) CancelJob(ctx context.Context, jobID uuid.UUID) (*JobProgress, error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, err := jm.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.Status {
+	case JobStatusCancelled:
+		return job, nil
+	case JobStatusCompleted, JobStatusFailed:
+		return job, ErrJobNotCancellable
+	}
+	previousStatus := job.Status
+
+	if job.Details == nil {
+		job.Details = make(map[string]interface{})
+	}
+	job.Details["cancelled_at"] = time.Now()
+	job.Status = JobStatusCancelled
+	jm.refreshJob(job)
+
+	if err := jm.saveJob(ctx, job); err != nil {
+		return nil, err
+	}
+	jm.notifyFinished(previousStatus, job)
+
+	jm.logger.WithFields(logrus.Fields{
+		"job_id":          jobID,
+		"processed_items": job.ProcessedItems,
+		"remaining_items": job.TotalItems - job.ProcessedItems - job.FailedItems,
+	}).Info("Job cancelled")
+
+	return job, nil
+}
+
+// IsJobCancelled reports whether a job has been cancelled. Lookup errors count as
+// not cancelled so a Redis hiccup never drops work.
+func (jm *JobManager) IsJobCancelled(ctx context.Context, jobID uuid.UUID) bool {
+	job, err := jm.GetJob(ctx, jobID)
+	return err == nil && job.Status == JobStatusCancelled
+}
+
+// StoreFailedItems keeps the payloads of failed items for RetryFailedItems.
+// Call it alongside RecordItemResult or FailItems, which do the counting.
+func (jm *JobManager) StoreFailedItems(ctx context.Context, jobID uuid.UUID, items []FailedItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	values := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			continue
+		}
+		values = append(values, data)
+	}
+
+	key := jobFailedItemsRedisKey(jobID)
+	pipe := jm.db.Redis.Warm.Pipeline()
+	pipe.RPush(ctx, key, values...)
+	pipe.Expire(ctx, key, jm.errorReportTTL())
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// TakeFailedItems removes the stored failed items of a finished job for a retry.
+// Their entries leave the error report and the failed counter, and the job goes
+// back to processing until the retried items are accounted for.
+func (jm *JobManager) TakeFailedItems(ctx context.Context, jobID uuid.UUID) ([]FailedItem, *JobProgress, error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, err := jm.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != JobStatusCompleted && job.Status != JobStatusFailed {
+		return nil, job, ErrJobNotRetryable
+	}
+
+	key := jobFailedItemsRedisKey(jobID)
+	pipe := jm.db.Redis.Warm.TxPipeline()
+	entries := pipe.LRange(ctx, key, 0, -1)
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, job, fmt.Errorf("failed to take failed items: %w", err)
+	}
+
+	items := make([]FailedItem, 0, len(entries.Val()))
+	for _, entry := range entries.Val() {
+		var item FailedItem
+		if err := json.Unmarshal([]byte(entry), &item); err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, job, ErrNoRetryableItems
+	}
+
+	errorsKey := jobErrorsRedisKey(jobID)
+	outcomesKey := jobOutcomesRedisKey(jobID)
+	for _, item := range items {
+		if data, err := json.Marshal(item.Error); err == nil {
+			if err := jm.db.Redis.Warm.LRem(ctx, errorsKey, 1, data).Err(); err != nil {
+				jm.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to remove retried item from error report")
+			}
+		}
+		outcome := FailedItemOutcome(item.Error, hintInt(item.Hints, HintItemIndex))
+		if data, err := json.Marshal(outcome); err == nil {
+			if err := jm.db.Redis.Warm.LRem(ctx, outcomesKey, 1, data).Err(); err != nil {
+				jm.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to remove retried item outcome")
+			}
+		}
+	}
+
+	job.FailedItems -= len(items)
+	if job.FailedItems < 0 {
+		job.FailedItems = 0
+	}
+	job.ErrorMessage = nil
+	if job.Details == nil {
+		job.Details = make(map[string]interface{})
+	}
+	retries, _ := job.Details["retries"].(float64)
+	job.Details["retries"] = int(retries) + 1
+	job.Status = JobStatusProcessing
+	jm.refreshJob(job)
+
+	if err := jm.saveJob(ctx, job); err != nil {
+		return nil, job, err
+	}
+
+	return items, job, nil
+}
+
+// ListJobs returns jobs newest first from PostgreSQL together with the cursor of
+// the next page, which is empty on the last page
+func (jm *JobManager) ListJobs(ctx context.Context, filter JobListFilter) ([]*JobProgress, string, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	query, args, err := jobListQuery(filter, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := jm.db.PG.Query(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*JobProgress{}
+	for rows.Next() {
+		var job JobProgress
+		var detailsJSON []byte
+
+		if err := rows.Scan(
+			&job.JobID, &job.Status, &job.Progress, &job.TotalItems, &job.ProcessedItems,
+			&job.FailedItems, &job.EstimatedTime, &job.ErrorMessage, &job.CreatedAt,
+			&job.UpdatedAt, &detailsJSON,
+		); err != nil {
+			return nil, "", fmt.Errorf("failed to scan job: %w", err)
+		}
+
+		if err := json.Unmarshal(detailsJSON, &job.Details); err != nil {
+			job.Details = make(map[string]interface{})
+		}
+		jobs = append(jobs, &job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	nextCursor := ""
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+		last := jobs[limit-1]
+		nextCursor = encodeJobCursor(last.CreatedAt, last.JobID)
+	}
+
+	return jobs, nextCursor, nil
+}
+
+// jobListQuery builds the query of one ListJobs page, fetching one extra row
+// to tell whether another page follows
+func jobListQuery(filter JobListFilter, limit int) (string, []interface{}, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	addCondition := func(format string, values ...interface{}) {
+		placeholders := make([]interface{}, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = len(args)
+		}
+		conditions = append(conditions, fmt.Sprintf(format, placeholders...))
+	}
+
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	if filter.JobType != "" {
+		addCondition("details->>'job_type' = $%d", filter.JobType)
+	}
+	if !filter.IncludeAdminJobs {
+		addCondition("NOT (coalesce(details->>'job_type', '') = ANY($%d))", adminJobTypes)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		addCondition("created_at >= $%d", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		addCondition("created_at < $%d", filter.CreatedBefore)
+	}
+	if filter.Cursor != "" {
+		createdAt, id, err := decodeJobCursor(filter.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		addCondition("(created_at, id) < ($%d, $%d)", createdAt, id)
+	}
+
+	query := `
+		SELECT id, status, progress, total_items, processed_items, failed_items,
+			   estimated_time, error_message, created_at, updated_at, details
+		FROM content_jobs
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit+1)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d", len(args))
+	return query, args, nil
+}
+
+// encodeJobCursor builds an opaque keyset cursor from the last job of a page
+func encodeJobCursor(createdAt time.Time, jobID uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + jobID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeJobCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidJobCursor
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return time.Time{}, uuid.Nil, ErrInvalidJobCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidJobCursor
+	}
+	jobID, err := uuid.Parse(parts[1])
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidJobCursor
+	}
+
+	return createdAt, jobID, nil
+}
+
+// GetItemOutcomes returns the recorded per-item outcomes of a job in input order
+func (jm *JobManager) GetItemOutcomes(ctx context.Context, jobID uuid.UUID) ([]models.ContentItemOutcome, error) {
+	entries, err := jm.db.Redis.Warm.LRange(ctx, jobOutcomesRedisKey(jobID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job item outcomes: %w", err)
+	}
+
+	outcomes := make([]models.ContentItemOutcome, 0, len(entries))
+	for _, entry := range entries {
+		var outcome models.ContentItemOutcome
+		if err := json.Unmarshal([]byte(entry), &outcome); err != nil {
+			continue
+		}
+		outcomes = append(outcomes, outcome)
+	}
+
+	// Workers finish items out of order
+	sort.SliceStable(outcomes, func(i, j int) bool {
+		return outcomes[i].Index < outcomes[j].Index
+	})
+
+	return outcomes, nil
+}
+
+// RecordWebhookDelivery stores the latest delivery state of a job webhook under
+// details.webhooks keyed by URL
+func (jm *JobManager) RecordWebhookDelivery(ctx context.Context, jobID uuid.UUID, url string, delivery map[string]interface{}) error {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, err := jm.GetJob(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+
+	if job.Details == nil {
+		job.Details = make(map[string]interface{})
+	}
+	webhooks, _ := job.Details["webhooks"].(map[string]interface{})
+	if webhooks == nil {
+		webhooks = make(map[string]interface{})
+	}
+	webhooks[url] = delivery
+	job.Details["webhooks"] = webhooks
+
+	return jm.saveJob(ctx, job)
+}
+
+// FailedItemOutcome builds the outcome recorded for a failed item
+func FailedItemOutcome(itemErr ItemError, index int) models.ContentItemOutcome {
+	if index < 0 {
+		index = 0
+	}
+	return models.ContentItemOutcome{
+		Index:      index,
+		Row:        itemErr.Row,
+		ExternalID: itemErr.ExternalID,
+		Status:     ItemStatusFailed,
+		Stage:      itemErr.Stage,
+		Error:      itemErr.Message,
+		Fields:     itemErr.Fields,
+	}
+}
+
+// ToStatus converts a job to its API representation
+func (job *JobProgress) ToStatus() models.ContentJobStatus {
+	return models.ContentJobStatus{
+		JobID:          job.JobID,
+		Status:         job.Status,
+		Progress:       job.Progress,
+		TotalItems:     job.TotalItems,
+		ProcessedItems: job.ProcessedItems,
+		FailedItems:    job.FailedItems,
+		EstimatedTime:  job.EstimatedTime,
+		ErrorMessage:   job.ErrorMessage,
+		Details:        job.Details,
+		CreatedAt:      job.CreatedAt,
+		UpdatedAt:      job.UpdatedAt,
+	}
+}
+
+func (jm *JobManager) appendItemOutcomes(ctx context.Context, jobID uuid.UUID, outcomes []models.ContentItemOutcome) error {
+	if len(outcomes) == 0 {
+		return nil
+	}
+
+	key := jobOutcomesRedisKey(jobID)
+
+	maxOutcomes := int64(10000)
+	if jm.config != nil && jm.config.MaxItemOutcomes > 0 {
+		maxOutcomes = int64(jm.config.MaxItemOutcomes)
+	}
+
+	stored, err := jm.db.Redis.Warm.LLen(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	room := maxOutcomes - stored
+	if room <= 0 {
+		return nil
+	}
+	if int64(len(outcomes)) > room {
+		outcomes = outcomes[:room]
+	}
+
+	values := make([]interface{}, 0, len(outcomes))
+	for _, outcome := range outcomes {
+		data, err := json.Marshal(outcome)
+		if err != nil {
+			continue
+		}
+		values = append(values, data)
+	}
+
+	pipe := jm.db.Redis.Warm.Pipeline()
+	pipe.RPush(ctx, key, values...)
+	pipe.Expire(ctx, key, jm.errorReportTTL())
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetItemErrors returns the error report of a job
+func (jm *JobManager) GetItemErrors(ctx context.Context, jobID uuid.UUID) ([]ItemError, error) {
+	entries, err := jm.db.Redis.Warm.LRange(ctx, jobErrorsRedisKey(jobID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job errors: %w", err)
+	}
+
+	itemErrors := make([]ItemError, 0, len(entries))
+	for _, entry := range entries {
+		var itemErr ItemError
+		if err := json.Unmarshal([]byte(entry), &itemErr); err != nil {
+			continue
+		}
+		itemErrors = append(itemErrors, itemErr)
+	}
+
+	return itemErrors, nil
+}
+
+func (jm *JobManager) appendItemErrors(ctx context.Context, jobID uuid.UUID, itemErrors []ItemError) error {
+	key := jobErrorsRedisKey(jobID)
+
+	maxReports := int64(10000)
+	if jm.config != nil && jm.config.BulkImport.MaxErrorReports > 0 {
+		maxReports = int64(jm.config.BulkImport.MaxErrorReports)
+	}
+
+	stored, err := jm.db.Redis.Warm.LLen(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	room := maxReports - stored
+	if room <= 0 {
+		return nil
+	}
+	if int64(len(itemErrors)) > room {
+		itemErrors = itemErrors[:room]
+	}
+
+	values := make([]interface{}, 0, len(itemErrors))
+	for _, itemErr := range itemErrors {
+		data, err := json.Marshal(itemErr)
+		if err != nil {
+			continue
+		}
+		values = append(values, data)
+	}
+
+	pipe := jm.db.Redis.Warm.Pipeline()
+	pipe.RPush(ctx, key, values...)
+	pipe.Expire(ctx, key, jm.errorReportTTL())
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (jm *JobManager) errorReportTTL() time.Duration {
+	if jm.config != nil && jm.config.BulkImport.ErrorReportTTL > 0 {
+		return jm.config.BulkImport.ErrorReportTTL
+	}
+	return 7 * 24 * time.Hour
+}
+
+// refreshJob recomputes progress, estimate and status after counters changed
+func (jm *JobManager) refreshJob(job *JobProgress) {
+	job.UpdatedAt = time.Now()
+	job.Status = deriveJobStatus(job)
+
+	if job.TotalItems > 0 {
+		job.Progress = int((float64(job.ProcessedItems+job.FailedItems) / float64(job.TotalItems)) * 100)
+	}
+
+	if job.Status == JobStatusProcessing && job.ProcessedItems > 0 {
+		elapsed := time.Since(job.CreatedAt).Seconds()
+		avgTimePerItem := elapsed / float64(job.ProcessedItems)
+		remainingItems := job.TotalItems - job.ProcessedItems - job.FailedItems
+		estimatedRemaining := int(avgTimePerItem * float64(remainingItems))
+		job.EstimatedTime = &estimatedRemaining
+	} else if job.Status != JobStatusProcessing && job.Status != JobStatusQueued {
+		zero := 0
+		job.EstimatedTime = &zero
+	}
+}
+
+// deriveJobStatus works out a job's status from its counters. Cancelled jobs stay
+// cancelled and streaming jobs stay open until sealed.
+func deriveJobStatus(job *JobProgress) string {
+	if job.Status == JobStatusCancelled {
+		return JobStatusCancelled
+	}
+
+	done := job.ProcessedItems + job.FailedItems
+	streaming, _ := job.Details[jobDetailStreaming].(bool)
+
+	switch {
+	case streaming || done < job.TotalItems:
+		if done == 0 && job.Status == JobStatusQueued {
+			return JobStatusQueued
+		}
+		return JobStatusProcessing
+	case job.ProcessedItems == 0 && job.FailedItems > 0:
+		return JobStatusFailed
+	default:
+		return JobStatusCompleted
+	}
+}
+
+// saveJob writes a job to Redis and PostgreSQL
+func (jm *JobManager) saveJob(ctx context.Context, job *JobProgress) error {
+	if err := jm.storeJobInRedis(ctx, job); err != nil {
+		return err
+	}
+
+	if err := jm.updateJobInPostgreSQL(ctx, job); err != nil {
+		jm.logger.WithError(err).WithField("job_id", job.JobID).Warn("Failed to update job in PostgreSQL")
+	}
+
+	return nil
+}
+
+func jobErrorsRedisKey(jobID uuid.UUID) string {
+	return fmt.Sprintf("job_errors:%s", jobID.String())
+}
+
+func jobOutcomesRedisKey(jobID uuid.UUID) string {
+	return fmt.Sprintf("job_items:%s", jobID.String())
+}
+
+func jobFailedItemsRedisKey(jobID uuid.UUID) string {
+	return fmt.Sprintf("job_failed_items:%s", jobID.String())
+}
+
+// RecordDuplicate appends a near-duplicate finding to the job details so it is
+// returned with the job status. Workers of a bulk job record concurrently, so
+// the job is re-read under the lock like the counters.
+func (jm *JobManager) RecordDuplicate(ctx context.Context, jobID uuid.UUID, match *DuplicateMatch) error {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, err := jm.GetJob(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+
+	if job.Details == nil {
+		job.Details = make(map[string]interface{})
+	}
+
+	duplicates, _ := job.Details["duplicates"].([]interface{})
+	job.Details["duplicates"] = append(duplicates, match)
+	job.UpdatedAt = time.Now()
+
+	if err := jm.storeJobInRedis(ctx, job); err != nil {
+		jm.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to update job in Redis")
+	}
+
+	if err := jm.updateJobInPostgreSQL(ctx, job); err != nil {
+		jm.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to update job in PostgreSQL")
+	}
+
+	return nil
+}
+
+// IdempotencyRecord binds a client idempotency key to the job created for it
+type IdempotencyRecord struct {
+	JobID       uuid.UUID `json:"job_id"`
+	RequestHash string    `json:"request_hash"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// GetIdempotencyRecord returns the record stored for a caller's idempotency key,
+// or nil if unused. scope names the caller, so clients choosing the same key do
+// not see each other's jobs.
+func (jm *JobManager) GetIdempotencyRecord(ctx context.Context, scope, key string) (*IdempotencyRecord, error) {
+	data, err := jm.db.Redis.Warm.Get(ctx, idempotencyRedisKey(scope, key)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	var record IdempotencyRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal idempotency record: %w", err)
+	}
+
+	return &record, nil
+}
+
+// BindIdempotencyKey atomically stores the job for an idempotency key. If another
+// request won the race, its record is returned and bound is false.
+func (jm *JobManager) BindIdempotencyKey(ctx context.Context, scope, key, requestHash string, jobID uuid.UUID) (*IdempotencyRecord, bool, error) {
+	record := &IdempotencyRecord{
+		JobID:       jobID,
+		RequestHash: requestHash,
+		CreatedAt:   time.Now(),
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	ttl := 24 * time.Hour
+	if jm.config != nil && jm.config.IdempotencyTTL > 0 {
+		ttl = jm.config.IdempotencyTTL
+	}
+
+	bound, err := jm.db.Redis.Warm.SetNX(ctx, idempotencyRedisKey(scope, key), data, ttl).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+
+	if !bound {
+		existing, err := jm.GetIdempotencyRecord(ctx, scope, key)
+		if err != nil || existing == nil {
+			return nil, false, fmt.Errorf("idempotency key in use but unreadable: %w", err)
+		}
+		return existing, false, nil
+	}
+
+	return record, true, nil
+}
+
+func idempotencyRedisKey(scope, key string) string {
+	return fmt.Sprintf("idempotency:content:%s:%s", scope, key)
 }
 
 func (jm *JobManager) ListActiveJobs(ctx context.Context, limit int) ([]*JobProgress, error) {
@@ -232,8 +1200,8 @@ func (jm *JobManager) CleanupCompletedJobs(ctx context.Context, olderThan time.D
 			continue
 		}
 
-		// Remove completed jobs older than cutoff
-		if (job.Status == JobStatusCompleted || job.Status == JobStatusFailed) && job.UpdatedAt.Before(cutoff) {
+		// Remove finished jobs older than cutoff
+		if isJobFinished(job.Status) && job.UpdatedAt.Before(cutoff) {
 			if err := jm.db.Redis.Warm.Del(ctx, key).Err(); err != nil {
 				jm.logger.WithError(err).WithField("job_id", job.JobID).Warn("Failed to delete job from Redis")
 			} else {
@@ -250,6 +1218,60 @@ func (jm *JobManager) CleanupCompletedJobs(ctx context.Context, olderThan time.D
 	return nil
 }
 
+// StartCleanup periodically removes finished jobs from Redis. PostgreSQL keeps
+// the full job history.
+func (jm *JobManager) StartCleanup(ctx context.Context) {
+	if jm.config == nil || !jm.config.JobCleanup.Enabled {
+		jm.logger.Info("Job cleanup disabled")
+		return
+	}
+
+	interval := jm.config.JobCleanup.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	retention := jm.config.JobCleanup.Retention
+	if retention <= 0 {
+		retention = 24 * time.Hour
+	}
+
+	jm.wg.Add(1)
+	go func() {
+		defer jm.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := jm.CleanupCompletedJobs(ctx, retention); err != nil {
+					jm.logger.WithError(err).Error("Job cleanup failed")
+				}
+			case <-jm.quit:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	jm.logger.WithFields(logrus.Fields{
+		"interval":  interval,
+		"retention": retention,
+	}).Info("Job cleanup scheduled")
+}
+
+// Stop ends the cleanup scheduler
+func (jm *JobManager) Stop() {
+	close(jm.quit)
+	jm.wg.Wait()
+}
+
+func isJobFinished(status string) bool {
+	return status == JobStatusCompleted || status == JobStatusFailed || status == JobStatusCancelled
+}
+
 // Redis operations
 
 func (jm *JobManager) storeJobInRedis(ctx context.Context, job *JobProgress) error {
@@ -260,9 +1282,9 @@ func (jm *JobManager) storeJobInRedis(ctx context.Context, job *JobProgress) err
 		return fmt.Errorf("failed to marshal job: %w", err)
 	}
 
-	// Set with TTL of 24 hours for completed jobs, no TTL for active jobs
+	// Set with TTL of 24 hours for finished jobs, no TTL for active jobs
 	ttl := time.Duration(0)
-	if job.Status == JobStatusCompleted || job.Status == JobStatusFailed {
+	if isJobFinished(job.Status) {
 		ttl = 24 * time.Hour
 	}
 
@@ -349,7 +1371,8 @@ func (jm *JobManager) updateJobInPostgreSQL(ctx context.Context, job *JobProgres
 	query := `
 		UPDATE content_jobs SET
 			status = $2, progress = $3, processed_items = $4, failed_items = $5,
-			estimated_time = $6, error_message = $7, updated_at = $8, details = $9
+			estimated_time = $6, error_message = $7, updated_at = $8, details = $9,
+			total_items = $10
 		WHERE id = $1
 	`
 
@@ -361,6 +1384,7 @@ func (jm *JobManager) updateJobInPostgreSQL(ctx context.Context, job *JobProgres
 	_, err = jm.db.PG.Exec(ctx, query,
 		job.JobID, job.Status, job.Progress, job.ProcessedItems, job.FailedItems,
 		job.EstimatedTime, job.ErrorMessage, job.UpdatedAt, detailsJSON,
+		job.TotalItems,
 	)
 
 	if err != nil {
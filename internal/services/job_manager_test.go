@@ -312,3 +312,89 @@ func TestJobManager_RedisKeyGeneration(t *testing.T) {
 		assert.Equal(t, jobID, parsedID)
 	}
 }
+
+func TestDeriveJobStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		job      JobProgress
+		expected string
+	}{
+		{
+			name:     "nothing processed yet",
+			job:      JobProgress{Status: JobStatusQueued, TotalItems: 3},
+			expected: JobStatusQueued,
+		},
+		{
+			name:     "partially processed",
+			job:      JobProgress{Status: JobStatusQueued, TotalItems: 3, ProcessedItems: 1},
+			expected: JobStatusProcessing,
+		},
+		{
+			name:     "all items processed",
+			job:      JobProgress{Status: JobStatusProcessing, TotalItems: 3, ProcessedItems: 2, FailedItems: 1},
+			expected: JobStatusCompleted,
+		},
+		{
+			name:     "all items failed",
+			job:      JobProgress{Status: JobStatusProcessing, TotalItems: 2, FailedItems: 2},
+			expected: JobStatusFailed,
+		},
+		{
+			name: "streaming job stays open until sealed",
+			job: JobProgress{
+				Status: JobStatusProcessing, TotalItems: 2, ProcessedItems: 2,
+				Details: map[string]interface{}{jobDetailStreaming: true},
+			},
+			expected: JobStatusProcessing,
+		},
+		{
+			name:     "cancelled job stays cancelled",
+			job:      JobProgress{Status: JobStatusCancelled, TotalItems: 2, ProcessedItems: 2},
+			expected: JobStatusCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, deriveJobStatus(&tt.job))
+		})
+	}
+}
+
+func TestJobCursor_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	jobID := uuid.New()
+
+	decodedAt, decodedID, err := decodeJobCursor(encodeJobCursor(createdAt, jobID))
+	assert.NoError(t, err)
+	assert.True(t, createdAt.Equal(decodedAt), "nanoseconds survive so keyset paging does not skip jobs")
+	assert.Equal(t, jobID, decodedID)
+
+	for _, cursor := range []string{"not base64!", "bm8tc2VwYXJhdG9y", encodeJobCursor(createdAt, jobID)[:10]} {
+		_, _, err := decodeJobCursor(cursor)
+		assert.ErrorIs(t, err, ErrInvalidJobCursor, cursor)
+	}
+}
+
+func TestJobListQuery_AdminJobTypes(t *testing.T) {
+	query, args, err := jobListQuery(JobListFilter{JobType: userDeletionJobType}, 50)
+	assert.NoError(t, err)
+	assert.Contains(t, query, "NOT (coalesce(details->>'job_type', '') = ANY($2))")
+	assert.Equal(t, []interface{}{userDeletionJobType, adminJobTypes, 51}, args)
+
+	query, args, err = jobListQuery(JobListFilter{IncludeAdminJobs: true}, 10)
+	assert.NoError(t, err)
+	assert.NotContains(t, query, "WHERE")
+	assert.Equal(t, []interface{}{11}, args)
+
+	_, _, err = jobListQuery(JobListFilter{Cursor: "not base64!"}, 10)
+	assert.ErrorIs(t, err, ErrInvalidJobCursor)
+}
+
+func TestIsJobFinished(t *testing.T) {
+	assert.True(t, isJobFinished(JobStatusCompleted))
+	assert.True(t, isJobFinished(JobStatusFailed))
+	assert.True(t, isJobFinished(JobStatusCancelled))
+	assert.False(t, isJobFinished(JobStatusQueued))
+	assert.False(t, isJobFinished(JobStatusProcessing))
+}
@@ -0,0 +1,621 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/database"
+	"github.com/temcen/pirex/internal/nlp"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+const (
+	// defaultMaxKeywordTerms is the number of distinct terms indexed per item
+	defaultMaxKeywordTerms = 100
+	// lexicalQueryTerms is the number of terms of a lexical query vector
+	lexicalQueryTerms = 50
+	// lexicalSourceItems is the number of liked items a user's query is built from
+	lexicalSourceItems = 50
+)
+
+// brandMetadataKey is the metadata field that names an item's brand
+const brandMetadataKey = "brand"
+
+// KeywordIndex keeps the catalog-wide document frequency model that item
+// keywords are weighted against, and the brand names learned from content
+// metadata. Ingestion updates both incrementally as items are stored; Start
+// reloads them periodically to pick up items stored by other instances.
+type KeywordIndex struct {
+	db     *database.Database
+	config *config.KeywordConfig
+	logger *logrus.Logger
+
+	mu          sync.RWMutex
+	corpus      nlp.CorpusStats
+	frequencies map[string]int // Term -> documents containing it
+	brands      *nlp.Gazetteer
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// KeywordUpdate is the change one stored item makes to the frequency model,
+// applied in memory once its transaction commits
+type KeywordUpdate struct {
+	Added         []string // Terms the item did not contain before
+	Removed       []string // Terms the item no longer contains
+	DocumentDelta int      // 1 for a new document, -1 for one without terms left
+	LengthDelta   float64
+	Brand         string // Brand named in the item metadata
+}
+
+func NewKeywordIndex(db *database.Database, cfg *config.KeywordConfig, logger *logrus.Logger) *KeywordIndex {
+	return &KeywordIndex{
+		db:          db,
+		config:      cfg,
+		logger:      logger,
+		frequencies: make(map[string]int),
+		brands:      nlp.NewGazetteer(),
+		quit:        make(chan struct{}),
+	}
+}
+
+// Weighting returns the configured term weighting. It is safe to call on a nil index.
+func (k *KeywordIndex) Weighting() nlp.Weighting {
+	weighting := nlp.DefaultWeighting()
+	if k == nil || k.config == nil {
+		return weighting
+	}
+	if k.config.Scoring != "" {
+		weighting.Scheme = k.config.Scoring
+	}
+	if k.config.K1 > 0 {
+		weighting.K1 = k.config.K1
+	}
+	if k.config.B > 0 {
+		weighting.B = k.config.B
+	}
+	return weighting
+}
+
+func (k *KeywordIndex) maxTerms() int {
+	if k == nil || k.config == nil || k.config.MaxTerms <= 0 {
+		return defaultMaxKeywordTerms
+	}
+	return k.config.MaxTerms
+}
+
+func (k *KeywordIndex) titleBoost() float64 {
+	if k == nil || k.config == nil || k.config.TitleBoost <= 0 {
+		return 1
+	}
+	return k.config.TitleBoost
+}
+
+// Stats returns the corpus statistics and the document frequency of each term
+func (k *KeywordIndex) Stats(terms []string) (nlp.CorpusStats, map[string]int) {
+	frequencies := make(map[string]int, len(terms))
+	if k == nil {
+		return nlp.CorpusStats{}, frequencies
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, term := range terms {
+		frequencies[term] = k.frequencies[term]
+	}
+	return k.corpus, frequencies
+}
+
+// Extract returns the weighted keyword vector of an item, ordered by weight.
+// Title words count TitleBoost times. The item is weighted as if it were
+// already part of the corpus, so the first items of a catalog get sensible
+// weights too. It is safe to call on a nil index, which weights by term
+// frequency alone.
+func (k *KeywordIndex) Extract(title string, description *string, language string) []models.Keyword {
+	type entry struct {
+		text      string
+		frequency float64
+		count     int // Unboosted, picks the surface form shown
+		first     int
+	}
+
+	entries := make(map[string]*entry)
+	collect := func(text string, boost float64, offset int) {
+		for _, term := range nlp.Terms(text, language) {
+			e, ok := entries[term.Stem]
+			if !ok {
+				e = &entry{text: term.Surface, first: offset + term.First}
+				entries[term.Stem] = e
+			} else if term.Count > e.count {
+				e.text = term.Surface
+			}
+			e.frequency += float64(term.Count) * boost
+			e.count += term.Count
+		}
+	}
+	collect(title, k.titleBoost(), 0)
+	if description != nil {
+		collect(*description, 1, len(title))
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// Keep the most frequent terms; they define the document for weighting
+	terms := make([]string, 0, len(entries))
+	for term := range entries {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		a, b := entries[terms[i]], entries[terms[j]]
+		if a.frequency != b.frequency {
+			return a.frequency > b.frequency
+		}
+		return a.first < b.first
+	})
+	if max := k.maxTerms(); len(terms) > max {
+		terms = terms[:max]
+	}
+
+	var length float64
+	for _, term := range terms {
+		length += entries[term].frequency
+	}
+
+	corpus, frequencies := k.Stats(terms)
+	corpus.Documents++
+	corpus.TotalLength += length
+	weighting := k.Weighting()
+
+	keywords := make([]models.Keyword, 0, len(terms))
+	for _, term := range terms {
+		e := entries[term]
+		keywords = append(keywords, models.Keyword{
+			Term:      term,
+			Text:      e.text,
+			Frequency: e.frequency,
+			Weight:    weighting.Weight(e.frequency, frequencies[term]+1, length, corpus),
+		})
+	}
+	sort.SliceStable(keywords, func(i, j int) bool {
+		return keywords[i].Weight > keywords[j].Weight
+	})
+	return keywords
+}
+
+// Brands returns the brand named in the metadata followed by the known brands
+// mentioned in text. It is safe to call on a nil index, which only reads the
+// metadata.
+func (k *KeywordIndex) Brands(text string, metadata map[string]interface{}) []string {
+	var brands []string
+	seen := make(map[string]bool)
+	add := func(brand string) {
+		if key := strings.ToLower(brand); !seen[key] {
+			seen[key] = true
+			brands = append(brands, brand)
+		}
+	}
+
+	if brand := MetadataBrand(metadata); brand != "" {
+		add(brand)
+	}
+	if k != nil {
+		k.mu.RLock()
+		gazetteer := k.brands
+		k.mu.RUnlock()
+		for _, brand := range gazetteer.Find(text) {
+			add(brand)
+		}
+	}
+	return brands
+}
+
+// MetadataBrand returns the brand named in content metadata
+func MetadataBrand(metadata map[string]interface{}) string {
+	brand, _ := metadata[brandMetadataKey].(string)
+	return strings.TrimSpace(brand)
+}
+
+// Start loads the frequency model and reloads it periodically
+func (k *KeywordIndex) Start(ctx context.Context) {
+	if err := k.Load(ctx); err != nil {
+		k.logger.WithError(err).Warn("Failed to load keyword document frequencies")
+	}
+
+	if k.config.ReloadInterval <= 0 {
+		return
+	}
+
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+
+		ticker := time.NewTicker(k.config.ReloadInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := k.Load(ctx); err != nil {
+					k.logger.WithError(err).Warn("Failed to reload keyword document frequencies")
+				}
+			case <-k.quit:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (k *KeywordIndex) Stop() {
+	close(k.quit)
+	k.wg.Wait()
+}
+
+// Load replaces the in-memory model with the frequencies stored in Postgres
+func (k *KeywordIndex) Load(ctx context.Context) error {
+	var corpus nlp.CorpusStats
+	err := k.db.PG.QueryRow(ctx, `
+		SELECT COUNT(DISTINCT content_id), COALESCE(SUM(frequency), 0)
+		FROM content_keywords
+	`).Scan(&corpus.Documents, &corpus.TotalLength)
+	if err != nil {
+		return fmt.Errorf("failed to load keyword corpus statistics: %w", err)
+	}
+
+	rows, err := k.db.PG.Query(ctx, `
+		SELECT term, document_count
+		FROM keyword_document_frequencies
+		WHERE document_count > 0
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query keyword document frequencies: %w", err)
+	}
+	frequencies := make(map[string]int)
+	for rows.Next() {
+		var term string
+		var count int
+		if err := rows.Scan(&term, &count); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan keyword document frequency: %w", err)
+		}
+		frequencies[term] = count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read keyword document frequencies: %w", err)
+	}
+
+	brands, err := k.loadBrands(ctx)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.corpus = corpus
+	k.frequencies = frequencies
+	k.brands = brands
+	k.mu.Unlock()
+
+	k.logger.WithFields(logrus.Fields{
+		"documents": corpus.Documents,
+		"terms":     len(frequencies),
+		"brands":    brands.Len(),
+	}).Debug("Loaded keyword document frequencies")
+	return nil
+}
+
+func (k *KeywordIndex) loadBrands(ctx context.Context) (*nlp.Gazetteer, error) {
+	rows, err := k.db.PG.Query(ctx, `
+		SELECT DISTINCT TRIM(metadata->>'brand')
+		FROM content_items
+		WHERE active = true
+		  AND TRIM(metadata->>'brand') <> ''
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query brands: %w", err)
+	}
+	defer rows.Close()
+
+	brands := nlp.NewGazetteer()
+	for rows.Next() {
+		var brand string
+		if err := rows.Scan(&brand); err != nil {
+			return nil, fmt.Errorf("failed to scan brand: %w", err)
+		}
+		brands.Add(brand)
+	}
+	return brands, rows.Err()
+}
+
+// Save replaces the stored keyword vector of a content item and updates the
+// document frequencies of the terms it gained or lost. Apply the returned
+// update once the transaction commits. It is safe to call on a nil index.
+func (k *KeywordIndex) Save(ctx context.Context, tx pgx.Tx, content *models.ContentItem) (*KeywordUpdate, error) {
+	rows, err := tx.Query(ctx, `DELETE FROM content_keywords WHERE content_id = $1 RETURNING term, frequency`, content.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear content keywords: %w", err)
+	}
+	oldTerms := make(map[string]bool)
+	var oldLength float64
+	for rows.Next() {
+		var term string
+		var frequency float64
+		if err := rows.Scan(&term, &frequency); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan content keyword: %w", err)
+		}
+		oldTerms[term] = true
+		oldLength += frequency
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to clear content keywords: %w", err)
+	}
+
+	update := &KeywordUpdate{Brand: MetadataBrand(content.Metadata)}
+	newTerms := make(map[string]bool, len(content.Keywords))
+	var newLength float64
+	for _, keyword := range content.Keywords {
+		newTerms[keyword.Term] = true
+		newLength += keyword.Frequency
+		if !oldTerms[keyword.Term] {
+			update.Added = append(update.Added, keyword.Term)
+		}
+	}
+	for term := range oldTerms {
+		if !newTerms[term] {
+			update.Removed = append(update.Removed, term)
+		}
+	}
+	update.LengthDelta = newLength - oldLength
+	switch {
+	case len(oldTerms) == 0 && len(newTerms) > 0:
+		update.DocumentDelta = 1
+	case len(oldTerms) > 0 && len(newTerms) == 0:
+		update.DocumentDelta = -1
+	}
+
+	// Sorted so concurrent transactions lock frequency rows in the same order
+	sort.Strings(update.Added)
+	sort.Strings(update.Removed)
+
+	if len(update.Removed) > 0 {
+		_, err := tx.Exec(ctx, `
+			UPDATE keyword_document_frequencies
+			SET document_count = GREATEST(document_count - 1, 0)
+			WHERE term = ANY($1)
+		`, update.Removed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update keyword document frequencies: %w", err)
+		}
+	}
+	if len(update.Added) > 0 {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO keyword_document_frequencies (term, document_count)
+			SELECT term, 1 FROM unnest($1::text[]) AS t(term)
+			ON CONFLICT (term) DO UPDATE SET
+				document_count = keyword_document_frequencies.document_count + 1
+		`, update.Added)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update keyword document frequencies: %w", err)
+		}
+	}
+
+	if len(content.Keywords) > 0 {
+		terms := make([]string, len(content.Keywords))
+		texts := make([]string, len(content.Keywords))
+		frequencies := make([]float64, len(content.Keywords))
+		weights := make([]float64, len(content.Keywords))
+		for i, keyword := range content.Keywords {
+			terms[i], texts[i] = keyword.Term, keyword.Text
+			frequencies[i], weights[i] = keyword.Frequency, keyword.Weight
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO content_keywords (content_id, term, surface, frequency, weight)
+			SELECT $1, k.term, k.surface, k.frequency, k.weight
+			FROM unnest($2::text[], $3::text[], $4::double precision[], $5::double precision[])
+				AS k(term, surface, frequency, weight)
+		`, content.ID, terms, texts, frequencies, weights)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store content keywords: %w", err)
+		}
+	}
+
+	return update, nil
+}
+
+// Apply adds a committed update to the in-memory model. It is safe to call on
+// a nil index.
+func (k *KeywordIndex) Apply(update *KeywordUpdate) {
+	if k == nil || update == nil {
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, term := range update.Added {
+		k.frequencies[term]++
+	}
+	for _, term := range update.Removed {
+		if k.frequencies[term] > 1 {
+			k.frequencies[term]--
+		} else {
+			delete(k.frequencies, term)
+		}
+	}
+	k.corpus.Documents += update.DocumentDelta
+	k.corpus.TotalLength += update.LengthDelta
+	if update.Brand != "" {
+		k.brands.Add(update.Brand)
+	}
+}
+
+// ItemKeywords returns the stored keyword vector of an item, ordered by weight
+func (k *KeywordIndex) ItemKeywords(ctx context.Context, itemID uuid.UUID) ([]models.Keyword, error) {
+	rows, err := k.db.PG.Query(ctx, `
+		SELECT term, surface, frequency, weight
+		FROM content_keywords
+		WHERE content_id = $1
+		ORDER BY weight DESC, term
+	`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query content keywords: %w", err)
+	}
+	defer rows.Close()
+
+	var keywords []models.Keyword
+	for rows.Next() {
+		var keyword models.Keyword
+		if err := rows.Scan(&keyword.Term, &keyword.Text, &keyword.Frequency, &keyword.Weight); err != nil {
+			return nil, fmt.Errorf("failed to scan content keyword: %w", err)
+		}
+		keywords = append(keywords, keyword)
+	}
+	return keywords, rows.Err()
+}
+
+// LexicalRecommendations returns items sharing weighted keywords with the seed
+// item, or with the items the user liked recently when there is no seed. The
+// score is the dot product of the sparse keyword vectors.
+func (k *KeywordIndex) LexicalRecommendations(
+	ctx context.Context,
+	userID uuid.UUID,
+	seedItemID *uuid.UUID,
+	exclude []uuid.UUID,
+	limit int,
+) ([]models.ScoredItem, error) {
+	var sources []uuid.UUID
+	if seedItemID != nil {
+		sources = []uuid.UUID{*seedItemID}
+	} else {
+		var err error
+		if sources, err = k.likedItems(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no liked items to match keywords against")
+	}
+
+	query, err := k.queryVector(ctx, sources)
+	if err != nil {
+		return nil, err
+	}
+	if len(query) == 0 {
+		return nil, fmt.Errorf("source items have no keywords")
+	}
+
+	terms := make([]string, 0, len(query))
+	weights := make([]float64, 0, len(query))
+	for term, weight := range query {
+		terms = append(terms, term)
+		weights = append(weights, weight)
+	}
+
+	rows, err := k.db.PG.Query(ctx, `
+		SELECT ck.content_id, SUM(ck.weight * q.weight) AS score, COUNT(*) AS matched
+		FROM unnest($1::text[], $2::double precision[]) AS q(term, weight)
+		JOIN content_keywords ck ON ck.term = q.term
+		JOIN content_items c ON c.id = ck.content_id
+		WHERE c.active = true
+		  AND NOT (ck.content_id = ANY($3))
+		GROUP BY ck.content_id
+		ORDER BY score DESC
+		LIMIT $4
+	`, terms, weights, append(sources, exclude...), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lexical candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var items []models.ScoredItem
+	for rows.Next() {
+		var item models.ScoredItem
+		var matched int
+		if err := rows.Scan(&item.ItemID, &item.Score, &matched); err != nil {
+			return nil, fmt.Errorf("failed to scan lexical candidate: %w", err)
+		}
+		item.Algorithm = "lexical"
+		item.Confidence = lexicalConfidence(matched, len(query))
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// likedItems returns the items a user rated highly or liked in the last 90 days
+func (k *KeywordIndex) likedItems(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := k.db.PG.Query(ctx, `
+		SELECT item_id
+		FROM user_interactions
+		WHERE user_id = $1
+		  AND interaction_type IN ('rating', 'like')
+		  AND (value IS NULL OR value >= 4.0)
+		  AND timestamp >= NOW() - INTERVAL '90 days'
+		GROUP BY item_id
+		ORDER BY MAX(timestamp) DESC
+		LIMIT $2
+	`, userID, lexicalSourceItems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query liked items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []uuid.UUID
+	for rows.Next() {
+		var itemID uuid.UUID
+		if err := rows.Scan(&itemID); err != nil {
+			return nil, fmt.Errorf("failed to scan liked item: %w", err)
+		}
+		items = append(items, itemID)
+	}
+	return items, rows.Err()
+}
+
+// queryVector sums the keyword vectors of the source items, keeping the
+// strongest terms
+func (k *KeywordIndex) queryVector(ctx context.Context, sources []uuid.UUID) (map[string]float64, error) {
+	rows, err := k.db.PG.Query(ctx, `
+		SELECT term, SUM(weight) AS weight
+		FROM content_keywords
+		WHERE content_id = ANY($1)
+		GROUP BY term
+		ORDER BY weight DESC
+		LIMIT $2
+	`, sources, lexicalQueryTerms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query source keywords: %w", err)
+	}
+	defer rows.Close()
+
+	query := make(map[string]float64)
+	for rows.Next() {
+		var term string
+		var weight float64
+		if err := rows.Scan(&term, &weight); err != nil {
+			return nil, fmt.Errorf("failed to scan source keyword: %w", err)
+		}
+		query[term] = weight
+	}
+	return query, rows.Err()
+}
+
+// lexicalConfidence grows with the share of query terms an item matches
+func lexicalConfidence(matched, queryTerms int) float64 {
+	if queryTerms == 0 {
+		return 0
+	}
+	return math.Min(0.9, 0.3+0.6*float64(matched)/float64(queryTerms))
+}
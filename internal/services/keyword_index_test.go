@@ -0,0 +1,95 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/nlp"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func newTestKeywordIndex(cfg *config.KeywordConfig) *KeywordIndex {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return NewKeywordIndex(nil, cfg, logger)
+}
+
+func keywordTerms(keywords []models.Keyword) []string {
+	terms := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		terms = append(terms, keyword.Term)
+	}
+	return terms
+}
+
+func TestKeywordIndex_Extract(t *testing.T) {
+	index := newTestKeywordIndex(&config.KeywordConfig{Scoring: nlp.SchemeBM25, TitleBoost: 2})
+	for i := 0; i < 100; i++ {
+		index.Apply(&KeywordUpdate{Added: []string{"wireless"}, DocumentDelta: 1, LengthDelta: 10})
+	}
+
+	t.Run("rare terms outrank catalog-wide ones", func(t *testing.T) {
+		keywords := index.Extract("Wireless Headphones", nil, "en")
+		require.Len(t, keywords, 2)
+		assert.Equal(t, []string{"headphone", "wireless"}, keywordTerms(keywords))
+		assert.Equal(t, "headphones", keywords[0].Text)
+		assert.Equal(t, 2.0, keywords[0].Frequency)
+		assert.Greater(t, keywords[0].Weight, keywords[1].Weight)
+	})
+
+	t.Run("title words are boosted", func(t *testing.T) {
+		keywords := index.Extract("Speaker", stringPtr("Portable speaker with bass"), "en")
+		require.NotEmpty(t, keywords)
+		assert.Equal(t, "speaker", keywords[0].Term)
+		assert.Equal(t, 3.0, keywords[0].Frequency)
+	})
+
+	t.Run("max terms", func(t *testing.T) {
+		limited := newTestKeywordIndex(&config.KeywordConfig{MaxTerms: 2})
+		keywords := limited.Extract("alpha beta gamma", stringPtr("gamma delta"), "en")
+		assert.ElementsMatch(t, []string{"gamma", "alpha"}, keywordTerms(keywords))
+	})
+
+	t.Run("nil index weights by frequency", func(t *testing.T) {
+		var none *KeywordIndex
+		keywords := none.Extract("Camera", stringPtr("camera bag"), "en")
+		assert.Equal(t, []string{"camera", "bag"}, keywordTerms(keywords))
+		assert.Empty(t, none.Extract("", nil, "en"))
+	})
+}
+
+func TestKeywordIndex_Apply(t *testing.T) {
+	index := newTestKeywordIndex(&config.KeywordConfig{})
+	index.Apply(&KeywordUpdate{Added: []string{"camera", "lens"}, DocumentDelta: 1, LengthDelta: 4, Brand: "Canon"})
+	index.Apply(&KeywordUpdate{Added: []string{"camera"}, DocumentDelta: 1, LengthDelta: 2})
+	index.Apply(&KeywordUpdate{Removed: []string{"lens"}, LengthDelta: -1})
+
+	corpus, frequencies := index.Stats([]string{"camera", "lens"})
+	assert.Equal(t, nlp.CorpusStats{Documents: 2, TotalLength: 5}, corpus)
+	assert.Equal(t, map[string]int{"camera": 2, "lens": 0}, frequencies)
+	assert.Equal(t, []string{"Canon"}, index.Brands("canon EOS camera", nil))
+}
+
+func TestKeywordIndex_Brands(t *testing.T) {
+	index := newTestKeywordIndex(&config.KeywordConfig{})
+	index.Apply(&KeywordUpdate{Brand: "Sony"})
+	index.Apply(&KeywordUpdate{Brand: "Bose"})
+
+	brands := index.Brands("Sony headphones, better than Bose", map[string]interface{}{"brand": " Apple "})
+	assert.Equal(t, []string{"Apple", "Sony", "Bose"}, brands)
+
+	var none *KeywordIndex
+	assert.Equal(t, []string{"Sony"}, none.Brands("Sony", map[string]interface{}{"brand": "Sony"}))
+	assert.Empty(t, none.Brands("Sony", map[string]interface{}{"brand": "  "}))
+	assert.Empty(t, none.Brands("Sony", nil))
+}
+
+func TestLexicalConfidence(t *testing.T) {
+	assert.Equal(t, 0.0, lexicalConfidence(0, 0))
+	assert.InDelta(t, 0.42, lexicalConfidence(1, 5), 1e-9)
+	assert.InDelta(t, 0.9, lexicalConfidence(5, 5), 1e-9)
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/text/language"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// AnyMarket is the market of an availability rule that applies to all markets
+// without a rule of their own
+const AnyMarket = "*"
+
+// LocaleFilter removes candidates that are not available in the request market
+// and boosts candidates written in the request language
+type LocaleFilter struct {
+	db     *pgxpool.Pool
+	config *config.LocaleConfig
+	logger *logrus.Logger
+}
+
+// contentLocale is the locale information of one candidate item
+type contentLocale struct {
+	Language string
+	Markets  []models.MarketAvailability
+}
+
+func NewLocaleFilter(db *pgxpool.Pool, config *config.LocaleConfig, logger *logrus.Logger) *LocaleFilter {
+	return &LocaleFilter{
+		db:     db,
+		config: config,
+		logger: logger,
+	}
+}
+
+// ParseLocale returns the lowercase language and uppercase region of a BCP 47 tag
+// or Accept-Language header. The region is empty when the tag has none.
+func ParseLocale(locale string) (string, string) {
+	locale = strings.TrimSpace(locale)
+	if locale == "" {
+		return "", ""
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(locale)
+	if err != nil || len(tags) == 0 {
+		return "", ""
+	}
+	tag := tags[0]
+
+	base, confidence := tag.Base()
+	if confidence == language.No {
+		return "", ""
+	}
+	region, confidence := tag.Region()
+	if confidence != language.Exact {
+		return base.String(), ""
+	}
+	return base.String(), region.String()
+}
+
+// ResolveLocale fills the request's Language and Market from its Locale, falling
+// back to the configured default market
+func (lf *LocaleFilter) ResolveLocale(reqCtx *RecommendationContext) {
+	lang, region := ParseLocale(reqCtx.Locale)
+	if reqCtx.Language == "" {
+		reqCtx.Language = lang
+	}
+	if reqCtx.Market == "" {
+		reqCtx.Market = region
+	}
+	if reqCtx.Market == "" {
+		reqCtx.Market = lf.config.DefaultMarket
+	}
+	reqCtx.Language = strings.ToLower(reqCtx.Language)
+	reqCtx.Market = strings.ToUpper(reqCtx.Market)
+}
+
+// Apply drops recommendations unavailable in the request market and boosts those
+// in the request language, then re-sorts by score
+func (lf *LocaleFilter) Apply(
+	ctx context.Context,
+	reqCtx *RecommendationContext,
+	recommendations []models.Recommendation,
+) ([]models.Recommendation, error) {
+	if !lf.config.Enabled || len(recommendations) == 0 || (reqCtx.Market == "" && reqCtx.Language == "") {
+		return recommendations, nil
+	}
+
+	itemIDs := make([]uuid.UUID, len(recommendations))
+	for i, rec := range recommendations {
+		itemIDs[i] = rec.ItemID
+	}
+
+	locales, err := lf.loadContentLocales(ctx, itemIDs)
+	if err != nil {
+		return recommendations, err
+	}
+
+	filtered := applyLocaleRules(recommendations, locales, reqCtx.Language, reqCtx.Market, lf.config, time.Now())
+
+	lf.logger.WithFields(logrus.Fields{
+		"user_id":  reqCtx.UserID,
+		"market":   reqCtx.Market,
+		"language": reqCtx.Language,
+		"dropped":  len(recommendations) - len(filtered),
+	}).Debug("Applied locale filter")
+
+	return filtered, nil
+}
+
+// applyLocaleRules is the pure part of Apply. Items with no locale information
+// are kept unboosted.
+func applyLocaleRules(
+	recommendations []models.Recommendation,
+	locales map[uuid.UUID]*contentLocale,
+	lang, market string,
+	cfg *config.LocaleConfig,
+	now time.Time,
+) []models.Recommendation {
+	filtered := make([]models.Recommendation, 0, len(recommendations))
+	for _, rec := range recommendations {
+		info := locales[rec.ItemID]
+		if info == nil {
+			filtered = append(filtered, rec)
+			continue
+		}
+
+		if market != "" && !IsAvailableInMarket(info.Markets, market, now) {
+			continue
+		}
+
+		if lang != "" && knownLanguage(info.Language) {
+			if info.Language == lang {
+				rec.Score = math.Min(1.0, rec.Score*(1+cfg.LanguageBoost))
+			} else if cfg.FilterLanguage {
+				continue
+			}
+		}
+
+		filtered = append(filtered, rec)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Score > filtered[j].Score
+	})
+	for i := range filtered {
+		filtered[i].Position = i + 1
+	}
+	return filtered
+}
+
+// IsAvailableInMarket evaluates availability rules for a market. No rules means
+// available everywhere; otherwise the market's own rule applies, then the "*"
+// rule, and markets without either are excluded.
+func IsAvailableInMarket(rules []models.MarketAvailability, market string, now time.Time) bool {
+	if len(rules) == 0 {
+		return true
+	}
+
+	var fallback *models.MarketAvailability
+	for i := range rules {
+		switch {
+		case strings.EqualFold(rules[i].Market, market):
+			return ruleAllows(&rules[i], now)
+		case rules[i].Market == AnyMarket:
+			fallback = &rules[i]
+		}
+	}
+	if fallback != nil {
+		return ruleAllows(fallback, now)
+	}
+	return false
+}
+
+func ruleAllows(rule *models.MarketAvailability, now time.Time) bool {
+	if rule.Blocked {
+		return false
+	}
+	if rule.AvailableFrom != nil && now.Before(*rule.AvailableFrom) {
+		return false
+	}
+	if rule.AvailableUntil != nil && !now.Before(*rule.AvailableUntil) {
+		return false
+	}
+	return true
+}
+
+func knownLanguage(lang string) bool {
+	return lang != "" && lang != "unknown"
+}
+
+func (lf *LocaleFilter) loadContentLocales(ctx context.Context, itemIDs []uuid.UUID) (map[uuid.UUID]*contentLocale, error) {
+	locales := make(map[uuid.UUID]*contentLocale, len(itemIDs))
+
+	rows, err := lf.db.Query(ctx, `
+		SELECT id, COALESCE(language, '')
+		FROM content_items
+		WHERE id = ANY($1)
+	`, itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query content languages: %w", err)
+	}
+	for rows.Next() {
+		var id uuid.UUID
+		var lang string
+		if err := rows.Scan(&id, &lang); err != nil {
+			continue
+		}
+		locales[id] = &contentLocale{Language: lang}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	markets, err := LoadMarketAvailability(ctx, lf.db, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	for id, rules := range markets {
+		if info := locales[id]; info != nil {
+			info.Markets = rules
+		}
+	}
+
+	return locales, nil
+}
+
+// LoadMarketAvailability returns the availability rules of the given items
+func LoadMarketAvailability(ctx context.Context, db *pgxpool.Pool, itemIDs []uuid.UUID) (map[uuid.UUID][]models.MarketAvailability, error) {
+	rows, err := db.Query(ctx, `
+		SELECT content_id, market, blocked, COALESCE(currency, ''), price::float8, available_from, available_until
+		FROM content_market_availability
+		WHERE content_id = ANY($1)
+	`, itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query market availability: %w", err)
+	}
+	defer rows.Close()
+
+	markets := make(map[uuid.UUID][]models.MarketAvailability)
+	for rows.Next() {
+		var id uuid.UUID
+		var rule models.MarketAvailability
+		if err := rows.Scan(&id, &rule.Market, &rule.Blocked, &rule.Currency, &rule.Price,
+			&rule.AvailableFrom, &rule.AvailableUntil); err != nil {
+			continue
+		}
+		markets[id] = append(markets[id], rule)
+	}
+
+	return markets, rows.Err()
+}
+
+// SaveMarketAvailability replaces the availability rules of a content item
+func SaveMarketAvailability(ctx context.Context, tx pgx.Tx, contentID uuid.UUID, markets []models.MarketAvailability) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM content_market_availability WHERE content_id = $1`, contentID); err != nil {
+		return fmt.Errorf("failed to clear market availability: %w", err)
+	}
+
+	for _, rule := range markets {
+		var currency *string
+		if rule.Currency != "" {
+			currency = &rule.Currency
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO content_market_availability (
+				content_id, market, blocked, currency, price, available_from, available_until
+			) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (content_id, market) DO UPDATE SET
+				blocked = EXCLUDED.blocked,
+				currency = EXCLUDED.currency,
+				price = EXCLUDED.price,
+				available_from = EXCLUDED.available_from,
+				available_until = EXCLUDED.available_until
+		`, contentID, rule.Market, rule.Blocked, currency, rule.Price, rule.AvailableFrom, rule.AvailableUntil)
+		if err != nil {
+			return fmt.Errorf("failed to store market availability for %s: %w", rule.Market, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		locale         string
+		expectedLang   string
+		expectedRegion string
+	}{
+		{"es-MX", "es", "MX"},
+		{"en_GB", "en", "GB"},
+		{"fr", "fr", ""},
+		{"pt-BR,pt;q=0.9,en;q=0.8", "pt", "BR"},
+		{"", "", ""},
+		{"not a locale!", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			lang, region := ParseLocale(tt.locale)
+			assert.Equal(t, tt.expectedLang, lang)
+			assert.Equal(t, tt.expectedRegion, region)
+		})
+	}
+}
+
+func TestLocaleFilter_ResolveLocale(t *testing.T) {
+	filter := NewLocaleFilter(nil, &config.LocaleConfig{Enabled: true, DefaultMarket: "US"}, logrus.New())
+
+	reqCtx := &RecommendationContext{Locale: "es-mx"}
+	filter.ResolveLocale(reqCtx)
+	assert.Equal(t, "es", reqCtx.Language)
+	assert.Equal(t, "MX", reqCtx.Market)
+
+	// An explicit market wins over the locale region
+	reqCtx = &RecommendationContext{Locale: "es-MX", Market: "es"}
+	filter.ResolveLocale(reqCtx)
+	assert.Equal(t, "ES", reqCtx.Market)
+
+	// Default market when neither is given
+	reqCtx = &RecommendationContext{Locale: "de"}
+	filter.ResolveLocale(reqCtx)
+	assert.Equal(t, "de", reqCtx.Language)
+	assert.Equal(t, "US", reqCtx.Market)
+}
+
+func TestIsAvailableInMarket(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-24 * time.Hour)
+	future := now.Add(24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		rules    []models.MarketAvailability
+		market   string
+		expected bool
+	}{
+		{"no rules means everywhere", nil, "DE", true},
+		{"listed market", []models.MarketAvailability{{Market: "DE"}}, "de", true},
+		{"unlisted market", []models.MarketAvailability{{Market: "DE"}}, "FR", false},
+		{"wildcard", []models.MarketAvailability{{Market: "DE"}, {Market: AnyMarket}}, "FR", true},
+		{"blocked market beats wildcard", []models.MarketAvailability{{Market: AnyMarket}, {Market: "FR", Blocked: true}}, "FR", false},
+		{"not yet available", []models.MarketAvailability{{Market: "DE", AvailableFrom: &future}}, "DE", false},
+		{"no longer available", []models.MarketAvailability{{Market: "DE", AvailableUntil: &past}}, "DE", false},
+		{"inside window", []models.MarketAvailability{{Market: "DE", AvailableFrom: &past, AvailableUntil: &future}}, "DE", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsAvailableInMarket(tt.rules, tt.market, now))
+		})
+	}
+}
+
+func TestApplyLocaleRules(t *testing.T) {
+	english, spanish, unknown, unavailable, missing := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	recommendations := []models.Recommendation{
+		{ItemID: english, Score: 0.80, Position: 1},
+		{ItemID: spanish, Score: 0.75, Position: 2},
+		{ItemID: unknown, Score: 0.70, Position: 3},
+		{ItemID: unavailable, Score: 0.65, Position: 4},
+		{ItemID: missing, Score: 0.60, Position: 5},
+	}
+	locales := map[uuid.UUID]*contentLocale{
+		english:     {Language: "en"},
+		spanish:     {Language: "es", Markets: []models.MarketAvailability{{Market: "ES"}, {Market: "MX"}}},
+		unknown:     {Language: "unknown"},
+		unavailable: {Language: "es", Markets: []models.MarketAvailability{{Market: "ES"}}},
+	}
+	now := time.Now()
+
+	t.Run("boosts language and filters market", func(t *testing.T) {
+		cfg := &config.LocaleConfig{Enabled: true, LanguageBoost: 0.2}
+		result := applyLocaleRules(recommendations, locales, "es", "MX", cfg, now)
+
+		ids := make([]uuid.UUID, len(result))
+		for i, rec := range result {
+			ids[i] = rec.ItemID
+			assert.Equal(t, i+1, rec.Position)
+		}
+		assert.Equal(t, []uuid.UUID{spanish, english, unknown, missing}, ids)
+		assert.InDelta(t, 0.90, result[0].Score, 1e-9)
+	})
+
+	t.Run("filter language keeps unknown", func(t *testing.T) {
+		cfg := &config.LocaleConfig{Enabled: true, LanguageBoost: 0.2, FilterLanguage: true}
+		result := applyLocaleRules(recommendations, locales, "es", "", cfg, now)
+
+		ids := make([]uuid.UUID, len(result))
+		for i, rec := range result {
+			ids[i] = rec.ItemID
+		}
+		assert.Equal(t, []uuid.UUID{spanish, unavailable, unknown, missing}, ids)
+	})
+
+	t.Run("boost is capped", func(t *testing.T) {
+		cfg := &config.LocaleConfig{Enabled: true, LanguageBoost: 0.5}
+		result := applyLocaleRules([]models.Recommendation{{ItemID: english, Score: 0.9}}, locales, "en", "", cfg, now)
+		assert.Equal(t, 1.0, result[0].Score)
+	})
+}
+
+func TestDataPreprocessor_NormalizeMarkets(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	preprocessor := NewDataPreprocessor(logger)
+
+	price := 19.99
+	content := models.ContentIngestionRequest{
+		Markets: []models.MarketAvailability{
+			{Market: "de", Currency: "eur", Price: &price},
+			{Market: "*"},
+			{Market: "DE"},
+			{Market: "Germany"},
+			{Market: "FR", Currency: "euro"},
+		},
+	}
+	result := &ProcessingResult{ProcessedContent: &models.ContentItem{}}
+
+	err := preprocessor.normalizeMarkets(content, result)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate rule for market DE")
+	assert.Contains(t, err.Error(), `invalid market "GERMANY"`)
+	assert.Contains(t, err.Error(), `invalid currency "EURO"`)
+	assert.Equal(t, []models.MarketAvailability{
+		{Market: "DE", Currency: "EUR", Price: &price},
+		{Market: AnyMarket},
+	}, result.ProcessedContent.Markets)
+}
@@ -28,6 +28,7 @@ type MetricEvent struct {
 	Context          map[string]interface{} `json:"context"`
 	UserTier         string                 `json:"user_tier,omitempty"`
 	ContentCategory  string                 `json:"content_category,omitempty"`
+	Market           string                 `json:"market,omitempty"` // ISO 3166-1 alpha-2
 }
 
 // BusinessMetrics holds aggregated business metrics
@@ -40,6 +41,7 @@ type BusinessMetrics struct {
 	ConversionRate       float64                     `json:"conversion_rate"`
 	AvgConfidenceScore   float64                     `json:"avg_confidence_score"`
 	AlgorithmPerformance map[string]AlgorithmMetrics `json:"algorithm_performance"`
+	MarketPerformance    map[string]AlgorithmMetrics `json:"market_performance"` // Keyed by market, "unknown" when not recorded
 }
 
 // AlgorithmMetrics holds performance metrics for a specific algorithm
@@ -111,13 +113,13 @@ func NewMetricsCollector(db *pgxpool.Pool) *MetricsCollector {
 
 		clickThroughRate: promauto.NewGaugeVec(prometheus.GaugeOpts{
 			Name: "click_through_rate",
-			Help: "Click-through rate by algorithm and user segment",
-		}, []string{"algorithm", "user_tier", "content_category"}),
+			Help: "Click-through rate by algorithm, user segment and market",
+		}, []string{"algorithm", "user_tier", "content_category", "market"}),
 
 		conversionRate: promauto.NewGaugeVec(prometheus.GaugeOpts{
 			Name: "conversion_rate",
-			Help: "Conversion rate by algorithm and user segment",
-		}, []string{"algorithm", "user_tier", "content_category"}),
+			Help: "Conversion rate by algorithm, user segment and market",
+		}, []string{"algorithm", "user_tier", "content_category", "market"}),
 
 		userEngagement: promauto.NewGaugeVec(prometheus.GaugeOpts{
 			Name: "user_engagement_score",
@@ -221,8 +223,8 @@ func (mc *MetricsCollector) insertBatch(events []MetricEvent) {
 			INSERT INTO recommendation_metrics (
 				user_id, item_id, recommendation_id, event_type, algorithm_used,
 				position_in_list, confidence_score, timestamp, session_id, context,
-				user_tier, content_category
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+				user_tier, content_category, market
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		`,
 			event.UserID,
 			event.ItemID,
@@ -236,6 +238,7 @@ func (mc *MetricsCollector) insertBatch(events []MetricEvent) {
 			string(contextJSON),
 			event.UserTier,
 			event.ContentCategory,
+			nullableString(event.Market),
 		)
 		if err != nil {
 			log.Printf("Error inserting metric event: %v", err)
@@ -266,18 +269,19 @@ func (mc *MetricsCollector) calculateAndUpdateMetrics() {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
 
-	// Calculate CTR by algorithm, user tier, and content category
+	// Calculate CTR by algorithm, user tier, content category and market
 	ctrQuery := `
 		SELECT 
 			algorithm_used,
 			COALESCE(user_tier, 'unknown') as user_tier,
 			COALESCE(content_category, 'unknown') as content_category,
+			COALESCE(market, 'unknown') as market,
 			COUNT(CASE WHEN event_type = 'impression' THEN 1 END) as impressions,
 			COUNT(CASE WHEN event_type = 'click' THEN 1 END) as clicks,
 			COUNT(CASE WHEN event_type = 'conversion' THEN 1 END) as conversions
 		FROM recommendation_metrics 
 		WHERE timestamp >= NOW() - INTERVAL '1 hour'
-		GROUP BY algorithm_used, user_tier, content_category
+		GROUP BY algorithm_used, user_tier, content_category, market
 		HAVING COUNT(CASE WHEN event_type = 'impression' THEN 1 END) > 0
 	`
 
@@ -289,10 +293,10 @@ func (mc *MetricsCollector) calculateAndUpdateMetrics() {
 	defer rows.Close()
 
 	for rows.Next() {
-		var algorithm, userTier, contentCategory string
+		var algorithm, userTier, contentCategory, market string
 		var impressions, clicks, conversions int
 
-		err := rows.Scan(&algorithm, &userTier, &contentCategory, &impressions, &clicks, &conversions)
+		err := rows.Scan(&algorithm, &userTier, &contentCategory, &market, &impressions, &clicks, &conversions)
 		if err != nil {
 			log.Printf("Error scanning CTR row: %v", err)
 			continue
@@ -300,12 +304,12 @@ func (mc *MetricsCollector) calculateAndUpdateMetrics() {
 
 		// Calculate and update CTR
 		ctr := float64(clicks) / float64(impressions) * 100
-		mc.clickThroughRate.WithLabelValues(algorithm, userTier, contentCategory).Set(ctr)
+		mc.clickThroughRate.WithLabelValues(algorithm, userTier, contentCategory, market).Set(ctr)
 
 		// Calculate and update conversion rate
 		if clicks > 0 {
 			convRate := float64(conversions) / float64(clicks) * 100
-			mc.conversionRate.WithLabelValues(algorithm, userTier, contentCategory).Set(convRate)
+			mc.conversionRate.WithLabelValues(algorithm, userTier, contentCategory, market).Set(convRate)
 		}
 
 		// Update algorithm performance score (composite metric)
@@ -405,11 +409,21 @@ func (mc *MetricsCollector) GetBusinessMetrics(startDate, endDate time.Time) (*B
 		metrics.ConversionRate = float64(metrics.TotalConversions) / float64(metrics.TotalClicks) * 100
 	}
 
-	// Get algorithm-specific metrics
-	metrics.AlgorithmPerformance = make(map[string]AlgorithmMetrics)
-	algorithmQuery := `
+	// Get algorithm- and market-specific metrics
+	metrics.AlgorithmPerformance = mc.queryDimensionMetrics(ctx, "algorithm_used", startDate, endDate)
+	metrics.MarketPerformance = mc.queryDimensionMetrics(ctx, "COALESCE(market, 'unknown')", startDate, endDate)
+
+	return &metrics, nil
+}
+
+// queryDimensionMetrics aggregates events in the date range grouped by a column
+// expression. Errors yield an empty map so callers still get partial results.
+func (mc *MetricsCollector) queryDimensionMetrics(ctx context.Context, dimension string, startDate, endDate time.Time) map[string]AlgorithmMetrics {
+	results := make(map[string]AlgorithmMetrics)
+
+	query := fmt.Sprintf(`
 		SELECT 
-			algorithm_used,
+			%[1]s,
 			COUNT(CASE WHEN event_type = 'impression' THEN 1 END) as impressions,
 			COUNT(CASE WHEN event_type = 'click' THEN 1 END) as clicks,
 			COUNT(CASE WHEN event_type = 'conversion' THEN 1 END) as conversions,
@@ -417,48 +431,55 @@ func (mc *MetricsCollector) GetBusinessMetrics(startDate, endDate time.Time) (*B
 			AVG(position_in_list) as avg_position
 		FROM recommendation_metrics 
 		WHERE timestamp BETWEEN $1 AND $2
-		GROUP BY algorithm_used
-	`
+		GROUP BY %[1]s
+	`, dimension)
 
-	rows, err := mc.db.Query(ctx, algorithmQuery, startDate, endDate)
+	rows, err := mc.db.Query(ctx, query, startDate, endDate)
 	if err != nil {
-		return &metrics, nil // Return partial results
+		return results
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var algorithm string
+		var key string
 		var impressions, clicks, conversions int
 		var avgConfidence, avgPosition pgtype.Float8
 
-		err := rows.Scan(&algorithm, &impressions, &clicks, &conversions, &avgConfidence, &avgPosition)
+		err := rows.Scan(&key, &impressions, &clicks, &conversions, &avgConfidence, &avgPosition)
 		if err != nil {
 			continue
 		}
 
-		algMetrics := AlgorithmMetrics{
+		dimMetrics := AlgorithmMetrics{
 			Impressions: impressions,
 			Clicks:      clicks,
 			Conversions: conversions,
 		}
 
 		if impressions > 0 {
-			algMetrics.CTR = float64(clicks) / float64(impressions) * 100
+			dimMetrics.CTR = float64(clicks) / float64(impressions) * 100
 		}
 		if clicks > 0 {
-			algMetrics.ConversionRate = float64(conversions) / float64(clicks) * 100
+			dimMetrics.ConversionRate = float64(conversions) / float64(clicks) * 100
 		}
 		if avgConfidence.Valid {
-			algMetrics.AvgConfidence = avgConfidence.Float64
+			dimMetrics.AvgConfidence = avgConfidence.Float64
 		}
 		if avgPosition.Valid {
-			algMetrics.AvgPosition = avgPosition.Float64
+			dimMetrics.AvgPosition = avgPosition.Float64
 		}
 
-		metrics.AlgorithmPerformance[algorithm] = algMetrics
+		results[key] = dimMetrics
 	}
 
-	return &metrics, nil
+	return results
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
 }
 
 // Close gracefully shuts down the metrics collector
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// Suppression kinds
+const (
+	SuppressItem     = "item"
+	SuppressCategory = "category"
+	SuppressBrand    = "brand"
+)
+
+// negativeFeedbackStrength is the strength a category or brand suppression
+// gains from one negative feedback, up to 1
+const negativeFeedbackStrength = 0.5
+
+var ErrSuppressionNotFound = errors.New("suppression not found")
+
+// negativeFeedbackTypes are the recommendation feedback types that suppress
+// the item's categories and brand
+var negativeFeedbackTypes = map[string]bool{
+	"negative":       true,
+	"not_interested": true,
+	"not_relevant":   true,
+	"inappropriate":  true,
+}
+
+// NegativeFeedback turns what users reject into ranking penalties. Negative
+// recommendation feedback suppresses the item's categories and brand with a
+// strength that decays over time, and the configured hard feedback types also
+// hide the item itself until the user undoes it. Candidates similar to the
+// profile's negative vector, built from dislikes, are penalized as well.
+type NegativeFeedback struct {
+	db     *pgxpool.Pool
+	redis  *redis.Client // Recommendation caches invalidated when suppressions change
+	config *config.NegativeFeedbackConfig
+	logger *logrus.Logger
+
+	hardTypes map[string]bool
+}
+
+// negativeCandidate is what the penalties look at for one candidate item
+type negativeCandidate struct {
+	Categories []string
+	Brand      string  // Lowercase
+	Similarity float64 // Cosine similarity to the negative vector; 0 without one
+}
+
+func NewNegativeFeedback(db *pgxpool.Pool, redis *redis.Client, cfg *config.NegativeFeedbackConfig, logger *logrus.Logger) *NegativeFeedback {
+	hardTypes := make(map[string]bool)
+	for _, feedbackType := range cfg.HardFeedbackTypes {
+		hardTypes[feedbackType] = true
+	}
+	return &NegativeFeedback{
+		db:        db,
+		redis:     redis,
+		config:    cfg,
+		logger:    logger,
+		hardTypes: hardTypes,
+	}
+}
+
+// RecordFeedback suppresses what a negative recommendation feedback rejected.
+// Other feedback types are ignored.
+func (n *NegativeFeedback) RecordFeedback(ctx context.Context, feedback *models.RecommendationFeedback) error {
+	if !negativeFeedbackTypes[feedback.FeedbackType] {
+		return nil
+	}
+
+	var categories []string
+	var brand string
+	err := n.db.QueryRow(ctx, `
+		SELECT COALESCE(categories, '{}'), COALESCE(metadata->>'brand', '')
+		FROM content_items
+		WHERE id = $1`, feedback.ItemID).Scan(&categories, &brand)
+	if err != nil {
+		return fmt.Errorf("failed to load feedback item: %w", err)
+	}
+
+	tx, err := n.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Soft suppressions decay to their current strength before gaining more
+	upsertSoft := `
+		INSERT INTO user_suppressions (user_id, kind, value, strength, hard, source)
+		VALUES ($1, $2, $3, $4, false, $5)
+		ON CONFLICT (user_id, kind, value) DO UPDATE SET
+			strength = CASE WHEN user_suppressions.hard THEN 1.0 ELSE LEAST(1.0,
+				user_suppressions.strength * POWER(0.5, EXTRACT(EPOCH FROM NOW() - user_suppressions.updated_at) / $6)
+				+ EXCLUDED.strength) END,
+			source = EXCLUDED.source,
+			updated_at = NOW()`
+	halfLife := n.halfLife().Seconds()
+
+	for _, category := range categories {
+		if _, err := tx.Exec(ctx, upsertSoft, feedback.UserID, SuppressCategory, category,
+			negativeFeedbackStrength, feedback.FeedbackType, halfLife); err != nil {
+			return fmt.Errorf("failed to suppress category: %w", err)
+		}
+	}
+	if brand = strings.ToLower(strings.TrimSpace(brand)); brand != "" {
+		if _, err := tx.Exec(ctx, upsertSoft, feedback.UserID, SuppressBrand, brand,
+			negativeFeedbackStrength, feedback.FeedbackType, halfLife); err != nil {
+			return fmt.Errorf("failed to suppress brand: %w", err)
+		}
+	}
+
+	if n.hardTypes[feedback.FeedbackType] {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO user_suppressions (user_id, kind, value, strength, hard, source)
+			VALUES ($1, $2, $3, 1.0, true, $4)
+			ON CONFLICT (user_id, kind, value) DO UPDATE SET
+				strength = 1.0, hard = true, source = EXCLUDED.source, updated_at = NOW()`,
+			feedback.UserID, SuppressItem, feedback.ItemID.String(), feedback.FeedbackType)
+		if err != nil {
+			return fmt.Errorf("failed to suppress item: %w", err)
+		}
+	}
+
+	// Drop soft suppressions that have decayed away
+	_, err = tx.Exec(ctx, `
+		DELETE FROM user_suppressions
+		WHERE user_id = $1 AND NOT hard
+			AND strength * POWER(0.5, EXTRACT(EPOCH FROM NOW() - updated_at) / $2) < $3`,
+		feedback.UserID, halfLife, n.minStrength())
+	if err != nil {
+		return fmt.Errorf("failed to prune suppressions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit suppressions: %w", err)
+	}
+
+	n.logger.WithFields(logrus.Fields{
+		"user_id":       feedback.UserID,
+		"item_id":       feedback.ItemID,
+		"feedback_type": feedback.FeedbackType,
+		"categories":    len(categories),
+		"hard":          n.hardTypes[feedback.FeedbackType],
+	}).Debug("Recorded negative feedback")
+
+	return nil
+}
+
+// List returns the user's active suppressions with their decayed strength,
+// hard suppressions first, then strongest first
+func (n *NegativeFeedback) List(ctx context.Context, userID uuid.UUID) ([]models.Suppression, error) {
+	suppressions, err := n.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(suppressions, func(i, j int) bool {
+		if suppressions[i].Hard != suppressions[j].Hard {
+			return suppressions[i].Hard
+		}
+		return suppressions[i].Strength > suppressions[j].Strength
+	})
+	return suppressions, nil
+}
+
+// Remove undoes one of the user's suppressions
+func (n *NegativeFeedback) Remove(ctx context.Context, userID, suppressionID uuid.UUID) error {
+	tag, err := n.db.Exec(ctx, `DELETE FROM user_suppressions WHERE id = $1 AND user_id = $2`, suppressionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete suppression: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSuppressionNotFound
+	}
+
+	n.invalidateRecommendations(ctx, userID)
+	return nil
+}
+
+// Apply drops hard suppressed recommendations and penalizes those in
+// suppressed categories or brands or similar to the negative vector, then
+// re-sorts by score
+func (n *NegativeFeedback) Apply(
+	ctx context.Context,
+	userID uuid.UUID,
+	negativeVector []float32,
+	recommendations []models.Recommendation,
+) ([]models.Recommendation, error) {
+	if len(recommendations) == 0 {
+		return recommendations, nil
+	}
+
+	suppressions, err := n.load(ctx, userID)
+	if err != nil {
+		return recommendations, err
+	}
+	if len(suppressions) == 0 && len(negativeVector) == 0 {
+		return recommendations, nil
+	}
+
+	itemIDs := make([]uuid.UUID, len(recommendations))
+	for i, rec := range recommendations {
+		itemIDs[i] = rec.ItemID
+	}
+	candidates, err := n.loadCandidates(ctx, itemIDs, negativeVector)
+	if err != nil {
+		return recommendations, err
+	}
+
+	filtered := applyNegativeFeedback(recommendations, candidates, suppressions, n.config)
+
+	n.logger.WithFields(logrus.Fields{
+		"user_id":      userID,
+		"suppressions": len(suppressions),
+		"dropped":      len(recommendations) - len(filtered),
+	}).Debug("Applied negative feedback")
+
+	return filtered, nil
+}
+
+// applyNegativeFeedback is the pure part of Apply. Each candidate's score is
+// multiplied by one minus the penalty of its strongest category or brand
+// suppression and by one minus the penalty for its similarity to the negative
+// vector above the threshold, scaled to [0,1].
+func applyNegativeFeedback(
+	recommendations []models.Recommendation,
+	candidates map[uuid.UUID]*negativeCandidate,
+	suppressions []models.Suppression,
+	cfg *config.NegativeFeedbackConfig,
+) []models.Recommendation {
+	hidden := make(map[string]bool)
+	strengths := make(map[string]float64)
+	for _, suppression := range suppressions {
+		if suppression.Kind == SuppressItem {
+			hidden[suppression.Value] = true
+			continue
+		}
+		key := suppression.Kind + ":" + suppression.Value
+		strengths[key] = math.Max(strengths[key], suppression.Strength)
+	}
+
+	filtered := make([]models.Recommendation, 0, len(recommendations))
+	for _, rec := range recommendations {
+		if hidden[rec.ItemID.String()] {
+			continue
+		}
+
+		if candidate := candidates[rec.ItemID]; candidate != nil {
+			strength := strengths[SuppressBrand+":"+candidate.Brand]
+			for _, category := range candidate.Categories {
+				strength = math.Max(strength, strengths[SuppressCategory+":"+category])
+			}
+			rec.Score *= 1 - cfg.SuppressionPenalty*strength
+
+			if candidate.Similarity > cfg.SimilarityThreshold && cfg.SimilarityThreshold < 1 {
+				excess := (candidate.Similarity - cfg.SimilarityThreshold) / (1 - cfg.SimilarityThreshold)
+				rec.Score *= 1 - cfg.VectorPenalty*math.Min(excess, 1)
+			}
+		}
+
+		filtered = append(filtered, rec)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Score > filtered[j].Score
+	})
+	for i := range filtered {
+		filtered[i].Position = i + 1
+	}
+	return filtered
+}
+
+// decayedStrength returns a soft suppression's strength after halving once
+// per half-life since it was last updated
+func decayedStrength(strength float64, updatedAt time.Time, halfLife time.Duration, now time.Time) float64 {
+	age := now.Sub(updatedAt)
+	if age <= 0 || halfLife <= 0 {
+		return strength
+	}
+	return strength * math.Pow(0.5, age.Hours()/halfLife.Hours())
+}
+
+// load returns the user's hard suppressions and soft suppressions still above
+// the minimum strength, with their decayed strength
+func (n *NegativeFeedback) load(ctx context.Context, userID uuid.UUID) ([]models.Suppression, error) {
+	rows, err := n.db.Query(ctx, `
+		SELECT id, user_id, kind, value, strength, hard, source, created_at, updated_at
+		FROM user_suppressions
+		WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suppressions: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var suppressions []models.Suppression
+	for rows.Next() {
+		var suppression models.Suppression
+		if err := rows.Scan(&suppression.ID, &suppression.UserID, &suppression.Kind, &suppression.Value,
+			&suppression.Strength, &suppression.Hard, &suppression.Source,
+			&suppression.CreatedAt, &suppression.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan suppression: %w", err)
+		}
+		if !suppression.Hard {
+			suppression.Strength = decayedStrength(suppression.Strength, suppression.UpdatedAt, n.halfLife(), now)
+			if suppression.Strength < n.minStrength() {
+				continue
+			}
+		}
+		suppressions = append(suppressions, suppression)
+	}
+	return suppressions, rows.Err()
+}
+
+func (n *NegativeFeedback) loadCandidates(ctx context.Context, itemIDs []uuid.UUID, negativeVector []float32) (map[uuid.UUID]*negativeCandidate, error) {
+	query := `
+		SELECT id, COALESCE(categories, '{}'), COALESCE(metadata->>'brand', ''), 0::float8
+		FROM content_items
+		WHERE id = ANY($1)`
+	args := []interface{}{itemIDs}
+	if len(negativeVector) > 0 {
+		query = `
+			SELECT id, COALESCE(categories, '{}'), COALESCE(metadata->>'brand', ''),
+				COALESCE(1 - (embedding <=> $2), 0)::float8
+			FROM content_items
+			WHERE id = ANY($1)`
+		args = append(args, negativeVector)
+	}
+
+	rows, err := n.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query candidate items: %w", err)
+	}
+	defer rows.Close()
+
+	candidates := make(map[uuid.UUID]*negativeCandidate, len(itemIDs))
+	for rows.Next() {
+		var id uuid.UUID
+		var candidate negativeCandidate
+		if err := rows.Scan(&id, &candidate.Categories, &candidate.Brand, &candidate.Similarity); err != nil {
+			continue
+		}
+		candidate.Brand = strings.ToLower(strings.TrimSpace(candidate.Brand))
+		candidates[id] = &candidate
+	}
+	return candidates, rows.Err()
+}
+
+// invalidateRecommendations drops the user's cached recommendations so an
+// undone suppression shows up immediately
+func (n *NegativeFeedback) invalidateRecommendations(ctx context.Context, userID uuid.UUID) {
+	if n.redis == nil {
+		return
+	}
+	keys, err := n.redis.Keys(ctx, fmt.Sprintf("orchestration:%s:*", userID.String())).Result()
+	if err == nil && len(keys) > 0 {
+		err = n.redis.Del(ctx, keys...).Err()
+	}
+	if err != nil {
+		n.logger.WithError(err).WithField("user_id", userID).Warn("Failed to invalidate recommendations")
+	}
+}
+
+func (n *NegativeFeedback) halfLife() time.Duration {
+	if n.config.HalfLife <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return n.config.HalfLife
+}
+
+func (n *NegativeFeedback) minStrength() float64 {
+	if n.config.MinStrength <= 0 {
+		return 0.05
+	}
+	return n.config.MinStrength
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func negativeFeedbackTestConfig() *config.NegativeFeedbackConfig {
+	return &config.NegativeFeedbackConfig{
+		Enabled:             true,
+		VectorPenalty:       0.5,
+		SimilarityThreshold: 0.6,
+		SuppressionPenalty:  0.5,
+		HalfLife:            720 * time.Hour,
+		MinStrength:         0.05,
+		HardFeedbackTypes:   []string{"not_interested", "inappropriate"},
+	}
+}
+
+func TestApplyNegativeFeedback(t *testing.T) {
+	hidden, tent, boots, stove, plain := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	recommendations := []models.Recommendation{
+		{ItemID: hidden, Score: 0.95},
+		{ItemID: tent, Score: 0.9},
+		{ItemID: boots, Score: 0.85},
+		{ItemID: stove, Score: 0.8},
+		{ItemID: plain, Score: 0.5},
+	}
+	candidates := map[uuid.UUID]*negativeCandidate{
+		tent:  {Categories: []string{"camping", "outdoor"}},
+		boots: {Categories: []string{"footwear"}, Brand: "acme"},
+		stove: {Categories: []string{"kitchen"}, Similarity: 0.8},
+		plain: {Categories: []string{"books"}, Similarity: 0.5},
+	}
+	suppressions := []models.Suppression{
+		{Kind: SuppressItem, Value: hidden.String(), Strength: 1, Hard: true},
+		{Kind: SuppressCategory, Value: "camping", Strength: 0.4},
+		{Kind: SuppressCategory, Value: "outdoor", Strength: 0.8},
+		{Kind: SuppressBrand, Value: "acme", Strength: 1},
+	}
+
+	filtered := applyNegativeFeedback(recommendations, candidates, suppressions, negativeFeedbackTestConfig())
+	require.Len(t, filtered, 4, "hard suppressed items are dropped")
+
+	scores := make(map[uuid.UUID]float64)
+	for i, rec := range filtered {
+		assert.Equal(t, i+1, rec.Position)
+		scores[rec.ItemID] = rec.Score
+	}
+	assert.InDelta(t, 0.9*0.6, scores[tent], 1e-9, "the strongest category suppression applies")
+	assert.InDelta(t, 0.85*0.5, scores[boots], 1e-9)
+	assert.InDelta(t, 0.8*0.75, scores[stove], 1e-9, "penalty scales with similarity above the threshold")
+	assert.InDelta(t, 0.5, scores[plain], 1e-9, "dissimilar items are not penalized")
+	assert.Equal(t, stove, filtered[0].ItemID)
+}
+
+func TestApplyNegativeFeedback_UnknownItems(t *testing.T) {
+	itemID := uuid.New()
+	filtered := applyNegativeFeedback(
+		[]models.Recommendation{{ItemID: itemID, Score: 0.7}},
+		nil,
+		[]models.Suppression{{Kind: SuppressCategory, Value: "books", Strength: 1}},
+		negativeFeedbackTestConfig(),
+	)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, 0.7, filtered[0].Score)
+}
+
+func TestDecayedStrength(t *testing.T) {
+	now := time.Now()
+	halfLife := 30 * 24 * time.Hour
+
+	assert.InDelta(t, 0.8, decayedStrength(0.8, now, halfLife, now), 1e-9)
+	assert.InDelta(t, 0.4, decayedStrength(0.8, now.Add(-halfLife), halfLife, now), 1e-9)
+	assert.InDelta(t, 0.2, decayedStrength(0.8, now.Add(-2*halfLife), halfLife, now), 1e-9)
+	assert.InDelta(t, 0.8, decayedStrength(0.8, now.Add(time.Hour), halfLife, now), 1e-9, "future updates do not grow")
+}
+
+func TestNegativeFeedback_RecordFeedbackIgnoresOtherTypes(t *testing.T) {
+	negativeFeedback := NewNegativeFeedback(nil, nil, negativeFeedbackTestConfig(), logrus.New())
+
+	err := negativeFeedback.RecordFeedback(context.Background(), &models.RecommendationFeedback{
+		UserID:       uuid.New(),
+		ItemID:       uuid.New(),
+		FeedbackType: "positive",
+	})
+	assert.NoError(t, err)
+}
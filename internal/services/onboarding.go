@@ -0,0 +1,340 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// ErrNoOnboardingItems is returned when none of the selected items exist
+var ErrNoOnboardingItems = errors.New("none of the selected items are available")
+
+// OnboardingService runs the cold-start questionnaire. It offers new users a
+// set of items covering the catalogue's categories and embedding space and
+// turns their picks into a preference vector and favourite categories, so the
+// next recommendation request is personalized.
+type OnboardingService struct {
+	db       *pgxpool.Pool
+	redis    *redis.Client
+	users    *UserInteractionService
+	taxonomy *TaxonomyService // Canonicalizes picked categories; nil uses the default taxonomy
+	config   *config.OnboardingConfig
+	logger   *logrus.Logger
+}
+
+// onboardingCandidate is an item that may be offered during onboarding
+type onboardingCandidate struct {
+	Item      models.OnboardingItem
+	Embedding []float32 // L2 normalized
+}
+
+func NewOnboardingService(
+	db *pgxpool.Pool,
+	redis *redis.Client,
+	users *UserInteractionService,
+	taxonomy *TaxonomyService,
+	config *config.OnboardingConfig,
+	logger *logrus.Logger,
+) *OnboardingService {
+	return &OnboardingService{
+		db:       db,
+		redis:    redis,
+		users:    users,
+		taxonomy: taxonomy,
+		config:   config,
+		logger:   logger,
+	}
+}
+
+// DefaultItemCount is the number of items offered when the request names none
+func (o *OnboardingService) DefaultItemCount() int {
+	if o.config.ItemCount <= 0 {
+		return 12
+	}
+	return o.config.ItemCount
+}
+
+// Items returns count items to pick from. The set is the same for every user
+// and cached for the configured TTL.
+func (o *OnboardingService) Items(ctx context.Context, count int) ([]models.OnboardingItem, error) {
+	cacheKey := fmt.Sprintf("onboarding:items:%d", count)
+	if o.redis != nil {
+		if cached, err := o.redis.Get(ctx, cacheKey).Bytes(); err == nil {
+			var items []models.OnboardingItem
+			if json.Unmarshal(cached, &items) == nil {
+				return items, nil
+			}
+		}
+	}
+
+	candidates, err := o.loadCandidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := selectOnboardingItems(candidates, count)
+	items := make([]models.OnboardingItem, len(selected))
+	for i, candidate := range selected {
+		items[i] = candidate.Item
+	}
+
+	if o.redis != nil && len(items) > 0 {
+		if data, err := json.Marshal(items); err == nil {
+			o.redis.Set(ctx, cacheKey, data, o.cacheTTL())
+		}
+	}
+
+	o.logger.WithFields(logrus.Fields{
+		"candidates": len(candidates),
+		"items":      len(items),
+	}).Debug("Selected onboarding items")
+
+	return items, nil
+}
+
+// Submit records the user's picks and synthesizes their profile from them:
+// the picks count as likes in the preference vector and interests, and the
+// categories picked directly and the strongest categories of the picked items
+// become favourites. Picked categories the user blocked earlier stay blocked.
+func (o *OnboardingService) Submit(ctx context.Context, userID uuid.UUID, selection *models.OnboardingSelection) (*models.UserProfile, error) {
+	picks, err := o.loadPicks(ctx, selection.SelectedItems)
+	if err != nil {
+		return nil, err
+	}
+	if len(picks) == 0 {
+		return nil, ErrNoOnboardingItems
+	}
+
+	pickedIDs := make([]string, 0, len(picks))
+	for itemID := range picks {
+		pickedIDs = append(pickedIDs, itemID.String())
+	}
+
+	taxonomy := o.taxonomy.Current()
+	direct := taxonomy.Expand(selection.FavoriteCategories, false)
+	now := time.Now()
+
+	err = o.users.editExplicitPreferences(ctx, userID, func(stored map[string]interface{}) map[string]interface{} {
+		current := ExplicitPreferencesOf(&models.UserProfile{ExplicitPrefs: stored})
+		favorites := onboardingFavorites(direct, picks, current.BlockedCategories, o.maxFavorites())
+
+		prefs := mergeExplicitPreferences(stored, &models.ProfileUpdateRequest{
+			FavoriteCategories: &favorites,
+			ContentTypes:       selection.ContentTypes,
+			Onboarding:         true,
+		}, taxonomy, now)
+		prefs[models.PrefOnboardingItems] = pickedIDs
+		return prefs
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the preference vector, interests and category preferences now
+	// rather than after the first interaction
+	if err := o.users.updateUserProfile(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to build profile from onboarding: %w", err)
+	}
+	o.users.invalidateRecommendations(ctx, userID)
+
+	o.logger.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"picks":     len(picks),
+		"requested": len(selection.SelectedItems),
+	}).Info("Onboarding completed")
+
+	return o.users.GetUserProfile(ctx, userID)
+}
+
+// selectOnboardingItems picks up to count items covering as many categories
+// and as much of the embedding space as possible: repeatedly the candidate
+// adding the most uncovered categories, the one least similar to the items
+// picked so far among those. Candidates come most popular first, so
+// popularity breaks ties.
+func selectOnboardingItems(candidates []onboardingCandidate, count int) []onboardingCandidate {
+	// Compare embeddings of one dimension only
+	var usable []onboardingCandidate
+	for _, candidate := range candidates {
+		if len(candidate.Embedding) == 0 {
+			continue
+		}
+		if len(usable) > 0 && len(candidate.Embedding) != len(usable[0].Embedding) {
+			continue
+		}
+		usable = append(usable, candidate)
+	}
+
+	covered := make(map[string]bool)
+	picked := make([]bool, len(usable))
+	var vectors [][]float32
+	var selected []onboardingCandidate
+
+	for len(selected) < count {
+		best, bestNew, bestDistance := -1, -1, math.Inf(-1)
+		for i, candidate := range usable {
+			if picked[i] {
+				continue
+			}
+
+			uncovered := 0
+			for _, category := range candidate.Item.Categories {
+				if !covered[category] {
+					uncovered++
+				}
+			}
+			distance := 1.0
+			if len(vectors) > 0 {
+				_, similarity := nearestCentroid(candidate.Embedding, vectors)
+				distance = 1 - similarity
+			}
+
+			if uncovered > bestNew || (uncovered == bestNew && distance > bestDistance) {
+				best, bestNew, bestDistance = i, uncovered, distance
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		picked[best] = true
+		vectors = append(vectors, usable[best].Embedding)
+		for _, category := range usable[best].Item.Categories {
+			covered[category] = true
+		}
+		selected = append(selected, usable[best])
+	}
+	return selected
+}
+
+// onboardingFavorites returns the categories picked directly, then the
+// categories shared by most picked items that the user has not blocked, up to
+// max derived categories
+func onboardingFavorites(direct []string, picks map[uuid.UUID][]string, blocked []string, max int) []string {
+	excluded := make(map[string]bool, len(direct)+len(blocked))
+	for _, category := range blocked {
+		excluded[category] = true
+	}
+	favorites := make([]string, 0, len(direct)+max)
+	for _, category := range direct {
+		favorites = append(favorites, category)
+		excluded[category] = true
+	}
+
+	counts := make(map[string]float64)
+	for _, categories := range picks {
+		for _, category := range categories {
+			if !excluded[category] {
+				counts[category]++
+			}
+		}
+	}
+	return append(favorites, topCategories(counts, max)...)
+}
+
+// loadCandidates returns the most popular items of each primary category,
+// most popular first
+func (o *OnboardingService) loadCandidates(ctx context.Context) ([]onboardingCandidate, error) {
+	rows, err := o.db.Query(ctx, `
+		SELECT id, type, title, COALESCE(image_urls[1], ''), categories, embedding
+		FROM (
+			SELECT c.id, c.type, c.title, c.image_urls, COALESCE(c.categories, '{}') AS categories, c.embedding,
+				COUNT(i.id) AS interactions, c.quality_score,
+				ROW_NUMBER() OVER (
+					PARTITION BY COALESCE(c.categories[1], '')
+					ORDER BY COUNT(i.id) DESC, c.quality_score DESC
+				) AS category_rank
+			FROM content_items c
+			LEFT JOIN user_interactions i ON i.item_id = c.id AND i.timestamp > $1
+			WHERE c.active = true AND c.embedding IS NOT NULL
+			GROUP BY c.id
+		) ranked
+		WHERE category_rank <= $2
+		ORDER BY interactions DESC, quality_score DESC
+		LIMIT $3`,
+		time.Now().Add(-o.popularityWindow()), o.candidatesPerCategory(), o.poolSize())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query onboarding candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []onboardingCandidate
+	for rows.Next() {
+		var candidate onboardingCandidate
+		item := &candidate.Item
+		if err := rows.Scan(&item.ItemID, &item.Type, &item.Title, &item.ImageURL, &item.Categories, &candidate.Embedding); err != nil {
+			return nil, fmt.Errorf("failed to scan onboarding candidate: %w", err)
+		}
+		candidate.Embedding = normalizedVector(candidate.Embedding)
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
+
+// loadPicks returns the categories of the selected items that are active
+func (o *OnboardingService) loadPicks(ctx context.Context, itemIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	rows, err := o.db.Query(ctx, `
+		SELECT id, COALESCE(categories, '{}')
+		FROM content_items
+		WHERE id = ANY($1) AND active = true`, itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query selected items: %w", err)
+	}
+	defer rows.Close()
+
+	picks := make(map[uuid.UUID][]string, len(itemIDs))
+	for rows.Next() {
+		var itemID uuid.UUID
+		var categories []string
+		if err := rows.Scan(&itemID, &categories); err != nil {
+			return nil, fmt.Errorf("failed to scan selected item: %w", err)
+		}
+		picks[itemID] = categories
+	}
+	return picks, rows.Err()
+}
+
+func (o *OnboardingService) candidatesPerCategory() int {
+	if o.config.CandidatesPerCategory <= 0 {
+		return 10
+	}
+	return o.config.CandidatesPerCategory
+}
+
+func (o *OnboardingService) poolSize() int {
+	if o.config.PoolSize <= 0 {
+		return 500
+	}
+	return o.config.PoolSize
+}
+
+func (o *OnboardingService) popularityWindow() time.Duration {
+	if o.config.PopularityWindow <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return o.config.PopularityWindow
+}
+
+func (o *OnboardingService) cacheTTL() time.Duration {
+	if o.config.CacheTTL <= 0 {
+		return time.Hour
+	}
+	return o.config.CacheTTL
+}
+
+func (o *OnboardingService) maxFavorites() int {
+	if o.config.MaxFavorites <= 0 {
+		return 5
+	}
+	return o.config.MaxFavorites
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func onboardingCandidateOf(title string, embedding []float32, categories ...string) onboardingCandidate {
+	return onboardingCandidate{
+		Item: models.OnboardingItem{
+			ItemID:     uuid.New(),
+			Title:      title,
+			Categories: categories,
+		},
+		Embedding: normalizedVector(embedding),
+	}
+}
+
+func titles(candidates []onboardingCandidate) []string {
+	result := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		result[i] = candidate.Item.Title
+	}
+	return result
+}
+
+func TestSelectOnboardingItems(t *testing.T) {
+	// Most popular first
+	candidates := []onboardingCandidate{
+		onboardingCandidateOf("laptop", []float32{1, 0, 0}, "Electronics"),
+		onboardingCandidateOf("phone", []float32{0.9, 0.1, 0}, "Electronics"),
+		onboardingCandidateOf("novel", []float32{0, 1, 0}, "Books"),
+		onboardingCandidateOf("cookbook", []float32{0.1, 0.6, 0.6}, "Books", "Food"),
+		onboardingCandidateOf("tent", []float32{0, 0, 1}, "Sports"),
+		onboardingCandidateOf("bike", []float32{0.1, 0.1, 1}, "Sports"),
+		onboardingCandidateOf("short vector", []float32{1, 0}, "Travel"),
+	}
+
+	t.Run("categories first", func(t *testing.T) {
+		selected := selectOnboardingItems(candidates, 3)
+
+		// The cookbook covers two categories, the laptop is furthest from it
+		// and the tent further than the bike
+		assert.Equal(t, []string{"cookbook", "laptop", "tent"}, titles(selected))
+	})
+
+	t.Run("then the embedding space", func(t *testing.T) {
+		selected := selectOnboardingItems(candidates, 5)
+
+		// With every category covered, the novel is furthest from the picks
+		// and the phone closest to the laptop
+		assert.Equal(t, []string{"cookbook", "laptop", "tent", "novel", "bike"}, titles(selected))
+	})
+
+	t.Run("fewer candidates than requested", func(t *testing.T) {
+		selected := selectOnboardingItems(candidates, 20)
+
+		// The item with another embedding dimension is skipped
+		assert.Len(t, selected, 6)
+		assert.NotContains(t, titles(selected), "short vector")
+	})
+
+	assert.Empty(t, selectOnboardingItems(nil, 5))
+}
+
+func TestOnboardingFavorites(t *testing.T) {
+	picks := map[uuid.UUID][]string{
+		uuid.New(): {"Books", "Food"},
+		uuid.New(): {"Books"},
+		uuid.New(): {"Sports", "Food"},
+		uuid.New(): {"Horror", "Books"},
+	}
+
+	favorites := onboardingFavorites([]string{"Travel", "Food"}, picks, []string{"Horror"}, 2)
+
+	// Picked categories first; the blocked one is never derived
+	assert.Equal(t, []string{"Travel", "Food", "Books", "Sports"}, favorites)
+	assert.Equal(t, []string{"Books"}, onboardingFavorites(nil, picks, nil, 1))
+}
+
+func TestOnboardingSelectionWeight(t *testing.T) {
+	onboardedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.InDelta(t, 1.0, onboardingSelectionWeight(1.0, onboardedAt, onboardedAt), 1e-9)
+	assert.InDelta(t, 0.5, onboardingSelectionWeight(1.0, onboardedAt, onboardedAt.AddDate(0, 0, 30)), 1e-9)
+	// Unset weights count like a like
+	assert.InDelta(t, 0.8, onboardingSelectionWeight(0, onboardedAt, onboardedAt), 1e-9)
+	// Clock skew does not raise the weight
+	assert.InDelta(t, 1.0, onboardingSelectionWeight(1.0, onboardedAt, onboardedAt.Add(-time.Hour)), 1e-9)
+}
+
+func TestExplicitPreferencesOf_OnboardingItems(t *testing.T) {
+	itemID := uuid.New()
+	prefs := ExplicitPreferencesOf(&models.UserProfile{ExplicitPrefs: map[string]interface{}{
+		models.PrefOnboardingItems: []interface{}{itemID.String()},
+	}})
+
+	require.Len(t, prefs.OnboardingItems, 1)
+	assert.Equal(t, itemID, prefs.OnboardingItems[0])
+}
@@ -2,15 +2,21 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 
+	"github.com/temcen/pirex/internal/config"
 	"github.com/temcen/pirex/internal/database"
+	"github.com/temcen/pirex/internal/graph"
 	"github.com/temcen/pirex/internal/messaging"
+	"github.com/temcen/pirex/internal/ml"
 	"github.com/temcen/pirex/pkg/models"
 )
 
@@ -19,8 +25,20 @@ type PipelineOrchestrator struct {
 	messageBus   *messaging.MessageBus
 	preprocessor *DataPreprocessor
 	jobManager   *JobManager
+	deduplicator *ContentDeduplicator
+	keywordIndex *KeywordIndex // Optional; stored keywords still update the frequency tables without it
+	graph        *graph.Repository
 	logger       *logrus.Logger
 
+	// Optional; items get a placeholder embedding without it
+	embedder       ContentEmbedder
+	textModelName  string
+	imageModelName string
+
+	// Embedding cache format
+	embeddingTTL      time.Duration
+	embeddingEncoding ml.EmbeddingEncoding
+
 	// Worker pool configuration
 	workerCount int
 	workerPool  chan chan messaging.KafkaMessage
@@ -30,6 +48,12 @@ type PipelineOrchestrator struct {
 	wg          sync.WaitGroup
 }
 
+// ContentEmbedder generates the stored embedding of an item with the fusion
+// method configured for its content type
+type ContentEmbedder interface {
+	GenerateContentEmbedding(contentType, text, imageURL, textModelName, imageModelName string) (*ml.FusionResult, error)
+}
+
 type Worker struct {
 	id           int
 	orchestrator *PipelineOrchestrator
@@ -38,14 +62,28 @@ type Worker struct {
 	logger       *logrus.Logger
 }
 
+// Processing hints read by the pipeline
+const (
+	HintItemIndex = "item_index" // 0-based input position reported in item outcomes
+	HintImportRow = "import_row" // 1-based source row of bulk imports
+)
+
+// Model recorded in the header of cached content embeddings
+const (
+	contentEmbeddingModel   = "multimodal_fusion"
+	contentEmbeddingVersion = "placeholder"
+)
+
 type ProcessingStage string
 
 const (
 	StageValidate          ProcessingStage = "validate"
 	StagePreprocess        ProcessingStage = "preprocess"
 	StageGenerateEmbedding ProcessingStage = "generate_embedding"
+	StageDeduplicate       ProcessingStage = "deduplicate"
 	StageStore             ProcessingStage = "store"
 	StageUpdateCache       ProcessingStage = "update_cache"
+	StageDeactivate        ProcessingStage = "deactivate"
 )
 
 type ProcessingContext struct {
@@ -53,10 +91,12 @@ type ProcessingContext struct {
 	Message          messaging.KafkaMessage
 	ProcessedContent *models.ContentItem
 	ProcessingResult *ProcessingResult
+	Duplicate        *DuplicateMatch
 	CurrentStage     ProcessingStage
 	StartTime        time.Time
 	StageTimings     map[ProcessingStage]time.Duration
 	Errors           []error
+	FieldErrors      []models.FieldError // Validation errors reported in the item outcome
 }
 
 func NewPipelineOrchestrator(
@@ -64,20 +104,35 @@ func NewPipelineOrchestrator(
 	messageBus *messaging.MessageBus,
 	preprocessor *DataPreprocessor,
 	jobManager *JobManager,
+	deduplicator *ContentDeduplicator,
+	cacheConfig *config.CachingConfig,
 	logger *logrus.Logger,
 ) *PipelineOrchestrator {
 	workerCount := 5 // As specified in requirements
 
+	embeddingTTL := cacheConfig.EmbeddingsTTL
+	if embeddingTTL <= 0 {
+		embeddingTTL = 24 * time.Hour
+	}
+	embeddingEncoding, err := ml.ParseEmbeddingEncoding(cacheConfig.EmbeddingEncoding)
+	if err != nil {
+		logger.WithError(err).Warn("Invalid embedding cache encoding, using float32")
+	}
+
 	po := &PipelineOrchestrator{
 		db:           db,
 		messageBus:   messageBus,
 		preprocessor: preprocessor,
 		jobManager:   jobManager,
+		deduplicator: deduplicator,
 		logger:       logger,
 		workerCount:  workerCount,
-		workerPool:   make(chan chan messaging.KafkaMessage, workerCount),
-		jobQueue:     make(chan messaging.KafkaMessage, 100),
-		quit:         make(chan bool),
+
+		embeddingTTL:      embeddingTTL,
+		embeddingEncoding: embeddingEncoding,
+		workerPool:        make(chan chan messaging.KafkaMessage, workerCount),
+		jobQueue:          make(chan messaging.KafkaMessage, 100),
+		quit:              make(chan bool),
 	}
 
 	// Initialize workers
@@ -95,6 +150,23 @@ func NewPipelineOrchestrator(
 	return po
 }
 
+// SetKeywordIndex keeps the in-memory document frequencies current as items are stored
+func (po *PipelineOrchestrator) SetKeywordIndex(index *KeywordIndex) {
+	po.keywordIndex = index
+}
+
+// SetEmbedder embeds ingested items with the given text and image models
+func (po *PipelineOrchestrator) SetEmbedder(embedder ContentEmbedder, textModelName, imageModelName string) {
+	po.embedder = embedder
+	po.textModelName = textModelName
+	po.imageModelName = imageModelName
+}
+
+// SetGraph mirrors stored and deactivated items to the recommendation graph
+func (po *PipelineOrchestrator) SetGraph(repo *graph.Repository) {
+	po.graph = repo
+}
+
 func (po *PipelineOrchestrator) Start(ctx context.Context) error {
 	po.logger.Info("Starting pipeline orchestrator")
 
@@ -204,21 +276,48 @@ func (w *Worker) processJob(message messaging.KafkaMessage) {
 		"retry_count":  message.RetryCount,
 	}).Info("Processing job")
 
-	// Update job status to processing
-	if err := w.orchestrator.jobManager.UpdateJobProgress(
-		ctx, message.JobID, 0, 0, JobStatusProcessing, nil,
-	); err != nil {
+	// Update job status to processing; items of cancelled jobs are dropped
+	if err := w.orchestrator.jobManager.MarkJobProcessing(ctx, message.JobID); err != nil {
+		if errors.Is(err, ErrJobCancelled) {
+			w.logger.WithField("job_id", message.JobID).Debug("Skipping item of cancelled job")
+			if err := w.orchestrator.jobManager.DropItems(ctx, message.JobID, 1); err != nil {
+				w.logger.WithError(err).WithField("job_id", message.JobID).Warn("Failed to count dropped item")
+			}
+			return
+		}
 		w.logger.WithError(err).Warn("Failed to update job status to processing")
 	}
 
-	// Execute processing pipeline
-	success := w.executePipeline(ctx, processingCtx)
+	// Execute processing pipeline; deactivations skip it entirely
+	var success bool
+	if op, _ := message.ProcessingHints[HintOperation].(string); op == OperationDeactivate {
+		processingCtx.CurrentStage = StageDeactivate
+		success = w.deactivateContent(ctx, processingCtx)
+	} else {
+		success = w.executePipeline(ctx, processingCtx)
+	}
 
 	// Update final job status
+	index := hintInt(message.ProcessingHints, HintItemIndex)
 	if success {
-		if err := w.orchestrator.jobManager.UpdateJobProgress(
-			ctx, message.JobID, 1, 0, JobStatusCompleted, nil,
-		); err != nil {
+		outcome := models.ContentItemOutcome{
+			Index:  index,
+			Row:    hintInt(message.ProcessingHints, HintImportRow),
+			Status: ItemStatusSucceeded,
+		}
+		if message.ContentItem.ExternalID != nil {
+			outcome.ExternalID = *message.ContentItem.ExternalID
+		}
+		if processingCtx.ProcessedContent != nil {
+			contentID := processingCtx.ProcessedContent.ID
+			outcome.ContentID = &contentID
+		}
+		if processingCtx.Duplicate != nil {
+			duplicateOf := processingCtx.Duplicate.DuplicateOf
+			outcome.DuplicateOf = &duplicateOf
+		}
+
+		if err := w.orchestrator.jobManager.RecordItemResult(ctx, message.JobID, outcome); err != nil {
 			w.logger.WithError(err).Warn("Failed to update job status to completed")
 		}
 		w.logger.WithField("job_id", message.JobID).Info("Job completed successfully")
@@ -228,9 +327,23 @@ func (w *Worker) processJob(message messaging.KafkaMessage) {
 			errorMsg = processingCtx.Errors[0].Error()
 		}
 
-		if err := w.orchestrator.jobManager.UpdateJobProgress(
-			ctx, message.JobID, 0, 1, JobStatusFailed, &errorMsg,
-		); err != nil {
+		itemErr := ItemError{
+			Row:     hintInt(message.ProcessingHints, HintImportRow),
+			Stage:   string(processingCtx.CurrentStage),
+			Message: errorMsg,
+			Fields:  processingCtx.FieldErrors,
+		}
+		if message.ContentItem.ExternalID != nil {
+			itemErr.ExternalID = *message.ContentItem.ExternalID
+		}
+
+		// Keep the payload so the item can be retried once the job has finished
+		failed := []FailedItem{{Error: itemErr, Item: message.ContentItem, Hints: message.ProcessingHints}}
+		if err := w.orchestrator.jobManager.StoreFailedItems(ctx, message.JobID, failed); err != nil {
+			w.logger.WithError(err).Warn("Failed to store failed item for retry")
+		}
+
+		if err := w.orchestrator.jobManager.RecordItemResult(ctx, message.JobID, FailedItemOutcome(itemErr, index)); err != nil {
 			w.logger.WithError(err).Warn("Failed to update job status to failed")
 		}
 		w.logger.WithField("job_id", message.JobID).Error("Job failed")
@@ -252,6 +365,7 @@ func (w *Worker) executePipeline(ctx context.Context, processingCtx *ProcessingC
 		StageValidate,
 		StagePreprocess,
 		StageGenerateEmbedding,
+		StageDeduplicate,
 		StageStore,
 		StageUpdateCache,
 	}
@@ -291,6 +405,8 @@ func (w *Worker) executeStage(ctx context.Context, processingCtx *ProcessingCont
 		return w.preprocessContent(ctx, processingCtx)
 	case StageGenerateEmbedding:
 		return w.generateEmbedding(ctx, processingCtx)
+	case StageDeduplicate:
+		return w.deduplicateContent(ctx, processingCtx)
 	case StageStore:
 		return w.storeContent(ctx, processingCtx)
 	case StageUpdateCache:
@@ -301,27 +417,36 @@ func (w *Worker) executeStage(ctx context.Context, processingCtx *ProcessingCont
 	}
 }
 
+// validateContent checks the title, the content type and the metadata against
+// the type's schema
 func (w *Worker) validateContent(processingCtx *ProcessingContext) bool {
-	content := processingCtx.Message.ContentItem
-
-	// Basic validation
-	if content.Title == "" {
-		processingCtx.Errors = append(processingCtx.Errors, fmt.Errorf("title is required"))
-		return false
+	fieldErrors := w.orchestrator.preprocessor.ValidateContent(processingCtx.Message.ContentItem)
+	if len(fieldErrors) == 0 {
+		return true
 	}
 
-	if content.Type == "" {
-		processingCtx.Errors = append(processingCtx.Errors, fmt.Errorf("type is required"))
-		return false
-	}
+	processingCtx.FieldErrors = fieldErrors
+	processingCtx.Errors = append(processingCtx.Errors, FieldErrorsMessage(fieldErrors))
+	return false
+}
 
-	validTypes := map[string]bool{"product": true, "video": true, "article": true}
-	if !validTypes[content.Type] {
-		processingCtx.Errors = append(processingCtx.Errors, fmt.Errorf("invalid content type: %s", content.Type))
-		return false
+// ValidateContent checks an item the way the pipeline's validation stage does, so
+// the API can reject it before queueing
+func (po *PipelineOrchestrator) ValidateContent(content models.ContentIngestionRequest) []models.FieldError {
+	var preprocessor *DataPreprocessor
+	if po != nil {
+		preprocessor = po.preprocessor
 	}
+	return preprocessor.ValidateContent(content)
+}
 
-	return true
+// FieldErrorsMessage summarizes field errors as one error
+func FieldErrorsMessage(fieldErrors []models.FieldError) error {
+	messages := make([]string, len(fieldErrors))
+	for i, fieldErr := range fieldErrors {
+		messages[i] = fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message)
+	}
+	return fmt.Errorf("validation failed: %s", strings.Join(messages, "; "))
 }
 
 func (w *Worker) preprocessContent(ctx context.Context, processingCtx *ProcessingContext) bool {
@@ -348,30 +473,159 @@ func (w *Worker) preprocessContent(ctx context.Context, processingCtx *Processin
 }
 
 func (w *Worker) generateEmbedding(ctx context.Context, processingCtx *ProcessingContext) bool {
-	// TODO: This will be implemented in task 3 (ML Models and Embedding Generation)
-	// For now, we'll create a placeholder embedding
+	content := processingCtx.ProcessedContent
+	po := w.orchestrator
+	if po.embedder == nil {
+		w.logger.WithField("job_id", processingCtx.JobID).Debug("No content embedder configured, storing a placeholder embedding")
+
+		// Placeholder embedding (768 dimensions as specified in design)
+		embedding := make([]float32, 768)
+		for i := range embedding {
+			embedding[i] = 0.1
+		}
+		content.Embedding = embedding
+		return true
+	}
 
-	w.logger.WithField("job_id", processingCtx.JobID).Info("Embedding generation placeholder - will be implemented in task 3")
+	text, imageURL := contentEmbeddingInput(content)
+	result, err := po.embedder.GenerateContentEmbedding(content.Type, text, imageURL, po.textModelName, po.imageModelName)
+	if err != nil {
+		processingCtx.Errors = append(processingCtx.Errors, fmt.Errorf("embedding generation failed: %w", err))
+		return false
+	}
 
-	// Create placeholder embedding (768 dimensions as specified in design)
-	embedding := make([]float32, 768)
-	for i := range embedding {
-		embedding[i] = 0.1 // Placeholder value
+	content.Embedding = result.FinalEmbedding
+	return true
+}
+
+// contentEmbeddingInput returns the text and the image an item is embedded
+// from: its title and description, and its first image if it has one
+func contentEmbeddingInput(content *models.ContentItem) (string, string) {
+	text := content.Title
+	if content.Description != nil && *content.Description != "" {
+		text += "\n" + *content.Description
+	}
+
+	imageURL := ""
+	if len(content.ImageURLs) > 0 {
+		imageURL = content.ImageURLs[0]
+	}
+	return text, imageURL
+}
+
+func (w *Worker) deduplicateContent(ctx context.Context, processingCtx *ProcessingContext) bool {
+	dedup := w.orchestrator.deduplicator
+	if dedup == nil {
+		return true
 	}
 
-	processingCtx.ProcessedContent.Embedding = embedding
+	content := processingCtx.ProcessedContent
+	externalID := processingCtx.Message.ContentItem.ExternalID
+
+	// Re-sent items with a known external ID are updates, not duplicates
+	if externalID != nil {
+		existingID, err := dedup.ResolveExternalID(ctx, content.Type, *externalID)
+		if err != nil {
+			processingCtx.Errors = append(processingCtx.Errors, fmt.Errorf("external ID lookup failed: %w", err))
+			return false
+		}
+		if existingID != nil {
+			content.ID = *existingID
+			return true
+		}
+	}
+
+	match, err := dedup.FindNearDuplicate(ctx, content, processingCtx.ProcessingResult.Fingerprint)
+	if err != nil {
+		// Duplicate detection is best-effort and must not block ingestion
+		w.logger.WithError(err).WithField("job_id", processingCtx.JobID).Warn("Near-duplicate detection failed")
+	} else if match != nil {
+		match.ExternalID = externalID
+		if dedup.Policy() == DedupPolicyMerge {
+			match.Action = "merged"
+			content.ID = match.DuplicateOf
+		} else {
+			match.Action = "flagged"
+		}
+		processingCtx.Duplicate = match
+
+		if err := w.orchestrator.jobManager.RecordDuplicate(ctx, processingCtx.JobID, match); err != nil {
+			w.logger.WithError(err).WithField("job_id", processingCtx.JobID).Warn("Failed to record duplicate in job")
+		}
+
+		w.logger.WithFields(logrus.Fields{
+			"job_id":           processingCtx.JobID,
+			"content_id":       match.ContentID,
+			"duplicate_of":     match.DuplicateOf,
+			"hamming_distance": match.HammingDistance,
+			"action":           match.Action,
+		}).Info("Near-duplicate content detected")
+	}
+
+	w.reportVisualDuplicates(ctx, processingCtx)
+
 	return true
 }
 
+// reportVisualDuplicates records other listings showing the same picture in the
+// job's dedup report. They are only flagged; the item is stored as usual.
+func (w *Worker) reportVisualDuplicates(ctx context.Context, processingCtx *ProcessingContext) {
+	content := processingCtx.ProcessedContent
+
+	matches, err := w.orchestrator.deduplicator.FindVisualDuplicates(ctx, content)
+	if err != nil {
+		w.logger.WithError(err).WithField("job_id", processingCtx.JobID).Warn("Visual duplicate detection failed")
+		return
+	}
+
+	for _, match := range matches {
+		// Already reported as a text duplicate of the same item
+		if processingCtx.Duplicate != nil && processingCtx.Duplicate.DuplicateOf == match.DuplicateOf {
+			continue
+		}
+		match.ExternalID = processingCtx.Message.ContentItem.ExternalID
+
+		if err := w.orchestrator.jobManager.RecordDuplicate(ctx, processingCtx.JobID, match); err != nil {
+			w.logger.WithError(err).WithField("job_id", processingCtx.JobID).Warn("Failed to record duplicate in job")
+		}
+
+		w.logger.WithFields(logrus.Fields{
+			"job_id":         processingCtx.JobID,
+			"content_id":     match.ContentID,
+			"duplicate_of":   match.DuplicateOf,
+			"image_url":      match.ImageURL,
+			"image_distance": match.ImageDistance,
+		}).Info("Visual duplicate content detected")
+	}
+}
+
 func (w *Worker) storeContent(ctx context.Context, processingCtx *ProcessingContext) bool {
 	content := processingCtx.ProcessedContent
+	externalID := processingCtx.Message.ContentItem.ExternalID
+
+	tx, err := w.orchestrator.db.PG.Begin(ctx)
+	if err != nil {
+		processingCtx.Errors = append(processingCtx.Errors, fmt.Errorf("failed to begin transaction: %w", err))
+		return false
+	}
+	defer tx.Rollback(ctx)
+
+	// Claim the external ID first so concurrent workers converge on one content ID
+	if externalID != nil && w.orchestrator.deduplicator != nil {
+		mappedID, err := w.orchestrator.deduplicator.ClaimExternalID(ctx, tx, content.Type, *externalID, content.ID)
+		if err != nil {
+			processingCtx.Errors = append(processingCtx.Errors, err)
+			return false
+		}
+		content.ID = mappedID
+	}
 
 	// Store in PostgreSQL
 	query := `
 		INSERT INTO content_items (
 			id, type, title, description, image_urls, metadata, categories,
-			embedding, quality_score, active, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			embedding, quality_score, active, fingerprint, language, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		ON CONFLICT (id) DO UPDATE SET
 			type = EXCLUDED.type,
 			title = EXCLUDED.title,
@@ -382,13 +636,16 @@ func (w *Worker) storeContent(ctx context.Context, processingCtx *ProcessingCont
 			embedding = EXCLUDED.embedding,
 			quality_score = EXCLUDED.quality_score,
 			active = EXCLUDED.active,
+			fingerprint = EXCLUDED.fingerprint,
+			language = EXCLUDED.language,
 			updated_at = EXCLUDED.updated_at
 	`
 
-	_, err := w.orchestrator.db.PG.Exec(ctx, query,
+	_, err = tx.Exec(ctx, query,
 		content.ID, content.Type, content.Title, content.Description,
 		content.ImageURLs, content.Metadata, content.Categories,
 		content.Embedding, content.QualityScore, content.Active,
+		int64(processingCtx.ProcessingResult.Fingerprint), content.Language,
 		content.CreatedAt, content.UpdatedAt,
 	)
 
@@ -397,12 +654,46 @@ func (w *Worker) storeContent(ctx context.Context, processingCtx *ProcessingCont
 		return false
 	}
 
+	if err := SaveImageHashes(ctx, tx, content.ID, content.ImageHashes); err != nil {
+		processingCtx.Errors = append(processingCtx.Errors, err)
+		return false
+	}
+
+	if err := SaveMarketAvailability(ctx, tx, content.ID, content.Markets); err != nil {
+		processingCtx.Errors = append(processingCtx.Errors, err)
+		return false
+	}
+
+	keywordUpdate, err := w.orchestrator.keywordIndex.Save(ctx, tx, content)
+	if err != nil {
+		processingCtx.Errors = append(processingCtx.Errors, err)
+		return false
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		processingCtx.Errors = append(processingCtx.Errors, fmt.Errorf("failed to commit content: %w", err))
+		return false
+	}
+	w.orchestrator.keywordIndex.Apply(keywordUpdate)
+
 	w.logger.WithFields(logrus.Fields{
 		"job_id":        processingCtx.JobID,
 		"content_id":    content.ID,
 		"quality_score": content.QualityScore,
 	}).Info("Content stored in PostgreSQL")
 
+	// Postgres is the source of truth; a missed graph write only delays the
+	// item's category links until it is stored again
+	err = w.orchestrator.graph.UpsertContents(ctx, []graph.ContentNode{{
+		ID:         content.ID,
+		Type:       content.Type,
+		Categories: content.Categories,
+		Active:     content.Active,
+	}})
+	if err != nil {
+		w.logger.WithError(err).WithField("content_id", content.ID).Warn("Failed to write content to graph")
+	}
+
 	return true
 }
 
@@ -420,24 +711,35 @@ func (w *Worker) updateCache(ctx context.Context, processingCtx *ProcessingConte
 		"description":   content.Description,
 		"image_urls":    content.ImageURLs,
 		"categories":    content.Categories,
+		"language":      content.Language,
 		"quality_score": content.QualityScore,
 		"active":        content.Active,
 		"updated_at":    content.UpdatedAt,
 	}
 
-	if err := w.orchestrator.db.Redis.Warm.HSet(ctx, cacheKey, cacheContent).Err(); err != nil {
+	// Write metadata and its TTL (1 hour as specified in design) in one round trip
+	_, err := w.orchestrator.db.Redis.Warm.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, cacheKey, cacheContent)
+		pipe.Expire(ctx, cacheKey, time.Hour)
+		return nil
+	})
+	if err != nil {
 		w.logger.WithError(err).WithField("job_id", processingCtx.JobID).Warn("Failed to cache content metadata")
 		// Don't fail the entire pipeline for cache errors
 	}
 
-	// Set cache TTL (1 hour as specified in design)
-	if err := w.orchestrator.db.Redis.Warm.Expire(ctx, cacheKey, time.Hour).Err(); err != nil {
-		w.logger.WithError(err).WithField("job_id", processingCtx.JobID).Warn("Failed to set cache TTL")
-	}
-
-	// Cache embedding in cold cache (longer TTL)
+	// Cache embedding in cold cache (longer TTL) in the shared binary format
 	embeddingKey := fmt.Sprintf("embedding:%s", content.ID.String())
-	if err := w.orchestrator.db.Redis.Cold.Set(ctx, embeddingKey, content.Embedding, 24*time.Hour).Err(); err != nil {
+	data, err := ml.EncodeEmbedding(ml.EmbeddingHeader{
+		Encoding:     w.orchestrator.embeddingEncoding,
+		Model:        contentEmbeddingModel,
+		ModelVersion: contentEmbeddingVersion,
+		Dimensions:   len(content.Embedding),
+	}, content.Embedding)
+	if err == nil {
+		err = w.orchestrator.db.Redis.Cold.Set(ctx, embeddingKey, data, w.orchestrator.embeddingTTL).Err()
+	}
+	if err != nil {
 		w.logger.WithError(err).WithField("job_id", processingCtx.JobID).Warn("Failed to cache embedding")
 	}
 
@@ -445,6 +747,117 @@ func (w *Worker) updateCache(ctx context.Context, processingCtx *ProcessingConte
 	return true
 }
 
+// deactivateContent marks the item behind an external ID inactive and evicts it from
+// the warm cache. Items that were never stored are treated as already inactive.
+func (w *Worker) deactivateContent(ctx context.Context, processingCtx *ProcessingContext) bool {
+	content := processingCtx.Message.ContentItem
+	if content.ExternalID == nil {
+		processingCtx.Errors = append(processingCtx.Errors, fmt.Errorf("deactivation requires an external ID"))
+		return false
+	}
+
+	dedup := w.orchestrator.deduplicator
+	if dedup == nil {
+		processingCtx.Errors = append(processingCtx.Errors, fmt.Errorf("external ID resolution is not available"))
+		return false
+	}
+
+	contentID, err := dedup.ResolveExternalID(ctx, content.Type, *content.ExternalID)
+	if err != nil {
+		processingCtx.Errors = append(processingCtx.Errors, err)
+		return false
+	}
+	if contentID == nil {
+		w.logger.WithFields(logrus.Fields{
+			"job_id":      processingCtx.JobID,
+			"external_id": *content.ExternalID,
+		}).Debug("Nothing to deactivate for unknown external ID")
+		return true
+	}
+
+	_, err = w.orchestrator.db.PG.Exec(ctx,
+		"UPDATE content_items SET active = false, updated_at = NOW() WHERE id = $1", *contentID)
+	if err != nil {
+		processingCtx.Errors = append(processingCtx.Errors, fmt.Errorf("failed to deactivate content: %w", err))
+		return false
+	}
+
+	cacheKey := fmt.Sprintf("content:%s", contentID.String())
+	if err := w.orchestrator.db.Redis.Warm.Del(ctx, cacheKey).Err(); err != nil {
+		w.logger.WithError(err).WithField("job_id", processingCtx.JobID).Warn("Failed to evict deactivated content from cache")
+	}
+
+	if err := w.orchestrator.graph.SetContentActive(ctx, *contentID, false); err != nil {
+		w.logger.WithError(err).WithField("content_id", contentID).Warn("Failed to deactivate content in graph")
+	}
+
+	processingCtx.ProcessedContent = &models.ContentItem{ID: *contentID, Type: content.Type, Active: false}
+
+	w.logger.WithFields(logrus.Fields{
+		"job_id":     processingCtx.JobID,
+		"content_id": contentID,
+	}).Info("Content deactivated")
+
+	return true
+}
+
+// hintInt reads an integer processing hint; JSON decoding turns numbers into float64
+func hintInt(hints map[string]interface{}, key string) int {
+	switch v := hints[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
+// RetryFailedItems re-publishes the failed items of a finished job. Only items that
+// reached the topic keep their payload; rows rejected while parsing or validating
+// an import stay in the error report. It returns the number of items re-published.
+func (po *PipelineOrchestrator) RetryFailedItems(ctx context.Context, jobID uuid.UUID) (*JobProgress, int, error) {
+	items, job, err := po.jobManager.TakeFailedItems(ctx, jobID)
+	if err != nil {
+		return job, 0, err
+	}
+
+	contents := make([]models.ContentIngestionRequest, len(items))
+	hints := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		contents[i] = item.Item
+		hints[i] = make(map[string]interface{}, len(item.Hints)+1)
+		for k, v := range item.Hints {
+			hints[i][k] = v
+		}
+		hints[i]["retry_of_stage"] = item.Error.Stage
+	}
+
+	if err := po.messageBus.PublishContentIngestionBatch(jobID, contents, hints); err != nil {
+		// Put the items back so the retry can be attempted again
+		itemErrors := make([]ItemError, len(items))
+		for i := range items {
+			items[i].Error.Stage = "publish"
+			items[i].Error.Message = err.Error()
+			itemErrors[i] = items[i].Error
+		}
+		if storeErr := po.jobManager.StoreFailedItems(ctx, jobID, items); storeErr != nil {
+			po.logger.WithError(storeErr).WithField("job_id", jobID).Error("Failed to restore failed items after retry")
+		}
+		if failErr := po.jobManager.FailItems(ctx, jobID, itemErrors); failErr != nil {
+			po.logger.WithError(failErr).WithField("job_id", jobID).Error("Failed to record retry publish failure")
+		}
+		return job, 0, fmt.Errorf("failed to publish retried items: %w", err)
+	}
+
+	po.logger.WithFields(logrus.Fields{
+		"job_id": jobID,
+		"items":  len(items),
+	}).Info("Failed job items re-published")
+
+	return job, len(items), nil
+}
+
 // GetMetrics returns pipeline processing metrics
 func (po *PipelineOrchestrator) GetMetrics() map[string]interface{} {
 	return map[string]interface{}{
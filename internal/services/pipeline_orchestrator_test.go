@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/ml"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+type fakeContentEmbedder struct {
+	contentType, text, imageURL string
+	err                         error
+}
+
+func (f *fakeContentEmbedder) GenerateContentEmbedding(contentType, text, imageURL, textModelName, imageModelName string) (*ml.FusionResult, error) {
+	f.contentType, f.text, f.imageURL = contentType, text, imageURL
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &ml.FusionResult{FinalEmbedding: []float32{0.6, 0.8}}, nil
+}
+
+func TestWorker_GenerateEmbedding(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	newContext := func() *ProcessingContext {
+		return &ProcessingContext{ProcessedContent: &models.ContentItem{
+			Type:        "product",
+			Title:       "Wireless headphones",
+			Description: stringPtr("Noise cancelling"),
+			ImageURLs:   []string{"https://example.com/a.jpg", "https://example.com/b.jpg"},
+		}}
+	}
+
+	t.Run("placeholder without an embedder", func(t *testing.T) {
+		worker := &Worker{orchestrator: &PipelineOrchestrator{}, logger: logger}
+		processingCtx := newContext()
+
+		require.True(t, worker.generateEmbedding(context.Background(), processingCtx))
+		assert.Len(t, processingCtx.ProcessedContent.Embedding, 768)
+	})
+
+	t.Run("fused embedding of the content type", func(t *testing.T) {
+		embedder := &fakeContentEmbedder{}
+		orchestrator := &PipelineOrchestrator{}
+		orchestrator.SetEmbedder(embedder, "text-model", "image-model")
+		worker := &Worker{orchestrator: orchestrator, logger: logger}
+		processingCtx := newContext()
+
+		require.True(t, worker.generateEmbedding(context.Background(), processingCtx))
+		assert.Equal(t, []float32{0.6, 0.8}, processingCtx.ProcessedContent.Embedding)
+		assert.Equal(t, "product", embedder.contentType)
+		assert.Equal(t, "Wireless headphones\nNoise cancelling", embedder.text)
+		assert.Equal(t, "https://example.com/a.jpg", embedder.imageURL)
+	})
+
+	t.Run("embedding failure fails the item", func(t *testing.T) {
+		orchestrator := &PipelineOrchestrator{}
+		orchestrator.SetEmbedder(&fakeContentEmbedder{err: errors.New("model not loaded")}, "text-model", "image-model")
+		worker := &Worker{orchestrator: orchestrator, logger: logger}
+		processingCtx := newContext()
+
+		assert.False(t, worker.generateEmbedding(context.Background(), processingCtx))
+		require.Len(t, processingCtx.Errors, 1)
+		assert.ErrorContains(t, processingCtx.Errors[0], "model not loaded")
+	})
+}
+
+func TestContentEmbeddingInput(t *testing.T) {
+	text, imageURL := contentEmbeddingInput(&models.ContentItem{Title: "Short story"})
+	assert.Equal(t, "Short story", text)
+	assert.Empty(t, imageURL)
+}
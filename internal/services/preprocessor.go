@@ -4,30 +4,41 @@ import (
 	"context"
 	"fmt"
 	"html"
-	"net/http"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
-	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/text/unicode/norm"
 
+	"github.com/temcen/pirex/internal/media"
+	"github.com/temcen/pirex/internal/nlp"
+	"github.com/temcen/pirex/internal/validation"
 	"github.com/temcen/pirex/pkg/models"
 )
 
 type DataPreprocessor struct {
-	logger           *logrus.Logger
-	httpClient       *http.Client
-	categoryTaxonomy map[string][]string
-	stopWords        map[string]bool
+	logger       *logrus.Logger
+	imageFetcher *media.ImageFetcher
+	imageStore   *media.ImageStore           // Optional; keeps downloaded images for re-embedding
+	hashImages   bool                        // Download images to compute perceptual hashes
+	taxonomy     *TaxonomyService            // Optional; the built-in flat taxonomy is used without it
+	contentTypes *validation.SchemaValidator // Metadata schema per content type
+	keywordIndex *KeywordIndex               // Optional; keywords are weighted by term frequency alone without it
 }
 
+const (
+	// maxKeywords is the number of keywords kept as processing hints
+	maxKeywords = 10
+	// maxNounPhrases is the number of noun phrases kept as entities
+	maxNounPhrases = 10
+)
+
 type ProcessingResult struct {
 	ProcessedContent *models.ContentItem
 	QualityScore     float64
+	Fingerprint      uint64
 	ProcessingHints  map[string]interface{}
 	Errors           []string
 }
@@ -38,18 +49,85 @@ type ImageMetadata struct {
 	Format      string `json:"format"`
 	Size        int64  `json:"size"`
 	ContentType string `json:"content_type"`
+	PHash       string `json:"phash,omitempty"` // Set when images are downloaded
+	DHash       string `json:"dhash,omitempty"`
 	Valid       bool   `json:"valid"`
+
+	hashes *media.ImageHashes
 }
 
 func NewDataPreprocessor(logger *logrus.Logger) *DataPreprocessor {
 	return &DataPreprocessor{
-		logger: logger,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		categoryTaxonomy: initializeCategoryTaxonomy(),
-		stopWords:        initializeStopWords(),
+		logger:       logger,
+		imageFetcher: media.NewImageFetcher(media.DefaultImageFetchConfig()),
+		contentTypes: validation.DefaultContentTypes(),
+	}
+}
+
+// SetContentTypes replaces the built-in content types, e.g. with schemas loaded
+// from ingestion.content_types.schema_dir
+func (dp *DataPreprocessor) SetContentTypes(contentTypes *validation.SchemaValidator) {
+	dp.contentTypes = contentTypes
+}
+
+// contentTypeSchemas returns the content types in use. It is safe to call on a
+// nil preprocessor.
+func (dp *DataPreprocessor) contentTypeSchemas() *validation.SchemaValidator {
+	if dp == nil || dp.contentTypes == nil {
+		return validation.DefaultContentTypes()
 	}
+	return dp.contentTypes
+}
+
+// ValidateContent checks that the content type is known and that its metadata
+// matches the type's schema. Metadata fields the schema does not declare are not
+// errors; preprocessing drops them.
+func (dp *DataPreprocessor) ValidateContent(content models.ContentIngestionRequest) []models.FieldError {
+	var fieldErrors []models.FieldError
+	if strings.TrimSpace(content.Title) == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "title", Code: validation.CodeRequired, Message: "title is required"})
+	}
+	if content.Type == "" {
+		return append(fieldErrors, models.FieldError{Field: "type", Code: validation.CodeRequired, Message: "type is required"})
+	}
+
+	result := dp.contentTypeSchemas().ValidateMetadata(content.Type, content.Metadata)
+	for _, err := range result.Errors {
+		if err.Code == validation.CodeUnknownField {
+			continue
+		}
+		fieldErrors = append(fieldErrors, models.FieldError{Field: err.Field, Code: err.Code, Message: err.Message})
+	}
+	return fieldErrors
+}
+
+// SetKeywordIndex weights keywords against the catalog document frequencies
+// and recognises brands learned from other items
+func (dp *DataPreprocessor) SetKeywordIndex(index *KeywordIndex) {
+	dp.keywordIndex = index
+}
+
+// SetTaxonomy makes category normalization use the managed taxonomy, including
+// synonyms and parent expansion
+func (dp *DataPreprocessor) SetTaxonomy(taxonomy *TaxonomyService) {
+	dp.taxonomy = taxonomy
+}
+
+// SetImageFetcher replaces the default image fetcher, e.g. with configured limits
+func (dp *DataPreprocessor) SetImageFetcher(fetcher *media.ImageFetcher) {
+	dp.imageFetcher = fetcher
+}
+
+// SetImageHashing makes image validation download each image to compute its
+// perceptual hashes for visual duplicate detection
+func (dp *DataPreprocessor) SetImageHashing(enabled bool) {
+	dp.hashImages = enabled
+}
+
+// SetImageStore makes image validation download and keep images, so later
+// embedding reads them from the store
+func (dp *DataPreprocessor) SetImageStore(store *media.ImageStore) {
+	dp.imageStore = store
 }
 
 func (dp *DataPreprocessor) ProcessContent(ctx context.Context, jobID uuid.UUID, content models.ContentIngestionRequest) (*ProcessingResult, error) {
@@ -91,6 +169,11 @@ func (dp *DataPreprocessor) ProcessContent(ctx context.Context, jobID uuid.UUID,
 		result.Errors = append(result.Errors, fmt.Sprintf("category normalization error: %v", err))
 	}
 
+	// Market availability
+	if err := dp.normalizeMarkets(content, result); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("market availability error: %v", err))
+	}
+
 	// Metadata validation
 	if err := dp.validateMetadata(content, result); err != nil {
 		result.Errors = append(result.Errors, fmt.Sprintf("metadata validation error: %v", err))
@@ -123,16 +206,28 @@ func (dp *DataPreprocessor) processText(content models.ContentIngestionRequest,
 		result.ProcessedContent.Description = &cleanDesc
 	}
 
-	// Extract keywords using TF-IDF (simplified)
-	keywords := dp.extractKeywords(cleanTitle, content.Description)
-	result.ProcessingHints["keywords"] = keywords
-
-	// Detect language (simplified)
-	language := dp.detectLanguage(cleanTitle)
+	// Detect language on title and description; the description carries most signal
+	text := cleanTitle
+	if result.ProcessedContent.Description != nil {
+		text += " " + *result.ProcessedContent.Description
+	}
+	language := dp.detectLanguage(text)
+	if content.Language != nil && *content.Language != "" {
+		language = strings.ToLower(strings.TrimSpace(*content.Language))
+	}
+	result.ProcessedContent.Language = language
 	result.ProcessingHints["language"] = language
 
-	// Extract entities using regex patterns
-	entities := dp.extractEntities(cleanTitle, content.Description)
+	// Weight keywords against the catalog; the full vector is stored for lexical matching
+	keywords := dp.keywordIndex.Extract(cleanTitle, result.ProcessedContent.Description, language)
+	result.ProcessedContent.Keywords = keywords
+	result.ProcessingHints["keywords"] = keywordTexts(keywords, maxKeywords)
+
+	// Extract entities using patterns, noun phrases and known brands
+	entities := dp.extractEntities(cleanTitle, content.Description, language)
+	if brands := dp.keywordIndex.Brands(text, content.Metadata); len(brands) > 0 {
+		entities["brands"] = brands
+	}
 	result.ProcessingHints["entities"] = entities
 
 	return nil
@@ -146,6 +241,7 @@ func (dp *DataPreprocessor) processImages(ctx context.Context, content models.Co
 
 	validImages := []string{}
 	imageMetadata := []ImageMetadata{}
+	var imageHashes []models.ImageHash
 
 	for _, imageURL := range content.ImageURLs {
 		metadata, err := dp.validateImageURL(ctx, imageURL)
@@ -156,20 +252,29 @@ func (dp *DataPreprocessor) processImages(ctx context.Context, content models.Co
 
 		if metadata.Valid {
 			validImages = append(validImages, imageURL)
+			if metadata.hashes != nil {
+				imageHashes = append(imageHashes, models.ImageHash{
+					ImageURL: imageURL,
+					PHash:    metadata.hashes.PHash,
+					DHash:    metadata.hashes.DHash,
+				})
+			}
 		}
 		imageMetadata = append(imageMetadata, *metadata)
 	}
 
 	result.ProcessedContent.ImageURLs = validImages
+	result.ProcessedContent.ImageHashes = imageHashes
 	result.ProcessingHints["image_metadata"] = imageMetadata
 
 	return nil
 }
 
 func (dp *DataPreprocessor) extractFeatures(content models.ContentIngestionRequest, result *ProcessingResult) error {
-	// Generate content fingerprint
+	// Generate content fingerprint for near-duplicate detection
 	fingerprint := dp.generateFingerprint(content.Title, content.Description)
-	result.ProcessingHints["fingerprint"] = fingerprint
+	result.Fingerprint = fingerprint
+	result.ProcessingHints["fingerprint"] = fmt.Sprintf("%016x", fingerprint)
 
 	// Extract content features
 	features := map[string]interface{}{
@@ -189,47 +294,98 @@ func (dp *DataPreprocessor) extractFeatures(content models.ContentIngestionReque
 	return nil
 }
 
+// normalizeCategories maps categories and synonyms to taxonomy names, removes
+// duplicates and, when configured, adds the ancestors of each category
 func (dp *DataPreprocessor) normalizeCategories(content models.ContentIngestionRequest, result *ProcessingResult) error {
-	normalizedCategories := []string{}
+	result.ProcessedContent.Categories = dp.taxonomy.Normalize(content.Categories)
+	return nil
+}
 
-	for _, category := range content.Categories {
-		normalized := dp.normalizeCategoryName(category)
-		if normalized != "" {
-			normalizedCategories = append(normalizedCategories, normalized)
+// normalizeMarkets upper-cases market and currency codes and drops invalid or
+// repeated rules, reporting them in the returned error
+func (dp *DataPreprocessor) normalizeMarkets(content models.ContentIngestionRequest, result *ProcessingResult) error {
+	var markets []models.MarketAvailability
+	var invalid []string
+	seen := make(map[string]bool)
+
+	for _, rule := range content.Markets {
+		rule.Market = strings.ToUpper(strings.TrimSpace(rule.Market))
+		rule.Currency = strings.ToUpper(strings.TrimSpace(rule.Currency))
+
+		switch {
+		case rule.Market != AnyMarket && !isAlphaCode(rule.Market, 2):
+			invalid = append(invalid, fmt.Sprintf("invalid market %q", rule.Market))
+		case rule.Currency != "" && !isAlphaCode(rule.Currency, 3):
+			invalid = append(invalid, fmt.Sprintf("invalid currency %q for market %s", rule.Currency, rule.Market))
+		case rule.AvailableFrom != nil && rule.AvailableUntil != nil && !rule.AvailableFrom.Before(*rule.AvailableUntil):
+			invalid = append(invalid, fmt.Sprintf("empty availability window for market %s", rule.Market))
+		case seen[rule.Market]:
+			invalid = append(invalid, fmt.Sprintf("duplicate rule for market %s", rule.Market))
+		default:
+			seen[rule.Market] = true
+			markets = append(markets, rule)
 		}
 	}
 
-	// Remove duplicates
-	categorySet := make(map[string]bool)
-	uniqueCategories := []string{}
-	for _, cat := range normalizedCategories {
-		if !categorySet[cat] {
-			categorySet[cat] = true
-			uniqueCategories = append(uniqueCategories, cat)
-		}
+	result.ProcessedContent.Markets = markets
+	if len(invalid) > 0 {
+		return fmt.Errorf("%s", strings.Join(invalid, "; "))
 	}
-
-	result.ProcessedContent.Categories = uniqueCategories
 	return nil
 }
 
-func (dp *DataPreprocessor) validateMetadata(content models.ContentIngestionRequest, result *ProcessingResult) error {
-	validatedMetadata := make(map[string]interface{})
+func isAlphaCode(code string, length int) bool {
+	if len(code) != length {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
 
+// validateMetadata keeps the metadata fields that match the content type schema.
+// Undeclared fields are dropped with a warning; invalid values are dropped and
+// reported in the returned error.
+func (dp *DataPreprocessor) validateMetadata(content models.ContentIngestionRequest, result *ProcessingResult) error {
+	validatedMetadata := make(map[string]interface{}, len(content.Metadata))
 	for key, value := range content.Metadata {
-		// Validate metadata based on content type
-		if dp.isValidMetadataField(content.Type, key, value) {
-			validatedMetadata[key] = value
-		} else {
+		validatedMetadata[key] = value
+	}
+	result.ProcessedContent.Metadata = validatedMetadata
+
+	// Unknown types fail validation before preprocessing
+	schemas := dp.contentTypeSchemas()
+	if !schemas.HasContentType(content.Type) {
+		return nil
+	}
+
+	var invalid []string
+	for _, fieldErr := range schemas.ValidateMetadata(content.Type, content.Metadata).Errors {
+		key := validation.MetadataKey(fieldErr.Field)
+		if key == "" {
+			continue
+		}
+		if _, present := validatedMetadata[key]; !present {
+			continue // Missing required fields have nothing to drop
+		}
+		delete(validatedMetadata, key)
+
+		if fieldErr.Code == validation.CodeUnknownField {
 			dp.logger.WithFields(logrus.Fields{
 				"content_type": content.Type,
 				"key":          key,
-				"value":        value,
-			}).Warn("Invalid metadata field")
+			}).Warn("Dropping metadata field not in content type schema")
+			continue
 		}
+		invalid = append(invalid, fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message))
 	}
 
-	result.ProcessedContent.Metadata = validatedMetadata
+	if len(invalid) > 0 {
+		return fmt.Errorf("%s", strings.Join(invalid, "; "))
+	}
 	return nil
 }
 
@@ -306,68 +462,36 @@ func (dp *DataPreprocessor) cleanText(text string) string {
 	return strings.TrimSpace(cleaned)
 }
 
+// extractKeywords returns the highest weighted non-stop-word terms of the title
+// and description, using the stop words and stemmer of the detected language
 func (dp *DataPreprocessor) extractKeywords(title string, description *string) []string {
 	text := title
 	if description != nil {
 		text += " " + *description
 	}
+	return keywordTexts(dp.keywordIndex.Extract(title, description, dp.detectLanguage(text)), maxKeywords)
+}
 
-	// Simple keyword extraction (TF-IDF would be more sophisticated)
-	words := strings.Fields(strings.ToLower(text))
-	wordCount := make(map[string]int)
-
-	for _, word := range words {
-		// Remove punctuation
-		word = regexp.MustCompile(`[^\p{L}\p{N}]`).ReplaceAllString(word, "")
-		if len(word) >= 3 && !dp.stopWords[word] {
-			wordCount[word]++
-		}
+// keywordTexts returns the surface forms of the first limit keywords
+func keywordTexts(keywords []models.Keyword, limit int) []string {
+	if len(keywords) > limit {
+		keywords = keywords[:limit]
 	}
-
-	// Get top keywords
-	keywords := []string{}
-	for word, count := range wordCount {
-		if count >= 2 || len(keywords) < 10 {
-			keywords = append(keywords, word)
-		}
-		if len(keywords) >= 10 {
-			break
-		}
+	texts := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		texts = append(texts, keyword.Text)
 	}
-
-	return keywords
+	return texts
 }
 
+// detectLanguage returns the ISO 639-1 code of the text, or "unknown"
 func (dp *DataPreprocessor) detectLanguage(text string) string {
-	// Simplified language detection
-	// In a real implementation, you'd use a proper language detection library
-
-	// Count character frequencies for basic detection
-	latinCount := 0
-	totalCount := 0
-
-	for _, r := range text {
-		if unicode.IsLetter(r) {
-			totalCount++
-			if r <= 255 {
-				latinCount++
-			}
-		}
-	}
-
-	if totalCount == 0 {
-		return "unknown"
-	}
-
-	latinRatio := float64(latinCount) / float64(totalCount)
-	if latinRatio > 0.8 {
-		return "en" // Assume English for Latin script
-	}
-
-	return "unknown"
+	return nlp.DetectLanguage(text).Language
 }
 
-func (dp *DataPreprocessor) extractEntities(title string, description *string) map[string][]string {
+// extractEntities finds emails, URLs, numbers, product model numbers and noun
+// phrases in the title and description
+func (dp *DataPreprocessor) extractEntities(title string, description *string, language string) map[string][]string {
 	text := title
 	if description != nil {
 		text += " " + *description
@@ -396,174 +520,95 @@ func (dp *DataPreprocessor) extractEntities(title string, description *string) m
 		entities["numbers"] = numbers
 	}
 
+	// Model numbers such as "WH-1000XM4"; URLs and emails are left out
+	if modelNumbers := nlp.ModelNumbers(urlRegex.ReplaceAllString(emailRegex.ReplaceAllString(text, " "), " ")); len(modelNumbers) > 0 {
+		entities["models"] = modelNumbers
+	}
+
+	if phrases := nlp.NounPhrases(text, language, maxNounPhrases); len(phrases) > 0 {
+		entities["noun_phrases"] = phrases
+	}
+
 	return entities
 }
 
+// validateImageURL checks an image through the SSRF-safe fetcher. With an image
+// store or image hashing the image is downloaded and its perceptual hashes are
+// computed; otherwise only a HEAD request is made.
 func (dp *DataPreprocessor) validateImageURL(ctx context.Context, imageURL string) (*ImageMetadata, error) {
 	metadata := &ImageMetadata{
 		Valid: false,
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "HEAD", imageURL, nil)
-	if err != nil {
-		return metadata, fmt.Errorf("invalid URL: %w", err)
-	}
-
-	resp, err := dp.httpClient.Do(req)
-	if err != nil {
-		return metadata, fmt.Errorf("HTTP request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return metadata, fmt.Errorf("HTTP status %d", resp.StatusCode)
-	}
-
-	// Check content type
-	contentType := resp.Header.Get("Content-Type")
-	if !strings.HasPrefix(contentType, "image/") {
-		return metadata, fmt.Errorf("not an image: %s", contentType)
-	}
-
-	// Check content length
-	contentLengthStr := resp.Header.Get("Content-Length")
-	if contentLengthStr != "" {
-		contentLength, err := strconv.ParseInt(contentLengthStr, 10, 64)
-		if err == nil {
-			if contentLength > 10*1024*1024 { // 10MB limit
-				return metadata, fmt.Errorf("image too large: %d bytes", contentLength)
-			}
-			metadata.Size = contentLength
+	if dp.imageStore != nil {
+		stored, _, err := dp.imageStore.Get(ctx, imageURL)
+		if err != nil {
+			return metadata, err
 		}
-	}
-
-	metadata.ContentType = contentType
-	metadata.Format = strings.TrimPrefix(contentType, "image/")
-	metadata.Valid = true
-
-	return metadata, nil
-}
-
-func (dp *DataPreprocessor) generateFingerprint(title string, description *string) string {
-	text := title
-	if description != nil {
-		text += *description
-	}
-
-	// Simple hash-based fingerprint
-	// In production, you might use a more sophisticated approach
-	hash := 0
-	for _, char := range text {
-		hash = hash*31 + int(char)
-	}
-
-	return fmt.Sprintf("%x", hash)
-}
-
-func (dp *DataPreprocessor) normalizeCategoryName(category string) string {
-	// Convert to lowercase and trim
-	normalized := strings.ToLower(strings.TrimSpace(category))
-
-	// Check against taxonomy
-	for standardCategory, aliases := range dp.categoryTaxonomy {
-		if normalized == strings.ToLower(standardCategory) {
-			return standardCategory
+		hashes, err := stored.Hashes()
+		if err != nil {
+			return metadata, err
 		}
-		for _, alias := range aliases {
-			if normalized == strings.ToLower(alias) {
-				return standardCategory
-			}
+		metadata.Width = stored.Width
+		metadata.Height = stored.Height
+		metadata.Format = stored.Format
+		metadata.Size = stored.Size
+		metadata.ContentType = stored.ContentType
+		metadata.setHashes(hashes)
+		metadata.Valid = true
+		return metadata, nil
+	}
+
+	if dp.hashImages {
+		fetched, err := dp.imageFetcher.Fetch(ctx, imageURL)
+		if err != nil {
+			return metadata, err
 		}
+		info, _, err := media.InspectImage(fetched.Data, dp.imageFetcher.MaxPixels())
+		if err != nil {
+			return metadata, err
+		}
+		metadata.Width = info.Width
+		metadata.Height = info.Height
+		metadata.Format = info.Format
+		metadata.Size = int64(len(fetched.Data))
+		metadata.ContentType = fetched.ContentType
+		metadata.setHashes(info.Hashes)
+		metadata.Valid = true
+		return metadata, nil
 	}
 
-	// If not in taxonomy, return cleaned version
-	if len(normalized) > 0 {
-		return normalized
+	probe, err := dp.imageFetcher.Probe(ctx, imageURL)
+	if err != nil {
+		return metadata, err
 	}
 
-	return ""
-}
-
-func (dp *DataPreprocessor) isValidMetadataField(contentType, key string, value interface{}) bool {
-	// Type-specific validation rules
-	switch contentType {
-	case "product":
-		return dp.isValidProductMetadata(key, value)
-	case "video":
-		return dp.isValidVideoMetadata(key, value)
-	case "article":
-		return dp.isValidArticleMetadata(key, value)
-	default:
-		return true // Allow all for unknown types
+	if probe.ContentLength > 0 {
+		metadata.Size = probe.ContentLength
 	}
-}
+	metadata.ContentType = probe.ContentType
+	metadata.Format = strings.TrimPrefix(probe.ContentType, "image/")
+	metadata.Valid = true
 
-func (dp *DataPreprocessor) isValidProductMetadata(key string, value interface{}) bool {
-	validKeys := map[string]bool{
-		"price":        true,
-		"currency":     true,
-		"brand":        true,
-		"model":        true,
-		"sku":          true,
-		"availability": true,
-		"rating":       true,
-		"reviews":      true,
-	}
-	return validKeys[key]
+	return metadata, nil
 }
 
-func (dp *DataPreprocessor) isValidVideoMetadata(key string, value interface{}) bool {
-	validKeys := map[string]bool{
-		"duration":   true,
-		"resolution": true,
-		"format":     true,
-		"views":      true,
-		"likes":      true,
-		"channel":    true,
-		"published":  true,
-	}
-	return validKeys[key]
+func (m *ImageMetadata) setHashes(hashes media.ImageHashes) {
+	m.hashes = &hashes
+	m.PHash = media.FormatHash(hashes.PHash)
+	m.DHash = media.FormatHash(hashes.DHash)
 }
 
-func (dp *DataPreprocessor) isValidArticleMetadata(key string, value interface{}) bool {
-	validKeys := map[string]bool{
-		"author":       true,
-		"published":    true,
-		"word_count":   true,
-		"reading_time": true,
-		"tags":         true,
-		"source":       true,
+func (dp *DataPreprocessor) generateFingerprint(title string, description *string) uint64 {
+	text := dp.cleanText(title)
+	if description != nil {
+		text += " " + dp.cleanText(*description)
 	}
-	return validKeys[key]
-}
 
-func initializeCategoryTaxonomy() map[string][]string {
-	return map[string][]string{
-		"Electronics":   {"electronic", "tech", "technology", "gadget", "gadgets"},
-		"Clothing":      {"clothes", "apparel", "fashion", "wear"},
-		"Books":         {"book", "literature", "reading"},
-		"Home":          {"house", "household", "domestic"},
-		"Sports":        {"sport", "fitness", "exercise", "athletic"},
-		"Food":          {"foods", "cuisine", "cooking", "recipe"},
-		"Travel":        {"tourism", "vacation", "trip", "journey"},
-		"Health":        {"medical", "wellness", "healthcare"},
-		"Education":     {"learning", "academic", "school", "university"},
-		"Entertainment": {"fun", "games", "movies", "music"},
-	}
+	// SimHash keeps near-identical texts within a small Hamming distance
+	return SimHash(text)
 }
 
-func initializeStopWords() map[string]bool {
-	stopWords := []string{
-		"a", "an", "and", "are", "as", "at", "be", "by", "for", "from",
-		"has", "he", "in", "is", "it", "its", "of", "on", "that", "the",
-		"to", "was", "will", "with", "the", "this", "but", "they", "have",
-		"had", "what", "said", "each", "which", "she", "do", "how", "their",
-		"if", "up", "out", "many", "then", "them", "these", "so", "some",
-	}
-
-	stopWordMap := make(map[string]bool)
-	for _, word := range stopWords {
-		stopWordMap[word] = true
-	}
-	return stopWordMap
+func (dp *DataPreprocessor) normalizeCategoryName(category string) string {
+	return dp.taxonomy.Current().Canonical(category)
 }
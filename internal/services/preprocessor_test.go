@@ -1,7 +1,13 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/google/uuid"
@@ -9,6 +15,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/media"
+	"github.com/temcen/pirex/internal/validation"
 	"github.com/temcen/pirex/pkg/models"
 )
 
@@ -232,7 +241,7 @@ func TestDataPreprocessor_ExtractEntities(t *testing.T) {
 	title := "Contact us at support@example.com or visit https://example.com"
 	description := stringPtr("Price: $99.99 for premium package")
 
-	entities := preprocessor.extractEntities(title, description)
+	entities := preprocessor.extractEntities(title, description, "en")
 
 	assert.Contains(t, entities, "emails")
 	assert.Contains(t, entities["emails"], "support@example.com")
@@ -244,6 +253,31 @@ func TestDataPreprocessor_ExtractEntities(t *testing.T) {
 	assert.Contains(t, entities["numbers"], "$99.99")
 }
 
+func TestDataPreprocessor_ProductEntities(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	preprocessor := NewDataPreprocessor(logger)
+	index := newTestKeywordIndex(&config.KeywordConfig{})
+	index.Apply(&KeywordUpdate{Brand: "Bose"})
+	preprocessor.SetKeywordIndex(index)
+
+	result, err := preprocessor.ProcessContent(context.Background(), uuid.New(), models.ContentIngestionRequest{
+		Type:        "product",
+		Title:       "Sony WH-1000XM4",
+		Description: stringPtr("Quieter than Bose. Ideal for noise cancelling on flights."),
+		Metadata:    map[string]interface{}{"brand": "Sony"},
+	})
+	require.NoError(t, err)
+
+	entities := result.ProcessingHints["entities"].(map[string][]string)
+	assert.Equal(t, []string{"Sony", "Bose"}, entities["brands"])
+	assert.Equal(t, []string{"WH-1000XM4"}, entities["models"])
+	assert.Contains(t, entities["noun_phrases"], "noise cancelling")
+
+	require.NotEmpty(t, result.ProcessedContent.Keywords)
+	assert.Contains(t, result.ProcessingHints["keywords"], "sony")
+}
+
 func TestDataPreprocessor_NormalizeCategoryName(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.WarnLevel)
@@ -375,6 +409,17 @@ func TestDataPreprocessor_ValidateMetadata(t *testing.T) {
 			expectValid:   []string{"duration", "views"},
 			expectInvalid: []string{"invalid_field"},
 		},
+		{
+			name:        "course metadata",
+			contentType: "course",
+			metadata: map[string]interface{}{
+				"instructor":    "Jane Doe",
+				"level":         "beginner",
+				"invalid_field": "should be filtered",
+			},
+			expectValid:   []string{"instructor", "level"},
+			expectInvalid: []string{"invalid_field"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -403,7 +448,116 @@ func TestDataPreprocessor_ValidateMetadata(t *testing.T) {
 	}
 }
 
+func TestDataPreprocessor_ValidateMetadata_InvalidValues(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	preprocessor := NewDataPreprocessor(logger)
+
+	content := models.ContentIngestionRequest{
+		Type:  "product",
+		Title: "Test",
+		Metadata: map[string]interface{}{
+			"price": "free",
+			"brand": "TestBrand",
+		},
+	}
+	result := &ProcessingResult{ProcessedContent: &models.ContentItem{}}
+
+	err := preprocessor.validateMetadata(content, result)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "metadata.price")
+	assert.Equal(t, map[string]interface{}{"brand": "TestBrand"}, result.ProcessedContent.Metadata)
+}
+
+func TestDataPreprocessor_ValidateContent(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	preprocessor := NewDataPreprocessor(logger)
+
+	t.Run("valid", func(t *testing.T) {
+		assert.Empty(t, preprocessor.ValidateContent(models.ContentIngestionRequest{
+			Type:     "podcast",
+			Title:    "Episode 1",
+			Metadata: map[string]interface{}{"episode": 1, "duration": "45:10", "unknown": "dropped later"},
+		}))
+	})
+
+	t.Run("per-field errors", func(t *testing.T) {
+		fieldErrors := preprocessor.ValidateContent(models.ContentIngestionRequest{
+			Type:     "book",
+			Metadata: map[string]interface{}{"isbn": "not-an-isbn", "pages": 0},
+		})
+		fields := make([]string, len(fieldErrors))
+		for i, fieldErr := range fieldErrors {
+			fields[i] = fieldErr.Field
+		}
+		assert.Equal(t, []string{"title", "metadata.isbn", "metadata.pages"}, fields)
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		fieldErrors := preprocessor.ValidateContent(models.ContentIngestionRequest{Type: "invalid_type", Title: "Test"})
+		require.Len(t, fieldErrors, 1)
+		assert.Equal(t, "type", fieldErrors[0].Field)
+		assert.Equal(t, validation.CodeUnknownContentType, fieldErrors[0].Code)
+	})
+}
+
 // Helper function to create string pointer
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestDataPreprocessor_ImageHashing(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	img := image.NewGray(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8((x*x + y*3) % 256)})
+		}
+	}
+	var pngData bytes.Buffer
+	require.NoError(t, png.Encode(&pngData, img))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngData.Bytes())
+	}))
+	defer server.Close()
+
+	preprocessor := NewDataPreprocessor(logger)
+	preprocessor.SetImageFetcher(media.NewImageFetcher(config.ImageFetchConfig{AllowPrivateNetworks: true}))
+	preprocessor.SetImageHashing(true)
+
+	result, err := preprocessor.ProcessContent(context.Background(), uuid.New(), models.ContentIngestionRequest{
+		Type:      "product",
+		Title:     "Desk lamp",
+		ImageURLs: []string{server.URL + "/lamp.png"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.ProcessedContent.ImageHashes, 1)
+	hash := result.ProcessedContent.ImageHashes[0]
+	assert.Equal(t, server.URL+"/lamp.png", hash.ImageURL)
+	assert.Equal(t, media.PerceptualHash(img), hash.PHash)
+	assert.Equal(t, media.DifferenceHash(img), hash.DHash)
+
+	metadata := result.ProcessingHints["image_metadata"].([]ImageMetadata)
+	require.Len(t, metadata, 1)
+	assert.Equal(t, 64, metadata[0].Width)
+	assert.Equal(t, media.FormatHash(hash.PHash), metadata[0].PHash)
+
+	// The default fetcher refuses the loopback test server
+	strict := NewDataPreprocessor(logger)
+	strict.SetImageHashing(true)
+	result, err = strict.ProcessContent(context.Background(), uuid.New(), models.ContentIngestionRequest{
+		Type:      "product",
+		Title:     "Desk lamp",
+		ImageURLs: []string{server.URL + "/lamp.png"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, result.ProcessedContent.ImageURLs)
+	assert.Empty(t, result.ProcessedContent.ImageHashes)
+}
@@ -2,19 +2,22 @@ package services
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"math"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 
 	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/graph"
 	"github.com/temcen/pirex/pkg/models"
 )
 
@@ -26,7 +29,7 @@ type DatabaseQuerier interface {
 // RecommendationAlgorithmsService implements core recommendation algorithms
 type RecommendationAlgorithmsService struct {
 	db     DatabaseQuerier
-	neo4j  neo4j.DriverWithContext
+	graph  *graph.Repository
 	redis  *redis.Client // warm cache
 	config *config.AlgorithmConfig
 	logger *logrus.Logger
@@ -35,36 +38,38 @@ type RecommendationAlgorithmsService struct {
 // NewRecommendationAlgorithmsService creates a new recommendation algorithms service
 func NewRecommendationAlgorithmsService(
 	db DatabaseQuerier,
-	neo4j neo4j.DriverWithContext,
+	graphRepo *graph.Repository,
 	redis *redis.Client,
 	config *config.AlgorithmConfig,
 	logger *logrus.Logger,
 ) *RecommendationAlgorithmsService {
 	return &RecommendationAlgorithmsService{
 		db:     db,
-		neo4j:  neo4j,
+		graph:  graphRepo,
 		redis:  redis,
 		config: config,
 		logger: logger,
 	}
 }
 
-// SemanticSearchRecommendations generates recommendations using semantic search with pgvector
+// SemanticSearchRecommendations generates recommendations using semantic search with pgvector.
+// Each interest gets its own nearest neighbour query and a share of the slots
+// proportional to its weight, so a strong interest cannot crowd out the others.
 func (s *RecommendationAlgorithmsService) SemanticSearchRecommendations(
 	ctx context.Context,
 	userID uuid.UUID,
-	userEmbedding []float32,
+	interests []models.InterestVector,
 	contentTypes []string,
 	categories []string,
 	limit int,
 ) ([]models.ScoredItem, error) {
-	if !s.config.SemanticSearch.Enabled {
+	if !s.config.SemanticSearch.Enabled || len(interests) == 0 {
 		return nil, nil
 	}
 
-	// Create cache key for frequent queries
-	cacheKey := fmt.Sprintf("semantic_search:%s:%v:%v:%d",
-		userID.String(), contentTypes, categories, limit)
+	// Create cache key for frequent queries; the vectors change with the session
+	cacheKey := fmt.Sprintf("semantic_search:%s:%s:%v:%v:%d",
+		userID.String(), interestsFingerprint(interests), contentTypes, categories, limit)
 
 	// Try cache first
 	if cached, err := s.getCachedResults(ctx, cacheKey); err == nil && cached != nil {
@@ -72,6 +77,69 @@ func (s *RecommendationAlgorithmsService) SemanticSearchRecommendations(
 		return cached, nil
 	}
 
+	var results []models.ScoredItem
+	if len(interests) == 1 {
+		items, err := s.semanticSearchQuery(ctx, userID, interests[0].Vector, contentTypes, categories, limit)
+		if err != nil {
+			return nil, err
+		}
+		results = items
+	} else {
+		weights := make([]float64, len(interests))
+		for i, interest := range interests {
+			weights[i] = interest.Weight
+		}
+		slots := allocateInterestSlots(weights, limit)
+
+		// Fetch extra candidates per interest to make up for overlap between interests
+		lists := make([][]models.ScoredItem, len(interests))
+		errs := make([]error, len(interests))
+		var wg sync.WaitGroup
+		for i, interest := range interests {
+			if slots[i] == 0 {
+				continue
+			}
+			wg.Add(1)
+			go func(i int, vector []float32) {
+				defer wg.Done()
+				lists[i], errs[i] = s.semanticSearchQuery(ctx, userID, vector, contentTypes, categories, slots[i]*2)
+			}(i, interest.Vector)
+		}
+		wg.Wait()
+
+		failed := 0
+		for i, err := range errs {
+			if err != nil {
+				s.logger.Warn("Interest semantic search failed", "user_id", userID, "interest", i, "error", err)
+				failed++
+			}
+		}
+		if failed == len(interests) {
+			return nil, errs[0]
+		}
+		results = mergeInterestResults(lists, slots, limit)
+	}
+
+	// Cache results for 30 minutes
+	if err := s.cacheResults(ctx, cacheKey, results, 30*time.Minute); err != nil {
+		s.logger.Warn("Failed to cache semantic search results", "error", err)
+	}
+
+	s.logger.Debug("Semantic search completed",
+		"user_id", userID, "interests", len(interests), "results", len(results))
+
+	return results, nil
+}
+
+// semanticSearchQuery returns the nearest items to a single vector
+func (s *RecommendationAlgorithmsService) semanticSearchQuery(
+	ctx context.Context,
+	userID uuid.UUID,
+	userEmbedding []float32,
+	contentTypes []string,
+	categories []string,
+	limit int,
+) ([]models.ScoredItem, error) {
 	// Build query with metadata filtering
 	query := `
 		SELECT 
@@ -106,7 +174,7 @@ func (s *RecommendationAlgorithmsService) SemanticSearchRecommendations(
 			FROM user_interactions 
 			WHERE user_id = $%d 
 				AND item_id IS NOT NULL
-				AND interaction_type IN ('rating', 'like', 'dislike')
+				AND interaction_type IN ('rating', 'like', 'dislike', 'not_interested')
 		)`, argIndex)
 	args = append(args, userID)
 
@@ -137,15 +205,105 @@ func (s *RecommendationAlgorithmsService) SemanticSearchRecommendations(
 		})
 	}
 
-	// Cache results for 30 minutes
-	if err := s.cacheResults(ctx, cacheKey, results, 30*time.Minute); err != nil {
-		s.logger.Warn("Failed to cache semantic search results", "error", err)
+	return results, nil
+}
+
+// allocateInterestSlots splits limit slots between interests in proportion to
+// their weights with the largest remainder method. While there are enough
+// slots to go around, interests left without one take it from the largest share.
+func allocateInterestSlots(weights []float64, limit int) []int {
+	slots := make([]int, len(weights))
+	if len(weights) == 0 || limit <= 0 {
+		return slots
 	}
 
-	s.logger.Debug("Semantic search completed",
-		"user_id", userID, "results", len(results))
+	var total float64
+	for _, weight := range weights {
+		total += math.Max(weight, 0)
+	}
 
-	return results, nil
+	remainders := make([]float64, len(weights))
+	allocated := 0
+	for i, weight := range weights {
+		share := float64(limit) / float64(len(weights))
+		if total > 0 {
+			share = float64(limit) * math.Max(weight, 0) / total
+		}
+		slots[i] = int(share)
+		allocated += slots[i]
+		remainders[i] = share - float64(slots[i])
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for i := 0; allocated < limit; i++ {
+		slots[order[i%len(order)]]++
+		allocated++
+	}
+
+	if limit >= len(weights) {
+		for i := range slots {
+			if slots[i] > 0 {
+				continue
+			}
+			largest := 0
+			for j := range slots {
+				if slots[j] > slots[largest] {
+					largest = j
+				}
+			}
+			slots[largest]--
+			slots[i]++
+		}
+	}
+	return slots
+}
+
+// mergeInterestResults takes each interest's share of its own nearest items,
+// skipping items already taken by a stronger interest, then fills slots an
+// interest could not use with the best remaining candidates of any interest
+func mergeInterestResults(lists [][]models.ScoredItem, slots []int, limit int) []models.ScoredItem {
+	taken := make(map[uuid.UUID]bool)
+	var merged, leftover []models.ScoredItem
+
+	for i, items := range lists {
+		used := 0
+		for _, item := range items {
+			if taken[item.ItemID] {
+				continue
+			}
+			if used < slots[i] && len(merged) < limit {
+				taken[item.ItemID] = true
+				merged = append(merged, item)
+				used++
+				continue
+			}
+			leftover = append(leftover, item)
+		}
+	}
+
+	sort.SliceStable(leftover, func(i, j int) bool {
+		return leftover[i].Score > leftover[j].Score
+	})
+	for _, item := range leftover {
+		if len(merged) >= limit {
+			break
+		}
+		if !taken[item.ItemID] {
+			taken[item.ItemID] = true
+			merged = append(merged, item)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Score > merged[j].Score
+	})
+	return merged
 }
 
 // CollaborativeFilteringRecommendations generates recommendations using collaborative filtering
@@ -212,96 +370,23 @@ func (s *RecommendationAlgorithmsService) PersonalizedPageRankRecommendations(
 		return cached, nil
 	}
 
-	// Create user-centric graph projection and run PageRank
-	session := s.neo4j.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
-	defer session.Close(ctx)
-
-	// First, create a dynamic subgraph including user + similar users + their interactions
-	projectionQuery := `
-		CALL gds.graph.project.cypher(
-			'user-centric-' + $userId,
-			'MATCH (n) WHERE n:User OR n:Content RETURN id(n) AS id, labels(n) AS labels',
-			'MATCH (u:User)-[r:RATED|VIEWED|INTERACTED_WITH]-(c:Content) 
-			 WHERE u.user_id = $userId OR 
-				   u.user_id IN [user.user_id | user IN [(u2:User)-[:SIMILAR_TO]-(u3:User) WHERE u3.user_id = $userId | u2][0..50]]
-			 RETURN id(startNode(r)) AS source, id(endNode(r)) AS target, 
-					CASE type(r) 
-						WHEN "RATED" THEN coalesce(r.rating, 3.0) / 5.0
-						WHEN "VIEWED" THEN coalesce(r.progress, 50.0) / 100.0
-						WHEN "INTERACTED_WITH" THEN 0.5
-						ELSE 0.3
-					END AS weight'
-		) YIELD graphName
-		RETURN graphName`
-
-	result, err := session.Run(ctx, projectionQuery, map[string]interface{}{
-		"userId": userID.String(),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create graph projection: %w", err)
-	}
-
-	var graphName string
-	if result.Next(ctx) {
-		record := result.Record()
-		graphName = record.Values[0].(string)
-	}
-	if err := result.Err(); err != nil {
-		return nil, fmt.Errorf("failed to get graph projection name: %w", err)
-	}
-
-	// Run personalized PageRank
-	pageRankQuery := `
-		CALL gds.pageRank.stream($graphName, {
-			dampingFactor: 0.85,
-			maxIterations: 20,
-			tolerance: 0.0001,
-			sourceNodes: [id(u) | u IN [(user:User) WHERE user.user_id = $userId | user]]
-		})
-		YIELD nodeId, score
-		MATCH (n) WHERE id(n) = nodeId AND n:Content
-		RETURN n.content_id AS item_id, score
-		ORDER BY score DESC
-		LIMIT $limit`
-
-	pageRankResult, err := session.Run(ctx, pageRankQuery, map[string]interface{}{
-		"graphName": graphName,
-		"userId":    userID.String(),
-		"limit":     limit,
-	})
+	// Run PageRank over a user-centric projection of the user, similar users
+	// and their interactions
+	scores, err := s.graph.PersonalizedPageRank(ctx, userID, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to run PageRank: %w", err)
+		return nil, err
 	}
 
-	var results []models.ScoredItem
-	for pageRankResult.Next(ctx) {
-		record := pageRankResult.Record()
-		itemIDStr := record.Values[0].(string)
-		score := record.Values[1].(float64)
-
-		itemID, err := uuid.Parse(itemIDStr)
-		if err != nil {
-			s.logger.Error("Failed to parse item ID from PageRank result", "item_id", itemIDStr)
-			continue
-		}
-
+	results := make([]models.ScoredItem, 0, len(scores))
+	for _, score := range scores {
 		results = append(results, models.ScoredItem{
-			ItemID:     itemID,
-			Score:      score,
+			ItemID:     score.ItemID,
+			Score:      score.Score,
 			Algorithm:  "pagerank",
-			Confidence: s.calculatePageRankConfidence(score),
+			Confidence: s.calculatePageRankConfidence(score.Score),
 		})
 	}
 
-	// Clean up graph projection
-	cleanupQuery := `CALL gds.graph.drop($graphName)`
-	_, err = session.Run(ctx, cleanupQuery, map[string]interface{}{
-		"graphName": graphName,
-	})
-	if err != nil {
-		s.logger.Warn("Failed to cleanup graph projection", "graph_name", graphName, "error", err)
-	}
-
 	// Cache results for 30 minutes
 	if err := s.cacheResults(ctx, cacheKey, results, 30*time.Minute); err != nil {
 		s.logger.Warn("Failed to cache PageRank results", "error", err)
@@ -327,24 +412,21 @@ func (s *RecommendationAlgorithmsService) GraphSignalAnalysisRecommendations(
 		return cached, nil
 	}
 
-	session := s.neo4j.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
-	defer session.Close(ctx)
-
-	// Community detection using Louvain algorithm
-	communities, err := s.detectUserCommunities(ctx, session, userID)
+	// Communities detected by Louvain in the periodic graph sync
+	communities, err := s.detectUserCommunities(ctx, userID)
 	if err != nil {
 		s.logger.Warn("Failed to detect communities", "error", err)
-		communities = []int{0} // Default community
+		communities = nil // No community restriction
 	}
 
 	// Item similarity based on shared users (Jaccard similarity)
-	itemSimilarities, err := s.calculateItemSimilarities(ctx, session, userID, communities)
+	itemSimilarities, err := s.calculateItemSimilarities(ctx, userID, communities)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate item similarities: %w", err)
 	}
 
 	// Signal propagation through user networks
-	propagatedScores, err := s.propagateSignalThroughNetwork(ctx, session, userID, communities, limit)
+	propagatedScores, err := s.propagateSignalThroughNetwork(ctx, userID, communities, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to propagate signal: %w", err)
 	}
@@ -379,58 +461,12 @@ func (s *RecommendationAlgorithmsService) findSimilarUsers(
 		}
 	}
 
-	session := s.neo4j.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
-	defer session.Close(ctx)
-
 	// Find users with shared ratings (minimum 3 items) and calculate Pearson correlation
-	query := `
-		MATCH (u1:User {user_id: $userId})-[r1:RATED]->(item:Content)<-[r2:RATED]-(u2:User)
-		WHERE u1 <> u2
-		WITH u1, u2, collect({item: item.content_id, rating1: r1.rating, rating2: r2.rating}) AS shared_ratings
-		WHERE size(shared_ratings) >= 3
-		WITH u1, u2, shared_ratings,
-			 reduce(sum = 0.0, rating IN shared_ratings | sum + rating.rating1) / size(shared_ratings) AS avg1,
-			 reduce(sum = 0.0, rating IN shared_ratings | sum + rating.rating2) / size(shared_ratings) AS avg2
-		WITH u1, u2, shared_ratings, avg1, avg2,
-			 reduce(num = 0.0, rating IN shared_ratings | num + (rating.rating1 - avg1) * (rating.rating2 - avg2)) AS numerator,
-			 sqrt(reduce(sum = 0.0, rating IN shared_ratings | sum + (rating.rating1 - avg1)^2)) AS denom1,
-			 sqrt(reduce(sum = 0.0, rating IN shared_ratings | sum + (rating.rating2 - avg2)^2)) AS denom2
-		WITH u2, shared_ratings, 
-			 CASE WHEN denom1 * denom2 = 0 THEN 0 ELSE numerator / (denom1 * denom2) END AS correlation
-		WHERE correlation >= $threshold
-		RETURN u2.user_id AS user_id, correlation AS similarity_score, size(shared_ratings) AS shared_items
-		ORDER BY correlation DESC
-		LIMIT $limit`
-
-	result, err := session.Run(ctx, query, map[string]interface{}{
-		"userId":    userID.String(),
-		"threshold": s.config.CollaborativeFilter.SimilarityThreshold,
-		"limit":     limit,
-	})
+	users, err := s.graph.CorrelatedUsers(ctx, userID, s.config.CollaborativeFilter.SimilarityThreshold, limit)
 	if err != nil {
 		return nil, err
 	}
 
-	var users []models.SimilarUser
-	for result.Next(ctx) {
-		record := result.Record()
-		userIDStr := record.Values[0].(string)
-		similarity := record.Values[1].(float64)
-		sharedItems := int(record.Values[2].(int64))
-
-		similarUserID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			continue
-		}
-
-		users = append(users, models.SimilarUser{
-			UserID:          similarUserID,
-			SimilarityScore: similarity,
-			Basis:           "pearson_correlation",
-			SharedItems:     sharedItems,
-		})
-	}
-
 	// Cache for 1 hour
 	if data, err := json.Marshal(users); err == nil {
 		s.redis.Set(ctx, cacheKey, data, time.Hour)
@@ -445,33 +481,17 @@ func (s *RecommendationAlgorithmsService) generateCollaborativeRecommendations(
 	similarUsers []models.SimilarUser,
 	limit int,
 ) ([]models.ScoredItem, error) {
-	session := s.neo4j.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
-	defer session.Close(ctx)
-
 	// Get weighted average ratings from similar users
-	userIDs := make([]string, len(similarUsers))
-	weights := make(map[string]float64)
+	userIDs := make([]uuid.UUID, len(similarUsers))
+	weights := make(map[uuid.UUID]float64)
 
 	for i, user := range similarUsers {
-		userIDs[i] = user.UserID.String()
-		weights[user.UserID.String()] = user.SimilarityScore
+		userIDs[i] = user.UserID
+		weights[user.UserID] = user.SimilarityScore
 	}
 
-	query := `
-		MATCH (u:User)-[r:RATED]->(item:Content)
-		WHERE u.user_id IN $userIds
-			AND NOT EXISTS {
-				MATCH (target:User {user_id: $targetUserId})-[:RATED|LIKED|DISLIKED]->(item)
-			}
-		WITH item, collect({userId: u.user_id, rating: r.rating}) AS ratings
-		RETURN item.content_id AS item_id, ratings
-		LIMIT $limit`
-
-	result, err := session.Run(ctx, query, map[string]interface{}{
-		"userIds":      userIDs,
-		"targetUserId": userID.String(),
-		"limit":        limit * 2, // Get more to account for filtering
-	})
+	// Get more to account for filtering
+	items, err := s.graph.RatingsByUsers(ctx, userIDs, userID, limit*2)
 	if err != nil {
 		return nil, err
 	}
@@ -485,32 +505,19 @@ func (s *RecommendationAlgorithmsService) generateCollaborativeRecommendations(
 
 	itemScores := make(map[uuid.UUID]*itemScore)
 
-	for result.Next(ctx) {
-		record := result.Record()
-		itemIDStr := record.Values[0].(string)
-		ratings := record.Values[1].([]interface{})
-
-		itemID, err := uuid.Parse(itemIDStr)
-		if err != nil {
-			continue
-		}
+	for _, item := range items {
+		score := &itemScore{itemID: item.ItemID}
 
-		score := &itemScore{itemID: itemID}
-
-		for _, ratingData := range ratings {
-			ratingMap := ratingData.(map[string]interface{})
-			userIDStr := ratingMap["userId"].(string)
-			rating := ratingMap["rating"].(float64)
-
-			if weight, exists := weights[userIDStr]; exists {
-				score.weightedSum += rating * weight
+		for _, rating := range item.Ratings {
+			if weight, exists := weights[rating.UserID]; exists {
+				score.weightedSum += rating.Rating * weight
 				score.weightSum += weight
 				score.contributorCount++
 			}
 		}
 
 		if score.weightSum > 0 {
-			itemScores[itemID] = score
+			itemScores[item.ItemID] = score
 		}
 	}
 
@@ -561,7 +568,7 @@ func (s *RecommendationAlgorithmsService) getPopularityBasedRecommendations(
 				FROM user_interactions 
 				WHERE user_id = $1 
 					AND item_id IS NOT NULL
-					AND interaction_type IN ('rating', 'like', 'dislike')
+					AND interaction_type IN ('rating', 'like', 'dislike', 'not_interested')
 			)
 		GROUP BY ci.id, ci.quality_score
 		HAVING COUNT(CASE WHEN ui.interaction_type IN ('rating', 'like', 'view') THEN 1 END) >= 5
@@ -602,7 +609,6 @@ func (s *RecommendationAlgorithmsService) getPopularityBasedRecommendations(
 
 func (s *RecommendationAlgorithmsService) detectUserCommunities(
 	ctx context.Context,
-	session neo4j.SessionWithContext,
 	userID uuid.UUID,
 ) ([]int, error) {
 	// Check cache first
@@ -614,38 +620,11 @@ func (s *RecommendationAlgorithmsService) detectUserCommunities(
 		}
 	}
 
-	query := `
-		CALL gds.graph.project.cypher(
-			'user-similarity-graph',
-			'MATCH (u:User) RETURN id(u) AS id',
-			'MATCH (u1:User)-[:SIMILAR_TO]-(u2:User) RETURN id(u1) AS source, id(u2) AS target'
-		) YIELD graphName
-		CALL gds.louvain.stream(graphName)
-		YIELD nodeId, communityId
-		MATCH (u:User) WHERE id(u) = nodeId AND u.user_id = $userId
-		WITH collect(communityId) AS userCommunities
-		CALL gds.graph.drop('user-similarity-graph')
-		RETURN userCommunities`
-
-	result, err := session.Run(ctx, query, map[string]interface{}{
-		"userId": userID.String(),
-	})
+	communities, err := s.graph.UserCommunities(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	var communities []int
-	if result.Next(ctx) {
-		record := result.Record()
-		if communityList, ok := record.Values[0].([]interface{}); ok {
-			for _, community := range communityList {
-				if communityID, ok := community.(int64); ok {
-					communities = append(communities, int(communityID))
-				}
-			}
-		}
-	}
-
 	// Cache for 2 hours
 	if data, err := json.Marshal(communities); err == nil {
 		s.redis.Set(ctx, cacheKey, data, 2*time.Hour)
@@ -656,97 +635,21 @@ func (s *RecommendationAlgorithmsService) detectUserCommunities(
 
 func (s *RecommendationAlgorithmsService) calculateItemSimilarities(
 	ctx context.Context,
-	session neo4j.SessionWithContext,
 	userID uuid.UUID,
 	communities []int,
 ) (map[uuid.UUID]float64, error) {
 	// Get items that users in the same communities have interacted with
-	query := `
-		MATCH (u:User)-[r:RATED|VIEWED|INTERACTED_WITH]->(item1:Content)
-		WHERE u.community IN $communities
-		MATCH (item1)<-[:RATED|VIEWED|INTERACTED_WITH]-(u2:User)-[:RATED|VIEWED|INTERACTED_WITH]->(item2:Content)
-		WHERE item1 <> item2 AND u2.community IN $communities
-		WITH item1, item2, count(DISTINCT u2) AS shared_users
-		WHERE shared_users >= 3
-		MATCH (item1)<-[:RATED|VIEWED|INTERACTED_WITH]-(all_users1:User)
-		MATCH (item2)<-[:RATED|VIEWED|INTERACTED_WITH]-(all_users2:User)
-		WITH item1, item2, shared_users, 
-			 count(DISTINCT all_users1) AS total_users1,
-			 count(DISTINCT all_users2) AS total_users2
-		WITH item1, item2, 
-			 toFloat(shared_users) / (total_users1 + total_users2 - shared_users) AS jaccard_similarity
-		WHERE jaccard_similarity > 0.1
-		RETURN item2.content_id AS item_id, jaccard_similarity
-		ORDER BY jaccard_similarity DESC
-		LIMIT 100`
-
-	result, err := session.Run(ctx, query, map[string]interface{}{
-		"communities": communities,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	similarities := make(map[uuid.UUID]float64)
-	for result.Next(ctx) {
-		record := result.Record()
-		itemIDStr := record.Values[0].(string)
-		similarity := record.Values[1].(float64)
-
-		if itemID, err := uuid.Parse(itemIDStr); err == nil {
-			similarities[itemID] = similarity
-		}
-	}
-
-	return similarities, nil
+	return s.graph.CommunityItemSimilarities(ctx, communities)
 }
 
 func (s *RecommendationAlgorithmsService) propagateSignalThroughNetwork(
 	ctx context.Context,
-	session neo4j.SessionWithContext,
 	userID uuid.UUID,
 	communities []int,
 	limit int,
 ) (map[uuid.UUID]float64, error) {
 	// Propagate user preferences through the network
-	query := `
-		MATCH (source:User {user_id: $userId})-[r1:RATED]->(item:Content)
-		WHERE r1.rating >= 4.0
-		MATCH (item)<-[r2:RATED]-(intermediate:User)-[r3:RATED]->(target:Content)
-		WHERE intermediate.community IN $communities 
-			AND r2.rating >= 4.0 
-			AND r3.rating >= 4.0
-			AND target <> item
-		WITH target, 
-			 count(DISTINCT intermediate) AS propagation_strength,
-			 avg(r3.rating) AS avg_rating
-		WHERE propagation_strength >= 2
-		RETURN target.content_id AS item_id, 
-			   (propagation_strength * avg_rating / 5.0) AS propagated_score
-		ORDER BY propagated_score DESC
-		LIMIT $limit`
-
-	result, err := session.Run(ctx, query, map[string]interface{}{
-		"userId":      userID.String(),
-		"communities": communities,
-		"limit":       limit,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	scores := make(map[uuid.UUID]float64)
-	for result.Next(ctx) {
-		record := result.Record()
-		itemIDStr := record.Values[0].(string)
-		score := record.Values[1].(float64)
-
-		if itemID, err := uuid.Parse(itemIDStr); err == nil {
-			scores[itemID] = score
-		}
-	}
-
-	return scores, nil
+	return s.graph.PropagatedScores(ctx, userID, communities, limit)
 }
 
 func (s *RecommendationAlgorithmsService) combineGraphSignals(
@@ -839,3 +742,26 @@ func (s *RecommendationAlgorithmsService) cacheResults(ctx context.Context, key
 
 	return s.redis.Set(ctx, key, data, ttl).Err()
 }
+
+// interestsFingerprint identifies the query vectors and their weights in cache keys
+func interestsFingerprint(interests []models.InterestVector) string {
+	hash := fnv.New64a()
+	var buf [8]byte
+	for _, interest := range interests {
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(interest.Weight))
+		hash.Write(buf[:])
+		hash.Write([]byte(vectorFingerprint(interest.Vector)))
+	}
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// vectorFingerprint identifies a query vector in cache keys
+func vectorFingerprint(vector []float32) string {
+	hash := fnv.New64a()
+	var buf [4]byte
+	for _, value := range vector {
+		binary.LittleEndian.PutUint32(buf[:], math.Float32bits(value))
+		hash.Write(buf[:])
+	}
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
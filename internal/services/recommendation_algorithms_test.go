@@ -68,7 +68,7 @@ func TestRecommendationAlgorithmsService_SemanticSearchRecommendations(t *testin
 			WillReturnRows(rows)
 
 		results, err := service.SemanticSearchRecommendations(
-			context.Background(), userID, userEmbedding, contentTypes, categories, limit)
+			context.Background(), userID, []models.InterestVector{{Vector: userEmbedding, Weight: 1}}, contentTypes, categories, limit)
 
 		require.NoError(t, err)
 		assert.Len(t, results, 2)
@@ -95,12 +95,12 @@ func TestRecommendationAlgorithmsService_SemanticSearchRecommendations(t *testin
 			},
 		}
 
-		cacheKey := "semantic_search:" + userID.String() + ":[product]:[electronics]:10"
+		cacheKey := "semantic_search:" + userID.String() + ":" + interestsFingerprint([]models.InterestVector{{Vector: userEmbedding, Weight: 1}}) + ":[product]:[electronics]:10"
 		data, _ := json.Marshal(cachedResults)
 		redisClient.Set(context.Background(), cacheKey, data, time.Minute)
 
 		results, err := service.SemanticSearchRecommendations(
-			context.Background(), userID, userEmbedding, []string{"product"}, []string{"electronics"}, 10)
+			context.Background(), userID, []models.InterestVector{{Vector: userEmbedding, Weight: 1}}, []string{"product"}, []string{"electronics"}, 10)
 
 		require.NoError(t, err)
 		assert.Len(t, results, 1)
@@ -117,7 +117,7 @@ func TestRecommendationAlgorithmsService_SemanticSearchRecommendations(t *testin
 		disabledService := NewRecommendationAlgorithmsService(mockDB, nil, redisClient, disabledConfig, logger)
 
 		results, err := disabledService.SemanticSearchRecommendations(
-			context.Background(), uuid.New(), []float32{0.1}, []string{}, []string{}, 10)
+			context.Background(), uuid.New(), []models.InterestVector{{Vector: []float32{0.1}, Weight: 1}}, []string{}, []string{}, 10)
 
 		require.NoError(t, err)
 		assert.Nil(t, results)
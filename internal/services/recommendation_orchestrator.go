@@ -38,6 +38,11 @@ type RecommendationContext struct {
 	SeedItemID          *uuid.UUID  `json:"seed_item_id,omitempty"` // For item-based recommendations
 	IncludeExplanations bool        `json:"include_explanations"`
 	TimeoutMs           int         `json:"timeout_ms"`
+	Locale              string      `json:"locale,omitempty"`   // BCP 47 tag or Accept-Language header
+	Language            string      `json:"language,omitempty"` // ISO 639-1; derived from Locale when empty
+	Market              string      `json:"market,omitempty"`   // ISO 3166-1 alpha-2; derived from Locale when empty
+
+	sessionVersion string // Current session state, part of the cache key
 }
 
 // AlgorithmResult represents the result from a single algorithm
@@ -68,6 +73,12 @@ type RecommendationOrchestrator struct {
 	userService        UserInteractionServiceInterface
 	diversityFilter    *DiversityFilter
 	explanationService *ExplanationService
+	localeFilter       *LocaleFilter      // Optional; market availability and language boost
+	keywordIndex       *KeywordIndex      // Optional; enables the lexical candidate generator
+	sessions           *SessionStore      // Optional; short-term intent of the current session
+	coVisitation       *CoVisitationIndex // Optional; items often interacted with together
+	negativeFeedback   *NegativeFeedback  // Optional; suppressions and dislike penalties
+	preferences        *PreferenceFilter  // Optional; preferences stated in the profile
 	redis              *redis.Client
 	config             *config.AlgorithmConfig
 	logger             *logrus.Logger
@@ -103,6 +114,78 @@ func NewRecommendationOrchestrator(
 	return orchestrator
 }
 
+// SetLocaleFilter enables market availability filtering and language boosting
+func (o *RecommendationOrchestrator) SetLocaleFilter(filter *LocaleFilter) {
+	o.localeFilter = filter
+}
+
+// SetKeywordIndex adds the lexical algorithm, which matches keyword vectors
+// against the seed item or the items the user liked, to every user tier
+func (o *RecommendationOrchestrator) SetKeywordIndex(index *KeywordIndex) {
+	o.keywordIndex = index
+
+	weight := 0.2
+	if o.config != nil && o.config.Lexical.Weight > 0 {
+		weight = o.config.Lexical.Weight
+	}
+	for _, weights := range o.algorithmWeights {
+		weights["lexical"] = weight
+		o.normalizeWeights(weights)
+	}
+}
+
+// SetSessionStore blends the current session into the preference vector and
+// adds the session algorithm, which recommends items similar to the latest
+// session items, to every user tier
+func (o *RecommendationOrchestrator) SetSessionStore(store *SessionStore) {
+	o.sessions = store
+
+	weight := 0.3
+	if o.config != nil && o.config.Session.Weight > 0 {
+		weight = o.config.Session.Weight
+	}
+	for _, weights := range o.algorithmWeights {
+		weights["session"] = weight
+		o.normalizeWeights(weights)
+	}
+}
+
+// SetCoVisitation adds the co_visitation algorithm, which recommends items
+// often interacted with or bought together with the seed item, the session's
+// items or the user's latest items, to every user tier
+func (o *RecommendationOrchestrator) SetCoVisitation(index *CoVisitationIndex) {
+	o.coVisitation = index
+
+	weight := 0.25
+	if o.config != nil && o.config.CoVisitation.Weight > 0 {
+		weight = o.config.CoVisitation.Weight
+	}
+	for _, weights := range o.algorithmWeights {
+		weights["co_visitation"] = weight
+		o.normalizeWeights(weights)
+	}
+}
+
+// SetNegativeFeedback records suppressions from negative feedback and applies
+// them, with the profile's negative vector, to every ranking
+func (o *RecommendationOrchestrator) SetNegativeFeedback(negativeFeedback *NegativeFeedback) {
+	o.negativeFeedback = negativeFeedback
+}
+
+// SetPreferenceFilter applies the preferences users state in their profile to
+// every ranking and adds the explicit_preferences algorithm, which recommends
+// popular items in favourite categories, for new users
+func (o *RecommendationOrchestrator) SetPreferenceFilter(filter *PreferenceFilter) {
+	o.preferences = filter
+
+	weight := 0.5
+	if o.config != nil && o.config.ExplicitPreferences.Weight > 0 {
+		weight = o.config.ExplicitPreferences.Weight
+	}
+	o.algorithmWeights[NewUser]["explicit_preferences"] = weight
+	o.normalizeWeights(o.algorithmWeights[NewUser])
+}
+
 // GenerateRecommendations orchestrates multiple algorithms to generate final recommendations
 func (o *RecommendationOrchestrator) GenerateRecommendations(
 	ctx context.Context,
@@ -110,6 +193,14 @@ func (o *RecommendationOrchestrator) GenerateRecommendations(
 ) (*OrchestrationResult, error) {
 	startTime := time.Now()
 
+	// Resolve language and market before the cache key is built
+	if o.localeFilter != nil {
+		o.localeFilter.ResolveLocale(reqCtx)
+	}
+
+	// Session activity changes the result, so it is part of the cache key
+	session := o.currentSession(ctx, reqCtx)
+
 	// Check cache first
 	if cached, err := o.getCachedRecommendations(ctx, reqCtx); err == nil && cached != nil {
 		o.logger.Debug("Orchestration cache hit", "user_id", reqCtx.UserID)
@@ -132,7 +223,7 @@ func (o *RecommendationOrchestrator) GenerateRecommendations(
 	}
 
 	// Execute algorithms in parallel
-	algorithmResults := o.executeAlgorithmsParallel(ctx, reqCtx, userProfile, userTier, strategy)
+	algorithmResults := o.executeAlgorithmsParallel(ctx, reqCtx, userProfile, session, userTier, strategy)
 
 	// Combine and rank results
 	finalRecommendations, err := o.combineAndRankResults(ctx, reqCtx, algorithmResults, userTier)
@@ -150,6 +241,40 @@ func (o *RecommendationOrchestrator) GenerateRecommendations(
 		}
 	}
 
+	// Drop items unavailable in the market and boost the user's language
+	if o.localeFilter != nil {
+		localized, err := o.localeFilter.Apply(ctx, reqCtx, finalRecommendations)
+		if err != nil {
+			o.logger.Warn("Failed to apply locale filter", "error", err)
+		} else {
+			finalRecommendations = localized
+		}
+	}
+
+	// Drop what the user's stated preferences exclude and boost favourites
+	if o.preferences != nil && userProfile != nil {
+		preferred, err := o.preferences.Apply(ctx, reqCtx.UserID, ExplicitPreferencesOf(userProfile), finalRecommendations)
+		if err != nil {
+			o.logger.Warn("Failed to apply explicit preferences", "error", err)
+		} else {
+			finalRecommendations = preferred
+		}
+	}
+
+	// Drop suppressed items and penalize what the user rejected
+	if o.negativeFeedback != nil {
+		var negativeVector []float32
+		if userProfile != nil {
+			negativeVector = userProfile.NegativeVector
+		}
+		penalized, err := o.negativeFeedback.Apply(ctx, reqCtx.UserID, negativeVector, finalRecommendations)
+		if err != nil {
+			o.logger.Warn("Failed to apply negative feedback", "error", err)
+		} else {
+			finalRecommendations = penalized
+		}
+	}
+
 	// Limit to requested count
 	if len(finalRecommendations) > reqCtx.Count {
 		finalRecommendations = finalRecommendations[:reqCtx.Count]
@@ -208,6 +333,7 @@ func (o *RecommendationOrchestrator) executeAlgorithmsParallel(
 	ctx context.Context,
 	reqCtx *RecommendationContext,
 	userProfile *models.UserProfile,
+	session *SessionState,
 	userTier UserTier,
 	strategy string,
 ) map[string]*AlgorithmResult {
@@ -215,6 +341,18 @@ func (o *RecommendationOrchestrator) executeAlgorithmsParallel(
 	// Determine which algorithms to run based on strategy
 	algorithmsToRun := o.selectAlgorithms(userTier, strategy)
 
+	// Session candidates need items from the current session
+	seeds := o.sessions.Seeds(session)
+	if len(seeds) > 0 {
+		algorithmsToRun = append(algorithmsToRun, "session")
+	}
+
+	// New users who stated favourite categories get candidates from them
+	prefs := ExplicitPreferencesOf(userProfile)
+	if o.preferences != nil && userTier == NewUser && len(prefs.FavoriteCategories) > 0 {
+		algorithmsToRun = append(algorithmsToRun, "explicit_preferences")
+	}
+
 	// Set timeout for algorithm execution
 	timeout := time.Duration(reqCtx.TimeoutMs) * time.Millisecond
 	if timeout == 0 {
@@ -245,9 +383,9 @@ func (o *RecommendationOrchestrator) executeAlgorithmsParallel(
 			// Execute specific algorithm
 			switch alg {
 			case "semantic_search":
-				if userProfile != nil && len(userProfile.PreferenceVector) > 0 {
+				if interests := o.queryInterests(algorithmCtx, userProfile, seeds); len(interests) > 0 {
 					items, err := o.algorithmService.SemanticSearchRecommendations(
-						algorithmCtx, reqCtx.UserID, userProfile.PreferenceVector,
+						algorithmCtx, reqCtx.UserID, interests,
 						reqCtx.ContentTypes, reqCtx.Categories, reqCtx.Count*2,
 					)
 					result.Items = items
@@ -277,6 +415,36 @@ func (o *RecommendationOrchestrator) executeAlgorithmsParallel(
 				result.Items = items
 				result.Error = err
 
+			case "lexical":
+				items, err := o.keywordIndex.LexicalRecommendations(
+					algorithmCtx, reqCtx.UserID, reqCtx.SeedItemID, reqCtx.ExcludeItems, reqCtx.Count*2,
+				)
+				result.Items = items
+				result.Error = err
+
+			case "co_visitation":
+				items, err := o.coVisitation.Recommendations(
+					algorithmCtx, reqCtx.UserID, coVisitationSeeds(reqCtx, seeds), reqCtx.ExcludeItems, reqCtx.Count*2,
+				)
+				result.Items = items
+				result.Error = err
+
+			case "session":
+				items, err := o.sessions.Recommendations(
+					algorithmCtx, session, seeds, reqCtx.ContentTypes, reqCtx.Categories,
+					reqCtx.ExcludeItems, reqCtx.Count*2,
+				)
+				result.Items = items
+				result.Error = err
+
+			case "explicit_preferences":
+				items, err := o.preferences.Recommendations(
+					algorithmCtx, prefs, reqCtx.ContentTypes, reqCtx.Categories,
+					reqCtx.ExcludeItems, reqCtx.Count*2,
+				)
+				result.Items = items
+				result.Error = err
+
 			default:
 				result.Error = fmt.Errorf("unknown algorithm: %s", alg)
 			}
@@ -309,6 +477,67 @@ func (o *RecommendationOrchestrator) executeAlgorithmsParallel(
 	return results
 }
 
+// coVisitationSeeds returns the items co-visitation candidates are found for:
+// the seed item of item-based requests, else the session's items. Without
+// either the index falls back to the user's latest items.
+func coVisitationSeeds(reqCtx *RecommendationContext, sessionSeeds []SessionSeed) []SessionSeed {
+	if reqCtx.SeedItemID != nil {
+		return []SessionSeed{{ItemID: *reqCtx.SeedItemID, Weight: 1}}
+	}
+	return sessionSeeds
+}
+
+// currentSession loads the user's current session and records its version
+// on the request. Failures only lose the short-term signal.
+func (o *RecommendationOrchestrator) currentSession(ctx context.Context, reqCtx *RecommendationContext) *SessionState {
+	session, err := o.sessions.Current(ctx, reqCtx.UserID)
+	if err != nil {
+		o.logger.Warn("Failed to load session state", "user_id", reqCtx.UserID, "error", err)
+		return nil
+	}
+	if session != nil {
+		reqCtx.sessionVersion = fmt.Sprintf("%s.%d", session.SessionID, session.Version)
+	}
+	return session
+}
+
+// queryInterests returns the vectors semantic search queries with: the
+// profile's interests, or its single preference vector before interests are
+// built, each blended with the short-term vector of the session seeds. Users
+// without a profile yet get the session vector alone.
+func (o *RecommendationOrchestrator) queryInterests(ctx context.Context, userProfile *models.UserProfile, seeds []SessionSeed) []models.InterestVector {
+	var interests []models.InterestVector
+	if userProfile != nil {
+		interests = userProfile.Interests
+		if len(interests) == 0 && len(userProfile.PreferenceVector) > 0 {
+			interests = []models.InterestVector{{Vector: userProfile.PreferenceVector, Weight: 1}}
+		}
+	}
+	if len(seeds) == 0 {
+		return interests
+	}
+
+	shortTerm, err := o.sessions.ShortTermVector(ctx, seeds)
+	if err != nil {
+		o.logger.Warn("Failed to build session vector", "error", err)
+		return interests
+	}
+	if len(interests) == 0 {
+		if len(shortTerm) == 0 {
+			return nil
+		}
+		return []models.InterestVector{{Vector: shortTerm, Weight: 1}}
+	}
+
+	weight := o.sessions.BlendWeight(len(seeds))
+	blended := make([]models.InterestVector, len(interests))
+	for i, interest := range interests {
+		blended[i] = interest
+		blended[i].Vector = blendVectors(interest.Vector, shortTerm, weight)
+	}
+	return blended
+}
+
 // combineAndRankResults combines results from multiple algorithms using weighted scoring
 func (o *RecommendationOrchestrator) combineAndRankResults(
 	ctx context.Context,
@@ -490,6 +719,14 @@ func (o *RecommendationOrchestrator) generateExplanation(algorithms []string, in
 			explanation = "Popular in your network"
 		case "graph_signal_analysis":
 			explanation = "Trending in your community"
+		case "lexical":
+			explanation = "Shares keywords with items you liked"
+		case "session":
+			explanation = "Similar to what you are browsing now"
+		case "co_visitation":
+			explanation = "Often viewed together with items you viewed"
+		case "explicit_preferences":
+			explanation = "Popular in categories you chose"
 		default:
 			explanation = "Personalized recommendation"
 		}
@@ -565,18 +802,27 @@ func (o *RecommendationOrchestrator) selectStrategy(userTier UserTier, reqCtx *R
 
 // selectAlgorithms determines which algorithms to run based on user tier and strategy
 func (o *RecommendationOrchestrator) selectAlgorithms(userTier UserTier, strategy string) []string {
+	var algorithms []string
 	switch userTier {
 	case NewUser:
-		return []string{"semantic_search"} // Simple content-based for new users
+		algorithms = []string{"semantic_search"} // Simple content-based for new users
 	case ActiveUser:
-		return []string{"semantic_search", "collaborative_filtering", "pagerank"}
+		algorithms = []string{"semantic_search", "collaborative_filtering", "pagerank"}
 	case PowerUser:
-		return []string{"collaborative_filtering", "pagerank", "graph_signal_analysis"}
+		algorithms = []string{"collaborative_filtering", "pagerank", "graph_signal_analysis"}
 	case InactiveUser:
-		return []string{"semantic_search", "collaborative_filtering"} // Re-engagement focus
+		algorithms = []string{"semantic_search", "collaborative_filtering"} // Re-engagement focus
 	default:
-		return []string{"semantic_search"}
+		algorithms = []string{"semantic_search"}
+	}
+
+	if o.keywordIndex != nil {
+		algorithms = append(algorithms, "lexical")
+	}
+	if o.coVisitation != nil {
+		algorithms = append(algorithms, "co_visitation")
 	}
+	return algorithms
 }
 
 // applyFallbackStrategy provides fallback recommendations when primary algorithms fail
@@ -688,13 +934,22 @@ func (o *RecommendationOrchestrator) cacheRecommendations(
 }
 
 func (o *RecommendationOrchestrator) buildCacheKey(reqCtx *RecommendationContext) string {
-	return fmt.Sprintf("orchestration:%s:%s:%d:%v:%v",
+	key := fmt.Sprintf("orchestration:%s:%s:%d:%v:%v:%s:%s",
 		reqCtx.UserID.String(),
 		reqCtx.Context,
 		reqCtx.Count,
 		reqCtx.ContentTypes,
 		reqCtx.Categories,
+		reqCtx.Language,
+		reqCtx.Market,
 	)
+	if reqCtx.SeedItemID != nil {
+		key += ":seed:" + reqCtx.SeedItemID.String()
+	}
+	if reqCtx.sessionVersion != "" {
+		key += ":session:" + reqCtx.sessionVersion
+	}
+	return key
 }
 
 // ProcessFeedback processes user feedback on recommendations for learning
@@ -706,6 +961,13 @@ func (o *RecommendationOrchestrator) ProcessFeedback(ctx context.Context, feedba
 		"recommendation_id", feedback.RecommendationID,
 	)
 
+	// Suppress what negative feedback rejected
+	if o.negativeFeedback != nil {
+		if err := o.negativeFeedback.RecordFeedback(ctx, feedback); err != nil {
+			o.logger.Warn("Failed to record negative feedback", "error", err)
+		}
+	}
+
 	// Invalidate user-specific caches on feedback
 	if err := o.invalidateUserCaches(ctx, feedback.UserID); err != nil {
 		o.logger.Warn("Failed to invalidate user caches", "error", err)
@@ -23,9 +23,9 @@ type MockRecommendationAlgorithmsService struct {
 }
 
 func (m *MockRecommendationAlgorithmsService) SemanticSearchRecommendations(
-	ctx context.Context, userID uuid.UUID, userEmbedding []float32, contentTypes []string, categories []string, limit int,
+	ctx context.Context, userID uuid.UUID, interests []models.InterestVector, contentTypes []string, categories []string, limit int,
 ) ([]models.ScoredItem, error) {
-	args := m.Called(ctx, userID, userEmbedding, contentTypes, categories, limit)
+	args := m.Called(ctx, userID, interests, contentTypes, categories, limit)
 	return args.Get(0).([]models.ScoredItem), args.Error(1)
 }
 
@@ -82,6 +82,14 @@ func (m *MockUserInteractionService) GetUserProfile(ctx context.Context, userID
 	return args.Get(0).(*models.UserProfile), args.Error(1)
 }
 
+func (m *MockUserInteractionService) UpdateExplicitPreferences(ctx context.Context, userID uuid.UUID, req *models.ProfileUpdateRequest) (*models.UserProfile, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserProfile), args.Error(1)
+}
+
 func (m *MockUserInteractionService) GetSimilarUsers(ctx context.Context, userID uuid.UUID, limit int) ([]models.SimilarUser, error) {
 	args := m.Called(ctx, userID, limit)
 	return args.Get(0).([]models.SimilarUser), args.Error(1)
@@ -158,7 +166,7 @@ func TestRecommendationOrchestrator_GenerateRecommendations(t *testing.T) {
 		}
 
 		mockAlgorithmService.On("SemanticSearchRecommendations",
-			mock.Anything, userID, userProfile.PreferenceVector, []string(nil), []string(nil), 20).
+			mock.Anything, userID, []models.InterestVector{{Vector: userProfile.PreferenceVector, Weight: 1}}, []string(nil), []string(nil), 20).
 			Return(semanticItems, nil)
 
 		mockAlgorithmService.On("CollaborativeFilteringRecommendations",
@@ -234,7 +242,7 @@ func TestRecommendationOrchestrator_GenerateRecommendations(t *testing.T) {
 		}
 
 		mockAlgorithmService.On("SemanticSearchRecommendations",
-			mock.Anything, userID, userProfile.PreferenceVector, []string(nil), []string(nil), 10).
+			mock.Anything, userID, []models.InterestVector{{Vector: userProfile.PreferenceVector, Weight: 1}}, []string(nil), []string(nil), 10).
 			Return(semanticItems, nil)
 
 		reqCtx := &RecommendationContext{
@@ -561,6 +569,13 @@ func TestRecommendationOrchestrator_ExplanationGeneration(t *testing.T) {
 		assert.Contains(t, *explanation, "semantic_search")
 	})
 
+	t.Run("lexical explanation", func(t *testing.T) {
+		explanation := orchestrator.generateExplanation([]string{"lexical"}, true)
+
+		require.NotNil(t, explanation)
+		assert.Equal(t, "Shares keywords with items you liked", *explanation)
+	})
+
 	t.Run("explanations disabled", func(t *testing.T) {
 		algorithms := []string{"semantic_search"}
 
@@ -617,6 +632,41 @@ func TestRecommendationOrchestrator_FilterExcludedItems(t *testing.T) {
 }
 
 // Helper function
+func TestRecommendationOrchestrator_SetKeywordIndex(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	cfg := &config.AlgorithmConfig{Lexical: config.AlgorithmWeightConfig{Enabled: true, Weight: 0.25}}
+	orchestrator := NewRecommendationOrchestrator(nil, nil, nil, nil, nil, cfg, logger)
+
+	assert.NotContains(t, orchestrator.selectAlgorithms(ActiveUser, ""), "lexical")
+
+	orchestrator.SetKeywordIndex(NewKeywordIndex(nil, &config.KeywordConfig{}, logger))
+
+	assert.Equal(t, []string{"semantic_search", "lexical"}, orchestrator.selectAlgorithms(NewUser, ""))
+	for tier, weights := range orchestrator.algorithmWeights {
+		sum := 0.0
+		for _, weight := range weights {
+			sum += weight
+		}
+		assert.InDelta(t, 1.0, sum, 1e-9, "tier %d", tier)
+		assert.InDelta(t, 0.25/1.25, weights["lexical"], 1e-9, "tier %d", tier)
+	}
+}
+
+func TestRecommendationOrchestrator_SetPreferenceFilter(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	cfg := &config.AlgorithmConfig{ExplicitPreferences: config.ExplicitPreferencesConfig{Enabled: true, Weight: 0.5}}
+	orchestrator := NewRecommendationOrchestrator(nil, nil, nil, nil, nil, cfg, logger)
+
+	orchestrator.SetPreferenceFilter(NewPreferenceFilter(nil, &cfg.ExplicitPreferences, logger))
+
+	// Only new users get favourite category candidates
+	assert.InDelta(t, 0.5/1.5, orchestrator.algorithmWeights[NewUser]["explicit_preferences"], 1e-9)
+	assert.InDelta(t, 1/1.5, orchestrator.algorithmWeights[NewUser]["semantic_search"], 1e-9)
+	assert.NotContains(t, orchestrator.algorithmWeights[ActiveUser], "explicit_preferences")
+}
+
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
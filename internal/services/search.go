@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// Search retrievers
+const (
+	SearchRetrieverLexical      = "lexical"
+	SearchRetrieverVector       = "vector"
+	SearchRetrieverPersonalized = "personalized"
+)
+
+// contentEmbeddingDimensions is the size of content_items.embedding. Query
+// embeddings of any other size cannot be compared with it.
+const contentEmbeddingDimensions = 768
+
+// ErrEmptySearchQuery is returned for queries without any searchable text
+var ErrEmptySearchQuery = errors.New("search query is empty")
+
+// QueryEmbedder turns search queries into vectors. *ml.TextEmbeddingService
+// implements it.
+type QueryEmbedder interface {
+	GenerateEmbedding(text string, modelName string) ([]float32, error)
+}
+
+// SearchRequest describes one hybrid search
+type SearchRequest struct {
+	Query        string
+	UserID       uuid.UUID
+	ContentTypes []string
+	Categories   []string
+	Limit        int
+	Personalize  bool // Fuse in the user's preference vector ranking
+}
+
+// SearchService answers free-text queries by fusing Postgres full-text retrieval
+// with pgvector nearest neighbours of the query embedding
+type SearchService struct {
+	db       *pgxpool.Pool
+	users    UserInteractionServiceInterface // Optional; preference vectors for personalization
+	taxonomy *TaxonomyService                // Optional; canonicalizes category filters
+	config   *config.SearchConfig
+	logger   *logrus.Logger
+
+	embedder       QueryEmbedder // Optional; without it search is full-text only
+	embeddingModel string
+}
+
+// rankedList holds one retriever's results, best first
+type rankedList struct {
+	retriever string
+	weight    float64
+	items     []uuid.UUID
+}
+
+func NewSearchService(db *pgxpool.Pool, users UserInteractionServiceInterface, cfg *config.SearchConfig, logger *logrus.Logger) *SearchService {
+	return &SearchService{
+		db:     db,
+		users:  users,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// SetEmbedder enables vector retrieval with the given text embedding model
+func (s *SearchService) SetEmbedder(embedder QueryEmbedder, modelName string) {
+	s.embedder = embedder
+	s.embeddingModel = modelName
+}
+
+// SetTaxonomy canonicalizes category filters, so a synonym or category ID
+// matches the category name stored on content
+func (s *SearchService) SetTaxonomy(taxonomy *TaxonomyService) {
+	s.taxonomy = taxonomy
+}
+
+// Search runs full-text and vector retrieval in parallel, fuses their rankings
+// with reciprocal rank fusion and, when asked, fuses in the ranking of the
+// candidates by similarity to the user's preference vector. A retriever that
+// fails is left out; the search fails only when every retriever does.
+func (s *SearchService) Search(ctx context.Context, req *SearchRequest) (*models.SearchResponse, error) {
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, ErrEmptySearchQuery
+	}
+	if maxLen := s.config.MaxQueryLength; maxLen > 0 && len([]rune(query)) > maxLen {
+		query = string([]rune(query)[:maxLen])
+	}
+
+	filter := *req
+	filter.Query = query
+	if len(filter.Categories) > 0 {
+		filter.Categories = s.taxonomy.Current().Expand(filter.Categories, false)
+	}
+
+	candidates := s.config.CandidateLimit
+	if candidates < filter.Limit {
+		candidates = filter.Limit
+	}
+
+	var (
+		wg      sync.WaitGroup
+		lexical []uuid.UUID
+		vector  []uuid.UUID
+		lexErr  error
+		vecErr  error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		lexical, lexErr = s.lexicalSearch(ctx, &filter, candidates)
+	}()
+
+	vectorEnabled := s.embedder != nil
+	if vectorEnabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vector, vecErr = s.vectorSearch(ctx, &filter, candidates)
+		}()
+	}
+	wg.Wait()
+
+	var lists []rankedList
+	var retrievers []string
+	if lexErr != nil {
+		s.logger.WithError(lexErr).Warn("Full-text search failed")
+	} else {
+		lists = append(lists, rankedList{retriever: SearchRetrieverLexical, weight: 1, items: lexical})
+		retrievers = append(retrievers, SearchRetrieverLexical)
+	}
+	if vecErr != nil {
+		s.logger.WithError(vecErr).Warn("Vector search failed, using full-text results only")
+	} else if vectorEnabled && vector != nil {
+		lists = append(lists, rankedList{retriever: SearchRetrieverVector, weight: 1, items: vector})
+		retrievers = append(retrievers, SearchRetrieverVector)
+	}
+	if len(lists) == 0 {
+		return nil, fmt.Errorf("search failed: %w", lexErr)
+	}
+
+	results := fuseRankings(lists, s.config.RRFK)
+
+	personalized := false
+	if req.Personalize && len(results) > 0 {
+		personal, err := s.personalRanking(ctx, req.UserID, results)
+		if err != nil {
+			s.logger.WithError(err).WithField("user_id", req.UserID).Warn("Search personalization failed")
+		} else if len(personal) > 0 {
+			lists = append(lists, rankedList{
+				retriever: SearchRetrieverPersonalized,
+				weight:    s.config.PersonalizationWeight,
+				items:     personal,
+			})
+			retrievers = append(retrievers, SearchRetrieverPersonalized)
+			results = fuseRankings(lists, s.config.RRFK)
+			personalized = true
+		}
+	}
+
+	total := len(results)
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+	if err := s.loadItems(ctx, results); err != nil {
+		return nil, err
+	}
+
+	return &models.SearchResponse{
+		Query:        query,
+		Results:      results,
+		Total:        total,
+		Retrievers:   retrievers,
+		Personalized: personalized,
+		GeneratedAt:  time.Now(),
+	}, nil
+}
+
+// lexicalSearch ranks active items by full-text relevance. The query accepts
+// web search syntax: quoted phrases, OR and -excluded words.
+func (s *SearchService) lexicalSearch(ctx context.Context, req *SearchRequest, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT id
+		FROM content_items, websearch_to_tsquery('simple', $1) AS query
+		WHERE active = true
+			AND search_vector @@ query`
+	args := []interface{}{req.Query}
+	query, args = appendSearchFilters(query, args, req)
+	query += fmt.Sprintf(`
+		ORDER BY ts_rank_cd(search_vector, query, 32) DESC, quality_score DESC
+		LIMIT $%d`, len(args)+1)
+	args = append(args, limit)
+
+	return s.queryIDs(ctx, query, args...)
+}
+
+// vectorSearch ranks active items by cosine distance to the query embedding. It
+// returns nil without error when the query cannot be embedded in time or the
+// model's dimensions do not match the content embeddings.
+func (s *SearchService) vectorSearch(ctx context.Context, req *SearchRequest, limit int) ([]uuid.UUID, error) {
+	embedding, err := s.embedQuery(ctx, req.Query)
+	if err != nil {
+		s.logger.WithError(err).Debug("Query embedding unavailable, skipping vector search")
+		return nil, nil
+	}
+	if len(embedding) != contentEmbeddingDimensions {
+		s.logger.WithFields(logrus.Fields{
+			"model":      s.embeddingModel,
+			"dimensions": len(embedding),
+			"expected":   contentEmbeddingDimensions,
+		}).Debug("Query embedding dimensions do not match content, skipping vector search")
+		return nil, nil
+	}
+
+	query := `
+		SELECT id
+		FROM content_items
+		WHERE active = true
+			AND embedding IS NOT NULL`
+	args := []interface{}{embedding}
+	query, args = appendSearchFilters(query, args, req)
+	query += fmt.Sprintf(`
+		ORDER BY embedding <=> $1
+		LIMIT $%d`, len(args)+1)
+	args = append(args, limit)
+
+	return s.queryIDs(ctx, query, args...)
+}
+
+// embedQuery generates the query embedding, giving up after the configured
+// timeout. The embedder does not take a context, so a late result is dropped.
+func (s *SearchService) embedQuery(ctx context.Context, text string) ([]float32, error) {
+	type result struct {
+		embedding []float32
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		embedding, err := s.embedder.GenerateEmbedding(text, s.embeddingModel)
+		done <- result{embedding, err}
+	}()
+
+	timeout := s.config.EmbeddingTimeout
+	if timeout <= 0 {
+		timeout = 500 * time.Millisecond
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.embedding, r.err
+	case <-timer.C:
+		return nil, fmt.Errorf("query embedding timed out after %s", timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// personalRanking orders the fused candidates by similarity to the user's
+// preference vector. Users without one yet get no ranking.
+func (s *SearchService) personalRanking(ctx context.Context, userID uuid.UUID, candidates []models.SearchResult) ([]uuid.UUID, error) {
+	if s.users == nil || userID == uuid.Nil {
+		return nil, nil
+	}
+	profile, err := s.users.GetUserProfile(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+	if profile == nil || isZeroVector(profile.PreferenceVector) {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.ItemID
+	}
+
+	query := `
+		SELECT id
+		FROM content_items
+		WHERE id = ANY($1)
+			AND embedding IS NOT NULL
+		ORDER BY embedding <=> $2`
+
+	return s.queryIDs(ctx, query, ids, profile.PreferenceVector)
+}
+
+// loadItems attaches the content of each result
+func (s *SearchService) loadItems(ctx context.Context, results []models.SearchResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(results))
+	for i, result := range results {
+		ids[i] = result.ItemID
+	}
+
+	query := `
+		SELECT id, type, title, description, image_urls, categories, language, quality_score
+		FROM content_items
+		WHERE id = ANY($1)`
+
+	rows, err := s.db.Query(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load search results: %w", err)
+	}
+	defer rows.Close()
+
+	items := make(map[uuid.UUID]*models.ContentItem, len(results))
+	for rows.Next() {
+		var item models.ContentItem
+		var language *string
+		if err := rows.Scan(&item.ID, &item.Type, &item.Title, &item.Description, &item.ImageURLs,
+			&item.Categories, &language, &item.QualityScore); err != nil {
+			return fmt.Errorf("failed to scan search result: %w", err)
+		}
+		if language != nil {
+			item.Language = *language
+		}
+		item.Active = true
+		items[item.ID] = &item
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load search results: %w", err)
+	}
+
+	for i := range results {
+		results[i].Item = items[results[i].ItemID]
+	}
+	return nil
+}
+
+func (s *SearchService) queryIDs(ctx context.Context, query string, args ...interface{}) ([]uuid.UUID, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// appendSearchFilters adds the content type and category filters of a request,
+// with the same semantics as recommendation filtering: any of the types, and
+// at least one of the categories
+func appendSearchFilters(query string, args []interface{}, req *SearchRequest) (string, []interface{}) {
+	if len(req.ContentTypes) > 0 {
+		args = append(args, req.ContentTypes)
+		query += fmt.Sprintf(" AND type = ANY($%d)", len(args))
+	}
+	if len(req.Categories) > 0 {
+		args = append(args, req.Categories)
+		query += fmt.Sprintf(" AND categories && $%d", len(args))
+	}
+	return query, args
+}
+
+// fuseRankings combines ranked lists with weighted reciprocal rank fusion: an
+// item scores the sum of weight / (k + rank) over the lists that contain it.
+// Ties go to the item with the best single rank, then to the earlier list.
+func fuseRankings(lists []rankedList, k int) []models.SearchResult {
+	if k <= 0 {
+		k = 60
+	}
+
+	index := make(map[uuid.UUID]int)
+	var results []models.SearchResult
+	bestRank := []int{}
+	for _, list := range lists {
+		for i, id := range list.items {
+			rank := i + 1
+			j, ok := index[id]
+			if !ok {
+				j = len(results)
+				index[id] = j
+				results = append(results, models.SearchResult{ItemID: id, Ranks: make(map[string]int)})
+				bestRank = append(bestRank, rank)
+			}
+			if _, seen := results[j].Ranks[list.retriever]; seen {
+				continue
+			}
+			results[j].Ranks[list.retriever] = rank
+			results[j].Score += list.weight / float64(k+rank)
+			if rank < bestRank[j] {
+				bestRank[j] = rank
+			}
+		}
+	}
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ra, rb := results[order[a]], results[order[b]]
+		if ra.Score != rb.Score {
+			return ra.Score > rb.Score
+		}
+		return bestRank[order[a]] < bestRank[order[b]]
+	})
+
+	fused := make([]models.SearchResult, len(results))
+	for position, i := range order {
+		fused[position] = results[i]
+		fused[position].Position = position + 1
+	}
+	return fused
+}
+
+func isZeroVector(vector []float32) bool {
+	for _, v := range vector {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+)
+
+func TestFuseRankings(t *testing.T) {
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	t.Run("items found by both retrievers win", func(t *testing.T) {
+		results := fuseRankings([]rankedList{
+			{retriever: SearchRetrieverLexical, weight: 1, items: []uuid.UUID{a, b, c}},
+			{retriever: SearchRetrieverVector, weight: 1, items: []uuid.UUID{d, c, b}},
+		}, 60)
+
+		require.Len(t, results, 4)
+		assert.Equal(t, b, results[0].ItemID) // 1/62 + 1/63
+		assert.Equal(t, c, results[1].ItemID) // Same score, listed later
+		assert.Equal(t, a, results[2].ItemID)
+		assert.Equal(t, d, results[3].ItemID)
+		assert.InDelta(t, 1.0/62+1.0/63, results[0].Score, 1e-12)
+		assert.Equal(t, map[string]int{SearchRetrieverLexical: 2, SearchRetrieverVector: 3}, results[0].Ranks)
+		for i, result := range results {
+			assert.Equal(t, i+1, result.Position)
+		}
+	})
+
+	t.Run("weights", func(t *testing.T) {
+		results := fuseRankings([]rankedList{
+			{retriever: SearchRetrieverLexical, weight: 1, items: []uuid.UUID{a, b}},
+			{retriever: SearchRetrieverPersonalized, weight: 3, items: []uuid.UUID{b, a}},
+		}, 1)
+		assert.Equal(t, b, results[0].ItemID)
+	})
+
+	t.Run("duplicates within a list count once", func(t *testing.T) {
+		results := fuseRankings([]rankedList{
+			{retriever: SearchRetrieverLexical, weight: 1, items: []uuid.UUID{a, a}},
+		}, 0)
+		require.Len(t, results, 1)
+		assert.InDelta(t, 1.0/61, results[0].Score, 1e-12)
+	})
+
+	assert.Empty(t, fuseRankings(nil, 60))
+}
+
+func TestAppendSearchFilters(t *testing.T) {
+	query, args := appendSearchFilters("WHERE active = true", []interface{}{"q"}, &SearchRequest{
+		ContentTypes: []string{"product"},
+		Categories:   []string{"audio"},
+	})
+	assert.Equal(t, "WHERE active = true AND type = ANY($2) AND categories && $3", query)
+	assert.Equal(t, []interface{}{"q", []string{"product"}, []string{"audio"}}, args)
+
+	query, args = appendSearchFilters("WHERE active = true", nil, &SearchRequest{})
+	assert.Equal(t, "WHERE active = true", query)
+	assert.Empty(t, args)
+}
+
+func TestSearchService_EmptyQuery(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	search := NewSearchService(nil, nil, &config.SearchConfig{}, logger)
+
+	_, err := search.Search(context.Background(), &SearchRequest{Query: "   "})
+	assert.ErrorIs(t, err, ErrEmptySearchQuery)
+}
@@ -1,9 +1,18 @@
 package services
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/temcen/pirex/internal/config"
 	"github.com/temcen/pirex/internal/database"
+	"github.com/temcen/pirex/internal/graph"
+	"github.com/temcen/pirex/internal/media"
 	"github.com/temcen/pirex/internal/messaging"
+	"github.com/temcen/pirex/internal/ml"
+	"github.com/temcen/pirex/internal/validation"
 
 	"github.com/sirupsen/logrus"
 )
@@ -13,14 +22,32 @@ type Services struct {
 	Health                     *HealthService
 	RateLimit                  *RateLimitService
 	MessageBus                 *messaging.MessageBus
+	Graph                      *graph.Repository
+	GraphOutbox                *GraphOutboxRelay
+	GraphReconciliation        *GraphReconciliationService
+	UserData                   *UserDataService
 	JobManager                 *JobManager
 	DataPreprocessor           *DataPreprocessor
+	Taxonomy                   *TaxonomyService
+	KeywordIndex               *KeywordIndex
+	ImageStore                 *media.ImageStore // Nil unless ingestion.images.store is enabled
+	ContentDeduplicator        *ContentDeduplicator
+	BulkImporter               *BulkImporter
+	CatalogSync                *CatalogSyncService
+	Webhooks                   *WebhookDispatcher
 	PipelineOrchestrator       *PipelineOrchestrator
 	UserInteraction            *UserInteractionService
 	RecommendationAlgorithms   *RecommendationAlgorithmsService
 	DiversityFilter            *DiversityFilter
 	ExplanationService         *ExplanationService
 	RecommendationOrchestrator *RecommendationOrchestrator
+	CoVisitation               *CoVisitationIndex       // Nil unless recommendation.co_visitation is enabled
+	NegativeFeedback           *NegativeFeedback        // Nil unless recommendation.negative_feedback is enabled
+	Onboarding                 *OnboardingService       // Nil unless recommendation.onboarding is enabled
+	TextEmbedding              *ml.TextEmbeddingService // Nil unless recommendation.search.vector_search is enabled
+	ContentEmbedding           *ml.MLService            // Nil unless models.fusion is enabled
+	FusionWeights              *FusionWeightsWatcher    // Nil unless models.fusion is enabled
+	Search                     *SearchService
 }
 
 func New(cfg *config.Config, logger *logrus.Logger, db *database.Database) (*Services, error) {
@@ -34,37 +61,202 @@ func New(cfg *config.Config, logger *logrus.Logger, db *database.Database) (*Ser
 		return nil, err
 	}
 
-	jobManager := NewJobManager(db, logger)
+	graphRepo := graph.NewRepository(db.Neo4j, logger)
+	jobManager := NewJobManager(db, &cfg.Ingestion, logger)
+	taxonomy := NewTaxonomyService(db, &cfg.Ingestion.Taxonomy, logger)
+	taxonomy.SetGraph(graphRepo)
 	dataPreprocessor := NewDataPreprocessor(logger)
-	pipelineOrchestrator := NewPipelineOrchestrator(db, messageBus, dataPreprocessor, jobManager, logger)
-	userInteractionService := NewUserInteractionService(db, cfg, logger)
+	dataPreprocessor.SetTaxonomy(taxonomy)
+	keywordIndex := NewKeywordIndex(db, &cfg.Ingestion.Keywords, logger)
+	dataPreprocessor.SetKeywordIndex(keywordIndex)
+	if cfg.Ingestion.ContentTypes.SchemaDir != "" {
+		contentTypes := validation.NewSchemaValidator()
+		if err := contentTypes.LoadDefaultContentTypes(); err != nil {
+			return nil, fmt.Errorf("failed to load content types: %w", err)
+		}
+		if err := contentTypes.LoadContentTypeSchemas(cfg.Ingestion.ContentTypes.SchemaDir); err != nil {
+			return nil, fmt.Errorf("failed to load content types: %w", err)
+		}
+		dataPreprocessor.SetContentTypes(contentTypes)
+		logger.WithField("content_types", contentTypes.ContentTypes()).Info("Loaded content type schemas")
+	}
+	imageFetcher := media.NewImageFetcher(cfg.Ingestion.Images.Fetch)
+	dataPreprocessor.SetImageFetcher(imageFetcher)
+	dataPreprocessor.SetImageHashing(cfg.Ingestion.Dedup.Enabled && cfg.Ingestion.Dedup.ImageHashing)
+	var imageStore *media.ImageStore
+	if cfg.Ingestion.Images.Store.Enabled {
+		blobs, err := media.NewBlobStore(cfg.Ingestion.Images.Store)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create image store: %w", err)
+		}
+		imageStore = media.NewImageStore(imageFetcher, blobs, cfg.Ingestion.Images.Store.ThumbnailSize, logger)
+		dataPreprocessor.SetImageStore(imageStore)
+	}
+	contentDeduplicator := NewContentDeduplicator(db, &cfg.Ingestion.Dedup, logger)
+	bulkImporter := NewBulkImporter(messageBus, jobManager, &cfg.Ingestion.BulkImport, logger)
+	bulkImporter.SetPreprocessor(dataPreprocessor)
+	webhooks := NewWebhookDispatcher(jobManager, &cfg.Ingestion.Webhooks, logger)
+	catalogSync := NewCatalogSyncService(db, messageBus, jobManager, &cfg.Ingestion.CatalogSync, logger)
+	catalogSync.SetPreprocessor(dataPreprocessor)
+	pipelineOrchestrator := NewPipelineOrchestrator(db, messageBus, dataPreprocessor, jobManager, contentDeduplicator, &cfg.Algorithms.Caching, logger)
+	pipelineOrchestrator.SetKeywordIndex(keywordIndex)
+	pipelineOrchestrator.SetGraph(graphRepo)
+	var contentEmbedding *ml.MLService
+	var fusionWeights *FusionWeightsWatcher
+	if cfg.Models.Fusion.Enabled {
+		textModel := embeddingModelName(cfg.Models.TextEmbedding.ModelPath)
+		imageModel := embeddingModelName(cfg.Models.ImageEmbedding.ModelPath)
+		contentEmbedding, err = ml.NewMLService(db.Redis.Warm, logger, contentEmbeddingConfig(cfg, textModel, imageModel))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create content embedding service: %w", err)
+		}
+		if imageStore != nil {
+			contentEmbedding.SetImageStore(imageStore)
+		}
+		pipelineOrchestrator.SetEmbedder(contentEmbedding, textModel, imageModel)
+		fusionWeights = NewFusionWeightsWatcher(contentEmbedding, &cfg.Models.Fusion, logger)
+	}
+	graphOutbox := NewGraphOutboxRelay(db, graphRepo, &cfg.Neo4j.Outbox, logger)
+	userInteractionService := NewUserInteractionService(db, graphRepo, cfg, logger)
+	userInteractionService.SetGraphOutbox(graphOutbox)
+	userInteractionService.SetTaxonomy(taxonomy)
+	graphReconciliation := NewGraphReconciliationService(db, graphRepo, jobManager, &cfg.Neo4j.Reconciliation, logger)
+	userData := NewUserDataService(db, graphRepo, jobManager, messageBus, &cfg.Security.Privacy, logger)
 
 	// Initialize recommendation services
 	recommendationAlgorithms := NewRecommendationAlgorithmsService(
-		db.PG, db.Neo4j, db.Redis.Warm, &cfg.Algorithms, logger,
+		db.PG, graphRepo, db.Redis.Warm, &cfg.Algorithms, logger,
 	)
 
 	// Initialize diversity filter and explanation service
 	diversityFilter := NewDiversityFilter(db.PG, &cfg.Algorithms.Diversity, logger)
 	explanationService := NewExplanationService(db.PG, logger)
+	diversityFilter.SetTaxonomy(taxonomy)
+	explanationService.SetTaxonomy(taxonomy)
+	explanationService.SetKeywordIndex(keywordIndex)
 
 	recommendationOrchestrator := NewRecommendationOrchestrator(
 		recommendationAlgorithms, userInteractionService, diversityFilter, explanationService,
 		db.Redis.Warm, &cfg.Algorithms, logger,
 	)
+	if cfg.Algorithms.Locale.Enabled {
+		recommendationOrchestrator.SetLocaleFilter(NewLocaleFilter(db.PG, &cfg.Algorithms.Locale, logger))
+	}
+	if cfg.Algorithms.Lexical.Enabled {
+		recommendationOrchestrator.SetKeywordIndex(keywordIndex)
+	}
+	if cfg.Algorithms.Session.Enabled {
+		sessionStore := NewSessionStore(db.Redis.Hot, db.PG, &cfg.Algorithms.Session, logger)
+		userInteractionService.SetSessionStore(sessionStore)
+		recommendationOrchestrator.SetSessionStore(sessionStore)
+	}
+	if cfg.Algorithms.ExplicitPreferences.Enabled {
+		recommendationOrchestrator.SetPreferenceFilter(NewPreferenceFilter(db.PG, &cfg.Algorithms.ExplicitPreferences, logger))
+	}
+	var negativeFeedback *NegativeFeedback
+	if cfg.Algorithms.NegativeFeedback.Enabled {
+		negativeFeedback = NewNegativeFeedback(db.PG, db.Redis.Warm, &cfg.Algorithms.NegativeFeedback, logger)
+		recommendationOrchestrator.SetNegativeFeedback(negativeFeedback)
+	}
+	var coVisitation *CoVisitationIndex
+	if cfg.Algorithms.CoVisitation.Enabled {
+		coVisitation = NewCoVisitationIndex(db.Redis.Hot, db.Redis.Cold, db.PG, &cfg.Algorithms.CoVisitation, logger)
+		userInteractionService.SetCoVisitation(coVisitation)
+		recommendationOrchestrator.SetCoVisitation(coVisitation)
+	}
+
+	var onboarding *OnboardingService
+	if cfg.Algorithms.Onboarding.Enabled {
+		onboarding = NewOnboardingService(db.PG, db.Redis.Warm, userInteractionService, taxonomy, &cfg.Algorithms.Onboarding, logger)
+	}
+
+	search := NewSearchService(db.PG, userInteractionService, &cfg.Algorithms.Search, logger)
+	search.SetTaxonomy(taxonomy)
+	var textEmbedding *ml.TextEmbeddingService
+	if cfg.Algorithms.Search.VectorSearch {
+		registry := ml.NewModelRegistry(logger)
+		if err := registry.RegisterModel(&ml.ModelInfo{
+			Name:       cfg.Algorithms.Search.EmbeddingModel,
+			Path:       cfg.Models.TextEmbedding.ModelPath,
+			ModelType:  "text",
+			Dimensions: cfg.Models.TextEmbedding.Dimensions,
+			Version:    "1.0.0",
+			LoadedAt:   time.Now(),
+		}); err != nil {
+			return nil, fmt.Errorf("failed to register search embedding model: %w", err)
+		}
+		textEmbedding = ml.NewTextEmbeddingService(registry, db.Redis.Warm, logger, ml.TextEmbeddingConfig{
+			CacheTTL:      cfg.Algorithms.Caching.EmbeddingsTTL,
+			CacheEncoding: cfg.Algorithms.Caching.EmbeddingEncoding,
+		})
+		search.SetEmbedder(textEmbedding, cfg.Algorithms.Search.EmbeddingModel)
+	}
 
 	return &Services{
 		Auth:                       authService,
 		Health:                     healthService,
 		RateLimit:                  rateLimitService,
 		MessageBus:                 messageBus,
+		Graph:                      graphRepo,
+		GraphOutbox:                graphOutbox,
+		GraphReconciliation:        graphReconciliation,
+		UserData:                   userData,
 		JobManager:                 jobManager,
 		DataPreprocessor:           dataPreprocessor,
+		Taxonomy:                   taxonomy,
+		KeywordIndex:               keywordIndex,
+		ImageStore:                 imageStore,
+		ContentDeduplicator:        contentDeduplicator,
+		BulkImporter:               bulkImporter,
+		CatalogSync:                catalogSync,
+		Webhooks:                   webhooks,
 		PipelineOrchestrator:       pipelineOrchestrator,
 		UserInteraction:            userInteractionService,
 		RecommendationAlgorithms:   recommendationAlgorithms,
 		DiversityFilter:            diversityFilter,
 		ExplanationService:         explanationService,
 		RecommendationOrchestrator: recommendationOrchestrator,
+		CoVisitation:               coVisitation,
+		NegativeFeedback:           negativeFeedback,
+		Onboarding:                 onboarding,
+		TextEmbedding:              textEmbedding,
+		ContentEmbedding:           contentEmbedding,
+		FusionWeights:              fusionWeights,
+		Search:                     search,
 	}, nil
 }
+
+// contentEmbeddingConfig configures the text and image models and the fusion
+// that embed ingested items
+func contentEmbeddingConfig(cfg *config.Config, textModel, imageModel string) *ml.MLConfig {
+	mlConfig := ml.DefaultMLConfig()
+
+	text := mlConfig.Models["text-embedding"]
+	text.Name = textModel
+	text.Path = cfg.Models.TextEmbedding.ModelPath
+	text.Dimensions = cfg.Models.TextEmbedding.Dimensions
+	mlConfig.Models["text-embedding"] = text
+
+	image := mlConfig.Models["image-embedding"]
+	image.Name = imageModel
+	image.Path = cfg.Models.ImageEmbedding.ModelPath
+	image.Dimensions = cfg.Models.ImageEmbedding.Dimensions
+	mlConfig.Models["image-embedding"] = image
+
+	mlConfig.TextEmbedding.CacheTTL = cfg.Algorithms.Caching.EmbeddingsTTL
+	mlConfig.TextEmbedding.CacheEncoding = cfg.Algorithms.Caching.EmbeddingEncoding
+	mlConfig.ImageEmbedding.CacheTTL = cfg.Algorithms.Caching.EmbeddingsTTL
+	mlConfig.ImageEmbedding.CacheEncoding = cfg.Algorithms.Caching.EmbeddingEncoding
+
+	mlConfig.Fusion.TextDimensions = cfg.Models.TextEmbedding.Dimensions
+	mlConfig.Fusion.ImageDimensions = cfg.Models.ImageEmbedding.Dimensions
+	mlConfig.Fusion.Method = cfg.Models.Fusion.Method
+	mlConfig.Fusion.ContentTypeMethods = cfg.Models.Fusion.ContentTypeMethods
+	mlConfig.Fusion.WeightsPath = cfg.Models.Fusion.WeightsPath
+	return mlConfig
+}
+
+// embeddingModelName names a model after its file, e.g. all-MiniLM-L6-v2
+func embeddingModelName(modelPath string) string {
+	return strings.TrimSuffix(filepath.Base(modelPath), filepath.Ext(modelPath))
+}
@@ -0,0 +1,547 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+const (
+	// sessionFullStrengthSeeds is the number of session items at which the
+	// session vector is blended in with its full short-term weight
+	sessionFullStrengthSeeds = 3
+	// sessionCategoryBoost is the relative score boost for candidates in the
+	// category the session dwelt on most
+	sessionCategoryBoost = 0.2
+)
+
+// SessionEvent is one item interaction of a session
+type SessionEvent struct {
+	ItemID uuid.UUID `json:"item_id"`
+	Type   string    `json:"type"`
+	Weight float64   `json:"weight"` // Interaction weight; negative for dislikes
+	At     time.Time `json:"at"`
+}
+
+// SessionState is the short-term intent of a user's current session
+type SessionState struct {
+	SessionID  uuid.UUID
+	Items      []SessionEvent     // Newest first
+	Categories map[string]float64 // Category -> summed interaction weight
+	Queries    []string           // Newest first
+	Version    int64              // Events recorded; changes whenever the state does
+}
+
+// SessionSeed is a session item weighted by interaction and recency
+type SessionSeed struct {
+	ItemID uuid.UUID
+	Weight float64
+}
+
+// sessionNeighbor is an item similar to one of the session seeds
+type sessionNeighbor struct {
+	SeedID     uuid.UUID
+	ItemID     uuid.UUID
+	Categories []string
+	Similarity float64
+}
+
+// SessionStore keeps the state of each user's current session in Redis, so
+// recommendations follow what the user is doing right now and not only the
+// 90-day profile. State expires after the configured idle timeout; a new
+// session ID replaces the user's previous session.
+type SessionStore struct {
+	redis  *redis.Client
+	db     *pgxpool.Pool
+	config *config.SessionConfig
+	logger *logrus.Logger
+}
+
+func NewSessionStore(redis *redis.Client, db *pgxpool.Pool, cfg *config.SessionConfig, logger *logrus.Logger) *SessionStore {
+	return &SessionStore{
+		redis:  redis,
+		db:     db,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Enabled reports whether session state is kept. It is safe to call on a nil store.
+func (s *SessionStore) Enabled() bool {
+	return s != nil && s.redis != nil
+}
+
+// Record adds an interaction to the user's current session. Item interactions
+// add the item and its categories, searches add the query.
+func (s *SessionStore) Record(ctx context.Context, interaction *models.UserInteraction, weight float64) error {
+	if !s.Enabled() {
+		return nil
+	}
+
+	var categories []string
+	if interaction.ItemID != nil && s.db != nil {
+		err := s.db.QueryRow(ctx,
+			`SELECT COALESCE(categories, '{}') FROM content_items WHERE id = $1`,
+			*interaction.ItemID).Scan(&categories)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to read item categories: %w", err)
+		}
+	}
+
+	ttl := s.idleTimeout()
+	sessionID := interaction.SessionID
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, sessionCurrentKey(interaction.UserID), sessionID.String(), ttl)
+
+	if interaction.ItemID != nil {
+		event, err := json.Marshal(SessionEvent{
+			ItemID: *interaction.ItemID,
+			Type:   interaction.InteractionType,
+			Weight: weight,
+			At:     interaction.Timestamp,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode session event: %w", err)
+		}
+		itemsKey := sessionKey(sessionID, "items")
+		pipe.LPush(ctx, itemsKey, event)
+		pipe.LTrim(ctx, itemsKey, 0, int64(s.maxItems()-1))
+		pipe.Expire(ctx, itemsKey, ttl)
+
+		if len(categories) > 0 {
+			categoriesKey := sessionKey(sessionID, "categories")
+			for _, category := range categories {
+				pipe.ZIncrBy(ctx, categoriesKey, weight, category)
+			}
+			pipe.Expire(ctx, categoriesKey, ttl)
+		}
+	}
+
+	if interaction.Query != nil {
+		if query := strings.TrimSpace(*interaction.Query); query != "" {
+			queriesKey := sessionKey(sessionID, "queries")
+			pipe.LPush(ctx, queriesKey, query)
+			pipe.LTrim(ctx, queriesKey, 0, int64(s.maxQueries()-1))
+			pipe.Expire(ctx, queriesKey, ttl)
+		}
+	}
+
+	versionKey := sessionKey(sessionID, "version")
+	pipe.Incr(ctx, versionKey)
+	pipe.Expire(ctx, versionKey, ttl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record session event: %w", err)
+	}
+	return nil
+}
+
+// Current returns the state of the user's current session, or nil when the
+// user has none or it expired
+func (s *SessionStore) Current(ctx context.Context, userID uuid.UUID) (*SessionState, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+
+	current, err := s.redis.Get(ctx, sessionCurrentKey(userID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read current session: %w", err)
+	}
+	sessionID, err := uuid.Parse(current)
+	if err != nil {
+		return nil, fmt.Errorf("invalid current session %q: %w", current, err)
+	}
+
+	pipe := s.redis.Pipeline()
+	items := pipe.LRange(ctx, sessionKey(sessionID, "items"), 0, -1)
+	categories := pipe.ZRangeWithScores(ctx, sessionKey(sessionID, "categories"), 0, -1)
+	queries := pipe.LRange(ctx, sessionKey(sessionID, "queries"), 0, -1)
+	version := pipe.Get(ctx, sessionKey(sessionID, "version"))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to read session state: %w", err)
+	}
+
+	state := &SessionState{
+		SessionID:  sessionID,
+		Categories: make(map[string]float64),
+		Queries:    queries.Val(),
+	}
+	if state.Version, _ = version.Int64(); state.Version == 0 {
+		return nil, nil
+	}
+	for _, raw := range items.Val() {
+		var event SessionEvent
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			continue // Skip events written by an incompatible version
+		}
+		state.Items = append(state.Items, event)
+	}
+	for _, category := range categories.Val() {
+		if name, ok := category.Member.(string); ok {
+			state.Categories[name] = category.Score
+		}
+	}
+	return state, nil
+}
+
+// Seeds returns the session items candidates are generated from, using the
+// configured seed count and recency decay. It is safe to call with a nil state.
+func (s *SessionStore) Seeds(state *SessionState) []SessionSeed {
+	if !s.Enabled() || state == nil {
+		return nil
+	}
+	return state.Seeds(s.seedItems(), s.recencyDecay())
+}
+
+// Seeds returns up to limit distinct items the session engaged with
+// positively, newest first. Each step back in the session keeps decay of an
+// event's weight, the sequence heuristic that lets the latest items steer;
+// repeated interactions add up, and an item whose latest interaction was
+// negative is left out.
+func (st *SessionState) Seeds(limit int, decay float64) []SessionSeed {
+	var seeds []SessionSeed
+	index := make(map[uuid.UUID]int)
+	for step, event := range st.Items {
+		if i, seen := index[event.ItemID]; seen {
+			if i >= 0 && event.Weight > 0 {
+				seeds[i].Weight += event.Weight * math.Pow(decay, float64(step))
+			}
+			continue
+		}
+		if event.Weight <= 0 {
+			index[event.ItemID] = -1
+			continue
+		}
+		if len(seeds) == limit {
+			continue
+		}
+		index[event.ItemID] = len(seeds)
+		seeds = append(seeds, SessionSeed{
+			ItemID: event.ItemID,
+			Weight: event.Weight * math.Pow(decay, float64(step)),
+		})
+	}
+	return seeds
+}
+
+// ItemIDs returns every item the session interacted with
+func (st *SessionState) ItemIDs() []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(st.Items))
+	var ids []uuid.UUID
+	for _, event := range st.Items {
+		if !seen[event.ItemID] {
+			seen[event.ItemID] = true
+			ids = append(ids, event.ItemID)
+		}
+	}
+	return ids
+}
+
+// Recommendations generates item-to-item candidates from the session seeds:
+// the nearest neighbours of each seed by embedding, scored by their similarity
+// to the seeds weighted by recency, and boosted in the categories the session
+// dwelt on. Items already seen in the session are left out.
+func (s *SessionStore) Recommendations(
+	ctx context.Context,
+	state *SessionState,
+	seeds []SessionSeed,
+	contentTypes []string,
+	categories []string,
+	exclude []uuid.UUID,
+	limit int,
+) ([]models.ScoredItem, error) {
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("no active session")
+	}
+
+	seedIDs := make([]uuid.UUID, len(seeds))
+	for i, seed := range seeds {
+		seedIDs[i] = seed.ItemID
+	}
+	excluded := append(state.ItemIDs(), exclude...)
+
+	query := `
+		SELECT seed.id, n.id, n.categories, n.similarity
+		FROM content_items seed
+		CROSS JOIN LATERAL (
+			SELECT c.id, COALESCE(c.categories, '{}') AS categories,
+				1 - (c.embedding <=> seed.embedding) AS similarity
+			FROM content_items c
+			WHERE c.active = true
+				AND c.embedding IS NOT NULL
+				AND c.id <> ALL($2)`
+
+	args := []interface{}{seedIDs, excluded, s.neighborsPerSeed()}
+	argIndex := 4
+
+	if len(contentTypes) > 0 {
+		query += fmt.Sprintf(" AND c.type = ANY($%d)", argIndex)
+		args = append(args, contentTypes)
+		argIndex++
+	}
+	if len(categories) > 0 {
+		query += fmt.Sprintf(" AND c.categories && $%d", argIndex)
+		args = append(args, categories)
+	}
+
+	query += `
+			ORDER BY c.embedding <=> seed.embedding
+			LIMIT $3
+		) n
+		WHERE seed.id = ANY($1) AND seed.embedding IS NOT NULL`
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("session candidate query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var neighbors []sessionNeighbor
+	for rows.Next() {
+		var neighbor sessionNeighbor
+		if err := rows.Scan(&neighbor.SeedID, &neighbor.ItemID, &neighbor.Categories, &neighbor.Similarity); err != nil {
+			s.logger.Error("Failed to scan session candidate", "error", err)
+			continue
+		}
+		neighbors = append(neighbors, neighbor)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("session candidate query failed: %w", err)
+	}
+
+	return scoreSessionNeighbors(seeds, neighbors, state.Categories, limit), nil
+}
+
+// ShortTermVector returns the seed embeddings averaged by seed weight, or nil
+// when none of the seeds has an embedding
+func (s *SessionStore) ShortTermVector(ctx context.Context, seeds []SessionSeed) ([]float32, error) {
+	if len(seeds) == 0 {
+		return nil, nil
+	}
+
+	seedIDs := make([]uuid.UUID, len(seeds))
+	for i, seed := range seeds {
+		seedIDs[i] = seed.ItemID
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT id, embedding FROM content_items
+		WHERE id = ANY($1) AND embedding IS NOT NULL`, seedIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	embeddings := make(map[uuid.UUID][]float32, len(seeds))
+	for rows.Next() {
+		var itemID uuid.UUID
+		var embedding []float32
+		if err := rows.Scan(&itemID, &embedding); err != nil {
+			continue // Skip invalid rows
+		}
+		embeddings[itemID] = embedding
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session embeddings: %w", err)
+	}
+
+	return weightedMeanVector(seeds, embeddings), nil
+}
+
+// BlendWeight returns the share of the session vector in the blended
+// preference vector. It grows with the number of seeds so a single click does
+// not outweigh months of history.
+func (s *SessionStore) BlendWeight(seeds int) float64 {
+	weight := 0.5
+	if s.config != nil && s.config.ShortTermWeight > 0 {
+		weight = s.config.ShortTermWeight
+	}
+	return weight * math.Min(1, float64(seeds)/sessionFullStrengthSeeds)
+}
+
+// scoreSessionNeighbors sums each candidate's similarity to the seeds weighted
+// by seed weight, and boosts candidates by the session's affinity to their
+// categories
+func scoreSessionNeighbors(seeds []SessionSeed, neighbors []sessionNeighbor, categories map[string]float64, limit int) []models.ScoredItem {
+	seedWeights := make(map[uuid.UUID]float64, len(seeds))
+	var totalWeight float64
+	for _, seed := range seeds {
+		seedWeights[seed.ItemID] = seed.Weight
+		totalWeight += seed.Weight
+	}
+	if totalWeight == 0 {
+		return nil
+	}
+
+	var topCategory float64
+	for _, weight := range categories {
+		topCategory = math.Max(topCategory, weight)
+	}
+
+	scores := make(map[uuid.UUID]float64)
+	support := make(map[uuid.UUID]int)
+	affinity := make(map[uuid.UUID]float64)
+	for _, neighbor := range neighbors {
+		if neighbor.Similarity <= 0 {
+			continue
+		}
+		scores[neighbor.ItemID] += seedWeights[neighbor.SeedID] * neighbor.Similarity / totalWeight
+		support[neighbor.ItemID]++
+		if topCategory > 0 {
+			for _, category := range neighbor.Categories {
+				affinity[neighbor.ItemID] = math.Max(affinity[neighbor.ItemID], categories[category]/topCategory)
+			}
+		}
+	}
+
+	items := make([]models.ScoredItem, 0, len(scores))
+	for itemID, score := range scores {
+		score *= 1 + sessionCategoryBoost*affinity[itemID]
+		items = append(items, models.ScoredItem{
+			ItemID:     itemID,
+			Score:      score,
+			Algorithm:  "session",
+			Confidence: math.Min(0.95, 0.4+0.4*score+0.05*float64(support[itemID]-1)),
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		return items[i].ItemID.String() < items[j].ItemID.String()
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+// weightedMeanVector averages the embeddings by seed weight and L2 normalizes
+// the result. Embeddings of another dimension than the first are skipped.
+func weightedMeanVector(seeds []SessionSeed, embeddings map[uuid.UUID][]float32) []float32 {
+	var mean []float32
+	for _, seed := range seeds {
+		embedding, ok := embeddings[seed.ItemID]
+		if !ok || len(embedding) == 0 {
+			continue
+		}
+		if mean == nil {
+			mean = make([]float32, len(embedding))
+		}
+		if len(embedding) != len(mean) {
+			continue
+		}
+		for i, value := range embedding {
+			mean[i] += float32(seed.Weight) * value
+		}
+	}
+	return normalizedVector(mean)
+}
+
+// blendVectors mixes the normalized long-term and short-term vectors, giving
+// the short-term vector shortTermWeight. Either may be missing; vectors of
+// different dimensions are not mixed.
+func blendVectors(longTerm, shortTerm []float32, shortTermWeight float64) []float32 {
+	switch {
+	case len(shortTerm) == 0 || shortTermWeight <= 0:
+		return longTerm
+	case len(longTerm) == 0:
+		return shortTerm
+	case len(longTerm) != len(shortTerm):
+		return longTerm
+	}
+
+	longTerm = normalizedVector(longTerm)
+	shortTerm = normalizedVector(shortTerm)
+	blended := make([]float32, len(longTerm))
+	for i := range blended {
+		blended[i] = float32((1-shortTermWeight)*float64(longTerm[i]) + shortTermWeight*float64(shortTerm[i]))
+	}
+	return normalizedVector(blended)
+}
+
+// normalizedVector returns an L2 normalized copy of the vector
+func normalizedVector(vector []float32) []float32 {
+	var norm float64
+	for _, value := range vector {
+		norm += float64(value) * float64(value)
+	}
+	if norm == 0 {
+		return vector
+	}
+	norm = math.Sqrt(norm)
+
+	normalized := make([]float32, len(vector))
+	for i, value := range vector {
+		normalized[i] = float32(float64(value) / norm)
+	}
+	return normalized
+}
+
+func sessionCurrentKey(userID uuid.UUID) string {
+	return fmt.Sprintf("session:current:%s", userID)
+}
+
+func sessionKey(sessionID uuid.UUID, part string) string {
+	return fmt.Sprintf("session:%s:%s", sessionID, part)
+}
+
+func (s *SessionStore) idleTimeout() time.Duration {
+	if s.config != nil && s.config.IdleTimeout > 0 {
+		return s.config.IdleTimeout
+	}
+	return 30 * time.Minute
+}
+
+func (s *SessionStore) maxItems() int {
+	if s.config != nil && s.config.MaxItems > 0 {
+		return s.config.MaxItems
+	}
+	return 50
+}
+
+func (s *SessionStore) maxQueries() int {
+	if s.config != nil && s.config.MaxQueries > 0 {
+		return s.config.MaxQueries
+	}
+	return 10
+}
+
+func (s *SessionStore) seedItems() int {
+	if s.config != nil && s.config.SeedItems > 0 {
+		return s.config.SeedItems
+	}
+	return 5
+}
+
+func (s *SessionStore) neighborsPerSeed() int {
+	if s.config != nil && s.config.NeighborsPerSeed > 0 {
+		return s.config.NeighborsPerSeed
+	}
+	return 20
+}
+
+func (s *SessionStore) recencyDecay() float64 {
+	if s.config != nil && s.config.RecencyDecay > 0 && s.config.RecencyDecay <= 1 {
+		return s.config.RecencyDecay
+	}
+	return 0.7
+}
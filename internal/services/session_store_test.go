@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func TestSessionState_Seeds(t *testing.T) {
+	boots, socks, tent, stove := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	// Newest first: the user viewed boots twice, disliked the tent after viewing it
+	state := &SessionState{Items: []SessionEvent{
+		{ItemID: boots, Type: "view", Weight: 1.0},
+		{ItemID: tent, Type: "dislike", Weight: -0.8},
+		{ItemID: socks, Type: "click", Weight: 0.6},
+		{ItemID: tent, Type: "view", Weight: 0.4},
+		{ItemID: boots, Type: "view", Weight: 0.5},
+		{ItemID: stove, Type: "view", Weight: 0.4},
+	}}
+
+	seeds := state.Seeds(5, 0.5)
+	require.Len(t, seeds, 3)
+	assert.Equal(t, boots, seeds[0].ItemID)
+	assert.InDelta(t, 1.0+0.5*math.Pow(0.5, 4), seeds[0].Weight, 1e-9, "repeat views add up with decay")
+	assert.Equal(t, socks, seeds[1].ItemID)
+	assert.InDelta(t, 0.6*0.25, seeds[1].Weight, 1e-9)
+	assert.Equal(t, stove, seeds[2].ItemID)
+
+	assert.Len(t, state.Seeds(1, 0.5), 1, "seed count is capped")
+	assert.ElementsMatch(t, []uuid.UUID{boots, tent, socks, stove}, state.ItemIDs())
+
+	var store *SessionStore
+	assert.False(t, store.Enabled())
+	assert.Nil(t, store.Seeds(state))
+	current, err := store.Current(context.Background(), uuid.New())
+	assert.NoError(t, err)
+	assert.Nil(t, current)
+	assert.NoError(t, store.Record(context.Background(), &models.UserInteraction{}, 1))
+}
+
+func TestScoreSessionNeighbors(t *testing.T) {
+	latest, earlier := uuid.New(), uuid.New()
+	shared, nearLatest, nearEarlier := uuid.New(), uuid.New(), uuid.New()
+
+	seeds := []SessionSeed{{ItemID: latest, Weight: 1.0}, {ItemID: earlier, Weight: 0.5}}
+	neighbors := []sessionNeighbor{
+		{SeedID: latest, ItemID: nearLatest, Similarity: 0.9},
+		{SeedID: earlier, ItemID: nearEarlier, Similarity: 0.9, Categories: []string{"footwear"}},
+		{SeedID: latest, ItemID: shared, Similarity: 0.55},
+		{SeedID: earlier, ItemID: shared, Similarity: 0.55},
+		{SeedID: latest, ItemID: uuid.New(), Similarity: -0.2},
+	}
+
+	items := scoreSessionNeighbors(seeds, neighbors, map[string]float64{"footwear": 2.0}, 10)
+	require.Len(t, items, 3, "dissimilar items are dropped")
+
+	assert.Equal(t, nearLatest, items[0].ItemID, "the latest item steers")
+	assert.InDelta(t, 0.9/1.5, items[0].Score, 1e-9)
+	assert.Equal(t, shared, items[1].ItemID, "support from several seeds adds up")
+	assert.InDelta(t, 0.55, items[1].Score, 1e-9)
+	assert.Equal(t, nearEarlier, items[2].ItemID)
+	assert.InDelta(t, 0.3*(1+sessionCategoryBoost), items[2].Score, 1e-9, "session categories are boosted")
+
+	for _, item := range items {
+		assert.Equal(t, "session", item.Algorithm)
+		assert.Greater(t, item.Confidence, 0.0)
+		assert.LessOrEqual(t, item.Confidence, 0.95)
+	}
+	assert.Greater(t, items[1].Confidence, 0.4+0.4*items[1].Score, "multi-seed support raises confidence")
+
+	assert.Len(t, scoreSessionNeighbors(seeds, neighbors, nil, 1), 1)
+	assert.Nil(t, scoreSessionNeighbors(nil, neighbors, nil, 10))
+}
+
+func TestBlendVectors(t *testing.T) {
+	longTerm := []float32{3, 0}
+	shortTerm := []float32{0, 1}
+
+	blended := blendVectors(longTerm, shortTerm, 0.5)
+	assert.InDelta(t, math.Sqrt(0.5), blended[0], 1e-6)
+	assert.InDelta(t, math.Sqrt(0.5), blended[1], 1e-6)
+
+	assert.Equal(t, longTerm, blendVectors(longTerm, nil, 0.5))
+	assert.Equal(t, longTerm, blendVectors(longTerm, shortTerm, 0))
+	assert.Equal(t, shortTerm, blendVectors(nil, shortTerm, 0.5), "new users get the session vector")
+	assert.Equal(t, longTerm, blendVectors(longTerm, []float32{1, 0, 0}, 0.5), "dimensions must match")
+}
+
+func TestWeightedMeanVector(t *testing.T) {
+	a, b, missing := uuid.New(), uuid.New(), uuid.New()
+	seeds := []SessionSeed{{ItemID: a, Weight: 3}, {ItemID: b, Weight: 1}, {ItemID: missing, Weight: 5}}
+
+	vector := weightedMeanVector(seeds, map[uuid.UUID][]float32{
+		a: {1, 0},
+		b: {0, 1},
+	})
+	require.Len(t, vector, 2)
+	assert.InDelta(t, 3/math.Sqrt(10), vector[0], 1e-6)
+	assert.InDelta(t, 1/math.Sqrt(10), vector[1], 1e-6)
+
+	assert.Nil(t, weightedMeanVector(seeds, nil))
+}
+
+func TestSessionStore_BlendWeight(t *testing.T) {
+	store := NewSessionStore(nil, nil, &config.SessionConfig{ShortTermWeight: 0.6}, nil)
+
+	assert.InDelta(t, 0.2, store.BlendWeight(1), 1e-9, "one item only nudges the profile")
+	assert.InDelta(t, 0.6, store.BlendWeight(sessionFullStrengthSeeds), 1e-9)
+	assert.InDelta(t, 0.6, store.BlendWeight(10), 1e-9)
+	assert.Zero(t, store.BlendWeight(0))
+}
+
+func TestVectorFingerprint(t *testing.T) {
+	assert.Equal(t, vectorFingerprint([]float32{0.1, 0.2}), vectorFingerprint([]float32{0.1, 0.2}))
+	assert.NotEqual(t, vectorFingerprint([]float32{0.1, 0.2}), vectorFingerprint([]float32{0.2, 0.1}))
+}
@@ -0,0 +1,674 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/database"
+	"github.com/temcen/pirex/internal/graph"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+var (
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategoryExists      = errors.New("category already exists")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+	ErrInvalidCategory     = errors.New("invalid category")
+)
+
+var categorySlugRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Taxonomy is an immutable snapshot of the category tree. Lookups accept a
+// category ID, name or synonym, case-insensitively. Categories that are not in
+// the taxonomy are treated as unrelated roots.
+type Taxonomy struct {
+	byID  map[string]*models.Category
+	byKey map[string]*models.Category // Lowercased ID, name and synonyms
+}
+
+var (
+	defaultTaxonomyOnce sync.Once
+	defaultTaxonomy     *Taxonomy
+)
+
+// DefaultTaxonomy returns the built-in flat taxonomy used until categories are
+// loaded from the database
+func DefaultTaxonomy() *Taxonomy {
+	defaultTaxonomyOnce.Do(func() {
+		defaults := map[string][]string{
+			"Electronics":   {"electronic", "tech", "technology", "gadget", "gadgets"},
+			"Clothing":      {"clothes", "apparel", "fashion", "wear"},
+			"Books":         {"book", "literature", "reading"},
+			"Home":          {"house", "household", "domestic"},
+			"Sports":        {"sport", "fitness", "exercise", "athletic"},
+			"Food":          {"foods", "cuisine", "cooking", "recipe"},
+			"Travel":        {"tourism", "vacation", "trip", "journey"},
+			"Health":        {"medical", "wellness", "healthcare"},
+			"Education":     {"learning", "academic", "school", "university"},
+			"Entertainment": {"fun", "games", "movies", "music"},
+		}
+
+		categories := make([]models.Category, 0, len(defaults))
+		for name, synonyms := range defaults {
+			categories = append(categories, models.Category{ID: CategorySlug(name), Name: name, Synonyms: synonyms})
+		}
+
+		taxonomy, err := NewTaxonomy(categories)
+		if err != nil {
+			panic(fmt.Sprintf("invalid default taxonomy: %v", err))
+		}
+		defaultTaxonomy = taxonomy
+	})
+	return defaultTaxonomy
+}
+
+// CategorySlug derives a category ID from its name
+func CategorySlug(name string) string {
+	return strings.Trim(categorySlugRegex.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// NewTaxonomy builds a snapshot, computing paths and depths. It rejects unknown
+// parents, cycles, and names or synonyms that resolve to more than one category.
+func NewTaxonomy(categories []models.Category) (*Taxonomy, error) {
+	t := &Taxonomy{
+		byID:  make(map[string]*models.Category, len(categories)),
+		byKey: make(map[string]*models.Category, len(categories)*2),
+	}
+
+	for i := range categories {
+		category := categories[i]
+		if category.ID == "" || strings.TrimSpace(category.Name) == "" {
+			return nil, fmt.Errorf("%w: id and name are required", ErrInvalidCategory)
+		}
+		if _, exists := t.byID[category.ID]; exists {
+			return nil, fmt.Errorf("%w: duplicate id %q", ErrCategoryExists, category.ID)
+		}
+		category.Synonyms = append([]string(nil), category.Synonyms...)
+		t.byID[category.ID] = &category
+	}
+
+	for _, category := range t.byID {
+		path, err := t.buildPath(category)
+		if err != nil {
+			return nil, err
+		}
+		category.Path = path
+		category.Depth = len(path)
+	}
+
+	// IDs first so a name or synonym can never shadow another category's ID
+	for id, category := range t.byID {
+		t.byKey[strings.ToLower(id)] = category
+	}
+	for _, category := range t.byID {
+		keys := append([]string{category.Name}, category.Synonyms...)
+		for _, key := range keys {
+			key = strings.ToLower(strings.TrimSpace(key))
+			if key == "" {
+				continue
+			}
+			if existing, ok := t.byKey[key]; ok && existing != category {
+				return nil, fmt.Errorf("%w: %q already refers to category %q", ErrCategoryExists, key, existing.ID)
+			}
+			t.byKey[key] = category
+		}
+	}
+
+	return t, nil
+}
+
+func (t *Taxonomy) buildPath(category *models.Category) ([]string, error) {
+	var reversed []string
+	seen := make(map[string]bool)
+	for current := category; current != nil; {
+		if seen[current.ID] {
+			return nil, fmt.Errorf("%w: cycle through %q", ErrInvalidCategory, current.ID)
+		}
+		seen[current.ID] = true
+		reversed = append(reversed, current.Name)
+
+		if current.ParentID == nil {
+			break
+		}
+		parent, ok := t.byID[*current.ParentID]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown parent %q of %q", ErrInvalidCategory, *current.ParentID, current.ID)
+		}
+		current = parent
+	}
+
+	path := make([]string, len(reversed))
+	for i, name := range reversed {
+		path[len(reversed)-1-i] = name
+	}
+	return path, nil
+}
+
+// Categories returns copies of all categories ordered by path
+func (t *Taxonomy) Categories() []models.Category {
+	categories := make([]models.Category, 0, len(t.byID))
+	for _, category := range t.byID {
+		categories = append(categories, *category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return strings.Join(categories[i].Path, "/") < strings.Join(categories[j].Path, "/")
+	})
+	return categories
+}
+
+// Get returns a category by ID
+func (t *Taxonomy) Get(id string) (models.Category, bool) {
+	category, ok := t.byID[id]
+	if !ok {
+		return models.Category{}, false
+	}
+	return *category, true
+}
+
+// Resolve finds a category by ID, name or synonym
+func (t *Taxonomy) Resolve(name string) (models.Category, bool) {
+	category, ok := t.lookup(name)
+	if !ok {
+		return models.Category{}, false
+	}
+	return *category, true
+}
+
+func (t *Taxonomy) lookup(name string) (*models.Category, bool) {
+	if t == nil {
+		return nil, false
+	}
+	category, ok := t.byKey[strings.ToLower(strings.TrimSpace(name))]
+	return category, ok
+}
+
+// Canonical maps a category name, ID or synonym to the category name. Unknown
+// categories are returned trimmed and lowercased.
+func (t *Taxonomy) Canonical(name string) string {
+	if category, ok := t.lookup(name); ok {
+		return category.Name
+	}
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Expand canonicalizes categories, removing duplicates, and optionally adds the
+// ancestors of each category after the given ones
+func (t *Taxonomy) Expand(categories []string, withParents bool) []string {
+	seen := make(map[string]bool)
+	expanded := []string{}
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			expanded = append(expanded, name)
+		}
+	}
+
+	for _, name := range categories {
+		add(t.Canonical(name))
+	}
+	if withParents {
+		for _, name := range append([]string(nil), expanded...) {
+			category, ok := t.lookup(name)
+			if !ok {
+				continue
+			}
+			for i := len(category.Path) - 2; i >= 0; i-- {
+				add(category.Path[i])
+			}
+		}
+	}
+
+	return expanded
+}
+
+// MostSpecific drops categories that are ancestors of another given category, so
+// an item expanded to [Headphones, Audio, Electronics] counts as Headphones
+func (t *Taxonomy) MostSpecific(categories []string) []string {
+	ancestors := make(map[string]bool)
+	for _, name := range categories {
+		if category, ok := t.lookup(name); ok {
+			for _, ancestor := range category.Path[:len(category.Path)-1] {
+				ancestors[strings.ToLower(ancestor)] = true
+			}
+		}
+	}
+
+	var specific []string
+	for _, name := range categories {
+		if !ancestors[strings.ToLower(t.Canonical(name))] {
+			specific = append(specific, name)
+		}
+	}
+	return specific
+}
+
+// CommonAncestor returns the name of the deepest category on both paths, or ""
+// when the categories share no root
+func (t *Taxonomy) CommonAncestor(a, b string) string {
+	pathA, pathB := t.path(a), t.path(b)
+	common := ""
+	for i := 0; i < len(pathA) && i < len(pathB); i++ {
+		if !strings.EqualFold(pathA[i], pathB[i]) {
+			break
+		}
+		common = pathA[i]
+	}
+	return common
+}
+
+// Similarity scores two categories by their positions in the tree (Wu-Palmer):
+// 1 for the same category, 2*depth(common ancestor)/(depth(a)+depth(b)) otherwise,
+// 0 for different roots
+func (t *Taxonomy) Similarity(a, b string) float64 {
+	pathA, pathB := t.path(a), t.path(b)
+	shared := 0
+	for shared < len(pathA) && shared < len(pathB) && strings.EqualFold(pathA[shared], pathB[shared]) {
+		shared++
+	}
+	if shared == 0 {
+		return 0
+	}
+	return 2 * float64(shared) / float64(len(pathA)+len(pathB))
+}
+
+// path returns the root-to-category names, or the name alone when it is unknown
+func (t *Taxonomy) path(name string) []string {
+	if category, ok := t.lookup(name); ok {
+		return category.Path
+	}
+	return []string{strings.ToLower(strings.TrimSpace(name))}
+}
+
+// withCategory returns a new snapshot with the category added or replaced
+func (t *Taxonomy) withCategory(category models.Category) (*Taxonomy, error) {
+	categories := make([]models.Category, 0, len(t.byID)+1)
+	for id, existing := range t.byID {
+		if id != category.ID {
+			categories = append(categories, *existing)
+		}
+	}
+	return NewTaxonomy(append(categories, category))
+}
+
+// withoutCategory returns a new snapshot without a leaf category
+func (t *Taxonomy) withoutCategory(id string) (*Taxonomy, error) {
+	if _, ok := t.byID[id]; !ok {
+		return nil, ErrCategoryNotFound
+	}
+	categories := make([]models.Category, 0, len(t.byID))
+	for otherID, existing := range t.byID {
+		if otherID == id {
+			continue
+		}
+		if existing.ParentID != nil && *existing.ParentID == id {
+			return nil, ErrCategoryHasChildren
+		}
+		categories = append(categories, *existing)
+	}
+	return NewTaxonomy(categories)
+}
+
+// TaxonomyService keeps the category taxonomy in Postgres, serves the current
+// snapshot to ingestion and ranking, and mirrors it to Neo4j
+type TaxonomyService struct {
+	db      *database.Database
+	graph   *graph.Repository
+	config  *config.TaxonomyConfig
+	logger  *logrus.Logger
+	current atomic.Pointer[Taxonomy]
+	mu      sync.Mutex // Serializes mutations
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func NewTaxonomyService(db *database.Database, cfg *config.TaxonomyConfig, logger *logrus.Logger) *TaxonomyService {
+	s := &TaxonomyService{
+		db:     db,
+		config: cfg,
+		logger: logger,
+		quit:   make(chan struct{}),
+	}
+	s.current.Store(DefaultTaxonomy())
+	return s
+}
+
+// SetGraph mirrors the taxonomy to the recommendation graph
+func (s *TaxonomyService) SetGraph(repo *graph.Repository) {
+	s.graph = repo
+}
+
+// Current returns the taxonomy snapshot in use. It is safe to call on a nil service.
+func (s *TaxonomyService) Current() *Taxonomy {
+	if s == nil {
+		return DefaultTaxonomy()
+	}
+	return s.current.Load()
+}
+
+// ExpandParents reports whether ingested content gets ancestor categories
+func (s *TaxonomyService) ExpandParents() bool {
+	return s != nil && s.config.ExpandParents
+}
+
+// Normalize canonicalizes content categories, adding ancestors when configured
+func (s *TaxonomyService) Normalize(categories []string) []string {
+	return s.Current().Expand(categories, s.ExpandParents())
+}
+
+// Start loads the taxonomy, mirrors it to the graph and reloads it periodically
+func (s *TaxonomyService) Start(ctx context.Context) {
+	if err := s.Load(ctx); err != nil {
+		s.logger.WithError(err).Warn("Failed to load category taxonomy, using defaults")
+	} else {
+		s.syncGraph(ctx, s.Current())
+	}
+
+	if s.config.ReloadInterval <= 0 {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.ReloadInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Load(ctx); err != nil {
+					s.logger.WithError(err).Warn("Failed to reload category taxonomy")
+				}
+			case <-s.quit:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (s *TaxonomyService) Stop() {
+	close(s.quit)
+	s.wg.Wait()
+}
+
+// Load replaces the snapshot with the categories stored in Postgres. An empty
+// table keeps the built-in defaults.
+func (s *TaxonomyService) Load(ctx context.Context) error {
+	categories, err := s.loadCategories(ctx)
+	if err != nil {
+		return err
+	}
+	if len(categories) == 0 {
+		return nil
+	}
+
+	taxonomy, err := NewTaxonomy(categories)
+	if err != nil {
+		return fmt.Errorf("invalid stored taxonomy: %w", err)
+	}
+	s.current.Store(taxonomy)
+
+	s.logger.WithField("categories", len(categories)).Debug("Loaded category taxonomy")
+	return nil
+}
+
+func (s *TaxonomyService) loadCategories(ctx context.Context) ([]models.Category, error) {
+	rows, err := s.db.PG.Query(ctx, `
+		SELECT c.id, c.name, c.parent_id, c.created_at, c.updated_at,
+		       COALESCE(array_agg(cs.synonym ORDER BY cs.synonym) FILTER (WHERE cs.synonym IS NOT NULL), '{}')
+		FROM categories c
+		LEFT JOIN category_synonyms cs ON cs.category_id = c.id
+		GROUP BY c.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		var category models.Category
+		if err := rows.Scan(&category.ID, &category.Name, &category.ParentID,
+			&category.CreatedAt, &category.UpdatedAt, &category.Synonyms); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// List returns all categories ordered by path
+func (s *TaxonomyService) List() []models.Category {
+	return s.Current().Categories()
+}
+
+// Get returns a category by ID
+func (s *TaxonomyService) Get(id string) (*models.Category, error) {
+	category, ok := s.Current().Get(id)
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+	return &category, nil
+}
+
+// Create adds a category. Its ID is derived from the name unless given.
+func (s *TaxonomyService) Create(ctx context.Context, req *models.CategoryRequest) (*models.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := req.ID
+	if id == "" {
+		id = CategorySlug(req.Name)
+	}
+	if id == "" {
+		return nil, fmt.Errorf("%w: name must contain letters or digits", ErrInvalidCategory)
+	}
+
+	current := s.Current()
+	if _, exists := current.Get(id); exists {
+		return nil, fmt.Errorf("%w: %s", ErrCategoryExists, id)
+	}
+
+	now := time.Now()
+	category := models.Category{
+		ID:        id,
+		Name:      strings.TrimSpace(req.Name),
+		ParentID:  req.ParentID,
+		Synonyms:  normalizeSynonyms(req.Synonyms),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	next, err := current.withCategory(category)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO categories (id, name, parent_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, category.ID, category.Name, category.ParentID, now, now); err != nil {
+			return fmt.Errorf("failed to insert category: %w", err)
+		}
+		return saveSynonyms(ctx, tx, category.ID, category.Synonyms)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.publish(ctx, next, id), nil
+}
+
+// Update replaces a category's name, parent and synonyms. Content tagged with the
+// old name is renamed.
+func (s *TaxonomyService) Update(ctx context.Context, id string, req *models.CategoryRequest) (*models.Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.Current()
+	existing, ok := current.Get(id)
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+
+	category := existing
+	category.Name = strings.TrimSpace(req.Name)
+	category.ParentID = req.ParentID
+	category.Synonyms = normalizeSynonyms(req.Synonyms)
+	category.UpdatedAt = time.Now()
+
+	next, err := current.withCategory(category)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO categories (id, name, parent_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (id) DO UPDATE SET
+				name = EXCLUDED.name,
+				parent_id = EXCLUDED.parent_id,
+				updated_at = EXCLUDED.updated_at
+		`, category.ID, category.Name, category.ParentID, category.CreatedAt, category.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to update category: %w", err)
+		}
+		if existing.Name != category.Name {
+			if _, err := tx.Exec(ctx, `
+				UPDATE content_items SET categories = array_replace(categories, $1, $2), updated_at = NOW()
+				WHERE $1 = ANY(categories)
+			`, existing.Name, category.Name); err != nil {
+				return fmt.Errorf("failed to rename category on content: %w", err)
+			}
+		}
+		return saveSynonyms(ctx, tx, category.ID, category.Synonyms)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.publish(ctx, next, id), nil
+}
+
+// Delete removes a category without subcategories. Content keeps its category
+// names, which become unknown to the taxonomy.
+func (s *TaxonomyService) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next, err := s.Current().withoutCategory(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.inTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM categories WHERE id = $1`, id); err != nil {
+			return fmt.Errorf("failed to delete category: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(ctx, next, "")
+	return nil
+}
+
+func (s *TaxonomyService) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := s.db.PG.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// publish swaps in a committed snapshot, mirrors it to the graph and returns the
+// category with its computed path
+func (s *TaxonomyService) publish(ctx context.Context, next *Taxonomy, id string) *models.Category {
+	s.current.Store(next)
+	s.syncGraph(ctx, next)
+
+	if id == "" {
+		return nil
+	}
+	category, _ := next.Get(id)
+	return &category
+}
+
+func saveSynonyms(ctx context.Context, tx pgx.Tx, categoryID string, synonyms []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM category_synonyms WHERE category_id = $1`, categoryID); err != nil {
+		return fmt.Errorf("failed to clear category synonyms: %w", err)
+	}
+	for _, synonym := range synonyms {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO category_synonyms (synonym, category_id) VALUES ($1, $2)
+		`, synonym, categoryID); err != nil {
+			return fmt.Errorf("failed to store synonym %q: %w", synonym, err)
+		}
+	}
+	return nil
+}
+
+func normalizeSynonyms(synonyms []string) []string {
+	seen := make(map[string]bool, len(synonyms))
+	normalized := make([]string, 0, len(synonyms))
+	for _, synonym := range synonyms {
+		synonym = strings.ToLower(strings.TrimSpace(synonym))
+		if synonym != "" && !seen[synonym] {
+			seen[synonym] = true
+			normalized = append(normalized, synonym)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// syncGraph mirrors the taxonomy as (:Category)-[:SUBCATEGORY_OF]->(:Category).
+// Postgres is the source of truth, so failures are only logged and repaired by
+// the next mutation or restart.
+func (s *TaxonomyService) syncGraph(ctx context.Context, taxonomy *Taxonomy) {
+	if !s.config.SyncGraph || s.graph == nil {
+		return
+	}
+
+	categories := taxonomy.Categories()
+	nodes := make([]graph.CategoryNode, len(categories))
+	for i, category := range categories {
+		nodes[i] = graph.CategoryNode{
+			ID:    category.ID,
+			Name:  category.Name,
+			Path:  category.Path,
+			Depth: category.Depth,
+		}
+		if category.ParentID != nil {
+			nodes[i].ParentID = *category.ParentID
+		}
+	}
+
+	err := s.graph.SyncCategories(ctx, nodes)
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to mirror category taxonomy to Neo4j")
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// testTaxonomyCategories is Electronics > Audio > {Headphones, Speakers},
+// Electronics > Phones and Books > Fiction
+func testTaxonomyCategories() []models.Category {
+	return []models.Category{
+		{ID: "electronics", Name: "Electronics", Synonyms: []string{"tech"}},
+		{ID: "audio", Name: "Audio", ParentID: stringPtr("electronics")},
+		{ID: "headphones", Name: "Headphones", ParentID: stringPtr("audio"), Synonyms: []string{"earphones", "headsets"}},
+		{ID: "speakers", Name: "Speakers", ParentID: stringPtr("audio")},
+		{ID: "phones", Name: "Phones", ParentID: stringPtr("electronics"), Synonyms: []string{"smartphones"}},
+		{ID: "books", Name: "Books"},
+		{ID: "fiction", Name: "Fiction", ParentID: stringPtr("books")},
+	}
+}
+
+func newTestTaxonomyService(t *testing.T, expandParents bool) *TaxonomyService {
+	taxonomy, err := NewTaxonomy(testTaxonomyCategories())
+	require.NoError(t, err)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	s := NewTaxonomyService(nil, &config.TaxonomyConfig{ExpandParents: expandParents}, logger)
+	s.current.Store(taxonomy)
+	return s
+}
+
+func TestNewTaxonomy(t *testing.T) {
+	taxonomy, err := NewTaxonomy(testTaxonomyCategories())
+	require.NoError(t, err)
+
+	headphones, ok := taxonomy.Resolve("EARPHONES")
+	require.True(t, ok)
+	assert.Equal(t, "headphones", headphones.ID)
+	assert.Equal(t, []string{"Electronics", "Audio", "Headphones"}, headphones.Path)
+	assert.Equal(t, 3, headphones.Depth)
+
+	categories := taxonomy.Categories()
+	require.Len(t, categories, 7)
+	assert.Equal(t, "Books", categories[0].Name)
+
+	t.Run("unknown parent", func(t *testing.T) {
+		_, err := NewTaxonomy([]models.Category{{ID: "a", Name: "A", ParentID: stringPtr("missing")}})
+		assert.ErrorIs(t, err, ErrInvalidCategory)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := NewTaxonomy([]models.Category{
+			{ID: "a", Name: "A", ParentID: stringPtr("b")},
+			{ID: "b", Name: "B", ParentID: stringPtr("a")},
+		})
+		assert.ErrorIs(t, err, ErrInvalidCategory)
+	})
+
+	t.Run("synonym used twice", func(t *testing.T) {
+		_, err := NewTaxonomy([]models.Category{
+			{ID: "a", Name: "A", Synonyms: []string{"shared"}},
+			{ID: "b", Name: "B", Synonyms: []string{"Shared"}},
+		})
+		assert.ErrorIs(t, err, ErrCategoryExists)
+	})
+
+	t.Run("name reused as synonym", func(t *testing.T) {
+		_, err := NewTaxonomy([]models.Category{
+			{ID: "a", Name: "A"},
+			{ID: "b", Name: "B", Synonyms: []string{"a"}},
+		})
+		assert.ErrorIs(t, err, ErrCategoryExists)
+	})
+}
+
+func TestTaxonomy_Expand(t *testing.T) {
+	taxonomy, err := NewTaxonomy(testTaxonomyCategories())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Headphones", "Phones", "vinyl"},
+		taxonomy.Expand([]string{"headsets", "Headphones", "smartphones", " Vinyl "}, false))
+	assert.Equal(t, []string{"Headphones", "Phones", "vinyl", "Audio", "Electronics"},
+		taxonomy.Expand([]string{"headsets", "smartphones", "vinyl"}, true))
+	assert.Equal(t, []string{}, taxonomy.Expand([]string{"  "}, true))
+}
+
+func TestTaxonomy_MostSpecific(t *testing.T) {
+	taxonomy, err := NewTaxonomy(testTaxonomyCategories())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Headphones", "Books"},
+		taxonomy.MostSpecific([]string{"Headphones", "Audio", "Electronics", "Books"}))
+	assert.Equal(t, []string{"Electronics", "vinyl"},
+		taxonomy.MostSpecific([]string{"Electronics", "vinyl"}))
+}
+
+func TestTaxonomy_Similarity(t *testing.T) {
+	taxonomy, err := NewTaxonomy(testTaxonomyCategories())
+	require.NoError(t, err)
+
+	tests := []struct {
+		a, b     string
+		expected float64
+	}{
+		{"Headphones", "headsets", 1},
+		{"Headphones", "Speakers", 2.0 * 2 / 6},
+		{"Headphones", "Phones", 2.0 * 1 / 5},
+		{"Headphones", "Audio", 2.0 * 2 / 5},
+		{"Headphones", "Fiction", 0},
+		{"vinyl", "VINYL", 1},
+		{"vinyl", "cassettes", 0},
+	}
+
+	for _, tt := range tests {
+		assert.InDelta(t, tt.expected, taxonomy.Similarity(tt.a, tt.b), 1e-9, "%s/%s", tt.a, tt.b)
+	}
+}
+
+func TestTaxonomy_CommonAncestor(t *testing.T) {
+	taxonomy, err := NewTaxonomy(testTaxonomyCategories())
+	require.NoError(t, err)
+
+	assert.Equal(t, "Audio", taxonomy.CommonAncestor("headphones", "Speakers"))
+	assert.Equal(t, "Electronics", taxonomy.CommonAncestor("Headphones", "smartphones"))
+	assert.Equal(t, "", taxonomy.CommonAncestor("Headphones", "Fiction"))
+}
+
+func TestTaxonomy_WithoutCategory(t *testing.T) {
+	taxonomy, err := NewTaxonomy(testTaxonomyCategories())
+	require.NoError(t, err)
+
+	_, err = taxonomy.withoutCategory("audio")
+	assert.ErrorIs(t, err, ErrCategoryHasChildren)
+
+	_, err = taxonomy.withoutCategory("missing")
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+
+	next, err := taxonomy.withoutCategory("speakers")
+	require.NoError(t, err)
+	_, ok := next.Resolve("Speakers")
+	assert.False(t, ok)
+}
+
+func TestCategorySlug(t *testing.T) {
+	assert.Equal(t, "home-garden", CategorySlug("Home & Garden"))
+	assert.Equal(t, "tv-s", CategorySlug("  TV's "))
+}
+
+func TestDataPreprocessor_NormalizeCategoriesWithTaxonomy(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	preprocessor := NewDataPreprocessor(logger)
+	preprocessor.SetTaxonomy(newTestTaxonomyService(t, true))
+
+	result := &ProcessingResult{ProcessedContent: &models.ContentItem{}}
+	err := preprocessor.normalizeCategories(models.ContentIngestionRequest{
+		Categories: []string{"earphones", "Wireless"},
+	}, result)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Headphones", "wireless", "Audio", "Electronics"}, result.ProcessedContent.Categories)
+}
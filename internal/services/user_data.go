@@ -0,0 +1,823 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/database"
+	"github.com/temcen/pirex/internal/graph"
+	"github.com/temcen/pirex/internal/messaging"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+const userDeletionJobType = "user_deletion"
+
+var (
+	ErrUserNotFound       = errors.New("no data stored for user")
+	ErrDeletionInProgress = errors.New("user deletion already running")
+)
+
+var (
+	userDataExports = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_data_exports_total",
+		Help: "User data exports by outcome",
+	}, []string{"status"})
+
+	userDeletions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_deletions_total",
+		Help: "User deletion jobs by outcome",
+	}, []string{"status"})
+)
+
+// userTables hold rows about a user, deleted in this order. Optional tables
+// only exist when the metrics schema is installed.
+var userTables = []struct {
+	name     string
+	optional bool
+}{
+	{name: "graph_outbox"}, // First, so the relay cannot recreate the user's node
+	{name: "user_interactions"},
+	{name: "user_suppressions"},
+	{name: "user_interests"},
+	{name: "user_profiles"},
+	{name: "recommendation_metrics"},
+	{name: "user_engagement_metrics", optional: true},
+}
+
+// deletionNotes explain what a deletion cannot remove itself
+var deletionNotes = []string{
+	"Kafka records are immutable; records keyed by the user expire with topic retention",
+	"HTTP response cache entries are keyed by a hash of the request and expire with their TTL",
+}
+
+// UserDataService exports everything stored about a user and deletes it from
+// Postgres, Neo4j, the Redis tiers and, through a deletion event, Kafka
+// consumers. Deletions run in the background as jobs whose details hold a
+// models.UserDeletionReport.
+type UserDataService struct {
+	db         *database.Database
+	graph      *graph.Repository
+	jobManager *JobManager
+	messageBus *messaging.MessageBus
+	config     *config.PrivacyConfig
+	logger     *logrus.Logger
+
+	deleting sync.Map // User IDs with a deletion running
+	wg       sync.WaitGroup
+}
+
+func NewUserDataService(db *database.Database, graphRepo *graph.Repository, jobManager *JobManager, messageBus *messaging.MessageBus, cfg *config.PrivacyConfig, logger *logrus.Logger) *UserDataService {
+	return &UserDataService{
+		db:         db,
+		graph:      graphRepo,
+		jobManager: jobManager,
+		messageBus: messageBus,
+		config:     cfg,
+		logger:     logger,
+	}
+}
+
+// Stop waits for running deletions, which are not interrupted
+func (s *UserDataService) Stop() {
+	s.wg.Wait()
+}
+
+// Export collects the user's profile, interactions, suppressions,
+// recommendation events, graph relationships and cached feedback
+func (s *UserDataService) Export(ctx context.Context, userID uuid.UUID) (*models.UserDataExport, error) {
+	export, err := s.export(ctx, userID)
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		userDataExports.WithLabelValues("not_found").Inc()
+	case err != nil:
+		userDataExports.WithLabelValues("error").Inc()
+	default:
+		userDataExports.WithLabelValues("success").Inc()
+	}
+	return export, err
+}
+
+func (s *UserDataService) export(ctx context.Context, userID uuid.UUID) (*models.UserDataExport, error) {
+	export := &models.UserDataExport{
+		UserID:               userID,
+		ExportedAt:           time.Now(),
+		Interactions:         []models.UserInteraction{},
+		Suppressions:         []models.Suppression{},
+		RecommendationEvents: []models.RecommendationEvent{},
+		GraphRelationships:   []models.GraphRelationship{},
+		Feedback:             []models.RecommendationFeedback{},
+	}
+
+	var err error
+	if export.Profile, err = s.exportProfile(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Interactions, err = s.exportInteractions(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Suppressions, err = s.exportSuppressions(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.RecommendationEvents, err = s.exportRecommendationEvents(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	relationships, err := s.graph.InteractionsOf(ctx, []uuid.UUID{userID})
+	switch {
+	case errors.Is(err, graph.ErrUnavailable):
+		export.Omitted = append(export.Omitted, "neo4j")
+	case err != nil:
+		return nil, fmt.Errorf("failed to read graph relationships: %w", err)
+	default:
+		for _, relationship := range relationships {
+			export.GraphRelationships = append(export.GraphRelationships, models.GraphRelationship{
+				ItemID:     relationship.ItemID,
+				Type:       relationship.Type,
+				Properties: relationship.Properties,
+			})
+		}
+	}
+
+	if export.Feedback, err = s.exportFeedback(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if export.Profile == nil && len(export.Interactions) == 0 && len(export.Suppressions) == 0 &&
+		len(export.RecommendationEvents) == 0 && len(export.GraphRelationships) == 0 && len(export.Feedback) == 0 {
+		return nil, ErrUserNotFound
+	}
+	return export, nil
+}
+
+func (s *UserDataService) exportProfile(ctx context.Context, userID uuid.UUID) (*models.UserProfileExport, error) {
+	var profile models.UserProfileExport
+	var explicitPrefsJSON, behaviorPatternsJSON, demographicsJSON []byte
+	err := s.db.PG.QueryRow(ctx, `
+		SELECT user_id, preference_vector, negative_vector, explicit_preferences, behavior_patterns,
+			demographics, interaction_count, last_interaction, created_at, updated_at
+		FROM user_profiles
+		WHERE user_id = $1`, userID).Scan(
+		&profile.UserID, &profile.PreferenceVector, &profile.NegativeVector,
+		&explicitPrefsJSON, &behaviorPatternsJSON, &demographicsJSON,
+		&profile.InteractionCount, &profile.LastInteraction, &profile.CreatedAt, &profile.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user profile: %w", err)
+	}
+
+	for _, field := range []struct {
+		raw    []byte
+		target *map[string]interface{}
+	}{
+		{explicitPrefsJSON, &profile.ExplicitPrefs},
+		{behaviorPatternsJSON, &profile.BehaviorPatterns},
+		{demographicsJSON, &profile.Demographics},
+	} {
+		if len(field.raw) > 0 {
+			if err := json.Unmarshal(field.raw, field.target); err != nil {
+				s.logger.WithError(err).WithField("user_id", userID).Warn("Failed to unmarshal profile field")
+			}
+		}
+	}
+
+	rows, err := s.db.PG.Query(ctx, `
+		SELECT vector, weight, item_count, categories
+		FROM user_interests
+		WHERE user_id = $1
+		ORDER BY position`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user interests: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var interest models.InterestExport
+		if err := rows.Scan(&interest.Vector, &interest.Weight, &interest.ItemCount, &interest.Categories); err != nil {
+			return nil, fmt.Errorf("failed to scan user interest: %w", err)
+		}
+		profile.Interests = append(profile.Interests, interest)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read user interests: %w", err)
+	}
+	return &profile, nil
+}
+
+func (s *UserDataService) exportInteractions(ctx context.Context, userID uuid.UUID) ([]models.UserInteraction, error) {
+	rows, err := s.db.PG.Query(ctx, `
+		SELECT id, user_id, item_id, interaction_type, value, duration, query, session_id, context, timestamp
+		FROM user_interactions
+		WHERE user_id = $1
+		ORDER BY timestamp, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query interactions: %w", err)
+	}
+	defer rows.Close()
+
+	interactions := []models.UserInteraction{}
+	for rows.Next() {
+		var interaction models.UserInteraction
+		var contextJSON []byte
+		if err := rows.Scan(&interaction.ID, &interaction.UserID, &interaction.ItemID, &interaction.InteractionType,
+			&interaction.Value, &interaction.Duration, &interaction.Query, &interaction.SessionID,
+			&contextJSON, &interaction.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan interaction: %w", err)
+		}
+		if len(contextJSON) > 0 {
+			if err := json.Unmarshal(contextJSON, &interaction.Context); err != nil {
+				s.logger.WithError(err).Warn("Failed to unmarshal interaction context")
+			}
+		}
+		interactions = append(interactions, interaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read interactions: %w", err)
+	}
+	return interactions, nil
+}
+
+// exportSuppressions returns suppressions as stored, before decay
+func (s *UserDataService) exportSuppressions(ctx context.Context, userID uuid.UUID) ([]models.Suppression, error) {
+	rows, err := s.db.PG.Query(ctx, `
+		SELECT id, user_id, kind, value, strength, hard, source, created_at, updated_at
+		FROM user_suppressions
+		WHERE user_id = $1
+		ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suppressions: %w", err)
+	}
+	defer rows.Close()
+
+	suppressions := []models.Suppression{}
+	for rows.Next() {
+		var suppression models.Suppression
+		if err := rows.Scan(&suppression.ID, &suppression.UserID, &suppression.Kind, &suppression.Value,
+			&suppression.Strength, &suppression.Hard, &suppression.Source,
+			&suppression.CreatedAt, &suppression.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan suppression: %w", err)
+		}
+		suppressions = append(suppressions, suppression)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read suppressions: %w", err)
+	}
+	return suppressions, nil
+}
+
+func (s *UserDataService) exportRecommendationEvents(ctx context.Context, userID uuid.UUID) ([]models.RecommendationEvent, error) {
+	rows, err := s.db.PG.Query(ctx, `
+		SELECT id, item_id, recommendation_id, event_type, algorithm_used, position_in_list,
+			confidence_score, session_id, context, market, timestamp
+		FROM recommendation_metrics
+		WHERE user_id = $1
+		ORDER BY timestamp, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recommendation events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.RecommendationEvent{}
+	for rows.Next() {
+		var event models.RecommendationEvent
+		var contextJSON []byte
+		if err := rows.Scan(&event.ID, &event.ItemID, &event.RecommendationID, &event.EventType, &event.Algorithm,
+			&event.Position, &event.Confidence, &event.SessionID, &contextJSON, &event.Market, &event.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan recommendation event: %w", err)
+		}
+		if len(contextJSON) > 0 {
+			if err := json.Unmarshal(contextJSON, &event.Context); err != nil {
+				s.logger.WithError(err).Warn("Failed to unmarshal recommendation event context")
+			}
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recommendation events: %w", err)
+	}
+	return events, nil
+}
+
+// exportFeedback reads the recommendation feedback the orchestrator caches
+func (s *UserDataService) exportFeedback(ctx context.Context, userID uuid.UUID) ([]models.RecommendationFeedback, error) {
+	feedback := []models.RecommendationFeedback{}
+	if s.db.Redis.Warm == nil {
+		return feedback, nil
+	}
+
+	iter := s.db.Redis.Warm.Scan(ctx, 0, fmt.Sprintf("feedback:%s:*", userID), s.scanCount()).Iterator()
+	for iter.Next(ctx) {
+		data, err := s.db.Redis.Warm.Get(ctx, iter.Val()).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read feedback: %w", err)
+		}
+		var entry models.RecommendationFeedback
+		if err := json.Unmarshal(data, &entry); err != nil {
+			s.logger.WithError(err).WithField("key", iter.Val()).Warn("Failed to unmarshal cached feedback")
+			continue
+		}
+		feedback = append(feedback, entry)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan feedback: %w", err)
+	}
+	return feedback, nil
+}
+
+// Delete starts deleting the user in the background and returns its job.
+// Deleting is idempotent, so a failed job can be retried with a new request.
+func (s *UserDataService) Delete(ctx context.Context, userID uuid.UUID) (*JobProgress, error) {
+	if _, running := s.deleting.LoadOrStore(userID, true); running {
+		return nil, ErrDeletionInProgress
+	}
+
+	job, err := s.jobManager.CreateStreamingJob(ctx, userDeletionJobType, map[string]interface{}{
+		"user_id": userID.String(),
+	})
+	if err != nil {
+		s.deleting.Delete(userID)
+		userDeletions.WithLabelValues("error").Inc()
+		return nil, fmt.Errorf("failed to create deletion job: %w", err)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.deleting.Delete(userID)
+		s.run(context.Background(), job.JobID, userID)
+	}()
+
+	return job, nil
+}
+
+// deletion is the state of one deletion job
+type deletion struct {
+	jobID      uuid.UUID
+	userID     uuid.UUID
+	sessionIDs []uuid.UUID
+	report     *models.UserDeletionReport
+}
+
+// run deletes the user store by store, then verifies every store is empty.
+// Each store and the verification count as one job item.
+func (s *UserDataService) run(ctx context.Context, jobID, userID uuid.UUID) {
+	startTime := time.Now()
+	d := &deletion{
+		jobID:  jobID,
+		userID: userID,
+		report: &models.UserDeletionReport{
+			UserID:    userID,
+			JobID:     jobID,
+			StartedAt: startTime,
+			Stores:    []models.StoreDeletion{},
+			Notes:     deletionNotes,
+		},
+	}
+
+	steps := []func(context.Context, *deletion) models.StoreDeletion{
+		s.deletePostgres,
+		s.deleteGraph,
+		s.deleteRedis,
+		s.announceDeletion,
+	}
+	if err := s.jobManager.ExpandJob(ctx, jobID, len(steps)+1); err != nil {
+		s.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to size deletion job")
+	}
+
+	// Sessions are only known from interactions and the current session, so
+	// they are collected before those are deleted
+	sessionIDs, err := s.sessionIDs(ctx, userID)
+	if err != nil {
+		s.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to collect user sessions; session keys expire with their TTL")
+	}
+	d.sessionIDs = sessionIDs
+
+	failed := 0
+	for _, step := range steps {
+		result := step(ctx, d)
+		d.report.Stores = append(d.report.Stores, result)
+		if result.Status == models.DeletionFailed {
+			failed++
+		}
+		s.advance(ctx, jobID, result.Status != models.DeletionFailed)
+	}
+
+	verifyErr := s.verify(ctx, d)
+	s.advance(ctx, jobID, verifyErr == nil)
+	d.report.Verified = failed == 0 && verifyErr == nil && nothingRemaining(d.report.Stores)
+
+	completedAt := time.Now()
+	d.report.CompletedAt = &completedAt
+	d.report.Digest = deletionDigest(d.report)
+	details := map[string]interface{}{"deletion": d.report}
+
+	fields := logrus.Fields{
+		"job_id":   jobID,
+		"verified": d.report.Verified,
+		"duration": time.Since(startTime),
+	}
+
+	if d.report.Verified {
+		userDeletions.WithLabelValues("success").Inc()
+		if err := s.jobManager.SealJob(ctx, jobID, details); err != nil {
+			s.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to seal deletion job")
+		}
+		s.logger.WithFields(fields).Info("User deletion completed")
+		return
+	}
+
+	userDeletions.WithLabelValues("failed").Inc()
+	message := "user data remains after deletion; retry the deletion"
+	if verifyErr != nil {
+		message = fmt.Sprintf("failed to verify deletion: %v", verifyErr)
+	}
+	if err := s.jobManager.AbortJob(ctx, jobID, message, details); err != nil {
+		s.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to fail deletion job")
+	}
+	s.logger.WithFields(fields).Error("User deletion incomplete")
+}
+
+func (s *UserDataService) advance(ctx context.Context, jobID uuid.UUID, ok bool) {
+	processed, failed := 1, 0
+	if !ok {
+		processed, failed = 0, 1
+	}
+	if err := s.jobManager.AdvanceJob(ctx, jobID, processed, failed); err != nil && !errors.Is(err, ErrJobCancelled) {
+		s.logger.WithError(err).WithField("job_id", jobID).Warn("Failed to advance deletion job")
+	}
+}
+
+// sessionIDs returns the sessions of the user's interactions and events and
+// the current session
+func (s *UserDataService) sessionIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	seen := make(map[uuid.UUID]bool)
+	if s.db.Redis.Hot != nil {
+		current, err := s.db.Redis.Hot.Get(ctx, sessionCurrentKey(userID)).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("failed to read current session: %w", err)
+		}
+		if sessionID, err := uuid.Parse(current); err == nil {
+			seen[sessionID] = true
+		}
+	}
+
+	rows, err := s.db.PG.Query(ctx, `
+		SELECT session_id FROM user_interactions WHERE user_id = $1
+		UNION
+		SELECT session_id FROM recommendation_metrics WHERE user_id = $1 AND session_id IS NOT NULL`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sessionID uuid.UUID
+		if err := rows.Scan(&sessionID); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		seen[sessionID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read sessions: %w", err)
+	}
+
+	sessionIDs := make([]uuid.UUID, 0, len(seen))
+	for sessionID := range seen {
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+	return sessionIDs, nil
+}
+
+// deletePostgres deletes the user's rows from every table in one transaction
+func (s *UserDataService) deletePostgres(ctx context.Context, d *deletion) models.StoreDeletion {
+	result := models.StoreDeletion{Store: "postgres", Status: models.DeletionDeleted, Deleted: map[string]int64{}}
+
+	err := func() error {
+		tx, err := s.db.PG.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback(ctx)
+
+		for _, table := range userTables {
+			if table.optional {
+				var exists bool
+				if err := tx.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, table.name).Scan(&exists); err != nil {
+					return fmt.Errorf("failed to look up %s: %w", table.name, err)
+				}
+				if !exists {
+					continue
+				}
+			}
+			tag, err := tx.Exec(ctx, `DELETE FROM `+table.name+` WHERE user_id = $1`, d.userID)
+			if err != nil {
+				return fmt.Errorf("failed to delete from %s: %w", table.name, err)
+			}
+			result.Deleted[table.name] = tag.RowsAffected()
+		}
+		return tx.Commit(ctx)
+	}()
+	if err != nil {
+		return failedStore(result, err)
+	}
+	return result
+}
+
+// deleteGraph removes the user's node and its relationships
+func (s *UserDataService) deleteGraph(ctx context.Context, d *deletion) models.StoreDeletion {
+	result := models.StoreDeletion{Store: "neo4j", Status: models.DeletionDeleted, Deleted: map[string]int64{}}
+
+	relationships, err := s.graph.DeleteUser(ctx, d.userID)
+	switch {
+	case errors.Is(err, graph.ErrUnavailable):
+		result.Status = models.DeletionSkipped
+	case err != nil:
+		return failedStore(result, err)
+	default:
+		result.Deleted["relationships"] = relationships
+	}
+	return result
+}
+
+// deleteRedis deletes the user's keys and the keys of their sessions from
+// every tier
+func (s *UserDataService) deleteRedis(ctx context.Context, d *deletion) models.StoreDeletion {
+	result := models.StoreDeletion{Store: "redis", Status: models.DeletionDeleted, Deleted: map[string]int64{}}
+
+	sessionKeys := userSessionKeys(d.sessionIDs)
+	for _, client := range s.redisTiers() {
+		for _, family := range userKeyFamilies(d.userID) {
+			n, err := s.deleteMatching(ctx, client, family.pattern)
+			if err != nil {
+				return failedStore(result, err)
+			}
+			result.Deleted[family.name] += n
+		}
+
+		for _, keys := range chunkKeys(sessionKeys, int(s.scanCount())) {
+			n, err := client.Del(ctx, keys...).Result()
+			if err != nil {
+				return failedStore(result, fmt.Errorf("failed to delete session keys: %w", err))
+			}
+			result.Deleted["sessions"] += n
+		}
+	}
+	return result
+}
+
+// announceDeletion tells Kafka consumers to purge the user
+func (s *UserDataService) announceDeletion(ctx context.Context, d *deletion) models.StoreDeletion {
+	result := models.StoreDeletion{Store: "kafka", Status: models.DeletionDeleted, Deleted: map[string]int64{}}
+	if !s.config.PublishDeletions || s.messageBus == nil {
+		result.Status = models.DeletionSkipped
+		return result
+	}
+
+	err := s.messageBus.PublishUserDeletion(ctx, messaging.UserDeletionEvent{
+		UserID:      d.userID,
+		JobID:       d.jobID,
+		RequestedAt: d.report.StartedAt,
+	})
+	if err != nil {
+		return failedStore(result, err)
+	}
+	result.Deleted["deletion_events"] = 1
+	return result
+}
+
+// verify counts what is left of the user in every store that was purged
+func (s *UserDataService) verify(ctx context.Context, d *deletion) error {
+	for i := range d.report.Stores {
+		store := &d.report.Stores[i]
+		if store.Status != models.DeletionDeleted {
+			continue
+		}
+
+		remaining := map[string]int64{}
+		switch store.Store {
+		case "postgres":
+			for _, table := range userTables {
+				if _, deleted := store.Deleted[table.name]; !deleted {
+					continue // Optional table that does not exist
+				}
+				var count int64
+				if err := s.db.PG.QueryRow(ctx, `SELECT COUNT(*) FROM `+table.name+` WHERE user_id = $1`, d.userID).Scan(&count); err != nil {
+					return fmt.Errorf("failed to count %s: %w", table.name, err)
+				}
+				remaining[table.name] = count
+			}
+		case "neo4j":
+			exists, err := s.graph.UserExists(ctx, d.userID)
+			if err != nil {
+				return fmt.Errorf("failed to look up user node: %w", err)
+			}
+			if exists {
+				remaining["user_nodes"] = 1
+			} else {
+				remaining["user_nodes"] = 0
+			}
+		case "redis":
+			sessionKeys := userSessionKeys(d.sessionIDs)
+			for _, client := range s.redisTiers() {
+				for _, family := range userKeyFamilies(d.userID) {
+					n, err := s.countMatching(ctx, client, family.pattern)
+					if err != nil {
+						return err
+					}
+					remaining[family.name] += n
+				}
+				for _, keys := range chunkKeys(sessionKeys, int(s.scanCount())) {
+					n, err := client.Exists(ctx, keys...).Result()
+					if err != nil {
+						return fmt.Errorf("failed to check session keys: %w", err)
+					}
+					remaining["sessions"] += n
+				}
+			}
+		default:
+			continue
+		}
+		store.Remaining = remaining
+	}
+	return nil
+}
+
+func (s *UserDataService) redisTiers() []*redis.Client {
+	var tiers []*redis.Client
+	for _, client := range []*redis.Client{s.db.Redis.Hot, s.db.Redis.Warm, s.db.Redis.Cold} {
+		if client != nil {
+			tiers = append(tiers, client)
+		}
+	}
+	return tiers
+}
+
+// deleteMatching deletes the keys matching a pattern and returns how many
+// were deleted
+func (s *UserDataService) deleteMatching(ctx context.Context, client *redis.Client, pattern string) (int64, error) {
+	var deleted int64
+	iter := client.Scan(ctx, 0, pattern, s.scanCount()).Iterator()
+	var batch []string
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := client.Del(ctx, batch...).Result()
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %w", pattern, err)
+		}
+		deleted += n
+		batch = batch[:0]
+		return nil
+	}
+
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if int64(len(batch)) >= s.scanCount() {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, fmt.Errorf("failed to scan %s: %w", pattern, err)
+	}
+	return deleted, flush()
+}
+
+func (s *UserDataService) countMatching(ctx context.Context, client *redis.Client, pattern string) (int64, error) {
+	var count int64
+	iter := client.Scan(ctx, 0, pattern, s.scanCount()).Iterator()
+	for iter.Next(ctx) {
+		count++
+	}
+	if err := iter.Err(); err != nil {
+		return 0, fmt.Errorf("failed to scan %s: %w", pattern, err)
+	}
+	return count, nil
+}
+
+func (s *UserDataService) scanCount() int64 {
+	if s.config.ScanCount > 0 {
+		return s.config.ScanCount
+	}
+	return 500
+}
+
+func failedStore(result models.StoreDeletion, err error) models.StoreDeletion {
+	result.Status = models.DeletionFailed
+	result.Error = err.Error()
+	return result
+}
+
+// nothingRemaining reports whether verification found no data in any store
+func nothingRemaining(stores []models.StoreDeletion) bool {
+	for _, store := range stores {
+		for _, n := range store.Remaining {
+			if n > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// userKeyFamily is a group of Redis keys holding a user's data
+type userKeyFamily struct {
+	name    string
+	pattern string // SCAN pattern
+}
+
+// userKeyFamilies lists the Redis keys built from the user's ID. Keys built
+// from item IDs only, such as co-visitation neighbour lists, are aggregates
+// and not personal.
+func userKeyFamilies(userID uuid.UUID) []userKeyFamily {
+	id := userID.String()
+	return []userKeyFamily{
+		{name: "user_profile", pattern: "user_profile:" + id},
+		{name: "recommendations", pattern: "orchestration:" + id + ":*"},
+		{name: "recommendations", pattern: "recommendations:user:" + id},
+		{name: "algorithm_results", pattern: "semantic_search:" + id + ":*"},
+		{name: "algorithm_results", pattern: "collaborative_filtering:" + id + ":*"},
+		{name: "algorithm_results", pattern: "pagerank:" + id + ":*"},
+		{name: "algorithm_results", pattern: "graph_signal:" + id + ":*"},
+		{name: "similar_users", pattern: "similar_users:" + id + ":*"},
+		{name: "similar_users", pattern: "user_similarities:" + id},
+		{name: "user_communities", pattern: "user_communities:" + id},
+		{name: "feedback", pattern: "feedback:" + id + ":*"},
+		{name: "auth_session", pattern: "session:" + id},
+		{name: "sessions", pattern: sessionCurrentKey(userID)},
+		{name: "spam", pattern: "spam:*:" + id},
+		{name: "rate_limit", pattern: "rate_limit:user:" + id},
+		{name: "rate_limit", pattern: "rate_limit:" + id + ":*"},
+		{name: "plugin_enrichment", pattern: "plugin_enrichment:" + id},
+	}
+}
+
+// userSessionKeys lists the session store and co-visitation keys of sessions
+func userSessionKeys(sessionIDs []uuid.UUID) []string {
+	keys := make([]string, 0, len(sessionIDs)*8)
+	for _, sessionID := range sessionIDs {
+		for _, part := range []string{"items", "categories", "queries", "version"} {
+			keys = append(keys, sessionKey(sessionID, part))
+		}
+		for _, matrix := range []string{coVisitMatrix, boughtTogetherMatrix} {
+			keys = append(keys, coSessionKey(matrix, sessionID, "seen"), coSessionKey(matrix, sessionID, "weights"))
+		}
+	}
+	return keys
+}
+
+func chunkKeys(keys []string, size int) [][]string {
+	var chunks [][]string
+	for len(keys) > size {
+		chunks = append(chunks, keys[:size])
+		keys = keys[size:]
+	}
+	if len(keys) > 0 {
+		chunks = append(chunks, keys)
+	}
+	return chunks
+}
+
+// deletionDigest is the hex SHA-256 of the report's compact JSON with an empty
+// digest and object keys sorted, so it can be recomputed from the job details
+func deletionDigest(report *models.UserDeletionReport) string {
+	unsigned := *report
+	unsigned.Digest = ""
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return ""
+	}
+
+	// Round trip through maps, which encoding/json writes with sorted keys
+	var canonical interface{}
+	if err := json.Unmarshal(data, &canonical); err != nil {
+		return ""
+	}
+	if data, err = json.Marshal(canonical); err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
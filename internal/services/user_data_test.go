@@ -0,0 +1,97 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/temcen/pirex/pkg/models"
+)
+
+func TestUserKeyFamilies(t *testing.T) {
+	userID := uuid.New()
+	families := userKeyFamilies(userID)
+	require.NotEmpty(t, families)
+
+	for _, family := range families {
+		assert.NotEmpty(t, family.name)
+		assert.Contains(t, family.pattern, userID.String(), "every pattern is scoped to the user")
+		assert.False(t, strings.HasPrefix(family.pattern, "*"), "patterns never start with a wildcard")
+	}
+
+	patterns := make(map[string]bool)
+	for _, family := range families {
+		patterns[family.pattern] = true
+	}
+	assert.True(t, patterns["orchestration:"+userID.String()+":*"])
+	assert.True(t, patterns["user_communities:"+userID.String()])
+	assert.True(t, patterns["spam:*:"+userID.String()])
+	assert.True(t, patterns[sessionCurrentKey(userID)])
+}
+
+func TestUserSessionKeys(t *testing.T) {
+	sessionID := uuid.New()
+	keys := userSessionKeys([]uuid.UUID{sessionID})
+
+	assert.Len(t, keys, 8)
+	assert.Contains(t, keys, sessionKey(sessionID, "items"))
+	assert.Contains(t, keys, coSessionKey(coVisitMatrix, sessionID, "weights"))
+	assert.Contains(t, keys, coSessionKey(boughtTogetherMatrix, sessionID, "seen"))
+	assert.Empty(t, userSessionKeys(nil))
+}
+
+func TestChunkKeys(t *testing.T) {
+	keys := []string{"a", "b", "c", "d", "e"}
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, chunkKeys(keys, 2))
+	assert.Equal(t, [][]string{keys}, chunkKeys(keys, 10))
+	assert.Empty(t, chunkKeys(nil, 10))
+}
+
+func TestDeletionDigest(t *testing.T) {
+	completedAt := time.Date(2026, 3, 1, 12, 0, 5, 0, time.UTC)
+	report := &models.UserDeletionReport{
+		UserID:      uuid.New(),
+		JobID:       uuid.New(),
+		StartedAt:   time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		CompletedAt: &completedAt,
+		Stores: []models.StoreDeletion{{
+			Store:     "postgres",
+			Status:    models.DeletionDeleted,
+			Deleted:   map[string]int64{"user_interactions": 12, "user_profiles": 1},
+			Remaining: map[string]int64{"user_interactions": 0, "user_profiles": 0},
+		}},
+		Verified: true,
+	}
+
+	digest := deletionDigest(report)
+	require.Len(t, digest, 64)
+
+	report.Digest = digest
+	assert.Equal(t, digest, deletionDigest(report), "the digest field is excluded")
+
+	// The digest can be recomputed from the report as stored in job details
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	var stored models.UserDeletionReport
+	require.NoError(t, json.Unmarshal(data, &stored))
+	assert.Equal(t, digest, deletionDigest(&stored))
+
+	report.Stores[0].Deleted["user_interactions"] = 11
+	assert.NotEqual(t, digest, deletionDigest(report))
+}
+
+func TestNothingRemaining(t *testing.T) {
+	assert.True(t, nothingRemaining(nil))
+	assert.True(t, nothingRemaining([]models.StoreDeletion{
+		{Store: "postgres", Remaining: map[string]int64{"user_profiles": 0}},
+		{Store: "neo4j", Status: models.DeletionSkipped},
+	}))
+	assert.False(t, nothingRemaining([]models.StoreDeletion{
+		{Store: "redis", Remaining: map[string]int64{"sessions": 0, "feedback": 2}},
+	}))
+}
@@ -10,44 +10,45 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/sirupsen/logrus"
 
 	"github.com/temcen/pirex/internal/config"
 	"github.com/temcen/pirex/internal/database"
+	"github.com/temcen/pirex/internal/graph"
 	"github.com/temcen/pirex/pkg/models"
 )
 
 type UserInteractionService struct {
 	db                *database.Database
+	graph             *graph.Repository
 	logger            *logrus.Logger
 	config            *config.Config
 	profileUpdateChan chan uuid.UUID
-	batchUpdateChan   chan []Neo4jRelationship
 	stopChan          chan struct{}
 	wg                sync.WaitGroup
-}
 
-type Neo4jRelationship struct {
-	UserID     uuid.UUID
-	ItemID     uuid.UUID
-	Type       string
-	Properties map[string]interface{}
+	outbox       *GraphOutboxRelay  // Queues interactions for the graph; nil skips the graph
+	sessions     *SessionStore      // Keeps the current session's short-term intent; nil skips it
+	coVisitation *CoVisitationIndex // Counts items interacted with together; nil skips it
+	taxonomy     *TaxonomyService   // Canonicalizes stated categories; nil uses the default taxonomy
 }
 
+// Neo4jRelationship is an interaction mirrored to the graph
+type Neo4jRelationship = graph.Interaction
+
 type ProfileUpdateStats struct {
 	InteractionCount int
 	LastUpdate       time.Time
 	PendingUpdates   int
 }
 
-func NewUserInteractionService(db *database.Database, cfg *config.Config, logger *logrus.Logger) *UserInteractionService {
+func NewUserInteractionService(db *database.Database, graphRepo *graph.Repository, cfg *config.Config, logger *logrus.Logger) *UserInteractionService {
 	service := &UserInteractionService{
 		db:                db,
+		graph:             graphRepo,
 		logger:            logger,
 		config:            cfg,
 		profileUpdateChan: make(chan uuid.UUID, 1000),
-		batchUpdateChan:   make(chan []Neo4jRelationship, 100),
 		stopChan:          make(chan struct{}),
 	}
 
@@ -57,15 +58,31 @@ func NewUserInteractionService(db *database.Database, cfg *config.Config, logger
 	return service
 }
 
+// SetGraphOutbox mirrors recorded interactions to the graph through the outbox
+func (s *UserInteractionService) SetGraphOutbox(outbox *GraphOutboxRelay) {
+	s.outbox = outbox
+}
+
+// SetSessionStore records interactions in the user's current session
+func (s *UserInteractionService) SetSessionStore(sessions *SessionStore) {
+	s.sessions = sessions
+}
+
+// SetCoVisitation counts recorded interactions into the co-visitation matrices
+func (s *UserInteractionService) SetCoVisitation(index *CoVisitationIndex) {
+	s.coVisitation = index
+}
+
+// SetTaxonomy canonicalizes the categories of explicit preferences
+func (s *UserInteractionService) SetTaxonomy(taxonomy *TaxonomyService) {
+	s.taxonomy = taxonomy
+}
+
 func (s *UserInteractionService) startBackgroundWorkers() {
 	// Profile update worker
 	s.wg.Add(1)
 	go s.profileUpdateWorker()
 
-	// Neo4j batch update worker
-	s.wg.Add(1)
-	go s.neo4jBatchWorker()
-
 	// Periodic sync worker (every 10 minutes)
 	s.wg.Add(1)
 	go s.periodicSyncWorker()
@@ -96,9 +113,6 @@ func (s *UserInteractionService) RecordExplicitInteraction(ctx context.Context,
 	// Trigger profile update
 	s.triggerProfileUpdate(req.UserID)
 
-	// Queue Neo4j relationship update
-	s.queueNeo4jUpdate(interaction)
-
 	s.logger.WithFields(logrus.Fields{
 		"user_id":          req.UserID,
 		"item_id":          req.ItemID,
@@ -129,15 +143,10 @@ func (s *UserInteractionService) RecordImplicitInteraction(ctx context.Context,
 	}
 
 	// Trigger profile update (less frequent for implicit interactions)
-	if req.Type == "click" || req.Type == "view" {
+	if req.Type == "click" || req.Type == "view" || req.Type == "purchase" {
 		s.triggerProfileUpdate(req.UserID)
 	}
 
-	// Queue Neo4j relationship update for significant interactions
-	if req.ItemID != nil && (req.Type == "click" || req.Type == "view") {
-		s.queueNeo4jUpdate(interaction)
-	}
-
 	s.logger.WithFields(logrus.Fields{
 		"user_id":          req.UserID,
 		"item_id":          req.ItemID,
@@ -275,11 +284,17 @@ func (s *UserInteractionService) GetUserInteractions(ctx context.Context, userID
 func (s *UserInteractionService) storeInteraction(ctx context.Context, interaction *models.UserInteraction) error {
 	contextJSON, _ := json.Marshal(interaction.Context)
 
+	tx, err := s.db.PG.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		INSERT INTO user_interactions (id, user_id, item_id, interaction_type, value, duration, query, session_id, context, timestamp)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
-	_, err := s.db.PG.Exec(ctx, query,
+	_, err = tx.Exec(ctx, query,
 		interaction.ID,
 		interaction.UserID,
 		interaction.ItemID,
@@ -291,8 +306,34 @@ func (s *UserInteractionService) storeInteraction(ctx context.Context, interacti
 		contextJSON,
 		interaction.Timestamp,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	// The graph relationship is committed with the interaction and relayed later
+	queued := s.outbox.Enabled() && mirroredToGraph(interaction)
+	if queued {
+		if err := s.outbox.Enqueue(ctx, tx, []Neo4jRelationship{graphInteraction(interaction)}); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	if queued {
+		s.outbox.Notify()
+	}
+
+	// Session state is a cache of recent activity; losing an event is harmless
+	weight := s.getInteractionWeight(interaction.InteractionType, interaction.Value, interaction.Duration)
+	if err := s.sessions.Record(ctx, interaction, weight); err != nil {
+		s.logger.WithError(err).WithField("session_id", interaction.SessionID).Warn("Failed to record session event")
+	}
+	if err := s.coVisitation.Record(ctx, interaction, weight); err != nil {
+		s.logger.WithError(err).WithField("session_id", interaction.SessionID).Warn("Failed to count co-visitation")
+	}
+	return nil
 }
 
 // triggerProfileUpdate queues a user for profile update
@@ -306,55 +347,60 @@ func (s *UserInteractionService) triggerProfileUpdate(userID uuid.UUID) {
 	}
 }
 
-// queueNeo4jUpdate queues a Neo4j relationship update
-func (s *UserInteractionService) queueNeo4jUpdate(interaction *models.UserInteraction) {
+// mapInteractionTypeToRelationship maps interaction types to Neo4j relationship types
+func (s *UserInteractionService) mapInteractionTypeToRelationship(interactionType string) string {
+	return graph.RelationshipForInteraction(interactionType)
+}
+
+// calculateConfidence calculates confidence score for the interaction
+func (s *UserInteractionService) calculateConfidence(interaction *models.UserInteraction) float64 {
+	return interactionConfidence(interaction)
+}
+
+// graphInteractionTypes are the interaction types mirrored to the graph. Explicit
+// feedback always is; implicit feedback only for clicks and views.
+var graphInteractionTypes = []string{"rating", "like", "dislike", "share", "click", "view"}
+
+// mirroredToGraph reports whether an interaction becomes a graph relationship
+func mirroredToGraph(interaction *models.UserInteraction) bool {
 	if interaction.ItemID == nil {
-		return // Skip interactions without item ID
+		return false
 	}
+	for _, interactionType := range graphInteractionTypes {
+		if interaction.InteractionType == interactionType {
+			return true
+		}
+	}
+	return false
+}
 
+// graphInteraction builds the relationship an interaction with an item is
+// mirrored to. Reconciliation rebuilds expected edges from Postgres with it, so
+// it must only depend on the stored interaction.
+func graphInteraction(interaction *models.UserInteraction) Neo4jRelationship {
 	relationship := Neo4jRelationship{
 		UserID: interaction.UserID,
 		ItemID: *interaction.ItemID,
-		Type:   s.mapInteractionTypeToRelationship(interaction.InteractionType),
+		Type:   graph.RelationshipForInteraction(interaction.InteractionType),
 		Properties: map[string]interface{}{
-			"timestamp":  interaction.Timestamp.Unix(),
-			"confidence": s.calculateConfidence(interaction),
+			graph.PropInteractionType: interaction.InteractionType,
+			graph.PropTimestamp:       interaction.Timestamp.Unix(),
+			graph.PropConfidence:      interactionConfidence(interaction),
 		},
 	}
 
 	// Add type-specific properties
-	if interaction.Value != nil {
-		relationship.Properties["rating"] = *interaction.Value
+	if rating, ok := graph.ImpliedRating(interaction.InteractionType, interaction.Value); ok {
+		relationship.Properties[graph.PropRating] = rating
 	}
 	if interaction.Duration != nil {
-		relationship.Properties["duration"] = *interaction.Duration
-	}
-
-	select {
-	case s.batchUpdateChan <- []Neo4jRelationship{relationship}:
-		// Successfully queued
-	default:
-		// Channel full, log warning
-		s.logger.WithField("user_id", interaction.UserID).Warn("Neo4j update queue full")
-	}
-}
-
-// mapInteractionTypeToRelationship maps interaction types to Neo4j relationship types
-func (s *UserInteractionService) mapInteractionTypeToRelationship(interactionType string) string {
-	switch interactionType {
-	case "rating", "like", "dislike":
-		return "RATED"
-	case "view", "click":
-		return "VIEWED"
-	case "share":
-		return "SHARED"
-	default:
-		return "INTERACTED_WITH"
+		relationship.Properties[graph.PropDuration] = *interaction.Duration
 	}
+	return relationship
 }
 
-// calculateConfidence calculates confidence score for the interaction
-func (s *UserInteractionService) calculateConfidence(interaction *models.UserInteraction) float64 {
+// interactionConfidence is the confidence stored on an interaction's relationship
+func interactionConfidence(interaction *models.UserInteraction) float64 {
 	switch interaction.InteractionType {
 	case "rating":
 		return 0.9 // High confidence for explicit ratings
@@ -362,6 +408,8 @@ func (s *UserInteractionService) calculateConfidence(interaction *models.UserInt
 		return 0.8 // High confidence for explicit feedback
 	case "share":
 		return 0.85 // Very high confidence for sharing
+	case "purchase":
+		return 0.95 // Conversions are the strongest implicit signal
 	case "click":
 		return 0.6 // Medium confidence for clicks
 	case "view":
@@ -417,42 +465,6 @@ func (s *UserInteractionService) profileUpdateWorker() {
 	}
 }
 
-// neo4jBatchWorker processes Neo4j relationship updates in batches
-func (s *UserInteractionService) neo4jBatchWorker() {
-	defer s.wg.Done()
-
-	var batch []Neo4jRelationship
-	ticker := time.NewTicker(30 * time.Second) // Process batch every 30 seconds
-	defer ticker.Stop()
-
-	for {
-		select {
-		case relationships := <-s.batchUpdateChan:
-			batch = append(batch, relationships...)
-
-			// Process batch if it reaches 100 items
-			if len(batch) >= 100 {
-				s.processBatchNeo4jUpdates(batch)
-				batch = nil
-			}
-
-		case <-ticker.C:
-			// Process any pending batch
-			if len(batch) > 0 {
-				s.processBatchNeo4jUpdates(batch)
-				batch = nil
-			}
-
-		case <-s.stopChan:
-			// Process final batch before stopping
-			if len(batch) > 0 {
-				s.processBatchNeo4jUpdates(batch)
-			}
-			return
-		}
-	}
-}
-
 // periodicSyncWorker runs periodic synchronization tasks
 func (s *UserInteractionService) periodicSyncWorker() {
 	defer s.wg.Done()
@@ -463,14 +475,27 @@ func (s *UserInteractionService) periodicSyncWorker() {
 	for {
 		select {
 		case <-ticker.C:
+			ctx := context.Background()
+
 			// Update user similarities
-			if err := s.updateUserSimilarities(context.Background()); err != nil {
+			if count, err := s.graph.UpdateUserSimilarities(ctx); err != nil {
 				s.logger.WithError(err).Error("Failed to update user similarities")
+			} else {
+				s.logger.WithField("similarities_created", count).Info("Updated user similarities")
 			}
 
 			// Update content similarities
-			if err := s.updateContentSimilarities(context.Background()); err != nil {
+			if count, err := s.graph.UpdateContentSimilarities(ctx); err != nil {
 				s.logger.WithError(err).Error("Failed to update content similarities")
+			} else {
+				s.logger.WithField("similarities_created", count).Info("Updated content similarities")
+			}
+
+			// Group similar users into communities for graph signal analysis
+			if count, err := s.graph.UpdateCommunities(ctx); err != nil {
+				s.logger.WithError(err).Error("Failed to update user communities")
+			} else {
+				s.logger.WithField("communities", count).Info("Updated user communities")
 			}
 
 		case <-s.stopChan:
@@ -500,6 +525,8 @@ func (s *UserInteractionService) updateUserProfile(ctx context.Context, userID u
 	var weightedEmbeddings [][]float32
 	var weights []float64
 	var totalWeight float64
+	var points []interestPoint
+	var negativeVector []float32
 	categoryPrefs := make(map[string]float64)
 	behaviorPatterns := make(map[string]interface{})
 
@@ -537,11 +564,39 @@ func (s *UserInteractionService) updateUserProfile(ctx context.Context, userID u
 			weightedEmbeddings = append(weightedEmbeddings, embedding)
 			weights = append(weights, weight)
 			totalWeight += weight
+			points = append(points, interestPoint{Embedding: embedding, Weight: weight, Categories: categories})
 
 			// Update category preferences
 			for _, category := range categories {
 				categoryPrefs[category] += weight
 			}
+		} else if weight < -0.01 { // Dislikes push towards the negative vector
+			if negativeVector == nil {
+				negativeVector = make([]float32, len(embedding))
+			}
+			if len(embedding) == len(negativeVector) {
+				for j, val := range embedding {
+					negativeVector[j] += val * float32(-weight)
+				}
+			}
+		}
+	}
+	if negativeVector != nil {
+		s.normalizeVector(negativeVector)
+	}
+
+	// Items picked during onboarding count like interactions from that time
+	onboardingPoints, err := s.onboardingPoints(ctx, userID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Warn("Failed to load onboarding picks")
+	}
+	for _, point := range onboardingPoints {
+		weightedEmbeddings = append(weightedEmbeddings, point.Embedding)
+		weights = append(weights, point.Weight)
+		totalWeight += point.Weight
+		points = append(points, point)
+		for _, category := range point.Categories {
+			categoryPrefs[category] += point.Weight
 		}
 	}
 
@@ -564,6 +619,9 @@ func (s *UserInteractionService) updateUserProfile(ctx context.Context, userID u
 		preferenceVector = make([]float32, 768)
 	}
 
+	// Cluster the same items into interest vectors
+	interests := clusterInterests(points, s.config.Algorithms.Interests)
+
 	// Calculate behavior patterns
 	behaviorPatterns["interaction_frequency"] = s.calculateInteractionFrequency(ctx, userID)
 	behaviorPatterns["time_patterns"] = s.calculateTimePatterns(ctx, userID)
@@ -571,7 +629,62 @@ func (s *UserInteractionService) updateUserProfile(ctx context.Context, userID u
 	behaviorPatterns["category_preferences"] = categoryPrefs
 
 	// Update user profile in PostgreSQL
-	return s.updateUserProfileInDB(ctx, userID, preferenceVector, behaviorPatterns, interactionCount, lastInteraction)
+	return s.updateUserProfileInDB(ctx, userID, preferenceVector, negativeVector, interests, behaviorPatterns, interactionCount, lastInteraction)
+}
+
+// onboardingPoints returns the items the user picked during onboarding,
+// weighted like interactions made when onboarding completed
+func (s *UserInteractionService) onboardingPoints(ctx context.Context, userID uuid.UUID) ([]interestPoint, error) {
+	var prefsJSON []byte
+	err := s.db.PG.QueryRow(ctx, `SELECT explicit_preferences FROM user_profiles WHERE user_id = $1`, userID).Scan(&prefsJSON)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query explicit preferences: %w", err)
+	}
+	stored := make(map[string]interface{})
+	if len(prefsJSON) > 0 {
+		json.Unmarshal(prefsJSON, &stored)
+	}
+	prefs := ExplicitPreferencesOf(&models.UserProfile{ExplicitPrefs: stored})
+	if len(prefs.OnboardingItems) == 0 || prefs.OnboardedAt == nil {
+		return nil, nil
+	}
+
+	weight := onboardingSelectionWeight(s.config.Algorithms.Onboarding.SelectionWeight, *prefs.OnboardedAt, time.Now())
+	if weight <= 0.01 {
+		return nil, nil
+	}
+
+	rows, err := s.db.PG.Query(ctx, `
+		SELECT embedding, COALESCE(categories, '{}')
+		FROM content_items
+		WHERE id = ANY($1) AND embedding IS NOT NULL`, prefs.OnboardingItems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query onboarding items: %w", err)
+	}
+	defer rows.Close()
+
+	var points []interestPoint
+	for rows.Next() {
+		point := interestPoint{Weight: weight}
+		if err := rows.Scan(&point.Embedding, &point.Categories); err != nil {
+			continue
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// onboardingSelectionWeight decays the weight of an onboarding pick with the
+// 30-day half-life of interactions, so real interactions take over
+func onboardingSelectionWeight(weight float64, onboardedAt, now time.Time) float64 {
+	if weight <= 0 {
+		weight = 0.8 // Weighs like a like
+	}
+	daysSince := math.Max(now.Sub(onboardedAt).Hours()/24, 0)
+	return weight * math.Exp(-daysSince*math.Ln2/30)
 }
 
 // getInteractionWeight calculates weight for different interaction types
@@ -586,8 +699,12 @@ func (s *UserInteractionService) getInteractionWeight(interactionType string, va
 		return 0.8
 	case "dislike":
 		return -0.8 // Negative weight for dislikes
+	case "not_interested":
+		return -1.0
 	case "share":
 		return 0.9
+	case "purchase":
+		return 1.0
 	case "click":
 		return 0.6
 	case "view":
@@ -734,26 +851,56 @@ func (s *UserInteractionService) calculateAvgSessionDuration(ctx context.Context
 	return 0
 }
 
-// updateUserProfileInDB updates user profile in PostgreSQL
-func (s *UserInteractionService) updateUserProfileInDB(ctx context.Context, userID uuid.UUID, preferenceVector []float32, behaviorPatterns map[string]interface{}, interactionCount int, lastInteraction time.Time) error {
+// updateUserProfileInDB updates user profile and replaces its interests in PostgreSQL
+func (s *UserInteractionService) updateUserProfileInDB(ctx context.Context, userID uuid.UUID, preferenceVector, negativeVector []float32, interests []models.InterestVector, behaviorPatterns map[string]interface{}, interactionCount int, lastInteraction time.Time) error {
 	behaviorJSON, _ := json.Marshal(behaviorPatterns)
 
+	tx, err := s.db.PG.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		INSERT INTO user_profiles (user_id, preference_vector, behavior_patterns, interaction_count, last_interaction, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		INSERT INTO user_profiles (user_id, preference_vector, negative_vector, behavior_patterns, interaction_count, last_interaction, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
 		ON CONFLICT (user_id) 
 		DO UPDATE SET 
 			preference_vector = EXCLUDED.preference_vector,
+			negative_vector = EXCLUDED.negative_vector,
 			behavior_patterns = EXCLUDED.behavior_patterns,
 			interaction_count = EXCLUDED.interaction_count,
 			last_interaction = EXCLUDED.last_interaction,
 			updated_at = NOW()`
 
-	_, err := s.db.PG.Exec(ctx, query, userID, preferenceVector, behaviorJSON, interactionCount, lastInteraction)
+	// Profiles built from onboarding picks alone have no interaction yet
+	var lastInteractionAt *time.Time
+	if !lastInteraction.IsZero() {
+		lastInteractionAt = &lastInteraction
+	}
+
+	_, err = tx.Exec(ctx, query, userID, preferenceVector, negativeVector, behaviorJSON, interactionCount, lastInteractionAt)
 	if err != nil {
 		return fmt.Errorf("failed to update user profile: %w", err)
 	}
 
+	if _, err := tx.Exec(ctx, `DELETE FROM user_interests WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear user interests: %w", err)
+	}
+	for position, interest := range interests {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO user_interests (user_id, position, vector, weight, item_count, categories, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NOW())`,
+			userID, position, interest.Vector, interest.Weight, interest.ItemCount, interest.Categories)
+		if err != nil {
+			return fmt.Errorf("failed to store user interest: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit user profile: %w", err)
+	}
+
 	// Invalidate cache
 	cacheKey := fmt.Sprintf("user_profile:%s", userID.String())
 	s.db.Redis.Hot.Del(ctx, cacheKey)
@@ -761,6 +908,7 @@ func (s *UserInteractionService) updateUserProfileInDB(ctx context.Context, user
 	s.logger.WithFields(logrus.Fields{
 		"user_id":           userID,
 		"interaction_count": interactionCount,
+		"interests":         len(interests),
 		"vector_norm":       s.calculateVectorNorm(preferenceVector),
 	}).Debug("Updated user profile")
 
@@ -776,162 +924,21 @@ func (s *UserInteractionService) calculateVectorNorm(vector []float32) float64 {
 	return math.Sqrt(sum)
 }
 
-// processBatchNeo4jUpdates processes a batch of Neo4j relationship updates
-func (s *UserInteractionService) processBatchNeo4jUpdates(batch []Neo4jRelationship) {
-	ctx := context.Background()
-	session := s.db.Neo4j.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	defer session.Close(ctx)
-
-	// Create relationships in batch
-	cypher := `
-		UNWIND $relationships AS rel
-		MERGE (u:User {id: rel.user_id})
-		MERGE (c:Content {id: rel.item_id})
-		MERGE (u)-[r:` + "`" + `rel.type` + "`" + `]->(c)
-		SET r += rel.properties
-		SET r.updated_at = datetime()`
-
-	relationships := make([]map[string]interface{}, len(batch))
-	for i, rel := range batch {
-		relationships[i] = map[string]interface{}{
-			"user_id":    rel.UserID.String(),
-			"item_id":    rel.ItemID.String(),
-			"type":       rel.Type,
-			"properties": rel.Properties,
-		}
-	}
-
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		result, err := tx.Run(ctx, cypher, map[string]interface{}{
-			"relationships": relationships,
-		})
-		if err != nil {
-			return nil, err
-		}
-
-		summary, err := result.Consume(ctx)
-		if err != nil {
-			return nil, err
-		}
-
-		return summary.Counters(), nil
-	})
-
-	if err != nil {
-		s.logger.WithError(err).WithField("batch_size", len(batch)).Error("Failed to process Neo4j batch update")
-	} else {
-		s.logger.WithField("batch_size", len(batch)).Debug("Processed Neo4j batch update")
-	}
-}
-
-// updateUserSimilarities calculates and stores user similarity relationships
-func (s *UserInteractionService) updateUserSimilarities(ctx context.Context) error {
-	session := s.db.Neo4j.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	defer session.Close(ctx)
-
-	// Calculate user similarities based on shared ratings (Pearson correlation)
-	cypher := `
-		MATCH (u1:User)-[r1:RATED]->(item:Content)<-[r2:RATED]-(u2:User)
-		WHERE u1.id < u2.id AND r1.rating IS NOT NULL AND r2.rating IS NOT NULL
-		WITH u1, u2, 
-			 COUNT(item) as shared_items,
-			 COLLECT({r1: r1.rating, r2: r2.rating}) as ratings
-		WHERE shared_items >= 3
-		WITH u1, u2, shared_items,
-			 REDUCE(sum = 0.0, rating IN ratings | sum + rating.r1 * rating.r2) as dot_product,
-			 REDUCE(sum1 = 0.0, rating IN ratings | sum1 + rating.r1 * rating.r1) as sum_sq1,
-			 REDUCE(sum2 = 0.0, rating IN ratings | sum2 + rating.r2 * rating.r2) as sum_sq2,
-			 REDUCE(sum1 = 0.0, rating IN ratings | sum1 + rating.r1) as sum1,
-			 REDUCE(sum2 = 0.0, rating IN ratings | sum2 + rating.r2) as sum2
-		WITH u1, u2, shared_items,
-			 (dot_product - (sum1 * sum2 / shared_items)) / 
-			 SQRT((sum_sq1 - (sum1 * sum1 / shared_items)) * (sum_sq2 - (sum2 * sum2 / shared_items))) as correlation
-		WHERE correlation > 0.5
-		MERGE (u1)-[s:SIMILAR_TO]-(u2)
-		SET s.score = correlation,
-			s.basis = 'collaborative_filtering',
-			s.shared_items = shared_items,
-			s.computed_at = datetime()
-		RETURN COUNT(s) as similarities_created`
-
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		result, err := tx.Run(ctx, cypher, nil)
-		if err != nil {
-			return nil, err
-		}
-
-		record, err := result.Single(ctx)
-		if err != nil {
-			return nil, err
-		}
-
-		count, _ := record.Get("similarities_created")
-		s.logger.WithField("similarities_created", count).Info("Updated user similarities")
-		return count, nil
-	})
-
-	return err
-}
-
-// updateContentSimilarities calculates and stores content similarity relationships
-func (s *UserInteractionService) updateContentSimilarities(ctx context.Context) error {
-	session := s.db.Neo4j.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	defer session.Close(ctx)
-
-	// Calculate content similarities based on users who interacted with both (Jaccard similarity)
-	cypher := `
-		MATCH (c1:Content)<-[:RATED|VIEWED|INTERACTED_WITH]-(user:User)-[:RATED|VIEWED|INTERACTED_WITH]->(c2:Content)
-		WHERE c1.id < c2.id
-		WITH c1, c2, COUNT(DISTINCT user) as shared_users
-		WHERE shared_users >= 3
-		MATCH (c1)<-[:RATED|VIEWED|INTERACTED_WITH]-(u1:User)
-		WITH c1, c2, shared_users, COUNT(DISTINCT u1) as users_c1
-		MATCH (c2)<-[:RATED|VIEWED|INTERACTED_WITH]-(u2:User)
-		WITH c1, c2, shared_users, users_c1, COUNT(DISTINCT u2) as users_c2
-		WITH c1, c2, shared_users, users_c1, users_c2,
-			 toFloat(shared_users) / (users_c1 + users_c2 - shared_users) as jaccard_similarity
-		WHERE jaccard_similarity > 0.1
-		MERGE (c1)-[s:SIMILAR_TO]-(c2)
-		SET s.score = jaccard_similarity,
-			s.algorithm = 'jaccard_similarity',
-			s.shared_users = shared_users,
-			s.computed_at = datetime()
-		RETURN COUNT(s) as similarities_created`
-
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		result, err := tx.Run(ctx, cypher, nil)
-		if err != nil {
-			return nil, err
-		}
-
-		record, err := result.Single(ctx)
-		if err != nil {
-			return nil, err
-		}
-
-		count, _ := record.Get("similarities_created")
-		s.logger.WithField("similarities_created", count).Info("Updated content similarities")
-		return count, nil
-	})
-
-	return err
-}
-
 // GetUserProfile retrieves user profile with caching
 func (s *UserInteractionService) GetUserProfile(ctx context.Context, userID uuid.UUID) (*models.UserProfile, error) {
 	// Try cache first
 	cacheKey := fmt.Sprintf("user_profile:%s", userID.String())
 	cached, err := s.db.Redis.Hot.Get(ctx, cacheKey).Result()
 	if err == nil {
-		var profile models.UserProfile
-		if json.Unmarshal([]byte(cached), &profile) == nil {
-			return &profile, nil
+		var entry profileCacheEntry
+		if json.Unmarshal([]byte(cached), &entry) == nil && entry.Profile != nil {
+			return entry.restore(), nil
 		}
 	}
 
 	// Query from database
 	query := `
-		SELECT user_id, preference_vector, explicit_preferences, behavior_patterns, 
+		SELECT user_id, preference_vector, negative_vector, explicit_preferences, behavior_patterns, 
 			   demographics, interaction_count, last_interaction, created_at, updated_at
 		FROM user_profiles 
 		WHERE user_id = $1`
@@ -942,6 +949,7 @@ func (s *UserInteractionService) GetUserProfile(ctx context.Context, userID uuid
 	err = s.db.PG.QueryRow(ctx, query, userID).Scan(
 		&profile.UserID,
 		&profile.PreferenceVector,
+		&profile.NegativeVector,
 		&explicitPrefsJSON,
 		&behaviorPatternsJSON,
 		&demographicsJSON,
@@ -983,15 +991,156 @@ func (s *UserInteractionService) GetUserProfile(ctx context.Context, userID uuid
 		if len(demographicsJSON) > 0 {
 			json.Unmarshal(demographicsJSON, &profile.Demographics)
 		}
+
+		interests, err := s.getUserInterests(ctx, userID)
+		if err != nil {
+			s.logger.WithError(err).WithField("user_id", userID).Warn("Failed to load user interests")
+		}
+		profile.Interests = interests
 	}
 
 	// Cache the profile
-	profileJSON, _ := json.Marshal(profile)
+	profileJSON, _ := json.Marshal(newProfileCacheEntry(&profile))
 	s.db.Redis.Hot.Set(ctx, cacheKey, profileJSON, time.Hour)
 
 	return &profile, nil
 }
 
+// UpdateExplicitPreferences merges a profile update into the user's explicit
+// preferences, creating the profile for new users, and drops the cached
+// profile and recommendations so the next request uses them
+func (s *UserInteractionService) UpdateExplicitPreferences(ctx context.Context, userID uuid.UUID, req *models.ProfileUpdateRequest) (*models.UserProfile, error) {
+	err := s.editExplicitPreferences(ctx, userID, func(stored map[string]interface{}) map[string]interface{} {
+		return mergeExplicitPreferences(stored, req, s.taxonomy.Current(), time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateRecommendations(ctx, userID)
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"onboarding": req.Onboarding,
+	}).Info("Updated explicit preferences")
+
+	return s.GetUserProfile(ctx, userID)
+}
+
+// editExplicitPreferences replaces the user's explicit preferences with the
+// result of edit under a row lock, creating the profile for new users, and
+// drops the cached profile
+func (s *UserInteractionService) editExplicitPreferences(
+	ctx context.Context,
+	userID uuid.UUID,
+	edit func(stored map[string]interface{}) map[string]interface{},
+) error {
+	// Creates the profile of a new user
+	if _, err := s.GetUserProfile(ctx, userID); err != nil {
+		return err
+	}
+
+	tx, err := s.db.PG.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var storedJSON []byte
+	err = tx.QueryRow(ctx, `
+		SELECT explicit_preferences FROM user_profiles WHERE user_id = $1 FOR UPDATE`, userID).Scan(&storedJSON)
+	if err != nil {
+		return fmt.Errorf("failed to lock user profile: %w", err)
+	}
+	stored := make(map[string]interface{})
+	if len(storedJSON) > 0 {
+		json.Unmarshal(storedJSON, &stored)
+	}
+
+	prefsJSON, err := json.Marshal(edit(stored))
+	if err != nil {
+		return fmt.Errorf("failed to encode explicit preferences: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE user_profiles SET explicit_preferences = $2, updated_at = NOW() WHERE user_id = $1`,
+		userID, prefsJSON); err != nil {
+		return fmt.Errorf("failed to update explicit preferences: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit explicit preferences: %w", err)
+	}
+
+	s.db.Redis.Hot.Del(ctx, fmt.Sprintf("user_profile:%s", userID.String()))
+	return nil
+}
+
+// invalidateRecommendations drops the user's cached recommendations
+func (s *UserInteractionService) invalidateRecommendations(ctx context.Context, userID uuid.UUID) {
+	keys, err := s.db.Redis.Warm.Keys(ctx, fmt.Sprintf("orchestration:%s:*", userID.String())).Result()
+	if err == nil && len(keys) > 0 {
+		err = s.db.Redis.Warm.Del(ctx, keys...).Err()
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Warn("Failed to invalidate recommendations")
+	}
+}
+
+// getUserInterests loads the interest vectors of a user, strongest first
+func (s *UserInteractionService) getUserInterests(ctx context.Context, userID uuid.UUID) ([]models.InterestVector, error) {
+	rows, err := s.db.PG.Query(ctx, `
+		SELECT vector, weight, item_count, categories
+		FROM user_interests
+		WHERE user_id = $1
+		ORDER BY position`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user interests: %w", err)
+	}
+	defer rows.Close()
+
+	var interests []models.InterestVector
+	for rows.Next() {
+		var interest models.InterestVector
+		if err := rows.Scan(&interest.Vector, &interest.Weight, &interest.ItemCount, &interest.Categories); err != nil {
+			return nil, fmt.Errorf("failed to scan user interest: %w", err)
+		}
+		interests = append(interests, interest)
+	}
+	return interests, rows.Err()
+}
+
+// profileCacheEntry is the cached form of a user profile. The profile's
+// vectors are left out of its API representation, so they are cached beside it.
+type profileCacheEntry struct {
+	Profile          *models.UserProfile `json:"profile"`
+	PreferenceVector []float32           `json:"preference_vector"`
+	NegativeVector   []float32           `json:"negative_vector,omitempty"`
+	InterestVectors  [][]float32         `json:"interest_vectors,omitempty"`
+}
+
+func newProfileCacheEntry(profile *models.UserProfile) profileCacheEntry {
+	entry := profileCacheEntry{
+		Profile:          profile,
+		PreferenceVector: profile.PreferenceVector,
+		NegativeVector:   profile.NegativeVector,
+	}
+	for _, interest := range profile.Interests {
+		entry.InterestVectors = append(entry.InterestVectors, interest.Vector)
+	}
+	return entry
+}
+
+// restore returns the cached profile with its vectors
+func (e profileCacheEntry) restore() *models.UserProfile {
+	profile := e.Profile
+	profile.PreferenceVector = e.PreferenceVector
+	profile.NegativeVector = e.NegativeVector
+	for i := range profile.Interests {
+		if i < len(e.InterestVectors) {
+			profile.Interests[i].Vector = e.InterestVectors[i]
+		}
+	}
+	return profile
+}
+
 // createUserProfile creates a new user profile
 func (s *UserInteractionService) createUserProfile(ctx context.Context, profile *models.UserProfile) error {
 	explicitPrefsJSON, _ := json.Marshal(profile.ExplicitPrefs)
@@ -1018,50 +1167,11 @@ func (s *UserInteractionService) createUserProfile(ctx context.Context, profile
 
 // GetSimilarUsers finds similar users using Neo4j
 func (s *UserInteractionService) GetSimilarUsers(ctx context.Context, userID uuid.UUID, limit int) ([]models.SimilarUser, error) {
-	session := s.db.Neo4j.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
-	defer session.Close(ctx)
-
-	cypher := `
-		MATCH (u:User {id: $user_id})-[s:SIMILAR_TO]-(similar:User)
-		RETURN similar.id as user_id, s.score as similarity_score, s.basis as basis, s.shared_items as shared_items
-		ORDER BY s.score DESC
-		LIMIT $limit`
-
-	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		result, err := tx.Run(ctx, cypher, map[string]interface{}{
-			"user_id": userID.String(),
-			"limit":   limit,
-		})
-		if err != nil {
-			return nil, err
-		}
-
-		var similarUsers []models.SimilarUser
-		for result.Next(ctx) {
-			record := result.Record()
-			userIDStr, _ := record.Get("user_id")
-			score, _ := record.Get("similarity_score")
-			basis, _ := record.Get("basis")
-			sharedItems, _ := record.Get("shared_items")
-
-			if userUUID, err := uuid.Parse(userIDStr.(string)); err == nil {
-				similarUsers = append(similarUsers, models.SimilarUser{
-					UserID:          userUUID,
-					SimilarityScore: score.(float64),
-					Basis:           basis.(string),
-					SharedItems:     int(sharedItems.(int64)),
-				})
-			}
-		}
-
-		return similarUsers, result.Err()
-	})
-
+	similarUsers, err := s.graph.StoredSimilarUsers(ctx, userID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get similar users: %w", err)
 	}
-
-	return result.([]models.SimilarUser), nil
+	return similarUsers, nil
 }
 
 // ValidateDataConsistency checks consistency between PostgreSQL and Neo4j
@@ -1075,8 +1185,6 @@ func (s *UserInteractionService) ValidateDataConsistency(ctx context.Context) er
 	defer rows.Close()
 
 	var inconsistencies []string
-	session := s.db.Neo4j.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
-	defer session.Close(ctx)
 
 	for rows.Next() {
 		var userID uuid.UUID
@@ -1085,25 +1193,8 @@ func (s *UserInteractionService) ValidateDataConsistency(ctx context.Context) er
 		}
 
 		// Check if user exists in Neo4j
-		cypher := `MATCH (u:User {id: $user_id}) RETURN COUNT(u) as count`
-		result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-			result, err := tx.Run(ctx, cypher, map[string]interface{}{
-				"user_id": userID.String(),
-			})
-			if err != nil {
-				return 0, err
-			}
-
-			record, err := result.Single(ctx)
-			if err != nil {
-				return 0, err
-			}
-
-			count, _ := record.Get("count")
-			return count.(int64), nil
-		})
-
-		if err != nil || result.(int64) == 0 {
+		exists, err := s.graph.UserExists(ctx, userID)
+		if err != nil || !exists {
 			inconsistencies = append(inconsistencies, fmt.Sprintf("User %s missing in Neo4j", userID))
 		}
 	}
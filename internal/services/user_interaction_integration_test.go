@@ -25,7 +25,6 @@ func TestUserInteractionService_IntegrationWorkflow(t *testing.T) {
 		service := &UserInteractionService{
 			logger:            logger,
 			profileUpdateChan: make(chan uuid.UUID, 100),
-			batchUpdateChan:   make(chan []Neo4jRelationship, 100),
 			stopChan:          make(chan struct{}),
 		}
 
@@ -88,14 +87,14 @@ func TestUserInteractionService_IntegrationWorkflow(t *testing.T) {
 		newChannelSize := len(service.profileUpdateChan)
 		assert.Equal(t, initialChannelSize+1, newChannelSize, "Profile update should be queued")
 
-		// Test Neo4j relationship queuing
-		initialBatchSize := len(service.batchUpdateChan)
-		service.queueNeo4jUpdate(explicitInteraction)
-		// Note: queueNeo4jUpdate requires ItemID, so we need to set it
+		// Test Neo4j relationship mirroring; interactions need an item
+		assert.False(t, mirroredToGraph(explicitInteraction), "Interactions without an item should not be mirrored")
+		explicitInteraction.UserID = userID
 		explicitInteraction.ItemID = &itemID
-		service.queueNeo4jUpdate(explicitInteraction)
-		newBatchSize := len(service.batchUpdateChan)
-		assert.Equal(t, initialBatchSize+1, newBatchSize, "Neo4j update should be queued")
+		assert.True(t, mirroredToGraph(explicitInteraction), "Ratings should be mirrored")
+		relationship := graphInteraction(explicitInteraction)
+		assert.Equal(t, "RATED", relationship.Type)
+		assert.Equal(t, 4.5, relationship.Properties["rating"], "Rating should be carried to the graph")
 	})
 
 	// Test vector operations
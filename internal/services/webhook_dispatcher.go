@@ -0,0 +1,321 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/temcen/pirex/internal/config"
+	"github.com/temcen/pirex/internal/media"
+	"github.com/temcen/pirex/pkg/models"
+)
+
+// Webhook request headers
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+
+	// jobDetailWebhookURL holds a job's own callback URL
+	jobDetailWebhookURL = "webhook_url"
+)
+
+var ErrInvalidWebhookURL = errors.New("callback URL must be an absolute http or https URL on a public host")
+
+// WebhookEvent is the JSON body posted to webhook receivers
+type WebhookEvent struct {
+	ID             uuid.UUID               `json:"id"`
+	Event          string                  `json:"event"` // job.completed, job.failed or job.cancelled
+	CreatedAt      time.Time               `json:"created_at"`
+	Job            models.ContentJobStatus `json:"job"`
+	ItemsTruncated bool                    `json:"items_truncated,omitempty"`
+}
+
+// WebhookDispatcher posts HMAC-signed job events when jobs finish. Failed
+// deliveries are retried with exponential backoff up to max_attempts.
+type WebhookDispatcher struct {
+	jobManager *JobManager
+	config     *config.WebhookConfig
+	client     *http.Client
+	logger     *logrus.Logger
+
+	queue chan *webhookDelivery
+	quit  chan struct{}
+	wg    sync.WaitGroup
+}
+
+type webhookDelivery struct {
+	job      *JobProgress
+	url      string
+	event    *WebhookEvent
+	body     []byte
+	attempts int
+}
+
+func NewWebhookDispatcher(jobManager *JobManager, cfg *config.WebhookConfig, logger *logrus.Logger) *WebhookDispatcher {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	// Callback URLs come from clients, so receivers must be public addresses
+	client := media.NewPublicClient(timeout, 0, cfg.AllowPrivateNetworks)
+	// Receivers must answer directly; a redirect is treated as a failure
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &WebhookDispatcher{
+		jobManager: jobManager,
+		config:     cfg,
+		client:     client,
+		logger:     logger,
+		queue:      make(chan *webhookDelivery, 1000),
+		quit:       make(chan struct{}),
+	}
+}
+
+// Start launches the delivery workers and subscribes to finished jobs
+func (wd *WebhookDispatcher) Start(ctx context.Context) {
+	if !wd.config.Enabled {
+		wd.logger.Info("Job webhooks disabled")
+		return
+	}
+	if wd.config.Secret == "" {
+		wd.logger.Error("Job webhooks disabled: ingestion.webhooks.secret is required to sign events")
+		return
+	}
+
+	workers := wd.config.Workers
+	if workers <= 0 {
+		workers = 2
+	}
+	for i := 0; i < workers; i++ {
+		wd.wg.Add(1)
+		go wd.worker(ctx)
+	}
+
+	wd.jobManager.OnJobFinished(wd.Notify)
+
+	wd.logger.WithField("workers", workers).Info("Job webhooks started")
+}
+
+func (wd *WebhookDispatcher) Stop() {
+	close(wd.quit)
+	wd.wg.Wait()
+}
+
+// Notify queues the finished event of a job for every configured receiver and the
+// job's own callback URL. It never blocks; events are dropped if the queue is full.
+func (wd *WebhookDispatcher) Notify(job *JobProgress) {
+	urls := append([]string(nil), wd.config.URLs...)
+	if callbackURL, ok := job.Details[jobDetailWebhookURL].(string); ok && callbackURL != "" {
+		urls = append(urls, callbackURL)
+	}
+
+	snapshot := *job
+	for _, target := range urls {
+		wd.enqueue(&webhookDelivery{job: &snapshot, url: target})
+	}
+}
+
+func (wd *WebhookDispatcher) enqueue(delivery *webhookDelivery) {
+	select {
+	case wd.queue <- delivery:
+	case <-wd.quit:
+	default:
+		wd.logger.WithFields(logrus.Fields{
+			"job_id": delivery.job.JobID,
+			"url":    delivery.url,
+		}).Error("Webhook queue full, dropping delivery")
+	}
+}
+
+func (wd *WebhookDispatcher) worker(ctx context.Context) {
+	defer wd.wg.Done()
+
+	for {
+		select {
+		case delivery := <-wd.queue:
+			wd.deliver(ctx, delivery)
+		case <-wd.quit:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (wd *WebhookDispatcher) deliver(ctx context.Context, delivery *webhookDelivery) {
+	logger := wd.logger.WithFields(logrus.Fields{
+		"job_id": delivery.job.JobID,
+		"url":    delivery.url,
+	})
+
+	// The body is built once so every attempt carries the same event ID
+	if delivery.body == nil {
+		event, err := wd.buildEvent(ctx, delivery.job)
+		if err != nil {
+			logger.WithError(err).Error("Failed to build webhook event")
+			return
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			logger.WithError(err).Error("Failed to marshal webhook event")
+			return
+		}
+		delivery.event = event
+		delivery.body = body
+	}
+
+	delivery.attempts++
+	retryable, err := wd.post(ctx, delivery)
+
+	state := map[string]interface{}{
+		"event":        delivery.event.ID,
+		"attempts":     delivery.attempts,
+		"last_sent_at": time.Now(),
+	}
+
+	switch {
+	case err == nil:
+		state["status"] = "delivered"
+		logger.WithField("attempts", delivery.attempts).Info("Webhook delivered")
+	case retryable && delivery.attempts < wd.maxAttempts():
+		delay := webhookBackoff(delivery.attempts, wd.config.InitialBackoff, wd.config.MaxBackoff)
+		state["status"] = "retrying"
+		state["last_error"] = err.Error()
+		state["next_attempt_at"] = time.Now().Add(delay)
+		logger.WithError(err).WithField("retry_in", delay).Warn("Webhook delivery failed, retrying")
+
+		time.AfterFunc(delay, func() { wd.enqueue(delivery) })
+	default:
+		state["status"] = "failed"
+		state["last_error"] = err.Error()
+		logger.WithError(err).WithField("attempts", delivery.attempts).Error("Webhook delivery failed")
+	}
+
+	if err := wd.jobManager.RecordWebhookDelivery(ctx, delivery.job.JobID, delivery.url, state); err != nil {
+		logger.WithError(err).Warn("Failed to record webhook delivery")
+	}
+}
+
+// post sends one attempt. Network errors, timeouts, 408, 429 and 5xx responses
+// are retryable; other non-2xx responses are not.
+func (wd *WebhookDispatcher) post(ctx context.Context, delivery *webhookDelivery) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.url, bytes.NewReader(delivery.body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.event.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.event.ID.String())
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(wd.config.Secret, timestamp, delivery.body))
+
+	resp, err := wd.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return true, fmt.Errorf("receiver returned status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("receiver returned status %d", resp.StatusCode)
+	}
+}
+
+// buildEvent loads the job's item outcomes, capped at max_payload_items
+func (wd *WebhookDispatcher) buildEvent(ctx context.Context, job *JobProgress) (*WebhookEvent, error) {
+	outcomes, err := wd.jobManager.GetItemOutcomes(ctx, job.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := job.ToStatus()
+	total := len(outcomes)
+	status.ItemsTotal = &total
+
+	truncated := false
+	if limit := wd.config.MaxPayloadItems; limit > 0 && len(outcomes) > limit {
+		outcomes = outcomes[:limit]
+		truncated = true
+	}
+	status.Items = outcomes
+
+	return &WebhookEvent{
+		ID:             uuid.New(),
+		Event:          "job." + job.Status,
+		CreatedAt:      time.Now(),
+		Job:            status,
+		ItemsTruncated: truncated,
+	}, nil
+}
+
+func (wd *WebhookDispatcher) maxAttempts() int {
+	if wd.config.MaxAttempts > 0 {
+		return wd.config.MaxAttempts
+	}
+	return 6
+}
+
+// SignWebhookPayload returns the hex HMAC-SHA256 of "<timestamp>.<body>". Receivers
+// recompute it with the shared secret and should reject stale timestamps.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateWebhookURL checks a client-supplied callback URL. Hostnames that
+// resolve to internal addresses are refused when the event is posted.
+func ValidateWebhookURL(raw string) error {
+	if _, err := media.ValidatePublicURL(raw, false); err != nil {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// webhookBackoff doubles the delay per attempt from initial up to max, with up to
+// 20% jitter so receivers recovering from an outage are not hit all at once
+func webhookBackoff(attempt int, initial, max time.Duration) time.Duration {
+	if initial <= 0 {
+		initial = 5 * time.Second
+	}
+	if max <= 0 {
+		max = 10 * time.Minute
+	}
+
+	delay := initial
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	return delay + jitter
+}
@@ -0,0 +1,216 @@
+package validation
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Content type validation error codes
+const (
+	CodeUnknownContentType = "UNKNOWN_CONTENT_TYPE"
+	CodeUnknownField       = "UNKNOWN_FIELD"
+	CodeRequired           = "REQUIRED"
+	CodeInvalidType        = "INVALID_TYPE"
+	CodeInvalidValue       = "INVALID_VALUE"
+)
+
+// defaultContentTypes holds the metadata schema of each built-in content type.
+// The file name without extension is the content type name.
+//
+//go:embed content-types/*.json
+var defaultContentTypes embed.FS
+
+var (
+	defaultContentTypeValidatorOnce sync.Once
+	defaultContentTypeValidator     *SchemaValidator
+)
+
+// DefaultContentTypes returns a validator with the built-in content types loaded
+func DefaultContentTypes() *SchemaValidator {
+	defaultContentTypeValidatorOnce.Do(func() {
+		sv := NewSchemaValidator()
+		if err := sv.LoadDefaultContentTypes(); err != nil {
+			panic(fmt.Sprintf("invalid built-in content type schemas: %v", err))
+		}
+		defaultContentTypeValidator = sv
+	})
+	return defaultContentTypeValidator
+}
+
+// LoadDefaultContentTypes loads the built-in content type schemas
+func (sv *SchemaValidator) LoadDefaultContentTypes() error {
+	return sv.LoadContentTypeSchemasFromFS(defaultContentTypes, "content-types")
+}
+
+// LoadContentTypeSchemas loads a metadata schema for every <type>.json file in
+// the directory, adding content types or replacing built-in ones
+func (sv *SchemaValidator) LoadContentTypeSchemas(schemaDir string) error {
+	return sv.LoadContentTypeSchemasFromFS(os.DirFS(schemaDir), ".")
+}
+
+// LoadContentTypeSchemasFromFS loads content type schemas from a filesystem
+func (sv *SchemaValidator) LoadContentTypeSchemasFromFS(fsys fs.FS, schemaDir string) error {
+	entries, err := fs.ReadDir(fsys, schemaDir)
+	if err != nil {
+		return fmt.Errorf("failed to read content type schemas: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		contentType := strings.ToLower(strings.TrimSuffix(entry.Name(), ".json"))
+
+		schemaBytes, err := fs.ReadFile(fsys, path.Join(schemaDir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read content type schema %s: %w", entry.Name(), err)
+		}
+
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schemaBytes))
+		if err != nil {
+			return fmt.Errorf("failed to load content type schema %s: %w", contentType, err)
+		}
+
+		sv.contentTypes[contentType] = schema
+	}
+
+	return nil
+}
+
+// ContentTypes returns the names of the loaded content types, sorted
+func (sv *SchemaValidator) ContentTypes() []string {
+	types := make([]string, 0, len(sv.contentTypes))
+	for name := range sv.contentTypes {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// HasContentType reports whether a content type has a schema
+func (sv *SchemaValidator) HasContentType(contentType string) bool {
+	_, exists := sv.contentTypes[contentType]
+	return exists
+}
+
+// ValidateMetadata validates content metadata against its content type schema.
+// Error fields are prefixed with "metadata.", e.g. "metadata.price"; properties
+// the schema does not allow are reported with CodeUnknownField.
+func (sv *SchemaValidator) ValidateMetadata(contentType string, metadata map[string]interface{}) *ValidationResult {
+	schema, exists := sv.contentTypes[contentType]
+	if !exists {
+		return &ValidationResult{
+			Valid: false,
+			Errors: []ValidationError{{
+				Field:   "type",
+				Message: fmt.Sprintf("Unknown content type %q, expected one of: %s", contentType, strings.Join(sv.ContentTypes(), ", ")),
+				Code:    CodeUnknownContentType,
+				Value:   contentType,
+			}},
+		}
+	}
+
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	jsonBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return &ValidationResult{
+			Valid: false,
+			Errors: []ValidationError{{
+				Field:   "metadata",
+				Message: fmt.Sprintf("Failed to marshal metadata to JSON: %v", err),
+				Code:    "JSON_MARSHAL_ERROR",
+			}},
+		}
+	}
+
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(jsonBytes))
+	if err != nil {
+		return &ValidationResult{
+			Valid: false,
+			Errors: []ValidationError{{
+				Field:   "metadata",
+				Message: fmt.Sprintf("Validation error: %v", err),
+				Code:    "VALIDATION_ERROR",
+			}},
+		}
+	}
+
+	validationResult := &ValidationResult{
+		Valid:  result.Valid(),
+		Errors: make([]ValidationError, 0),
+	}
+	for _, resultErr := range result.Errors() {
+		validationResult.Errors = append(validationResult.Errors, metadataError(resultErr))
+	}
+
+	// Sorted so the same document always reports errors in the same order
+	sort.SliceStable(validationResult.Errors, func(i, j int) bool {
+		return validationResult.Errors[i].Field < validationResult.Errors[j].Field
+	})
+
+	return validationResult
+}
+
+// metadataError converts a schema error into a field error on metadata
+func metadataError(resultErr gojsonschema.ResultError) ValidationError {
+	field := resultErr.Field()
+	value := resultErr.Value()
+
+	// Errors about a missing or extra property are reported on the object; name
+	// the property instead
+	if property, ok := resultErr.Details()["property"].(string); ok {
+		switch resultErr.Type() {
+		case "required", "additional_property_not_allowed":
+			if field == gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+				field = property
+			} else {
+				field += "." + property
+			}
+			value = nil
+		}
+	}
+
+	fieldName := "metadata"
+	if field != gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+		fieldName += "." + field
+	}
+
+	code := CodeInvalidValue
+	switch resultErr.Type() {
+	case "additional_property_not_allowed":
+		code = CodeUnknownField
+	case "required":
+		code = CodeRequired
+	case "invalid_type":
+		code = CodeInvalidType
+	}
+
+	return ValidationError{
+		Field:   fieldName,
+		Message: resultErr.Description(),
+		Code:    code,
+		Value:   value,
+	}
+}
+
+// MetadataKey returns the top-level metadata key of a field reported by
+// ValidateMetadata, or "" for errors on the metadata object itself
+func MetadataKey(field string) string {
+	key, found := strings.CutPrefix(field, "metadata.")
+	if !found {
+		return ""
+	}
+	key, _, _ = strings.Cut(key, ".")
+	return key
+}
@@ -0,0 +1,101 @@
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultContentTypes(t *testing.T) {
+	sv := DefaultContentTypes()
+	assert.Equal(t, []string{"article", "book", "course", "podcast", "product", "video"}, sv.ContentTypes())
+	assert.True(t, sv.HasContentType("podcast"))
+	assert.False(t, sv.HasContentType("invalid_type"))
+}
+
+func TestSchemaValidator_ValidateMetadata(t *testing.T) {
+	sv := DefaultContentTypes()
+
+	t.Run("valid", func(t *testing.T) {
+		result := sv.ValidateMetadata("product", map[string]interface{}{
+			"price":  999.99,
+			"brand":  "TechCorp",
+			"rating": 4.5,
+			"sku":    12345, // CSV imports turn numeric identifiers into numbers
+		})
+		assert.True(t, result.Valid)
+		assert.Empty(t, result.Errors)
+	})
+
+	t.Run("nil metadata", func(t *testing.T) {
+		assert.True(t, sv.ValidateMetadata("video", nil).Valid)
+	})
+
+	t.Run("per-field errors", func(t *testing.T) {
+		result := sv.ValidateMetadata("product", map[string]interface{}{
+			"price":         "cheap",
+			"rating":        7,
+			"invalid_field": true,
+		})
+		assert.False(t, result.Valid)
+		require.Len(t, result.Errors, 3)
+
+		assert.Equal(t, "metadata.invalid_field", result.Errors[0].Field)
+		assert.Equal(t, CodeUnknownField, result.Errors[0].Code)
+		assert.Equal(t, "metadata.price", result.Errors[1].Field)
+		assert.Equal(t, CodeInvalidType, result.Errors[1].Code)
+		assert.Equal(t, "metadata.rating", result.Errors[2].Field)
+		assert.Equal(t, CodeInvalidValue, result.Errors[2].Code)
+	})
+
+	t.Run("string or number", func(t *testing.T) {
+		assert.True(t, sv.ValidateMetadata("video", map[string]interface{}{"duration": "10:30"}).Valid)
+		assert.True(t, sv.ValidateMetadata("video", map[string]interface{}{"duration": 630}).Valid)
+		assert.False(t, sv.ValidateMetadata("video", map[string]interface{}{"duration": "ten minutes"}).Valid)
+	})
+
+	t.Run("unknown content type", func(t *testing.T) {
+		result := sv.ValidateMetadata("invalid_type", nil)
+		assert.False(t, result.Valid)
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, "type", result.Errors[0].Field)
+		assert.Equal(t, CodeUnknownContentType, result.Errors[0].Code)
+	})
+}
+
+func TestSchemaValidator_LoadContentTypeSchemas(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "recipe.json"), []byte(`{
+		"type": "object",
+		"properties": {"servings": {"type": "integer", "minimum": 1}},
+		"required": ["servings"],
+		"additionalProperties": false
+	}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0o644))
+
+	sv := NewSchemaValidator()
+	require.NoError(t, sv.LoadDefaultContentTypes())
+	require.NoError(t, sv.LoadContentTypeSchemas(dir))
+
+	assert.True(t, sv.HasContentType("recipe"))
+	assert.True(t, sv.HasContentType("product"))
+	assert.True(t, sv.ValidateMetadata("recipe", map[string]interface{}{"servings": 4}).Valid)
+
+	result := sv.ValidateMetadata("recipe", map[string]interface{}{})
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "metadata.servings", result.Errors[0].Field)
+	assert.Equal(t, CodeRequired, result.Errors[0].Code)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"type": 42}`), 0o644))
+	assert.Error(t, NewSchemaValidator().LoadContentTypeSchemas(dir))
+}
+
+func TestMetadataKey(t *testing.T) {
+	assert.Equal(t, "price", MetadataKey("metadata.price"))
+	assert.Equal(t, "tags", MetadataKey("metadata.tags.0"))
+	assert.Equal(t, "", MetadataKey("metadata"))
+	assert.Equal(t, "", MetadataKey("type"))
+}
@@ -11,13 +11,15 @@ import (
 
 // SchemaValidator handles JSON schema validation for API requests and responses
 type SchemaValidator struct {
-	schemas map[string]*gojsonschema.Schema
+	schemas      map[string]*gojsonschema.Schema
+	contentTypes map[string]*gojsonschema.Schema // Metadata schema per content type
 }
 
 // NewSchemaValidator creates a new schema validator instance
 func NewSchemaValidator() *SchemaValidator {
 	return &SchemaValidator{
-		schemas: make(map[string]*gojsonschema.Schema),
+		schemas:      make(map[string]*gojsonschema.Schema),
+		contentTypes: make(map[string]*gojsonschema.Schema),
 	}
 }
 
@@ -7,10 +7,14 @@ import (
 	"github.com/google/uuid"
 )
 
+// RoleAdmin is the role of operators allowed to act on any user's data
+const RoleAdmin = "admin"
+
 type JWTClaims struct {
 	UserID   uuid.UUID `json:"user_id"`
 	APIKey   string    `json:"api_key,omitempty"`
-	UserTier string    `json:"user_tier"` // free, premium, enterprise
+	UserTier string    `json:"user_tier"`      // free, premium, enterprise
+	Role     string    `json:"role,omitempty"` // admin for operators acting on any user
 	jwt.RegisteredClaims
 }
 
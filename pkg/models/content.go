@@ -8,41 +8,119 @@ import (
 
 type ContentItem struct {
 	ID           uuid.UUID              `json:"id" db:"id"`
-	Type         string                 `json:"type" db:"type" validate:"required,oneof=product video article"`
+	Type         string                 `json:"type" db:"type" validate:"required,min=1,max=50"` // A content type with a metadata schema
 	Title        string                 `json:"title" db:"title" validate:"required,min=1,max=255"`
 	Description  *string                `json:"description,omitempty" db:"description"`
 	ImageURLs    []string               `json:"image_urls,omitempty" db:"image_urls"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty" db:"metadata"`
 	Categories   []string               `json:"categories,omitempty" db:"categories"`
 	Embedding    []float32              `json:"-" db:"embedding"`
+	Language     string                 `json:"language,omitempty" db:"language"` // ISO 639-1 code detected at ingestion
+	ImageHashes  []ImageHash            `json:"image_hashes,omitempty" db:"-"`    // Stored in content_image_hashes
+	Markets      []MarketAvailability   `json:"markets,omitempty" db:"-"`         // Stored in content_market_availability
+	Keywords     []Keyword              `json:"keywords,omitempty" db:"-"`        // Stored in content_keywords
 	QualityScore float64                `json:"quality_score" db:"quality_score"`
 	Active       bool                   `json:"active" db:"active"`
 	CreatedAt    time.Time              `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time              `json:"updated_at" db:"updated_at"`
 }
 
+// ImageHash holds the 64-bit perceptual hashes of one content image, used to
+// detect the same picture across different listings
+type ImageHash struct {
+	ImageURL string `json:"image_url"`
+	PHash    uint64 `json:"phash,string"`
+	DHash    uint64 `json:"dhash,string"`
+}
+
+// Keyword is one entry of an item's sparse keyword vector: a stemmed term and
+// its TF-IDF or BM25 weight against the catalog at ingestion time
+type Keyword struct {
+	Term      string  `json:"term"` // Normalized stem, shared by all variants of the word
+	Text      string  `json:"text"` // Most frequent form in the item
+	Frequency float64 `json:"frequency"`
+	Weight    float64 `json:"weight"`
+}
+
+// MarketAvailability is an availability rule for one market. An item without
+// rules is available everywhere; once it has rules, only markets with a matching
+// rule (or a "*" rule) can show it.
+type MarketAvailability struct {
+	Market         string     `json:"market" validate:"required"` // ISO 3166-1 alpha-2 country code or "*"
+	Blocked        bool       `json:"blocked,omitempty"`          // Never shown in this market
+	Currency       string     `json:"currency,omitempty"`         // ISO 4217 code of Price
+	Price          *float64   `json:"price,omitempty"`
+	AvailableFrom  *time.Time `json:"available_from,omitempty"`
+	AvailableUntil *time.Time `json:"available_until,omitempty"`
+}
+
 type ContentIngestionRequest struct {
-	Type        string                 `json:"type" validate:"required,oneof=product video article"`
+	ExternalID  *string                `json:"external_id,omitempty" validate:"omitempty,min=1,max=255"` // Client-side identifier (SKU, slug)
+	Type        string                 `json:"type" validate:"required,min=1,max=50"`                    // Checked against the content type schemas
 	Title       string                 `json:"title" validate:"required,min=1,max=255"`
 	Description *string                `json:"description,omitempty"`
 	ImageURLs   []string               `json:"image_urls,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 	Categories  []string               `json:"categories,omitempty"`
+	Language    *string                `json:"language,omitempty" validate:"omitempty,min=2,max=16"` // Overrides detection
+	Markets     []MarketAvailability   `json:"markets,omitempty" validate:"omitempty,dive"`
 }
 
 type ContentBatchRequest struct {
 	Items []ContentIngestionRequest `json:"items" validate:"required,min=1,max=100"`
 }
 
+// ContentImportRequest starts a bulk import from a file. Uploads send the file as
+// multipart "file" with these fields as form values; path/url sources send JSON.
+type ContentImportRequest struct {
+	Format        string            `json:"format,omitempty" form:"format" validate:"omitempty,oneof=ndjson csv"`
+	Path          *string           `json:"path,omitempty"`
+	URL           *string           `json:"url,omitempty" validate:"omitempty,url"`
+	Mapping       map[string]string `json:"mapping,omitempty"` // ContentIngestionRequest field -> source column
+	DefaultType   string            `json:"default_type,omitempty" form:"default_type" validate:"omitempty,min=1,max=50"`
+	ListSeparator string            `json:"list_separator,omitempty" form:"list_separator" validate:"omitempty,max=5"`
+	Delimiter     string            `json:"delimiter,omitempty" form:"delimiter" validate:"omitempty,len=1"` // CSV only
+	CallbackURL   string            `json:"callback_url,omitempty" form:"callback_url" validate:"omitempty,url"`
+}
+
 type ContentJobStatus struct {
-	JobID          uuid.UUID `json:"job_id"`
-	Status         string    `json:"status"`   // queued, processing, completed, failed
-	Progress       int       `json:"progress"` // 0-100
-	TotalItems     int       `json:"total_items"`
-	ProcessedItems int       `json:"processed_items"`
-	FailedItems    int       `json:"failed_items"`
-	EstimatedTime  *int      `json:"estimated_time,omitempty"` // seconds
-	ErrorMessage   *string   `json:"error_message,omitempty"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	JobID          uuid.UUID              `json:"job_id"`
+	Status         string                 `json:"status"`   // queued, processing, completed, failed, cancelled
+	Progress       int                    `json:"progress"` // 0-100
+	TotalItems     int                    `json:"total_items"`
+	ProcessedItems int                    `json:"processed_items"`
+	FailedItems    int                    `json:"failed_items"`
+	EstimatedTime  *int                   `json:"estimated_time,omitempty"` // seconds
+	ErrorMessage   *string                `json:"error_message,omitempty"`
+	Details        map[string]interface{} `json:"details,omitempty"`     // Import stats, duplicates
+	Items          []ContentItemOutcome   `json:"items,omitempty"`       // Per-item outcomes when requested
+	ItemsTotal     *int                   `json:"items_total,omitempty"` // Number of recorded outcomes
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
+
+// ContentItemOutcome reports what happened to one input item of a job
+type ContentItemOutcome struct {
+	Index       int          `json:"index"`                  // 0-based position in the request, feed or file
+	Row         int          `json:"row,omitempty"`          // 1-based source row for imports
+	ExternalID  string       `json:"external_id,omitempty"`  // Client-side identifier, if sent
+	ContentID   *uuid.UUID   `json:"content_id,omitempty"`   // Assigned content ID on success
+	DuplicateOf *uuid.UUID   `json:"duplicate_of,omitempty"` // Near-duplicate match, if any
+	Status      string       `json:"status"`                 // succeeded or failed
+	Stage       string       `json:"stage,omitempty"`        // Stage that failed
+	Error       string       `json:"error,omitempty"`
+	Fields      []FieldError `json:"fields,omitempty"` // Per-field validation errors
+}
+
+// FieldError is a validation error on one field of an ingested item, e.g.
+// "metadata.price"
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ContentJobList struct {
+	Jobs       []ContentJobStatus `json:"jobs"`
+	Pagination PaginationResponse `json:"pagination"`
 }
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Graph reconciliation issue kinds
+const (
+	GraphIssueMissingRelationship      = "missing_relationship"
+	GraphIssueExtraRelationship        = "extra_relationship"
+	GraphIssueStaleRating              = "stale_rating"
+	GraphIssueMissingUserNode          = "missing_user_node"
+	GraphIssueMissingContentNode       = "missing_content_node"
+	GraphIssueStaleContentNode         = "stale_content_node"
+	GraphIssueDeactivatedContentLinked = "deactivated_content_linked"
+	GraphIssueOrphanContentNode        = "orphan_content_node"
+)
+
+// GraphIssue is one difference between Postgres and the Neo4j graph
+type GraphIssue struct {
+	Kind         string     `json:"kind"`
+	UserID       *uuid.UUID `json:"user_id,omitempty"`
+	ItemID       *uuid.UUID `json:"item_id,omitempty"`
+	Relationship string     `json:"relationship,omitempty"`
+	Detail       string     `json:"detail,omitempty"`
+}
+
+// GraphReconciliationReport summarizes a reconciliation run. Counts are keyed
+// by issue kind, except Scanned which is keyed by users, content_items,
+// graph_users and graph_contents.
+type GraphReconciliationReport struct {
+	Repair       bool           `json:"repair"`
+	StartedAt    time.Time      `json:"started_at"`
+	CompletedAt  *time.Time     `json:"completed_at,omitempty"`
+	Scanned      map[string]int `json:"scanned"`
+	Issues       map[string]int `json:"issues"`
+	Repaired     map[string]int `json:"repaired"`
+	RepairErrors int            `json:"repair_errors"`
+	Samples      []GraphIssue   `json:"samples"` // First issues found, up to max_samples
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserDataExport is everything stored about a user, as returned by the export
+// endpoint. Stores that could not be read are listed in Omitted.
+type UserDataExport struct {
+	UserID               uuid.UUID                `json:"user_id"`
+	ExportedAt           time.Time                `json:"exported_at"`
+	Profile              *UserProfileExport       `json:"profile"` // Nil if no profile was built yet
+	Interactions         []UserInteraction        `json:"interactions"`
+	Suppressions         []Suppression            `json:"suppressions"`
+	RecommendationEvents []RecommendationEvent    `json:"recommendation_events"`
+	GraphRelationships   []GraphRelationship      `json:"graph_relationships"`
+	Feedback             []RecommendationFeedback `json:"feedback"` // Recommendation feedback still cached
+	Omitted              []string                 `json:"omitted,omitempty"`
+}
+
+// UserProfileExport is a profile including the vectors the API usually hides
+type UserProfileExport struct {
+	UserProfile
+	PreferenceVector []float32        `json:"preference_vector,omitempty"`
+	NegativeVector   []float32        `json:"negative_vector,omitempty"`
+	Interests        []InterestExport `json:"interests,omitempty"`
+}
+
+// InterestExport is an interest including its centroid
+type InterestExport struct {
+	InterestVector
+	Vector []float32 `json:"vector"`
+}
+
+// RecommendationEvent is a recorded impression, click or conversion of a
+// recommendation shown to the user
+type RecommendationEvent struct {
+	ID               uuid.UUID              `json:"id"`
+	ItemID           *uuid.UUID             `json:"item_id,omitempty"`
+	RecommendationID uuid.UUID              `json:"recommendation_id"`
+	EventType        string                 `json:"event_type"`
+	Algorithm        string                 `json:"algorithm"`
+	Position         *int                   `json:"position,omitempty"`
+	Confidence       *float64               `json:"confidence,omitempty"`
+	SessionID        *uuid.UUID             `json:"session_id,omitempty"`
+	Context          map[string]interface{} `json:"context,omitempty"`
+	Market           *string                `json:"market,omitempty"`
+	Timestamp        time.Time              `json:"timestamp"`
+}
+
+// GraphRelationship is one of the user's relationships in the Neo4j graph
+type GraphRelationship struct {
+	ItemID     uuid.UUID              `json:"item_id"`
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// User deletion store outcomes
+const (
+	DeletionDeleted = "deleted"
+	DeletionSkipped = "skipped" // The store is not configured
+	DeletionFailed  = "failed"
+)
+
+// UserDeletionReport records what deleting a user removed from each store and
+// what a final verification pass still found. Digest is the hex SHA-256 of the
+// report's JSON with an empty digest, so a copy kept by the requester can be
+// checked against the job.
+type UserDeletionReport struct {
+	UserID      uuid.UUID       `json:"user_id"`
+	JobID       uuid.UUID       `json:"job_id"`
+	StartedAt   time.Time       `json:"started_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	Stores      []StoreDeletion `json:"stores"`
+	Verified    bool            `json:"verified"` // Every store was purged and nothing was found afterwards
+	Notes       []string        `json:"notes,omitempty"`
+	Digest      string          `json:"digest"`
+}
+
+// StoreDeletion is the outcome of deleting a user from one store. Counts are
+// keyed by table, node or relationship kind, or Redis key family.
+type StoreDeletion struct {
+	Store     string           `json:"store"` // postgres, neo4j, redis or kafka
+	Status    string           `json:"status"`
+	Deleted   map[string]int64 `json:"deleted"`
+	Remaining map[string]int64 `json:"remaining,omitempty"` // Found by verification
+	Error     string           `json:"error,omitempty"`
+}
@@ -21,6 +21,8 @@ type RecommendationRequest struct {
 	Count   int       `json:"count" validate:"min=1,max=100"`
 	Context string    `json:"context,omitempty" validate:"omitempty,oneof=home search category product"`
 	Explain bool      `json:"explain"`
+	Locale  string    `json:"locale,omitempty"` // BCP 47 tag, e.g. es-MX
+	Market  string    `json:"market,omitempty"` // ISO 3166-1 alpha-2; defaults to the locale's region
 }
 
 type RecommendationResponse struct {
@@ -50,8 +52,11 @@ type SimilarItemResponse struct {
 	UserID          uuid.UUID        `json:"user_id"`
 	SeedItemID      uuid.UUID        `json:"seed_item_id"`
 	Recommendations []Recommendation `json:"recommendations"`
-	GeneratedAt     time.Time        `json:"generated_at"`
-	CacheHit        bool             `json:"cache_hit"`
+	// FrequentlyBoughtTogether lists items often converted on with the seed
+	// item, strongest first, with the share of the strongest pair's count
+	FrequentlyBoughtTogether []ScoredItem `json:"frequently_bought_together,omitempty"`
+	GeneratedAt              time.Time    `json:"generated_at"`
+	CacheHit                 bool         `json:"cache_hit"`
 }
 
 type RecommendationFeedback struct {
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SearchResult is one item returned by hybrid search
+type SearchResult struct {
+	ItemID   uuid.UUID `json:"item_id"`
+	Score    float64   `json:"score"` // Reciprocal rank fusion score
+	Position int       `json:"position"`
+	// Ranks holds the 1-based rank of the item in each retriever that returned
+	// it, e.g. {"lexical": 1, "vector": 4}
+	Ranks map[string]int `json:"ranks"`
+	Item  *ContentItem   `json:"item,omitempty"`
+}
+
+type SearchResponse struct {
+	Query        string         `json:"query"`
+	Results      []SearchResult `json:"results"`
+	Total        int            `json:"total"`
+	Retrievers   []string       `json:"retrievers"` // Retrievers that ran: lexical, vector, personalized
+	Personalized bool           `json:"personalized"`
+	GeneratedAt  time.Time      `json:"generated_at"`
+}
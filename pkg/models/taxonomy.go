@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Category is a node of the category taxonomy. Content stores category names;
+// the ID is a stable slug used by the admin API and the graph.
+type Category struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	ParentID  *string   `json:"parent_id,omitempty" db:"parent_id"`
+	Path      []string  `json:"path" db:"-"`               // Category names from the root down to this category
+	Depth     int       `json:"depth" db:"-"`              // 1 for root categories
+	Synonyms  []string  `json:"synonyms,omitempty" db:"-"` // Stored in category_synonyms
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CategoryRequest creates a category or replaces an existing one
+type CategoryRequest struct {
+	ID       string   `json:"id,omitempty" validate:"omitempty,min=1,max=100"` // Derived from the name when empty
+	Name     string   `json:"name" validate:"required,min=1,max=100"`
+	ParentID *string  `json:"parent_id,omitempty"`
+	Synonyms []string `json:"synonyms,omitempty" validate:"omitempty,max=50,dive,min=1,max=100"`
+}
@@ -9,6 +9,8 @@ import (
 type UserProfile struct {
 	UserID           uuid.UUID              `json:"user_id" db:"user_id"`
 	PreferenceVector []float32              `json:"-" db:"preference_vector"`
+	Interests        []InterestVector       `json:"interests,omitempty" db:"-"` // Strongest first
+	NegativeVector   []float32              `json:"-" db:"negative_vector"`     // Weighted mean of disliked items; nil without dislikes
 	ExplicitPrefs    map[string]interface{} `json:"explicit_preferences" db:"explicit_preferences"`
 	BehaviorPatterns map[string]interface{} `json:"behavior_patterns" db:"behavior_patterns"`
 	Demographics     map[string]interface{} `json:"demographics,omitempty" db:"demographics"`
@@ -18,6 +20,73 @@ type UserProfile struct {
 	UpdatedAt        time.Time              `json:"updated_at" db:"updated_at"`
 }
 
+// Keys of the explicit preferences stored in UserProfile.ExplicitPrefs
+const (
+	PrefFavoriteCategories = "favorite_categories"
+	PrefBlockedCategories  = "blocked_categories"
+	PrefContentTypes       = "content_types"
+	PrefOnboardedAt        = "onboarded_at"
+	PrefOnboardingItems    = "onboarding_items"
+)
+
+// ExplicitPreferences are the preferences a user stated, as opposed to those
+// learned from interactions
+type ExplicitPreferences struct {
+	FavoriteCategories []string           `json:"favorite_categories"`
+	BlockedCategories  []string           `json:"blocked_categories"`
+	ContentTypes       map[string]float64 `json:"content_types"` // Weight per content type; 0 hides the type, above 1 boosts it
+	OnboardedAt        *time.Time         `json:"onboarded_at,omitempty"`
+	OnboardingItems    []uuid.UUID        `json:"onboarding_items,omitempty"` // Items picked during onboarding
+}
+
+// ProfileUpdateRequest changes a user's explicit preferences. Omitted fields
+// are kept; an empty list or map clears them.
+type ProfileUpdateRequest struct {
+	FavoriteCategories *[]string           `json:"favorite_categories,omitempty" validate:"omitempty,max=50,dive,required,max=100"`
+	BlockedCategories  *[]string           `json:"blocked_categories,omitempty" validate:"omitempty,max=50,dive,required,max=100"`
+	ContentTypes       *map[string]float64 `json:"content_types,omitempty" validate:"omitempty,max=20,dive,keys,required,max=50,endkeys,min=0,max=5"`
+	Onboarding         bool                `json:"onboarding,omitempty"` // Records the preferences as captured by onboarding
+}
+
+// OnboardingItem is an item offered to a new user to pick what they like
+type OnboardingItem struct {
+	ItemID     uuid.UUID `json:"item_id"`
+	Type       string    `json:"type"`
+	Title      string    `json:"title"`
+	ImageURL   string    `json:"image_url,omitempty"`
+	Categories []string  `json:"categories"`
+}
+
+// OnboardingSelection is what a new user picked from the onboarding items
+type OnboardingSelection struct {
+	SelectedItems      []uuid.UUID         `json:"selected_items" validate:"required,min=1,max=50"`
+	FavoriteCategories []string            `json:"favorite_categories,omitempty" validate:"omitempty,max=50,dive,required,max=100"` // Picked directly; kept ahead of those of the items
+	ContentTypes       *map[string]float64 `json:"content_types,omitempty" validate:"omitempty,max=20,dive,keys,required,max=50,endkeys,min=0,max=5"`
+}
+
+// InterestVector is one of a user's interests: the centroid of a cluster of
+// the item embeddings the user interacted with
+type InterestVector struct {
+	Vector     []float32 `json:"-"`
+	Weight     float64   `json:"weight"`               // Share of the user's recency weighted interactions
+	ItemCount  int       `json:"item_count"`           // Interacted items in the cluster
+	Categories []string  `json:"categories,omitempty"` // Strongest categories of the cluster's items
+}
+
+// Suppression is something a user asked not to be shown: an item, hidden for
+// good, or a category or brand, penalized with a strength that decays over time
+type Suppression struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Kind      string    `json:"kind"` // item, category or brand
+	Value     string    `json:"value"`
+	Strength  float64   `json:"strength"` // Current strength after decay; 1 for hard suppressions
+	Hard      bool      `json:"hard"`     // Hard suppressions drop the item instead of penalizing it
+	Source    string    `json:"source"`   // Feedback type that created or last strengthened it
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 type UserInteraction struct {
 	ID              uuid.UUID              `json:"id" db:"id"`
 	UserID          uuid.UUID              `json:"user_id" db:"user_id" validate:"required"`
@@ -34,7 +103,7 @@ type UserInteraction struct {
 type ExplicitInteractionRequest struct {
 	UserID    uuid.UUID `json:"user_id" validate:"required"`
 	ItemID    uuid.UUID `json:"item_id" validate:"required"`
-	Type      string    `json:"type" validate:"required,oneof=rating like dislike share"`
+	Type      string    `json:"type" validate:"required,oneof=rating like dislike share not_interested"`
 	Value     *float64  `json:"value,omitempty" validate:"omitempty,min=1,max=5"`
 	SessionID uuid.UUID `json:"session_id" validate:"required"`
 }
@@ -42,7 +111,7 @@ type ExplicitInteractionRequest struct {
 type ImplicitInteractionRequest struct {
 	UserID    uuid.UUID              `json:"user_id" validate:"required"`
 	ItemID    *uuid.UUID             `json:"item_id,omitempty"`
-	Type      string                 `json:"type" validate:"required,oneof=click view search browse"`
+	Type      string                 `json:"type" validate:"required,oneof=click view search browse purchase"`
 	Duration  *int                   `json:"duration,omitempty"`
 	Query     *string                `json:"query,omitempty"`
 	SessionID uuid.UUID              `json:"session_id" validate:"required"`
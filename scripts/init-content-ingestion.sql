@@ -7,7 +7,7 @@ CREATE EXTENSION IF NOT EXISTS "vector";
 -- Content items table with vector embeddings
 CREATE TABLE IF NOT EXISTS content_items (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    type VARCHAR(50) NOT NULL CHECK (type IN ('product', 'video', 'article')),
+    type VARCHAR(50) NOT NULL, -- Content type; types are defined by metadata JSON Schemas
     title VARCHAR(255) NOT NULL,
     description TEXT,
     image_urls TEXT[] DEFAULT '{}',
@@ -16,10 +16,90 @@ CREATE TABLE IF NOT EXISTS content_items (
     embedding vector(768), -- 768 dimensions as specified in design
     quality_score FLOAT NOT NULL DEFAULT 0.0 CHECK (quality_score >= 0.0 AND quality_score <= 1.0),
     active BOOLEAN NOT NULL DEFAULT true,
+    fingerprint BIGINT, -- 64-bit SimHash of title + description for near-duplicate detection
+    language VARCHAR(16), -- Detected ISO 639-1 code or 'unknown'
+    -- Full-text search document: title (weight A) and description (weight B).
+    -- The 'simple' configuration does not stem, so every language is searchable.
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+    ) STORED,
     created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 );
 
+-- Client-supplied external IDs (SKU, article slug) mapped to internal content IDs
+CREATE TABLE IF NOT EXISTS content_external_ids (
+    content_type VARCHAR(50) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    content_id UUID NOT NULL REFERENCES content_items(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (content_type, external_id)
+);
+
+-- Perceptual hashes of content images for visual near-duplicate detection.
+-- The pHash is also split into four 16-bit bands: hashes within Hamming distance 3
+-- share at least one band, so band lookups find every visual duplicate candidate.
+CREATE TABLE IF NOT EXISTS content_image_hashes (
+    content_id UUID NOT NULL REFERENCES content_items(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+    image_url TEXT NOT NULL,
+    phash BIGINT NOT NULL,
+    dhash BIGINT NOT NULL,
+    phash_band0 INTEGER NOT NULL,
+    phash_band1 INTEGER NOT NULL,
+    phash_band2 INTEGER NOT NULL,
+    phash_band3 INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (content_id, image_url)
+);
+
+-- Per-market availability rules. Items without rules are available everywhere;
+-- otherwise only markets with a rule (or a '*' rule) that is not blocked can show them.
+CREATE TABLE IF NOT EXISTS content_market_availability (
+    content_id UUID NOT NULL REFERENCES content_items(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+    market VARCHAR(8) NOT NULL, -- ISO 3166-1 alpha-2 country code or '*'
+    blocked BOOLEAN NOT NULL DEFAULT false,
+    currency CHAR(3),
+    price NUMERIC(12, 2),
+    available_from TIMESTAMP WITH TIME ZONE,
+    available_until TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (content_id, market)
+);
+
+-- Sparse keyword vector of each item. Weights are TF-IDF or BM25 against the
+-- catalog when the item was stored.
+CREATE TABLE IF NOT EXISTS content_keywords (
+    content_id UUID NOT NULL REFERENCES content_items(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+    term TEXT NOT NULL, -- Normalized stem
+    surface TEXT NOT NULL, -- Most frequent form in the item
+    frequency REAL NOT NULL, -- Occurrences, title words boosted
+    weight REAL NOT NULL,
+    PRIMARY KEY (content_id, term)
+);
+
+-- Number of items containing each term, maintained as items are stored
+CREATE TABLE IF NOT EXISTS keyword_document_frequencies (
+    term TEXT PRIMARY KEY,
+    document_count INTEGER NOT NULL DEFAULT 0
+);
+
+-- Category taxonomy. Content stores category names; parent_id links a category
+-- to its parent, NULL for root categories.
+CREATE TABLE IF NOT EXISTS categories (
+    id VARCHAR(100) PRIMARY KEY, -- Slug, e.g. 'headphones'
+    name VARCHAR(100) NOT NULL,
+    parent_id VARCHAR(100) REFERENCES categories(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Lowercase alternative names of categories
+CREATE TABLE IF NOT EXISTS category_synonyms (
+    synonym VARCHAR(100) PRIMARY KEY,
+    category_id VARCHAR(100) NOT NULL REFERENCES categories(id) ON DELETE CASCADE
+);
+
 -- Content processing jobs table
 CREATE TABLE IF NOT EXISTS content_jobs (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -35,6 +115,17 @@ CREATE TABLE IF NOT EXISTS content_jobs (
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 );
 
+-- Catalog sync state: what each connector last emitted, by external ID
+CREATE TABLE IF NOT EXISTS catalog_sync_state (
+    connector VARCHAR(255) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    title TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (connector, external_id)
+);
+
 -- Indexes for performance
 
 -- Content items indexes
@@ -44,6 +135,18 @@ CREATE INDEX IF NOT EXISTS idx_content_items_quality_score ON content_items(qual
 CREATE INDEX IF NOT EXISTS idx_content_items_created_at ON content_items(created_at DESC);
 CREATE INDEX IF NOT EXISTS idx_content_items_categories ON content_items USING GIN(categories);
 CREATE INDEX IF NOT EXISTS idx_content_items_metadata ON content_items USING GIN(metadata);
+-- SimHash bands: fingerprints within 3 bits share at least one
+CREATE INDEX IF NOT EXISTS idx_content_items_fingerprint_band0 ON content_items ((fingerprint & 65535));
+CREATE INDEX IF NOT EXISTS idx_content_items_fingerprint_band1 ON content_items (((fingerprint >> 16) & 65535));
+CREATE INDEX IF NOT EXISTS idx_content_items_fingerprint_band2 ON content_items (((fingerprint >> 32) & 65535));
+CREATE INDEX IF NOT EXISTS idx_content_items_fingerprint_band3 ON content_items (((fingerprint >> 48) & 65535));
+CREATE INDEX IF NOT EXISTS idx_content_items_language ON content_items(language);
+CREATE INDEX IF NOT EXISTS idx_content_items_search_vector ON content_items USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_content_external_ids_content_id ON content_external_ids(content_id);
+CREATE INDEX IF NOT EXISTS idx_content_image_hashes_band0 ON content_image_hashes(phash_band0);
+CREATE INDEX IF NOT EXISTS idx_content_image_hashes_band1 ON content_image_hashes(phash_band1);
+CREATE INDEX IF NOT EXISTS idx_content_image_hashes_band2 ON content_image_hashes(phash_band2);
+CREATE INDEX IF NOT EXISTS idx_content_image_hashes_band3 ON content_image_hashes(phash_band3);
 
 -- Vector similarity search index (HNSW for fast approximate nearest neighbor search)
 CREATE INDEX IF NOT EXISTS idx_content_items_embedding_hnsw ON content_items 
@@ -55,6 +158,12 @@ WITH (m = 16, ef_construction = 64);
 -- USING ivfflat (embedding vector_cosine_ops) 
 -- WITH (lists = 100);
 
+-- Category taxonomy indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_name ON categories(LOWER(name));
+CREATE INDEX IF NOT EXISTS idx_content_keywords_term ON content_keywords(term);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+CREATE INDEX IF NOT EXISTS idx_category_synonyms_category_id ON category_synonyms(category_id);
+
 -- Content jobs indexes
 CREATE INDEX IF NOT EXISTS idx_content_jobs_status ON content_jobs(status);
 CREATE INDEX IF NOT EXISTS idx_content_jobs_created_at ON content_jobs(created_at DESC);
@@ -82,6 +191,38 @@ CREATE TRIGGER update_content_jobs_updated_at
     BEFORE UPDATE ON content_jobs 
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+DROP TRIGGER IF EXISTS update_categories_updated_at ON categories;
+CREATE TRIGGER update_categories_updated_at
+    BEFORE UPDATE ON categories
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Default root categories; subcategories are added through the admin API
+INSERT INTO categories (id, name) VALUES
+    ('electronics', 'Electronics'),
+    ('clothing', 'Clothing'),
+    ('books', 'Books'),
+    ('home', 'Home'),
+    ('sports', 'Sports'),
+    ('food', 'Food'),
+    ('travel', 'Travel'),
+    ('health', 'Health'),
+    ('education', 'Education'),
+    ('entertainment', 'Entertainment')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO category_synonyms (synonym, category_id) VALUES
+    ('electronic', 'electronics'), ('tech', 'electronics'), ('technology', 'electronics'), ('gadget', 'electronics'), ('gadgets', 'electronics'),
+    ('clothes', 'clothing'), ('apparel', 'clothing'), ('fashion', 'clothing'), ('wear', 'clothing'),
+    ('book', 'books'), ('literature', 'books'), ('reading', 'books'),
+    ('house', 'home'), ('household', 'home'), ('domestic', 'home'),
+    ('sport', 'sports'), ('fitness', 'sports'), ('exercise', 'sports'), ('athletic', 'sports'),
+    ('foods', 'food'), ('cuisine', 'food'), ('cooking', 'food'), ('recipe', 'food'),
+    ('tourism', 'travel'), ('vacation', 'travel'), ('trip', 'travel'), ('journey', 'travel'),
+    ('medical', 'health'), ('wellness', 'health'), ('healthcare', 'health'),
+    ('learning', 'education'), ('academic', 'education'), ('school', 'education'), ('university', 'education'),
+    ('fun', 'entertainment'), ('games', 'entertainment'), ('movies', 'entertainment'), ('music', 'entertainment')
+ON CONFLICT DO NOTHING;
+
 -- Sample data for testing (optional)
 -- INSERT INTO content_items (type, title, description, categories, quality_score) VALUES
 -- ('product', 'Sample Product', 'This is a sample product for testing', ARRAY['Electronics', 'Gadgets'], 0.8),
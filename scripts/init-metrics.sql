@@ -13,6 +13,7 @@ CREATE TABLE IF NOT EXISTS recommendation_metrics (
     context JSONB,
     user_tier VARCHAR(50) DEFAULT 'free',
     content_category VARCHAR(100),
+    market VARCHAR(8), -- ISO 3166-1 alpha-2 market the recommendation was served in
     created_at TIMESTAMP DEFAULT NOW()
 );
 
@@ -22,6 +23,7 @@ CREATE INDEX IF NOT EXISTS idx_recommendation_metrics_user_id ON recommendation_
 CREATE INDEX IF NOT EXISTS idx_recommendation_metrics_event_type ON recommendation_metrics(event_type);
 CREATE INDEX IF NOT EXISTS idx_recommendation_metrics_algorithm ON recommendation_metrics(algorithm_used);
 CREATE INDEX IF NOT EXISTS idx_recommendation_metrics_session ON recommendation_metrics(session_id);
+CREATE INDEX IF NOT EXISTS idx_recommendation_metrics_market ON recommendation_metrics(market);
 CREATE INDEX IF NOT EXISTS idx_recommendation_metrics_composite ON recommendation_metrics(timestamp, event_type, algorithm_used);
 
 -- Aggregated metrics table for fast dashboard queries
@@ -1,3 +1,7 @@
+// Bootstrap script for a fresh database. The application owns the schema
+// (internal/graph) and applies its versioned migrations at startup, so this
+// script is optional and every statement here must stay idempotent.
+
 // Create constraints for User nodes
 CREATE CONSTRAINT user_id_unique IF NOT EXISTS FOR (u:User) REQUIRE u.id IS UNIQUE;
 CREATE CONSTRAINT user_id_not_null IF NOT EXISTS FOR (u:User) REQUIRE u.id IS NOT NULL;
@@ -6,6 +10,9 @@ CREATE CONSTRAINT user_id_not_null IF NOT EXISTS FOR (u:User) REQUIRE u.id IS NO
 CREATE CONSTRAINT content_id_unique IF NOT EXISTS FOR (c:Content) REQUIRE c.id IS UNIQUE;
 CREATE CONSTRAINT content_id_not_null IF NOT EXISTS FOR (c:Content) REQUIRE c.id IS NOT NULL;
 
+// Create constraints for Category nodes (mirrored from the categories table)
+CREATE CONSTRAINT category_id_unique IF NOT EXISTS FOR (c:Category) REQUIRE c.id IS UNIQUE;
+
 // Create indexes for performance
 CREATE INDEX user_created_at IF NOT EXISTS FOR (u:User) ON (u.created_at);
 CREATE INDEX user_last_interaction IF NOT EXISTS FOR (u:User) ON (u.last_interaction);
@@ -24,14 +31,20 @@ CREATE INDEX similar_to_computed_at IF NOT EXISTS FOR ()-[r:SIMILAR_TO]-() ON (r
 
 // Create sample data structure (will be populated by application)
 // User node properties: id, created_at, last_interaction, interaction_count, user_tier
+// User node properties: community (Louvain community, refreshed with SIMILAR_TO)
 // Content node properties: id, type, title, categories, created_at, active, quality_score
+// Category node properties: id, name, path, depth
 
 // Relationship types and their properties:
-// (:User)-[:RATED {rating: float, timestamp: datetime, confidence: float, session_id: string}]->(:Content)
-// (:User)-[:VIEWED {duration: int, progress: float, timestamp: datetime, session_id: string}]->(:Content)  
-// (:User)-[:INTERACTED_WITH {type: string, value: float, timestamp: datetime, session_id: string}]->(:Content)
+// (:User)-[:RATED {rating: float, interaction_type: string, timestamp: int, confidence: float}]->(:Content)
+//   Likes and dislikes are stored as ratings of 5 and 1
+// (:User)-[:VIEWED {duration: int, interaction_type: string, timestamp: int, confidence: float}]->(:Content)
+// (:User)-[:SHARED {interaction_type: string, timestamp: int, confidence: float}]->(:Content)
+// (:User)-[:INTERACTED_WITH {interaction_type: string, timestamp: int, confidence: float}]->(:Content)
 // (:User)-[:SIMILAR_TO {score: float, basis: string, computed_at: datetime}]->(:User)
 // (:Content)-[:SIMILAR_TO {score: float, algorithm: string, computed_at: datetime}]->(:Content)
+// (:Content)-[:IN_CATEGORY]->(:Category)
+// (:Category)-[:SUBCATEGORY_OF]->(:Category)
 
 // Create procedures for common graph operations
 CALL gds.graph.project.cypher(
@@ -10,7 +10,7 @@ CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 -- Create content_items table
 CREATE TABLE content_items (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    type VARCHAR(50) NOT NULL CHECK (type IN ('product', 'video', 'article')),
+    type VARCHAR(50) NOT NULL, -- Content type; types are defined by metadata JSON Schemas
     title VARCHAR(255) NOT NULL,
     description TEXT,
     image_urls TEXT[],
@@ -19,14 +19,108 @@ CREATE TABLE content_items (
     embedding VECTOR(768), -- Will be set after pgvector extension
     quality_score FLOAT DEFAULT 0.0 CHECK (quality_score >= 0.0 AND quality_score <= 1.0),
     active BOOLEAN DEFAULT true,
+    fingerprint BIGINT, -- SimHash for near-duplicate detection
+    language VARCHAR(16), -- Detected ISO 639-1 code
+    -- Full-text search document; 'simple' keeps every language searchable
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+    ) STORED,
     created_at TIMESTAMP DEFAULT NOW(),
     updated_at TIMESTAMP DEFAULT NOW()
 );
 
+-- Create content_external_ids table mapping client IDs (SKU, slug) to content
+CREATE TABLE content_external_ids (
+    content_type VARCHAR(50) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    content_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (content_type, external_id),
+
+    FOREIGN KEY (content_id) REFERENCES content_items(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED
+);
+
+-- Create content_image_hashes table with perceptual hashes of content images.
+-- The pHash is also split into four 16-bit bands: hashes within Hamming distance 3
+-- share at least one band, so band lookups find every visual duplicate candidate.
+CREATE TABLE content_image_hashes (
+    content_id UUID NOT NULL,
+    image_url TEXT NOT NULL,
+    phash BIGINT NOT NULL,
+    dhash BIGINT NOT NULL,
+    phash_band0 INTEGER NOT NULL,
+    phash_band1 INTEGER NOT NULL,
+    phash_band2 INTEGER NOT NULL,
+    phash_band3 INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (content_id, image_url),
+
+    FOREIGN KEY (content_id) REFERENCES content_items(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED
+);
+
+-- Create content_market_availability table with per-market availability rules.
+-- Items without rules are available everywhere; otherwise only markets with a rule
+-- (or a '*' rule) that is not blocked can show them.
+CREATE TABLE content_market_availability (
+    content_id UUID NOT NULL,
+    market VARCHAR(8) NOT NULL, -- ISO 3166-1 alpha-2 country code or '*'
+    blocked BOOLEAN DEFAULT false,
+    currency CHAR(3),
+    price NUMERIC(12, 2),
+    available_from TIMESTAMP,
+    available_until TIMESTAMP,
+    PRIMARY KEY (content_id, market),
+
+    FOREIGN KEY (content_id) REFERENCES content_items(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED
+);
+
+-- Create content_keywords table with the sparse keyword vector of each item.
+-- Weights are TF-IDF or BM25 against the catalog when the item was stored.
+CREATE TABLE content_keywords (
+    content_id UUID NOT NULL,
+    term TEXT NOT NULL, -- Normalized stem
+    surface TEXT NOT NULL, -- Most frequent form in the item
+    frequency REAL NOT NULL, -- Occurrences, title words boosted
+    weight REAL NOT NULL,
+    PRIMARY KEY (content_id, term),
+
+    FOREIGN KEY (content_id) REFERENCES content_items(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED
+);
+
+-- Create keyword_document_frequencies table with the number of items containing
+-- each term, maintained as items are stored
+CREATE TABLE keyword_document_frequencies (
+    term TEXT PRIMARY KEY,
+    document_count INTEGER NOT NULL DEFAULT 0
+);
+
+-- Create categories table with the category taxonomy. Content stores category
+-- names; parent_id links a category to its parent, NULL for root categories.
+CREATE TABLE categories (
+    id VARCHAR(100) PRIMARY KEY, -- Slug, e.g. 'headphones'
+    name VARCHAR(100) NOT NULL,
+    parent_id VARCHAR(100),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    FOREIGN KEY (parent_id) REFERENCES categories(id)
+);
+
+-- Create category_synonyms table mapping lowercase alternative names to categories
+CREATE TABLE category_synonyms (
+    synonym VARCHAR(100) PRIMARY KEY,
+    category_id VARCHAR(100) NOT NULL,
+
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
 -- Create user_profiles table
 CREATE TABLE user_profiles (
     user_id UUID PRIMARY KEY,
     preference_vector VECTOR(768), -- Will be set after pgvector extension
+    negative_vector VECTOR(768), -- Weighted mean of disliked items; NULL without dislikes
     explicit_preferences JSONB DEFAULT '{}',
     behavior_patterns JSONB DEFAULT '{}',
     demographics JSONB DEFAULT '{}',
@@ -36,6 +130,33 @@ CREATE TABLE user_profiles (
     updated_at TIMESTAMP DEFAULT NOW()
 );
 
+-- Create user_interests table: a user's interest clusters, rebuilt with the profile
+CREATE TABLE user_interests (
+    user_id UUID NOT NULL,
+    position INTEGER NOT NULL, -- 0 is the strongest interest
+    vector VECTOR(768) NOT NULL,
+    weight FLOAT NOT NULL, -- Share of the user's recency weighted interactions
+    item_count INTEGER NOT NULL,
+    categories TEXT[] DEFAULT '{}',
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (user_id, position),
+    FOREIGN KEY (user_id) REFERENCES user_profiles(user_id) ON DELETE CASCADE
+);
+
+-- Create user_suppressions table: items, categories and brands a user asked not to see
+CREATE TABLE user_suppressions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('item', 'category', 'brand')),
+    value TEXT NOT NULL, -- Item ID, category or lowercase brand
+    strength FLOAT NOT NULL DEFAULT 1.0, -- Strength at updated_at; decays for soft suppressions
+    hard BOOLEAN NOT NULL DEFAULT FALSE,
+    source VARCHAR(50) NOT NULL, -- Feedback type that created or last strengthened it
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (user_id, kind, value)
+);
+
 -- Create user_interactions table
 CREATE TABLE user_interactions (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -52,6 +173,22 @@ CREATE TABLE user_interactions (
     FOREIGN KEY (item_id) REFERENCES content_items(id) ON DELETE SET NULL
 );
 
+-- Create graph_outbox table of interaction relationships waiting to be written
+-- to Neo4j. Rows are inserted in the same transaction as the interaction and
+-- deleted once relayed; failed_at marks rows that ran out of attempts.
+CREATE TABLE graph_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    item_id UUID NOT NULL,
+    relationship VARCHAR(50) NOT NULL,
+    properties JSONB NOT NULL DEFAULT '{}',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    failed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
 -- Create recommendation_metrics table for business analytics
 CREATE TABLE recommendation_metrics (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -65,6 +202,7 @@ CREATE TABLE recommendation_metrics (
     timestamp TIMESTAMP DEFAULT NOW(),
     session_id UUID,
     context JSONB DEFAULT '{}',
+    market VARCHAR(8), -- ISO 3166-1 alpha-2 market the recommendation was served in
     
     FOREIGN KEY (item_id) REFERENCES content_items(id) ON DELETE SET NULL
 );
@@ -96,23 +234,53 @@ CREATE TABLE content_jobs (
     updated_at TIMESTAMP DEFAULT NOW()
 );
 
+-- Create catalog_sync_state table: what each catalog sync connector last emitted
+CREATE TABLE catalog_sync_state (
+    connector VARCHAR(255) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    title TEXT NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (connector, external_id)
+);
+
 -- Create indexes for performance
 CREATE INDEX idx_content_items_type ON content_items(type);
 CREATE INDEX idx_content_items_categories ON content_items USING GIN(categories);
 CREATE INDEX idx_content_items_active ON content_items(active);
 CREATE INDEX idx_content_items_created_at ON content_items(created_at);
+-- SimHash bands: fingerprints within 3 bits share at least one
+CREATE INDEX idx_content_items_fingerprint_band0 ON content_items ((fingerprint & 65535));
+CREATE INDEX idx_content_items_fingerprint_band1 ON content_items (((fingerprint >> 16) & 65535));
+CREATE INDEX idx_content_items_fingerprint_band2 ON content_items (((fingerprint >> 32) & 65535));
+CREATE INDEX idx_content_items_fingerprint_band3 ON content_items (((fingerprint >> 48) & 65535));
+CREATE INDEX idx_content_items_language ON content_items(language);
+CREATE INDEX idx_content_items_search_vector ON content_items USING GIN(search_vector);
+CREATE INDEX idx_content_external_ids_content_id ON content_external_ids(content_id);
+CREATE INDEX idx_content_image_hashes_band0 ON content_image_hashes(phash_band0);
+CREATE INDEX idx_content_image_hashes_band1 ON content_image_hashes(phash_band1);
+CREATE INDEX idx_content_image_hashes_band2 ON content_image_hashes(phash_band2);
+CREATE INDEX idx_content_image_hashes_band3 ON content_image_hashes(phash_band3);
+
+CREATE UNIQUE INDEX idx_categories_name ON categories(LOWER(name));
+CREATE INDEX idx_content_keywords_term ON content_keywords(term);
+CREATE INDEX idx_categories_parent_id ON categories(parent_id);
+CREATE INDEX idx_category_synonyms_category_id ON category_synonyms(category_id);
 
 CREATE INDEX idx_user_interactions_user_id ON user_interactions(user_id);
 CREATE INDEX idx_user_interactions_item_id ON user_interactions(item_id);
 CREATE INDEX idx_user_interactions_type ON user_interactions(interaction_type);
 CREATE INDEX idx_user_interactions_timestamp ON user_interactions(timestamp);
 CREATE INDEX idx_user_interactions_session_id ON user_interactions(session_id);
+CREATE INDEX idx_graph_outbox_pending ON graph_outbox(next_attempt_at, id) WHERE failed_at IS NULL;
 
 CREATE INDEX idx_recommendation_metrics_user_id ON recommendation_metrics(user_id);
 CREATE INDEX idx_recommendation_metrics_item_id ON recommendation_metrics(item_id);
 CREATE INDEX idx_recommendation_metrics_event_type ON recommendation_metrics(event_type);
 CREATE INDEX idx_recommendation_metrics_timestamp ON recommendation_metrics(timestamp);
 CREATE INDEX idx_recommendation_metrics_algorithm ON recommendation_metrics(algorithm_used);
+CREATE INDEX idx_recommendation_metrics_market ON recommendation_metrics(market);
 
 -- Create triggers for updated_at timestamps
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -130,4 +298,32 @@ CREATE TRIGGER update_user_profiles_updated_at BEFORE UPDATE ON user_profiles
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
 CREATE TRIGGER update_content_jobs_updated_at BEFORE UPDATE ON content_jobs
-    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
\ No newline at end of file
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_categories_updated_at BEFORE UPDATE ON categories
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Default root categories; subcategories are added through the admin API
+INSERT INTO categories (id, name) VALUES
+    ('electronics', 'Electronics'),
+    ('clothing', 'Clothing'),
+    ('books', 'Books'),
+    ('home', 'Home'),
+    ('sports', 'Sports'),
+    ('food', 'Food'),
+    ('travel', 'Travel'),
+    ('health', 'Health'),
+    ('education', 'Education'),
+    ('entertainment', 'Entertainment');
+
+INSERT INTO category_synonyms (synonym, category_id) VALUES
+    ('electronic', 'electronics'), ('tech', 'electronics'), ('technology', 'electronics'), ('gadget', 'electronics'), ('gadgets', 'electronics'),
+    ('clothes', 'clothing'), ('apparel', 'clothing'), ('fashion', 'clothing'), ('wear', 'clothing'),
+    ('book', 'books'), ('literature', 'books'), ('reading', 'books'),
+    ('house', 'home'), ('household', 'home'), ('domestic', 'home'),
+    ('sport', 'sports'), ('fitness', 'sports'), ('exercise', 'sports'), ('athletic', 'sports'),
+    ('foods', 'food'), ('cuisine', 'food'), ('cooking', 'food'), ('recipe', 'food'),
+    ('tourism', 'travel'), ('vacation', 'travel'), ('trip', 'travel'), ('journey', 'travel'),
+    ('medical', 'health'), ('wellness', 'health'), ('healthcare', 'health'),
+    ('learning', 'education'), ('academic', 'education'), ('school', 'education'), ('university', 'education'),
+    ('fun', 'entertainment'), ('games', 'entertainment'), ('movies', 'entertainment'), ('music', 'entertainment');